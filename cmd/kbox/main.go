@@ -4,10 +4,11 @@ import (
 	"os"
 
 	"github.com/bobbyrathoree/kbox/internal/cli"
+	"github.com/bobbyrathoree/kbox/internal/output"
 )
 
 func main() {
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(output.ExitCode(err))
 	}
 }
@@ -0,0 +1,192 @@
+// Package airgap reads and writes offline deploy bundles: a tar.gz of an
+// app's rendered manifests, the images they run (as written by docker save)
+// and release metadata, for clusters that can't reach the registries or the
+// app's sources
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// Files of a bundle archive
+const (
+	MetadataFile  = "bundle.json"
+	ManifestsFile = "manifests.yaml"
+	ImagesFile    = "images.tar"
+)
+
+// FormatVersion is the bundle layout this kbox writes and reads
+const FormatVersion = 1
+
+// Metadata describes a bundle: what it deploys, where from, and the release
+// it records
+type Metadata struct {
+	FormatVersion int       `json:"formatVersion"`
+	App           string    `json:"app"`
+	Env           string    `json:"env,omitempty"`
+	Namespace     string    `json:"namespace"`
+	KboxVersion   string    `json:"kboxVersion"`
+	Created       time.Time `json:"created"`
+	// Change is the git commit and author the bundle was exported from
+	Change render.ChangeInfo `json:"change"`
+	Images []Image           `json:"images"`
+	// Config is the app's config after the environment overlay, saved to the
+	// release history on deploy so rollback works. Unset for multi-service.
+	Config *config.AppConfig `json:"config,omitempty"`
+}
+
+// Image is an image saved in the bundle
+type Image struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Bundle is an opened bundle archive. Its images stay in the archive until
+// ExtractImages.
+type Bundle struct {
+	Path      string
+	Metadata  Metadata
+	Manifests []byte
+}
+
+// Write writes a bundle to path: meta, manifests, and the docker save
+// archive at imagesTar ("" for a bundle without images). The manifests
+// include the app's secrets, so only the owner can read the file.
+func Write(path string, meta Metadata, manifests []byte, imagesTar string) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	meta.FormatVersion = FormatVersion
+	metaJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, MetadataFile, int64(len(metaJSON)), meta.Created, bytes.NewReader(metaJSON)); err != nil {
+		return err
+	}
+	if err := writeFile(tw, ManifestsFile, int64(len(manifests)), meta.Created, bytes.NewReader(manifests)); err != nil {
+		return err
+	}
+	if imagesTar != "" {
+		images, err := os.Open(imagesTar)
+		if err != nil {
+			return err
+		}
+		defer images.Close()
+		info, err := images.Stat()
+		if err != nil {
+			return err
+		}
+		if err := writeFile(tw, ImagesFile, info.Size(), meta.Created, images); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Open reads a bundle's metadata and manifests
+func Open(path string) (*Bundle, error) {
+	b := &Bundle{Path: path}
+	var foundMeta bool
+	err := b.walk(func(name string, r io.Reader) (bool, error) {
+		var err error
+		switch name {
+		case MetadataFile:
+			foundMeta = true
+			err = json.NewDecoder(r).Decode(&b.Metadata)
+		case ManifestsFile:
+			b.Manifests, err = io.ReadAll(r)
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !foundMeta || b.Manifests == nil {
+		return nil, fmt.Errorf("%s is not a kbox bundle (no %s or %s)", path, MetadataFile, ManifestsFile)
+	}
+	if b.Metadata.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%s was written by a newer kbox (bundle format %d)\n  → Upgrade kbox with 'kbox upgrade'", path, b.Metadata.FormatVersion)
+	}
+	return b, nil
+}
+
+// ExtractImages writes the bundle's docker save archive to dst. It returns
+// false if the bundle has no images.
+func (b *Bundle) ExtractImages(dst string) (bool, error) {
+	var found bool
+	err := b.walk(func(name string, r io.Reader) (bool, error) {
+		if name != ImagesFile {
+			return false, nil
+		}
+		found = true
+		f, err := os.Create(dst)
+		if err != nil {
+			return true, err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return true, err
+		}
+		return true, f.Close()
+	})
+	return found, err
+}
+
+// walk calls fn for each file of the archive until it returns done
+func (b *Bundle) walk(fn func(name string, r io.Reader) (done bool, err error)) error {
+	f, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a kbox bundle: %w", b.Path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", b.Path, err)
+		}
+		done, err := fn(hdr.Name, tr)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func writeFile(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: size, ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
@@ -0,0 +1,60 @@
+package airgap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteOpen(t *testing.T) {
+	dir := t.TempDir()
+	images := filepath.Join(dir, "images.tar")
+	os.WriteFile(images, []byte("layers"), 0644)
+
+	meta := Metadata{
+		App:       "myapp",
+		Env:       "prod",
+		Namespace: "shop",
+		Created:   time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC),
+		Images:    []Image{{Image: "myapp:v1", Digest: "sha256:abc"}},
+	}
+	path := filepath.Join(dir, "myapp.bundle.tar.gz")
+	if err := Write(path, meta, []byte("kind: Deployment\n"), images); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("bundle mode = %v, want 0600 as it holds secrets", info.Mode().Perm())
+	}
+
+	b, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if b.Metadata.App != "myapp" || b.Metadata.FormatVersion != FormatVersion || b.Metadata.Images[0].Digest != "sha256:abc" {
+		t.Errorf("unexpected metadata: %+v", b.Metadata)
+	}
+	if string(b.Manifests) != "kind: Deployment\n" {
+		t.Errorf("manifests = %q", b.Manifests)
+	}
+
+	extracted := filepath.Join(dir, "out.tar")
+	if found, err := b.ExtractImages(extracted); !found || err != nil {
+		t.Fatalf("ExtractImages() = %v, %v", found, err)
+	}
+	if data, _ := os.ReadFile(extracted); string(data) != "layers" {
+		t.Errorf("extracted images = %q", data)
+	}
+
+	// Without images
+	path = filepath.Join(dir, "noimages.tar.gz")
+	Write(path, meta, []byte("kind: Deployment\n"), "")
+	b, _ = Open(path)
+	if found, err := b.ExtractImages(extracted); found || err != nil {
+		t.Errorf("ExtractImages() of a bundle without images = %v, %v", found, err)
+	}
+
+	if _, err := Open(images); err == nil {
+		t.Error("expected an error opening a file that isn't a bundle")
+	}
+}
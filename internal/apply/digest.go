@@ -0,0 +1,39 @@
+package apply
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ImageDigests returns the digest the cluster pulled for each of images,
+// read from the namespace's pods: once pulled, a container's status carries
+// an imageID of <repo>@sha256:<digest>. Images no running pod reports are
+// left out, as are runtimes that only report a local image ID.
+func ImageDigests(ctx context.Context, client kubernetes.Interface, namespace string, images []string) map[string]string {
+	digests := make(map[string]string)
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return digests
+	}
+	for _, pod := range pods.Items {
+		statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+		for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			if !slices.Contains(images, c.Image) || digests[c.Image] != "" {
+				continue
+			}
+			for _, s := range statuses {
+				if s.Name != c.Name {
+					continue
+				}
+				if _, digest, ok := strings.Cut(s.ImageID, "@"); ok && strings.HasPrefix(digest, "sha256:") {
+					digests[c.Image] = digest
+				}
+			}
+		}
+	}
+	return digests
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -13,7 +15,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -22,6 +26,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
@@ -31,22 +36,46 @@ const (
 
 	// DefaultTimeout for operations
 	DefaultTimeout = 5 * time.Minute
+
+	// DefaultConcurrency is how many resources of a stage are applied at once
+	DefaultConcurrency = 5
 )
 
 // Engine handles applying Kubernetes resources
 type Engine struct {
-	client        *kubernetes.Clientset
+	client        kubernetes.Interface
 	dynamicClient dynamic.Interface
 	out           io.Writer
+	progress      *output.Progress
 	timeout       time.Duration
+	concurrency   int
+
+	// retries and retryBackoff control retrying transient API errors
+	retries      int
+	retryBackoff time.Duration
+
+	// owner is the bundle's anchor, set by Apply once it exists
+	owner *metav1.OwnerReference
+
+	// transactional makes Apply undo its changes when a resource fails;
+	// tx records them during an Apply
+	transactional bool
+	tx            *transaction
+
+	// rotate names the shared secrets to regenerate (see SetRotateSecrets)
+	rotate []string
 }
 
 // NewEngine creates a new apply engine
-func NewEngine(client *kubernetes.Clientset, out io.Writer) *Engine {
+func NewEngine(client kubernetes.Interface, out io.Writer) *Engine {
 	return &Engine{
-		client:  client,
-		out:     out,
-		timeout: DefaultTimeout,
+		client:       client,
+		out:          out,
+		progress:     output.NewProgress(out, false),
+		timeout:      DefaultTimeout,
+		concurrency:  DefaultConcurrency,
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
 	}
 }
 
@@ -55,6 +84,26 @@ func (e *Engine) SetTimeout(timeout time.Duration) {
 	e.timeout = timeout
 }
 
+// SetConcurrency sets how many resources of a stage are applied at once
+func (e *Engine) SetConcurrency(n int) {
+	if n > 0 {
+		e.concurrency = n
+	}
+}
+
+// SetProgress sets the renderer for apply, rollout and prune progress
+// (e.g. one shared with the build, or quiet in CI mode)
+func (e *Engine) SetProgress(p *output.Progress) {
+	e.progress = p
+}
+
+// SetTransactional makes Apply all-or-nothing: when a resource fails, the
+// resources already applied are restored to their state before the apply,
+// and those it created are deleted
+func (e *Engine) SetTransactional(transactional bool) {
+	e.transactional = transactional
+}
+
 // SetDynamicClient sets the dynamic client for CRD support
 func (e *Engine) SetDynamicClient(client dynamic.Interface) {
 	e.dynamicClient = client
@@ -65,292 +114,345 @@ type ApplyResult struct {
 	Created []string
 	Updated []string
 	Errors  []error
+
+	// Resources has per-resource outcome and timing, in apply order
+	Resources []ResourceApply
+
+	// RolledBack has the outcome of undoing each applied resource after a
+	// failed transactional apply (action restored or deleted), in the order
+	// they were undone
+	RolledBack []ResourceApply
 }
 
-// Apply applies a bundle to the cluster using Server-Side Apply
+// ResourceApply is the outcome of applying a single resource
+type ResourceApply struct {
+	Kind     string
+	Name     string
+	Action   string // created, updated, failed; restored, deleted when rolled back
+	Duration time.Duration
+	Err      error
+}
+
+// resourceOp is one resource to apply within a stage
+type resourceOp struct {
+	kind  string
+	name  string
+	apply func(ctx context.Context) (bool, error)
+}
+
+// applyStage is a group of independent resources applied concurrently.
+// Stages run in order; a failure in a critical stage stops the apply.
+type applyStage struct {
+	ops      []resourceOp
+	critical bool
+	optional bool // failures are warnings (e.g. ServiceMonitor without its CRD)
+}
+
+// Apply applies a bundle to the cluster using Server-Side Apply.
+// Resources within a stage are applied concurrently (see SetConcurrency).
+// In transactional mode (see SetTransactional), a failed apply is rolled back
+// before Apply returns.
 func (e *Engine) Apply(ctx context.Context, bundle *render.Bundle) (*ApplyResult, error) {
-	result := &ApplyResult{}
+	if !e.transactional {
+		return e.apply(ctx, bundle)
+	}
 
-	// Stage 0: ServiceAccount (CRITICAL - must exist before workloads that reference it)
-	if bundle.ServiceAccount != nil {
-		created, err := e.applyServiceAccount(ctx, bundle.ServiceAccount)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("serviceaccount %s: %w", bundle.ServiceAccount.Name, err))
-			return result, fmt.Errorf("critical resource failed: serviceaccount %s: %w", bundle.ServiceAccount.Name, err)
+	e.tx = &transaction{}
+	defer func() { e.tx = nil }()
+	result, err := e.apply(ctx, bundle)
+	failure := err
+	for _, r := range result.Resources {
+		// ServiceMonitors are optional: a cluster without their CRD isn't a failure
+		if failure == nil && r.Err != nil && r.Kind != "ServiceMonitor" {
+			failure = fmt.Errorf("%s %s: %w", strings.ToLower(r.Kind), r.Name, r.Err)
 		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("ServiceAccount/%s", bundle.ServiceAccount.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("ServiceAccount/%s", bundle.ServiceAccount.Name))
+	}
+	if failure == nil {
+		return result, nil
+	}
+
+	fmt.Fprintln(e.out, "\nRolling back the applied resources...")
+	result.RolledBack = e.rollback(ctx)
+	var unrestored []string
+	for _, r := range result.RolledBack {
+		if r.Err != nil {
+			unrestored = append(unrestored, fmt.Sprintf("%s/%s: %v", r.Kind, r.Name, r.Err))
 		}
-		fmt.Fprintf(e.out, "  ✓ ServiceAccount/%s\n", bundle.ServiceAccount.Name)
 	}
+	if len(unrestored) > 0 {
+		return result, output.WithCode(output.ErrPartialApply, fmt.Errorf("apply failed (%w) and %d resource(s) couldn't be rolled back:\n    - %s\n  → Check them with 'kubectl get', or redeploy",
+			failure, len(unrestored), strings.Join(unrestored, "\n    - ")))
+	}
+	return result, output.WithCode(output.ErrPartialApply, fmt.Errorf("apply failed and was rolled back, leaving the cluster as it was: %w", failure))
+}
 
-	// Stage 0.5: PersistentVolumeClaims (CRITICAL - storage before anything else)
-	for _, pvc := range bundle.PersistentVolumeClaims {
-		created, err := e.applyPVC(ctx, pvc)
+func (e *Engine) apply(ctx context.Context, bundle *render.Bundle) (*ApplyResult, error) {
+	result := &ApplyResult{}
+
+	// The anchor goes first so every other object can reference it as owner
+	e.owner = nil
+	if anchor := bundle.Anchor; anchor != nil {
+		owner, err := e.applyAnchor(ctx, anchor)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("pvc %s: %w", pvc.Name, err))
-			return result, fmt.Errorf("critical resource failed: pvc %s: %w", pvc.Name, err)
+			result.Errors = append(result.Errors, fmt.Errorf("configmap %s: %w", anchor.Name, err))
+			return result, output.WithCode(output.ErrPartialApply, fmt.Errorf("critical resource failed: %w", err))
 		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("PersistentVolumeClaim/%s", pvc.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("PersistentVolumeClaim/%s", pvc.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ PersistentVolumeClaim/%s\n", pvc.Name)
+		e.owner = owner
 	}
 
-	// Stage 1: ConfigMaps (CRITICAL - config must exist before workloads)
-	for _, cm := range bundle.ConfigMaps {
-		created, err := e.applyConfigMap(ctx, cm)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("configmap %s: %w", cm.Name, err))
-			return result, fmt.Errorf("critical resource failed: configmap %s: %w", cm.Name, err)
+	if err := e.keepSharedSecrets(ctx, bundle); err != nil {
+		result.Errors = append(result.Errors, err)
+		return result, output.WithCode(output.ErrPartialApply, fmt.Errorf("critical resource failed: %w", err))
+	}
+
+	for _, stage := range e.stages(bundle) {
+		outcomes := e.applyConcurrently(ctx, stage)
+
+		var stageErr error
+		for _, o := range outcomes {
+			result.Resources = append(result.Resources, o)
+			ref := fmt.Sprintf("%s/%s", o.Kind, o.Name)
+			if o.Err != nil {
+				err := fmt.Errorf("%s %s: %w", strings.ToLower(o.Kind), o.Name, o.Err)
+				result.Errors = append(result.Errors, err)
+				if stageErr == nil {
+					stageErr = err
+				}
+				continue
+			}
+			if o.Action == "created" {
+				result.Created = append(result.Created, ref)
+			} else {
+				result.Updated = append(result.Updated, ref)
+			}
 		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("ConfigMap/%s", cm.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("ConfigMap/%s", cm.Name))
+
+		if stage.critical && stageErr != nil {
+			return result, output.WithCode(output.ErrPartialApply, fmt.Errorf("critical resource failed: %w", stageErr))
 		}
-		fmt.Fprintf(e.out, "  ✓ ConfigMap/%s\n", cm.Name)
 	}
 
-	// Stage 1: Secrets (CRITICAL - secrets must exist before workloads)
-	for _, secret := range bundle.Secrets {
-		created, err := e.applySecret(ctx, secret)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("secret %s: %w", secret.Name, err))
-			return result, fmt.Errorf("critical resource failed: secret %s: %w", secret.Name, err)
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("Secret/%s", secret.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("Secret/%s", secret.Name))
+	return result, nil
+}
+
+// stages groups the bundle into ordered stages of independent resources
+func (e *Engine) stages(bundle *render.Bundle) []applyStage {
+	var stages []applyStage
+	add := func(critical bool, ops ...resourceOp) {
+		if len(ops) > 0 {
+			stages = append(stages, applyStage{ops: ops, critical: critical})
 		}
-		fmt.Fprintf(e.out, "  ✓ Secret/%s\n", secret.Name)
 	}
 
+	// Stage 0: ServiceAccount (CRITICAL - must exist before workloads that reference it)
+	if sa := bundle.ServiceAccount; sa != nil {
+		add(true, resourceOp{"ServiceAccount", sa.Name, func(ctx context.Context) (bool, error) { return e.applyServiceAccount(ctx, sa) }})
+	}
+
+	// Stage 0.25: RBAC for in-cluster automation (e.g. the sleep schedule)
+	var rbacOps []resourceOp
+	for _, sa := range bundle.ServiceAccounts {
+		rbacOps = append(rbacOps, resourceOp{"ServiceAccount", sa.Name, func(ctx context.Context) (bool, error) { return e.applyServiceAccount(ctx, sa) }})
+	}
+	for _, role := range bundle.Roles {
+		rbacOps = append(rbacOps, resourceOp{"Role", role.Name, func(ctx context.Context) (bool, error) { return e.applyRole(ctx, role) }})
+	}
+	for _, binding := range bundle.RoleBindings {
+		rbacOps = append(rbacOps, resourceOp{"RoleBinding", binding.Name, func(ctx context.Context) (bool, error) { return e.applyRoleBinding(ctx, binding) }})
+	}
+	add(false, rbacOps...)
+
+	// Stage 0.5: PersistentVolumeClaims (CRITICAL - storage before anything else)
+	var ops []resourceOp
+	for _, pvc := range bundle.PersistentVolumeClaims {
+		ops = append(ops, resourceOp{"PersistentVolumeClaim", pvc.Name, func(ctx context.Context) (bool, error) { return e.applyPVC(ctx, pvc) }})
+	}
+	add(true, ops...)
+
+	// Stage 1: ConfigMaps and Secrets (CRITICAL - config must exist before workloads)
+	ops = nil
+	for _, cm := range bundle.ConfigMaps {
+		ops = append(ops, resourceOp{"ConfigMap", cm.Name, func(ctx context.Context) (bool, error) { return e.applyConfigMap(ctx, cm) }})
+	}
+	for _, secret := range bundle.Secrets {
+		ops = append(ops, resourceOp{"Secret", secret.Name, func(ctx context.Context) (bool, error) { return e.applySecret(ctx, secret) }})
+	}
+	add(true, ops...)
+
 	// Stage 2: Services (CRITICAL - services must exist for proper networking)
+	ops = nil
 	for _, svc := range bundle.Services {
-		created, err := e.applyService(ctx, svc)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("service %s: %w", svc.Name, err))
-			return result, fmt.Errorf("critical resource failed: service %s: %w", svc.Name, err)
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("Service/%s", svc.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("Service/%s", svc.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ Service/%s\n", svc.Name)
+		ops = append(ops, resourceOp{"Service", svc.Name, func(ctx context.Context) (bool, error) { return e.applyService(ctx, svc) }})
 	}
+	add(true, ops...)
 
 	// Stage 2.5: StatefulSets (CRITICAL - databases/dependencies must be ready before app Deployment)
+	ops = nil
 	for _, ss := range bundle.StatefulSets {
-		created, err := e.applyStatefulSet(ctx, ss)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("statefulset %s: %w", ss.Name, err))
-			return result, fmt.Errorf("critical resource failed: statefulset %s: %w", ss.Name, err)
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("StatefulSet/%s", ss.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("StatefulSet/%s", ss.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ StatefulSet/%s\n", ss.Name)
+		ops = append(ops, resourceOp{"StatefulSet", ss.Name, func(ctx context.Context) (bool, error) { return e.applyStatefulSet(ctx, ss) }})
 	}
+	add(true, ops...)
 
-	// Stage 3: Deployment (CRITICAL - the main workload)
-	if bundle.Deployment != nil {
-		created, err := e.applyDeployment(ctx, bundle.Deployment)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("deployment %s: %w", bundle.Deployment.Name, err))
-			return result, fmt.Errorf("critical resource failed: deployment %s: %w", bundle.Deployment.Name, err)
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("Deployment/%s", bundle.Deployment.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("Deployment/%s", bundle.Deployment.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ Deployment/%s\n", bundle.Deployment.Name)
+	// Stage 3: Deployments (CRITICAL - the main workloads)
+	deployments := bundle.Deployments
+	if len(deployments) == 0 && bundle.Deployment != nil {
+		deployments = []*appsv1.Deployment{bundle.Deployment}
 	}
+	ops = nil
+	for _, dep := range deployments {
+		ops = append(ops, resourceOp{"Deployment", dep.Name, func(ctx context.Context) (bool, error) { return e.applyDeployment(ctx, dep) }})
+	}
+	add(true, ops...)
 
 	// Stage 4: Ingresses
+	ops = nil
 	for _, ing := range bundle.Ingresses {
-		created, err := e.applyIngress(ctx, ing)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("ingress %s: %w", ing.Name, err))
-			continue
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("Ingress/%s", ing.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("Ingress/%s", ing.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ Ingress/%s\n", ing.Name)
+		ops = append(ops, resourceOp{"Ingress", ing.Name, func(ctx context.Context) (bool, error) { return e.applyIngress(ctx, ing) }})
 	}
+	add(false, ops...)
 
 	// Stage 5: Jobs
+	ops = nil
 	for _, job := range bundle.Jobs {
-		created, err := e.applyJob(ctx, job)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("job %s: %w", job.Name, err))
-			continue
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("Job/%s", job.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("Job/%s", job.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ Job/%s\n", job.Name)
+		ops = append(ops, resourceOp{"Job", job.Name, func(ctx context.Context) (bool, error) { return e.applyJob(ctx, job) }})
 	}
+	add(false, ops...)
 
 	// Stage 6: CronJobs
+	ops = nil
 	for _, cronJob := range bundle.CronJobs {
-		created, err := e.applyCronJob(ctx, cronJob)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("cronjob %s: %w", cronJob.Name, err))
-			continue
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("CronJob/%s", cronJob.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("CronJob/%s", cronJob.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ CronJob/%s\n", cronJob.Name)
+		ops = append(ops, resourceOp{"CronJob", cronJob.Name, func(ctx context.Context) (bool, error) { return e.applyCronJob(ctx, cronJob) }})
 	}
+	add(false, ops...)
 
 	// Stage 7: HPA (after Deployment)
-	if bundle.HPA != nil {
-		created, err := e.applyHPA(ctx, bundle.HPA)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("hpa %s: %w", bundle.HPA.Name, err))
-		} else {
-			if created {
-				result.Created = append(result.Created, fmt.Sprintf("HorizontalPodAutoscaler/%s", bundle.HPA.Name))
-			} else {
-				result.Updated = append(result.Updated, fmt.Sprintf("HorizontalPodAutoscaler/%s", bundle.HPA.Name))
-			}
-			fmt.Fprintf(e.out, "  ✓ HorizontalPodAutoscaler/%s\n", bundle.HPA.Name)
-		}
+	if hpa := bundle.HPA; hpa != nil {
+		add(false, resourceOp{"HorizontalPodAutoscaler", hpa.Name, func(ctx context.Context) (bool, error) { return e.applyHPA(ctx, hpa) }})
 	}
 
 	// Stage 8: PDB (after Deployment)
-	if bundle.PDB != nil {
-		created, err := e.applyPDB(ctx, bundle.PDB)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("pdb %s: %w", bundle.PDB.Name, err))
-		} else {
-			if created {
-				result.Created = append(result.Created, fmt.Sprintf("PodDisruptionBudget/%s", bundle.PDB.Name))
-			} else {
-				result.Updated = append(result.Updated, fmt.Sprintf("PodDisruptionBudget/%s", bundle.PDB.Name))
-			}
-			fmt.Fprintf(e.out, "  ✓ PodDisruptionBudget/%s\n", bundle.PDB.Name)
-		}
+	if pdb := bundle.PDB; pdb != nil {
+		add(false, resourceOp{"PodDisruptionBudget", pdb.Name, func(ctx context.Context) (bool, error) { return e.applyPDB(ctx, pdb) }})
 	}
 
 	// Stage 9: NetworkPolicies
+	ops = nil
 	for _, np := range bundle.NetworkPolicies {
-		created, err := e.applyNetworkPolicy(ctx, np)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("networkpolicy %s: %w", np.Name, err))
-			continue
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("NetworkPolicy/%s", np.Name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("NetworkPolicy/%s", np.Name))
-		}
-		fmt.Fprintf(e.out, "  ✓ NetworkPolicy/%s\n", np.Name)
+		ops = append(ops, resourceOp{"NetworkPolicy", np.Name, func(ctx context.Context) (bool, error) { return e.applyNetworkPolicy(ctx, np) }})
 	}
+	add(false, ops...)
 
 	// Stage 10: ServiceMonitors (optional Prometheus observability)
+	ops = nil
 	for _, sm := range bundle.ServiceMonitors {
 		name, _, _ := unstructured.NestedString(sm.Object, "metadata", "name")
-		created, err := e.applyServiceMonitor(ctx, sm)
-		if err != nil {
-			// ServiceMonitor is non-critical - CRD may not be installed
-			result.Errors = append(result.Errors, fmt.Errorf("servicemonitor %s: %w", name, err))
-			fmt.Fprintf(e.out, "  ⚠ ServiceMonitor/%s (skipped: %v)\n", name, err)
-			continue
-		}
-		if created {
-			result.Created = append(result.Created, fmt.Sprintf("ServiceMonitor/%s", name))
-		} else {
-			result.Updated = append(result.Updated, fmt.Sprintf("ServiceMonitor/%s", name))
-		}
-		fmt.Fprintf(e.out, "  ✓ ServiceMonitor/%s\n", name)
+		ops = append(ops, resourceOp{"ServiceMonitor", name, func(ctx context.Context) (bool, error) { return e.applyServiceMonitor(ctx, sm) }})
+	}
+	if len(ops) > 0 {
+		// ServiceMonitor is non-critical - CRD may not be installed
+		stages = append(stages, applyStage{ops: ops, optional: true})
 	}
 
-	return result, nil
+	return stages
 }
 
-// WaitForRollout waits for a deployment to complete its rollout
-func (e *Engine) WaitForRollout(ctx context.Context, namespace, name string) error {
-	fmt.Fprintf(e.out, "  ⠋ Waiting for rollout...")
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	timeout := time.After(e.timeout)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-timeout:
-			return fmt.Errorf("timeout waiting for rollout\n  → Run 'kbox logs' to check for errors\n  → Run 'kbox status' to see pod state")
-		case <-ticker.C:
-			dep, err := e.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				continue
-			}
-
-			// Check for pod failures (crashloops, image pull errors)
-			pods, err := e.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-				LabelSelector: fmt.Sprintf("app=%s", name),
-			})
-			if err == nil {
-				for _, pod := range pods.Items {
-					for _, cs := range pod.Status.ContainerStatuses {
-						if cs.State.Waiting != nil {
-							reason := cs.State.Waiting.Reason
-							if reason == "CrashLoopBackOff" ||
-								reason == "ImagePullBackOff" ||
-								reason == "ErrImagePull" {
-								fmt.Fprintf(e.out, "\r")
-								return fmt.Errorf("pod %s: %s\n  → Run 'kbox logs' to diagnose\n  → Run 'kbox status' to see events",
-									pod.Name, reason)
-							}
-						}
-					}
-				}
+// applyConcurrently runs a stage's ops with at most e.concurrency in flight,
+// reporting each as it finishes, and returns their outcomes in the order of ops
+func (e *Engine) applyConcurrently(ctx context.Context, stage applyStage) []ResourceApply {
+	ops := stage.ops
+	outcomes := make([]ResourceApply, len(ops))
+	workers := e.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, op resourceOp) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			task := e.progress.Start(fmt.Sprintf("%s/%s", op.kind, op.name))
+			start := time.Now()
+			created, err := op.apply(ctx)
+			outcome := ResourceApply{Kind: op.kind, Name: op.name, Duration: time.Since(start), Err: err}
+			switch {
+			case err != nil && stage.optional:
+				outcome.Action = "failed"
+				task.Warn(fmt.Sprintf("skipped: %v", err))
+			case err != nil:
+				outcome.Action = "failed"
+				task.Fail(err)
+			case created:
+				outcome.Action = "created"
+				task.Done("")
+			default:
+				outcome.Action = "updated"
+				task.Done("")
 			}
+			outcomes[i] = outcome
+		}(i, op)
+	}
+	wg.Wait()
+	return outcomes
+}
 
-			// Check rollout status
-			if dep.Status.ObservedGeneration >= dep.Generation {
-				if dep.Status.UpdatedReplicas == *dep.Spec.Replicas &&
-					dep.Status.ReadyReplicas == *dep.Spec.Replicas &&
-					dep.Status.AvailableReplicas == *dep.Spec.Replicas {
-					fmt.Fprintf(e.out, "\r  ✓ Rollout complete (%d/%d pods ready)\n",
-						dep.Status.ReadyReplicas, *dep.Spec.Replicas)
-					return nil
-				}
-			}
+// applyAnchor applies the app's anchor ConfigMap and returns an owner reference to it.
+// The reference is nil when the API server didn't report a UID.
+func (e *Engine) applyAnchor(ctx context.Context, anchor *corev1.ConfigMap) (*metav1.OwnerReference, error) {
+	if _, err := e.applyObject(ctx, anchor, "configmaps", anchor.Namespace, anchor.Name); err != nil {
+		return nil, err
+	}
+	live, err := e.client.CoreV1().ConfigMaps(anchor.Namespace).Get(ctx, anchor.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if live.UID == "" {
+		return nil, nil
+	}
+	return &metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       live.Name,
+		UID:        live.UID,
+	}, nil
+}
 
-			// Update progress
-			fmt.Fprintf(e.out, "\r  ⠋ Waiting for rollout... (%d/%d pods ready)",
-				dep.Status.ReadyReplicas, *dep.Spec.Replicas)
+// withOwner returns a copy of obj owned by the anchor. PVCs are never owned,
+// so deleting the app doesn't take its data with it.
+func (e *Engine) withOwner(obj runtime.Object, resource string) runtime.Object {
+	if e.owner == nil || resource == "persistentvolumeclaims" {
+		return obj
+	}
+	obj = obj.DeepCopyObject()
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return obj
+	}
+	for _, ref := range accessor.GetOwnerReferences() {
+		if ref.UID == e.owner.UID {
+			return obj
 		}
 	}
+	accessor.SetOwnerReferences(append(accessor.GetOwnerReferences(), *e.owner))
+	return obj
 }
 
 func (e *Engine) applyServiceAccount(ctx context.Context, sa *corev1.ServiceAccount) (bool, error) {
 	return e.applyObject(ctx, sa, "serviceaccounts", sa.Namespace, sa.Name)
 }
 
+func (e *Engine) applyRole(ctx context.Context, role *rbacv1.Role) (bool, error) {
+	return e.applyObject(ctx, role, "roles", role.Namespace, role.Name)
+}
+
+func (e *Engine) applyRoleBinding(ctx context.Context, binding *rbacv1.RoleBinding) (bool, error) {
+	return e.applyObject(ctx, binding, "rolebindings", binding.Namespace, binding.Name)
+}
+
 func (e *Engine) applyPVC(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
 	return e.applyObject(ctx, pvc, "persistentvolumeclaims", pvc.Namespace, pvc.Name)
 }
@@ -368,10 +470,20 @@ func (e *Engine) applyService(ctx context.Context, svc *corev1.Service) (bool, e
 }
 
 func (e *Engine) applyDeployment(ctx context.Context, dep *appsv1.Deployment) (bool, error) {
+	if dep.Spec.Replicas == nil {
+		if err := e.handOverReplicas(ctx, "deployments", dep.Namespace, dep.Name); err != nil {
+			return false, fmt.Errorf("failed to hand replicas over to the autoscaler: %w", err)
+		}
+	}
 	return e.applyObject(ctx, dep, "deployments", dep.Namespace, dep.Name)
 }
 
 func (e *Engine) applyStatefulSet(ctx context.Context, ss *appsv1.StatefulSet) (bool, error) {
+	if ss.Spec.Replicas == nil {
+		if err := e.handOverReplicas(ctx, "statefulsets", ss.Namespace, ss.Name); err != nil {
+			return false, fmt.Errorf("failed to hand replicas over to the autoscaler: %w", err)
+		}
+	}
 	return e.applyObject(ctx, ss, "statefulsets", ss.Namespace, ss.Name)
 }
 
@@ -399,6 +511,13 @@ func (e *Engine) applyPDB(ctx context.Context, pdb *policyv1.PodDisruptionBudget
 	return e.applyObject(ctx, pdb, "poddisruptionbudgets", pdb.Namespace, pdb.Name)
 }
 
+// serviceMonitorGVR is prometheus-operator's ServiceMonitor
+var serviceMonitorGVR = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "servicemonitors",
+}
+
 // applyServiceMonitor applies a ServiceMonitor CRD using the dynamic client
 func (e *Engine) applyServiceMonitor(ctx context.Context, sm *unstructured.Unstructured) (bool, error) {
 	if e.dynamicClient == nil {
@@ -408,21 +527,21 @@ func (e *Engine) applyServiceMonitor(ctx context.Context, sm *unstructured.Unstr
 	namespace, _, _ := unstructured.NestedString(sm.Object, "metadata", "namespace")
 	name, _, _ := unstructured.NestedString(sm.Object, "metadata", "name")
 
-	// ServiceMonitor GVR for prometheus-operator
-	gvr := schema.GroupVersionResource{
-		Group:    "monitoring.coreos.com",
-		Version:  "v1",
-		Resource: "servicemonitors",
-	}
+	gvr := serviceMonitorGVR
 
 	// Check if ServiceMonitor CRD exists
-	_, err := e.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	live, err := e.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
 	exists := err == nil
 	if err != nil && !errors.IsNotFound(err) {
-		// CRD might not be installed
-		if errors.IsNotFound(err) {
-			return false, fmt.Errorf("ServiceMonitor CRD not installed (prometheus-operator required)")
+		// As in applyObject, a rollback must not take it for a new object
+		return false, fmt.Errorf("failed to read the live ServiceMonitor: %w", err)
+	}
+	if e.tx != nil {
+		var before runtime.Object
+		if exists {
+			before = live
 		}
+		e.tx.record("servicemonitors", namespace, name, before)
 	}
 
 	// Apply using SSA with Force=true
@@ -437,7 +556,10 @@ func (e *Engine) applyServiceMonitor(ctx context.Context, sm *unstructured.Unstr
 		return false, fmt.Errorf("failed to marshal ServiceMonitor: %w", err)
 	}
 
-	_, err = e.dynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+	err = e.withRetry(ctx, func() error {
+		_, err := e.dynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		return err
+	})
 	if err != nil {
 		return false, err
 	}
@@ -445,55 +567,72 @@ func (e *Engine) applyServiceMonitor(ctx context.Context, sm *unstructured.Unstr
 	return !exists, nil
 }
 
-func (e *Engine) applyObject(ctx context.Context, obj runtime.Object, resource, namespace, name string) (bool, error) {
-	// Convert object to JSON for SSA patch
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal object: %w", err)
-	}
-
-	// Check if object exists
-	var exists bool
+// getObject reads the live object applyObject is about to patch
+func (e *Engine) getObject(ctx context.Context, resource, namespace, name string) (runtime.Object, error) {
 	switch resource {
 	case "serviceaccounts":
-		_, err = e.client.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "persistentvolumeclaims":
-		_, err = e.client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "roles":
+		return e.client.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "rolebindings":
+		return e.client.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "configmaps":
-		_, err = e.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "secrets":
-		_, err = e.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "services":
-		_, err = e.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "deployments":
-		_, err = e.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "statefulsets":
-		_, err = e.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "ingresses":
-		_, err = e.client.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "networkpolicies":
-		_, err = e.client.NetworkingV1().NetworkPolicies(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.NetworkingV1().NetworkPolicies(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "jobs":
-		_, err = e.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "cronjobs":
-		_, err = e.client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "horizontalpodautoscalers":
-		_, err = e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
 	case "poddisruptionbudgets":
-		_, err = e.client.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
-		exists = err == nil
+		return e.client.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unknown resource type: %s", resource)
+	}
+}
+
+func (e *Engine) applyObject(ctx context.Context, obj runtime.Object, resource, namespace, name string) (bool, error) {
+	// Convert object to JSON for SSA patch
+	data, err := json.Marshal(e.withOwner(obj, resource))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal object: %w", err)
+	}
+
+	// Check if object exists, keeping it for a transaction to restore. Only
+	// NotFound means it doesn't: after any other error the object may well
+	// exist, and a rollback would delete it
+	var live runtime.Object
+	err = e.withRetry(ctx, func() error {
+		var err error
+		live, err = e.getObject(ctx, resource, namespace, name)
+		return err
+	})
+	exists := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		if errors.IsForbidden(err) {
+			return false, fmt.Errorf("permission denied: %w\n  → Check your RBAC permissions for the target namespace", err)
+		}
+		return false, fmt.Errorf("failed to read the live object: %w", err)
+	}
+	if e.tx != nil {
+		if !exists {
+			live = nil
+		}
+		e.tx.record(resource, namespace, name, live)
 	}
 
 	// Apply using SSA with Force=true to resolve field ownership conflicts
@@ -504,36 +643,45 @@ func (e *Engine) applyObject(ctx context.Context, obj runtime.Object, resource,
 		Force:        &forceTrue,
 	}
 
-	switch resource {
-	case "serviceaccounts":
-		_, err = e.client.CoreV1().ServiceAccounts(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "persistentvolumeclaims":
-		_, err = e.client.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "configmaps":
-		_, err = e.client.CoreV1().ConfigMaps(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "secrets":
-		_, err = e.client.CoreV1().Secrets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "services":
-		_, err = e.client.CoreV1().Services(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "deployments":
-		_, err = e.client.AppsV1().Deployments(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "statefulsets":
-		_, err = e.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "ingresses":
-		_, err = e.client.NetworkingV1().Ingresses(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "networkpolicies":
-		_, err = e.client.NetworkingV1().NetworkPolicies(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "jobs":
-		_, err = e.client.BatchV1().Jobs(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "cronjobs":
-		_, err = e.client.BatchV1().CronJobs(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "horizontalpodautoscalers":
-		_, err = e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	case "poddisruptionbudgets":
-		_, err = e.client.PolicyV1().PodDisruptionBudgets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
-	default:
-		return false, fmt.Errorf("unknown resource type: %s", resource)
-	}
+	// Transient errors (conflicts, throttling, webhook timeouts) are retried
+	err = e.withRetry(ctx, func() error {
+		var err error
+		switch resource {
+		case "serviceaccounts":
+			_, err = e.client.CoreV1().ServiceAccounts(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "persistentvolumeclaims":
+			_, err = e.client.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "roles":
+			_, err = e.client.RbacV1().Roles(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "rolebindings":
+			_, err = e.client.RbacV1().RoleBindings(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "configmaps":
+			_, err = e.client.CoreV1().ConfigMaps(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "secrets":
+			_, err = e.client.CoreV1().Secrets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "services":
+			_, err = e.client.CoreV1().Services(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "deployments":
+			_, err = e.client.AppsV1().Deployments(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "statefulsets":
+			_, err = e.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "ingresses":
+			_, err = e.client.NetworkingV1().Ingresses(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "networkpolicies":
+			_, err = e.client.NetworkingV1().NetworkPolicies(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "jobs":
+			_, err = e.client.BatchV1().Jobs(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "cronjobs":
+			_, err = e.client.BatchV1().CronJobs(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "horizontalpodautoscalers":
+			_, err = e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		case "poddisruptionbudgets":
+			_, err = e.client.PolicyV1().PodDisruptionBudgets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		default:
+			return fmt.Errorf("unknown resource type: %s", resource)
+		}
+		return err
+	})
 
 	if err != nil {
 		if errors.IsNotFound(err) {
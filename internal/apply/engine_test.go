@@ -3,12 +3,28 @@ package apply
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
@@ -283,3 +299,801 @@ func TestEngineApplyWithNilClient(t *testing.T) {
 		t.Errorf("expected 0 updated with empty bundle, got %d", len(result.Updated))
 	}
 }
+
+func TestApplyConcurrentlyBoundsWorkers(t *testing.T) {
+	engine := NewEngine(nil, &bytes.Buffer{})
+	engine.SetConcurrency(2)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var ops []resourceOp
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("cm-%d", i)
+		ops = append(ops, resourceOp{"ConfigMap", name, func(ctx context.Context) (bool, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			if name == "cm-3" {
+				return false, fmt.Errorf("boom")
+			}
+			return true, nil
+		}})
+	}
+
+	outcomes := engine.applyConcurrently(context.Background(), applyStage{ops: ops})
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent applies, got %d", maxInFlight)
+	}
+	for i, o := range outcomes {
+		if o.Name != fmt.Sprintf("cm-%d", i) {
+			t.Errorf("expected outcomes in op order, got %s at %d", o.Name, i)
+		}
+	}
+	if outcomes[3].Action != "failed" || outcomes[3].Err == nil || outcomes[0].Action != "created" {
+		t.Errorf("unexpected outcomes: %+v", outcomes)
+	}
+}
+
+func TestApplyMultiServiceBundle(t *testing.T) {
+	client := fake.NewClientset()
+	var buf bytes.Buffer
+	engine := NewEngine(client, &buf)
+
+	deployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+	}
+	bundle := &render.Bundle{
+		ConfigMaps: []*corev1.ConfigMap{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop-config", Namespace: "default"},
+		}},
+		Deployments: []*appsv1.Deployment{deployment("shop-api"), deployment("shop-web"), deployment("shop-worker")},
+	}
+	bundle.Deployment = bundle.Deployments[0]
+
+	result, err := engine.Apply(context.Background(), bundle)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(result.Created) != 4 {
+		t.Errorf("expected 4 created resources, got %v (errors: %v)", result.Created, result.Errors)
+	}
+	for _, name := range []string{"shop-api", "shop-web", "shop-worker"} {
+		if _, err := client.AppsV1().Deployments("default").Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+			t.Errorf("expected deployment %s to be applied: %v", name, err)
+		}
+	}
+
+	// Stage order is kept: the ConfigMap is reported before the Deployments
+	if len(result.Resources) != 4 || result.Resources[0].Kind != "ConfigMap" || result.Resources[3].Name != "shop-worker" {
+		t.Errorf("unexpected resource order: %+v", result.Resources)
+	}
+
+	// Reapplying updates in place
+	result, err = engine.Apply(context.Background(), bundle)
+	if err != nil || len(result.Updated) != 4 {
+		t.Errorf("expected 4 updated on reapply, got %v (%v)", result.Updated, err)
+	}
+}
+
+func TestApplySecurityAndAutoscalingObjects(t *testing.T) {
+	client := fake.NewClientset()
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default"}
+	}
+	bundle := &render.Bundle{
+		ServiceAccount: &corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta("shop"),
+		},
+		Deployments: []*appsv1.Deployment{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: meta("shop"),
+		}},
+		NetworkPolicies: []*networkingv1.NetworkPolicy{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+			ObjectMeta: meta("shop-default-deny"),
+		}},
+		HPA: &autoscalingv2.HorizontalPodAutoscaler{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+			ObjectMeta: meta("shop"),
+		},
+		PDB: &policyv1.PodDisruptionBudget{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "policy/v1", Kind: "PodDisruptionBudget"},
+			ObjectMeta: meta("shop"),
+		},
+	}
+	bundle.Deployment = bundle.Deployments[0]
+
+	result, err := engine.Apply(context.Background(), bundle)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.CoreV1().ServiceAccounts("default").Get(ctx, "shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the ServiceAccount to be applied: %v", err)
+	}
+	if _, err := client.AutoscalingV2().HorizontalPodAutoscalers("default").Get(ctx, "shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the HPA to be applied: %v", err)
+	}
+	if _, err := client.PolicyV1().PodDisruptionBudgets("default").Get(ctx, "shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the PDB to be applied: %v", err)
+	}
+	if _, err := client.NetworkingV1().NetworkPolicies("default").Get(ctx, "shop-default-deny", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the NetworkPolicy to be applied: %v", err)
+	}
+
+	// The ServiceAccount comes before the workload using it, the rest after
+	var kinds []string
+	for _, r := range result.Resources {
+		kinds = append(kinds, r.Kind)
+	}
+	want := []string{"ServiceAccount", "Deployment", "HorizontalPodAutoscaler", "PodDisruptionBudget", "NetworkPolicy"}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Errorf("applied %v, want %v", kinds, want)
+	}
+
+	// Reapplying goes through server-side apply and updates each in place
+	result, err = engine.Apply(ctx, bundle)
+	if err != nil || len(result.Updated) != 5 {
+		t.Errorf("expected 5 updated on reapply, got %v (%v)", result.Updated, err)
+	}
+}
+
+func TestApplyOwnsObjectsByAnchor(t *testing.T) {
+	// The fake clientset doesn't assign UIDs, so the anchor already exists
+	anchor := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: render.AnchorName("shop"), Namespace: "default", UID: "anchor-uid"},
+	}
+	client := fake.NewClientset(anchor.DeepCopy())
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	bundle := &render.Bundle{
+		Anchor: anchor,
+		PersistentVolumeClaims: []*corev1.PersistentVolumeClaim{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop-data", Namespace: "default"},
+		}},
+		Services: []*corev1.Service{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+		}},
+	}
+	if _, err := engine.Apply(context.Background(), bundle); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	svc, err := client.CoreV1().Services("default").Get(context.Background(), "shop", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected service to be applied: %v", err)
+	}
+	refs := svc.OwnerReferences
+	if len(refs) != 1 || refs[0].Kind != "ConfigMap" || refs[0].Name != "shop-kbox-app" || refs[0].UID != "anchor-uid" {
+		t.Errorf("expected service owned by the anchor, got %+v", refs)
+	}
+	if len(bundle.Services[0].OwnerReferences) != 0 {
+		t.Error("Apply should not modify the bundle's objects")
+	}
+
+	pvc, err := client.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), "shop-data", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected PVC to be applied: %v", err)
+	}
+	if len(pvc.OwnerReferences) != 0 {
+		t.Errorf("PVCs must not be owned (data would be deleted with the app), got %+v", pvc.OwnerReferences)
+	}
+}
+
+func TestTransactionalApplyRollsBack(t *testing.T) {
+	existing := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+	}
+	client := fake.NewClientset(existing.DeepCopy())
+	client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("spec.template.spec.containers[0].image: Required value")
+	})
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTransactional(true)
+
+	svc := existing.DeepCopy()
+	svc.Spec.Ports[0].Port = 8080
+	bundle := &render.Bundle{
+		ConfigMaps: []*corev1.ConfigMap{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop-config", Namespace: "default"},
+		}},
+		Services: []*corev1.Service{svc},
+		Deployment: &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+		},
+	}
+
+	result, err := engine.Apply(context.Background(), bundle)
+	if output.CodeOf(err) != output.ErrPartialApply {
+		t.Fatalf("expected a partial apply error, got %v", err)
+	}
+
+	// The new ConfigMap is deleted and the Service is back on its old port
+	if _, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), "shop-config", metav1.GetOptions{}); err == nil {
+		t.Error("expected the created ConfigMap to be deleted")
+	}
+	live, err := client.CoreV1().Services("default").Get(context.Background(), "shop", metav1.GetOptions{})
+	if err != nil || live.Spec.Ports[0].Port != 80 {
+		t.Errorf("expected the Service restored to port 80, got %+v (%v)", live, err)
+	}
+
+	actions := map[string]string{}
+	for _, r := range result.RolledBack {
+		if r.Err != nil {
+			t.Errorf("rolling back %s/%s failed: %v", r.Kind, r.Name, r.Err)
+		}
+		actions[r.Kind] = r.Action
+	}
+	want := map[string]string{"ConfigMap": "deleted", "Service": "restored", "Deployment": "deleted"}
+	if fmt.Sprint(actions) != fmt.Sprint(want) {
+		t.Errorf("expected rollback outcomes %v, got %v", want, actions)
+	}
+}
+
+func TestTransactionalApplyKeepsObjectsItCouldNotRead(t *testing.T) {
+	existing := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+	}
+	client := fake.NewClientset(existing.DeepCopy())
+	client.PrependReactor("get", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "shop", fmt.Errorf("RBAC"))
+	})
+	var servicePatches int
+	client.PrependReactor("patch", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		servicePatches++
+		return false, nil, nil
+	})
+	client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("spec.template.spec.containers[0].image: Required value")
+	})
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTransactional(true)
+
+	bundle := &render.Bundle{
+		Services: []*corev1.Service{existing.DeepCopy()},
+		Deployment: &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+		},
+	}
+	result, err := engine.Apply(context.Background(), bundle)
+	if err == nil {
+		t.Fatal("expected the apply to fail")
+	}
+
+	// The Service wasn't patched, and the rollback left it alone
+	if servicePatches != 0 {
+		t.Errorf("expected no patch after a failed read, got %d", servicePatches)
+	}
+	if _, err := client.Tracker().Get(corev1.SchemeGroupVersion.WithResource("services"), "default", "shop"); err != nil {
+		t.Errorf("expected the existing Service kept, got %v", err)
+	}
+	for _, r := range result.RolledBack {
+		if r.Kind == "Service" {
+			t.Errorf("expected no rollback of the unread Service, got %s", r.Action)
+		}
+	}
+}
+
+func TestApplyRetriesTransientErrors(t *testing.T) {
+	client := fake.NewClientset()
+	var patches int
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches++
+		if patches <= 2 {
+			return true, nil, apierrors.NewTooManyRequests("slow down", 0)
+		}
+		return false, nil, nil
+	})
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetRetry(3, time.Millisecond)
+
+	bundle := &render.Bundle{ConfigMaps: []*corev1.ConfigMap{{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-config", Namespace: "default"},
+	}}}
+	if _, err := engine.Apply(context.Background(), bundle); err != nil {
+		t.Fatalf("expected throttled apply to succeed on retry: %v", err)
+	}
+	if patches != 3 {
+		t.Errorf("expected 3 patch attempts, got %d", patches)
+	}
+
+	// Out of retries, the error is returned
+	patches = 0
+	engine.SetRetry(1, time.Millisecond)
+	result, _ := engine.Apply(context.Background(), bundle)
+	if len(result.Errors) != 1 || patches != 2 {
+		t.Errorf("expected one error after 2 attempts, got %v after %d", result.Errors, patches)
+	}
+
+	// Errors that won't go away aren't retried
+	patches = 0
+	engine.SetRetry(3, time.Millisecond)
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches++
+		return true, nil, apierrors.NewBadRequest("invalid")
+	})
+	engine.Apply(context.Background(), bundle)
+	if patches != 1 {
+		t.Errorf("expected a bad request not to be retried, got %d attempts", patches)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if d := retryDelay(fmt.Errorf("conflict"), time.Second, 2); d < 4*time.Second || d > 5*time.Second {
+		t.Errorf("expected about 4s on the third attempt, got %v", d)
+	}
+	if d := retryDelay(fmt.Errorf("conflict"), time.Second, 20); d != maxRetryDelay {
+		t.Errorf("expected the delay capped at %v, got %v", maxRetryDelay, d)
+	}
+	if d := retryDelay(apierrors.NewTooManyRequests("slow down", 10), 100*time.Millisecond, 0); d != 10*time.Second {
+		t.Errorf("expected the server's Retry-After of 10s, got %v", d)
+	}
+}
+
+func TestPruneKeepsRetainedConfigVersions(t *testing.T) {
+	owned := map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "shop"}
+	configMap := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: owned}}
+	}
+	client := fake.NewClientset(
+		configMap("shop-config-current"),
+		configMap("shop-config-previous"),
+		configMap("shop-config-expired"),
+	)
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	bundle := &render.Bundle{ConfigMaps: []*corev1.ConfigMap{configMap("shop-config-current")}}
+	result, err := engine.Prune(context.Background(), "default", "shop", bundle, PruneOptions{
+		Keep: []string{"ConfigMap/shop-config-previous"},
+	})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "ConfigMap/shop-config-expired" {
+		t.Errorf("expected only the expired version pruned, got %v", result.Deleted)
+	}
+	for _, name := range []string{"shop-config-current", "shop-config-previous"} {
+		if _, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+			t.Errorf("expected %s to survive the prune: %v", name, err)
+		}
+	}
+}
+
+func TestPruneKeepsReleaseHistory(t *testing.T) {
+	owned := map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "shop"}
+	client := fake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: render.ReleasesName("shop"), Namespace: "default", Labels: owned},
+	})
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	result, err := engine.Prune(context.Background(), "default", "shop", &render.Bundle{}, PruneOptions{})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(result.Deleted) != 0 {
+		t.Errorf("expected the release history kept, got %v deleted", result.Deleted)
+	}
+}
+
+func TestPruneRemovesDroppedRBAC(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "shop"}}
+	}
+	client := fake.NewClientset(
+		&corev1.ServiceAccount{ObjectMeta: meta("shop")},
+		&corev1.ServiceAccount{ObjectMeta: meta("shop-sleep")},
+		&rbacv1.Role{ObjectMeta: meta("shop-sleep")},
+		&rbacv1.RoleBinding{ObjectMeta: meta("shop-sleep")},
+	)
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	// The sleep schedule was removed: only the app's ServiceAccount is left
+	bundle := &render.Bundle{ServiceAccount: &corev1.ServiceAccount{ObjectMeta: meta("shop")}}
+	result, err := engine.Prune(context.Background(), "default", "shop", bundle, PruneOptions{})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	want := []string{"RoleBinding/shop-sleep", "Role/shop-sleep", "ServiceAccount/shop-sleep"}
+	if !slices.Equal(result.Deleted, want) {
+		t.Errorf("Deleted = %v, want %v", result.Deleted, want)
+	}
+	if _, err := client.CoreV1().ServiceAccounts("default").Get(context.Background(), "shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the app's ServiceAccount to survive the prune: %v", err)
+	}
+}
+
+func rolloutDeployment(replicas, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			UpdatedReplicas:    ready,
+			ReadyReplicas:      ready,
+			AvailableReplicas:  ready,
+		},
+	}
+}
+
+func TestWaitForRolloutAlreadyComplete(t *testing.T) {
+	client := fake.NewClientset(rolloutDeployment(2, 2))
+	var buf bytes.Buffer
+	engine := NewEngine(client, &buf)
+	engine.SetTimeout(5 * time.Second)
+
+	if err := engine.WaitForRollout(context.Background(), "default", "web"); err != nil {
+		t.Fatalf("WaitForRollout failed: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Rollout complete (2/2 pods ready)")) {
+		t.Errorf("expected completion message, got %q", buf.String())
+	}
+}
+
+func TestWaitForRolloutTimesOutWhenWatchNeverSyncs(t *testing.T) {
+	client := fake.NewClientset()
+	client.PrependReactor("list", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTimeout(300 * time.Millisecond)
+
+	start := time.Now()
+	err := engine.WaitForRollout(context.Background(), "default", "web")
+	if output.CodeOf(err) != output.ErrRolloutTimeout {
+		t.Fatalf("expected a rollout timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("WaitForRollout took %s, want about the 300ms timeout", elapsed)
+	}
+}
+
+func TestWaitForStatefulSetRollout(t *testing.T) {
+	replicas := int32(2)
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "queue", Namespace: "default", Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			ReadyReplicas:      2,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "queue-1",
+			UpdateRevision:     "queue-2",
+		},
+	}
+	client := fake.NewClientset(ss)
+	var buf bytes.Buffer
+	engine := NewEngine(client, &buf)
+	engine.SetTimeout(5 * time.Second)
+
+	// queue-0 is still on the old revision; finish the ordered rollout
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		done := ss.DeepCopy()
+		done.Status.UpdatedReplicas = 2
+		done.Status.CurrentRevision = "queue-2"
+		_, _ = client.AppsV1().StatefulSets("default").UpdateStatus(context.Background(), done, metav1.UpdateOptions{})
+	}()
+
+	if err := engine.WaitForRollout(context.Background(), "default", "queue"); err != nil {
+		t.Fatalf("WaitForRollout failed: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Rollout complete (2/2 pods ready, 2 updated)")) {
+		t.Errorf("expected completion message, got %q", buf.String())
+	}
+}
+
+func TestWaitForRolloutWatchesProgress(t *testing.T) {
+	client := fake.NewClientset(rolloutDeployment(1, 0))
+	var buf bytes.Buffer
+	engine := NewEngine(client, &buf)
+	engine.SetTimeout(5 * time.Second)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		dep := rolloutDeployment(1, 1)
+		client.AppsV1().Deployments("default").UpdateStatus(context.Background(), dep, metav1.UpdateOptions{})
+	}()
+
+	start := time.Now()
+	if err := engine.WaitForRollout(context.Background(), "default", "web"); err != nil {
+		t.Fatalf("WaitForRollout failed: %v", err)
+	}
+	// The update is picked up from the watch, not a 2s poll
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("rollout noticed after %v", elapsed)
+	}
+}
+
+func TestWaitForRolloutCrashLoop(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "web",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		},
+	}
+	client := fake.NewClientset(rolloutDeployment(1, 0), pod)
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTimeout(5 * time.Second)
+
+	err := engine.WaitForRollout(context.Background(), "default", "web")
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("pod web-abc: CrashLoopBackOff")) {
+		t.Errorf("expected crashloop error, got %v", err)
+	}
+}
+
+func TestWaitForRolloutMeshSidecar(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "web", Ready: true},
+				{Name: "istio-proxy", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	if got := sidecarProgress([]*corev1.Pod{pod}); got != ", 1 waiting for istio-proxy" {
+		t.Errorf("unexpected sidecar progress: %q", got)
+	}
+
+	// A native sidecar that can't start fails the rollout with a mesh hint
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "linkerd-proxy",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	client := fake.NewClientset(rolloutDeployment(1, 0), pod)
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTimeout(5 * time.Second)
+
+	err := engine.WaitForRollout(context.Background(), "default", "web")
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("mesh sidecar linkerd-proxy: CrashLoopBackOff")) || !bytes.Contains([]byte(err.Error()), []byte("kubectl -n linkerd")) {
+		t.Errorf("expected sidecar crashloop error, got %v", err)
+	}
+}
+
+func TestWaitForRolloutTimeout(t *testing.T) {
+	client := fake.NewClientset(rolloutDeployment(1, 0))
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetTimeout(300 * time.Millisecond)
+
+	err := engine.WaitForRollout(context.Background(), "default", "web")
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("timeout waiting for rollout")) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if output.CodeOf(err) != output.ErrRolloutTimeout {
+		t.Errorf("expected rollout_timeout code, got %q", output.CodeOf(err))
+	}
+}
+
+func TestRolloutProgressMessages(t *testing.T) {
+	one, two := int32(1), int32(2)
+	oldRS := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-123"}, Spec: appsv1.ReplicaSetSpec{Replicas: &one}}
+	newRS := oldRS.DeepCopy()
+	newRS.Spec.Replicas = &two
+	if got := replicaSetProgress(oldRS, newRS); got != "Scaled up replica set web-123 to 2" {
+		t.Errorf("unexpected replica set progress: %q", got)
+	}
+
+	oldPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-abc"}, Spec: corev1.PodSpec{NodeName: "node-1"}}
+	newPod := oldPod.DeepCopy()
+	newPod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}}
+	if got := podProgress(oldPod, newPod); got != "Pod web-abc scheduled on node-1" {
+		t.Errorf("unexpected pod progress: %q", got)
+	}
+
+	w := &rolloutWatch{name: "web", start: time.Now().Add(-time.Minute)}
+	ev := &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-abc"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Unhealthy",
+		Message:        "Readiness probe failed: connection refused\nmore",
+		LastTimestamp:  metav1.Now(),
+	}
+	if got := w.warningProgress(ev); got != "Pod web-abc: Unhealthy: Readiness probe failed: connection refused" {
+		t.Errorf("unexpected warning progress: %q", got)
+	}
+	ev.InvolvedObject.Name = "other-abc"
+	if got := w.warningProgress(ev); got != "" {
+		t.Errorf("expected warnings for other apps to be ignored, got %q", got)
+	}
+}
+
+func TestReportLoadBalancer(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP},
+				{Name: "udp-7777", Port: 7777, Protocol: corev1.ProtocolUDP},
+			},
+		},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{Hostname: "game-123.elb.amazonaws.com"}},
+		}},
+	}
+	if got := LoadBalancerAddress(svc); got != "game-123.elb.amazonaws.com" {
+		t.Errorf("LoadBalancerAddress() = %q", got)
+	}
+
+	var buf bytes.Buffer
+	engine := NewEngine(fake.NewClientset(svc), &buf)
+	engine.reportLoadBalancer(context.Background(), "default", "game")
+	if !bytes.Contains(buf.Bytes(), []byte("Load balancer: game-123.elb.amazonaws.com (80/TCP, 7777/UDP)")) {
+		t.Errorf("expected the address and ports, got %q", buf.String())
+	}
+
+	// No address within the timeout is only a warning
+	svc.Status = corev1.ServiceStatus{}
+	buf.Reset()
+	engine = NewEngine(fake.NewClientset(svc), &buf)
+	engine.SetTimeout(50 * time.Millisecond)
+	engine.reportLoadBalancer(context.Background(), "default", "game")
+	if !bytes.Contains(buf.Bytes(), []byte("no external address")) {
+		t.Errorf("expected a pending warning, got %q", buf.String())
+	}
+}
+
+func TestImageDigests(t *testing.T) {
+	digest := "sha256:4a5b6c"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "migrate", Image: "web-migrate:v1"}},
+			Containers:     []corev1.Container{{Name: "web", Image: "web:v1"}},
+		},
+		Status: corev1.PodStatus{
+			// kind reports a local image ID, not a registry digest
+			InitContainerStatuses: []corev1.ContainerStatus{{Name: "migrate", ImageID: "sha256:local"}},
+			ContainerStatuses:     []corev1.ContainerStatus{{Name: "web", ImageID: "docker.io/library/web@" + digest}},
+		},
+	}
+
+	got := ImageDigests(context.Background(), fake.NewClientset(pod), "default", []string{"web:v1", "web-migrate:v1", "redis:7"})
+	if len(got) != 1 || got["web:v1"] != digest {
+		t.Errorf("ImageDigests() = %v, want only web:v1 -> %s", got, digest)
+	}
+}
+
+func TestApplyKeepsSharedSecrets(t *testing.T) {
+	generatedAt := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	live := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: map[string]string{render.AnnotationRotatedAt: generatedAt}},
+			Data:       map[string][]byte{"TOKEN": []byte("live-" + name)},
+		}
+	}
+	shared := func(name, rotateEvery string) *corev1.Secret {
+		s := &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop-" + name, Namespace: "default", Labels: map[string]string{render.LabelSharedSecret: name}},
+			StringData: map[string]string{"TOKEN": "generated", "NEW_KEY": "generated"},
+		}
+		if rotateEvery != "" {
+			s.Annotations = map[string]string{render.AnnotationRotateEvery: rotateEvery}
+		}
+		return s
+	}
+	usesSecret := func(name string, secrets ...string) *appsv1.Deployment {
+		var env []corev1.EnvVar
+		for _, s := range secrets {
+			env = append(env, corev1.EnvVar{Name: strings.ToUpper(strings.ReplaceAll(s, "-", "_")), ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: s}, Key: "TOKEN"},
+			}})
+		}
+		d := &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		d.Spec.Template.Spec.Containers = []corev1.Container{{Name: name, Env: env}}
+		return d
+	}
+
+	client := fake.NewClientset(live("shop-kept"), live("shop-due"), live("shop-forced"))
+	engine := NewEngine(client, &bytes.Buffer{})
+	engine.SetRotateSecrets([]string{"forced"})
+	bundle := &render.Bundle{
+		Secrets: []*corev1.Secret{shared("kept", "720h"), shared("due", "24h"), shared("forced", ""), shared("new", "")},
+		Deployments: []*appsv1.Deployment{
+			usesSecret("shop-api", "shop-kept", "shop-new"),
+			usesSecret("shop-worker"),
+		},
+	}
+	if _, err := engine.Apply(context.Background(), bundle); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	byName := map[string]*corev1.Secret{}
+	for _, s := range bundle.Secrets {
+		byName[s.Labels[render.LabelSharedSecret]] = s
+	}
+	kept := byName["kept"]
+	if kept.StringData["TOKEN"] != "live-shop-kept" || kept.StringData["NEW_KEY"] != "generated" {
+		t.Errorf("expected the live value kept and the new key generated, got %v", kept.StringData)
+	}
+	if kept.Annotations[render.AnnotationRotatedAt] != generatedAt {
+		t.Errorf("expected the live rotated-at kept, got %q", kept.Annotations[render.AnnotationRotatedAt])
+	}
+	for _, name := range []string{"due", "forced", "new"} {
+		s := byName[name]
+		if s.StringData["TOKEN"] != "generated" {
+			t.Errorf("expected %s to be regenerated, got %v", name, s.StringData)
+		}
+		if at := s.Annotations[render.AnnotationRotatedAt]; at == "" || at == generatedAt {
+			t.Errorf("expected %s to get a new rotated-at, got %q", name, at)
+		}
+	}
+
+	api := bundle.Deployments[0].Spec.Template.Annotations[render.AnnotationSharedSecrets]
+	want := "shop-kept=" + generatedAt + ",shop-new=" + byName["new"].Annotations[render.AnnotationRotatedAt]
+	if api != want {
+		t.Errorf("expected the api's pod template stamped %q, got %q", want, api)
+	}
+	if _, stamped := bundle.Deployments[1].Spec.Template.Annotations[render.AnnotationSharedSecrets]; stamped {
+		t.Error("expected the worker, which uses no shared secret, left alone")
+	}
+}
+
+func TestWaitForRollout_FakeCluster(t *testing.T) {
+	cluster := k8s.NewFakeCluster()
+	defer cluster.Close()
+	var buf bytes.Buffer
+	engine := NewEngine(cluster.Clientset(), &buf)
+	engine.SetTimeout(30 * time.Second)
+
+	replicas := int32(2)
+	labels := map[string]string{"app": "myapp"}
+	bundle := &render.Bundle{
+		Deployment: &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "default", Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "myapp", Image: "myapp:v1"}}},
+				},
+			},
+		},
+	}
+	bundle.Deployments = []*appsv1.Deployment{bundle.Deployment}
+
+	ctx := context.Background()
+	if _, err := engine.Apply(ctx, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.WaitForRollout(ctx, "default", "myapp"); err != nil {
+		t.Fatalf("expected the rollout to complete, got %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Rollout complete (2/2 pods ready)") {
+		t.Errorf("expected the rollout reported complete, got:\n%s", buf.String())
+	}
+}
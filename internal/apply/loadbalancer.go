@@ -0,0 +1,77 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// loadBalancerWait bounds how long WaitForRollout waits for a LoadBalancer
+// Service's address: clusters without a load balancer provider (kind,
+// minikube without a tunnel) never assign one
+const loadBalancerWait = 2 * time.Minute
+
+// LoadBalancerAddress returns a LoadBalancer Service's external IP or
+// hostname, or "" until the cloud provider assigns one
+func LoadBalancerAddress(svc *corev1.Service) string {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return ""
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
+// reportLoadBalancer waits for the workload's Service, if it is a
+// LoadBalancer, to get an external address and prints it with its ports.
+// A missing address is only a warning: the rollout itself is done.
+func (e *Engine) reportLoadBalancer(ctx context.Context, namespace, name string) {
+	svc, err := e.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return
+	}
+
+	wait := min(loadBalancerWait, e.timeout)
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	task := e.progress.Start("Waiting for load balancer")
+	for {
+		if addr := LoadBalancerAddress(svc); addr != "" {
+			task.Done(fmt.Sprintf("Load balancer: %s (%s)", addr, servicePorts(svc)))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			task.Warn(fmt.Sprintf("no external address after %s; check 'kubectl -n %s describe service %s'", wait, namespace, name))
+			return
+		case <-time.After(2 * time.Second):
+		}
+		latest, err := e.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			task.Warn(err.Error())
+			return
+		} else if err == nil {
+			svc = latest
+		}
+	}
+}
+
+// servicePorts lists a Service's ports, e.g. "80/TCP, 7777/UDP"
+func servicePorts(svc *corev1.Service) string {
+	ports := make([]string, 0, len(svc.Spec.Ports))
+	for _, p := range svc.Spec.Ports {
+		ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+	}
+	return strings.Join(ports, ", ")
+}
@@ -12,6 +12,9 @@ import (
 // PruneOptions configures pruning behavior
 type PruneOptions struct {
 	DryRun bool
+	// Keep lists extra objects to leave in place, as "Kind/name", such as
+	// versioned ConfigMaps and Secrets that older releases still use
+	Keep []string
 }
 
 // PruneResult contains the result of a prune operation
@@ -20,7 +23,7 @@ type PruneResult struct {
 	Errors  []error
 }
 
-// Prune removes resources with label app=<appName> that aren't in bundle.
+// Prune removes resources kbox owns for appName (see render.OwnedSelectors) that aren't in bundle.
 // This prevents orphaned resources when config changes remove resources.
 func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *render.Bundle, opts PruneOptions) (*PruneResult, error) {
 	result := &PruneResult{}
@@ -66,11 +69,38 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if bundle.ServiceAccount != nil {
 		bundleResources[fmt.Sprintf("ServiceAccount/%s", bundle.ServiceAccount.Name)] = true
 	}
+	for _, sa := range bundle.ServiceAccounts {
+		bundleResources[fmt.Sprintf("ServiceAccount/%s", sa.Name)] = true
+	}
+	for _, role := range bundle.Roles {
+		bundleResources[fmt.Sprintf("Role/%s", role.Name)] = true
+	}
+	for _, binding := range bundle.RoleBindings {
+		bundleResources[fmt.Sprintf("RoleBinding/%s", binding.Name)] = true
+	}
 	for _, pvc := range bundle.PersistentVolumeClaims {
 		bundleResources[fmt.Sprintf("PersistentVolumeClaim/%s", pvc.Name)] = true
 	}
+	// Release history and the anchor aren't among the bundle's objects but must survive a prune
+	bundleResources[fmt.Sprintf("ConfigMap/%s", render.ReleasesName(appName))] = true
+	bundleResources[fmt.Sprintf("ConfigMap/%s", render.AnchorName(appName))] = true
+	for _, key := range opts.Keep {
+		bundleResources[key] = true
+	}
+
+	// Only objects kbox owns are candidates, so resources that merely share
+	// the app=<name> convention are never deleted
+	seen := make(map[string]bool)
+	for _, selector := range render.OwnedSelectors(appName) {
+		e.pruneSelected(ctx, namespace, selector, bundleResources, seen, opts, result)
+	}
+
+	return result, nil
+}
 
-	labelSelector := fmt.Sprintf("app=%s", appName)
+// pruneSelected deletes objects matching labelSelector that aren't in the bundle.
+// seen tracks objects already handled through another selector.
+func (e *Engine) pruneSelected(ctx context.Context, namespace, labelSelector string, bundleResources, seen map[string]bool, opts PruneOptions, result *PruneResult) {
 	deletePolicy := metav1.DeletePropagationForeground
 
 	// Prune ConfigMaps
@@ -80,17 +110,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, cm := range cms.Items {
 			key := fmt.Sprintf("ConfigMap/%s", cm.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.CoreV1().ConfigMaps(namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -102,17 +135,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, secret := range secrets.Items {
 			key := fmt.Sprintf("Secret/%s", secret.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -124,17 +160,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, svc := range svcs.Items {
 			key := fmt.Sprintf("Service/%s", svc.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.CoreV1().Services(namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -146,17 +185,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, dep := range deps.Items {
 			key := fmt.Sprintf("Deployment/%s", dep.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.AppsV1().Deployments(namespace).Delete(ctx, dep.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -168,17 +210,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, ss := range ssets.Items {
 			key := fmt.Sprintf("StatefulSet/%s", ss.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.AppsV1().StatefulSets(namespace).Delete(ctx, ss.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -190,17 +235,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, ing := range ings.Items {
 			key := fmt.Sprintf("Ingress/%s", ing.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.NetworkingV1().Ingresses(namespace).Delete(ctx, ing.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -212,17 +260,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, np := range nps.Items {
 			key := fmt.Sprintf("NetworkPolicy/%s", np.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, np.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -234,17 +285,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, hpa := range hpas.Items {
 			key := fmt.Sprintf("HorizontalPodAutoscaler/%s", hpa.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, hpa.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -256,17 +310,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, pdb := range pdbs.Items {
 			key := fmt.Sprintf("PodDisruptionBudget/%s", pdb.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, pdb.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -278,17 +335,20 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, job := range jobs.Items {
 			key := fmt.Sprintf("Job/%s", job.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.BatchV1().Jobs(namespace).Delete(ctx, job.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
@@ -300,20 +360,96 @@ func (e *Engine) Prune(ctx context.Context, namespace, appName string, bundle *r
 	if err == nil {
 		for _, cronJob := range cronJobs.Items {
 			key := fmt.Sprintf("CronJob/%s", cronJob.Name)
-			if !bundleResources[key] {
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
 				if !opts.DryRun {
 					if err := e.client.BatchV1().CronJobs(namespace).Delete(ctx, cronJob.Name, metav1.DeleteOptions{
 						PropagationPolicy: &deletePolicy,
 					}); err != nil {
+						task.Fail(err)
 						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
 						continue
 					}
 				}
 				result.Deleted = append(result.Deleted, key)
-				fmt.Fprintf(e.out, "  ✓ Pruned %s\n", key)
+				task.Done("Pruned " + key)
 			}
 		}
 	}
 
-	return result, nil
+	// Prune RoleBindings (e.g. the sleep schedule's) before what they bind
+	bindings, err := e.client.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err == nil {
+		for _, binding := range bindings.Items {
+			key := fmt.Sprintf("RoleBinding/%s", binding.Name)
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
+				if !opts.DryRun {
+					if err := e.client.RbacV1().RoleBindings(namespace).Delete(ctx, binding.Name, metav1.DeleteOptions{
+						PropagationPolicy: &deletePolicy,
+					}); err != nil {
+						task.Fail(err)
+						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
+						continue
+					}
+				}
+				result.Deleted = append(result.Deleted, key)
+				task.Done("Pruned " + key)
+			}
+		}
+	}
+
+	// Prune Roles
+	roles, err := e.client.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err == nil {
+		for _, role := range roles.Items {
+			key := fmt.Sprintf("Role/%s", role.Name)
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
+				if !opts.DryRun {
+					if err := e.client.RbacV1().Roles(namespace).Delete(ctx, role.Name, metav1.DeleteOptions{
+						PropagationPolicy: &deletePolicy,
+					}); err != nil {
+						task.Fail(err)
+						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
+						continue
+					}
+				}
+				result.Deleted = append(result.Deleted, key)
+				task.Done("Pruned " + key)
+			}
+		}
+	}
+
+	// Prune ServiceAccounts
+	serviceAccounts, err := e.client.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err == nil {
+		for _, sa := range serviceAccounts.Items {
+			key := fmt.Sprintf("ServiceAccount/%s", sa.Name)
+			if !bundleResources[key] && !seen[key] {
+				seen[key] = true
+				task := e.progress.Start("Pruning " + key)
+				if !opts.DryRun {
+					if err := e.client.CoreV1().ServiceAccounts(namespace).Delete(ctx, sa.Name, metav1.DeleteOptions{
+						PropagationPolicy: &deletePolicy,
+					}); err != nil {
+						task.Fail(err)
+						result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", key, err))
+						continue
+					}
+				}
+				result.Deleted = append(result.Deleted, key)
+				task.Done("Pruned " + key)
+			}
+		}
+	}
 }
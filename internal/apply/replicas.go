@@ -0,0 +1,91 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReplicasHandoverManager is the field manager that keeps a workload's
+// replica count once kbox stops applying it because an HPA took over
+const ReplicasHandoverManager = "kbox-replicas-handover"
+
+// handOverReplicas prepares a Deployment or StatefulSet whose replicas kbox
+// no longer applies. If kbox's previous apply set spec.replicas, dropping
+// the field would make the API server reset it to 1, scaling the app down
+// until the HPA catches up. So the live count is first applied under a
+// separate field manager, which owns it until the HPA writes a new one.
+func (e *Engine) handOverReplicas(ctx context.Context, resource, namespace, name string) error {
+	var kind string
+	var replicas *int32
+	var managedFields []metav1.ManagedFieldsEntry
+	switch resource {
+	case "deployments":
+		live, err := e.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		kind, replicas, managedFields = "Deployment", live.Spec.Replicas, live.ManagedFields
+	case "statefulsets":
+		live, err := e.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		kind, replicas, managedFields = "StatefulSet", live.Spec.Replicas, live.ManagedFields
+	default:
+		return fmt.Errorf("can't hand over the replicas of %s", resource)
+	}
+	if replicas == nil || !appliesReplicas(managedFields) {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata":   map[string]any{"name": name, "namespace": namespace},
+		"spec":       map[string]any{"replicas": *replicas},
+	})
+	if err != nil {
+		return err
+	}
+	// Not forced: kbox applied the same value, so the two managers share it
+	patchOpts := metav1.PatchOptions{FieldManager: ReplicasHandoverManager}
+	return e.withRetry(ctx, func() error {
+		var err error
+		if resource == "deployments" {
+			_, err = e.client.AppsV1().Deployments(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		} else {
+			_, err = e.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.ApplyPatchType, data, patchOpts)
+		}
+		return err
+	})
+}
+
+// appliesReplicas reports whether kbox's server-side apply owns spec.replicas
+func appliesReplicas(managedFields []metav1.ManagedFieldsEntry) bool {
+	for _, entry := range managedFields {
+		if entry.Manager != FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if json.Unmarshal(entry.FieldsV1.Raw, &fields) != nil {
+			continue
+		}
+		if _, ok := fields.Spec["f:replicas"]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+func TestApplyHandsReplicasOverToHPA(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	engine := NewEngine(client, &bytes.Buffer{})
+
+	deployment := func(replicas *int32) *render.Bundle {
+		return &render.Bundle{Deployment: &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "shop"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "shop", Image: "shop:v1"}}},
+				},
+			},
+		}}
+	}
+
+	// Deployed without autoscaling: kbox applies the replicas
+	three := int32(3)
+	if _, err := engine.Apply(ctx, deployment(&three)); err != nil {
+		t.Fatalf("first apply: %v", err)
+	}
+	live, _ := client.AppsV1().Deployments("default").Get(ctx, "shop", metav1.GetOptions{})
+	if !appliesReplicas(live.ManagedFields) {
+		t.Fatalf("expected kbox to own spec.replicas, managed fields: %+v", live.ManagedFields)
+	}
+
+	// Autoscaling enabled: the count is kept, and kbox no longer owns it
+	if _, err := engine.Apply(ctx, deployment(nil)); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+	live, _ = client.AppsV1().Deployments("default").Get(ctx, "shop", metav1.GetOptions{})
+	if live.Spec.Replicas == nil || *live.Spec.Replicas != 3 {
+		t.Errorf("expected the 3 replicas kept, got %v", live.Spec.Replicas)
+	}
+	if appliesReplicas(live.ManagedFields) {
+		t.Error("expected kbox to no longer own spec.replicas")
+	}
+	var handover bool
+	for _, entry := range live.ManagedFields {
+		handover = handover || entry.Manager == ReplicasHandoverManager
+	}
+	if !handover {
+		t.Errorf("expected %s among the managers, got %+v", ReplicasHandoverManager, live.ManagedFields)
+	}
+}
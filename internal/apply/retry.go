@@ -0,0 +1,92 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DefaultRetries is how many times a transient API error is retried
+	DefaultRetries = 4
+
+	// DefaultRetryBackoff is the delay before the first retry; it doubles
+	// with each one
+	DefaultRetryBackoff = 500 * time.Millisecond
+
+	// maxRetryDelay caps the backoff, and delays the API server asks for
+	maxRetryDelay = 30 * time.Second
+)
+
+// SetRetry sets how many times a transient API error on apply is retried,
+// and the backoff before the first retry. retries 0 disables retrying.
+func (e *Engine) SetRetry(retries int, backoff time.Duration) {
+	if retries >= 0 {
+		e.retries = retries
+	}
+	if backoff > 0 {
+		e.retryBackoff = backoff
+	}
+}
+
+// withRetry runs fn, retrying it with exponential backoff while it fails
+// with a transient error
+func (e *Engine) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= e.retries {
+			if attempt > 0 {
+				return fmt.Errorf("%w (gave up after %d retries)", err, attempt)
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay(err, e.retryBackoff, attempt)):
+		}
+	}
+}
+
+// retryable reports whether err is a transient API error worth retrying:
+// conflicts, throttling, timeouts, an unavailable API server, or an
+// admission webhook that didn't answer in time
+func retryable(err error) bool {
+	switch {
+	case errors.IsConflict(err), errors.IsTooManyRequests(err),
+		errors.IsServerTimeout(err), errors.IsTimeout(err), errors.IsServiceUnavailable(err):
+		return true
+	case errors.IsInternalError(err):
+		msg := err.Error()
+		return strings.Contains(msg, "failed calling webhook") &&
+			(strings.Contains(msg, "deadline exceeded") || strings.Contains(msg, "timeout") || strings.Contains(msg, "connection refused"))
+	}
+	return false
+}
+
+// retryDelay is how long to wait before retry attempt+1: the backoff doubled
+// per attempt with up to 25% jitter, or longer if the API server asked for it
+// (Retry-After on a 429)
+func retryDelay(err error, backoff time.Duration, attempt int) time.Duration {
+	delay := backoff << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	delay += time.Duration(rand.Int63n(int64(delay)/4 + 1))
+	if seconds, ok := errors.SuggestsClientDelay(err); ok {
+		if suggested := time.Duration(seconds) * time.Second; suggested > delay {
+			delay = suggested
+		}
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,381 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// rolloutFailureReasons are container waiting reasons that won't resolve on their own
+var rolloutFailureReasons = map[string]bool{
+	"CrashLoopBackOff": true,
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+}
+
+// rolloutWatch follows a Deployment or StatefulSet rollout through informers
+// on the workload, its ReplicaSets and Pods, and warning events on those Pods
+type rolloutWatch struct {
+	namespace string
+	name      string
+	start     time.Time
+
+	deployments  appslisters.DeploymentLister
+	statefulSets appslisters.StatefulSetLister
+	pods         corelisters.PodLister
+
+	// synced is set once the initial lists are in, so pre-existing
+	// objects are not reported as progress
+	synced atomic.Bool
+
+	// changed wakes the wait loop; progress carries messages to print
+	changed  chan struct{}
+	progress chan string
+}
+
+// WaitForRollout waits for a Deployment or StatefulSet to complete its rollout.
+// It watches instead of polling, printing progress (replica sets scaled, pods
+// scheduled and ready, failing probes) as it happens, then the external
+// address of its LoadBalancer Service once one is assigned.
+func (e *Engine) WaitForRollout(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &rolloutWatch{
+		namespace: namespace,
+		name:      name,
+		start:     time.Now(),
+		changed:   make(chan struct{}, 1),
+		progress:  make(chan string, 100),
+	}
+
+	// Each informer only lists and watches the objects of this rollout
+	byName := informers.NewSharedInformerFactoryWithOptions(e.client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = "metadata.name=" + name
+		}))
+	byApp := informers.NewSharedInformerFactoryWithOptions(e.client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = "app=" + name
+		}))
+	podWarnings := informers.NewSharedInformerFactoryWithOptions(e.client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = "involvedObject.kind=Pod,type=Warning"
+		}))
+
+	depInformer := byName.Apps().V1().Deployments()
+	ssInformer := byName.Apps().V1().StatefulSets()
+	rsInformer := byApp.Apps().V1().ReplicaSets()
+	podInformer := byApp.Core().V1().Pods()
+	eventInformer := podWarnings.Core().V1().Events()
+	w.deployments = depInformer.Lister()
+	w.statefulSets = ssInformer.Lister()
+	w.pods = podInformer.Lister()
+
+	depInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { w.notify("") },
+		UpdateFunc: func(_, _ interface{}) { w.notify("") },
+	})
+	ssInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { w.notify("") },
+		UpdateFunc: func(_, _ interface{}) { w.notify("") },
+	})
+	rsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if rs, ok := obj.(*appsv1.ReplicaSet); ok && w.synced.Load() {
+				w.notify(fmt.Sprintf("Created replica set %s", rs.Name))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.notify(replicaSetProgress(oldObj, newObj))
+		},
+	})
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { w.notify("") },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.notify(podProgress(oldObj, newObj))
+		},
+	})
+	eventInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { w.notify(w.warningProgress(obj)) },
+		UpdateFunc: func(_, newObj interface{}) {
+			w.notify(w.warningProgress(newObj))
+		},
+	})
+
+	factories := []informers.SharedInformerFactory{byName, byApp, podWarnings}
+	defer func() {
+		// Stop the informers before waiting for them to exit
+		cancel()
+		for _, f := range factories {
+			f.Shutdown()
+		}
+	}()
+	for _, f := range factories {
+		f.Start(ctx.Done())
+	}
+
+	// The timeout covers the informers' first list too, so an API server
+	// that never answers doesn't hang the deploy
+	waitCtx, cancelWait := context.WithTimeout(ctx, e.timeout)
+	defer cancelWait()
+
+	for _, f := range factories {
+		for typ, ok := range f.WaitForCacheSync(waitCtx.Done()) {
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if waitCtx.Err() != nil {
+					return output.WithCode(output.ErrRolloutTimeout, fmt.Errorf("timed out after %s watching %v for rollout\n  → Run 'kbox doctor' to diagnose connection issues", e.timeout, typ))
+				}
+				return fmt.Errorf("failed to watch %v for rollout", typ)
+			}
+		}
+	}
+	w.synced.Store(true)
+
+	task := e.progress.Start("Waiting for rollout")
+	for {
+		status, done, err := w.check()
+		if err != nil {
+			task.Fail(fmt.Errorf("%s", firstLine(err.Error())))
+			return output.WithCode(output.ErrRolloutFailed, err)
+		}
+		if done {
+			task.Done("Rollout complete (" + status + ")")
+			e.reportLoadBalancer(ctx, namespace, name)
+			return nil
+		}
+		task.Update(status)
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				task.Fail(ctx.Err())
+				return ctx.Err()
+			}
+			task.Fail(fmt.Errorf("timed out after %s", e.timeout))
+			return output.WithCode(output.ErrRolloutTimeout, fmt.Errorf("timeout waiting for rollout\n  → Run 'kbox logs' to check for errors\n  → Run 'kbox status' to see pod state"))
+		case msg := <-w.progress:
+			e.progress.Printf("    → %s", msg)
+		case <-w.changed:
+		}
+	}
+}
+
+// notify records a progress message (if any) and wakes the wait loop
+func (w *rolloutWatch) notify(msg string) {
+	if msg != "" && w.synced.Load() {
+		select {
+		case w.progress <- msg:
+		default: // Drop progress rather than block the informer
+		}
+	}
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// check evaluates the rollout from the informer caches, returning
+// how many pods are ready and whether the rollout is complete
+func (w *rolloutWatch) check() (string, bool, error) {
+	// Check for pod failures (crashloops, image pull errors)
+	pods, _ := w.pods.Pods(w.namespace).List(labels.Everything())
+	for _, pod := range pods {
+		for _, cs := range podContainerStatuses(pod) {
+			if cs.State.Waiting == nil || !rolloutFailureReasons[cs.State.Waiting.Reason] {
+				continue
+			}
+			if meshNamespace, ok := meshSidecars[cs.Name]; ok {
+				return "", false, fmt.Errorf("pod %s: mesh sidecar %s: %s\n  → Check the mesh control plane: kubectl -n %s get pods",
+					pod.Name, cs.Name, cs.State.Waiting.Reason, meshNamespace)
+			}
+			return "", false, fmt.Errorf("pod %s: %s\n  → Run 'kbox logs' to diagnose\n  → Run 'kbox status' to see events",
+				pod.Name, cs.State.Waiting.Reason)
+		}
+	}
+
+	var status string
+	var done bool
+	var err error
+	if dep, getErr := w.deployments.Deployments(w.namespace).Get(w.name); getErr == nil {
+		status, done, err = deploymentProgress(dep)
+	} else if ss, getErr := w.statefulSets.StatefulSets(w.namespace).Get(w.name); getErr == nil {
+		status, done, err = statefulSetProgress(ss)
+	} else {
+		return "", false, nil // Not in the cache yet
+	}
+	if !done && err == nil {
+		status += sidecarProgress(pods)
+	}
+	return status, done, err
+}
+
+// meshSidecars are the proxy containers service meshes inject, and the
+// namespaces of their control planes
+var meshSidecars = map[string]string{
+	"istio-proxy":   "istio-system",
+	"linkerd-proxy": "linkerd",
+}
+
+// podContainerStatuses returns the statuses of a pod's init containers,
+// where native sidecars run, and its containers
+func podContainerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	return append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+}
+
+// sidecarProgress notes pods whose own containers are ready but whose mesh
+// sidecar is not yet, which keeps them out of the ready count
+func sidecarProgress(pods []*corev1.Pod) string {
+	waiting := make(map[string]int)
+	for _, pod := range pods {
+		appReady, sidecar := true, ""
+		for _, cs := range podContainerStatuses(pod) {
+			_, isSidecar := meshSidecars[cs.Name]
+			switch {
+			case isSidecar && !cs.Ready:
+				sidecar = cs.Name
+			case !isSidecar && !cs.Ready && cs.State.Terminated == nil:
+				appReady = false
+			}
+		}
+		if appReady && sidecar != "" {
+			waiting[sidecar]++
+		}
+	}
+	var notes []string
+	for _, name := range slices.Sorted(maps.Keys(waiting)) {
+		notes = append(notes, fmt.Sprintf(", %d waiting for %s", waiting[name], name))
+	}
+	return strings.Join(notes, "")
+}
+
+// deploymentProgress reports how far a Deployment's rollout has come
+func deploymentProgress(dep *appsv1.Deployment) (string, bool, error) {
+	if dep.Status.ObservedGeneration < dep.Generation {
+		return "", false, nil
+	}
+	for _, cond := range dep.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return "", false, fmt.Errorf("rollout stalled: %s\n  → Run 'kbox why' for a diagnosis", cond.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	status := fmt.Sprintf("%d/%d pods ready", dep.Status.ReadyReplicas, replicas)
+	done := dep.Status.UpdatedReplicas == replicas &&
+		dep.Status.ReadyReplicas == replicas &&
+		dep.Status.AvailableReplicas == replicas
+	return status, done, nil
+}
+
+// statefulSetProgress reports how far a StatefulSet's rollout has come.
+// Replicas are replaced one at a time, highest ordinal first, and the
+// rollout is done once every replica runs the update revision.
+func statefulSetProgress(ss *appsv1.StatefulSet) (string, bool, error) {
+	if ss.Status.ObservedGeneration < ss.Generation {
+		return "", false, nil
+	}
+
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	status := fmt.Sprintf("%d/%d pods ready, %d updated", ss.Status.ReadyReplicas, replicas, ss.Status.UpdatedReplicas)
+	done := ss.Status.UpdatedReplicas == replicas &&
+		ss.Status.ReadyReplicas == replicas &&
+		ss.Status.CurrentRevision == ss.Status.UpdateRevision
+	return status, done, nil
+}
+
+// replicaSetProgress describes a ReplicaSet being scaled
+func replicaSetProgress(oldObj, newObj interface{}) string {
+	oldRS, ok1 := oldObj.(*appsv1.ReplicaSet)
+	newRS, ok2 := newObj.(*appsv1.ReplicaSet)
+	if !ok1 || !ok2 || oldRS.Spec.Replicas == nil || newRS.Spec.Replicas == nil {
+		return ""
+	}
+	switch {
+	case *newRS.Spec.Replicas > *oldRS.Spec.Replicas:
+		return fmt.Sprintf("Scaled up replica set %s to %d", newRS.Name, *newRS.Spec.Replicas)
+	case *newRS.Spec.Replicas < *oldRS.Spec.Replicas:
+		return fmt.Sprintf("Scaled down replica set %s to %d", newRS.Name, *newRS.Spec.Replicas)
+	}
+	return ""
+}
+
+// podProgress describes a pod being scheduled or becoming ready
+func podProgress(oldObj, newObj interface{}) string {
+	oldPod, ok1 := oldObj.(*corev1.Pod)
+	newPod, ok2 := newObj.(*corev1.Pod)
+	if !ok1 || !ok2 {
+		return ""
+	}
+	if !podCondition(oldPod, corev1.PodScheduled) && podCondition(newPod, corev1.PodScheduled) {
+		return fmt.Sprintf("Pod %s scheduled on %s", newPod.Name, newPod.Spec.NodeName)
+	}
+	if !podCondition(oldPod, corev1.PodReady) && podCondition(newPod, corev1.PodReady) {
+		return fmt.Sprintf("Pod %s ready", newPod.Name)
+	}
+	return ""
+}
+
+func podCondition(pod *corev1.Pod, typ corev1.PodConditionType) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == typ {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// warningProgress describes a recent warning on one of the rollout's pods
+// (failing probes, failed scheduling, ...)
+func (w *rolloutWatch) warningProgress(obj interface{}) string {
+	ev, ok := obj.(*corev1.Event)
+	if !ok || ev.InvolvedObject.Kind != "Pod" || ev.Type != corev1.EventTypeWarning {
+		return ""
+	}
+	if !strings.HasPrefix(ev.InvolvedObject.Name, w.name+"-") {
+		return ""
+	}
+	seen := ev.LastTimestamp.Time
+	if seen.IsZero() {
+		seen = ev.EventTime.Time
+	}
+	if seen.Before(w.start.Truncate(time.Second)) {
+		return ""
+	}
+	return fmt.Sprintf("Pod %s: %s: %s", ev.InvolvedObject.Name, ev.Reason, firstLine(ev.Message))
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// SetRotateSecrets makes Apply regenerate the values of these shared
+// secrets (by their sharedSecrets name) even if they aren't due
+func (e *Engine) SetRotateSecrets(names []string) {
+	e.rotate = names
+}
+
+// keepSharedSecrets swaps the freshly generated values of the bundle's
+// shared secrets for the live ones, so they're generated once, unless a
+// secret is being rotated: by SetRotateSecrets, or because its rotateEvery
+// has passed. Keys new to an existing secret keep their generated value.
+// The pod templates using a shared secret are annotated with when it was
+// generated, which rolls their pods when it's rotated.
+func (e *Engine) keepSharedSecrets(ctx context.Context, bundle *render.Bundle) error {
+	now := time.Now().UTC()
+	generatedAt := make(map[string]string) // Secret name -> its rotated-at
+	for _, secret := range bundle.Secrets {
+		name := secret.Labels[render.LabelSharedSecret]
+		if name == "" {
+			continue
+		}
+		rotatedAt := now.Format(time.RFC3339)
+		live, err := e.client.CoreV1().Secrets(secret.Namespace).Get(ctx, secret.Name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+		case err != nil:
+			return fmt.Errorf("failed to read shared secret %s: %w", name, err)
+		case slices.Contains(e.rotate, name) || rotationDue(secret.Annotations[render.AnnotationRotateEvery], live.Annotations[render.AnnotationRotatedAt], now):
+			e.progress.Printf("  Rotating shared secret %s", name)
+		default:
+			if at := live.Annotations[render.AnnotationRotatedAt]; at != "" {
+				rotatedAt = at
+			}
+			for key := range secret.StringData {
+				if value, ok := live.Data[key]; ok {
+					secret.StringData[key] = string(value)
+				}
+			}
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[render.AnnotationRotatedAt] = rotatedAt
+		generatedAt[secret.Name] = rotatedAt
+	}
+	if len(generatedAt) == 0 {
+		return nil
+	}
+
+	deployments := bundle.Deployments
+	if len(deployments) == 0 && bundle.Deployment != nil {
+		deployments = []*appsv1.Deployment{bundle.Deployment}
+	}
+	for _, dep := range deployments {
+		used := make(map[string]string)
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			for _, env := range c.Env {
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+					continue
+				}
+				if at, ok := generatedAt[env.ValueFrom.SecretKeyRef.Name]; ok {
+					used[env.ValueFrom.SecretKeyRef.Name] = at
+				}
+			}
+		}
+		if len(used) == 0 {
+			continue
+		}
+		var stamps []string
+		for _, name := range slices.Sorted(maps.Keys(used)) {
+			stamps = append(stamps, name+"="+used[name])
+		}
+		if dep.Spec.Template.Annotations == nil {
+			dep.Spec.Template.Annotations = make(map[string]string)
+		}
+		dep.Spec.Template.Annotations[render.AnnotationSharedSecrets] = strings.Join(stamps, ",")
+	}
+	return nil
+}
+
+// rotationDue reports whether a secret generated at rotatedAt (RFC 3339) is
+// older than its rotateEvery. Without either, it's never due.
+func rotationDue(rotateEvery, rotatedAt string, now time.Time) bool {
+	every, err := time.ParseDuration(rotateEvery)
+	if err != nil || every <= 0 {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339, rotatedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(at) >= every
+}
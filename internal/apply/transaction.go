@@ -0,0 +1,197 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// resourceKinds maps the resources applyObject applies to their kinds
+var resourceKinds = map[string]string{
+	"serviceaccounts":          "ServiceAccount",
+	"persistentvolumeclaims":   "PersistentVolumeClaim",
+	"roles":                    "Role",
+	"rolebindings":             "RoleBinding",
+	"configmaps":               "ConfigMap",
+	"secrets":                  "Secret",
+	"services":                 "Service",
+	"deployments":              "Deployment",
+	"statefulsets":             "StatefulSet",
+	"ingresses":                "Ingress",
+	"networkpolicies":          "NetworkPolicy",
+	"jobs":                     "Job",
+	"cronjobs":                 "CronJob",
+	"horizontalpodautoscalers": "HorizontalPodAutoscaler",
+	"poddisruptionbudgets":     "PodDisruptionBudget",
+	"servicemonitors":          "ServiceMonitor",
+}
+
+// rollbackTimeout bounds undoing a failed transactional apply, which runs
+// even after the apply's context is cancelled
+const rollbackTimeout = 2 * time.Minute
+
+// snapshot is an object as it was before a transactional apply touched it.
+// before is nil when the apply created the object.
+type snapshot struct {
+	resource  string
+	namespace string
+	name      string
+	before    runtime.Object
+}
+
+// transaction records the state of each object an Apply touches, so a failed
+// apply can be undone. Stages apply concurrently, hence the lock.
+type transaction struct {
+	mu        sync.Mutex
+	snapshots []snapshot
+}
+
+func (t *transaction) record(resource, namespace, name string, before runtime.Object) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.snapshots = append(t.snapshots, snapshot{resource: resource, namespace: namespace, name: name, before: before})
+}
+
+// rollback undoes the transaction's applies in reverse order: objects the
+// apply created are deleted, and the ones it changed are put back as they
+// were. The anchor is left in place, since deleting it would garbage collect
+// everything the app owns. It runs on a context of its own, so a deploy
+// interrupted by Ctrl+C or the global --timeout is still undone.
+func (e *Engine) rollback(ctx context.Context) []ResourceApply {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	var outcomes []ResourceApply
+	for i := len(e.tx.snapshots) - 1; i >= 0; i-- {
+		s := e.tx.snapshots[i]
+		outcome := ResourceApply{Kind: resourceKinds[s.resource], Name: s.name}
+		if s.before == nil {
+			outcome.Action = "deleted"
+			outcome.Err = e.deleteObject(ctx, s.resource, s.namespace, s.name)
+		} else {
+			outcome.Action = "restored"
+			outcome.Err = e.restoreObject(ctx, s.resource, s.namespace, s.before)
+		}
+		if outcome.Err != nil {
+			fmt.Fprintf(e.out, "  ✗ %s/%s: %v\n", outcome.Kind, s.name, outcome.Err)
+		} else {
+			fmt.Fprintf(e.out, "  ✓ %s/%s %s\n", outcome.Kind, s.name, outcome.Action)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// deleteObject deletes an object a failed apply created. One that's already
+// gone, because applying it failed, is fine.
+func (e *Engine) deleteObject(ctx context.Context, resource, namespace, name string) error {
+	deletePolicy := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &deletePolicy}
+
+	var err error
+	switch resource {
+	case "serviceaccounts":
+		err = e.client.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, opts)
+	case "persistentvolumeclaims":
+		err = e.client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, opts)
+	case "roles":
+		err = e.client.RbacV1().Roles(namespace).Delete(ctx, name, opts)
+	case "rolebindings":
+		err = e.client.RbacV1().RoleBindings(namespace).Delete(ctx, name, opts)
+	case "configmaps":
+		err = e.client.CoreV1().ConfigMaps(namespace).Delete(ctx, name, opts)
+	case "secrets":
+		err = e.client.CoreV1().Secrets(namespace).Delete(ctx, name, opts)
+	case "services":
+		err = e.client.CoreV1().Services(namespace).Delete(ctx, name, opts)
+	case "deployments":
+		err = e.client.AppsV1().Deployments(namespace).Delete(ctx, name, opts)
+	case "statefulsets":
+		err = e.client.AppsV1().StatefulSets(namespace).Delete(ctx, name, opts)
+	case "ingresses":
+		err = e.client.NetworkingV1().Ingresses(namespace).Delete(ctx, name, opts)
+	case "networkpolicies":
+		err = e.client.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, name, opts)
+	case "jobs":
+		err = e.client.BatchV1().Jobs(namespace).Delete(ctx, name, opts)
+	case "cronjobs":
+		err = e.client.BatchV1().CronJobs(namespace).Delete(ctx, name, opts)
+	case "horizontalpodautoscalers":
+		err = e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, name, opts)
+	case "poddisruptionbudgets":
+		err = e.client.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, name, opts)
+	case "servicemonitors":
+		err = e.dynamicClient.Resource(serviceMonitorGVR).Namespace(namespace).Delete(ctx, name, opts)
+	default:
+		return fmt.Errorf("unknown resource type: %s", resource)
+	}
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// restoreObject replaces an object with its state before the apply
+func (e *Engine) restoreObject(ctx context.Context, resource, namespace string, before runtime.Object) error {
+	obj := before.DeepCopyObject()
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	// An unconditional update: the apply has moved the resource version on
+	accessor.SetResourceVersion("")
+	accessor.SetManagedFields(nil)
+
+	opts := metav1.UpdateOptions{FieldManager: FieldManager}
+	switch o := obj.(type) {
+	case *corev1.ServiceAccount:
+		_, err = e.client.CoreV1().ServiceAccounts(namespace).Update(ctx, o, opts)
+	case *corev1.PersistentVolumeClaim:
+		_, err = e.client.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, o, opts)
+	case *rbacv1.Role:
+		_, err = e.client.RbacV1().Roles(namespace).Update(ctx, o, opts)
+	case *rbacv1.RoleBinding:
+		_, err = e.client.RbacV1().RoleBindings(namespace).Update(ctx, o, opts)
+	case *corev1.ConfigMap:
+		_, err = e.client.CoreV1().ConfigMaps(namespace).Update(ctx, o, opts)
+	case *corev1.Secret:
+		_, err = e.client.CoreV1().Secrets(namespace).Update(ctx, o, opts)
+	case *corev1.Service:
+		_, err = e.client.CoreV1().Services(namespace).Update(ctx, o, opts)
+	case *appsv1.Deployment:
+		_, err = e.client.AppsV1().Deployments(namespace).Update(ctx, o, opts)
+	case *appsv1.StatefulSet:
+		_, err = e.client.AppsV1().StatefulSets(namespace).Update(ctx, o, opts)
+	case *networkingv1.Ingress:
+		_, err = e.client.NetworkingV1().Ingresses(namespace).Update(ctx, o, opts)
+	case *networkingv1.NetworkPolicy:
+		_, err = e.client.NetworkingV1().NetworkPolicies(namespace).Update(ctx, o, opts)
+	case *batchv1.Job:
+		_, err = e.client.BatchV1().Jobs(namespace).Update(ctx, o, opts)
+	case *batchv1.CronJob:
+		_, err = e.client.BatchV1().CronJobs(namespace).Update(ctx, o, opts)
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		_, err = e.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Update(ctx, o, opts)
+	case *policyv1.PodDisruptionBudget:
+		_, err = e.client.PolicyV1().PodDisruptionBudgets(namespace).Update(ctx, o, opts)
+	case *unstructured.Unstructured:
+		_, err = e.dynamicClient.Resource(serviceMonitorGVR).Namespace(namespace).Update(ctx, o, opts)
+	default:
+		return fmt.Errorf("unknown resource type: %s", resource)
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	buildsFile  = "builds.json"
+	deploysFile = "deploys.json"
+)
+
+// BuildEntry records the image built from a build context
+type BuildEntry struct {
+	Image   string    `json:"image"`
+	ImageID string    `json:"imageId"`
+	Built   time.Time `json:"built"`
+}
+
+// DeployEntry records the last bundle deployed for an app to a cluster/namespace
+type DeployEntry struct {
+	BundleDigest string    `json:"bundleDigest"`
+	Image        string    `json:"image"`
+	Deployed     time.Time `json:"deployed"`
+}
+
+// Cache stores build and deploy state on disk so unchanged apps are not
+// rebuilt or redeployed. It is best-effort: a missing or corrupt cache is empty.
+type Cache struct {
+	dir string
+}
+
+// New creates a cache rooted at dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Default returns the cache at ~/.kbox/cache
+func Default() (*Cache, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(home, ".kbox", "cache")), nil
+}
+
+// Build returns the cached build for a build key
+func (c *Cache) Build(key string) (BuildEntry, bool) {
+	builds := map[string]BuildEntry{}
+	c.read(buildsFile, &builds)
+	entry, ok := builds[key]
+	return entry, ok
+}
+
+// PutBuild records the image built for a build key
+func (c *Cache) PutBuild(key string, entry BuildEntry) error {
+	builds := map[string]BuildEntry{}
+	c.read(buildsFile, &builds)
+	builds[key] = entry
+	return c.write(buildsFile, builds)
+}
+
+// BuildKey identifies a build of a context digest for an image repository,
+// so an image built for one repository is never published as another's
+func BuildKey(repository, digest string) string {
+	return repository + "@" + digest
+}
+
+// Deploy returns the last deploy recorded under key
+func (c *Cache) Deploy(key string) (DeployEntry, bool) {
+	deploys := map[string]DeployEntry{}
+	c.read(deploysFile, &deploys)
+	entry, ok := deploys[key]
+	return entry, ok
+}
+
+// PutDeploy records a deploy under key
+func (c *Cache) PutDeploy(key string, entry DeployEntry) error {
+	deploys := map[string]DeployEntry{}
+	c.read(deploysFile, &deploys)
+	deploys[key] = entry
+	return c.write(deploysFile, deploys)
+}
+
+// DeployKey identifies an app deployed to a namespace of a cluster
+func DeployKey(kubeContext, namespace, app string) string {
+	return kubeContext + "/" + namespace + "/" + app
+}
+
+func (c *Cache) read(name string, v interface{}) {
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, v)
+}
+
+func (c *Cache) write(name string, v interface{}) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so concurrent kbox runs never see a partial file
+	tmp, err := os.CreateTemp(c.dir, name+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, name))
+}
+
+// ContextDigest hashes a docker build context: every file's path, mode and
+// contents, skipping paths matched by .dockerignore plus .git and .kbox
+func ContextDigest(workDir string) (string, error) {
+	ignore := loadDockerignore(workDir)
+	hasNegation := false
+	for _, p := range ignore {
+		hasNegation = hasNegation || strings.HasPrefix(p, "!")
+	}
+
+	var files []string
+	err := filepath.Walk(workDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ".git" || rel == ".kbox" {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignored(ignore, rel) {
+			// A "!pattern" may re-include files below an ignored directory
+			if fi.IsDir() && !hasNegation {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash build context: %w", err)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		path := filepath.Join(workDir, filepath.FromSlash(rel))
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", rel, fi.Mode())
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			io.WriteString(h, target)
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadDockerignore reads the patterns in .dockerignore
+func loadDockerignore(workDir string) []string {
+	f, err := os.Open(filepath.Join(workDir, ".dockerignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimPrefix(filepath.ToSlash(line), "/"))
+	}
+	return patterns
+}
+
+// ignored applies .dockerignore patterns in order, so a later "!pattern" re-includes.
+// Only glob patterns are supported; "**" matches any number of directories
+// at the start of a pattern.
+func ignored(patterns []string, rel string) bool {
+	result := false
+	for _, p := range patterns {
+		negate := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		if matchPattern(p, rel) {
+			result = !negate
+		}
+	}
+	return result
+}
+
+func matchPattern(pattern, rel string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if rest, ok := strings.CutPrefix(pattern, "**/"); ok {
+		parts := strings.Split(rel, "/")
+		for i := range parts {
+			if matchPattern(rest, strings.Join(parts[i:], "/")) {
+				return true
+			}
+		}
+		return false
+	}
+	if ok, _ := filepath.Match(pattern, rel); ok {
+		return true
+	}
+	// A pattern matching a parent directory matches everything inside it
+	if i := strings.LastIndex(rel, "/"); i > 0 {
+		return matchPattern(pattern, rel[:i])
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestContextDigest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Dockerfile":          "FROM scratch",
+		"main.go":             "package main",
+		".dockerignore":       "node_modules\n*.log\n!keep.log\n",
+		"node_modules/x/a.js": "1",
+		"debug.log":           "noise",
+		"keep.log":            "kept",
+		".git/HEAD":           "ref",
+	})
+
+	digest := func() string {
+		d, err := ContextDigest(dir)
+		if err != nil {
+			t.Fatalf("ContextDigest failed: %v", err)
+		}
+		return d
+	}
+	base := digest()
+
+	// Ignored paths don't affect the digest
+	writeFiles(t, dir, map[string]string{
+		"node_modules/x/a.js": "2",
+		"debug.log":           "more noise",
+		".git/HEAD":           "other",
+		".kbox/state":         "context: kind",
+	})
+	if digest() != base {
+		t.Error("expected ignored files not to change the digest")
+	}
+
+	// Re-included and regular files do
+	writeFiles(t, dir, map[string]string{"keep.log": "changed"})
+	changed := digest()
+	if changed == base {
+		t.Error("expected a re-included file to change the digest")
+	}
+	writeFiles(t, dir, map[string]string{"main.go": "package main // edit"})
+	if digest() == changed {
+		t.Error("expected a source change to change the digest")
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"**/*.tmp", "build/", "docs/*.md", "!docs/README.md"}
+	tests := map[string]bool{
+		"a.tmp":          true,
+		"src/deep/b.tmp": true,
+		"build/out.bin":  true,
+		"docs/guide.md":  true,
+		"docs/README.md": false,
+		"src/main.go":    false,
+	}
+	for rel, want := range tests {
+		if got := ignored(patterns, rel); got != want {
+			t.Errorf("ignored(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "cache"))
+
+	build := BuildKey("myapp", "abc")
+	if _, ok := c.Build(build); ok {
+		t.Error("expected empty cache")
+	}
+	if err := c.PutBuild(build, BuildEntry{Image: "myapp:kbox-abc", ImageID: "sha256:1", Built: time.Now()}); err != nil {
+		t.Fatalf("PutBuild failed: %v", err)
+	}
+	if entry, ok := c.Build(build); !ok || entry.Image != "myapp:kbox-abc" {
+		t.Errorf("unexpected build entry %+v", entry)
+	}
+	if _, ok := c.Build(BuildKey("ghcr.io/acme/myapp", "abc")); ok {
+		t.Error("expected builds to be keyed by repository")
+	}
+
+	key := DeployKey("kind-dev", "default", "myapp")
+	if err := c.PutDeploy(key, DeployEntry{BundleDigest: "d1", Image: "myapp:kbox-abc"}); err != nil {
+		t.Fatalf("PutDeploy failed: %v", err)
+	}
+	if entry, ok := c.Deploy(key); !ok || entry.BundleDigest != "d1" {
+		t.Errorf("unexpected deploy entry %+v", entry)
+	}
+	if _, ok := c.Deploy(DeployKey("prod", "default", "myapp")); ok {
+		t.Error("expected deploys to be keyed by cluster")
+	}
+
+	// A corrupt file reads as empty
+	os.WriteFile(filepath.Join(c.dir, buildsFile), []byte("{not json"), 0644)
+	if _, ok := c.Build(build); ok {
+		t.Error("expected corrupt cache to read as empty")
+	}
+}
@@ -1,19 +1,28 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <dependency>",
-	Short: "Add a dependency to the app",
-	Long: `Add a managed dependency like postgres, redis, or mongodb.
+	Use:   "add <dependency|ingress|autoscale|metrics>",
+	Short: "Add a dependency, an ingress, autoscaling or metrics to the app",
+	Long: `Add a managed dependency like postgres, redis, or mongodb, or turn on
+an ingress, autoscaling or Prometheus metrics.
 
 This modifies your kbox.yaml to include the dependency, which will
 be deployed as a StatefulSet with persistent storage.
@@ -24,28 +33,48 @@ variables (DATABASE_URL, REDIS_URL, etc.) into your app.
 Supported dependencies:
   - postgres (or postgres:15)
   - redis (or redis:7)
-  - mongodb (or mongodb:6)
+  - mongodb (or mongodb:8)
   - mysql (or mysql:8)
+  - custom templates in .kbox/dependencies/<type>.yaml
+
+--from fetches a custom template into .kbox/dependencies/ first, from a
+git repository, an OCI artifact (pulled with oras), a URL or a file.
+Commit the fetched file with kbox.yaml; fetching again updates it.
+
+Capabilities are set in kbox.yaml, keeping comments, and the change is
+printed as a diff (only printed with --dry-run):
+  - ingress    --host, --path, --class, --tls, --tls-secret
+  - autoscale  --min, --max, --cpu (target CPU %); removes spec.replicas
+  - metrics    --path, --port, --interval (a Prometheus ServiceMonitor)
+Adding one that's already there updates the fields given.
 
 Examples:
   kbox add postgres           # Add PostgreSQL 15 (default)
   kbox add postgres:14        # Add specific version
   kbox add redis              # Add Redis
-  kbox add mongodb            # Add MongoDB`,
+  kbox add mongodb            # Add MongoDB
+  kbox add my-queue           # Custom template in .kbox/dependencies/my-queue.yaml
+  kbox add my-queue --from git::https://github.com/acme/kbox-templates.git//my-queue.yaml?ref=v1
+  kbox add my-queue --from oci://ghcr.io/acme/kbox-templates/my-queue:v1
+  kbox add ingress --host app.example.com --tls
+  kbox add autoscale --min 2 --max 10
+  kbox add metrics --path /metrics`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAdd,
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
 	storage, _ := cmd.Flags().GetString("storage")
+	from, _ := cmd.Flags().GetString("from")
 
 	// Parse dependency spec (e.g., "postgres:15" or "postgres")
 	depSpec := args[0]
 	depType, version := parseDependencySpec(depSpec)
-
-	// Validate dependency type
-	if !dependencies.IsSupported(depType) {
-		return fmt.Errorf("unsupported dependency: %s\n  → Supported: %v", depType, dependencies.SupportedTypes())
+	if depType == "autoscaling" {
+		depType = config.CapabilityAutoscale
+	}
+	if slices.Contains(config.Capabilities, depType) {
+		return runAddCapability(cmd, depType)
 	}
 
 	// Find config file path
@@ -55,6 +84,17 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to find kbox.yaml: %w\n  → Run 'kbox init' to create one first", err)
 	}
 
+	if from != "" {
+		if err := fetchDependencyTemplate(cmd.Context(), filepath.Dir(configPath), depType, from); err != nil {
+			return err
+		}
+	}
+
+	// Validate dependency type
+	if !dependencies.IsSupported(depType) {
+		return fmt.Errorf("unsupported dependency: %s\n  → Supported: %v\n  → For your own, add %s/%s.yaml or fetch one with --from", depType, dependencies.SupportedTypes(), dependencies.LocalDir, depType)
+	}
+
 	// Load YAML preserving comments
 	node, err := config.LoadYAMLWithComments(configPath)
 	if err != nil {
@@ -91,9 +131,12 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Added %s:%s to %s\n", depType, imageVersion, configPath)
+	for _, notice := range dependencies.CheckVersion(depType, imageVersion, time.Now()) {
+		fmt.Printf("  ⚠ %s\n", notice.Message)
+	}
 	fmt.Println()
 	fmt.Println("When deployed, the following env vars will be injected into your app:")
-	for k := range template.EnvVars {
+	for _, k := range slices.Sorted(maps.Keys(template.EnvVars)) {
 		fmt.Printf("  %s\n", k)
 	}
 	fmt.Println()
@@ -103,6 +146,58 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// loadDependencyTemplates registers the project's custom dependency
+// templates, from .kbox/dependencies next to --file or in the current
+// directory, then applies its pinned versions from .kbox/versions.yaml. It
+// runs before every command, so a bad file is skipped with a warning rather
+// than failing commands that don't use it.
+func loadDependencyTemplates(cmd *cobra.Command) {
+	dir := "."
+	if f := cmd.Flags().Lookup("file"); f != nil && f.Value.String() != "" {
+		dir = filepath.Dir(f.Value.String())
+	}
+	if _, err := dependencies.LoadDir(filepath.Join(dir, dependencies.LocalDir)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: skipped custom dependency templates that failed to load:\n")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		fmt.Fprintf(os.Stderr, "  → Fix or remove the file\n")
+	}
+	if err := dependencies.LoadVersions(filepath.Join(dir, dependencies.VersionsFile)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: skipped pinned dependency versions: %v\n  → Fix or remove the file\n", err)
+	}
+}
+
+// fetchDependencyTemplate fetches a custom template from source into the
+// project's dependency templates, next to kbox.yaml, and registers it
+func fetchDependencyTemplate(ctx context.Context, projectDir, depType, source string) error {
+	if dependencies.IsBuiltin(depType) {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%s is a built-in dependency\n  → Run 'kbox add %s' without --from", depType, depType))
+	}
+	data, err := dependencies.Fetch(ctx, source, depType)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the %s template: %w", depType, err)
+	}
+	name, template, err := dependencies.ParseTemplate(data, depType)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%s: %w", source, err))
+	}
+	if name != depType {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%s defines dependency %s, not %s\n  → Run 'kbox add %s --from %s'", source, name, depType, name, source))
+	}
+
+	path := filepath.Join(projectDir, dependencies.LocalDir, depType+".yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	content := append([]byte(fmt.Sprintf("# Fetched from %s by kbox add\n", source)), data...)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("Fetched the %s template to %s\n", depType, path)
+	return dependencies.Register(depType, template)
+}
+
 // parseDependencySpec parses "postgres:15" into ("postgres", "15")
 func parseDependencySpec(spec string) (string, string) {
 	parts := strings.SplitN(spec, ":", 2)
@@ -117,19 +212,33 @@ var removeCmd = &cobra.Command{
 	Short: "Remove a dependency from the app",
 	Long: `Remove a managed dependency from kbox.yaml.
 
-Note: This only removes the dependency from the configuration.
-To remove deployed resources, run 'kbox deploy' after removal,
-or manually delete them with kubectl.
+By default this only removes the dependency from the configuration.
+With --apply, its StatefulSet, Service and Secret are deleted from the
+cluster too. Its PersistentVolumeClaim (the data) is kept unless you
+also pass --delete-data, which asks for confirmation first.
+
+Env vars in kbox.yaml that still reference the dependency are listed,
+since they break once it's gone.
 
 Examples:
   kbox remove postgres
-  kbox remove redis`,
+  kbox remove redis --apply
+  kbox remove postgres --apply --delete-data
+  kbox remove postgres --apply --delete-data --yes   # No prompt (CI)`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRemove,
 }
 
 func runRemove(cmd *cobra.Command, args []string) error {
 	depType := strings.ToLower(args[0])
+	applyRemove, _ := cmd.Flags().GetBool("apply")
+	deleteData, _ := cmd.Flags().GetBool("delete-data")
+	if deleteData && !applyRemove {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("--delete-data needs --apply\n  → Run 'kbox remove %s --apply --delete-data'", depType))
+	}
+	if applyRemove && k8s.ReadOnly() {
+		return output.WithCode(output.ErrForbidden, fmt.Errorf("--apply changes the cluster, and kbox is running with --read-only\n  → Run 'kbox remove %s' without --apply to only edit kbox.yaml", depType))
+	}
 
 	// Find config file path
 	loader := config.NewLoader(".")
@@ -161,29 +270,60 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%s is not configured as a dependency", depType)
 	}
 
-	// Save YAML preserving comments
-	if err := config.SaveYAMLWithComments(configPath, node); err != nil {
-		return fmt.Errorf("failed to write %s: %w", configPath, err)
-	}
-
-	// Load config to get app name for output message
+	// Load config (before the edit) for the app name and namespace
 	cfg, _ := loader.Load()
 	appName := "myapp"
 	if cfg != nil {
 		appName = cfg.Metadata.Name
 	}
 
+	// Delete from the cluster first, so a failure leaves kbox.yaml as it was
+	if applyRemove {
+		if cfg == nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load %s\n  → Run 'kbox validate' to see what's wrong", configPath))
+		}
+		if err := removeDependencyResources(cmd, cfg, depType, deleteData); err != nil {
+			return err
+		}
+	}
+
+	// Save YAML preserving comments
+	if err := config.SaveYAMLWithComments(configPath, node); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
 	fmt.Printf("Removed %s from %s\n", depType, configPath)
-	fmt.Println()
-	fmt.Printf("Note: Deployed resources are not automatically removed.\n")
-	fmt.Printf("  → Run 'kbox deploy' to update the deployment\n")
-	fmt.Printf("  → Or delete manually: kubectl delete statefulset %s-%s\n", appName, depType)
+	if cfg != nil {
+		warnDependencyEnv(cfg, depType)
+	}
+	if !applyRemove {
+		fmt.Println()
+		fmt.Printf("Note: Deployed resources are not automatically removed.\n")
+		fmt.Printf("  → Run 'kbox remove %s --apply' to delete them from the cluster\n", depType)
+		fmt.Printf("  → Or delete manually: kubectl delete statefulset %s-%s\n", appName, depType)
+	}
 
 	return nil
 }
 
 func init() {
 	addCmd.Flags().String("storage", "", "Storage size (e.g., 5Gi)")
+	addCmd.Flags().String("from", "", "Fetch a custom template first: git::<repo>//<file>?ref=<ref>, oci://<ref>, a URL or a file")
+	addCmd.Flags().Bool("dry-run", false, "Print the kbox.yaml change without writing it (capabilities)")
+	addCmd.Flags().String("host", "", "Ingress host, e.g. app.example.com")
+	addCmd.Flags().String("path", "", "Ingress path prefix (default /), or the metrics path (default /metrics)")
+	addCmd.Flags().String("class", "", "Ingress class, e.g. nginx")
+	addCmd.Flags().Bool("tls", false, "Enable TLS on the ingress")
+	addCmd.Flags().String("tls-secret", "", "Secret with the ingress TLS certificate (implies --tls)")
+	addCmd.Flags().Int("min", 0, "Minimum replicas for autoscaling (default 1)")
+	addCmd.Flags().Int("max", 0, "Maximum replicas for autoscaling (default 10)")
+	addCmd.Flags().Int("cpu", 0, "Target CPU utilization % for autoscaling (default 80)")
+	addCmd.Flags().String("port", "", "Port name to scrape for metrics (default http)")
+	addCmd.Flags().String("interval", "", "Metrics scrape interval (default 30s)")
+
+	removeCmd.Flags().Bool("apply", false, "Also delete the dependency's StatefulSet, Service and Secret from the cluster")
+	removeCmd.Flags().Bool("delete-data", false, "With --apply, also delete its PersistentVolumeClaim (asks first)")
+	removeCmd.Flags().BoolP("yes", "y", false, "Confirm --delete-data without prompting")
 
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(removeCmd)
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/golden"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// runAddCapability turns on ingress, autoscaling or metrics in kbox.yaml,
+// keeping comments, and prints the change
+func runAddCapability(cmd *cobra.Command, capability string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	configPath, err := config.NewLoader(".").FindConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to find kbox.yaml: %w\n  → Run 'kbox init' to create one first", err)
+	}
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	node, err := config.LoadYAMLWithComments(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", configPath, err)
+	}
+	root := config.GetRootDocument(node)
+
+	var notes []string
+	switch capability {
+	case config.CapabilityIngress:
+		host, _ := cmd.Flags().GetString("host")
+		path, _ := cmd.Flags().GetString("path")
+		class, _ := cmd.Flags().GetString("class")
+		tls, _ := cmd.Flags().GetBool("tls")
+		tlsSecret, _ := cmd.Flags().GetString("tls-secret")
+		ing := config.IngressConfig{Host: host, Path: path, IngressClass: class}
+		if tls || tlsSecret != "" {
+			ing.TLS = &config.TLSConfig{Enabled: true, SecretName: tlsSecret}
+		}
+		config.AddIngressNode(root, ing)
+		notes = append(notes, "Requires an ingress controller in the cluster, such as ingress-nginx")
+	case config.CapabilityAutoscale:
+		minReplicas, _ := cmd.Flags().GetInt("min")
+		maxReplicas, _ := cmd.Flags().GetInt("max")
+		cpu, _ := cmd.Flags().GetInt("cpu")
+		if config.FindMapKey(config.FindMapKey(root, "spec"), "autoscaling") == nil && maxReplicas == 0 {
+			maxReplicas = 10 // Written out, so it's easy to tune
+		}
+		if config.AddAutoscalingNode(root, config.AutoscalingConfig{MinReplicas: minReplicas, MaxReplicas: maxReplicas, TargetCPUUtilization: cpu}) {
+			notes = append(notes, "Removed spec.replicas: the HPA sets the replica count")
+		}
+		notes = append(notes, "Requires metrics-server in the cluster, and CPU requests (spec.resources.cpu)")
+	case config.CapabilityMetrics:
+		path, _ := cmd.Flags().GetString("path")
+		port, _ := cmd.Flags().GetString("port")
+		interval, _ := cmd.Flags().GetString("interval")
+		config.AddMetricsNode(root, config.MetricsConfig{Path: path, Port: port, Interval: interval})
+		notes = append(notes, "Creates a ServiceMonitor, which requires the Prometheus Operator")
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	encoder.Close()
+	after := buf.Bytes()
+
+	diff := golden.Diff(string(before), string(after))
+	if diff == "" {
+		fmt.Printf("%s is already configured in %s\n", capability, configPath)
+		return nil
+	}
+
+	// Validate the result, with any kbox.d fragments, before touching kbox.yaml
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), ".kbox-add-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(after); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if _, err := config.NewLoader(".").LoadFile(tmp.Name()); err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("adding %s would make %s invalid: %w\n  → Check the flags: 'kbox add %s --help'", capability, configPath, err, capability))
+	}
+
+	fmt.Print(diff)
+	fmt.Println()
+	if dryRun {
+		fmt.Printf("Dry run: %s not changed\n", configPath)
+		return nil
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), configPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	fmt.Printf("Added %s to %s\n", capability, configPath)
+	for _, note := range notes {
+		fmt.Printf("  → %s\n", note)
+	}
+	fmt.Println("  → Run 'kbox diff' to see the cluster changes, then 'kbox deploy'")
+	return nil
+}
@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/airgap"
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/images"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Export and deploy offline bundles for air-gapped clusters",
+	Long: `Package an app for clusters that can't reach its registries or sources.
+
+'kbox bundle export' writes a tar.gz with the rendered manifests, every image
+they run (pulled and saved with docker), and release metadata: the git commit
+it came from and the config, so the release history and rollback work after
+deploying it.
+
+'kbox bundle import' loads the images where the cluster can run them: into
+kind or minikube, into containerd with ctr on a node, or pushed to a
+registry the cluster can reach (--registry), with the manifests rewritten
+to pull from it. 'kbox bundle deploy' imports and then deploys like
+'kbox deploy'.
+
+The bundle contains the app's secrets; it's written readable only by you.
+
+Examples:
+  kbox bundle export -e prod                   # Writes myapp-prod.bundle.tar.gz
+  kbox bundle export -e prod -n shop out.tgz   # For namespace shop
+  kbox bundle import myapp-prod.bundle.tar.gz --registry registry.internal:5000
+  kbox bundle deploy myapp-prod.bundle.tar.gz --context airgapped`,
+}
+
+var bundleExportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Write the app's manifests, images and release metadata to a tar.gz",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBundleExport,
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Load a bundle's images into the cluster or a registry",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := airgap.Open(args[0])
+		if err != nil {
+			return output.WithCode(output.ErrConfig, err)
+		}
+		kubeContext, _ := cmd.Flags().GetString("context")
+		_, err = importBundleImages(cmd, b, kubeContext)
+		return err
+	},
+}
+
+var bundleDeployCmd = &cobra.Command{
+	Use:         "deploy <bundle>",
+	Short:       "Import a bundle's images and deploy its manifests",
+	Args:        cobra.ExactArgs(1),
+	RunE:        runBundleDeploy,
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
+}
+
+func runBundleExport(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	noImages, _ := cmd.Flags().GetBool("no-images")
+	ctx := cmd.Context()
+	if k8s.NoCluster() && !noImages {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("bundle export pulls the images from their registries, which --no-cluster rules out\n  → Add --no-images to export the manifests only"))
+	}
+
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	target, err := loadTestTarget(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	if target.app != nil && namespace != "" {
+		target.app.Metadata.Namespace = namespace
+	}
+	if target.multi != nil && namespace != "" {
+		target.multi.Metadata.Namespace = namespace
+	}
+	if target.app != nil {
+		if cfg := target.app.ForEnvironment(env); cfg.Spec.Image == "" {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%s has no spec.image to bundle\n  → Push the image kbox up builds and set 'image:' in kbox.yaml (or in environments.%s)", path, env))
+		}
+	}
+
+	bundle, _, _, err := target.render(env)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	var manifests bytes.Buffer
+	if err := bundle.ToYAML(&manifests); err != nil {
+		return err
+	}
+
+	meta := airgap.Metadata{
+		App:         target.name(),
+		Env:         env,
+		Namespace:   bundle.Anchor.Namespace,
+		KboxVersion: Version,
+		Created:     time.Now().UTC(),
+		Change:      release.CurrentChange(ctx, filepath.Dir(path)),
+	}
+	if target.app != nil {
+		meta.Config = target.app.ForEnvironment(env)
+	}
+
+	seen := make(map[string]bool)
+	for _, use := range bundle.ImageUses() {
+		if use.Image == "" {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%s has no image to bundle\n  → Push the image kbox up builds and set its 'image:' in kbox.yaml", use.Workload))
+		}
+		if !seen[use.Image] {
+			seen[use.Image] = true
+			meta.Images = append(meta.Images, airgap.Image{Image: use.Image})
+		}
+	}
+
+	out := fmt.Sprintf("%s.bundle.tar.gz", meta.App)
+	if env != "" {
+		out = fmt.Sprintf("%s-%s.bundle.tar.gz", meta.App, env)
+	}
+	if len(args) > 0 {
+		out = args[0]
+	}
+
+	var imagesTar string
+	if !noImages && len(meta.Images) > 0 {
+		tmp, err := os.MkdirTemp("", "kbox-bundle-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		imagesTar = filepath.Join(tmp, airgap.ImagesFile)
+		if err := saveImages(ctx, meta.Images, imagesTar); err != nil {
+			return err
+		}
+	}
+
+	if err := airgap.Write(out, meta, manifests.Bytes(), imagesTar); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+
+	fmt.Printf("✓ Wrote %s\n", out)
+	fmt.Printf("  %d resource(s) for namespace %s\n", len(bundle.AllObjects()), meta.Namespace)
+	for _, img := range meta.Images {
+		if noImages {
+			fmt.Printf("  - %s (not included)\n", img.Image)
+		} else {
+			fmt.Printf("  - %s %s\n", img.Image, img.Digest)
+		}
+	}
+	if len(bundle.Secrets) > 0 {
+		fmt.Printf("  ⚠ Contains %d Secret(s): keep the bundle somewhere safe\n", len(bundle.Secrets))
+	}
+	return nil
+}
+
+// saveImages pulls images, falling back to local ones, records their
+// digests and writes them to path with docker save
+func saveImages(ctx context.Context, imgs []airgap.Image, path string) error {
+	var refs []string
+	for i, img := range imgs {
+		fmt.Printf("Pulling %s\n", img.Image)
+		if err := exec.CommandContext(ctx, "docker", "pull", "-q", img.Image).Run(); err != nil && imageID(ctx, img.Image) == "" {
+			return fmt.Errorf("failed to pull %s and it isn't a local image: %w\n  → Check 'docker pull %s' works", img.Image, err, img.Image)
+		}
+		if out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", img.Image).Output(); err == nil {
+			if _, digest, ok := strings.Cut(strings.SplitN(string(out), "\n", 2)[0], "@"); ok {
+				imgs[i].Digest = digest
+			}
+		}
+		refs = append(refs, img.Image)
+	}
+	fmt.Printf("Saving %d image(s)\n", len(refs))
+	save := exec.CommandContext(ctx, "docker", append([]string{"save", "-o", path}, refs...)...)
+	save.Stderr = os.Stderr
+	if err := save.Run(); err != nil {
+		return fmt.Errorf("docker save failed: %w", err)
+	}
+	return nil
+}
+
+// importBundleImages loads the bundle's images with --load, and returns the
+// images pushed to --registry by their original names
+func importBundleImages(cmd *cobra.Command, b *airgap.Bundle, kubeContext string) (map[string]string, error) {
+	registry, _ := cmd.Flags().GetString("registry")
+	loader, _ := cmd.Flags().GetString("load")
+	ctx := cmd.Context()
+
+	if len(b.Metadata.Images) == 0 {
+		return nil, nil
+	}
+	tmp, err := os.MkdirTemp("", "kbox-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, airgap.ImagesFile)
+	found, err := b.ExtractImages(archive)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		fmt.Println("  ⚠ The bundle has no images (exported with --no-images); the cluster must pull them")
+		return nil, nil
+	}
+
+	if loader == "auto" {
+		if registry != "" {
+			loader = "registry"
+		} else {
+			if kubeContext == "" {
+				client, err := k8s.NewClient(k8s.ClientOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to read kubeconfig: %w\n  → Pass --load or --registry", err)
+				}
+				kubeContext = client.Context
+			}
+			loader = bundleLoader(kubeContext)
+		}
+	}
+
+	run := func(name string, args ...string) error {
+		c := exec.CommandContext(ctx, name, args...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		return c.Run()
+	}
+
+	fmt.Printf("Loading %d image(s) with %s\n", len(b.Metadata.Images), loader)
+	switch loader {
+	case "kind":
+		name := strings.TrimPrefix(kubeContext, "kind-")
+		if name == "" {
+			name = "kind"
+		}
+		err = run("kind", "load", "image-archive", archive, "--name", name)
+	case "minikube":
+		err = run("minikube", "image", "load", archive)
+	case "ctr":
+		err = run("ctr", "-n", "k8s.io", "images", "import", archive)
+	case "docker":
+		err = run("docker", "load", "-i", archive)
+	case "registry":
+		if registry == "" {
+			return nil, output.WithCode(output.ErrConfig, fmt.Errorf("--load registry needs --registry"))
+		}
+		if err := run("docker", "load", "-i", archive); err != nil {
+			return nil, fmt.Errorf("docker load failed: %w", err)
+		}
+		pushed := make(map[string]string)
+		for _, img := range b.Metadata.Images {
+			target, err := registryImage(registry, img.Image)
+			if err != nil {
+				return nil, err
+			}
+			if err := run("docker", "tag", img.Image, target); err != nil {
+				return nil, fmt.Errorf("failed to tag %s: %w", img.Image, err)
+			}
+			if err := run("docker", "push", target); err != nil {
+				return nil, fmt.Errorf("failed to push %s: %w\n  → Run 'docker login %s'", target, err, registry)
+			}
+			pushed[img.Image] = target
+		}
+		fmt.Printf("  ✓ Pushed %d image(s) to %s\n", len(pushed), registry)
+		return pushed, nil
+	default:
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("unknown --load %q (must be auto, kind, minikube, ctr, docker or registry)", loader))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load images with %s: %w", loader, err)
+	}
+	fmt.Println("  ✓ Images loaded")
+	return nil, nil
+}
+
+// bundleLoader picks how to load images for a cluster: kind and minikube
+// have their own loaders, Docker Desktop runs docker's images, and other
+// clusters take them into containerd with ctr, run on the node
+func bundleLoader(kubeContext string) string {
+	switch {
+	case isKindCluster(kubeContext):
+		return "kind"
+	case isMinikubeCluster(kubeContext):
+		return "minikube"
+	case kubeContext == "docker-desktop" || kubeContext == "docker-for-desktop":
+		return "docker"
+	}
+	return "ctr"
+}
+
+// registryImage returns where image goes in registry: its repository and
+// tag, or digest, under the registry host (and path prefix, if any)
+func registryImage(registry, image string) (string, error) {
+	ref, err := images.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	target := strings.TrimSuffix(registry, "/") + "/" + ref.Repository
+	if ref.Tag != "" {
+		return target + ":" + ref.Tag, nil
+	}
+	// docker can't push by digest; tag with it instead
+	return target + ":" + strings.ReplaceAll(ref.Digest, ":", "-"), nil
+}
+
+func runBundleDeploy(cmd *cobra.Command, args []string) error {
+	kubeContext, _ := cmd.Flags().GetString("context")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	noWait, _ := cmd.Flags().GetBool("no-wait")
+	timeout, _ := cmd.Flags().GetDuration("rollout-timeout")
+	skipTests, _ := cmd.Flags().GetBool("skip-tests")
+	transactional, _ := cmd.Flags().GetBool("transactional")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
+	outputFormat := GetOutputFormat(cmd)
+	ciMode := IsCIMode(cmd)
+	timer := output.NewTimer()
+
+	result := &output.DeployResult{}
+	finalize := func(err error) error {
+		result.DurationMs = timer.ElapsedMs()
+		if err != nil {
+			result.Error = err.Error()
+			result.ErrorCode = output.CodeOf(err)
+		}
+		if outputFormat == "json" {
+			output.NewWriter(os.Stdout, outputFormat, ciMode).WriteDeployResult(result)
+			if !result.Success {
+				return output.Reported(err)
+			}
+			return nil
+		}
+		return err
+	}
+
+	b, err := airgap.Open(args[0])
+	if err != nil {
+		return finalize(output.WithCode(output.ErrConfig, err))
+	}
+	meta := b.Metadata
+	result.App = meta.App
+	result.Namespace = meta.Namespace
+	// The namespace is rendered into the manifests
+	if namespace != "" && namespace != meta.Namespace {
+		return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("%s was exported for namespace %s\n  → Export it with 'kbox bundle export -n %s'", args[0], meta.Namespace, namespace)))
+	}
+
+	bundle, err := render.ParseBundle(b.Manifests, meta.App)
+	if err != nil {
+		return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("failed to read the manifests of %s: %w", args[0], err)))
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext, Namespace: meta.Namespace})
+	if err != nil {
+		return finalize(output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)))
+	}
+	result.Context = client.Context
+
+	pushed, err := importBundleImages(cmd, b, client.Context)
+	if err != nil {
+		return finalize(err)
+	}
+	bundle.RewriteImages(pushed)
+
+	plan := &deployPlan{
+		appName:       meta.App,
+		namespace:     meta.Namespace,
+		bundle:        bundle,
+		cfg:           meta.Config,
+		noWait:        noWait,
+		timeout:       timeout,
+		skipTests:     skipTests,
+		transactional: transactional,
+		retries:       retries,
+		retryBackoff:  retryBackoff,
+		change:        meta.Change,
+	}
+	action := "bundle deploy"
+	if meta.Env != "" {
+		action += " -e " + meta.Env
+	}
+	plan.change.Cause = release.ChangeCause(action, plan.image(), plan.change)
+
+	var out io.Writer = os.Stdout
+	if ciMode {
+		out = io.Discard
+	}
+	if outputFormat != "json" {
+		fmt.Printf("\nDeploying %s to %s (context: %s)\n\n", meta.App, meta.Namespace, client.Context)
+	}
+	applyResult, err := plan.applyTo(cmd, client, meta.Namespace, result, out, ciMode)
+	if err != nil {
+		return finalize(err)
+	}
+	result.Success = true
+
+	if outputFormat != "json" {
+		fmt.Println()
+		fmt.Printf("Deploy complete: %d created, %d updated\n", len(applyResult.Created), len(applyResult.Updated))
+		if result.Revision > 0 {
+			fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(result.Revision))
+		}
+	}
+	return finalize(nil)
+}
+
+func init() {
+	bundleExportCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	bundleExportCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
+	bundleExportCmd.Flags().Bool("no-images", false, "Leave the images out, for clusters that can pull them")
+
+	for _, c := range []*cobra.Command{bundleImportCmd, bundleDeployCmd} {
+		c.Flags().String("registry", "", "Push the images to this registry (host[:port][/prefix]) and deploy them from it")
+		c.Flags().String("load", "auto", "How to load the images: auto, kind, minikube, ctr, docker or registry")
+	}
+	bundleDeployCmd.Flags().Bool("no-wait", false, "Don't wait for rollout to complete")
+	bundleDeployCmd.Flags().Duration("rollout-timeout", 5*time.Minute, "How long to wait for the rollout to complete (e.g., 10m, 30s)")
+	deprecateTimeout(bundleDeployCmd, "rollout-timeout")
+	bundleDeployCmd.Flags().Bool("skip-tests", false, "Skip the smoke tests of the bundled config")
+	bundleDeployCmd.Flags().Bool("transactional", false, "If any resource fails to apply, restore the ones already applied to their previous state")
+	addRetryFlags(bundleDeployCmd)
+
+	bundleCmd.AddCommand(bundleExportCmd, bundleImportCmd, bundleDeployCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
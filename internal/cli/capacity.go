@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// checkCapacity compares the bundle's resource requests (replicas, or HPA
+// max, times each pod's requests) with the namespace's ResourceQuotas and
+// the nodes' free allocatable capacity, so a deploy fails up front instead
+// of leaving pods Pending. The app's running pods are counted as free, since
+// the deploy replaces them. With force, problems are only warnings. Limits
+// larger than the biggest node are always only warnings. Anything kbox isn't
+// allowed to read is not checked.
+func checkCapacity(ctx context.Context, client *k8s.Client, namespace, app string, bundle *render.Bundle, force bool, errOut io.Writer) error {
+	workloads := bundle.ResourceRequests()
+	if len(workloads) == 0 {
+		return nil
+	}
+	want := corev1.ResourceList{}
+	var pods int64
+	for _, w := range workloads {
+		addResources(want, w.Total())
+		pods += w.Pods
+	}
+	current := appPods(ctx, client, namespace, app)
+
+	var nodes []corev1.Node
+	if list, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+		nodes = list.Items
+	}
+	for _, w := range nodeSizeWarnings(nodes, bundle) {
+		fmt.Fprintf(errOut, "Warning: %s\n", w)
+	}
+
+	problems := quotaProblems(ctx, client, namespace, want, pods, current)
+	problems = append(problems, nodeProblems(ctx, client, nodes, workloads, want, current)...)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if force {
+		for _, p := range problems {
+			fmt.Fprintf(errOut, "Warning: %s\n", p)
+		}
+		return nil
+	}
+	return output.WithCode(output.ErrPolicy, fmt.Errorf(
+		"not enough capacity to schedule %s in %s:\n    - %s\n  → Lower spec.replicas, spec.autoscaling.maxReplicas, or spec.resources\n  → Or use --force to deploy anyway",
+		app, namespace, strings.Join(problems, "\n    - ")))
+}
+
+// quotaProblems checks the namespace's ResourceQuotas for room for the bundle
+func quotaProblems(ctx context.Context, client *k8s.Client, namespace string, want corev1.ResourceList, pods int64, current []corev1.Pod) []string {
+	quotas, err := client.Clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	freed := corev1.ResourceList{}
+	for i := range current {
+		addResources(freed, render.PodRequests(&current[i].Spec))
+	}
+
+	checks := []struct {
+		names []corev1.ResourceName
+		want  resource.Quantity
+		freed resource.Quantity
+		label string
+	}{
+		{[]corev1.ResourceName{corev1.ResourceRequestsCPU, corev1.ResourceCPU}, want[corev1.ResourceCPU], freed[corev1.ResourceCPU], "CPU requests"},
+		{[]corev1.ResourceName{corev1.ResourceRequestsMemory, corev1.ResourceMemory}, want[corev1.ResourceMemory], freed[corev1.ResourceMemory], "memory requests"},
+		{[]corev1.ResourceName{corev1.ResourcePods}, *resource.NewQuantity(pods, resource.DecimalSI), *resource.NewQuantity(int64(len(current)), resource.DecimalSI), "pods"},
+	}
+
+	var problems []string
+	for _, quota := range quotas.Items {
+		for _, c := range checks {
+			for _, name := range c.names {
+				hard, ok := quota.Status.Hard[name]
+				if !ok {
+					continue
+				}
+				free := hard.DeepCopy()
+				free.Sub(quota.Status.Used[name])
+				free.Add(c.freed)
+				if c.want.Cmp(free) > 0 {
+					problems = append(problems, fmt.Sprintf("ResourceQuota %s allows %s more %s, the app needs %s",
+						quota.Name, free.String(), c.label, c.want.String()))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// nodeProblems checks that each workload's pods fit on some node, and that
+// the nodes have enough free capacity for all of them together
+func nodeProblems(ctx context.Context, client *k8s.Client, nodes []corev1.Node, workloads []render.WorkloadRequests, want corev1.ResourceList, current []corev1.Pod) []string {
+	if len(nodes) == 0 {
+		return nil
+	}
+	running, err := client.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil
+	}
+	replaced := make(map[string]bool)
+	for _, pod := range current {
+		replaced[pod.Namespace+"/"+pod.Name] = true
+	}
+	used := make(map[string]corev1.ResourceList)
+	for i, pod := range running.Items {
+		if pod.Spec.NodeName == "" || replaced[pod.Namespace+"/"+pod.Name] {
+			continue
+		}
+		if used[pod.Spec.NodeName] == nil {
+			used[pod.Spec.NodeName] = corev1.ResourceList{}
+		}
+		addResources(used[pod.Spec.NodeName], render.PodRequests(&running.Items[i].Spec))
+	}
+
+	// Free capacity of each node that accepts new pods
+	var free []corev1.ResourceList
+	var freeLabels []map[string]string
+	total := corev1.ResourceList{}
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		avail := corev1.ResourceList{}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			q := node.Status.Allocatable[name].DeepCopy()
+			q.Sub(used[node.Name][name])
+			avail[name] = q
+		}
+		free = append(free, avail)
+		freeLabels = append(freeLabels, node.Labels)
+		addResources(total, avail)
+	}
+
+	var problems []string
+	for _, w := range workloads {
+		fits := false
+		for i, avail := range free {
+			if labels.SelectorFromSet(w.NodeSelector).Matches(labels.Set(freeLabels[i])) && fitsIn(w.PerPod, avail) {
+				fits = true
+				break
+			}
+		}
+		if !fits && len(w.PerPod) > 0 {
+			problems = append(problems, fmt.Sprintf("%s pods request %s, more than any node has free", w.Workload, formatResources(w.PerPod)))
+		}
+	}
+	if !fitsIn(want, total) {
+		problems = append(problems, fmt.Sprintf("the app requests %s in total, the cluster's nodes have %s free",
+			formatResources(want), formatResources(total)))
+	}
+	return problems
+}
+
+// nodeSizeWarnings flags containers whose CPU or memory limit is more than
+// the largest node's allocatable, so they could never use all of it
+func nodeSizeWarnings(nodes []corev1.Node, bundle *render.Bundle) []string {
+	largest := corev1.ResourceList{}
+	for _, node := range nodes {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := node.Status.Allocatable[name]; ok && q.Cmp(largest[name]) > 0 {
+				largest[name] = q
+			}
+		}
+	}
+	if len(largest) == 0 {
+		return nil
+	}
+
+	var warnings []string
+	check := func(workload string, spec *corev1.PodSpec) {
+		for _, c := range spec.Containers {
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				limit, ok := c.Resources.Limits[name]
+				node, known := largest[name]
+				if ok && known && limit.Cmp(node) > 0 {
+					warnings = append(warnings, fmt.Sprintf("%s container %s has a %s %s limit, more than the largest node's %s allocatable",
+						workload, c.Name, limit.String(), name, node.String()))
+				}
+			}
+		}
+	}
+	for _, dep := range bundle.Deployments {
+		check("Deployment/"+dep.Name, &dep.Spec.Template.Spec)
+	}
+	for _, ss := range bundle.StatefulSets {
+		check("StatefulSet/"+ss.Name, &ss.Spec.Template.Spec)
+	}
+	return warnings
+}
+
+// appPods returns the app's running pods, whose resources the deploy frees
+func appPods(ctx context.Context, client *k8s.Client, namespace, app string) []corev1.Pod {
+	seen := make(map[string]bool)
+	var pods []corev1.Pod
+	for _, selector := range render.OwnedSelectors(app) {
+		list, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: selector,
+			FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+		})
+		if err != nil {
+			continue
+		}
+		for _, pod := range list.Items {
+			if !seen[pod.Name] {
+				seen[pod.Name] = true
+				pods = append(pods, pod)
+			}
+		}
+	}
+	return pods
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// addResources adds add to list
+func addResources(list, add corev1.ResourceList) {
+	for name, q := range add {
+		sum := list[name]
+		sum.Add(q)
+		list[name] = sum
+	}
+}
+
+// fitsIn reports whether every request in want is within avail
+func fitsIn(want, avail corev1.ResourceList) bool {
+	for name, q := range want {
+		if q.Cmp(avail[name]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// formatResources formats CPU and memory, e.g. "500m CPU / 1Gi memory"
+func formatResources(list corev1.ResourceList) string {
+	cpu := list[corev1.ResourceCPU]
+	mem := list[corev1.ResourceMemory]
+	return fmt.Sprintf("%s CPU / %s memory", cpu.String(), mem.String())
+}
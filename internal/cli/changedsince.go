@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// changedServices returns the services of a MultiApp affected by what
+// changed in the work tree since ref, printing the deploy or skip decision
+// for each service to out
+func changedServices(ctx context.Context, cfg *config.MultiServiceConfig, ref string, out io.Writer) ([]string, error) {
+	paths, err := changedPaths(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(out, "Files changed since %s: %d\n", ref, len(paths))
+	var affected []string
+	for _, c := range cfg.ChangedServices(paths) {
+		decision := "skip  "
+		if c.Affected {
+			decision = "deploy"
+			affected = append(affected, c.Service)
+		}
+		fmt.Fprintf(out, "  %s  %-20s %s\n", decision, c.Service, c.Reason)
+	}
+	fmt.Fprintln(out)
+	return affected, nil
+}
+
+// changedPaths lists the files that differ between ref and the work tree,
+// relative to the current directory, where kbox.yaml is
+func changedPaths(ctx context.Context, ref string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--relative", ref, "--")
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to list changes since %s: %w\n  → In CI, fetch enough history for the ref to exist (e.g. fetch-depth: 0)", ref, err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/depcheck"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+var checkDepsCmd = &cobra.Command{
+	Use:   "check-deps",
+	Short: "Check that the app can connect to its dependencies",
+	Long: `Check that each dependency in kbox.yaml accepts connections with the
+credentials injected into the app.
+
+kbox check-deps starts a probe pod in the app's namespace, with the env vars
+of the app's deployed container, and runs each dependency's check from it:
+pg_isready and a query for postgres, PING for redis, a ping command for
+mongodb and a query for mysql. Custom dependencies are checked with their
+template's checkCommand, or else by opening their port. It reports how long
+each check took, and tells rejected credentials apart from servers it
+couldn't reach. The probe pod is deleted afterwards.
+
+The probe pod isn't one of the app's pods, so a NetworkPolicy that only
+lets the app's pods through isn't what it tests.
+
+Examples:
+  kbox check-deps                # Check the app in kbox.yaml
+  kbox check-deps -e prod        # Check the prod environment
+  kbox check-deps -o json`,
+	Args:        cobra.NoArgs,
+	RunE:        runCheckDeps,
+	Annotations: map[string]string{writesAnnotation: "create pods"},
+}
+
+func runCheckDeps(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	env, _ := cmd.Flags().GetString("env")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	loader := config.NewLoader(".")
+	if isMulti, _ := loader.IsMultiService(); isMulti {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("a MultiApp's services have no dependencies to check\n  → Run kbox check-deps in an App's directory"))
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err))
+	}
+	if env != "" {
+		kubeContext, namespace = resolveEnvTarget(cmd, env, cfg.EnvironmentTarget(env), kubeContext, namespace)
+		cfg = cfg.ForEnvironment(env)
+	}
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+	}
+	if len(cfg.Spec.Dependencies) == 0 {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%s has no dependencies to check\n  → Add one with 'kbox add postgres'", cfg.Metadata.Name))
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err))
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+
+	app, err := deployedPodSpec(cmd, client, namespace, cfg)
+	if err != nil {
+		return err
+	}
+
+	targets := depcheck.Targets(cfg)
+	if !jsonOutput {
+		fmt.Printf("Checking %d dependencies of %s in %s from a probe pod...\n\n", len(targets), cfg.Metadata.Name, namespace)
+	}
+	results, err := depcheck.Run(cmd.Context(), client.Clientset, depcheck.ProbePod(cfg.Metadata.Name, namespace, app, targets), targets)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Status != depcheck.StatusOK && r.Status != depcheck.StatusSkipped {
+			failed++
+		}
+	}
+	var checkErr error
+	if failed > 0 {
+		checkErr = fmt.Errorf("%d of %d dependencies failed their check\n  → Run 'kbox describe' or 'kbox logs' on the dependency to see its side", failed, len(results))
+	}
+
+	if jsonOutput {
+		if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":      failed == 0,
+			"app":          cfg.Metadata.Name,
+			"namespace":    namespace,
+			"dependencies": results,
+		}); err != nil {
+			return err
+		}
+		return checkErr
+	}
+
+	for _, r := range results {
+		mark := "✓"
+		switch r.Status {
+		case depcheck.StatusSkipped:
+			mark = "-"
+		case depcheck.StatusOK:
+		default:
+			mark = "✗"
+		}
+		latency := ""
+		if r.Status != depcheck.StatusSkipped {
+			latency = fmt.Sprintf("%dms", r.LatencyMs)
+		}
+		line := fmt.Sprintf("  %s %-12s %-12s %8s", mark, r.Dependency, r.Status, latency)
+		if r.Message != "" {
+			line += "  " + r.Message
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	fmt.Println()
+	return checkErr
+}
+
+// deployedPodSpec returns the pod spec of the app's deployed workload, whose
+// env the probe pod connects with
+func deployedPodSpec(cmd *cobra.Command, client *k8s.Client, namespace string, cfg *config.AppConfig) (*corev1.PodSpec, error) {
+	name := cfg.Metadata.Name
+	var spec *corev1.PodSpec
+	var err error
+	if cfg.Spec.IsStatefulSet() {
+		sts, getErr := client.Clientset.AppsV1().StatefulSets(namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			spec = &sts.Spec.Template.Spec
+		}
+	} else {
+		dep, getErr := client.Clientset.AppsV1().Deployments(namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			spec = &dep.Spec.Template.Spec
+		}
+	}
+	if k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%s isn't deployed in %s, so there are no injected credentials to check\n  → Run 'kbox deploy' first", name, namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in %s: %w", name, namespace, err)
+	}
+	return spec, nil
+}
+
+func init() {
+	checkDepsCmd.Flags().StringP("env", "e", "", "Environment whose context/namespace binding to use")
+	rootCmd.AddCommand(checkDepsCmd)
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+)
+
+// completionTimeout bounds cluster lookups so a slow API server never stalls the shell
+const completionTimeout = 2 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for kbox.
+
+Completions include environment names from kbox.yaml, job names for
+'kbox job run/logs', service names, and context/namespace names from
+your kubeconfig.
+
+Bash:
+  source <(kbox completion bash)
+  # Load for every session (Linux):
+  kbox completion bash > /etc/bash_completion.d/kbox
+  # Load for every session (macOS):
+  kbox completion bash > $(brew --prefix)/etc/bash_completion.d/kbox
+
+Zsh:
+  # Enable completion once if it is not already:
+  echo "autoload -U compinit; compinit" >> ~/.zshrc
+  kbox completion zsh > "${fpath[1]}/_kbox"
+
+Fish:
+  kbox completion fish > ~/.config/fish/completions/kbox.fish
+
+PowerShell:
+  kbox completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell %q\n  → Use one of: bash, zsh, fish, powershell", args[0])
+	},
+}
+
+// appArgCommands take an app name as their first positional argument
+var appArgCommands = map[string]bool{
+	"connect":   true,
+	"dashboard": true,
+	"events":    true,
+	"history":   true,
+	"intercept": true,
+	"logs":      true,
+	"pf":        true,
+	"rollback":  true,
+	"share":     true,
+	"shell":     true,
+	"status":    true,
+	"top":       true,
+	"why":       true,
+}
+
+// registerDynamicCompletions wires flag and argument completions across the command tree.
+// Flags are matched by name so every command defining --env, --service, etc. gets them.
+func registerDynamicCompletions(cmd *cobra.Command) {
+	flagCompletions := map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		"env":       completeEnvironments,
+		"service":   completeServices,
+		"context":   completeContexts,
+		"namespace": completeNamespaces,
+	}
+	for name, fn := range flagCompletions {
+		if cmd.Flags().Lookup(name) == nil && cmd.PersistentFlags().Lookup(name) == nil {
+			continue
+		}
+		if _, exists := cmd.GetFlagCompletionFunc(name); exists {
+			continue
+		}
+		// Persistent flags are shared with children; registering twice is an error we can ignore
+		_ = cmd.RegisterFlagCompletionFunc(name, fn)
+	}
+
+	if appArgCommands[cmd.Name()] && cmd.Parent() == rootCmd && cmd.ValidArgsFunction == nil {
+		cmd.ValidArgsFunction = completeAppNames
+	}
+
+	for _, child := range cmd.Commands() {
+		registerDynamicCompletions(child)
+	}
+}
+
+// completeEnvironments suggests environment names defined in kbox.yaml
+func completeEnvironments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	loader := config.NewLoader(".")
+	var envs []string
+	if multi, err := loader.IsMultiService(); err == nil && multi {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		for name := range cfg.Environments {
+			envs = append(envs, name)
+		}
+	} else {
+		cfg, err := loader.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		for name := range cfg.Environments {
+			envs = append(envs, name)
+		}
+	}
+	return filterCompletions(envs, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeServices suggests service names from a multi-service kbox.yaml
+func completeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return filterCompletions(serviceNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeContexts suggests context names from kubeconfig
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	contexts, err := k8s.ListContexts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(contexts, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNamespaces suggests namespaces from the cluster, falling back to the
+// namespaces referenced in kubeconfig when the cluster is unreachable
+func completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kubeContext, _ := cmd.Flags().GetString("context")
+
+	if client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext}); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+		if list, err := client.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err == nil {
+			names := make([]string, 0, len(list.Items))
+			for _, ns := range list.Items {
+				names = append(names, ns.Name)
+			}
+			return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	namespaces, err := k8s.ContextNamespaces()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(namespaces, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAppNames suggests the app (or per-service app names) from kbox.yaml
+func completeAppNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	loader := config.NewLoader(".")
+	var names []string
+	if multi, err := loader.IsMultiService(); err == nil && multi {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		for svc := range cfg.Services {
+			names = append(names, cfg.Metadata.Name+"-"+svc)
+		}
+	} else if cfg, err := loader.Load(); err == nil {
+		names = append(names, cfg.Metadata.Name)
+	}
+	return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeJobNames suggests jobs from kbox.yaml; runnableOnly excludes CronJobs
+func completeJobNames(runnableOnly bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg, err := config.NewLoader(".").Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, job := range cfg.Spec.Jobs {
+			if runnableOnly && job.Schedule != "" {
+				continue
+			}
+			names = append(names, job.Name)
+		}
+		return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeDependencyTypes suggests the dependencies in kbox.yaml
+func completeDependencyTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := config.NewLoader(".").Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var types []string
+	for _, dep := range cfg.Spec.Dependencies {
+		types = append(types, dep.Type)
+	}
+	return filterCompletions(types, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// serviceNames returns the service names of a multi-service kbox.yaml, if any
+func serviceNames() []string {
+	loader := config.NewLoader(".")
+	if multi, err := loader.IsMultiService(); err != nil || !multi {
+		return nil
+	}
+	cfg, err := loader.LoadMultiService()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	return names
+}
+
+// filterCompletions returns the sorted candidates matching the typed prefix
+func filterCompletions(candidates []string, toComplete string) []string {
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
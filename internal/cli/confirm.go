@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// confirmation is a change kbox asks about before making it
+type confirmation struct {
+	// Action names the change in errors, e.g. `delete 12 resources of "myapp"`
+	Action string
+
+	// Details are printed before the question
+	Details []string
+
+	// Typed, for high-risk changes such as deleting data, is what the user
+	// has to type to go ahead; otherwise y or yes does
+	Typed string
+}
+
+// interactive reports whether kbox can ask the user something: not in CI or
+// JSON mode, and with a terminal on stdin
+func interactive(cmd *cobra.Command) bool {
+	return !IsCIMode(cmd) && GetOutputFormat(cmd) != "json" && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm asks before the change c describes and returns nil to go ahead.
+// --yes answers for the user. Without a terminal to ask on (CI, JSON output,
+// piped stdin) it fails closed, asking for --yes, instead of waiting on
+// stdin; a declined question is a cancelled error.
+func confirm(cmd *cobra.Command, c confirmation) error {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return nil
+	}
+	if !interactive(cmd) {
+		return fmt.Errorf("%s needs confirmation\n  → Re-run with --yes to confirm", c.Action)
+	}
+
+	for _, line := range c.Details {
+		fmt.Println(line)
+	}
+	if c.Typed != "" {
+		fmt.Printf("\nType %q to continue: ", c.Typed)
+	} else {
+		fmt.Print("\nContinue? [y/N] ")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(response)
+	confirmed := response == c.Typed
+	if c.Typed == "" {
+		response = strings.ToLower(response)
+		confirmed = response == "y" || response == "yes"
+	}
+	if !confirmed {
+		return output.WithCode(output.ErrCancelled, fmt.Errorf("%s cancelled", c.Action))
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/render"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect [service] [-- command...]",
+	Short: "Connect a local process to in-cluster dependencies",
+	Long: `Port-forward the app's dependencies and expose them as local env vars.
+
+Run your app natively on your laptop against the postgres/redis running in
+the cluster, without changing connection strings. Each dependency from
+spec.dependencies is forwarded to localhost (on its usual port when free),
+and the env vars kbox injects in-cluster (DATABASE_URL, REDIS_URL, ...) are
+rendered to point at the forwards.
+
+For a MultiApp kbox.yaml, name the service you will run locally: the
+services it dependsOn are forwarded and exported as <SERVICE>_URL.
+
+The forwards stay up until Ctrl+C. Pass a command after -- to run it with
+the env vars set; kbox disconnects when it exits.
+
+Examples:
+  kbox connect                          # Forward dependencies, print env vars
+  kbox connect --env-file               # Also write them to .env.local
+  kbox connect --env-file .env.dev      # Write to a specific file
+  kbox connect -- npm run dev           # Run the app with the env vars set
+  kbox connect web -- go run ./cmd/web  # MultiApp: forward web's dependencies`,
+	RunE:        runConnect,
+	Annotations: map[string]string{writesAnnotation: "create pods/portforward"},
+}
+
+func init() {
+	connectCmd.Flags().String("env-file", "", "Write env vars to a dotenv file (default .env.local)")
+	connectCmd.Flags().Lookup("env-file").NoOptDefVal = ".env.local"
+	rootCmd.AddCommand(connectCmd)
+}
+
+// connectTarget is an in-cluster workload to forward and the env vars it provides
+type connectTarget struct {
+	Name       string // display name
+	App        string // app label of the pods
+	RemotePort int
+	LocalPort  int
+
+	// env renders the env vars once the local port is known
+	env func(localPort int) map[string]string
+}
+
+func runConnect(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	envFile, _ := cmd.Flags().GetString("env-file")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+	quiet := IsCIMode(cmd) || jsonOutput
+
+	// Split off the command to run after --
+	var command []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		command = args[dash:]
+		args = args[:dash]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("expected at most one service, got %d\n  → Put the command to run after --", len(args))
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	targets, err := resolveConnectTargets(ctx, client, ns, args)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("nothing to connect to\n  → Add a dependency with 'kbox add postgres'")
+	}
+
+	// Handle Ctrl+C
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			if command == nil {
+				fmt.Fprintln(os.Stderr, "\nDisconnecting...")
+			}
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	env := make(map[string]string)
+	for i := range targets {
+		t := &targets[i]
+		t.LocalPort = localPortFor(t.RemotePort)
+		if err := forwardToApp(ctx, client, ns, t.App, debug.PodSelector{}, t.LocalPort, t.RemotePort); err != nil {
+			return err
+		}
+		for k, v := range t.env(t.LocalPort) {
+			env[k] = v
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "  ✓ %s → localhost:%d\n", t.Name, t.LocalPort)
+		}
+	}
+
+	if envFile != "" {
+		if err := os.WriteFile(envFile, []byte(formatDotenv(env)), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", envFile, err)
+		}
+		fmt.Fprintf(os.Stderr, "  ✓ Wrote %d env vars to %s\n", len(env), envFile)
+	}
+
+	// Run the given command with the env vars, then disconnect
+	if command != nil {
+		return runConnected(ctx, command, env)
+	}
+
+	if jsonOutput {
+		forwards := make(map[string]int, len(targets))
+		for _, t := range targets {
+			forwards[t.Name] = t.LocalPort
+		}
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   true,
+			"namespace": ns,
+			"forwards":  forwards,
+			"env":       env,
+		})
+	} else {
+		fmt.Fprintln(os.Stderr)
+		fmt.Print(formatDotenv(env))
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Connected. Press Ctrl+C to disconnect")
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// resolveConnectTargets builds the workloads to forward from kbox.yaml
+func resolveConnectTargets(ctx context.Context, client *k8s.Client, ns string, args []string) ([]connectTarget, error) {
+	loader := config.NewLoader(".")
+
+	if isMulti, _ := loader.IsMultiService(); isMulti {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kbox.yaml: %w", err)
+		}
+		names := make([]string, 0, len(cfg.Services))
+		for name := range cfg.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("name the service you will run locally\n  → One of: %s", strings.Join(names, ", "))
+		}
+		svc, ok := cfg.Services[args[0]]
+		if !ok {
+			return nil, fmt.Errorf("service %q not found in kbox.yaml\n  → One of: %s", args[0], strings.Join(names, ", "))
+		}
+
+		var targets []connectTarget
+		for _, dep := range svc.DependsOn {
+			envName := fmt.Sprintf("%s_URL", render.ToEnvName(dep))
+			targets = append(targets, connectTarget{
+				Name:       dep,
+				App:        fmt.Sprintf("%s-%s", cfg.Metadata.Name, dep),
+				RemotePort: cfg.Services[dep].Port,
+				env: func(localPort int) map[string]string {
+					return map[string]string{envName: fmt.Sprintf("http://localhost:%d", localPort)}
+				},
+			})
+		}
+		return targets, nil
+	}
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("service arguments only apply to a MultiApp kbox.yaml")
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kbox.yaml: %w\n  → Run 'kbox init' first", err)
+	}
+
+	var targets []connectTarget
+	for _, dep := range cfg.Spec.Dependencies {
+		// External servers aren't in the cluster to forward to
+		if dep.External != nil {
+			continue
+		}
+		template, ok := dependencies.Get(dep.Type)
+		if !ok {
+			return nil, fmt.Errorf("unsupported dependency type: %s\n  → Supported: %v", dep.Type, dependencies.SupportedTypes())
+		}
+		serviceName := fmt.Sprintf("%s-%s", cfg.Metadata.Name, dep.Type)
+
+		// The generated password lives in the dependency's secret
+		password := ""
+		if len(template.SecretKeys) > 0 {
+			secret, err := client.Clientset.CoreV1().Secrets(ns).Get(ctx, serviceName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read credentials for %s: %w\n  → Is the app deployed? Try 'kbox up' first", dep.Type, err)
+			}
+			password = string(secret.Data[template.SecretKeys[0]])
+		}
+
+		targets = append(targets, connectTarget{
+			Name:       dep.Type,
+			App:        serviceName,
+			RemotePort: int(template.DefaultPort),
+			env: func(localPort int) map[string]string {
+				return dependencies.RenderLocalEnvVars(template, localPort, password)
+			},
+		})
+	}
+	return targets, nil
+}
+
+// localPortFor prefers the dependency's own port so connection strings look
+// familiar, falling back to any free port when it is taken
+func localPortFor(port int) int {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err == nil {
+		listener.Close()
+		return port
+	}
+	if free, err := findAvailablePort(); err == nil {
+		return free
+	}
+	return port
+}
+
+// runConnected runs command with env added to the current environment
+func runConnected(ctx context.Context, command []string, env map[string]string) error {
+	c := exec.CommandContext(ctx, command[0], command[1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	for k, v := range env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+	if err := c.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", command[0], err)
+	}
+	return nil
+}
+
+// formatDotenv renders env vars as sorted KEY=value lines
+func formatDotenv(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := env[k]
+		if strings.ContainsAny(v, " \t#\"'") {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", k, v)
+	}
+	return b.String()
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/tui"
+)
+
+var ctxCmd = &cobra.Command{
+	Use:   "ctx [context]",
+	Short: "Show or switch the project's Kubernetes context",
+	Long: `Show or switch the Kubernetes context used by kbox in this project.
+
+The choice is saved to .kbox/state in the current directory, so every kbox
+command run from this project targets that context without touching your
+global kubeconfig. An explicit --context flag still wins.
+
+Without an argument, an interactive picker is shown (or the list of
+contexts when not running in a terminal).
+
+Examples:
+  kbox ctx                 # Pick a context interactively
+  kbox ctx kind-dev        # Use kind-dev for this project
+  kbox ctx --list          # List contexts
+  kbox ctx --unset         # Go back to the kubeconfig current context`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContexts,
+	RunE:              runCtx,
+}
+
+var nsCmd = &cobra.Command{
+	Use:   "ns [namespace]",
+	Short: "Show or switch the project's Kubernetes namespace",
+	Long: `Show or switch the namespace used by kbox in this project.
+
+The choice is saved to .kbox/state in the current directory and applies to
+every kbox command run from this project, as if -n were passed. An explicit
+--namespace flag still wins.
+
+Without an argument, an interactive picker is shown (or the list of
+namespaces when not running in a terminal).
+
+Examples:
+  kbox ns                  # Pick a namespace interactively
+  kbox ns staging          # Use staging for this project
+  kbox ns --list           # List namespaces
+  kbox ns --unset          # Go back to the kubeconfig namespace`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeNamespaces,
+	RunE:              runNs,
+}
+
+func runCtx(cmd *cobra.Command, args []string) error {
+	list, _ := cmd.Flags().GetBool("list")
+	unset, _ := cmd.Flags().GetBool("unset")
+
+	state, err := config.LoadState(".")
+	if err != nil {
+		return err
+	}
+
+	if unset {
+		state.Context = ""
+		if err := config.SaveState(".", state); err != nil {
+			return err
+		}
+		return printSelection(cmd, "context", "", "Cleared project context (using kubeconfig current context)")
+	}
+
+	contexts, err := k8s.ListContexts()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
+	}
+	current := state.Context
+	if current == "" {
+		current, _ = k8s.CurrentContext()
+	}
+
+	var target string
+	switch {
+	case len(args) > 0:
+		target = args[0]
+	case list || !interactive(cmd):
+		return printChoices(cmd, "contexts", contexts, current)
+	default:
+		if len(contexts) == 0 {
+			return fmt.Errorf("no contexts found in kubeconfig\n  → Create a cluster (e.g. 'kind create cluster') or set KUBECONFIG")
+		}
+		target, err = tui.Pick("Select Kubernetes context", contexts, current)
+		if errors.Is(err, tui.ErrPickerCancelled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if ok, _ := k8s.HasContext(target); !ok {
+		return fmt.Errorf("context %q not found in kubeconfig\n  → Run 'kbox ctx --list' to see available contexts", target)
+	}
+
+	state.Context = target
+	if err := config.SaveState(".", state); err != nil {
+		return err
+	}
+	return printSelection(cmd, "context", target, fmt.Sprintf("Using context %q for this project", target))
+}
+
+func runNs(cmd *cobra.Command, args []string) error {
+	kubeContext, _ := cmd.Flags().GetString("context")
+	list, _ := cmd.Flags().GetBool("list")
+	unset, _ := cmd.Flags().GetBool("unset")
+
+	state, err := config.LoadState(".")
+	if err != nil {
+		return err
+	}
+
+	if unset {
+		state.Namespace = ""
+		if err := config.SaveState(".", state); err != nil {
+			return err
+		}
+		return printSelection(cmd, "namespace", "", "Cleared project namespace (using kubeconfig namespace)")
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
+	}
+	current := state.Namespace
+	if current == "" {
+		current = client.Namespace
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+	defer cancel()
+
+	nsList, err := client.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	namespaces := make([]string, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		namespaces = append(namespaces, ns.Name)
+	}
+
+	var target string
+	switch {
+	case len(args) > 0:
+		target = args[0]
+	case list || !interactive(cmd):
+		return printChoices(cmd, "namespaces", namespaces, current)
+	default:
+		target, err = tui.Pick(fmt.Sprintf("Select namespace (%s)", client.Context), namespaces, current)
+		if errors.Is(err, tui.ErrPickerCancelled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	found := false
+	for _, ns := range namespaces {
+		if ns == target {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("namespace %q not found in context %q\n  → Create it with 'kubectl create namespace %s' or run 'kbox ns --list'", target, client.Context, target)
+	}
+
+	state.Namespace = target
+	if err := config.SaveState(".", state); err != nil {
+		return err
+	}
+	return printSelection(cmd, "namespace", target, fmt.Sprintf("Using namespace %q for this project", target))
+}
+
+// printChoices lists the available items, marking the active one
+func printChoices(cmd *cobra.Command, kind string, items []string, current string) error {
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			kind:      items,
+			"current": current,
+		})
+	}
+	for _, item := range items {
+		if item == current {
+			fmt.Printf("* %s\n", item)
+		} else {
+			fmt.Printf("  %s\n", item)
+		}
+	}
+	return nil
+}
+
+// printSelection reports the saved project default
+func printSelection(cmd *cobra.Command, kind, value, message string) error {
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			kind:      value,
+		})
+	}
+	fmt.Printf("  ✓ %s\n", message)
+	return nil
+}
+
+// applyProjectDefaults fills --context and --namespace from .kbox/state when not given
+func applyProjectDefaults(cmd *cobra.Command) error {
+	state, err := config.LoadState(".")
+	if err != nil {
+		return err
+	}
+	for name, value := range map[string]string{"context": state.Context, "namespace": state.Namespace} {
+		flag := cmd.Flags().Lookup(name)
+		if value == "" || flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{ctxCmd, nsCmd} {
+		c.Flags().BoolP("list", "l", false, "List available choices")
+		c.Flags().Bool("unset", false, "Clear the project default")
+		rootCmd.AddCommand(c)
+	}
+}
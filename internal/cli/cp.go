@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/spf13/cobra"
+)
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <src> <dst>",
+	Short: "Copy files to and from an app's container",
+	Long: `Copy files or directories between your machine and a running container.
+
+One side is a local path, the other is <app>:<path> (or <pod>:<path>).
+Like kbox shell, the pod is picked automatically from the app label and
+the main container is detected unless --container is given.
+
+Files are streamed with tar over exec, so the container image needs tar.
+
+Examples:
+  kbox cp myapp:/tmp/heap.hprof .             # Pull a heap dump
+  kbox cp ./fixtures myapp:/app/fixtures      # Push a directory
+  kbox cp seed.sql myapp:/tmp/                # Copy into a directory
+  kbox cp myapp:/var/log/app.log ./logs -c sidecar`,
+	Args:        cobra.ExactArgs(2),
+	RunE:        runCp,
+	Annotations: map[string]string{writesAnnotation: "create pods/exec"},
+}
+
+func runCp(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	container, _ := cmd.Flags().GetString("container")
+
+	src, err := debug.ParseCopySpec(args[0])
+	if err != nil {
+		return err
+	}
+	dst, err := debug.ParseCopySpec(args[1])
+	if err != nil {
+		return err
+	}
+	if src.IsRemote() == dst.IsRemote() {
+		return fmt.Errorf("one side must be local and the other <app>:<path>\n  → e.g. 'kbox cp myapp:/tmp/heap.hprof .'")
+	}
+	remote := src
+	if dst.IsRemote() {
+		remote = dst
+	}
+
+	// Set up signal handling for graceful cancellation
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Create K8s client
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	// Find pods for the app
+	pods, err := debug.FindPods(ctx, client.Clientset, ns, remote.Pod)
+	if err != nil {
+		return err
+	}
+
+	// Pick the first ready pod, or just the first one
+	var targetPod debug.PodInfo
+	for _, p := range pods {
+		if p.Ready {
+			targetPod = p
+			break
+		}
+	}
+	if targetPod.Name == "" {
+		targetPod = pods[0]
+	}
+
+	if container == "" {
+		container, err = debug.GetPodContainer(ctx, client.Clientset, ns, targetPod.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dst.IsRemote() {
+		if err := debug.CopyToPod(ctx, client.Clientset, client.RestConfig, ns, targetPod.Name, container, src.Path, dst.Path); err != nil {
+			return fmt.Errorf("failed to copy to %s: %w", targetPod.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "  ✓ Copied %s → %s:%s\n", src.Path, targetPod.Name, dst.Path)
+		return nil
+	}
+
+	if err := debug.CopyFromPod(ctx, client.Clientset, client.RestConfig, ns, targetPod.Name, container, src.Path, dst.Path); err != nil {
+		return fmt.Errorf("failed to copy from %s: %w", targetPod.Name, err)
+	}
+	fmt.Fprintf(os.Stderr, "  ✓ Copied %s:%s → %s\n", targetPod.Name, src.Path, dst.Path)
+	return nil
+}
+
+func init() {
+	cpCmd.Flags().StringP("container", "c", "", "Container name (auto-detected if not specified)")
+	rootCmd.AddCommand(cpCmd)
+}
@@ -1,14 +1,23 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/graph"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/bobbyrathoree/kbox/internal/tui"
+	"github.com/bobbyrathoree/kbox/internal/web"
 )
 
 var dashboardCmd = &cobra.Command{
@@ -30,10 +39,19 @@ Controls:
   ?         Show help
   q         Quit
 
+With --web, serve the same data as a read-only web page instead: deployment
+status, pods, streaming logs, events, release history and (with a kbox.yaml)
+the topology graph. Handy for sharing a status link during an incident. The
+page has no authentication, so it listens on localhost unless --addr says
+otherwise.
+
 Examples:
   kbox dashboard              # Auto-detect from kbox.yaml
   kbox dashboard myapp        # Monitor specific app
-  kbox dashboard -n staging   # Monitor in specific namespace`,
+  kbox dashboard -n staging   # Monitor in specific namespace
+  kbox dashboard --web        # Open http://127.0.0.1:8090 in a browser
+  kbox dashboard --web --addr 0.0.0.0:8090
+  kbox dashboard --output-file logs/myapp.log  # Also save the streamed logs`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runDashboard,
 }
@@ -42,7 +60,11 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 
+	webMode, _ := cmd.Flags().GetBool("web")
+	addr, _ := cmd.Flags().GetString("addr")
+
 	var appName string
+	var topology *graph.Topology
 
 	// Get app name from args or kbox.yaml
 	if len(args) > 0 {
@@ -59,6 +81,9 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 					if namespace == "" {
 						namespace = cfg.Metadata.Namespace
 					}
+					if t, err := graph.BuildFromMultiConfig(cfg); err == nil {
+						topology = t
+					}
 				}
 			} else {
 				cfg, err := loader.Load()
@@ -67,6 +92,9 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 					if namespace == "" {
 						namespace = cfg.Metadata.Namespace
 					}
+					if t, err := graph.BuildFromConfig(cfg); err == nil {
+						topology = t
+					}
 				}
 			}
 		}
@@ -93,21 +121,78 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 		ns = namespace
 	}
 
+	if webMode {
+		source := &web.ClusterSource{Client: client.Clientset, Namespace: ns, App: appName}
+		return serveWebDashboard(cmd.Context(), addr, web.New(appName, ns, source, topology))
+	}
+
 	// Create and run the TUI
-	model := tui.NewDashboard(client, appName, ns)
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
+	model := tui.NewDashboard(cmd.Context(), client, appName, ns).WithPodSelector(sel)
+	logFile, err := openLogFile(cmd)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+		model = model.WithLogFile(logFile)
+	}
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
+		tea.WithContext(cmd.Context()),
 	)
 
 	if _, err := p.Run(); err != nil {
+		if ctxErr := cmd.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return fmt.Errorf("dashboard error: %w", err)
 	}
 
 	return nil
 }
 
+// serveWebDashboard serves the web dashboard until interrupted
+func serveWebDashboard(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	fmt.Fprintf(os.Stderr, "Dashboard at http://%s (Ctrl+C to stop)\n", addr)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("dashboard server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Log streams never finish on their own, so don't wait long for them
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	return nil
+}
+
 func init() {
+	dashboardCmd.Flags().Bool("web", false, "Serve a read-only web dashboard instead of the terminal UI")
+	dashboardCmd.Flags().String("addr", "127.0.0.1:8090", "Address for the web dashboard (with --web)")
+	addLogFileFlags(dashboardCmd)
+	addPodSelectorFlags(dashboardCmd)
 	rootCmd.AddCommand(dashboardCmd)
 }
@@ -1,19 +1,25 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bobbyrathoree/kbox/internal/apply"
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/diagnose"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/notify"
 	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/release"
 	"github.com/bobbyrathoree/kbox/internal/render"
+	"github.com/bobbyrathoree/kbox/internal/smoke"
 )
 
 var deployCmd = &cobra.Command{
@@ -24,24 +30,52 @@ var deployCmd = &cobra.Command{
 This command:
   1. Renders Kubernetes manifests from kbox.yaml
   2. Applies them using Server-Side Apply (SSA)
-  3. Waits for the deployment to complete
+  3. Waits for the deployment, and its Jobs (e.g. migrations), to complete
+  4. Runs smoke tests from spec.tests (if any) as a go/no-go gate
 
 Examples:
-  kbox deploy              # Deploy with default environment
-  kbox deploy -e prod      # Deploy with prod environment overlay
-  kbox deploy --dry-run    # Show what would be deployed`,
-	RunE: runDeploy,
+  kbox deploy                  # Deploy with default environment
+  kbox deploy -e prod          # Deploy with prod environment overlay
+  kbox deploy -e prod --yes    # Skip confirmation for a protected environment
+  kbox deploy --dry-run        # Show what would be deployed
+  kbox deploy --auto-rollback  # Restore previous release if tests fail
+  kbox deploy --ci --artifacts-dir out/  # Save migration logs for CI to archive
+  kbox deploy --force          # Deploy even if the quota or nodes can't fit the pods
+  kbox deploy --all-clusters   # Deploy to each cluster in 'clusters:' in turn
+  kbox deploy --all-clusters --parallel  # Deploy to all clusters at once
+  kbox deploy --changed-since origin/main  # Deploy only the services changed since main
+  kbox deploy -e prod --set spec.image=myapp:$GIT_SHA  # Override a value without editing kbox.yaml`,
+	RunE:        runDeploy,
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
 	env, _ := cmd.Flags().GetString("env")
+	return deploy(cmd, env, nil)
+}
+
+// deploy deploys the config's env environment, with the image kbox promote
+// carries over when promoted isn't nil. Flags cmd doesn't have are unset.
+func deploy(cmd *cobra.Command, env string, promoted *promotion) error {
 	configFile, _ := cmd.Flags().GetString("file")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	noWait, _ := cmd.Flags().GetBool("no-wait")
-	timeout, _ := cmd.Flags().GetDuration("timeout")
+	timeout, _ := cmd.Flags().GetDuration("rollout-timeout")
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	prune, _ := cmd.Flags().GetBool("prune")
+	skipTests, _ := cmd.Flags().GetBool("skip-tests")
+	autoRollback, _ := cmd.Flags().GetBool("auto-rollback")
+	transactional, _ := cmd.Flags().GetBool("transactional")
+	allClusters, _ := cmd.Flags().GetBool("all-clusters")
+	parallel, _ := cmd.Flags().GetBool("parallel")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
+	force, _ := cmd.Flags().GetBool("force")
+	artifactsDir, _ := cmd.Flags().GetString("artifacts-dir")
+	rotateSecrets, _ := cmd.Flags().GetStringArray("rotate-secret")
+	changedSince, _ := cmd.Flags().GetString("changed-since")
 
 	// CI mode and output format
 	ciMode := IsCIMode(cmd)
@@ -53,18 +87,35 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		Success: false,
 	}
 
+	// Set once the deploy reaches the cluster; failures before that aren't announced
+	var notifications *config.NotificationsConfig
+	var event *notify.Event
+	var status *notify.StatusWebhook
+
 	// Helper to finalize and return
 	finalize := func(err error) error {
+		finishStatusWebhook(status, err)
 		result.DurationMs = timer.ElapsedMs()
 		if err != nil {
 			result.Error = err.Error()
+			result.ErrorCode = output.CodeOf(err)
+		}
+
+		if event != nil {
+			event.Revision = result.Revision
+			event.Duration = time.Duration(result.DurationMs) * time.Millisecond
+			event.Succeeded = err == nil
+			if err != nil {
+				event.Error = firstLine(err.Error())
+			}
+			sendNotification(cmd, notifications, *event)
 		}
 
 		// JSON output
 		if outputFormat == "json" {
 			output.NewWriter(os.Stdout, outputFormat, ciMode).WriteDeployResult(result)
 			if !result.Success {
-				os.Exit(1)
+				return output.Reported(err)
 			}
 			return nil
 		}
@@ -72,23 +123,59 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if parallel && !allClusters {
+		return finalize(fmt.Errorf("--parallel requires --all-clusters"))
+	}
+	if allClusters && cmd.Flags().Changed("context") {
+		return finalize(fmt.Errorf("--context cannot be combined with --all-clusters\n  → Clusters and their contexts come from 'clusters:' in kbox.yaml"))
+	}
+	if changedSince != "" && prune {
+		return finalize(fmt.Errorf("--prune cannot be combined with --changed-since\n  → Pruning would delete the services that are skipped"))
+	}
+
+	if !dryRun {
+		var err error
+		if status, err = startStatusWebhook(cmd, "deploy"); err != nil {
+			return finalize(err)
+		}
+		status.Stage("render", 0)
+	}
+
 	// Load config
 	loader := config.NewLoader(".")
 
-	// If specific file provided, load directly (skip multi-service detection)
+	// A specific file is always a single-service config (skip multi-service detection)
+	isMulti := false
+	configName := "kbox.yaml"
 	if configFile != "" {
-		return deployFromFile(cmd, configFile, env, namespace, kubeContext, dryRun, noWait, prune, timeout, ciMode, outputFormat, timer)
+		configName = configFile
+	} else {
+		var err error
+		isMulti, err = loader.IsMultiService()
+		if err != nil {
+			return finalize(fmt.Errorf("failed to load kbox.yaml: %w\n  → Run 'kbox init' to create one, or use 'kbox up' for zero-config deploy", err))
+		}
 	}
 
-	// Check if this is a multi-service config
-	isMulti, err := loader.IsMultiService()
-	if err != nil {
-		return finalize(fmt.Errorf("failed to load kbox.yaml: %w\n  → Run 'kbox init' to create one, or use 'kbox up' for zero-config deploy", err))
+	plan := &deployPlan{
+		noWait:        noWait,
+		timeout:       timeout,
+		prune:         prune,
+		skipTests:     skipTests,
+		autoRollback:  autoRollback,
+		transactional: transactional,
+		concurrency:   concurrency,
+		retries:       retries,
+		retryBackoff:  retryBackoff,
+		force:         force,
+		artifactsDir:  artifactsDir,
+		fleet:         allClusters,
+		rotateSecrets: rotateSecrets,
 	}
-
-	var bundle *render.Bundle
-	var appName string
-	var targetNamespace string
+	// envTarget is the environment's context/namespace binding from kbox.yaml
+	var envTarget config.EnvTarget
+	// clusters is the fleet for --all-clusters
+	var clusters []config.ClusterConfig
 
 	if isMulti {
 		// Handle multi-service config
@@ -97,37 +184,89 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 			return finalize(fmt.Errorf("failed to load kbox.yaml: %w", err))
 		}
 
-		appName = multiCfg.Metadata.Name
-		result.App = appName
+		plan.appName = multiCfg.Metadata.Name
+		result.App = plan.appName
+		status.SetApp(plan.appName)
+		clusters = multiCfg.Clusters
+		notifications = multiCfg.Notifications
 
-		// Apply environment overlay
+		// Apply environment overlay and its cluster binding
 		if env != "" {
+			envTarget = multiCfg.EnvironmentTarget(env)
 			multiCfg = multiCfg.ForEnvironment(env)
+			kubeContext, namespace = resolveEnvTarget(cmd, env, envTarget, kubeContext, namespace)
+		}
+		if multiCfg, err = multiCfg.WithOverrides(configOverrides(cmd)); err != nil {
+			return finalize(output.WithCode(output.ErrConfig, err))
+		}
+		if promoted != nil {
+			return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("kbox promote needs an App; a MultiApp's services have no release history to promote from")))
 		}
 
 		// Override namespace if specified
 		if namespace != "" {
 			multiCfg.Metadata.Namespace = namespace
 		}
-		targetNamespace = multiCfg.Metadata.Namespace
+		plan.namespace = multiCfg.Metadata.Namespace
+
+		for _, name := range rotateSecrets {
+			if _, ok := multiCfg.SharedSecrets[name]; !ok {
+				return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("--rotate-secret %s: no such entry in sharedSecrets", name)))
+			}
+		}
 
 		// Render using multi-service renderer
 		renderer := render.NewMultiService(multiCfg)
-		bundle, err = renderer.Render()
+		plan.bundle, err = renderer.Render()
 		if err != nil {
 			return finalize(fmt.Errorf("failed to render: %w", err))
 		}
+
+		// Deploy only the services the changes since the ref affect
+		if changedSince != "" {
+			var decisions io.Writer = os.Stdout
+			if outputFormat == "json" {
+				decisions = os.Stderr
+			}
+			affected, err := changedServices(cmd.Context(), multiCfg, changedSince, decisions)
+			if err != nil {
+				return finalize(err)
+			}
+			if len(affected) == 0 {
+				if outputFormat != "json" {
+					fmt.Printf("No service changed since %s, nothing to deploy\n", changedSince)
+				}
+				result.Success = true
+				return finalize(nil)
+			}
+			plan.bundle.OnlyServices(plan.appName, affected)
+		}
 	} else {
 		// Handle single-service config
-		cfg, err := loader.Load()
-		if err != nil {
-			return finalize(fmt.Errorf("failed to load kbox.yaml: %w\n  → Run 'kbox init' to create one, or use 'kbox up' for zero-config deploy", err))
+		if len(rotateSecrets) > 0 {
+			return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("--rotate-secret needs a MultiApp with sharedSecrets")))
+		}
+		if changedSince != "" {
+			return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("--changed-since needs a MultiApp, whose services it deploys selectively")))
+		}
+		var cfg *config.AppConfig
+		var err error
+		if configFile != "" {
+			cfg, err = loader.LoadFile(configFile)
+			if err != nil {
+				return finalize(fmt.Errorf("failed to load %s: %w", configFile, err))
+			}
+		} else {
+			cfg, err = loader.Load()
+			if err != nil {
+				return finalize(fmt.Errorf("failed to load kbox.yaml: %w\n  → Run 'kbox init' to create one, or use 'kbox up' for zero-config deploy", err))
+			}
 		}
 
 		// Validate with warnings for security issues
 		warnings, err := config.ValidateWithWarnings(cfg)
 		if err != nil {
-			return finalize(fmt.Errorf("validation failed: %w", err))
+			return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("validation failed: %w", err)))
 		}
 		// Print warnings to stderr (unless JSON output or CI mode suppresses them)
 		if !ciMode && outputFormat != "json" {
@@ -136,26 +275,37 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		appName = cfg.Metadata.Name
-		result.App = appName
+		plan.appName = cfg.Metadata.Name
+		result.App = plan.appName
+		status.SetApp(plan.appName)
+		clusters = cfg.Clusters
+		notifications = cfg.Notifications
 
-		// Apply environment overlay
+		// Apply environment overlay and its cluster binding
 		if env != "" {
+			envTarget = cfg.EnvironmentTarget(env)
 			cfg = cfg.ForEnvironment(env)
+			kubeContext, namespace = resolveEnvTarget(cmd, env, envTarget, kubeContext, namespace)
+		}
+		if cfg, err = cfg.WithOverrides(configOverrides(cmd)); err != nil {
+			return finalize(output.WithCode(output.ErrConfig, err))
+		}
+		if promoted != nil {
+			cfg.Spec.Image = promoted.from.Image
 		}
 
 		// Override namespace if specified
 		if namespace != "" {
 			cfg.Metadata.Namespace = namespace
 		}
-		targetNamespace = cfg.Metadata.Namespace
+		plan.namespace = cfg.Metadata.Namespace
 
 		// Check if we have an image
 		if cfg.Spec.Image == "" && cfg.Spec.Build == nil {
-			return finalize(fmt.Errorf("no image specified in kbox.yaml\n\n" +
-				"Choose one:\n" +
-				"  kbox up      → Build from Dockerfile + deploy (for development)\n" +
-				"  kbox deploy  → Deploy pre-built image (add 'image:' to kbox.yaml)"))
+			return finalize(output.WithCode(output.ErrConfig, fmt.Errorf("no image specified in %s\n\n"+
+				"Choose one:\n"+
+				"  kbox up      → Build from Dockerfile + deploy (for development)\n"+
+				"  kbox deploy  → Deploy pre-built image (add 'image:' to kbox.yaml)", configName)))
 		}
 
 		// If only build config, use a placeholder image
@@ -165,28 +315,72 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 		// Render
 		renderer := render.New(cfg)
-		bundle, err = renderer.Render()
+		plan.bundle, err = renderer.Render()
 		if err != nil {
 			return finalize(fmt.Errorf("failed to render: %w", err))
 		}
+		plan.cfg = cfg
+	}
+
+	if allClusters {
+		if len(clusters) == 0 {
+			return finalize(fmt.Errorf("--all-clusters requires a 'clusters:' list in %s\n  → Add clusters with a name and context for each", configName))
+		}
+		if envTarget.Context != "" {
+			return finalize(fmt.Errorf("environment %q is bound to context %q and cannot be deployed with --all-clusters", env, envTarget.Context))
+		}
 	}
 
 	// Dry run - show what would be applied
 	if dryRun {
 		if outputFormat == "json" {
 			// JSON output for CI pipelines
-			return bundle.ToJSON(os.Stdout)
+			return plan.bundle.ToJSON(os.Stdout)
 		}
 		// Show resource summary
 		fmt.Println("Dry run - would deploy the following resources:")
 		fmt.Println()
-		printDeployPreview(bundle)
+		printDeployPreview(plan.bundle)
+		if allClusters {
+			fmt.Println()
+			fmt.Printf("  Clusters:        %d\n", len(clusters))
+			for _, c := range clusters {
+				fmt.Printf("    - %s (context: %s)\n", c.Name, c.Context)
+			}
+		}
 		fmt.Println()
 		fmt.Println("Run without --dry-run to apply these resources.")
 		return nil
 	}
 
+	if plan.cfg != nil {
+		if err := checkSignature(cmd.Context(), &plan.cfg.Spec, plan.image()); err != nil {
+			return finalize(err)
+		}
+	}
+
+	// Record where this deploy came from on the Deployments and in the release
+	plan.change = release.CurrentChange(cmd.Context(), ".")
+	action := "deploy"
+	if env != "" {
+		action += " -e " + env
+	}
+	if promoted != nil {
+		action = fmt.Sprintf("promote %s → %s", promoted.from.Env, env)
+	}
+	plan.change.Cause = release.ChangeCause(action, plan.image(), plan.change)
+
+	plan.status = status
+	plan.promoted = promoted
+	if allClusters {
+		plan.notifications = notifications
+		err := deployFleet(cmd, plan, clusters, env, envTarget, parallel)
+		finishStatusWebhook(status, err)
+		return err
+	}
+
 	// Connect to cluster
+	status.Stage("connect", 10)
 	client, err := k8s.NewClient(k8s.ClientOptions{
 		Context:   kubeContext,
 		Namespace: namespace,
@@ -197,15 +391,30 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	result.Context = client.Context
 
-	targetNS := targetNamespace
+	targetNS := plan.namespace
 	if targetNS == "" {
 		targetNS = client.Namespace
 	}
 	result.Namespace = targetNS
 
+	if envTarget.Protected {
+		if err := confirmProtectedEnv(cmd, env, client.Context, targetNS); err != nil {
+			return finalize(err)
+		}
+	}
+
+	event = &notify.Event{
+		Type:      notify.EventDeploy,
+		App:       plan.appName,
+		Env:       env,
+		Context:   client.Context,
+		Namespace: targetNS,
+		Image:     plan.image(),
+	}
+
 	// Print header (unless CI mode with JSON output)
 	if !ciMode || outputFormat != "json" {
-		fmt.Printf("Deploying %s to %s (context: %s)\n", appName, targetNS, client.Context)
+		fmt.Printf("Deploying %s to %s (context: %s)\n", plan.appName, targetNS, client.Context)
 		if env != "" {
 			fmt.Printf("Environment: %s\n", env)
 		}
@@ -218,55 +427,161 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		applyOut = io.Discard // Suppress apply output in CI mode
 	}
 
-	// Apply
-	engine := apply.NewEngine(client.Clientset, applyOut)
-	if timeout > 0 {
-		engine.SetTimeout(timeout)
+	applyResult, err := plan.applyTo(cmd, client, targetNS, result, applyOut, ciMode)
+	if err != nil {
+		return finalize(err)
+	}
+
+	// Mark success
+	result.Success = true
+
+	// Summary (unless JSON mode)
+	if outputFormat != "json" {
+		fmt.Println()
+		fmt.Printf("Deploy complete: %d created, %d updated\n",
+			len(applyResult.Created), len(applyResult.Updated))
+		if result.Revision > 0 {
+			fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(result.Revision))
+		}
+	}
+
+	return finalize(nil)
+}
+
+// deployPlan is a rendered deploy that can be applied to one or more clusters
+type deployPlan struct {
+	appName   string
+	namespace string // from kbox.yaml or flags; empty means the client's default
+	bundle    *render.Bundle
+	cfg       *config.AppConfig // nil for multi-service
+
+	noWait       bool
+	timeout      time.Duration
+	prune        bool
+	skipTests    bool
+	autoRollback bool
+	// transactional undoes the apply if any resource fails to apply
+	transactional bool
+	concurrency   int
+	// retries and retryBackoff control retrying transient API errors on apply
+	// (see addRetryFlags)
+	retries      int
+	retryBackoff time.Duration
+	force        bool   // deploy even if the capacity check fails
+	artifactsDir string // where Job logs are written, per cluster for a fleet
+	fleet        bool
+	// rotateSecrets are the sharedSecrets to regenerate
+	rotateSecrets []string
+
+	notifications *config.NotificationsConfig
+	change        render.ChangeInfo // the revision is filled in per cluster
+
+	// status posts the deploy's stages to --status-webhook; nil without one
+	status *notify.StatusWebhook
+	// promoted is where kbox promote took the image from; nil for a deploy
+	promoted *promotion
+}
+
+// stage reports the start of a deploy stage to the status webhook, naming
+// the cluster when deploying to a fleet
+func (p *deployPlan) stage(client *k8s.Client, name string, percent int) {
+	if p.fleet {
+		name += " (" + client.Context + ")"
+	}
+	p.status.Stage(name, percent)
+}
+
+// image returns the app image being deployed, or "" for a bundle without an app workload
+func (p *deployPlan) image() string {
+	template := p.bundle.PodTemplate()
+	if template == nil || len(template.Spec.Containers) == 0 {
+		return ""
+	}
+	return template.Spec.Containers[0].Image
+}
+
+// applyTo deploys the plan to one cluster: apply, prune, wait for rollout, run smoke
+// tests and save the release. Progress goes to out; quiet suppresses stderr output.
+func (p *deployPlan) applyTo(cmd *cobra.Command, client *k8s.Client, targetNS string, result *output.DeployResult, out io.Writer, quiet bool) (*apply.ApplyResult, error) {
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	// Stamp the Deployments with the revision this deploy will be saved as
+	store := release.NewStore(client.Clientset, targetNS, p.appName)
+	change := p.change
+	if p.cfg != nil {
+		if next, err := store.NextRevision(cmd.Context()); err == nil {
+			change.Revision = next
+		}
 	}
+	bundle := p.bundle.WithChange(change)
+	result.Inventory = inventoryResult(bundle.Inventory())
+
+	// Fail before applying if the namespace's Pod Security Standard would reject the pods
+	p.stage(client, "checks", 15)
+	errOut := io.Writer(os.Stderr)
+	if quiet {
+		errOut = io.Discard
+	}
+	if err := checkPodSecurity(cmd.Context(), client, targetNS, bundle, errOut); err != nil {
+		return nil, err
+	}
+	if p.cfg != nil {
+		checkPlatform(cmd.Context(), client, &p.cfg.Spec, "", errOut)
+	}
+	if err := checkCapacity(cmd.Context(), client, targetNS, p.appName, bundle, p.force, errOut); err != nil {
+		return nil, err
+	}
+
+	// Apply
+	p.stage(client, "apply", 25)
+	engine := apply.NewEngine(client.Clientset, out)
+	engine.SetProgress(output.NewProgress(out, quiet))
+	if p.timeout > 0 {
+		engine.SetTimeout(p.timeout)
+	}
+	engine.SetConcurrency(p.concurrency)
+	engine.SetTransactional(p.transactional)
+	engine.SetRetry(p.retries, p.retryBackoff)
+	engine.SetRotateSecrets(p.rotateSecrets)
 	// Set up dynamic client for CRD support (ServiceMonitor, etc.)
 	if dynClient, err := client.DynamicClient(); err == nil {
 		engine.SetDynamicClient(dynClient)
 	}
 	applyResult, err := engine.Apply(cmd.Context(), bundle)
-	if err != nil {
-		return finalize(err)
-	}
 
-	// Build resource results
-	for _, name := range applyResult.Created {
-		result.Resources = append(result.Resources, output.ResourceResult{
-			Kind:   extractKind(name),
-			Name:   extractName(name),
-			Action: "created",
-		})
+	// Build resource results, also for a failed apply so the result shows
+	// how far it got and what a transactional deploy undid
+	if applyResult != nil {
+		result.Resources = append(result.Resources, resourceResults(applyResult.Resources)...)
+		result.Reverted = resourceResults(applyResult.RolledBack)
 	}
-	for _, name := range applyResult.Updated {
-		result.Resources = append(result.Resources, output.ResourceResult{
-			Kind:   extractKind(name),
-			Name:   extractName(name),
-			Action: "updated",
-		})
+	if err != nil {
+		return nil, err
 	}
 
 	// Check for errors
 	if len(applyResult.Errors) > 0 {
-		if !ciMode {
+		if !quiet {
 			fmt.Fprintln(os.Stderr, "\nErrors:")
 			for _, e := range applyResult.Errors {
 				fmt.Fprintf(os.Stderr, "  - %v\n", e)
 			}
 		}
-		return finalize(fmt.Errorf("deploy completed with %d errors", len(applyResult.Errors)))
+		return nil, output.WithCode(output.ErrPartialApply, fmt.Errorf("deploy completed with %d errors", len(applyResult.Errors)))
 	}
 
 	// Prune orphaned resources if requested
-	if prune {
-		if !ciMode {
-			fmt.Println("\nPruning orphaned resources...")
-		}
-		pruneResult, err := engine.Prune(cmd.Context(), targetNS, appName, bundle, apply.PruneOptions{DryRun: dryRun})
+	if p.prune {
+		p.stage(client, "prune", 55)
+		fmt.Fprintln(out, "\nPruning orphaned resources...")
+		// Keep config versions that stored releases can still roll back to
+		var pruneOpts apply.PruneOptions
+		if p.cfg != nil {
+			pruneOpts.Keep, _ = store.ConfigVersions(cmd.Context())
+		}
+		pruneResult, err := engine.Prune(cmd.Context(), targetNS, p.appName, bundle, pruneOpts)
 		if err != nil {
-			if !ciMode {
+			if !quiet {
 				fmt.Fprintf(os.Stderr, "Warning: prune failed: %v\n", err)
 			}
 		} else if len(pruneResult.Deleted) > 0 {
@@ -280,41 +595,216 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Wait for rollout
-	if !noWait && bundle.Deployment != nil {
-		if err := engine.WaitForRollout(cmd.Context(), targetNS, bundle.Deployment.Name); err != nil {
-			return finalize(fmt.Errorf("rollout failed: %w\n  → Run 'kbox logs' to see pod logs\n  → Run 'kbox status' to check deployment state", err))
+	// Wait for rollout of the app and each of its processes
+	var workloads []string
+	if !p.noWait {
+		workloads = p.bundle.Workloads()
+	}
+	if len(workloads) > 0 {
+		p.stage(client, "rollout", 60)
+	}
+	for _, workload := range workloads {
+		if err := engine.WaitForRollout(cmd.Context(), targetNS, workload); err != nil {
+			err = fmt.Errorf("rollout failed: %w\n  → Run 'kbox why' for a diagnosis\n  → Run 'kbox logs' to see pod logs", err)
+			diagnoseDeployFailure(cmd, client, targetNS, workload, p.cfg, result, quiet || jsonOutput)
+			if p.autoRollback {
+				autoRollbackDeploy(cmd, client, targetNS, p.appName, result, out, quiet)
+			}
+			return nil, err
+		}
+	}
+
+	// WaitForRollout has waited for the load balancer's address
+	if len(workloads) > 0 {
+		if svc, err := client.Clientset.CoreV1().Services(targetNS).Get(cmd.Context(), p.appName, metav1.GetOptions{}); err == nil {
+			result.LoadBalancer = apply.LoadBalancerAddress(svc)
+		}
+
+		// The rolled-out pods report the digests their tagged images resolved to
+		var tagged []string
+		for _, img := range result.Inventory.Images {
+			if img.Digest == "" {
+				tagged = append(tagged, img.Image)
+			}
+		}
+		digests := apply.ImageDigests(cmd.Context(), client.Clientset, targetNS, tagged)
+		for i, img := range result.Inventory.Images {
+			if digest, ok := digests[img.Image]; ok {
+				result.Inventory.Images[i].Digest = digest
+			}
+		}
+	}
+
+	// Wait for the deploy's Jobs (migrations) and capture their output
+	if !p.noWait && len(bundle.Jobs) > 0 {
+		p.stage(client, "jobs", 80)
+		result.Jobs = captureJobs(cmd.Context(), client, targetNS, bundle.Jobs, p.timeout, output.NewProgress(out, quiet))
+		if p.artifactsDir != "" {
+			dir := p.artifactsDir
+			if p.fleet {
+				dir = filepath.Join(dir, client.Context)
+			}
+			if err := writeJobArtifacts(dir, result.Jobs); err != nil && !quiet {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+		for _, job := range result.Jobs {
+			if job.Succeeded {
+				continue
+			}
+			reason := "failed"
+			if job.ExitCode != nil {
+				reason = fmt.Sprintf("failed with exit code %d", *job.ExitCode)
+			} else if job.Error != "" {
+				reason = "failed: " + job.Error
+			}
+			return nil, output.WithCode(output.ErrJobFailed, fmt.Errorf("job %s %s\n  → Run 'kbox job logs %s' to see its output", job.Name, reason, job.Name))
+		}
+	}
+
+	// Run smoke tests as a go/no-go gate (single-service only)
+	if p.cfg != nil && len(p.cfg.Spec.Tests) > 0 && !p.noWait && !p.skipTests {
+		testOut := out
+		if jsonOutput {
+			testOut = io.Discard
+		}
+		p.stage(client, "tests", 90)
+		fmt.Fprintln(testOut, "\nRunning smoke tests...")
+		runner := smoke.NewRunner(client.Clientset, client.RestConfig, targetNS, p.appName, p.cfg.Spec.Port, testOut)
+		report := runner.Run(cmd.Context(), p.cfg.Spec.Tests)
+		for _, r := range report.Results {
+			result.Tests = append(result.Tests, output.TestResult{
+				Name:       r.Name,
+				Type:       r.Type,
+				Passed:     r.Passed,
+				Message:    r.Message,
+				DurationMs: r.DurationMs,
+			})
+		}
+		if !report.Passed {
+			err := output.WithCode(output.ErrTestsFailed, fmt.Errorf("smoke tests failed: %d of %d failed\n  → Run 'kbox logs' to see pod logs", len(report.Failed()), len(report.Results)))
+			if p.autoRollback {
+				autoRollbackDeploy(cmd, client, targetNS, p.appName, result, out, quiet)
+			} else {
+				err = fmt.Errorf("%w\n  → Run 'kbox rollback' to restore the previous release", err)
+			}
+			return nil, err
 		}
 	}
 
 	// Save release to history (single-service only for now)
-	if !isMulti {
-		cfg, _ := loader.Load()
-		store := release.NewStore(client.Clientset, targetNS, appName)
-		revision, err := store.Save(cmd.Context(), cfg)
+	if p.cfg != nil {
+		revision, err := store.SaveWithChange(cmd.Context(), p.cfg, change, bundle.ConfigVersions())
 		if err != nil {
 			// Non-fatal - deployment succeeded
-			if !ciMode {
+			if !quiet {
 				fmt.Fprintf(os.Stderr, "Warning: failed to save release history: %v\n", err)
 			}
 		}
 		result.Revision = revision
+		if err == nil && p.promoted != nil {
+			if err := p.promoted.record(cmd.Context(), store, revision, client.Context, targetNS); err != nil && !quiet {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record the promotion in release history: %v\n", err)
+			}
+		}
 	}
 
-	// Mark success
-	result.Success = true
+	return applyResult, nil
+}
 
-	// Summary (unless JSON mode)
-	if outputFormat != "json" {
-		fmt.Println()
-		fmt.Printf("Deploy complete: %d created, %d updated\n",
-			len(applyResult.Created), len(applyResult.Updated))
-		if result.Revision > 0 {
-			fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(result.Revision))
+// diagnoseDeployFailure explains a failed rollout while the broken pods still exist
+func diagnoseDeployFailure(cmd *cobra.Command, client *k8s.Client, namespace, appName string, cfg *config.AppConfig, result *output.DeployResult, quiet bool) {
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+	defer cancel()
+
+	report, err := diagnose.Diagnose(ctx, client.Clientset, namespace, appName, cfg)
+	if err != nil || report.Healthy() {
+		return
+	}
+
+	for _, f := range report.Findings {
+		result.Diagnosis = append(result.Diagnosis, output.Diagnosis{
+			Title:       f.Title,
+			Explanation: f.Explanation,
+			Fixes:       f.Fixes,
+		})
+	}
+	if !quiet {
+		fmt.Fprintln(os.Stderr)
+		diagnose.Print(os.Stderr, report)
+	}
+}
+
+// addRetryFlags adds the flags for retrying transient API errors on apply
+func addRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("retries", apply.DefaultRetries, "Retries of a resource after a transient API error (conflict, throttling, webhook timeout); 0 to fail at once")
+	cmd.Flags().Duration("retry-backoff", apply.DefaultRetryBackoff, "Delay before the first retry, doubled for each one after")
+}
+
+// resourceResults converts the engine's per-resource outcomes for the result
+func resourceResults(applies []apply.ResourceApply) []output.ResourceResult {
+	var results []output.ResourceResult
+	for _, r := range applies {
+		res := output.ResourceResult{
+			Kind:       r.Kind,
+			Name:       r.Name,
+			Action:     r.Action,
+			DurationMs: r.Duration.Milliseconds(),
 		}
+		if r.Err != nil {
+			res.Error = r.Err.Error()
+		}
+		results = append(results, res)
 	}
+	return results
+}
 
-	return finalize(nil)
+// inventoryResult converts the bundle's inventory for the result
+func inventoryResult(inv *render.Inventory) *output.Inventory {
+	result := &output.Inventory{
+		Kinds:          inv.Kinds,
+		Total:          inv.Total,
+		CPURequests:    inv.CPURequests,
+		MemoryRequests: inv.MemoryRequests,
+		Images:         []output.InventoryImage{},
+		Secrets:        inv.Secrets,
+		SecretCount:    len(inv.Secrets),
+	}
+	for _, img := range inv.Images {
+		result.Images = append(result.Images, output.InventoryImage{
+			Image:     img.Image,
+			Digest:    img.Digest,
+			Workloads: img.Workloads,
+		})
+	}
+	return result
+}
+
+// autoRollbackDeploy restores the last saved release after a failed deploy.
+// The failed deploy has not been saved yet, so the latest release is the last good one.
+func autoRollbackDeploy(cmd *cobra.Command, client *k8s.Client, namespace, appName string, result *output.DeployResult, out io.Writer, quiet bool) {
+	store := release.NewStore(client.Clientset, namespace, appName)
+	latest, err := store.GetLatest(cmd.Context())
+	if err != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: auto-rollback skipped: no previous release to roll back to\n")
+		}
+		return
+	}
+
+	fmt.Fprintf(out, "\nRolling back to %s...\n", release.FormatRevision(latest.Revision))
+	rb, err := release.Rollback(cmd.Context(), client.Clientset, namespace, appName, release.RollbackOptions{
+		ToRevision: latest.Revision,
+		Output:     out,
+	})
+	if err != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: auto-rollback failed: %v\n", err)
+		}
+		return
+	}
+	result.RolledBack = rb.ToRevision
+	fmt.Fprintf(out, "  ✓ Rolled back to %s\n", release.FormatRevision(rb.ToRevision))
 }
 
 // extractKind extracts the kind from "Kind/Name" format
@@ -337,6 +827,22 @@ func extractName(s string) string {
 	return s
 }
 
+// previewReplicas describes the app workload's replicas, which the HPA
+// owns when autoscaling is enabled
+func previewReplicas(bundle *render.Bundle, replicas *int32) string {
+	if replicas == nil && bundle.HPA != nil {
+		minReplicas := int32(1)
+		if bundle.HPA.Spec.MinReplicas != nil {
+			minReplicas = *bundle.HPA.Spec.MinReplicas
+		}
+		return fmt.Sprintf("%d-%d replicas, set by the HPA", minReplicas, bundle.HPA.Spec.MaxReplicas)
+	}
+	if replicas == nil {
+		return "1 replicas"
+	}
+	return fmt.Sprintf("%d replicas", *replicas)
+}
+
 // printDeployPreview prints a preview of resources that would be deployed
 func printDeployPreview(bundle *render.Bundle) {
 	total := len(bundle.AllObjects())
@@ -344,15 +850,24 @@ func printDeployPreview(bundle *render.Bundle) {
 
 	// Core workload
 	if bundle.Deployment != nil {
-		replicas := int32(1)
-		if bundle.Deployment.Spec.Replicas != nil {
-			replicas = *bundle.Deployment.Spec.Replicas
-		}
-		fmt.Printf("  Deployment:      %s (%d replicas)\n", bundle.Deployment.Name, replicas)
+		fmt.Printf("  Deployment:      %s (%s)\n", bundle.Deployment.Name, previewReplicas(bundle, bundle.Deployment.Spec.Replicas))
 		if len(bundle.Deployment.Spec.Template.Spec.Containers) > 0 {
 			fmt.Printf("    Image: %s\n", bundle.Deployment.Spec.Template.Spec.Containers[0].Image)
 		}
 	}
+	if ss := bundle.AppStatefulSet; ss != nil {
+		fmt.Printf("  StatefulSet:     %s (%s)\n", ss.Name, previewReplicas(bundle, ss.Spec.Replicas))
+		if len(ss.Spec.Template.Spec.Containers) > 0 {
+			fmt.Printf("    Image: %s\n", ss.Spec.Template.Spec.Containers[0].Image)
+		}
+	}
+	for _, dep := range bundle.Processes() {
+		replicas := int32(1)
+		if dep.Spec.Replicas != nil {
+			replicas = *dep.Spec.Replicas
+		}
+		fmt.Printf("  Process:         %s (%d replicas)\n", dep.Name, replicas)
+	}
 
 	// Services
 	if len(bundle.Services) > 0 {
@@ -412,221 +927,28 @@ func printDeployPreview(bundle *render.Bundle) {
 	}
 }
 
-// deployFromFile handles deployment from a specific config file
-func deployFromFile(cmd *cobra.Command, configFile, env, namespace, kubeContext string, dryRun, noWait, prune bool, timeout time.Duration, ciMode bool, outputFormat string, timer *output.Timer) error {
-	result := &output.DeployResult{Success: false}
-
-	finalize := func(err error) error {
-		result.DurationMs = timer.ElapsedMs()
-		if err != nil {
-			result.Error = err.Error()
-		}
-		if outputFormat == "json" {
-			output.NewWriter(os.Stdout, outputFormat, ciMode).WriteDeployResult(result)
-			if !result.Success {
-				os.Exit(1)
-			}
-			return nil
-		}
-		return err
-	}
-
-	loader := config.NewLoader(".")
-	cfg, err := loader.LoadFile(configFile)
-	if err != nil {
-		return finalize(fmt.Errorf("failed to load %s: %w", configFile, err))
-	}
-
-	// Validate with warnings
-	warnings, err := config.ValidateWithWarnings(cfg)
-	if err != nil {
-		return finalize(fmt.Errorf("validation failed: %w", err))
-	}
-	if !ciMode && outputFormat != "json" {
-		for _, w := range warnings {
-			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
-		}
-	}
-
-	appName := cfg.Metadata.Name
-	result.App = appName
-
-	// Apply environment overlay
-	if env != "" {
-		cfg = cfg.ForEnvironment(env)
-	}
-
-	// Override namespace if specified
-	if namespace != "" {
-		cfg.Metadata.Namespace = namespace
-	}
-	targetNamespace := cfg.Metadata.Namespace
-
-	// Check if we have an image
-	if cfg.Spec.Image == "" && cfg.Spec.Build == nil {
-		return finalize(fmt.Errorf("no image specified in %s\n\n"+
-			"Choose one:\n"+
-			"  kbox up      → Build from Dockerfile + deploy (for development)\n"+
-			"  kbox deploy  → Deploy pre-built image (add 'image:' to kbox.yaml)", configFile))
-	}
-
-	// If only build config, use a placeholder image
-	if cfg.Spec.Image == "" && cfg.Spec.Build != nil {
-		cfg.Spec.Image = fmt.Sprintf("%s:latest", cfg.Metadata.Name)
-	}
-
-	// Render
-	renderer := render.New(cfg)
-	bundle, err := renderer.Render()
-	if err != nil {
-		return finalize(fmt.Errorf("failed to render: %w", err))
-	}
-
-	// Dry run - show what would be applied
-	if dryRun {
-		if outputFormat == "json" {
-			return bundle.ToJSON(os.Stdout)
-		}
-		fmt.Println("Dry run - would deploy the following resources:")
-		fmt.Println()
-		printDeployPreview(bundle)
-		fmt.Println()
-		fmt.Println("Run without --dry-run to apply these resources.")
-		return nil
-	}
-
-	// Connect to cluster
-	client, err := k8s.NewClient(k8s.ClientOptions{
-		Context:   kubeContext,
-		Namespace: namespace,
-	})
-	if err != nil {
-		return finalize(fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err))
-	}
-
-	result.Context = client.Context
-	targetNS := targetNamespace
-	if targetNS == "" {
-		targetNS = client.Namespace
-	}
-	result.Namespace = targetNS
-
-	// Print header
-	if !ciMode || outputFormat != "json" {
-		fmt.Printf("Deploying %s to %s (context: %s)\n", appName, targetNS, client.Context)
-		if env != "" {
-			fmt.Printf("Environment: %s\n", env)
-		}
-		fmt.Println()
-	}
-
-	// Determine output writer for apply engine
-	var applyOut io.Writer = os.Stdout
-	if ciMode {
-		applyOut = io.Discard
-	}
-
-	// Apply
-	engine := apply.NewEngine(client.Clientset, applyOut)
-	if timeout > 0 {
-		engine.SetTimeout(timeout)
-	}
-	// Set up dynamic client for CRD support (ServiceMonitor, etc.)
-	if dynClient, err := client.DynamicClient(); err == nil {
-		engine.SetDynamicClient(dynClient)
-	}
-	applyResult, err := engine.Apply(cmd.Context(), bundle)
-	if err != nil {
-		return finalize(err)
-	}
-
-	// Build resource results
-	for _, name := range applyResult.Created {
-		result.Resources = append(result.Resources, output.ResourceResult{
-			Kind:   extractKind(name),
-			Name:   extractName(name),
-			Action: "created",
-		})
-	}
-	for _, name := range applyResult.Updated {
-		result.Resources = append(result.Resources, output.ResourceResult{
-			Kind:   extractKind(name),
-			Name:   extractName(name),
-			Action: "updated",
-		})
-	}
-
-	// Check for errors
-	if len(applyResult.Errors) > 0 {
-		if !ciMode {
-			fmt.Fprintln(os.Stderr, "\nErrors:")
-			for _, e := range applyResult.Errors {
-				fmt.Fprintf(os.Stderr, "  - %v\n", e)
-			}
-		}
-		return finalize(fmt.Errorf("deploy completed with %d errors", len(applyResult.Errors)))
-	}
-
-	// Prune orphaned resources if requested
-	if prune {
-		if !ciMode {
-			fmt.Println("\nPruning orphaned resources...")
-		}
-		pruneResult, err := engine.Prune(cmd.Context(), targetNS, appName, bundle, apply.PruneOptions{DryRun: dryRun})
-		if err != nil {
-			if !ciMode {
-				fmt.Fprintf(os.Stderr, "Warning: prune failed: %v\n", err)
-			}
-		} else if len(pruneResult.Deleted) > 0 {
-			for _, name := range pruneResult.Deleted {
-				result.Resources = append(result.Resources, output.ResourceResult{
-					Kind:   extractKind(name),
-					Name:   extractName(name),
-					Action: "deleted",
-				})
-			}
-		}
-	}
-
-	// Wait for rollout
-	if !noWait && bundle.Deployment != nil {
-		if err := engine.WaitForRollout(cmd.Context(), targetNS, bundle.Deployment.Name); err != nil {
-			return finalize(fmt.Errorf("rollout failed: %w\n  → Run 'kbox logs' to see pod logs\n  → Run 'kbox status' to check deployment state", err))
-		}
-	}
-
-	// Save release to history
-	store := release.NewStore(client.Clientset, targetNS, appName)
-	revision, err := store.Save(cmd.Context(), cfg)
-	if err != nil {
-		if !ciMode {
-			fmt.Fprintf(os.Stderr, "Warning: failed to save release history: %v\n", err)
-		}
-	}
-	result.Revision = revision
-
-	// Mark success
-	result.Success = true
-
-	// Summary
-	if outputFormat != "json" {
-		fmt.Println()
-		fmt.Printf("Deploy complete: %d created, %d updated\n",
-			len(applyResult.Created), len(applyResult.Updated))
-		if result.Revision > 0 {
-			fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(result.Revision))
-		}
-	}
-
-	return finalize(nil)
-}
-
 func init() {
 	deployCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
 	deployCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
 	deployCmd.Flags().Bool("dry-run", false, "Show what would be deployed without applying")
 	deployCmd.Flags().Bool("no-wait", false, "Don't wait for rollout to complete")
-	deployCmd.Flags().Duration("timeout", 5*time.Minute, "Timeout for rollout completion (e.g., 10m, 30s)")
+	deployCmd.Flags().Duration("rollout-timeout", 5*time.Minute, "How long to wait for the rollout, and for each of the deploy's Jobs, to complete (e.g., 10m, 30s)")
+	deprecateTimeout(deployCmd, "rollout-timeout")
 	deployCmd.Flags().Bool("prune", false, "Delete orphaned resources not in kbox.yaml")
+	deployCmd.Flags().Bool("skip-tests", false, "Skip smoke tests defined in kbox.yaml")
+	deployCmd.Flags().Bool("auto-rollback", false, "Roll back to the previous release if rollout or smoke tests fail")
+	deployCmd.Flags().Bool("transactional", false, "If any resource fails to apply, restore the ones already applied to their previous state")
+	deployCmd.Flags().BoolP("yes", "y", false, "Skip confirmation for protected environments")
+	deployCmd.Flags().Bool("all-clusters", false, "Deploy to every cluster in the 'clusters:' list of kbox.yaml")
+	deployCmd.Flags().Bool("parallel", false, "With --all-clusters, deploy to all clusters at once")
+	deployCmd.Flags().Bool("force", false, "Deploy even if the ResourceQuota or node capacity check fails")
+	deployCmd.Flags().String("artifacts-dir", "", "Write the logs of the deploy's Jobs (e.g. migrations) to this directory")
+	deployCmd.Flags().Int("concurrency", apply.DefaultConcurrency, "Resources applied at once within each apply stage")
+	deployCmd.Flags().StringArray("rotate-secret", nil, "Regenerate a MultiApp shared secret, rolling the services using it; repeat for several")
+	deployCmd.Flags().String("changed-since", "", "Deploy only the MultiApp services whose build context or watchPaths changed since this git ref")
+	addOverrideFlags(deployCmd)
+	addRetryFlags(deployCmd)
+	addNotifyFlags(deployCmd)
+	addStatusWebhookFlag(deployCmd)
 	rootCmd.AddCommand(deployCmd)
 }
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe [kind/name]",
+	Short: "Describe the app's Deployment, a pod, the HPA or a dependency",
+	Long: `Show a focused, kubectl describe-like view of one of the app's objects:
+spec highlights, status conditions, related objects and recent events.
+
+kind is deployment (deploy), pod (po), hpa or statefulset (sts). Without a
+name, the app's own object is described; for a pod, the first ready one
+(choose another with --pick, --selector or --deployment). A dependency's
+StatefulSet can be named by its type.
+
+The app is taken from --app, or kbox.yaml in the current directory.
+
+Examples:
+  kbox describe                     # The app's Deployment
+  kbox describe pod                 # A ready pod of the app
+  kbox describe pod --pick newest   # The newest pod
+  kbox describe pod/myapp-7d9f-x2k  # A specific pod
+  kbox describe hpa                 # The app's autoscaler
+  kbox describe sts/postgres        # The postgres dependency (myapp-postgres)
+  kbox describe deploy -o json      # For tooling`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDescribe,
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	appName, _ := cmd.Flags().GetString("app")
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
+
+	ref := "deployment"
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	kind, name, err := debug.ParseDescribeRef(ref)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox describe deployment', 'pod', 'hpa' or 'sts/<dependency>'", err))
+	}
+
+	if appName == "" {
+		cfg, err := config.NewLoader(".").Load()
+		switch {
+		case err == nil:
+			appName = cfg.Metadata.Name
+			if namespace == "" {
+				namespace = cfg.Metadata.Namespace
+			}
+		case name == "":
+			return fmt.Errorf("no app specified and no kbox.yaml found\n  → Run 'kbox describe %s --app <app>' or name the object: 'kbox describe %s/<name>'", ref, ref)
+		}
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	desc, err := debug.Describe(cmd.Context(), client.Clientset, ns, appName, kind, name, sel)
+	if err != nil {
+		return err
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":     true,
+			"app":         appName,
+			"description": desc,
+		})
+	}
+
+	debug.PrintDescription(os.Stdout, desc)
+	return nil
+}
+
+func init() {
+	describeCmd.Flags().String("app", "", "App whose objects to describe (default: from kbox.yaml)")
+	addPodSelectorFlags(describeCmd)
+	rootCmd.AddCommand(describeCmd)
+}
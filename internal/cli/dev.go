@@ -51,6 +51,7 @@ Press Ctrl+C to exit.`,
 
   # Skip log streaming
   kbox dev --no-logs`,
+		Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runDev(cmd.Context(), devOptions{
 				watch:       watch,
@@ -131,9 +132,16 @@ func runDev(ctx context.Context, opts devOptions) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// Create cancellable context for log streaming
-	streamCtx, cancelStream := context.WithCancel(ctx)
-	defer cancelStream()
+	// Each rebuild gets a new context for its build and log stream, and
+	// cancels the previous one
+	cancelStream := context.CancelFunc(func() {})
+	defer func() { cancelStream() }()
+	nextStream := func() context.Context {
+		cancelStream()
+		streamCtx, cancel := context.WithCancel(ctx)
+		cancelStream = cancel
+		return streamCtx
+	}
 
 	// Input channel for manual trigger
 	inputChan := make(chan struct{})
@@ -159,10 +167,14 @@ func runDev(ctx context.Context, opts devOptions) error {
 			fmt.Println("\nShutting down...")
 			return nil
 
+		case <-ctx.Done():
+			// The global --timeout ran out
+			fmt.Println("\nShutting down...")
+			return ctx.Err()
+
 		case <-inputChan:
 			// Manual trigger - rebuild
-			cancelStream()
-			streamCtx, cancelStream = context.WithCancel(ctx)
+			streamCtx := nextStream()
 			iteration++
 
 			if err := devBuildAndDeploy(streamCtx, cfg, client, targetNS, iteration, opts.skipLogs); err != nil {
@@ -203,11 +215,8 @@ func devBuildAndDeploy(ctx context.Context, cfg *config.AppConfig, client *k8s.C
 		}
 	}
 
-	buildCmd := exec.CommandContext(ctx, "docker", "build",
-		"-t", imageName,
-		"-f", dockerfile,
-		buildCtx,
-	)
+	buildCmd := exec.CommandContext(ctx, "docker",
+		dockerBuildArgs(imageName, dockerfile, buildCtx, cfg.Spec.BuildPlatforms())...)
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
 
@@ -233,6 +242,14 @@ func devBuildAndDeploy(ctx context.Context, cfg *config.AppConfig, client *k8s.C
 		return fmt.Errorf("render failed: %w", err)
 	}
 
+	store := release.NewStore(client.Clientset, namespace, cfg.Metadata.Name)
+	change := release.CurrentChange(ctx, ".")
+	if next, err := store.NextRevision(ctx); err == nil {
+		change.Revision = next
+	}
+	change.Cause = release.ChangeCause("dev", imageName, change)
+	bundle = bundle.WithChange(change)
+
 	fmt.Println("Deploying...")
 	engine := apply.NewEngine(client.Clientset, os.Stdout)
 	_, err = engine.Apply(ctx, bundle)
@@ -246,8 +263,7 @@ func devBuildAndDeploy(ctx context.Context, cfg *config.AppConfig, client *k8s.C
 	}
 
 	// Save release
-	store := release.NewStore(client.Clientset, namespace, cfg.Metadata.Name)
-	rev, err := store.Save(ctx, cfg)
+	rev, err := store.SaveWithChange(ctx, cfg, change, bundle.ConfigVersions())
 	if err != nil {
 		fmt.Printf("Warning: failed to save release: %v\n", err)
 	} else {
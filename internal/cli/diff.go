@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
@@ -37,7 +37,7 @@ Use this before 'kbox deploy' to verify what will happen.`,
   # Use a specific config file
   kbox diff -f myapp.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			// Load config
 			loader := config.NewLoader(".")
@@ -62,7 +62,7 @@ Use this before 'kbox deploy' to verify what will happen.`,
 
 			// Validate
 			if err := config.Validate(cfg); err != nil {
-				return fmt.Errorf("config validation failed: %w", err)
+				return output.WithCode(output.ErrConfig, fmt.Errorf("config validation failed: %w", err))
 			}
 
 			// Determine namespace
@@ -1,16 +1,20 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 var dnsCmd = &cobra.Command{
@@ -61,47 +65,7 @@ func runDNS(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get LoadBalancer IP/hostname
-	var target string
-	var targetType string
-
-	// First check ingress status for LoadBalancer info
-	if len(ingress.Status.LoadBalancer.Ingress) > 0 {
-		lb := ingress.Status.LoadBalancer.Ingress[0]
-		if lb.IP != "" {
-			target = lb.IP
-			targetType = "A"
-		} else if lb.Hostname != "" {
-			target = lb.Hostname
-			targetType = "CNAME"
-		}
-	}
-
-	// If no LoadBalancer status, try to get info from ingress controller service
-	if target == "" {
-		// Look for common ingress controller services
-		for _, svcName := range []string{"ingress-nginx-controller", "nginx-ingress-controller", "traefik"} {
-			for _, ns := range []string{"ingress-nginx", "nginx-ingress", "traefik", "kube-system"} {
-				svc, err := client.Clientset.CoreV1().Services(ns).Get(cmd.Context(), svcName, metav1.GetOptions{})
-				if err != nil {
-					continue
-				}
-				if svc.Spec.Type == "LoadBalancer" && len(svc.Status.LoadBalancer.Ingress) > 0 {
-					lb := svc.Status.LoadBalancer.Ingress[0]
-					if lb.IP != "" {
-						target = lb.IP
-						targetType = "A"
-					} else if lb.Hostname != "" {
-						target = lb.Hostname
-						targetType = "CNAME"
-					}
-					break
-				}
-			}
-			if target != "" {
-				break
-			}
-		}
-	}
+	target, targetType := ingressAddress(cmd.Context(), client, ingress)
 
 	// Collect DNS records needed
 	type dnsRecord struct {
@@ -146,8 +110,13 @@ func runDNS(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	externalDNS := ingress.Annotations[render.AnnotationExternalDNSHostname] != ""
 	if !ciMode {
-		fmt.Println("Create the following DNS record(s) with your DNS provider:")
+		if externalDNS {
+			fmt.Println("external-dns creates the following DNS record(s):")
+		} else {
+			fmt.Println("Create the following DNS record(s) with your DNS provider:")
+		}
 		fmt.Println()
 	}
 
@@ -168,14 +137,63 @@ func runDNS(cmd *cobra.Command, args []string) error {
 
 	if !ciMode && target != "" {
 		fmt.Println()
-		fmt.Println("After creating the DNS record:")
+		if !externalDNS {
+			fmt.Println("After creating the DNS record:")
+		}
 		fmt.Println("  → DNS propagation may take 5-30 minutes")
-		fmt.Println("  → Use 'dig <hostname>' or 'nslookup <hostname>' to verify")
+		fmt.Println("  → Run 'kbox dns status' to check the record is live")
 	}
 
 	return nil
 }
 
+// ingressAddress returns the address the ingress is reachable at and the
+// record type to point a hostname at it: its own load balancer status, or
+// else a well-known ingress controller's LoadBalancer Service
+func ingressAddress(ctx context.Context, client *k8s.Client, ingress *networkingv1.Ingress) (string, string) {
+	// First check ingress status for LoadBalancer info
+	if len(ingress.Status.LoadBalancer.Ingress) > 0 {
+		lb := ingress.Status.LoadBalancer.Ingress[0]
+		if lb.IP != "" {
+			return lb.IP, "A"
+		} else if lb.Hostname != "" {
+			return lb.Hostname, "CNAME"
+		}
+	}
+
+	// If no LoadBalancer status, try to get info from ingress controller service
+	for _, svcName := range []string{"ingress-nginx-controller", "nginx-ingress-controller", "traefik"} {
+		for _, ns := range []string{"ingress-nginx", "nginx-ingress", "traefik", "kube-system"} {
+			svc, err := client.Clientset.CoreV1().Services(ns).Get(ctx, svcName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			if svc.Spec.Type == "LoadBalancer" && len(svc.Status.LoadBalancer.Ingress) > 0 {
+				if target, targetType := serviceAddress(svc); target != "" {
+					return target, targetType
+				}
+				break
+			}
+		}
+	}
+	return "", ""
+}
+
+// serviceAddress returns a LoadBalancer Service's assigned address and the
+// record type to point a hostname at it
+func serviceAddress(svc *corev1.Service) (string, string) {
+	if len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return "", ""
+	}
+	lb := svc.Status.LoadBalancer.Ingress[0]
+	if lb.IP != "" {
+		return lb.IP, "A"
+	} else if lb.Hostname != "" {
+		return lb.Hostname, "CNAME"
+	}
+	return "", ""
+}
+
 func init() {
 	rootCmd.AddCommand(dnsCmd)
 }
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var dnsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check your app's hostnames resolve to its load balancer",
+	Long: `Resolve the app's hostnames and compare them to the address of the
+load balancer they should point at, to tell whether DNS is live yet.
+
+The hostnames are the Ingress's hosts and the Service's external-dns hostname
+(ingress.dns and service.dns.hostname in kbox.yaml). Names are resolved with
+this machine's resolver, whose cache can lag behind the DNS provider.
+
+Exits non-zero unless every hostname is live.`,
+	Example: `  kbox dns status
+  kbox dns status --wait 10m     # Poll until live
+  kbox dns status --output=json`,
+	RunE: runDNSStatus,
+}
+
+// dnsCheck is one hostname's DNS status
+type dnsCheck struct {
+	Host string `json:"host"`
+	// Source is the object the hostname comes from: ingress or service
+	Source string `json:"source"`
+	// Target is the load balancer address the hostname should resolve to
+	Target   string   `json:"target,omitempty"`
+	Resolved []string `json:"resolved,omitempty"`
+	// Status is live, pending (no load balancer address yet), missing (the
+	// name doesn't resolve), or mismatch (it resolves somewhere else)
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func runDNSStatus(cmd *cobra.Command, args []string) error {
+	kubeContext, _ := cmd.Flags().GetString("context")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	wait, _ := cmd.Flags().GetDuration("wait")
+	ciMode := IsCIMode(cmd)
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	cfg, err := config.NewLoader(".").Load()
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err))
+	}
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext})
+	if err != nil {
+		return output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w", err))
+	}
+
+	ctx := cmd.Context()
+	if wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	var checks []dnsCheck
+	for {
+		checks, err = checkDNS(ctx, client, namespace, cfg.Metadata.Name)
+		if err != nil {
+			break
+		}
+		if wait == 0 || dnsLive(checks) {
+			break
+		}
+		if !ciMode && !jsonOutput {
+			fmt.Fprintf(os.Stderr, "Waiting for DNS (%d/%d live)...\n", countLive(checks), len(checks))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(10 * time.Second):
+			continue
+		}
+		break
+	}
+	if err == nil && !dnsLive(checks) {
+		var pending []string
+		for _, c := range checks {
+			if c.Status != "live" {
+				pending = append(pending, c.Host)
+			}
+		}
+		err = fmt.Errorf("DNS is not live for %s\n  → Check external-dns's logs, or create the records shown by 'kbox dns'", strings.Join(pending, ", "))
+	}
+
+	if jsonOutput {
+		result := map[string]interface{}{
+			"live":   err == nil,
+			"checks": checks,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+		json.NewEncoder(os.Stdout).Encode(result)
+		if err != nil {
+			return output.Reported(err)
+		}
+		return nil
+	}
+
+	if len(checks) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOST\tSOURCE\tTARGET\tRESOLVES TO\tSTATUS")
+		for _, c := range checks {
+			target, resolved := c.Target, strings.Join(c.Resolved, ",")
+			if target == "" {
+				target = "<pending>"
+			}
+			if resolved == "" {
+				resolved = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Host, c.Source, target, resolved, c.Status)
+		}
+		w.Flush()
+		if !ciMode {
+			for _, c := range checks {
+				if c.Message != "" {
+					fmt.Printf("  ⚠ %s: %s\n", c.Host, c.Message)
+				}
+			}
+		}
+	}
+	return err
+}
+
+// checkDNS resolves the hostnames of the app's Ingress and Service
+func checkDNS(ctx context.Context, client *k8s.Client, namespace, app string) ([]dnsCheck, error) {
+	var checks []dnsCheck
+
+	ingress, err := client.Clientset.NetworkingV1().Ingresses(namespace).Get(ctx, app, metav1.GetOptions{})
+	if err == nil {
+		target, _ := ingressAddress(ctx, client, ingress)
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" {
+				checks = append(checks, checkHost(ctx, rule.Host, "ingress", target, true))
+			}
+		}
+	}
+
+	svc, err := client.Clientset.CoreV1().Services(namespace).Get(ctx, app, metav1.GetOptions{})
+	if err == nil && svc.Annotations[render.AnnotationExternalDNSHostname] != "" {
+		target, _ := serviceAddress(svc)
+		for _, host := range strings.Split(svc.Annotations[render.AnnotationExternalDNSHostname], ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				checks = append(checks, checkHost(ctx, host, "service", target, svc.Spec.Type == corev1.ServiceTypeLoadBalancer))
+			}
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("no hostnames to check for %s in namespace %s\n  → Set ingress.dns or service.dns.hostname in kbox.yaml and deploy, or run 'kbox expose --host=<your-domain> --dns'", app, namespace))
+	}
+	return checks, nil
+}
+
+// checkHost resolves host and compares it to target. Without a load balancer
+// (a NodePort or headless Service), external-dns publishes node or pod IPs,
+// so resolving at all counts as live.
+func checkHost(ctx context.Context, host, source, target string, loadBalancer bool) dnsCheck {
+	c := dnsCheck{Host: host, Source: source, Target: target}
+
+	resolved, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		c.Status = "missing"
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			c.Message = err.Error()
+		}
+		if target == "" && loadBalancer {
+			c.Status = "pending"
+			c.Message = "the load balancer has no address yet"
+		}
+		return c
+	}
+	slices.Sort(resolved)
+	c.Resolved = resolved
+
+	switch {
+	case target == "" && loadBalancer:
+		c.Status = "pending"
+		c.Message = "the load balancer has no address yet"
+		return c
+	case target == "":
+		c.Status = "live"
+		return c
+	}
+
+	// A hostname target (e.g. an AWS ELB) is live through a CNAME to it, or
+	// an alias record resolving to the same addresses
+	expected := []string{target}
+	if net.ParseIP(target) == nil {
+		if cname, err := net.DefaultResolver.LookupCNAME(ctx, host); err == nil && strings.EqualFold(strings.TrimSuffix(cname, "."), strings.TrimSuffix(target, ".")) {
+			c.Status = "live"
+			return c
+		}
+		if expected, err = net.DefaultResolver.LookupHost(ctx, target); err != nil {
+			c.Status = "mismatch"
+			c.Message = fmt.Sprintf("can't resolve the load balancer %s: %v", target, err)
+			return c
+		}
+	}
+	for _, addr := range resolved {
+		if slices.Contains(expected, addr) {
+			c.Status = "live"
+			return c
+		}
+	}
+	c.Status = "mismatch"
+	c.Message = fmt.Sprintf("resolves to %s, not %s (a stale record, or a cached answer)", strings.Join(resolved, ","), target)
+	return c
+}
+
+func dnsLive(checks []dnsCheck) bool {
+	return countLive(checks) == len(checks)
+}
+
+func countLive(checks []dnsCheck) int {
+	n := 0
+	for _, c := range checks {
+		if c.Status == "live" {
+			n++
+		}
+	}
+	return n
+}
+
+func init() {
+	dnsStatusCmd.Flags().Duration("wait", 0, "Poll until every hostname is live, up to this long (e.g. 10m)")
+	dnsCmd.AddCommand(dnsStatusCmd)
+}
@@ -8,6 +8,8 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/spf13/cobra"
 	authv1 "k8s.io/api/authorization/v1"
@@ -20,7 +22,10 @@ var doctorCmd = &cobra.Command{
 	Long: `Diagnose your kbox setup by checking:
   - Required tools (kubectl, docker)
   - Kubernetes connectivity and permissions
-  - Optional tools (sops, kind)`,
+  - Optional tools (sops, kind)
+  - Dependency versions: default images (pinned in .kbox/versions.yaml, or
+    the ones kbox ships) and versions set in kbox.yaml that are end of life
+    or have a known critical vulnerability`,
 	RunE: runDoctor,
 }
 
@@ -28,6 +33,9 @@ type checkResult struct {
 	name    string
 	ok      bool
 	message string
+	// warn marks a passing check that found something worth fixing
+	warn bool
+	hint string
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -37,6 +45,64 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	results := doctorChecks(cmd)
+
+	// Check for errors
+	hasErrors := false
+	for _, r := range results {
+		if !r.ok {
+			hasErrors = true
+			break
+		}
+	}
+
+	if outputFormat == "json" {
+		// Build JSON-friendly result
+		checks := make([]map[string]interface{}, len(results))
+		for i, r := range results {
+			checks[i] = map[string]interface{}{
+				"name":    r.name,
+				"ok":      r.ok,
+				"warning": r.warn,
+				"message": r.message,
+			}
+			if r.hint != "" {
+				checks[i]["hint"] = r.hint
+			}
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": !hasErrors,
+			"checks":  checks,
+		})
+	}
+
+	// Print text results
+	fmt.Println("Results:")
+	for _, r := range results {
+		if r.ok && r.warn {
+			fmt.Printf("  ⚠ %s: %s\n", r.name, r.message)
+		} else if r.ok {
+			fmt.Printf("  ✓ %s: %s\n", r.name, r.message)
+		} else {
+			fmt.Printf("  ✗ %s: %s\n", r.name, r.message)
+		}
+		if r.hint != "" {
+			fmt.Printf("      → %s\n", r.hint)
+		}
+	}
+
+	fmt.Println()
+	if hasErrors {
+		fmt.Println("Some checks failed. Fix the issues above to use kbox effectively.")
+	} else {
+		fmt.Println("All checks passed. You're ready to use kbox!")
+	}
+
+	return nil
+}
+
+// doctorChecks runs the checks of kbox doctor
+func doctorChecks(cmd *cobra.Command) []checkResult {
 	var results []checkResult
 
 	// Check required tools
@@ -47,6 +113,8 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	results = append(results, checkTool("kind", "optional, for local clusters"))
 	results = append(results, checkTool("sops", "optional, for encrypted secrets"))
 
+	results = append(results, checkDependencyVersions()...)
+
 	// Check kubeconfig
 	if k8s.HasKubeconfig() {
 		results = append(results, checkResult{
@@ -54,6 +122,12 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			ok:      true,
 			message: k8s.KubeconfigPath(),
 		})
+	} else if k8s.InCluster() {
+		results = append(results, checkResult{
+			name:    "kubeconfig",
+			ok:      true,
+			message: "none, using the pod's service account",
+		})
 	} else {
 		results = append(results, checkResult{
 			name:    "kubeconfig",
@@ -63,7 +137,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check Kubernetes connectivity
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
 	defer cancel()
 
 	namespace, _ := cmd.Flags().GetString("namespace")
@@ -114,49 +188,52 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		results = append(results, checkPermission(ctx, client, ns, "pods/exec", "create"))
 	}
 
-	// Check for errors
-	hasErrors := false
-	for _, r := range results {
-		if !r.ok {
-			hasErrors = true
-			break
-		}
-	}
+	return results
+}
 
-	if outputFormat == "json" {
-		// Build JSON-friendly result
-		checks := make([]map[string]interface{}, len(results))
-		for i, r := range results {
-			checks[i] = map[string]interface{}{
-				"name":    r.name,
-				"ok":      r.ok,
-				"message": r.message,
-			}
+// checkDependencyVersions warns about default dependency versions, and
+// those kbox.yaml sets, that are end of life or have a critical advisory
+func checkDependencyVersions() []checkResult {
+	var results []checkResult
+	now := time.Now()
+	for _, depType := range dependencies.SupportedTypes() {
+		template, _ := dependencies.Get(depType)
+		for _, notice := range dependencies.CheckVersion(depType, template.DefaultVersion, now) {
+			results = append(results, checkResult{
+				name:    fmt.Sprintf("%s default (%s)", depType, template.DefaultVersion),
+				ok:      true,
+				warn:    true,
+				message: notice.Message,
+				hint:    fmt.Sprintf("Pin another default in %s, or set a version in kbox.yaml", dependencies.VersionsFile),
+			})
 		}
-		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
-			"success": !hasErrors,
-			"checks":  checks,
-		})
 	}
 
-	// Print text results
-	fmt.Println("Results:")
-	for _, r := range results {
-		if r.ok {
-			fmt.Printf("  ✓ %s: %s\n", r.name, r.message)
-		} else {
-			fmt.Printf("  ✗ %s: %s\n", r.name, r.message)
+	if cfg, err := config.NewLoader(".").Load(); err == nil {
+		for _, dep := range cfg.Spec.Dependencies {
+			if dep.Version == "" || dep.External != nil {
+				continue
+			}
+			for _, notice := range dependencies.CheckVersion(dep.Type, dep.Version, now) {
+				results = append(results, checkResult{
+					name:    fmt.Sprintf("%s in kbox.yaml (%s)", dep.Type, dep.Version),
+					ok:      true,
+					warn:    true,
+					message: notice.Message,
+					hint:    fmt.Sprintf("Run 'kbox upgrade-dep %s --to <version>'", dep.Type),
+				})
+			}
 		}
 	}
 
-	fmt.Println()
-	if hasErrors {
-		fmt.Println("Some checks failed. Fix the issues above to use kbox effectively.")
-	} else {
-		fmt.Println("All checks passed. You're ready to use kbox!")
+	if len(results) == 0 {
+		results = append(results, checkResult{
+			name:    "dependency versions",
+			ok:      true,
+			message: "none end of life or with a known critical advisory",
+		})
 	}
-
-	return nil
+	return results
 }
 
 func checkTool(name, description string) checkResult {
@@ -1,18 +1,28 @@
 package cli
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 var downCmd = &cobra.Command{
@@ -21,14 +31,61 @@ var downCmd = &cobra.Command{
 	Long: `Delete all Kubernetes resources created by kbox for this app.
 
 This removes: Deployment, Jobs, CronJobs, StatefulSets, Service, Ingress, ConfigMaps, Secrets.
-PersistentVolumeClaims are NOT deleted by default (to preserve data).
+Only resources labelled kbox.dev/managed-by=kbox and kbox.dev/app=<app> are
+selected (or, for apps deployed by older kbox versions, app=<app> together with
+app.kubernetes.io/managed-by=kbox); other resources sharing the app label are left alone.
+PersistentVolumeClaims are NOT deleted by default (to preserve data). With --all,
+PVCs created from StatefulSet volumeClaimTemplates (dependency data) are deleted too,
+after you type the app name to confirm.
+
+Without a terminal (CI, --output json, piped input) pass --yes: kbox won't wait
+for an answer it can't get.
 
 Examples:
-  kbox down              # Delete resources in default namespace
-  kbox down -n staging   # Delete from specific namespace
-  kbox down --all        # Also delete PVCs (data loss warning!)
-  kbox down --force      # Skip confirmation prompt`,
-	RunE: runDown,
+  kbox down                        # Delete resources in default namespace
+  kbox down -n staging             # Delete from specific namespace
+  kbox down --dry-run              # Show what would be deleted
+  kbox down --keep secrets,configmaps  # Leave some kinds in place
+  kbox down --all --wait           # Also delete PVCs and wait until they're gone
+  kbox down --yes                  # Skip confirmation prompt`,
+	RunE:        runDown,
+	Annotations: map[string]string{writesAnnotation: "delete deployments.apps"},
+}
+
+// downKind is a kind of resource removed by kbox down, in deletion order
+type downKind struct {
+	kind    string
+	gvr     schema.GroupVersionResource
+	aliases []string // accepted by --keep besides the kind and resource names
+	data    bool     // only deleted with --all
+}
+
+var downKinds = []downKind{
+	{kind: "Deployment", gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, aliases: []string{"deploy"}},
+	{kind: "Job", gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}},
+	{kind: "CronJob", gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, aliases: []string{"cj"}},
+	{kind: "StatefulSet", gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, aliases: []string{"sts"}},
+	{kind: "Service", gvr: schema.GroupVersionResource{Version: "v1", Resource: "services"}, aliases: []string{"svc"}},
+	{kind: "Ingress", gvr: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, aliases: []string{"ing"}},
+	{kind: "ConfigMap", gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, aliases: []string{"cm"}},
+	{kind: "Secret", gvr: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}},
+	{kind: "ServiceAccount", gvr: schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, aliases: []string{"sa"}},
+	{kind: "Role", gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}},
+	{kind: "RoleBinding", gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}},
+	{kind: "NetworkPolicy", gvr: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, aliases: []string{"netpol"}},
+	{kind: "HorizontalPodAutoscaler", gvr: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, aliases: []string{"hpa"}},
+	{kind: "PodDisruptionBudget", gvr: schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}, aliases: []string{"pdb"}},
+	{kind: "PersistentVolumeClaim", gvr: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, aliases: []string{"pvc"}, data: true},
+}
+
+// downTarget is one resource kbox down will delete
+type downTarget struct {
+	kind *downKind
+	name string
+}
+
+func (t downTarget) String() string {
+	return t.kind.kind + "/" + t.name
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
@@ -36,16 +93,24 @@ func runDown(cmd *cobra.Command, args []string) error {
 	kubeContext, _ := cmd.Flags().GetString("context")
 	force, _ := cmd.Flags().GetBool("force")
 	all, _ := cmd.Flags().GetBool("all")
-	ciMode := IsCIMode(cmd)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	keepFlag, _ := cmd.Flags().GetStringSlice("keep")
+	wait, _ := cmd.Flags().GetBool("wait")
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
 	outputFormat := GetOutputFormat(cmd)
 
+	keep, err := parseKeepKinds(keepFlag)
+	if err != nil {
+		return err
+	}
+
 	// Load config to get app name
 	loader := config.NewLoader(".")
 	var appName string
 
 	isMulti, err := loader.IsMultiService()
 	if err != nil {
-		return fmt.Errorf("no kbox.yaml found\n  → Run this command in a directory with kbox.yaml")
+		return output.WithCode(output.ErrConfig, fmt.Errorf("no kbox.yaml found\n  → Run this command in a directory with kbox.yaml"))
 	}
 
 	if isMulti {
@@ -76,368 +141,314 @@ func runDown(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
 	}
+	dyn, err := client.DynamicClient()
+	if err != nil {
+		return output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w", err))
+	}
 
 	targetNS := namespace
 	if targetNS == "" {
 		targetNS = client.Namespace
 	}
 
-	// Confirmation (unless force or CI mode)
-	if !force && !ciMode {
-		fmt.Printf("This will delete all resources for %q in namespace %q.\n", appName, targetNS)
-		if all {
-			fmt.Println("\n  WARNING: --all flag will also delete PersistentVolumeClaims (data loss!)")
-		}
-		fmt.Print("\nContinue? [y/N] ")
-
-		reader := bufio.NewReader(os.Stdin)
-		response, _ := reader.ReadString('\n')
-		response = strings.TrimSpace(strings.ToLower(response))
-		if response != "y" && response != "yes" {
-			fmt.Println("Cancelled.")
-			return nil
-		}
-		fmt.Println()
-	}
-
 	ctx := cmd.Context()
-	selector := fmt.Sprintf("app=%s", appName)
-	deleteOpts := metav1.DeleteOptions{}
-	listOpts := metav1.ListOptions{LabelSelector: selector}
+	targets, preserved := planDown(ctx, dyn, targetNS, appName, all, keep)
 
 	// Only print deletion messages if NOT in JSON mode
 	shouldPrint := outputFormat != "json"
 
-	var deleted []string
-	var errors []error
-
-	// Delete in reverse dependency order
-
-	// 1. Deployments (app first)
-	deps, err := client.Clientset.AppsV1().Deployments(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, dep := range deps.Items {
-			if err := client.Clientset.AppsV1().Deployments(targetNS).Delete(ctx, dep.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("Deployment/%s", dep.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Deployment/%s\n", dep.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Deployment/%s: %w", dep.Name, err))
-			}
+	if dryRun {
+		if !shouldPrint {
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"success": true,
+				"dry_run": true,
+				"deleted": targetNames(targets),
+			})
 		}
-	}
-
-	// 2. Jobs (one-off jobs)
-	jobs, err := client.Clientset.BatchV1().Jobs(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, job := range jobs.Items {
-			if err := client.Clientset.BatchV1().Jobs(targetNS).Delete(ctx, job.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("Job/%s", job.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Job/%s\n", job.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Job/%s: %w", job.Name, err))
-			}
+		if len(targets) == 0 {
+			fmt.Printf("No resources found for %q in namespace %q\n", appName, targetNS)
+			return nil
+		}
+		fmt.Printf("Would delete %d resources for %q in namespace %q:\n", len(targets), appName, targetNS)
+		for _, t := range targets {
+			fmt.Printf("  - %s\n", t)
 		}
+		if preserved > 0 {
+			fmt.Printf("\n%d PVC(s) would be preserved. Use --all to include them.\n", preserved)
+		}
+		return nil
 	}
 
-	// 3. CronJobs (scheduled jobs)
-	cronjobs, err := client.Clientset.BatchV1().CronJobs(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, cj := range cronjobs.Items {
-			if err := client.Clientset.BatchV1().CronJobs(targetNS).Delete(ctx, cj.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("CronJob/%s", cj.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted CronJob/%s\n", cj.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("CronJob/%s: %w", cj.Name, err))
-			}
+	// Confirmation (unless --yes); deleting data means typing the app name
+	if !force && len(targets) > 0 {
+		c := confirmation{
+			Action:  fmt.Sprintf("deleting %d resources of %q in namespace %q", len(targets), appName, targetNS),
+			Details: []string{fmt.Sprintf("This will delete %d resources for %q in namespace %q.", len(targets), appName, targetNS)},
+		}
+		if slices.ContainsFunc(targets, func(t downTarget) bool { return t.kind.kind == "PersistentVolumeClaim" }) {
+			c.Details = append(c.Details, "", "  WARNING: --all will also delete PersistentVolumeClaims (data loss!)")
+			c.Typed = appName
+		}
+		if err := confirm(cmd, c); err != nil {
+			return err
 		}
 	}
 
-	// 4. StatefulSets (dependencies like postgres/redis)
-	statefulsets, err := client.Clientset.AppsV1().StatefulSets(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, ss := range statefulsets.Items {
-			if err := client.Clientset.AppsV1().StatefulSets(targetNS).Delete(ctx, ss.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("StatefulSet/%s", ss.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted StatefulSet/%s\n", ss.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("StatefulSet/%s: %w", ss.Name, err))
-			}
-		}
+	// When waiting, delete dependents first so a gone object means its pods are gone too
+	deleteOpts := metav1.DeleteOptions{}
+	if wait {
+		policy := metav1.DeletePropagationForeground
+		deleteOpts.PropagationPolicy = &policy
 	}
 
-	// 5. Services
-	services, err := client.Clientset.CoreV1().Services(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, svc := range services.Items {
-			if err := client.Clientset.CoreV1().Services(targetNS).Delete(ctx, svc.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("Service/%s", svc.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Service/%s\n", svc.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Service/%s: %w", svc.Name, err))
-			}
-		}
+	var progressOut io.Writer
+	if shouldPrint {
+		progressOut = os.Stdout
 	}
+	progress := output.NewProgress(progressOut, false)
 
-	// 6. Ingresses
-	ingresses, err := client.Clientset.NetworkingV1().Ingresses(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, ing := range ingresses.Items {
-			if err := client.Clientset.NetworkingV1().Ingresses(targetNS).Delete(ctx, ing.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("Ingress/%s", ing.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Ingress/%s\n", ing.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Ingress/%s: %w", ing.Name, err))
-			}
+	var deleted []string
+	var deletedTargets []downTarget
+	var errors []error
+	for _, t := range targets {
+		task := progress.Start("Deleting " + t.String())
+		opts := deleteOpts
+		if len(keep) > 0 && t.kind.kind == "ConfigMap" && t.name == render.AnchorName(appName) {
+			// The anchor owns the app's objects; orphan them so --keep holds
+			orphan := metav1.DeletePropagationOrphan
+			opts.PropagationPolicy = &orphan
+		}
+		err := dyn.Resource(t.kind.gvr).Namespace(targetNS).Delete(ctx, t.name, opts)
+		if err != nil && !apierrors.IsNotFound(err) {
+			task.Fail(err)
+			errors = append(errors, fmt.Errorf("%s: %w", t, err))
+			continue
 		}
+		task.Done("Deleted " + t.String())
+		deleted = append(deleted, t.String())
+		deletedTargets = append(deletedTargets, t)
 	}
 
-	// 7. ConfigMaps
-	configmaps, err := client.Clientset.CoreV1().ConfigMaps(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, cm := range configmaps.Items {
-			if err := client.Clientset.CoreV1().ConfigMaps(targetNS).Delete(ctx, cm.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("ConfigMap/%s", cm.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted ConfigMap/%s\n", cm.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("ConfigMap/%s: %w", cm.Name, err))
-			}
+	// Block until finalizers (e.g. pvc-protection) have run and objects are gone
+	if wait && len(deletedTargets) > 0 {
+		if err := waitForDeletion(ctx, dyn, targetNS, deletedTargets, timeout, progress); err != nil {
+			errors = append(errors, err)
 		}
 	}
 
-	// 8. Secrets (with kbox labels)
-	secrets, err := client.Clientset.CoreV1().Secrets(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, secret := range secrets.Items {
-			if err := client.Clientset.CoreV1().Secrets(targetNS).Delete(ctx, secret.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("Secret/%s", secret.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Secret/%s\n", secret.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Secret/%s: %w", secret.Name, err))
-			}
+	// JSON output
+	if outputFormat == "json" {
+		errorStrings := make([]string, len(errors))
+		for i, e := range errors {
+			errorStrings[i] = e.Error()
+		}
+		result := map[string]interface{}{
+			"success": len(errors) == 0,
+			"deleted": deleted,
+		}
+		if len(errors) > 0 {
+			result["errors"] = errorStrings
+			result["errorCode"] = output.ErrPartialApply
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+			return err
+		}
+		if len(errors) > 0 {
+			return output.Reported(output.WithCode(output.ErrPartialApply, fmt.Errorf("down completed with %d errors", len(errors))))
 		}
+		return nil
 	}
 
-	// 9. PVCs (only with --all flag, data last)
-	if all {
-		pvcs, err := client.Clientset.CoreV1().PersistentVolumeClaims(targetNS).List(ctx, listOpts)
-		if err == nil {
-			for _, pvc := range pvcs.Items {
-				if err := client.Clientset.CoreV1().PersistentVolumeClaims(targetNS).Delete(ctx, pvc.Name, deleteOpts); err == nil {
-					deleted = append(deleted, fmt.Sprintf("PersistentVolumeClaim/%s", pvc.Name))
-					if shouldPrint {
-						fmt.Printf("  ✓ Deleted PersistentVolumeClaim/%s\n", pvc.Name)
-					}
-				} else {
-					errors = append(errors, fmt.Errorf("PersistentVolumeClaim/%s: %w", pvc.Name, err))
-				}
-			}
-		}
+	// Summary
+	fmt.Println()
+	if len(deleted) == 0 && len(errors) == 0 {
+		fmt.Printf("No resources found for %q in namespace %q\n", appName, targetNS)
+	} else {
+		fmt.Printf("Deleted %d resources\n", len(deleted))
 	}
 
-	// Also delete release history ConfigMap
-	releaseHistoryCM := fmt.Sprintf("%s-release-history", appName)
-	if err := client.Clientset.CoreV1().ConfigMaps(targetNS).Delete(ctx, releaseHistoryCM, deleteOpts); err == nil {
-		deleted = append(deleted, fmt.Sprintf("ConfigMap/%s", releaseHistoryCM))
-		if shouldPrint {
-			fmt.Printf("  ✓ Deleted ConfigMap/%s\n", releaseHistoryCM)
+	if len(errors) > 0 {
+		fmt.Fprintln(os.Stderr, "\nErrors:")
+		for _, e := range errors {
+			fmt.Fprintf(os.Stderr, "  - %v\n", e)
 		}
+		return output.WithCode(output.ErrPartialApply, fmt.Errorf("down completed with %d errors", len(errors)))
 	}
 
-	// Phase 2: Clean up dependency resources (postgres/redis StatefulSets, Services, Secrets)
-	// Dependencies use the kbox.dev/app label instead of the app label
-	depSelector := fmt.Sprintf("kbox.dev/app=%s", appName)
-	depListOpts := metav1.ListOptions{LabelSelector: depSelector}
-
-	// Track already deleted resources to avoid duplicates
-	deletedSet := make(map[string]bool)
-	for _, d := range deleted {
-		deletedSet[d] = true
+	if preserved > 0 && len(deleted) > 0 {
+		fmt.Printf("\nNote: %d PVC(s) preserved (data intact). Use 'kbox down --all' to delete them.\n", preserved)
 	}
 
-	// Delete dependency StatefulSets
-	depStatefulsets, err := client.Clientset.AppsV1().StatefulSets(targetNS).List(ctx, depListOpts)
-	if err == nil {
-		for _, ss := range depStatefulsets.Items {
-			resourceKey := fmt.Sprintf("StatefulSet/%s", ss.Name)
-			if deletedSet[resourceKey] {
-				continue // Already deleted in main app cleanup
-			}
-			if err := client.Clientset.AppsV1().StatefulSets(targetNS).Delete(ctx, ss.Name, deleteOpts); err == nil {
-				deleted = append(deleted, resourceKey)
-				deletedSet[resourceKey] = true
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted StatefulSet/%s\n", ss.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("StatefulSet/%s: %w", ss.Name, err))
-			}
-		}
-	}
+	return nil
+}
 
-	// Delete dependency Services
-	depServices, err := client.Clientset.CoreV1().Services(targetNS).List(ctx, depListOpts)
-	if err == nil {
-		for _, svc := range depServices.Items {
-			resourceKey := fmt.Sprintf("Service/%s", svc.Name)
-			if deletedSet[resourceKey] {
-				continue // Already deleted in main app cleanup
-			}
-			if err := client.Clientset.CoreV1().Services(targetNS).Delete(ctx, svc.Name, deleteOpts); err == nil {
-				deleted = append(deleted, resourceKey)
-				deletedSet[resourceKey] = true
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Service/%s\n", svc.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("Service/%s: %w", svc.Name, err))
-			}
+// planDown lists what kbox down deletes for an app, in deletion order, and
+// how many PVCs are left in place because --all wasn't given
+func planDown(ctx context.Context, dyn dynamic.Interface, namespace, appName string, all bool, keep map[string]bool) ([]downTarget, int) {
+	var targets []downTarget
+	seen := map[string]bool{}
+	add := func(k *downKind, name string) {
+		t := downTarget{kind: k, name: name}
+		if !seen[t.String()] {
+			seen[t.String()] = true
+			targets = append(targets, t)
 		}
 	}
 
-	// Delete dependency Secrets
-	depSecrets, err := client.Clientset.CoreV1().Secrets(targetNS).List(ctx, depListOpts)
-	if err == nil {
-		for _, secret := range depSecrets.Items {
-			resourceKey := fmt.Sprintf("Secret/%s", secret.Name)
-			if deletedSet[resourceKey] {
-				continue // Already deleted in main app cleanup
+	var statefulSets []appsv1.StatefulSet
+	for i := range downKinds {
+		k := &downKinds[i]
+		for _, sel := range render.OwnedSelectors(appName) {
+			list, err := dyn.Resource(k.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: sel})
+			if err != nil {
+				continue
 			}
-			if err := client.Clientset.CoreV1().Secrets(targetNS).Delete(ctx, secret.Name, deleteOpts); err == nil {
-				deleted = append(deleted, resourceKey)
-				deletedSet[resourceKey] = true
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted Secret/%s\n", secret.Name)
+			for _, item := range list.Items {
+				if k.kind == "ServiceAccount" && item.GetName() == "default" {
+					continue // Don't delete default SA
+				}
+				if k.kind == "StatefulSet" {
+					var ss appsv1.StatefulSet
+					if runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &ss) == nil {
+						statefulSets = append(statefulSets, ss)
+					}
 				}
-			} else {
-				errors = append(errors, fmt.Errorf("Secret/%s: %w", secret.Name, err))
+				add(k, item.GetName())
 			}
 		}
-	}
 
-	// 10. Delete ServiceAccounts (skip default)
-	serviceAccounts, err := client.Clientset.CoreV1().ServiceAccounts(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, sa := range serviceAccounts.Items {
-			if sa.Name == "default" {
-				continue // Don't delete default SA
+		switch k.kind {
+		case "ConfigMap":
+			// Release history saved by older kbox versions lacks the ownership labels
+			releaseHistoryCM := render.ReleasesName(appName)
+			if _, err := dyn.Resource(k.gvr).Namespace(namespace).Get(ctx, releaseHistoryCM, metav1.GetOptions{}); err == nil {
+				add(k, releaseHistoryCM)
 			}
-			if err := client.Clientset.CoreV1().ServiceAccounts(targetNS).Delete(ctx, sa.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("ServiceAccount/%s", sa.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted ServiceAccount/%s\n", sa.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("ServiceAccount/%s: %w", sa.Name, err))
+		case "PersistentVolumeClaim":
+			// PVCs from volumeClaimTemplates carry the pod labels, not the app's
+			list, err := dyn.Resource(k.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				break
 			}
-		}
-	}
-
-	// 11. Delete NetworkPolicies
-	networkPolicies, err := client.Clientset.NetworkingV1().NetworkPolicies(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, np := range networkPolicies.Items {
-			if err := client.Clientset.NetworkingV1().NetworkPolicies(targetNS).Delete(ctx, np.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("NetworkPolicy/%s", np.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted NetworkPolicy/%s\n", np.Name)
+			for _, item := range list.Items {
+				if claimedByStatefulSet(item.GetName(), statefulSets) {
+					add(k, item.GetName())
 				}
-			} else {
-				errors = append(errors, fmt.Errorf("NetworkPolicy/%s: %w", np.Name, err))
 			}
 		}
 	}
 
-	// 12. Delete HPAs
-	hpas, err := client.Clientset.AutoscalingV2().HorizontalPodAutoscalers(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, hpa := range hpas.Items {
-			if err := client.Clientset.AutoscalingV2().HorizontalPodAutoscalers(targetNS).Delete(ctx, hpa.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("HorizontalPodAutoscaler/%s", hpa.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted HorizontalPodAutoscaler/%s\n", hpa.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("HorizontalPodAutoscaler/%s: %w", hpa.Name, err))
-			}
+	var kept []downTarget
+	preserved := 0
+	for _, t := range targets {
+		if t.kind.data && !all {
+			preserved++
+			continue
 		}
+		if keep[t.kind.kind] {
+			continue
+		}
+		kept = append(kept, t)
 	}
+	return kept, preserved
+}
 
-	// 13. Delete PDBs
-	pdbs, err := client.Clientset.PolicyV1().PodDisruptionBudgets(targetNS).List(ctx, listOpts)
-	if err == nil {
-		for _, pdb := range pdbs.Items {
-			if err := client.Clientset.PolicyV1().PodDisruptionBudgets(targetNS).Delete(ctx, pdb.Name, deleteOpts); err == nil {
-				deleted = append(deleted, fmt.Sprintf("PodDisruptionBudget/%s", pdb.Name))
-				if shouldPrint {
-					fmt.Printf("  ✓ Deleted PodDisruptionBudget/%s\n", pdb.Name)
-				}
-			} else {
-				errors = append(errors, fmt.Errorf("PodDisruptionBudget/%s: %w", pdb.Name, err))
+// claimedByStatefulSet reports whether a PVC was created from one of the
+// StatefulSets' volumeClaimTemplates (<template>-<statefulset>-<ordinal>)
+func claimedByStatefulSet(pvcName string, statefulSets []appsv1.StatefulSet) bool {
+	for _, ss := range statefulSets {
+		for _, tmpl := range ss.Spec.VolumeClaimTemplates {
+			prefix := tmpl.Name + "-" + ss.Name + "-"
+			if rest, ok := strings.CutPrefix(pvcName, prefix); ok && ordinalPattern.MatchString(rest) {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	// JSON output
-	if outputFormat == "json" {
-		errorStrings := make([]string, len(errors))
-		for i, e := range errors {
-			errorStrings[i] = e.Error()
+var ordinalPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// waitForDeletion polls until every target is gone or timeout expires
+func waitForDeletion(ctx context.Context, dyn dynamic.Interface, namespace string, targets []downTarget, timeout time.Duration, progress *output.Progress) error {
+	task := progress.Start(fmt.Sprintf("Waiting for %d resources to be removed", len(targets)))
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	remaining := targets
+	for {
+		var still []downTarget
+		for _, t := range remaining {
+			if _, err := dyn.Resource(t.kind.gvr).Namespace(namespace).Get(ctx, t.name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				still = append(still, t)
+			}
 		}
-		result := map[string]interface{}{
-			"success": len(errors) == 0,
-			"deleted": deleted,
+		remaining = still
+		if len(remaining) == 0 {
+			task.Done("All resources removed")
+			return nil
 		}
-		if len(errors) > 0 {
-			result["errors"] = errorStrings
+		task.Update(fmt.Sprintf("%d remaining (%s)", len(remaining), remaining[0]))
+
+		select {
+		case <-ctx.Done():
+			task.Fail(ctx.Err())
+			return ctx.Err()
+		case <-deadline:
+			err := fmt.Errorf("timed out waiting for %d resources to be removed (e.g. %s)\n  → Check finalizers: kubectl get %s %s -n %s -o jsonpath='{.metadata.finalizers}'",
+				len(remaining), remaining[0], remaining[0].kind.gvr.Resource, remaining[0].name, namespace)
+			task.Fail(fmt.Errorf("timed out"))
+			return err
+		case <-ticker.C:
 		}
-		return json.NewEncoder(os.Stdout).Encode(result)
-	}
-
-	// Summary
-	fmt.Println()
-	if len(deleted) == 0 {
-		fmt.Printf("No resources found for %q in namespace %q\n", appName, targetNS)
-	} else {
-		fmt.Printf("Deleted %d resources\n", len(deleted))
 	}
+}
 
-	if len(errors) > 0 {
-		fmt.Fprintln(os.Stderr, "\nErrors:")
-		for _, e := range errors {
-			fmt.Fprintf(os.Stderr, "  - %v\n", e)
+// parseKeepKinds resolves --keep values (kinds, resource names or short names)
+func parseKeepKinds(values []string) (map[string]bool, error) {
+	keep := map[string]bool{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
 		}
-		return fmt.Errorf("down completed with %d errors", len(errors))
-	}
-
-	if !all && len(deleted) > 0 {
-		// Check if there are PVCs that weren't deleted
-		pvcs, _ := client.Clientset.CoreV1().PersistentVolumeClaims(targetNS).List(context.Background(), listOpts)
-		if len(pvcs.Items) > 0 {
-			fmt.Printf("\nNote: %d PVC(s) preserved (data intact). Use 'kbox down --all' to delete them.\n", len(pvcs.Items))
+		found := false
+		for _, k := range downKinds {
+			if v == strings.ToLower(k.kind) || v == k.gvr.Resource || slices.Contains(k.aliases, v) {
+				keep[k.kind] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, k := range downKinds {
+				names = append(names, k.gvr.Resource)
+			}
+			return nil, fmt.Errorf("unknown kind %q for --keep\n  → Use one of: %s", v, strings.Join(names, ", "))
 		}
 	}
+	return keep, nil
+}
 
-	return nil
+func targetNames(targets []downTarget) []string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.String())
+	}
+	return names
 }
 
 func init() {
+	downCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt (required without a terminal)")
 	downCmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	downCmd.Flags().MarkDeprecated("force", "use --yes instead")
 	downCmd.Flags().Bool("all", false, "Also delete PersistentVolumeClaims (data loss!)")
+	downCmd.Flags().Bool("dry-run", false, "Show what would be deleted without deleting")
+	downCmd.Flags().StringSlice("keep", nil, "Kinds to leave in place (e.g. secrets,configmaps,pvc)")
+	downCmd.Flags().Bool("wait", false, "Wait until deleted resources (and their finalizers) are gone")
+	downCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long --wait waits")
+	deprecateTimeout(downCmd, "wait-timeout")
 	rootCmd.AddCommand(downCmd)
 }
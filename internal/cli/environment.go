@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// resolveEnvTarget applies an environment's context/namespace binding from kbox.yaml.
+// Explicit --context/--namespace flags still win (with a warning when they disagree),
+// while the binding takes precedence over project defaults from .kbox/state.
+func resolveEnvTarget(cmd *cobra.Command, env string, target config.EnvTarget, kubeContext, namespace string) (string, string) {
+	quiet := IsCIMode(cmd) || GetOutputFormat(cmd) == "json"
+
+	if target.Context != "" {
+		if cmd.Flags().Changed("context") && kubeContext != target.Context {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Warning: --context %q overrides environment %q binding (context: %s)\n", kubeContext, env, target.Context)
+			}
+		} else {
+			kubeContext = target.Context
+		}
+	}
+
+	if target.Namespace != "" {
+		if cmd.Flags().Changed("namespace") && namespace != target.Namespace {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Warning: --namespace %q overrides environment %q binding (namespace: %s)\n", namespace, env, target.Namespace)
+			}
+		} else {
+			namespace = target.Namespace
+		}
+	}
+
+	return kubeContext, namespace
+}
+
+// confirmProtectedEnv requires an explicit confirmation before changing a protected environment:
+// typing its name, or --yes.
+func confirmProtectedEnv(cmd *cobra.Command, env, kubeContext, namespace string) error {
+	return confirm(cmd, confirmation{
+		Action: fmt.Sprintf("deploy to protected environment %q (%s/%s)", env, kubeContext, namespace),
+		Details: []string{
+			fmt.Sprintf("Environment %q is protected.", env),
+			fmt.Sprintf("  Context:   %s", kubeContext),
+			fmt.Sprintf("  Namespace: %s", namespace),
+		},
+		Typed: env,
+	})
+}
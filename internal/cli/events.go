@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events [app]",
+	Short: "Show Kubernetes events for an app",
+	Long: `List Kubernetes events for an app's workloads: deployment, replica sets,
+pods, HPA, ingress, jobs and dependencies.
+
+Repeated events are merged and the list is sorted oldest first.
+
+Examples:
+  kbox events                      # Auto-detect app from kbox.yaml
+  kbox events myapp                # Events from the last hour
+  kbox events myapp --since 10m    # Narrower window
+  kbox events myapp --warnings-only
+  kbox events myapp -f             # Keep streaming new events
+  kbox events myapp -o json        # JSON (one object per line with -f)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEvents,
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	follow, _ := cmd.Flags().GetBool("follow")
+	warningsOnly, _ := cmd.Flags().GetBool("warnings-only")
+	since, _ := cmd.Flags().GetDuration("since")
+
+	appName := ""
+	if len(args) > 0 {
+		appName = args[0]
+	} else {
+		loader := config.NewLoader(".")
+		cfg, err := loader.Load()
+		if err != nil {
+			return fmt.Errorf("no app specified and no kbox.yaml found\n  → Run 'kbox events <app>' or run from a project directory")
+		}
+		appName = cfg.Metadata.Name
+		if namespace == "" {
+			namespace = cfg.Metadata.Namespace
+		}
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	opts := debug.EventsOptions{
+		WarningsOnly: warningsOnly,
+		Since:        since,
+	}
+
+	events, err := debug.ListAppEvents(ctx, client.Clientset, ns, appName, opts)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	if jsonOutput && !follow {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   true,
+			"app":       appName,
+			"namespace": ns,
+			"events":    events,
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	emit := func(e debug.EventInfo) {
+		if jsonOutput {
+			_ = enc.Encode(e)
+			return
+		}
+		debug.PrintEvent(os.Stdout, e)
+	}
+
+	if len(events) == 0 && !jsonOutput {
+		fmt.Printf("No events for %s in namespace %s\n", appName, ns)
+	}
+	for _, e := range events {
+		emit(e)
+	}
+
+	if !follow {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Skip events already printed from the initial list
+	printed := make(map[string]bool)
+	for _, e := range events {
+		printed[eventKey(e)] = true
+	}
+
+	return debug.WatchAppEvents(ctx, client.Clientset, ns, appName, opts, func(e debug.EventInfo) {
+		key := eventKey(e)
+		if printed[key] {
+			return
+		}
+		printed[key] = true
+		emit(e)
+	})
+}
+
+// eventKey identifies an event occurrence for de-duplication across list and watch
+func eventKey(e debug.EventInfo) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%d/%d", e.Kind, e.Object, e.Reason, e.Message, e.Count, e.LastSeen.Unix())
+}
+
+func init() {
+	eventsCmd.Flags().BoolP("follow", "f", false, "Stream new events as they happen")
+	eventsCmd.Flags().Bool("warnings-only", false, "Only show Warning events")
+	eventsCmd.Flags().Duration("since", time.Hour, "Only show events newer than this (0 for all)")
+	rootCmd.AddCommand(eventsCmd)
+}
@@ -27,15 +27,18 @@ accessible from outside the cluster.
 Examples:
   kbox expose --host=myapp.example.com
   kbox expose --host=myapp.example.com --tls
-  kbox expose --host=myapp.example.com --tls --issuer=letsencrypt-prod`,
-	RunE: runExpose,
+  kbox expose --host=myapp.example.com --tls --issuer=letsencrypt-prod
+  kbox expose --host=myapp.example.com --dns      # Record created by external-dns`,
+	RunE:        runExpose,
+	Annotations: map[string]string{writesAnnotation: "create ingresses.networking.k8s.io"},
 }
 
 var unexposeCmd = &cobra.Command{
-	Use:   "unexpose",
-	Short: "Remove ingress from your app",
-	Long:  `Delete the ingress resource for your app, making it no longer externally accessible.`,
-	RunE:  runUnexpose,
+	Use:         "unexpose",
+	Short:       "Remove ingress from your app",
+	Long:        `Delete the ingress resource for your app, making it no longer externally accessible.`,
+	RunE:        runUnexpose,
+	Annotations: map[string]string{writesAnnotation: "delete ingresses.networking.k8s.io"},
 }
 
 func runExpose(cmd *cobra.Command, args []string) error {
@@ -44,6 +47,7 @@ func runExpose(cmd *cobra.Command, args []string) error {
 	enableTLS, _ := cmd.Flags().GetBool("tls")
 	issuer, _ := cmd.Flags().GetString("issuer")
 	ingressClass, _ := cmd.Flags().GetString("class")
+	dns, _ := cmd.Flags().GetBool("dns")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	namespace, _ := cmd.Flags().GetString("namespace")
 	ciMode := IsCIMode(cmd)
@@ -77,6 +81,9 @@ func runExpose(cmd *cobra.Command, args []string) error {
 	if ingressClass != "" {
 		cfg.Spec.Ingress.IngressClass = ingressClass
 	}
+	if dns {
+		cfg.Spec.Ingress.DNS = true
+	}
 
 	if enableTLS {
 		if cfg.Spec.Ingress.TLS == nil {
@@ -144,7 +151,11 @@ func runExpose(cmd *cobra.Command, args []string) error {
 			}
 		}
 		fmt.Println()
-		fmt.Printf("  → Run 'kbox dns' to see DNS records to create\n")
+		if cfg.Spec.Ingress.DNS {
+			fmt.Printf("  → external-dns will create the record; run 'kbox dns status' to check it's live\n")
+		} else {
+			fmt.Printf("  → Run 'kbox dns' to see DNS records to create\n")
+		}
 		fmt.Printf("  → Run 'kbox unexpose' to remove the ingress\n")
 	}
 
@@ -216,6 +227,7 @@ func init() {
 	exposeCmd.Flags().Bool("tls", false, "Enable TLS/HTTPS")
 	exposeCmd.Flags().String("issuer", "", "Cert-manager ClusterIssuer for automatic TLS certificates")
 	exposeCmd.Flags().String("class", "", "Ingress class (e.g., nginx, traefik)")
+	exposeCmd.Flags().Bool("dns", false, "Have external-dns create the DNS record for the host")
 
 	rootCmd.AddCommand(exposeCmd)
 	rootCmd.AddCommand(unexposeCmd)
@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/notify"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
+)
+
+// deployFleet applies the plan to every cluster in the fleet and aggregates the results.
+// Sequential deploys stop at the first failing cluster; parallel deploys run them all.
+func deployFleet(cmd *cobra.Command, plan *deployPlan, clusters []config.ClusterConfig, env string, envTarget config.EnvTarget, parallel bool) error {
+	ciMode := IsCIMode(cmd)
+	outputFormat := GetOutputFormat(cmd)
+	quiet := ciMode || outputFormat == "json"
+	timer := output.NewTimer()
+
+	fleet := &output.FleetDeployResult{App: plan.appName, Parallel: parallel}
+	fleet.Clusters = make([]output.ClusterDeployResult, len(clusters))
+	for i, c := range clusters {
+		fleet.Clusters[i] = output.ClusterDeployResult{
+			Cluster:      c.Name,
+			Region:       c.Region,
+			Weight:       c.Weight,
+			DeployResult: output.DeployResult{App: plan.appName, Context: c.Context},
+		}
+	}
+
+	if envTarget.Protected {
+		var contexts []string
+		for _, c := range clusters {
+			contexts = append(contexts, c.Context)
+		}
+		ns := plan.namespace
+		if ns == "" {
+			ns = "(context default)"
+		}
+		if err := confirmProtectedEnv(cmd, env, strings.Join(contexts, ", "), ns); err != nil {
+			return err
+		}
+	}
+
+	deployOne := func(i int, out io.Writer, quiet bool) {
+		c := clusters[i]
+		r := &fleet.Clusters[i].DeployResult
+		start := time.Now()
+		defer func() { r.DurationMs = time.Since(start).Milliseconds() }()
+
+		client, err := k8s.NewClient(k8s.ClientOptions{Context: c.Context, Namespace: plan.namespace})
+		if err != nil {
+			r.Error = fmt.Sprintf("failed to connect to cluster: %v", err)
+			r.ErrorCode = output.ErrCluster
+			return
+		}
+		r.Context = client.Context
+		r.Namespace = plan.namespace
+		if r.Namespace == "" {
+			r.Namespace = client.Namespace
+		}
+
+		if _, err := plan.applyTo(cmd, client, r.Namespace, r, out, quiet); err != nil {
+			r.Error = err.Error()
+			r.ErrorCode = output.CodeOf(err)
+			return
+		}
+		r.Success = true
+	}
+
+	if !quiet {
+		mode := "sequentially"
+		if parallel {
+			mode = "in parallel"
+		}
+		fmt.Printf("Deploying %s to %d clusters %s\n", plan.appName, len(clusters), mode)
+		if env != "" {
+			fmt.Printf("Environment: %s\n", env)
+		}
+		fmt.Println()
+	}
+
+	if parallel {
+		// Interleaved progress would be unreadable; report each cluster as it finishes
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for i := range clusters {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				deployOne(i, io.Discard, true)
+				if !quiet {
+					mu.Lock()
+					printClusterOutcome(&fleet.Clusters[i])
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		var out io.Writer = os.Stdout
+		if quiet {
+			out = io.Discard
+		}
+		failed := false
+		for i, c := range clusters {
+			if failed {
+				fleet.Clusters[i].Skipped = true
+				fleet.Clusters[i].Error = "skipped: an earlier cluster failed"
+				continue
+			}
+			if !quiet {
+				fmt.Printf("=== %s (context: %s) ===\n", c.Name, c.Context)
+			}
+			deployOne(i, out, quiet)
+			if !quiet {
+				printClusterOutcome(&fleet.Clusters[i])
+				fmt.Println()
+			}
+			failed = !fleet.Clusters[i].Success
+		}
+	}
+
+	var failedNames []string
+	var failedCode output.ErrorCode
+	succeeded, skipped := 0, 0
+	for _, c := range fleet.Clusters {
+		switch {
+		case c.Success:
+			succeeded++
+		case c.Skipped:
+			skipped++
+		default:
+			failedNames = append(failedNames, c.Cluster)
+			if failedCode == "" {
+				failedCode = c.ErrorCode
+			}
+		}
+	}
+	fleet.Success = succeeded == len(clusters)
+	fleet.DurationMs = timer.ElapsedMs()
+
+	for _, c := range fleet.Clusters {
+		if c.Skipped {
+			continue
+		}
+		sendNotification(cmd, plan.notifications, notify.Event{
+			Type:      notify.EventDeploy,
+			App:       c.App,
+			Env:       env,
+			Cluster:   c.Cluster,
+			Context:   c.Context,
+			Namespace: c.Namespace,
+			Revision:  c.Revision,
+			Image:     plan.image(),
+			Duration:  time.Duration(c.DurationMs) * time.Millisecond,
+			Succeeded: c.Success,
+			Error:     firstLine(c.Error),
+		})
+	}
+
+	var err error
+	if !fleet.Success {
+		msg := fmt.Sprintf("deploy failed on %d of %d clusters: %s", len(failedNames), len(clusters), strings.Join(failedNames, ", "))
+		if skipped > 0 {
+			msg += fmt.Sprintf(" (%d skipped)", skipped)
+		}
+		// The fleet fails with the first failed cluster's code
+		err = output.WithCode(failedCode, fmt.Errorf("%s\n  → Run 'kbox status --all-clusters' to see fleet state", msg))
+		fleet.Error = err.Error()
+		fleet.ErrorCode = failedCode
+	}
+	finishStatusWebhook(plan.status, err)
+
+	if outputFormat == "json" {
+		output.NewWriter(os.Stdout, outputFormat, ciMode).WriteJSON(fleet)
+		if !fleet.Success {
+			return output.Reported(err)
+		}
+		return nil
+	}
+
+	if ciMode {
+		for _, c := range fleet.Clusters {
+			if c.Success {
+				fmt.Printf("Deployed %s to %s/%s (revision %d)\n", c.App, c.Cluster, c.Namespace, c.Revision)
+			} else if c.Skipped {
+				fmt.Printf("Deploy to %s skipped\n", c.Cluster)
+			} else {
+				fmt.Printf("Deploy to %s failed: %s\n", c.Cluster, firstLine(c.Error))
+			}
+		}
+		return err
+	}
+
+	fmt.Printf("Fleet deploy: %d/%d clusters succeeded\n", succeeded, len(clusters))
+	return err
+}
+
+// printClusterOutcome prints a one-line result for a cluster of a fleet deploy
+func printClusterOutcome(c *output.ClusterDeployResult) {
+	switch {
+	case c.Success && c.Revision > 0:
+		fmt.Printf("  ✓ %s: deployed to %s (release %s)\n", c.Cluster, c.Namespace, release.FormatRevision(c.Revision))
+	case c.Success:
+		fmt.Printf("  ✓ %s: deployed to %s\n", c.Cluster, c.Namespace)
+	default:
+		fmt.Printf("  ✗ %s: %s\n", c.Cluster, firstLine(c.Error))
+	}
+}
+
+// clusterStatus is the state of an app in one cluster of the fleet
+type clusterStatus struct {
+	Cluster string           `json:"cluster"`
+	Region  string           `json:"region,omitempty"`
+	Weight  int              `json:"weight,omitempty"`
+	Context string           `json:"context"`
+	Status  *debug.AppStatus `json:"status,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// fleetStatus fetches the app status from every cluster concurrently
+func fleetStatus(ctx context.Context, clusters []config.ClusterConfig, namespace, appName string) []clusterStatus {
+	statuses := make([]clusterStatus, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		statuses[i] = clusterStatus{Cluster: c.Name, Region: c.Region, Weight: c.Weight, Context: c.Context}
+		wg.Add(1)
+		go func(s *clusterStatus) {
+			defer wg.Done()
+			client, err := k8s.NewClient(k8s.ClientOptions{Context: s.Context, Namespace: namespace})
+			if err != nil {
+				s.Error = fmt.Sprintf("failed to connect to cluster: %v", err)
+				return
+			}
+			ns := client.Namespace
+			if namespace != "" {
+				ns = namespace
+			}
+			ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+			defer cancel()
+			status, err := debug.GetAppStatus(ctx, client.Clientset, ns, appName)
+			if err != nil {
+				s.Error = err.Error()
+				return
+			}
+			s.Status = status
+		}(&statuses[i])
+	}
+	wg.Wait()
+	return statuses
+}
+
+// printFleetStatus writes a one-row-per-cluster summary of the app
+func printFleetStatus(w io.Writer, appName string, statuses []clusterStatus) {
+	fmt.Fprintf(w, "App: %s (%d clusters)\n\n", appName, len(statuses))
+	fmt.Fprintf(w, "  %-16s %-14s %-7s %-8s %s\n", "CLUSTER", "REGION", "WEIGHT", "READY", "IMAGE")
+	for _, s := range statuses {
+		region := s.Region
+		if region == "" {
+			region = "-"
+		}
+		weight := "-"
+		if s.Weight > 0 {
+			weight = fmt.Sprintf("%d", s.Weight)
+		}
+		if s.Error != "" {
+			fmt.Fprintf(w, "  %-16s %-14s %-7s %-8s %s\n", s.Cluster, region, weight, "✗", firstLine(s.Error))
+			continue
+		}
+		ready, image := "-", "-"
+		if d := s.Status.Deployment; d != nil {
+			ready = fmt.Sprintf("%d/%d", d.ReadyReplicas, d.Replicas)
+			image = d.Image
+		}
+		fmt.Fprintf(w, "  %-16s %-14s %-7s %-8s %s\n", s.Cluster, region, weight, ready, image)
+	}
+}
+
+// loadFleet returns the app name, namespace and clusters declared in kbox.yaml
+func loadFleet() (string, string, []config.ClusterConfig, error) {
+	loader := config.NewLoader(".")
+	if multi, err := loader.IsMultiService(); err == nil && multi {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return "", "", nil, err
+		}
+		return cfg.Metadata.Name, cfg.Metadata.Namespace, cfg.Clusters, nil
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return "", "", nil, err
+	}
+	return cfg.Metadata.Name, cfg.Metadata.Namespace, cfg.Clusters, nil
+}
+
+// firstLine returns the first line of a multi-line error message
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -34,7 +34,8 @@ Examples:
   kbox graph --web              # Open Mermaid diagram in browser
   kbox graph --format mermaid   # Output Mermaid code to stdout
   kbox graph --no-color         # ASCII without colors`,
-	RunE: runGraph,
+	RunE:        runGraph,
+	Annotations: map[string]string{offlineAnnotation: ""},
 }
 
 func init() {
@@ -1,17 +1,19 @@
 package cli
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"time"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/format"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/bobbyrathoree/kbox/internal/release"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 func newHistoryCmd() *cobra.Command {
@@ -21,12 +23,16 @@ func newHistoryCmd() *cobra.Command {
 	)
 
 	cmd := &cobra.Command{
-		Use:   "history [app]",
-		Short: "Show release history for an application",
+		Use:     "history [app]",
+		Aliases: []string{"releases"},
+		Short:   "Show release history for an application",
 		Long: `Show the deployment history for an application.
 
 Each deployment creates a release that can be rolled back to.
-The history shows the revision number, timestamp, and image deployed.`,
+The history shows the revision number, timestamp, image deployed,
+the git branch and commit it was deployed from, and who deployed it.
+The release currently running (per the Deployment's kbox.dev/revision
+annotation) is marked with *. 'kbox releases diff' compares two releases.`,
 		Example: `  # Show history for app in kbox.yaml
   kbox history
 
@@ -37,29 +43,14 @@ The history shows the revision number, timestamp, and image deployed.`,
   kbox history myapp -n production`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 
-			// Load config to get defaults
-			loader := config.NewLoader(".")
-			cfg, _ := loader.Load() // Ignore error - might not have kbox.yaml
-
-			// Determine app name
 			if len(args) > 0 {
 				appName = args[0]
-			} else if appName == "" && cfg != nil {
-				appName = cfg.Metadata.Name
 			}
-			if appName == "" {
-				return fmt.Errorf("app name required (specify as argument or use kbox.yaml)")
-			}
-
-			// Determine namespace
-			if namespace == "" {
-				if cfg != nil && cfg.Metadata.Namespace != "" {
-					namespace = cfg.Metadata.Namespace
-				} else {
-					namespace = "default"
-				}
+			var err error
+			if appName, namespace, err = historyTarget(appName, namespace); err != nil {
+				return err
 			}
 
 			// Get K8s client
@@ -89,29 +80,59 @@ The history shows the revision number, timestamp, and image deployed.`,
 				return nil
 			}
 
+			// The running revision, from the annotation kbox stamps on the Deployment
+			current := 0
+			if dep, err := client.Clientset.AppsV1().Deployments(namespace).Get(ctx, appName, metav1.GetOptions{}); err == nil {
+				current = render.ChangeInfoFrom(dep.Annotations).Revision
+			}
+
 			outputFormat := GetOutputFormat(cmd)
 			if outputFormat == "json" {
 				return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
-					"success":  true,
-					"app":      appName,
-					"releases": releases,
+					"success":          true,
+					"app":              appName,
+					"current_revision": current,
+					"releases":         releases,
 				})
 			}
 
 			// Print header
 			fmt.Printf("Release history for %s (namespace: %s)\n\n", appName, namespace)
-			fmt.Printf("%-10s %-25s %s\n", "REVISION", "DEPLOYED", "IMAGE")
-			fmt.Printf("%-10s %-25s %s\n", "--------", "--------", "-----")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REVISION\tDEPLOYED\tIMAGE\tSOURCE\tBY")
 
 			// Print releases (newest first)
 			for i := len(releases) - 1; i >= 0; i-- {
 				r := releases[i]
-				relativeTime := formatRelativeTime(r.Timestamp)
-				fmt.Printf("%-10s %-25s %s\n",
-					release.FormatRevision(r.Revision),
-					relativeTime,
-					truncateImage(r.Image, 50))
+				revision := release.FormatRevision(r.Revision)
+				if r.Revision == current {
+					revision += " *"
+				}
+				source := "-"
+				if r.GitSHA != "" {
+					source = release.ShortSHA(r.GitSHA)
+					if r.GitBranch != "" {
+						source = r.GitBranch + "@" + source
+					}
+				}
+				if from := r.PromotedFrom; from != nil {
+					source = "from " + promotionRef(*from)
+				}
+				for _, to := range r.PromotedTo {
+					source += ", → " + promotionRef(to)
+				}
+				by := r.DeployedBy
+				if by == "" {
+					by = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					revision,
+					format.Ago(r.Timestamp),
+					truncateImage(r.Image, 50),
+					source,
+					by)
 			}
+			w.Flush()
 
 			return nil
 		},
@@ -120,42 +141,40 @@ The history shows the revision number, timestamp, and image deployed.`,
 	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Kubernetes namespace")
 	cmd.Flags().StringVarP(&appName, "app", "a", "", "Application name (overrides kbox.yaml)")
 
+	cmd.AddCommand(newReleasesDiffCmd())
 	return cmd
 }
 
+// historyTarget fills in the app and namespace of a release history command
+// from kbox.yaml, when it has one and they weren't given
+func historyTarget(appName, namespace string) (string, string, error) {
+	cfg, _ := config.NewLoader(".").Load() // Ignore error - might not have kbox.yaml
+	if appName == "" && cfg != nil {
+		appName = cfg.Metadata.Name
+	}
+	if appName == "" {
+		return "", "", fmt.Errorf("app name required (specify as argument or use kbox.yaml)")
+	}
+	if namespace == "" {
+		if cfg != nil && cfg.Metadata.Namespace != "" {
+			namespace = cfg.Metadata.Namespace
+		} else {
+			namespace = "default"
+		}
+	}
+	return appName, namespace, nil
+}
+
 func init() {
 	rootCmd.AddCommand(newHistoryCmd())
 }
 
-// formatRelativeTime returns a human-readable relative time
-func formatRelativeTime(t time.Time) string {
-	now := time.Now().UTC()
-	diff := now.Sub(t)
-
-	switch {
-	case diff < time.Minute:
-		return "just now"
-	case diff < time.Hour:
-		mins := int(diff.Minutes())
-		if mins == 1 {
-			return "1 minute ago"
-		}
-		return fmt.Sprintf("%d minutes ago", mins)
-	case diff < 24*time.Hour:
-		hours := int(diff.Hours())
-		if hours == 1 {
-			return "1 hour ago"
-		}
-		return fmt.Sprintf("%d hours ago", hours)
-	case diff < 7*24*time.Hour:
-		days := int(diff.Hours() / 24)
-		if days == 1 {
-			return "1 day ago"
-		}
-		return fmt.Sprintf("%d days ago", days)
-	default:
-		return t.Format("Jan 02, 2006 15:04")
+// promotionRef names the other end of a promotion, e.g. "staging #12"
+func promotionRef(p release.Promotion) string {
+	if p.Revision == 0 {
+		return p.Env
 	}
+	return p.Env + " " + release.FormatRevision(p.Revision)
 }
 
 // truncateImage truncates long image names for display
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/images"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "List the images the config runs, with their digests",
+	Long: `List every image kbox.yaml references: the app's, its sidecars and init
+containers, dependencies such as postgres or redis, and jobs.
+
+Each tag is resolved to the digest it currently points to with a HEAD request
+to the registry, using the credentials from 'docker login'. Images kbox up
+builds are listed without a digest, as they don't exist until it runs.
+
+Examples:
+  kbox images                  # Images of the base config
+  kbox images -e prod          # Images of the prod environment
+  kbox images --no-resolve     # Don't contact the registries
+  kbox images -o json          # For scripts, e.g. to pin images by digest`,
+	RunE: runImages,
+}
+
+// imageEntry is one image of the config and everything that runs it
+type imageEntry struct {
+	Image     string   `json:"image"`
+	Roles     []string `json:"roles"`
+	Workloads []string `json:"workloads"`
+	Digest    string   `json:"digest,omitempty"`
+	// Built is set for the image kbox up builds
+	Built bool   `json:"built,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func runImages(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	noResolve, _ := cmd.Flags().GetBool("no-resolve")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	target, err := loadTestTarget(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	if env != "" && !slices.Contains(target.environments(), env) {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("environment %q is not defined in %s\n  → Defined environments: %s", env, path, strings.Join(target.environments(), ", ")))
+	}
+
+	// A build-only app renders with a placeholder image, and a build-only
+	// service with none: both are the image kbox up builds
+	built := map[string]bool{"": true}
+	if target.app != nil {
+		if cfg := target.app.ForEnvironment(env); cfg.Spec.Image == "" && cfg.Spec.Build != nil {
+			built[cfg.Metadata.Name+":latest"] = true
+		}
+	}
+
+	bundle, _, _, err := target.render(env)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+
+	var entries []*imageEntry
+	byImage := make(map[string]*imageEntry)
+	for _, use := range bundle.ImageUses() {
+		entry := byImage[use.Image]
+		if entry == nil {
+			entry = &imageEntry{Image: use.Image, Built: built[use.Image]}
+			byImage[use.Image] = entry
+			entries = append(entries, entry)
+		}
+		if !slices.Contains(entry.Roles, use.Role) {
+			entry.Roles = append(entry.Roles, use.Role)
+		}
+		if !slices.Contains(entry.Workloads, use.Workload) {
+			entry.Workloads = append(entry.Workloads, use.Workload)
+		}
+	}
+
+	if !noResolve {
+		resolver := images.NewResolver()
+		for _, entry := range entries {
+			if entry.Built {
+				continue
+			}
+			if entry.Digest, err = resolver.Resolve(cmd.Context(), entry.Image); err != nil {
+				entry.Error = err.Error()
+			}
+		}
+	}
+
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"app":    target.name(),
+			"env":    env,
+			"images": entries,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tROLE\tUSED BY\tDIGEST")
+	var failed []*imageEntry
+	for _, e := range entries {
+		image, digest := e.Image, e.Digest
+		switch {
+		case e.Built:
+			if image == "" {
+				image = "<built>"
+			}
+			digest = "built by kbox up"
+		case e.Error != "":
+			digest = "✗ unresolved"
+			failed = append(failed, e)
+		case digest == "":
+			digest = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", image, strings.Join(e.Roles, ","), strings.Join(e.Workloads, ","), digest)
+	}
+	w.Flush()
+
+	for _, e := range failed {
+		fmt.Printf("  ⚠ %s: %s\n", e.Image, e.Error)
+	}
+	return nil
+}
+
+func init() {
+	imagesCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	imagesCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
+	imagesCmd.Flags().Bool("no-resolve", false, "List the images without resolving their digests")
+	rootCmd.AddCommand(imagesCmd)
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/intercept"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/tunnel"
+	"github.com/spf13/cobra"
+)
+
+var interceptCmd = &cobra.Command{
+	Use:   "intercept [service]",
+	Short: "Route a service's cluster traffic to a local process",
+	Long: `Send the in-cluster traffic of one service to a process on your machine.
+
+kbox opens a tunnel to the local port, starts a small proxy in the cluster
+that forwards to the tunnel, points the Service at the proxy, and scales the
+original Deployment to zero. The rest of the stack keeps calling the service
+by its usual name, so you can debug one service of a MultiApp in place.
+
+On exit (Ctrl+C) the Service selector and Deployment replicas are restored
+and the proxy is removed. If a session is killed before it can clean up,
+run 'kbox intercept <service> --restore'.
+
+The tunnel uses the share providers (--provider, or spec.share.provider in
+kbox.yaml), so only HTTP traffic is intercepted.
+
+Examples:
+  kbox intercept api --port 8080        # MultiApp: send api traffic to localhost:8080
+  kbox intercept                        # Single app, on its configured port
+  kbox intercept api --provider cloudflared
+  kbox intercept api --restore          # Undo an intercept left behind`,
+	Args:        cobra.MaximumNArgs(1),
+	RunE:        runIntercept,
+	Annotations: map[string]string{writesAnnotation: "patch services"},
+}
+
+func init() {
+	interceptCmd.Flags().IntP("port", "p", 0, "Local port the process listens on (default: the service's port)")
+	interceptCmd.Flags().String("provider", "", "Tunnel provider: ngrok, cloudflared, localtunnel (default: ngrok)")
+	interceptCmd.Flags().String("token", "", "ngrok auth token (or set NGROK_AUTHTOKEN)")
+	interceptCmd.Flags().Bool("restore", false, "Restore a service left intercepted by an interrupted session")
+	interceptCmd.Flags().Duration("start-timeout", 2*time.Minute, "Time to wait for the intercept proxy to start")
+	deprecateTimeout(interceptCmd, "start-timeout")
+	rootCmd.AddCommand(interceptCmd)
+}
+
+func runIntercept(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	localPort, _ := cmd.Flags().GetInt("port")
+	authToken, _ := cmd.Flags().GetString("token")
+	restore, _ := cmd.Flags().GetBool("restore")
+	timeout, _ := cmd.Flags().GetDuration("start-timeout")
+
+	name, port, err := resolveInterceptTarget(args)
+	if err != nil {
+		return err
+	}
+	if localPort == 0 {
+		localPort = port
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+	manager := intercept.NewManager(client.Clientset, ns)
+
+	if restore {
+		if err := manager.Restore(cmd.Context(), name); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "  ✓ Restored %s\n", name)
+		return nil
+	}
+
+	active, err := manager.Active(cmd.Context(), name)
+	if err != nil {
+		return fmt.Errorf("service %s not found in %s: %w\n  → Is the app deployed? Try 'kbox up' first", name, ns, err)
+	}
+	if active {
+		return fmt.Errorf("service %s is already intercepted\n  → Run 'kbox intercept %s --restore' to reset it", name, name)
+	}
+
+	// Warn early if nothing is listening locally; the intercept still works once it starts
+	if conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", localPort), time.Second); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: nothing is listening on localhost:%d yet\n", localPort)
+	} else {
+		conn.Close()
+	}
+
+	shareCfg := resolveShareConfig(cmd)
+	provider, err := tunnel.NewProvider(shareCfg.Provider)
+	if err != nil {
+		return fmt.Errorf("%w\n  → Use --provider ngrok, cloudflared, or localtunnel", err)
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	// Handle Ctrl+C
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			fmt.Fprintln(os.Stderr, "\nStopping intercept...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Creating %s tunnel to localhost:%d...\n", provider.Name(), localPort)
+	tun, err := provider.CreateTunnel(ctx, tunnel.Config{
+		LocalPort:  localPort,
+		AuthToken:  authToken,
+		AppName:    name,
+		Namespace:  ns,
+		Hostname:   shareCfg.Hostname,
+		TunnelName: shareCfg.Tunnel,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create tunnel: %w\n  -> %s", err, shareProviderHint(provider.Name()))
+	}
+	defer tun.Close()
+
+	// From here on, always put the cluster back the way it was. The command
+	// context may already be cancelled, so restore with a fresh one.
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := manager.Restore(restoreCtx, name); err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ %v\n  → Run 'kbox intercept %s --restore' to retry\n", err, name)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "  ✓ Restored %s\n", name)
+	}()
+
+	fmt.Fprintln(os.Stderr, "Starting intercept proxy...")
+	if err := manager.Deploy(ctx, name, tun.URL()); err != nil {
+		return err
+	}
+	if err := manager.WaitReady(ctx, name, timeout); err != nil {
+		return fmt.Errorf("%w\n  → Check 'kbox logs %s'", err, intercept.ProxyName(name))
+	}
+	if err := manager.Swap(ctx, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "  ✓ Intercepting %s/%s → localhost:%d\n", ns, name, localPort)
+	fmt.Fprintf(os.Stderr, "    via %s\n", tun.URL())
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Press Ctrl+C to stop and restore the original deployment")
+
+	tunnelErr := make(chan error, 1)
+	go func() {
+		tunnelErr <- tun.Wait()
+	}()
+	select {
+	case err := <-tunnelErr:
+		if err != nil {
+			return fmt.Errorf("tunnel error: %w", err)
+		}
+		return fmt.Errorf("tunnel closed")
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// resolveInterceptTarget returns the Deployment/Service name and port to intercept
+func resolveInterceptTarget(args []string) (string, int, error) {
+	loader := config.NewLoader(".")
+
+	if isMulti, _ := loader.IsMultiService(); isMulti {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to load kbox.yaml: %w", err)
+		}
+		if len(args) == 0 {
+			return "", 0, fmt.Errorf("name the service to intercept\n  → e.g. 'kbox intercept %s --port 8080'", firstService(cfg))
+		}
+		if svc, ok := cfg.Services[args[0]]; ok {
+			return fmt.Sprintf("%s-%s", cfg.Metadata.Name, args[0]), svc.Port, nil
+		}
+		return args[0], config.DefaultPort, nil
+	}
+
+	if cfg, err := loader.Load(); err == nil {
+		if len(args) == 0 || args[0] == cfg.Metadata.Name {
+			return cfg.Metadata.Name, cfg.Spec.Port, nil
+		}
+	}
+	if len(args) == 0 {
+		return "", 0, fmt.Errorf("no service specified and no kbox.yaml found\n  → Run 'kbox intercept <service> --port <port>'")
+	}
+	return args[0], config.DefaultPort, nil
+}
+
+// firstService returns a service name to use in examples
+func firstService(cfg *config.MultiServiceConfig) string {
+	if order := cfg.ServiceOrder(); len(order) > 0 {
+		return order[0]
+	}
+	return "api"
+}
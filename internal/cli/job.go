@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/format"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
@@ -29,7 +32,9 @@ Use jobs for migrations, data processing, cleanup tasks, etc.
 Examples:
   kbox job run migrate         # Run the 'migrate' job
   kbox job list                # List all jobs and their status
-  kbox job logs migrate        # View logs from the last 'migrate' job run`,
+  kbox job logs migrate        # View logs from the last 'migrate' job run
+  kbox job history migrate     # List past 'migrate' runs
+  kbox job retry migrate       # Re-run the last failed 'migrate' run`,
 }
 
 var jobRunCmd = &cobra.Command{
@@ -44,8 +49,10 @@ The job must be defined in the 'jobs' section of your kbox.yaml.`,
 
   # Run with verbose output
   kbox job run migrate -v`,
-	Args: cobra.ExactArgs(1),
-	RunE: runJobRun,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeJobNames(true),
+	RunE:              runJobRun,
+	Annotations:       map[string]string{writesAnnotation: "create jobs.batch"},
 }
 
 var jobListCmd = &cobra.Command{
@@ -66,8 +73,9 @@ var jobLogsCmd = &cobra.Command{
 
   # Follow logs
   kbox job logs migrate -f`,
-	Args: cobra.ExactArgs(1),
-	RunE: runJobLogs,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeJobNames(false),
+	RunE:              runJobLogs,
 }
 
 func runJobRun(cmd *cobra.Command, args []string) error {
@@ -138,14 +146,19 @@ func runJobRun(cmd *cobra.Command, args []string) error {
 
 	if !ciMode {
 		fmt.Printf("  Created Job/%s\n", createdJob.Name)
-		fmt.Println("  Waiting for completion...")
 	}
 
 	// Wait for job completion
-	completed, err := waitForJob(cmd.Context(), client, namespace, createdJob.Name, ciMode)
+	progress := output.NewProgress(os.Stdout, ciMode)
+	if outputFormat == "json" {
+		progress = output.NewProgress(nil, true)
+	}
+	waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	completed, err := waitForJob(cmd.Context(), client, namespace, createdJob.Name, waitTimeout, progress)
 	if err != nil {
 		return err
 	}
+	gcJobRuns(cmd.Context(), client, namespace, cfg, jobName)
 
 	// Output
 	if outputFormat == "json" {
@@ -226,6 +239,8 @@ func runJobList(cmd *cobra.Command, args []string) error {
 		LastRun    string `json:"lastRun,omitempty"`
 		Status     string `json:"status"`
 		InCluster  bool   `json:"inCluster"`
+		NextRuns   []string `json:"nextRuns,omitempty"`
+		nextRuns   string
 	}
 
 	var jobInfos []jobInfo
@@ -238,12 +253,13 @@ func runJobList(cmd *cobra.Command, args []string) error {
 		if jc.Schedule != "" {
 			info.Type = "CronJob"
 			info.Schedule = jc.Schedule
+			info.NextRuns, info.nextRuns = nextRuns(jc)
 			// Find in cluster
 			for _, cj := range cronJobs.Items {
 				if cj.Labels["kbox.dev/job"] == jc.Name {
 					info.InCluster = true
 					if cj.Status.LastScheduleTime != nil {
-						info.LastRun = formatAge(cj.Status.LastScheduleTime.Time)
+						info.LastRun = format.Ago(cj.Status.LastScheduleTime.Time)
 					}
 					info.Status = "Scheduled"
 					break
@@ -255,7 +271,7 @@ func runJobList(cmd *cobra.Command, args []string) error {
 			for _, j := range jobs.Items {
 				if j.Labels["kbox.dev/job"] == jc.Name {
 					info.InCluster = true
-					info.LastRun = formatAge(j.CreationTimestamp.Time)
+					info.LastRun = format.Ago(j.CreationTimestamp.Time)
 					info.Status = getJobStatus(&j)
 					break
 				}
@@ -276,7 +292,7 @@ func runJobList(cmd *cobra.Command, args []string) error {
 
 	// Table output
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tTYPE\tSCHEDULE\tLAST RUN\tSTATUS")
+	fmt.Fprintln(w, "NAME\tTYPE\tSCHEDULE\tLAST RUN\tSTATUS\tNEXT RUNS")
 	for _, info := range jobInfos {
 		schedule := info.Schedule
 		if schedule == "" {
@@ -286,7 +302,11 @@ func runJobList(cmd *cobra.Command, args []string) error {
 		if lastRun == "" {
 			lastRun = "-"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Name, info.Type, schedule, lastRun, info.Status)
+		next := info.nextRuns
+		if next == "" {
+			next = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", info.Name, info.Type, schedule, lastRun, info.Status, next)
 	}
 	w.Flush()
 
@@ -402,18 +422,27 @@ func runJobLogs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// waitForJob waits for a job to complete
-func waitForJob(ctx context.Context, client *k8s.Client, namespace, name string, ciMode bool) (*batchv1.Job, error) {
-	timeout := time.After(5 * time.Minute)
+// waitForJob waits up to timeout (0: no limit) for a job to complete,
+// showing its pod counts as it runs
+func waitForJob(ctx context.Context, client *k8s.Client, namespace, name string, timeout time.Duration, progress *output.Progress) (*batchv1.Job, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	task := progress.Start(fmt.Sprintf("Waiting for Job/%s", name))
 	for {
 		select {
 		case <-ctx.Done():
+			task.Fail(ctx.Err())
 			return nil, ctx.Err()
-		case <-timeout:
-			return nil, fmt.Errorf("timeout waiting for job to complete\n  → Run 'kbox job logs %s' to check status", name)
+		case <-expired:
+			task.Fail(fmt.Errorf("timed out"))
+			return nil, output.WithCode(output.ErrTimeout, fmt.Errorf("timed out after %s waiting for job to complete\n  → Run 'kbox job logs %s' to check status, or wait longer with --wait-timeout", timeout, name))
 		case <-ticker.C:
 			job, err := client.Clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 			if err != nil {
@@ -422,22 +451,43 @@ func waitForJob(ctx context.Context, client *k8s.Client, namespace, name string,
 
 			// Check if job is complete
 			for _, c := range job.Status.Conditions {
-				if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
-					return job, nil
-				}
-				if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+				if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+					task.Done(fmt.Sprintf("Job/%s finished", name))
 					return job, nil
 				}
 			}
 
-			if !ciMode {
-				fmt.Printf("\r  Waiting... (active: %d, succeeded: %d, failed: %d)",
-					job.Status.Active, job.Status.Succeeded, job.Status.Failed)
-			}
+			task.Update(fmt.Sprintf("active: %d, succeeded: %d, failed: %d",
+				job.Status.Active, job.Status.Succeeded, job.Status.Failed))
 		}
 	}
 }
 
+// nextRuns returns the next three times a scheduled job runs, computed
+// client-side: as RFC 3339 times, and for the table, e.g.
+// "Mar 30 20:00, Mar 31 20:00, Apr 1 20:00 CEST"
+func nextRuns(jc config.JobConfig) ([]string, string) {
+	schedule, err := config.ParseSchedule(jc.Schedule)
+	if err != nil {
+		return nil, ""
+	}
+	// No timeZone means the cluster's, which is usually UTC
+	loc, err := time.LoadLocation(jc.TimeZone)
+	if err != nil {
+		return nil, ""
+	}
+	runs := schedule.NextRuns(time.Now().In(loc), 3)
+	if len(runs) == 0 {
+		return nil, ""
+	}
+	var times, short []string
+	for _, run := range runs {
+		times = append(times, run.Format(time.RFC3339))
+		short = append(short, run.Format("Jan 2 15:04"))
+	}
+	return times, strings.Join(short, ", ") + " " + runs[len(runs)-1].Format("MST")
+}
+
 // getJobStatus returns a human-readable status for a job
 func getJobStatus(job *batchv1.Job) string {
 	if job.Status.Succeeded > 0 {
@@ -470,11 +520,15 @@ func listJobNames(jobs []config.JobConfig) string {
 func init() {
 	// Job logs flags
 	jobLogsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	jobRunCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long to wait for the job to finish (0: no limit)")
+	jobRetryCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long to wait for the job to finish (0: no limit)")
 
 	// Add subcommands
 	jobCmd.AddCommand(jobRunCmd)
 	jobCmd.AddCommand(jobListCmd)
 	jobCmd.AddCommand(jobLogsCmd)
+	jobCmd.AddCommand(jobHistoryCmd)
+	jobCmd.AddCommand(jobRetryCmd)
 
 	rootCmd.AddCommand(jobCmd)
 }
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/format"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// AnnotationRetryOf names the failed run a 'kbox job retry' run retries
+const AnnotationRetryOf = "kbox.dev/retry-of"
+
+var jobHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "List past runs of a job",
+	Long: `List the runs of a job still in the cluster, newest first, with their
+status, duration, exit code, and pod.
+
+Finished runs beyond the job's historyLimit in kbox.yaml (default 5) are
+deleted after each 'kbox job run' and 'kbox job retry'.`,
+	Example: `  kbox job history migrate
+  kbox job history migrate --output=json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeJobNames(false),
+	RunE:              runJobHistory,
+}
+
+var jobRetryCmd = &cobra.Command{
+	Use:   "retry <name>",
+	Short: "Re-run the last failed run of a job",
+	Long: `Re-create the most recent failed run of a job with the same spec, and wait
+for it to complete.
+
+The run is retried as it ran, not as kbox.yaml defines the job now: use
+'kbox job run' to run the current definition.`,
+	Example:           `  kbox job retry migrate`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeJobNames(false),
+	RunE:              runJobRetry,
+	Annotations:       map[string]string{writesAnnotation: "create jobs.batch"},
+}
+
+// jobRun is one run of a job, as listed by kbox job history
+type jobRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Started    string `json:"started,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+	ExitCode   *int32 `json:"exitCode,omitempty"`
+	Pod        string `json:"pod,omitempty"`
+	RetryOf    string `json:"retryOf,omitempty"`
+}
+
+func runJobHistory(cmd *cobra.Command, args []string) error {
+	jobName := args[0]
+	cfg, client, namespace, err := jobTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	runs, err := listJobRuns(cmd.Context(), client, namespace, cfg.Metadata.Name, jobName)
+	if err != nil {
+		return err
+	}
+
+	var history []jobRun
+	for i := range runs {
+		job := &runs[i]
+		run := jobRun{
+			Name:    job.Name,
+			Status:  getJobStatus(job),
+			RetryOf: job.Annotations[AnnotationRetryOf],
+		}
+		if start := job.Status.StartTime; start != nil {
+			run.Started = start.UTC().Format(time.RFC3339)
+			end := time.Now()
+			if job.Status.CompletionTime != nil {
+				end = job.Status.CompletionTime.Time
+			} else if finished := jobFinishedAt(job); !finished.IsZero() {
+				end = finished
+			}
+			run.DurationMs = end.Sub(start.Time).Milliseconds()
+		}
+		if pod, err := latestJobPod(cmd.Context(), client, namespace, job.Name); err == nil {
+			run.Pod = pod.Name
+			container := jobContainer(job)
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.Name == container && status.State.Terminated != nil {
+					exitCode := status.State.Terminated.ExitCode
+					run.ExitCode = &exitCode
+				}
+			}
+		}
+		history = append(history, run)
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		if history == nil {
+			history = []jobRun{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(history)
+	}
+
+	if len(history) == 0 {
+		fmt.Printf("No runs found for job %q\n", jobName)
+		fmt.Printf("  → Run 'kbox job run %s' to create one\n", jobName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tSTATUS\tSTARTED\tDURATION\tEXIT CODE\tPOD")
+	for i, run := range history {
+		started, duration, exitCode, pod := "-", "-", "-", "-"
+		if start := runs[i].Status.StartTime; start != nil {
+			started = format.Ago(start.Time)
+			duration = (time.Duration(run.DurationMs) * time.Millisecond).Round(time.Second).String()
+		}
+		if run.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *run.ExitCode)
+		}
+		if run.Pod != "" {
+			pod = run.Pod
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.Name, run.Status, started, duration, exitCode, pod)
+	}
+	w.Flush()
+	return nil
+}
+
+func runJobRetry(cmd *cobra.Command, args []string) error {
+	jobName := args[0]
+	ciMode := IsCIMode(cmd)
+	outputFormat := GetOutputFormat(cmd)
+	cfg, client, namespace, err := jobTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	runs, err := listJobRuns(cmd.Context(), client, namespace, cfg.Metadata.Name, jobName)
+	if err != nil {
+		return err
+	}
+	var failed *batchv1.Job
+	for i := range runs {
+		if jobFailed(&runs[i]) {
+			failed = &runs[i]
+			break
+		}
+	}
+	if failed == nil {
+		return fmt.Errorf("no failed runs found for job %q\n  → Run 'kbox job history %s' to see its runs", jobName, jobName)
+	}
+
+	job := retryJob(failed, fmt.Sprintf("%s-%s-%d", cfg.Metadata.Name, jobName, time.Now().Unix()))
+	if !ciMode {
+		fmt.Printf("Retrying Job/%s...\n", failed.Name)
+	}
+	created, err := client.Clientset.BatchV1().Jobs(namespace).Create(cmd.Context(), job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	if !ciMode {
+		fmt.Printf("  Created Job/%s\n", created.Name)
+	}
+
+	progress := output.NewProgress(os.Stdout, ciMode)
+	if outputFormat == "json" {
+		progress = output.NewProgress(nil, true)
+	}
+	waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	completed, err := waitForJob(cmd.Context(), client, namespace, created.Name, waitTimeout, progress)
+	if err != nil {
+		return err
+	}
+	gcJobRuns(cmd.Context(), client, namespace, cfg, jobName)
+
+	if outputFormat == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   completed.Status.Succeeded > 0,
+			"name":      created.Name,
+			"retryOf":   failed.Name,
+			"namespace": namespace,
+			"status":    getJobStatus(completed),
+		})
+	}
+	if completed.Status.Succeeded == 0 {
+		return fmt.Errorf("job failed again\n  → Run 'kbox job logs %s' to see output", jobName)
+	}
+	if !ciMode {
+		fmt.Printf("  Job completed successfully\n")
+	}
+	return nil
+}
+
+// jobTarget loads kbox.yaml and connects to the cluster and namespace the
+// job commands act on
+func jobTarget(cmd *cobra.Command) (*config.AppConfig, *k8s.Client, string, error) {
+	kubeContext, _ := cmd.Flags().GetString("context")
+	namespace, _ := cmd.Flags().GetString("namespace")
+
+	cfg, err := config.NewLoader(".").Load()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load kbox.yaml: %w", err)
+	}
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext})
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+	}
+	return cfg, client, namespace, nil
+}
+
+// listJobRuns returns the runs of a job, newest first
+func listJobRuns(ctx context.Context, client *k8s.Client, namespace, app, jobName string) ([]batchv1.Job, error) {
+	jobs, err := client.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s,kbox.dev/job=%s", app, jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	runs := jobs.Items
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[j].CreationTimestamp.Before(&runs[i].CreationTimestamp)
+	})
+	return runs, nil
+}
+
+// retryJob returns a copy of a run to create under a new name, without the
+// selector and labels the Job controller generated for the original
+func retryJob(failed *batchv1.Job, name string) *batchv1.Job {
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   failed.Namespace,
+			Labels:      failed.Labels,
+			Annotations: map[string]string{AnnotationRetryOf: failed.Name},
+		},
+		Spec: *failed.Spec.DeepCopy(),
+	}
+	job.Spec.Selector = nil
+	job.Spec.ManualSelector = nil
+	labels := make(map[string]string)
+	for k, v := range job.Spec.Template.Labels {
+		switch k {
+		case "controller-uid", "job-name", batchv1.ControllerUidLabel, batchv1.JobNameLabel:
+		default:
+			labels[k] = v
+		}
+	}
+	job.Spec.Template.Labels = labels
+	return job
+}
+
+// gcJobRuns deletes a job's finished runs beyond its history limit. Only
+// runs kbox created on demand are deleted: the deploy's own Job is kept for
+// the next deploy to update, and a CronJob trims its own history.
+func gcJobRuns(ctx context.Context, client *k8s.Client, namespace string, cfg *config.AppConfig, jobName string) {
+	keep := int32(config.DefaultJobHistoryLimit)
+	for _, jc := range cfg.Spec.Jobs {
+		if jc.Name == jobName {
+			keep = jc.RunsToKeep()
+		}
+	}
+
+	runs, err := listJobRuns(ctx, client, namespace, cfg.Metadata.Name, jobName)
+	if err != nil {
+		return
+	}
+	var finished int32
+	propagation := metav1.DeletePropagationBackground
+	for i := range runs {
+		job := &runs[i]
+		if job.Name == cfg.Metadata.Name+"-"+jobName || len(job.OwnerReferences) > 0 {
+			continue
+		}
+		if jobFinishedAt(job).IsZero() {
+			continue
+		}
+		finished++
+		if finished <= keep {
+			continue
+		}
+		// Best-effort: a run that can't be deleted now is retried next time
+		client.Clientset.BatchV1().Jobs(namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	}
+}
+
+// jobFailed reports whether a run has failed
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// jobFinishedAt returns when a run completed or failed, or the zero time for
+// a run still going
+func jobFinishedAt(job *batchv1.Job) time.Time {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
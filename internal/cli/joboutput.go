@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// maxJobLogBytes caps the logs captured from each deploy Job
+const maxJobLogBytes = 1 << 20
+
+// captureJobs waits up to timeout for each of the Jobs a deploy applied
+// (migrations and other one-off hooks) and collects its exit status and logs
+func captureJobs(ctx context.Context, client *k8s.Client, namespace string, jobs []*batchv1.Job, timeout time.Duration, progress *output.Progress) []output.JobResult {
+	var results []output.JobResult
+	for _, job := range jobs {
+		result := output.JobResult{
+			Name: job.Labels["kbox.dev/job"],
+			Job:  job.Name,
+		}
+		if result.Name == "" {
+			result.Name = job.Name
+		}
+
+		completed, err := waitForJob(ctx, client, namespace, job.Name, timeout, progress)
+		if err != nil {
+			result.Error = firstLine(err.Error())
+			results = append(results, result)
+			continue
+		}
+		result.Succeeded = completed.Status.Succeeded > 0
+		if start := completed.Status.StartTime; start != nil {
+			end := time.Now()
+			if completed.Status.CompletionTime != nil {
+				end = completed.Status.CompletionTime.Time
+			}
+			result.DurationMs = end.Sub(start.Time).Milliseconds()
+		}
+
+		pod, err := latestJobPod(ctx, client, namespace, job.Name)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Pod = pod.Name
+		container := jobContainer(job)
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == container && status.State.Terminated != nil {
+				exitCode := status.State.Terminated.ExitCode
+				result.ExitCode = &exitCode
+			}
+		}
+
+		logs, err := jobLogs(ctx, client, namespace, pod.Name, container)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to get logs: %v", err)
+		}
+		result.Logs = logs
+		results = append(results, result)
+	}
+	return results
+}
+
+// writeJobArtifacts writes each Job's logs to <dir>/<job>.log, and a
+// jobs.json summary next to them, for CI to archive
+func writeJobArtifacts(dir string, results []output.JobResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create artifacts dir: %w", err)
+	}
+	for i := range results {
+		path := filepath.Join(dir, results[i].Name+".log")
+		if err := os.WriteFile(path, []byte(results[i].Logs), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		results[i].LogFile = path
+	}
+	summary, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "jobs.json")
+	if err := os.WriteFile(path, summary, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// latestJobPod returns the Job's most recent pod: the last retry, for a
+// Job that failed and was retried up to its backoffLimit
+func latestJobPod(ctx context.Context, client *k8s.Client, namespace, jobName string) (*corev1.Pod, error) {
+	pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for Job/%s", jobName)
+	}
+	latest := &pods.Items[0]
+	for i := range pods.Items {
+		if pods.Items[i].CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = &pods.Items[i]
+		}
+	}
+	return latest, nil
+}
+
+// jobContainer returns the name of the Job's own container, which a mesh
+// may have put a sidecar next to
+func jobContainer(job *batchv1.Job) string {
+	containers := job.Spec.Template.Spec.Containers
+	if name := job.Labels["kbox.dev/job"]; name != "" {
+		for _, c := range containers {
+			if c.Name == name {
+				return name
+			}
+		}
+	}
+	if len(containers) > 0 {
+		return containers[0].Name
+	}
+	return ""
+}
+
+// jobLogs reads a finished pod's logs, up to maxJobLogBytes
+func jobLogs(ctx context.Context, client *k8s.Client, namespace, pod, container string) (string, error) {
+	limit := int64(maxJobLogBytes)
+	stream, err := client.Clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:  container,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	return string(logs), err
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check kbox.yaml for best-practice issues",
+	Long: `Check kbox.yaml for issues that 'kbox validate' lets through, but that
+tend to bite in production:
+
+  - missing-resources     No resource requests (kbox's defaults are used)
+  - missing-health-check  No spec.healthCheck, so no readiness/liveness probes
+  - latest-tag            Image not pinned to a version or digest
+  - single-replica        One replica and no PodDisruptionBudget
+  - secret-in-env         Env values that look like passwords, tokens, or keys
+
+With --fix, the issues that can be fixed without changing what gets deployed
+are fixed in kbox.yaml, keeping its comments.
+
+Examples:
+  kbox lint                    # Lint ./kbox.yaml
+  kbox lint --fix              # Apply safe fixes
+  kbox lint --strict           # Fail on any issue (for CI)`,
+	RunE:        runLint,
+	Annotations: map[string]string{offlineAnnotation: ""},
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	fix, _ := cmd.Flags().GetBool("fix")
+	strict, _ := cmd.Flags().GetBool("strict")
+	outputFormat := GetOutputFormat(cmd)
+
+	loader := config.NewLoader(".")
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = loader.FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	cfg, err := loader.LoadFile(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load %s: %w\n  → Run 'kbox validate' for details", path, err))
+	}
+	issues := config.Lint(cfg)
+
+	fixed := 0
+	if fix && hasFixable(issues) {
+		if fixed, err = applyLintFixes(path, issues); err != nil {
+			return err
+		}
+		if cfg, err = loader.LoadFile(path); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("config is invalid after fixing: %w", err))
+		}
+		issues = config.Lint(cfg)
+	}
+
+	if outputFormat == "json" {
+		if issues == nil {
+			issues = []config.LintIssue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]interface{}{
+			"file":   path,
+			"issues": issues,
+			"fixed":  fixed,
+		}); err != nil {
+			return err
+		}
+	} else {
+		if fixed > 0 {
+			fmt.Printf("  ✓ Fixed %d issue(s) in %s\n", fixed, path)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("No issues in %s\n", path)
+		} else {
+			fmt.Printf("%d issue(s) in %s:\n", len(issues), path)
+			for _, issue := range issues {
+				fixable := ""
+				if issue.Fixable {
+					fixable = " (fixable with --fix)"
+				}
+				fmt.Printf("  ⚠ %s: %s [%s]%s\n", issue.Field, issue.Message, issue.Rule, fixable)
+			}
+		}
+	}
+
+	if strict && len(issues) > 0 {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("lint failed: %d issue(s) in strict mode", len(issues)))
+	}
+	return nil
+}
+
+func hasFixable(issues []config.LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Fixable {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLintFixes fixes the fixable issues in the config file, keeping its
+// comments. Configs split across documents or kbox.d files aren't edited:
+// which file a fix belongs in is up to the user.
+func applyLintFixes(path string, issues []config.LintIssue) (int, error) {
+	sources, err := config.ReadSources(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(sources) > 1 {
+		return 0, output.WithCode(output.ErrConfig, fmt.Errorf("--fix can't edit a config split across documents or %s files\n  → Fix the issues by hand", config.FragmentsDir))
+	}
+
+	node, err := config.LoadYAMLWithComments(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	fixed := config.ApplyLintFixes(node, issues)
+	if err := config.SaveYAMLWithComments(path, node); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return fixed, nil
+}
+
+func init() {
+	lintCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	lintCmd.Flags().Bool("fix", false, "Apply safe fixes to kbox.yaml")
+	lintCmd.Flags().Bool("strict", false, "Exit non-zero if any issue is found (for CI pipelines)")
+	rootCmd.AddCommand(lintCmd)
+}
@@ -2,14 +2,20 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/debug"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var logsCmd = &cobra.Command{
@@ -27,14 +33,23 @@ Examples:
   kbox logs myapp              # Stream logs from all myapp pods
   kbox logs myapp --no-follow  # Print recent logs and exit
   kbox logs myapp --previous   # Show previous container logs
-  kbox logs myapp --no-events  # Disable event interleaving`,
-	Args: cobra.ExactArgs(1),
+  kbox logs myapp --previous-crash  # Show the last crash and its stack trace
+  kbox logs myapp --no-events  # Disable event interleaving
+  kbox logs myapp --output-file logs/myapp.log --max-size 50Mi  # Also save to a rotating file
+  kbox logs myapp --record incident.jsonl  # Record logs and events for later review
+  kbox logs --replay incident.jsonl --replay-speed 10  # Replay a recording at 10x
+  kbox logs --all-services     # Every service of the MultiApp in ./kbox.yaml
+  kbox logs myapp --since-deploy  # Everything since the last release`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runLogs,
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
-	appName := args[0]
-
+	if replay, _ := cmd.Flags().GetString("replay"); replay != "" {
+		return replayLogs(cmd, replay)
+	}
+	allServices, _ := cmd.Flags().GetBool("all-services")
+	sinceDeploy, _ := cmd.Flags().GetBool("since-deploy")
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	follow, _ := cmd.Flags().GetBool("follow")
@@ -42,6 +57,45 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	tailLines, _ := cmd.Flags().GetInt64("tail")
 	previous, _ := cmd.Flags().GetBool("previous")
 	showEvents, _ := cmd.Flags().GetBool("events")
+	previousCrash, _ := cmd.Flags().GetBool("previous-crash")
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The app, or with --all-services the MultiApp, and its services
+	var appName string
+	var services []string
+	switch {
+	case allServices:
+		if len(args) > 0 {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("--all-services takes the services from ./kbox.yaml, not an app name"))
+		}
+		if sel.Selector != "" || sel.Deployment != "" {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("--all-services finds each service's pods itself\n  → Drop --selector and --deployment, or name one service instead"))
+		}
+		if sinceDeploy {
+			// MultiApp deploys don't save releases, so there's none to start from
+			return output.WithCode(output.ErrConfig, fmt.Errorf("--since-deploy needs a release, and MultiApp deploys don't save one\n  → Use --tail to choose how far back to start"))
+		}
+		loader := config.NewLoader(".")
+		if isMulti, err := loader.IsMultiService(); err != nil || !isMulti {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("--all-services needs a MultiApp kbox.yaml in the current directory"))
+		}
+		multiCfg, err := loader.LoadMultiService()
+		if err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err))
+		}
+		appName = multiCfg.Metadata.Name
+		services = multiCfg.ServiceOrder()
+		if namespace == "" {
+			namespace = multiCfg.Metadata.Namespace
+		}
+	case len(args) == 0:
+		return fmt.Errorf("requires an app name\n  → Usage: kbox logs <app>, kbox logs --all-services, or kbox logs --replay <recording>")
+	default:
+		appName = args[0]
+	}
 
 	// Create K8s client
 	client, err := k8s.NewClient(k8s.ClientOptions{
@@ -57,10 +111,46 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		ns = namespace
 	}
 
-	// Find pods for the app
-	pods, err := debug.FindPods(cmd.Context(), client.Clientset, ns, appName)
-	if err != nil {
-		return err
+	// Find pods for the app, or for each of its services
+	var pods []debug.PodInfo
+	if services == nil {
+		if pods, err = debug.FindPodsWith(cmd.Context(), client.Clientset, ns, appName, sel); err != nil {
+			return err
+		}
+	} else {
+		for _, svc := range services {
+			// Services run as <app>-<service>, see MultiServiceConfig.ToAppConfig
+			svcPods, err := debug.FindPodsWith(cmd.Context(), client.Clientset, ns, appName+"-"+svc, sel)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "No pods for service %s, skipping\n", svc)
+				continue
+			}
+			for i := range svcPods {
+				svcPods[i].Service = svc
+			}
+			pods = append(pods, svcPods...)
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("no pods found for any service of %q in namespace %q\n  → Deploy it with 'kbox deploy'", appName, ns)
+		}
+	}
+
+	// --since-deploy starts at the latest release, with all lines since
+	var since time.Time
+	if sinceDeploy {
+		latest, err := release.NewStore(client.Clientset, ns, appName).GetLatest(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("no release of %s to start from: %w\n  → Deploy with 'kbox deploy' first, or use --tail", appName, err)
+		}
+		since = latest.Timestamp
+		if !cmd.Flags().Changed("tail") {
+			tailLines = -1
+		}
+		fmt.Fprintf(os.Stderr, "Since revision %d, deployed %s\n", latest.Revision, latest.Timestamp.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if previousCrash {
+		return showPreviousCrash(cmd, client, ns, appName, pods)
 	}
 
 	// Print header
@@ -101,19 +191,145 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		Previous:     previous,
 		AutoPrevious: true,
 		ShowEvents:   showEvents,
+		SinceTime:    since,
+	}
+
+	logFile, err := openLogFile(cmd)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+		opts.Files = append(opts.Files, logFile)
+	}
+	if record, _ := cmd.Flags().GetString("record"); record != "" {
+		info := debug.SessionInfo{App: appName, Namespace: ns, Started: time.Now()}
+		for _, p := range pods {
+			info.Pods = append(info.Pods, p.Name)
+		}
+		recorder, err := debug.NewRecorder(record, info)
+		if err != nil {
+			return fmt.Errorf("failed to create recording: %w", err)
+		}
+		defer recorder.Close()
+		opts.Recorder = recorder
+		fmt.Fprintf(os.Stderr, "Recording logs and events to %s (replay with 'kbox logs --replay %s')\n\n", record, record)
 	}
 
 	return debug.StreamLogs(ctx, client.Clientset, ns, pods, opts, os.Stdout)
 }
 
+// replayLogs prints a session recorded with --record
+func replayLogs(cmd *cobra.Command, path string) error {
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+	showEvents, _ := cmd.Flags().GetBool("events")
+	speed, _ := cmd.Flags().GetFloat64("replay-speed")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+
+	info, lines, err := debug.ReadSession(f)
+	if err != nil {
+		return fmt.Errorf("failed to replay %s: %w", path, err)
+	}
+	if info != nil {
+		fmt.Fprintf(os.Stderr, "Replaying %s in %s, recorded %s from %d pods\n\n",
+			info.App, info.Namespace, info.Started.Local().Format("2006-01-02 15:04:05"), len(info.Pods))
+	}
+	debug.Replay(cmd.Context(), lines, os.Stdout, debug.LogsOptions{Timestamps: timestamps, ShowEvents: showEvents}, speed)
+	return nil
+}
+
+// addLogFileFlags adds the flags that save streamed logs to a file
+func addLogFileFlags(cmd *cobra.Command) {
+	cmd.Flags().String("output-file", "", "Also write the logs to this file")
+	cmd.Flags().String("file-format", debug.LogFormatText, "Format of --output-file: text or json (JSON lines)")
+	cmd.Flags().String("max-size", "100Mi", "Rotate --output-file when it reaches this size (0 never rotates)")
+	cmd.Flags().Int("max-files", debug.DefaultLogMaxFiles, "Number of rotated --output-file files to keep")
+}
+
+// openLogFile opens the --output-file log file, or returns nil without one
+func openLogFile(cmd *cobra.Command) (*debug.LogFile, error) {
+	path, _ := cmd.Flags().GetString("output-file")
+	if path == "" {
+		return nil, nil
+	}
+	format, _ := cmd.Flags().GetString("file-format")
+	maxSize, _ := cmd.Flags().GetString("max-size")
+	maxFiles, _ := cmd.Flags().GetInt("max-files")
+
+	size, err := resource.ParseQuantity(maxSize)
+	if err != nil {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("invalid --max-size %q: %w\n  → Use a size like 50Mi or 1Gi", maxSize, err))
+	}
+	logFile, err := debug.OpenLogFile(path, debug.LogFileOptions{Format: format, MaxSize: size.Value(), MaxFiles: maxFiles})
+	if err != nil {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("failed to open log file: %w", err))
+	}
+	return logFile, nil
+}
+
+// showPreviousCrash prints the most recent container crash with its extracted stack trace
+func showPreviousCrash(cmd *cobra.Command, client *k8s.Client, namespace, appName string, pods []debug.PodInfo) error {
+	crash, err := debug.FindLastCrash(cmd.Context(), client.Clientset, namespace, pods)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := GetOutputFormat(cmd) == "json"
+	if crash == nil {
+		if jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"success": true,
+				"app":     appName,
+				"crash":   nil,
+			})
+		}
+		fmt.Printf("No crashed containers found for %s\n", appName)
+		return nil
+	}
+
+	// Fetch enough history to capture a full trace before the final lines
+	if err := debug.FetchCrashLogs(cmd.Context(), client.Clientset, namespace, crash, 500); err != nil {
+		return fmt.Errorf("%w\n  → The crashed container's logs may have been rotated; try 'kbox logs %s --previous'", err, appName)
+	}
+
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"app":     appName,
+			"crash":   crash,
+		})
+	}
+
+	tailLines, _ := cmd.Flags().GetInt64("tail")
+	contextLines := 20
+	if cmd.Flags().Changed("tail") {
+		contextLines = int(tailLines)
+	}
+	debug.PrintCrash(os.Stdout, crash, contextLines)
+	return nil
+}
+
 func init() {
 	logsCmd.Flags().BoolP("follow", "f", true, "Follow log output")
 	logsCmd.Flags().BoolP("timestamps", "t", true, "Show timestamps")
 	logsCmd.Flags().Int64("tail", 100, "Number of lines to show from the end")
 	logsCmd.Flags().BoolP("previous", "p", false, "Show previous container logs")
 	logsCmd.Flags().Bool("events", true, "Show K8s events interleaved with logs")
+	logsCmd.Flags().Bool("previous-crash", false, "Show the last crash with its stack trace and exit")
 	logsCmd.Flags().Bool("no-follow", false, "Don't follow, just print recent logs")
 	logsCmd.Flags().Bool("no-events", false, "Don't show K8s events")
+	logsCmd.Flags().Bool("all-services", false, "Merge the logs of every service of the MultiApp in ./kbox.yaml")
+	logsCmd.Flags().Bool("since-deploy", false, "Start at the latest release (all lines since, unless --tail is set)")
+	logsCmd.Flags().String("record", "", "Record the session (logs and K8s events) to this file")
+	logsCmd.Flags().String("replay", "", "Replay a session recorded with --record instead of streaming")
+	logsCmd.Flags().Float64("replay-speed", 0, "Pace --replay by the recorded timestamps: 1 is real time, 10 is 10x (0: all at once)")
+	addPodSelectorFlags(logsCmd)
+	addLogFileFlags(logsCmd)
 
 	rootCmd.AddCommand(logsCmd)
 }
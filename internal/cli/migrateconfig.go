@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/golden"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+var migrateConfigCmd = &cobra.Command{
+	Use:   "migrate-config",
+	Short: "Upgrade kbox.yaml to the current apiVersion",
+	Long: `Upgrade a kbox.yaml written for an older apiVersion to the current
+one (` + config.DefaultAPIVersion + `), in place and keeping its comments.
+
+kbox still reads older versions, migrating them as it loads them and
+warning about what changed; this makes the change permanent. ` + config.DefaultAPIVersion + ` is
+the only apiVersion so far, so there's nothing to migrate yet.
+
+Examples:
+  kbox migrate-config              # Upgrade ./kbox.yaml
+  kbox migrate-config --dry-run    # Show the changes without writing them
+  kbox migrate-config -f app/kbox.yaml`,
+	RunE:        runMigrateConfig,
+	Annotations: map[string]string{offlineAnnotation: ""},
+}
+
+func runMigrateConfig(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to read %s: %w", path, err))
+	}
+	node, err := config.LoadYAMLWithComments(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to parse %s: %w", path, err))
+	}
+	from, changes := config.MigrateNode(node)
+
+	if len(changes) == 0 {
+		if jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"success": true,
+				"file":    path,
+				"from":    from,
+				"to":      from,
+				"changes": []string{},
+			})
+		}
+		if from != "" && from != config.DefaultAPIVersion {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%s uses apiVersion %q, which kbox can't migrate\n  → Supported: %s", path, from, config.DefaultAPIVersion))
+		}
+		fmt.Printf("%s is already %s\n", path, config.DefaultAPIVersion)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	encoder.Close()
+
+	// Check the migrated config loads, with any kbox.d fragments, before
+	// touching the file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kbox-migrate-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if err := loadConfigFile(tmp.Name()); err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("the migrated %s is invalid: %w\n  → Fix it by hand, then run 'kbox validate'", path, err))
+	}
+
+	if !dryRun {
+		if err := os.Chmod(tmp.Name(), 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"file":    path,
+			"from":    from,
+			"to":      config.DefaultAPIVersion,
+			"changes": changes,
+			"dry_run": dryRun,
+		})
+	}
+
+	fmt.Print(golden.Diff(string(before), buf.String()))
+	fmt.Println()
+	if dryRun {
+		fmt.Printf("Dry run: %s not changed\n", path)
+		return nil
+	}
+	fmt.Printf("✓ Migrated %s from %s to %s:\n", path, from, config.DefaultAPIVersion)
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	return nil
+}
+
+// loadConfigFile loads and validates an App or MultiApp config
+func loadConfigFile(path string) error {
+	loader := config.NewLoader(filepath.Dir(path))
+	isMulti, err := config.IsMultiService(path)
+	if err != nil {
+		return err
+	}
+	if isMulti {
+		_, err = loader.LoadMultiServiceFile(path)
+	} else {
+		_, err = loader.LoadFile(path)
+	}
+	return err
+}
+
+// warnDeprecatedConfig warns when kbox.yaml, or the --file given, is
+// written for an older apiVersion, listing what reading it as the current
+// one changed
+func warnDeprecatedConfig(cmd *cobra.Command) {
+	if cmd == migrateConfigCmd {
+		return
+	}
+	path := ""
+	if f := cmd.Flags().Lookup("file"); f != nil {
+		path = f.Value.String()
+	}
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return
+		}
+	}
+	from, changes := config.ReadMigrations(path)
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s uses the deprecated apiVersion %s, read as %s:\n", filepath.Base(path), from, config.DefaultAPIVersion)
+	for _, change := range changes {
+		fmt.Fprintf(os.Stderr, "  - %s\n", change)
+	}
+	fmt.Fprintf(os.Stderr, "  → Run 'kbox migrate-config' to update it\n")
+}
+
+func init() {
+	migrateConfigCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	migrateConfigCmd.Flags().Bool("dry-run", false, "Print the changes without writing them")
+	rootCmd.AddCommand(migrateConfigCmd)
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/notify"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// addNotifyFlags adds --notify/--no-notify to a command that sends notifications
+func addNotifyFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("notify", false, "Send notifications even if kbox.yaml sets notifications.enabled: false")
+	cmd.Flags().Bool("no-notify", false, "Don't send the notifications configured in kbox.yaml")
+	cmd.MarkFlagsMutuallyExclusive("notify", "no-notify")
+}
+
+// sendNotification posts an event to the channels in kbox.yaml's notifications block.
+// Delivery failures are warnings: a notification never fails the command.
+func sendNotification(cmd *cobra.Command, cfg *config.NotificationsConfig, event notify.Event) {
+	if cfg == nil {
+		return
+	}
+	enabled := cfg.Enabled == nil || *cfg.Enabled
+	if force, _ := cmd.Flags().GetBool("notify"); force {
+		enabled = true
+	}
+	if skip, _ := cmd.Flags().GetBool("no-notify"); skip {
+		enabled = false
+	}
+	if !enabled {
+		return
+	}
+
+	notifier, err := notify.New(cfg)
+	if err == nil {
+		// The command's context may already be cancelled (e.g., Ctrl+C during a rollout)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(cmd.Context()), 15*time.Second)
+		defer cancel()
+		err = notifier.Send(ctx, event)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send notification: %v\n", err)
+	}
+}
+
+// addStatusWebhookFlag adds --status-webhook to a long-running command
+func addStatusWebhookFlag(cmd *cobra.Command) {
+	cmd.Flags().String("status-webhook", "", "POST JSON progress events (stages, tasks, final result) to this URL (also KBOX_STATUS_WEBHOOK)")
+}
+
+// startStatusWebhook starts posting the progress of operation to
+// --status-webhook, or returns nil when none is set. Until it's finished,
+// every Progress reports its tasks to it.
+func startStatusWebhook(cmd *cobra.Command, operation string) (*notify.StatusWebhook, error) {
+	url, _ := cmd.Flags().GetString("status-webhook")
+	if url == "" {
+		url = os.Getenv("KBOX_STATUS_WEBHOOK")
+	}
+	if url == "" {
+		return nil, nil
+	}
+	status, err := notify.NewStatusWebhook(url, operation)
+	if err != nil {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("--status-webhook: %w", err))
+	}
+	output.SetProgressHook(func(e output.TaskEvent) {
+		status.Task(e.Label, e.Status, e.Message)
+	})
+	return status, nil
+}
+
+// finishStatusWebhook posts the operation's result to the status webhook.
+// Like notifications, delivery failures are warnings. Finishing twice posts
+// the first result only.
+func finishStatusWebhook(status *notify.StatusWebhook, err error) {
+	if status == nil {
+		return
+	}
+	output.SetProgressHook(nil)
+	if err := status.Finish(err); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to post status events: %v\n", err)
+	}
+}
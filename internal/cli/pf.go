@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -26,10 +27,12 @@ Port format:
   8080:3000  - Forward local 8080 to remote 3000
 
 Examples:
-  kbox pf myapp 8080        # Forward localhost:8080 to pod:8080
-  kbox pf myapp 9000:8080   # Forward localhost:9000 to pod:8080`,
-	Args: cobra.ExactArgs(2),
-	RunE: runPortForward,
+  kbox pf myapp 8080                # Forward localhost:8080 to pod:8080
+  kbox pf myapp 9000:8080           # Forward localhost:9000 to pod:8080
+  kbox pf myapp 8080 --pick oldest  # Forward to the oldest pod`,
+	Args:        cobra.ExactArgs(2),
+	RunE:        runPortForward,
+	Annotations: map[string]string{writesAnnotation: "create pods/portforward"},
 }
 
 func runPortForward(cmd *cobra.Command, args []string) error {
@@ -39,6 +42,11 @@ func runPortForward(cmd *cobra.Command, args []string) error {
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Parse port
 	localPort, remotePort, err := debug.ParsePort(portSpec)
 	if err != nil {
@@ -59,23 +67,12 @@ func runPortForward(cmd *cobra.Command, args []string) error {
 		ns = namespace
 	}
 
-	// Find pods for the app
-	pods, err := debug.FindPods(cmd.Context(), client.Clientset, ns, appName)
+	// Find pods for the app and pick the first ready one
+	pods, err := debug.FindPodsWith(cmd.Context(), client.Clientset, ns, appName, sel)
 	if err != nil {
 		return err
 	}
-
-	// Pick the first ready pod, or just the first one
-	var targetPod debug.PodInfo
-	for _, p := range pods {
-		if p.Ready {
-			targetPod = p
-			break
-		}
-	}
-	if targetPod.Name == "" {
-		targetPod = pods[0]
-	}
+	targetPod := debug.TargetPod(pods)
 
 	// Set up channels for port-forward lifecycle
 	stopCh := make(chan struct{}, 1)
@@ -115,5 +112,50 @@ func runPortForward(cmd *cobra.Command, args []string) error {
 }
 
 func init() {
+	addPodSelectorFlags(pfCmd)
 	rootCmd.AddCommand(pfCmd)
 }
+
+// forwardToApp port-forwards localPort to a ready pod of app in the background.
+// It returns once the forward is ready; cancelling ctx tears it down.
+func forwardToApp(ctx context.Context, client *k8s.Client, ns, app string, sel debug.PodSelector, localPort, remotePort int) error {
+	pods, err := debug.FindPodsWith(ctx, client.Clientset, ns, app, sel)
+	if err != nil || len(pods) == 0 {
+		return fmt.Errorf("no pods found for %q\n  -> Is the app deployed? Try 'kbox up' first", app)
+	}
+
+	// Pick the first ready pod
+	targetPod := debug.TargetPod(pods)
+	if !targetPod.Ready {
+		fmt.Fprintf(os.Stderr, "Warning: No ready pods found for %s, using %s anyway\n", app, targetPod.Name)
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		opts := debug.PortForwardOptions{
+			LocalPort:  localPort,
+			RemotePort: remotePort,
+			StopCh:     stopCh,
+			ReadyCh:    readyCh,
+			Out:        nil, // Suppress output
+			ErrOut:     nil,
+		}
+		errCh <- debug.PortForward(ctx, client.Clientset, client.RestConfig, ns, targetPod.Name, opts)
+	}()
+	go func() {
+		<-ctx.Done()
+		close(stopCh)
+	}()
+
+	// Wait for port-forward to be ready
+	select {
+	case <-readyCh:
+		return nil
+	case err := <-errCh:
+		return fmt.Errorf("port-forward to %s failed: %w", app, err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// dockerBuildArgs returns the docker arguments that build dir (with dockerfile,
+// if set) as tag for platforms. One platform is a plain cross-build; several
+// need buildx, which can only store the manifest list in a registry.
+func dockerBuildArgs(tag, dockerfile, dir string, platforms []string) []string {
+	args := []string{"build"}
+	if len(platforms) > 1 {
+		args = []string{"buildx", "build", "--push"}
+	}
+	if len(platforms) > 0 {
+		args = append(args, "--platform", strings.Join(platforms, ","))
+	}
+	args = append(args, "-t", tag)
+	if dockerfile != "" {
+		args = append(args, "-f", dockerfile)
+	}
+	return append(args, dir)
+}
+
+// platformTag suffixes tag with the platforms it was built for, so images
+// built for different nodes from the same sources don't overwrite each other
+func platformTag(tag string, platforms []string) string {
+	if len(platforms) == 0 {
+		return tag
+	}
+	suffix := strings.ReplaceAll(strings.Join(platforms, "_"), "/", "-")
+	return tag + "-" + suffix
+}
+
+// imagePlatform returns the os/arch of a local docker image, or "" if unknown
+func imagePlatform(ctx context.Context, tag string) string {
+	out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Os}}/{{.Architecture}}", tag).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// nodePlatforms counts the cluster's nodes by os/arch
+func nodePlatforms(ctx context.Context, client *k8s.Client) (map[string]int, error) {
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	platforms := make(map[string]int)
+	for _, node := range nodes.Items {
+		platforms[node.Labels[render.LabelNodeOS]+"/"+node.Labels[render.LabelNodeArch]]++
+	}
+	return platforms, nil
+}
+
+// checkPlatform warns when no node can run the app's pods: there are no nodes
+// of the target platform, or image (if built locally) is for another platform.
+// It only warns, since nodes may be added by a cluster autoscaler.
+func checkPlatform(ctx context.Context, client *k8s.Client, spec *config.AppSpec, image string, errOut io.Writer) {
+	nodes, err := nodePlatforms(ctx, client)
+	if err != nil || len(nodes) == 0 {
+		// Listing nodes needs cluster-wide RBAC; skip the check without it
+		return
+	}
+	available := slices.Sorted(maps.Keys(nodes))
+
+	target := spec.TargetPlatform()
+	if target != "" && nodes[target] == 0 {
+		fmt.Fprintf(errOut, "Warning: no %s nodes in the cluster (found %s); pods will stay Pending\n",
+			target, strings.Join(available, ", "))
+	}
+
+	if image == "" {
+		return
+	}
+	built := imagePlatform(ctx, image)
+	switch {
+	case built == "":
+	case target != "" && built != target:
+		fmt.Fprintf(errOut, "Warning: image %s is built for %s but the app targets %s nodes\n", image, built, target)
+	case target == "" && nodes[built] == 0:
+		fmt.Fprintf(errOut, "Warning: image %s is built for %s but the cluster only has %s nodes\n"+
+			"  → Set spec.arch or build.platforms to build for the cluster's nodes\n",
+			image, built, strings.Join(available, ", "))
+	}
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/plugin"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage kbox plugins",
+	Long: `Plugins add subcommands to kbox.
+
+Any executable named kbox-<name> on your PATH becomes 'kbox <name>'. Plugins can
+also be declared by a manifest in ~/.kbox/plugins/<name>.yaml:
+
+  name: costreport
+  description: Estimate the monthly cost of this app
+  command: ./costreport.sh    # relative to the manifest, or on PATH
+  args: ["--currency", "USD"] # prepended to the user's arguments
+
+A plugin receives its arguments unchanged, plus a JSON object on stdin with the
+parsed kbox.yaml (config), kube_context, namespace and kbox_version. The same
+values are set as KBOX_CONTEXT, KBOX_NAMESPACE, KBOX_CONFIG_FILE, KBOX_VERSION
+and KBOX_BIN (the kbox binary, for calling back into kbox).
+
+Built-in commands always win over plugins with the same name.`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed plugins",
+	Args:  cobra.NoArgs,
+	RunE:  runPluginList,
+}
+
+func runPluginList(cmd *cobra.Command, args []string) error {
+	plugins, skipped := plugin.Discover(plugin.DefaultManifestDir(), os.Getenv("PATH"))
+
+	if GetOutputFormat(cmd) == "json" {
+		if plugins == nil {
+			plugins = []plugin.Plugin{}
+		}
+		result := map[string]interface{}{
+			"plugins": plugins,
+		}
+		if len(skipped) > 0 {
+			warnings := make([]string, len(skipped))
+			for i, err := range skipped {
+				warnings[i] = err.Error()
+			}
+			result["warnings"] = warnings
+		}
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "  ⚠ %v (skipped)\n", err)
+	}
+
+	if len(plugins) == 0 {
+		fmt.Println("No plugins found.")
+		fmt.Println("  → Add an executable named kbox-<name> to your PATH, or a manifest to ~/.kbox/plugins")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tDESCRIPTION")
+	for _, p := range plugins {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.Path, p.Description)
+	}
+	w.Flush()
+
+	for _, p := range plugins {
+		if isBuiltinCommand(p.Name) {
+			fmt.Printf("  ⚠ %s is hidden by the built-in 'kbox %s' command\n", p.Path, p.Name)
+		}
+		for _, shadowed := range p.Shadowed {
+			fmt.Printf("  ⚠ %s is hidden by %s\n", shadowed, p.Path)
+		}
+	}
+	return nil
+}
+
+// isBuiltinCommand reports whether name is a kbox command or alias
+func isBuiltinCommand(name string) bool {
+	for _, c := range rootCmd.Commands() {
+		if _, ok := c.Annotations[pluginAnnotation]; !ok && (c.Name() == name || c.HasAlias(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginAnnotation marks commands added for plugins
+const pluginAnnotation = "kbox.dev/plugin"
+
+// registerPlugins adds a subcommand for every discovered plugin that doesn't
+// clash with a built-in command. Broken manifests are skipped here and
+// reported by 'kbox plugin list'.
+func registerPlugins(root *cobra.Command) {
+	plugins, _ := plugin.Discover(plugin.DefaultManifestDir(), os.Getenv("PATH"))
+	for _, p := range plugins {
+		if isBuiltinCommand(p.Name) {
+			continue
+		}
+		short := p.Description
+		if short == "" {
+			short = "Plugin " + p.Path
+		}
+		root.AddCommand(&cobra.Command{
+			Use:                p.Name,
+			Short:              short,
+			Annotations:        map[string]string{pluginAnnotation: p.Path, sensitiveArgsAnnotation: ""},
+			DisableFlagParsing: true, // The plugin parses its own flags
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runPlugin(cmd, p, args)
+			},
+		})
+	}
+}
+
+func runPlugin(cmd *cobra.Command, p plugin.Plugin, args []string) error {
+	// Flags aren't parsed for plugins; pick out kbox's own so the plugin
+	// sees the same cluster kbox would use
+	kubeContext, _ := cmd.Flags().GetString("context")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if v := pluginArgFlag(args, "--context"); v != "" {
+		kubeContext = v
+	}
+	if v := pluginArgFlag(args, "-n", "--namespace"); v != "" {
+		namespace = v
+	}
+
+	pctx := &plugin.Context{
+		Name:        p.Name,
+		Args:        args,
+		KboxVersion: Version,
+		KubeContext: kubeContext,
+		Namespace:   namespace,
+	}
+	if exe, err := os.Executable(); err == nil {
+		pctx.KboxPath = exe
+	}
+	if client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext, Namespace: namespace}); err == nil {
+		pctx.KubeContext = client.Context
+		pctx.Namespace = client.Namespace
+	}
+
+	loader := config.NewLoader(".")
+	if path, err := loader.FindConfigFile(); err == nil {
+		pctx.ConfigFile = path
+		var cfg interface{}
+		if multi, _ := loader.IsMultiService(); multi {
+			cfg, err = loader.LoadMultiService()
+		} else {
+			cfg, err = loader.Load()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ⚠ %s not passed to plugin: %v\n", path, err)
+		} else {
+			pctx.Config = cfg
+		}
+	}
+
+	err := p.Run(cmd.Context(), pctx, args, os.Stdout, os.Stderr)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// The plugin reported its own error; keep its exit status
+		return &output.ReportedError{Err: err, Status: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run plugin %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// pluginArgFlag returns the value of the first of names in args
+// (as "--flag value" or "--flag=value")
+func pluginArgFlag(args []string, names ...string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if v, ok := strings.CutPrefix(arg, name+"="); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// Namespace labels through which Pod Security Admission enforces or warns about a level
+const (
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	podSecurityWarnLabel    = "pod-security.kubernetes.io/warn"
+)
+
+// checkPodSecurity compares the bundle's pods with the Pod Security Standard
+// the namespace enforces, so a deploy fails up front instead of leaving
+// ReplicaSets that can't create pods. Violations of the namespace's warn
+// level are printed to errOut. A namespace kbox can't read is not checked.
+func checkPodSecurity(ctx context.Context, client *k8s.Client, namespace string, bundle *render.Bundle, errOut io.Writer) error {
+	ns, err := client.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+
+	enforce := ns.Labels[podSecurityEnforceLabel]
+	if violations := bundle.PodSecurityViolations(enforce); len(violations) > 0 {
+		return output.WithCode(output.ErrPolicy, fmt.Errorf(
+			"namespace %s enforces the %s Pod Security Standard and would reject these pods:\n    - %s\n  → Adjust spec.securityContext or spec.securityProfile to comply\n  → Or deploy to a namespace with a less strict level",
+			namespace, enforce, strings.Join(violations, "\n    - ")))
+	}
+
+	if warn := ns.Labels[podSecurityWarnLabel]; warn != "" && warn != enforce {
+		for _, v := range bundle.PodSecurityViolations(warn) {
+			fmt.Fprintf(errOut, "Warning: violates the %s Pod Security Standard of namespace %s: %s\n", warn, namespace, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// addPodSelectorFlags adds the flags choosing which of an app's pods a
+// command uses (logs, shell, pf, share, dashboard)
+func addPodSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("selector", "l", "", "Label selector for the pods, instead of the app's labels (e.g. track=canary)")
+	cmd.Flags().String("deployment", "", "Use the pods owned by this Deployment, across its ReplicaSets")
+	cmd.Flags().String("pick", "", "Use only the newest or oldest pod: newest, oldest")
+	cmd.Flags().Bool("include-terminating", false, "Include pods that are shutting down")
+}
+
+// podSelector reads the flags added by addPodSelectorFlags
+func podSelector(cmd *cobra.Command) (debug.PodSelector, error) {
+	var sel debug.PodSelector
+	sel.Selector, _ = cmd.Flags().GetString("selector")
+	sel.Deployment, _ = cmd.Flags().GetString("deployment")
+	sel.Pick, _ = cmd.Flags().GetString("pick")
+	sel.IncludeTerminating, _ = cmd.Flags().GetBool("include-terminating")
+	if err := sel.Validate(); err != nil {
+		return sel, output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Use --selector key=value[,key=value] and --pick newest or oldest", err))
+	}
+	return sel, nil
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +13,10 @@ import (
 
 	"github.com/bobbyrathoree/kbox/internal/apply"
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/format"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/notify"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/preview"
 	"github.com/bobbyrathoree/kbox/internal/render"
 )
@@ -38,13 +42,22 @@ var previewCreateCmd = &cobra.Command{
 	Long: `Create a new preview environment with an isolated namespace.
 
 The preview will have its own namespace containing all resources
-from your kbox.yaml configuration.`,
+from your kbox.yaml configuration.
+
+With --clone-data-from, the data of each dependency (postgres, mysql,
+mongodb, redis) is copied from an environment in kbox.yaml, or a namespace,
+into the preview's once it is running: a dump streamed into a restore,
+never stored locally.`,
 	Example: `  # Create a preview for pull request #123
   kbox preview create --name=pr-123
 
   # Create a preview with a custom name
-  kbox preview create --name=feature-dark-mode`,
-	RunE: runPreviewCreate,
+  kbox preview create --name=feature-dark-mode
+
+  # Seed the preview's databases with a copy of staging's data
+  kbox preview create --name=pr-123 --clone-data-from staging`,
+	RunE:        runPreviewCreate,
+	Annotations: map[string]string{writesAnnotation: "create namespaces"},
 }
 
 var previewDestroyCmd = &cobra.Command{
@@ -54,8 +67,12 @@ var previewDestroyCmd = &cobra.Command{
 
 This deletes the namespace and cascades to all resources within it.`,
 	Example: `  # Destroy the preview for PR #123
-  kbox preview destroy --name=pr-123`,
-	RunE: runPreviewDestroy,
+  kbox preview destroy --name=pr-123
+
+  # From CI, when the pull request closes
+  kbox preview destroy --name=pr-123 --yes`,
+	RunE:        runPreviewDestroy,
+	Annotations: map[string]string{writesAnnotation: "delete namespaces"},
 }
 
 var previewListCmd = &cobra.Command{
@@ -67,12 +84,20 @@ var previewListCmd = &cobra.Command{
 	RunE: runPreviewList,
 }
 
-func runPreviewCreate(cmd *cobra.Command, args []string) error {
+func runPreviewCreate(cmd *cobra.Command, args []string) (err error) {
 	name, _ := cmd.Flags().GetString("name")
 	kubeContext, _ := cmd.Flags().GetString("context")
+	cloneFrom, _ := cmd.Flags().GetString("clone-data-from")
 	ciMode := IsCIMode(cmd)
 	outputFormat := GetOutputFormat(cmd)
 
+	status, err := startStatusWebhook(cmd, "preview_create")
+	if err != nil {
+		return err
+	}
+	defer func() { finishStatusWebhook(status, err) }()
+	status.Stage("namespace", 0)
+
 	if name == "" {
 		return fmt.Errorf("preview name is required\n  → Use: kbox preview create --name=<name>")
 	}
@@ -88,6 +113,7 @@ func runPreviewCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load kbox.yaml: %w", err)
 	}
+	status.SetApp(cfg.Metadata.Name)
 
 	// Connect to cluster
 	client, err := k8s.NewClient(k8s.ClientOptions{
@@ -97,12 +123,28 @@ func runPreviewCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to connect to cluster: %w", err)
 	}
 
+	event := notify.Event{
+		Type:    notify.EventPreviewCreate,
+		App:     cfg.Metadata.Name,
+		Context: client.Context,
+		Preview: name,
+		Image:   cfg.Spec.Image,
+	}
+	start := time.Now()
+	notifyFailure := func(err error) error {
+		event.Duration = time.Since(start)
+		event.Error = firstLine(err.Error())
+		sendNotification(cmd, cfg.Notifications, event)
+		return err
+	}
+
 	// Create preview namespace
 	mgr := preview.NewManager(client.Clientset, cfg.Metadata.Name)
 	info, err := mgr.Create(cmd.Context(), name)
 	if err != nil {
-		return err
+		return notifyFailure(err)
 	}
+	event.Namespace = info.Namespace
 
 	if !ciMode {
 		fmt.Printf("Created preview namespace: %s\n", info.Namespace)
@@ -114,15 +156,17 @@ func runPreviewCreate(cmd *cobra.Command, args []string) error {
 	cfg.Metadata.Namespace = info.Namespace
 
 	// Render
+	status.Stage("render", 20)
 	renderer := render.New(cfg)
 	bundle, err := renderer.Render()
 	if err != nil {
 		// Try to clean up namespace on failure
 		_ = mgr.Destroy(cmd.Context(), name)
-		return fmt.Errorf("failed to render: %w", err)
+		return notifyFailure(fmt.Errorf("failed to render: %w", err))
 	}
 
 	// Apply
+	status.Stage("apply", 30)
 	var applyOut io.Writer = os.Stdout
 	if ciMode {
 		applyOut = io.Discard // Suppress apply output in CI mode
@@ -133,9 +177,20 @@ func runPreviewCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Try to clean up namespace on failure
 		_ = mgr.Destroy(cmd.Context(), name)
-		return fmt.Errorf("failed to deploy to preview: %w", err)
+		return notifyFailure(fmt.Errorf("failed to deploy to preview: %w", err))
+	}
+
+	if cloneFrom != "" {
+		status.Stage("clone data", 70)
+		if err := clonePreviewData(cmd, cfg, client, engine, info.Namespace, cloneFrom, ciMode || outputFormat == "json"); err != nil {
+			return notifyFailure(fmt.Errorf("%w\n  → The preview is running without the data; destroy it with 'kbox preview destroy --name=%s'", err, name))
+		}
 	}
 
+	event.Duration = time.Since(start)
+	event.Succeeded = true
+	sendNotification(cmd, cfg.Notifications, event)
+
 	// Output
 	if outputFormat == "json" {
 		return json.NewEncoder(os.Stdout).Encode(info)
@@ -153,8 +208,83 @@ func runPreviewCreate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// clonePreviewData copies each dependency's data from the --clone-data-from
+// source into the preview, once the preview's dependency is ready
+func clonePreviewData(cmd *cobra.Command, cfg *config.AppConfig, client *k8s.Client, engine *apply.Engine, namespace, from string, quiet bool) error {
+	if len(cfg.Spec.Dependencies) == 0 {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: --clone-data-from: %s has no dependencies to clone\n", cfg.Metadata.Name)
+		}
+		return nil
+	}
+	src, err := cloneSource(cfg, client, from)
+	if err != nil {
+		return err
+	}
+	dst := preview.Endpoint{Client: client.Clientset, Config: client.RestConfig, Namespace: namespace}
+
+	app := cfg.Metadata.Name
+	for _, dep := range cfg.Spec.Dependencies {
+		if dep.External != nil {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Warning: --clone-data-from: %s is external, skipping\n", dep.Type)
+			}
+			continue
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
+		err := engine.WaitForRollout(ctx, namespace, fmt.Sprintf("%s-%s", app, dep.Type))
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s did not become ready for cloning: %w", dep.Type, err)
+		}
+
+		cloneStart := time.Now()
+		n, err := preview.CloneData(cmd.Context(), app, dep.Type, src, dst)
+		if err != nil {
+			return fmt.Errorf("failed to clone %s data from %s: %w", dep.Type, from, err)
+		}
+		if !quiet {
+			fmt.Printf("  ✓ Cloned %s data from %s (%.1f MB in %v)\n", dep.Type, src.Namespace,
+				float64(n)/(1024*1024), time.Since(cloneStart).Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// cloneSource resolves --clone-data-from: an environment in kbox.yaml, on
+// the cluster and in the namespace it is bound to, or else a namespace on
+// the preview's cluster
+func cloneSource(cfg *config.AppConfig, client *k8s.Client, from string) (preview.Endpoint, error) {
+	src := preview.Endpoint{Client: client.Clientset, Config: client.RestConfig, Namespace: from}
+	if _, ok := cfg.Environments[from]; !ok {
+		return src, nil
+	}
+
+	target := cfg.EnvironmentTarget(from)
+	src.Namespace = target.Namespace
+	if src.Namespace == "" {
+		src.Namespace = cfg.Metadata.Namespace
+	}
+	if target.Context != "" && target.Context != client.Context {
+		envClient, err := k8s.NewClient(k8s.ClientOptions{Context: target.Context})
+		if err != nil {
+			return src, fmt.Errorf("failed to connect to %s's cluster: %w", from, err)
+		}
+		src.Client, src.Config = envClient.Clientset, envClient.RestConfig
+		if src.Namespace == "" {
+			src.Namespace = envClient.Namespace
+		}
+	}
+	if src.Namespace == "" {
+		src.Namespace = client.Namespace
+	}
+	return src, nil
+}
+
 func runPreviewDestroy(cmd *cobra.Command, args []string) error {
 	name, _ := cmd.Flags().GetString("name")
+	wait, _ := cmd.Flags().GetBool("wait")
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	ciMode := IsCIMode(cmd)
 	outputFormat := GetOutputFormat(cmd)
@@ -180,11 +310,45 @@ func runPreviewDestroy(cmd *cobra.Command, args []string) error {
 
 	// Destroy preview
 	mgr := preview.NewManager(client.Clientset, cfg.Metadata.Name)
+	if err := confirm(cmd, confirmation{
+		Action:  fmt.Sprintf("destroying preview %q", name),
+		Details: []string{fmt.Sprintf("This will delete namespace %q and everything in it, including its dependencies' data.", mgr.NamespaceName(name))},
+	}); err != nil {
+		return err
+	}
+	start := time.Now()
 	err = mgr.Destroy(cmd.Context(), name)
+	event := notify.Event{
+		Type:      notify.EventPreviewDestroy,
+		App:       cfg.Metadata.Name,
+		Context:   client.Context,
+		Namespace: mgr.NamespaceName(name),
+		Preview:   name,
+		Duration:  time.Since(start),
+		Succeeded: err == nil,
+	}
+	if err != nil {
+		event.Error = firstLine(err.Error())
+	}
+	sendNotification(cmd, cfg.Notifications, event)
 	if err != nil {
 		return err
 	}
 
+	// Namespace deletion is asynchronous; optionally block until it's gone
+	if wait {
+		var progressOut io.Writer = os.Stdout
+		if outputFormat == "json" {
+			progressOut = nil
+		}
+		task := output.NewProgress(progressOut, ciMode).Start(fmt.Sprintf("Waiting for preview %q to be removed", name))
+		if err := mgr.WaitDestroyed(cmd.Context(), name, timeout); err != nil {
+			task.Fail(fmt.Errorf("timed out"))
+			return err
+		}
+		task.Done(fmt.Sprintf("Preview %q removed", name))
+	}
+
 	// Output
 	if outputFormat == "json" {
 		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
@@ -245,7 +409,7 @@ func runPreviewList(cmd *cobra.Command, args []string) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "NAME\tNAMESPACE\tAGE\tSTATUS")
 	for _, p := range previews {
-		age := formatAge(p.Created)
+		age := format.Age(p.Created)
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Namespace, age, p.Status)
 	}
 	w.Flush()
@@ -253,30 +417,22 @@ func runPreviewList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// formatAge formats a duration since a time as a human-readable age
-func formatAge(t time.Time) string {
-	d := time.Since(t)
-
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
-}
-
 func init() {
 	// Preview create flags
 	previewCreateCmd.Flags().String("name", "", "Name for the preview environment (required)")
 	previewCreateCmd.MarkFlagRequired("name")
+	previewCreateCmd.Flags().String("clone-data-from", "", "Environment or namespace to copy dependency data from")
+	addNotifyFlags(previewCreateCmd)
+	addStatusWebhookFlag(previewCreateCmd)
 
 	// Preview destroy flags
 	previewDestroyCmd.Flags().String("name", "", "Name of the preview to destroy (required)")
 	previewDestroyCmd.MarkFlagRequired("name")
+	previewDestroyCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt (required without a terminal)")
+	previewDestroyCmd.Flags().Bool("wait", false, "Wait until the preview namespace and its volumes are gone")
+	previewDestroyCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long --wait waits")
+	deprecateTimeout(previewDestroyCmd, "wait-timeout")
+	addNotifyFlags(previewDestroyCmd)
 
 	// Add subcommands
 	previewCmd.AddCommand(previewCreateCmd)
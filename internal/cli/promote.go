@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/apply"
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote -e <from> --to <env>",
+	Short: "Deploy the image running in one environment to another",
+	Long: `Deploy exactly the artifact running in one environment to another.
+
+kbox promote reads the image of the app's Deployment in the source
+environment, pinned to the digest its pods pulled (or, without a
+Deployment, the image of its latest release), and deploys it to the target
+environment with the target's own config. Both environments' release
+history record the promotion, so 'kbox history' shows where a release came
+from and where it went.
+
+Examples:
+  kbox promote -e staging --to prod         # Ship what staging runs to prod
+  kbox promote -e staging --to prod --yes   # Skip the protected-environment prompt
+  kbox promote -e staging --to prod --dry-run`,
+	Args:        cobra.NoArgs,
+	RunE:        runPromote,
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
+}
+
+// promotion is the artifact kbox promote carries from one environment to
+// another
+type promotion struct {
+	from release.Promotion
+	to   string // the target environment
+	// source is the source environment's release history
+	source *release.Store
+}
+
+// record links the target's new release and the source's, in both
+// environments' release history
+func (p *promotion) record(ctx context.Context, target *release.Store, revision int, kubeContext, namespace string) error {
+	if err := target.SetPromotedFrom(ctx, revision, p.from); err != nil {
+		return err
+	}
+	if p.from.Revision == 0 {
+		return nil
+	}
+	return p.source.AddPromotedTo(ctx, p.from.Revision, release.Promotion{
+		Env:       p.to,
+		Context:   kubeContext,
+		Namespace: namespace,
+		Revision:  revision,
+		Image:     p.from.Image,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	from, _ := cmd.Flags().GetString("env")
+	to, _ := cmd.Flags().GetString("to")
+	configFile, _ := cmd.Flags().GetString("file")
+	if from == "" || to == "" {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("kbox promote needs the source and target environments\n  → kbox promote -e staging --to prod"))
+	}
+	if from == to {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("cannot promote %s to itself", from))
+	}
+
+	loader := config.NewLoader(".")
+	var cfg *config.AppConfig
+	var err error
+	if configFile != "" {
+		cfg, err = loader.LoadFile(configFile)
+	} else {
+		if isMulti, _ := loader.IsMultiService(); isMulti {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("kbox promote needs an App; a MultiApp's services have no release history to promote from"))
+		}
+		cfg, err = loader.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, env := range []string{from, to} {
+		if _, ok := cfg.Environments[env]; !ok {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("no environment %q in kbox.yaml\n  → kbox promote moves an image between two of its environments", env))
+		}
+	}
+
+	// The source is found by its own binding, not --context/--namespace,
+	// which are for the target
+	source := cfg.EnvironmentTarget(from)
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: source.Context})
+	if err != nil {
+		return fmt.Errorf("failed to connect to the %s cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", from, err)
+	}
+	namespace := cfg.ForEnvironment(from).Metadata.Namespace
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+
+	promoted, err := findPromotable(cmd.Context(), client, namespace, cfg.Metadata.Name)
+	if err != nil {
+		return err
+	}
+	promoted.from.Env, promoted.to = from, to
+
+	if GetOutputFormat(cmd) != "json" {
+		origin := "live Deployment"
+		if promoted.from.Revision > 0 {
+			origin = "release " + release.FormatRevision(promoted.from.Revision)
+		}
+		fmt.Printf("Promoting %s from %s (%s) to %s\n\n", promoted.from.Image, from, origin, to)
+	}
+	return deploy(cmd, to, promoted)
+}
+
+// findPromotable returns the image the app runs in namespace, pinned to the
+// digest its pods pulled, and the release that deployed it
+func findPromotable(ctx context.Context, client *k8s.Client, namespace, appName string) (*promotion, error) {
+	store := release.NewStore(client.Clientset, namespace, appName)
+	latest, _ := store.GetLatest(ctx)
+
+	image := ""
+	dep, err := client.Clientset.AppsV1().Deployments(namespace).Get(ctx, appName, metav1.GetOptions{})
+	switch {
+	case err == nil && len(dep.Spec.Template.Spec.Containers) > 0:
+		image = dep.Spec.Template.Spec.Containers[0].Image
+	case err != nil && !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to read deployment %s in %s: %w", appName, namespace, err)
+	case latest != nil:
+		image = latest.Image
+	}
+	if image == "" {
+		return nil, fmt.Errorf("%s isn't deployed in %s, so there is nothing to promote\n  → Deploy it there first", appName, namespace)
+	}
+
+	p := &promotion{
+		from: release.Promotion{
+			Context:   client.Context,
+			Namespace: namespace,
+			Image:     image,
+			Timestamp: time.Now().UTC(),
+		},
+		source: store,
+	}
+	if latest != nil && latest.Image == image {
+		p.from.Revision = latest.Revision
+	}
+	if !strings.Contains(image, "@sha256:") {
+		if digest, ok := apply.ImageDigests(ctx, client.Clientset, namespace, []string{image})[image]; ok {
+			p.from.Image = image + "@" + digest
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: no running pod in %s reports the digest of %s; promoting it by tag\n", namespace, image)
+		}
+	}
+	return p, nil
+}
+
+func init() {
+	promoteCmd.Flags().StringP("env", "e", "", "Environment to promote from")
+	promoteCmd.Flags().String("to", "", "Environment to deploy to")
+	promoteCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	promoteCmd.Flags().Bool("dry-run", false, "Show what would be deployed without applying")
+	promoteCmd.Flags().Bool("no-wait", false, "Don't wait for rollout to complete")
+	promoteCmd.Flags().Duration("rollout-timeout", 5*time.Minute, "How long to wait for the rollout, and for each of the deploy's Jobs, to complete (e.g., 10m, 30s)")
+	deprecateTimeout(promoteCmd, "rollout-timeout")
+	promoteCmd.Flags().Bool("skip-tests", false, "Skip smoke tests defined in kbox.yaml")
+	promoteCmd.Flags().Bool("auto-rollback", false, "Roll back to the previous release if rollout or smoke tests fail")
+	promoteCmd.Flags().BoolP("yes", "y", false, "Skip confirmation for protected environments")
+	promoteCmd.Flags().Int("concurrency", apply.DefaultConcurrency, "Resources applied at once within each apply stage")
+	addRetryFlags(promoteCmd)
+	addNotifyFlags(promoteCmd)
+	addStatusWebhookFlag(promoteCmd)
+	rootCmd.AddCommand(promoteCmd)
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/rbac"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var rbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Generate least-privilege RBAC for deploying with kbox",
+}
+
+var rbacGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print the minimal ServiceAccount, Role and RoleBinding a CI deployer needs",
+	Long: `Print the RBAC a CI service account needs to deploy this config with kbox,
+and nothing more: only the kinds the rendered bundle contains, with only the
+verbs kbox uses on them (get, create and patch to apply; list and watch on
+the workloads, ReplicaSets, pods and events to follow the rollout), plus the
+release history, Job logs and smoke test port-forwards or execs when the
+config has them.
+
+Grant what the deploy flags you use need with --prune and --transactional.
+The pre-deploy Pod Security and node capacity checks read cluster-scoped
+resources; kbox skips them without access, or --cluster-checks adds a
+ClusterRole for them.
+
+Apply the output once as a cluster admin, then deploy with the service
+account's token, or as it with --as system:serviceaccount:<namespace>:<name>.
+
+Examples:
+  kbox rbac generate -e prod | kubectl apply -f -
+  kbox rbac generate --prune --service-account ci   # For 'kbox deploy --prune'
+  kbox rbac generate --cluster-checks -n shop`,
+	Args:        cobra.NoArgs,
+	RunE:        runRBACGenerate,
+	Annotations: map[string]string{offlineAnnotation: ""},
+}
+
+func runRBACGenerate(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	opts := rbac.Options{}
+	opts.ServiceAccount, _ = cmd.Flags().GetString("service-account")
+	opts.Prune, _ = cmd.Flags().GetBool("prune")
+	opts.Transactional, _ = cmd.Flags().GetBool("transactional")
+	opts.ClusterChecks, _ = cmd.Flags().GetBool("cluster-checks")
+
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	target, err := loadTestTarget(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	if env != "" && !slices.Contains(target.environments(), env) {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("environment %q is not defined in %s\n  → Defined environments: %s", env, path, strings.Join(target.environments(), ", ")))
+	}
+	if target.app != nil && namespace != "" {
+		target.app.Metadata.Namespace = namespace
+	}
+	if target.multi != nil && namespace != "" {
+		target.multi.Metadata.Namespace = namespace
+	}
+
+	bundle, apps, _, err := target.render(env)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	objects := rbac.Generate(target.name(), bundle, apps, opts)
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(objects)
+	}
+	fmt.Printf("# RBAC for deploying %s with kbox, generated by 'kbox rbac generate'\n", target.name())
+	for _, obj := range objects {
+		data, err := render.ObjectToYAML(obj)
+		if err != nil {
+			return err
+		}
+		fmt.Print("---\n" + data)
+	}
+	return nil
+}
+
+func init() {
+	rbacGenerateCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	rbacGenerateCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
+	rbacGenerateCmd.Flags().String("service-account", "", "Name of the deployer's ServiceAccount (default: <app>-deployer)")
+	rbacGenerateCmd.Flags().Bool("prune", false, "Also grant what 'kbox deploy --prune' needs")
+	rbacGenerateCmd.Flags().Bool("transactional", false, "Also grant what 'kbox deploy --transactional' needs")
+	rbacGenerateCmd.Flags().Bool("cluster-checks", false, "Add a ClusterRole for the Pod Security and node capacity checks")
+	rbacCmd.AddCommand(rbacGenerateCmd)
+	rootCmd.AddCommand(rbacCmd)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var readinessCmd = &cobra.Command{
+	Use:   "readiness",
+	Short: "Score kbox.yaml's production readiness",
+	Long: `Score how ready kbox.yaml is for production, out of 100, with the
+reason for each item:
+
+  Reliability     replicas, PodDisruptionBudget, probes, resource requests
+  Security        non-root, NetworkPolicy, pinned image, secrets in env, ingress TLS
+  Observability   metrics scraping, tracing
+
+Items that don't apply, like ingress TLS without an ingress, don't count.
+With --threshold, kbox readiness fails when the score is lower (for CI).
+
+Examples:
+  kbox readiness                   # Score ./kbox.yaml
+  kbox readiness -e prod           # Score the prod environment
+  kbox readiness --threshold 80    # Fail below 80`,
+	RunE:        runReadiness,
+	Annotations: map[string]string{offlineAnnotation: ""},
+}
+
+func runReadiness(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	threshold, _ := cmd.Flags().GetInt("threshold")
+	outputFormat := GetOutputFormat(cmd)
+
+	loader := config.NewLoader(".")
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = loader.FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	cfg, err := loader.LoadFile(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load %s: %w\n  → Run 'kbox validate' for details", path, err))
+	}
+	cfg = cfg.ForEnvironment(env)
+	bundle, err := render.New(cfg).Render()
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to render: %w", err))
+	}
+	report, err := config.Readiness(cfg, bundle.NetworkPolicies)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+
+	if outputFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printReadiness(cfg.Metadata.Name, env, report)
+	}
+
+	if threshold > 0 && report.Score < threshold {
+		return output.WithCode(output.ErrPolicy, fmt.Errorf("readiness score %d is below the threshold of %d", report.Score, threshold))
+	}
+	return nil
+}
+
+// printReadiness prints the report by category, each check with its reason
+func printReadiness(app, env string, report *config.ReadinessReport) {
+	target := app
+	if env != "" {
+		target += " (" + env + ")"
+	}
+	fmt.Printf("Production readiness of %s: %d/100\n", target, report.Score)
+	for _, category := range config.ReadinessCategories {
+		fmt.Printf("\n%s  %d/100\n", strings.ToUpper(category[:1])+category[1:], report.Categories[category])
+		for _, check := range report.Checks {
+			if check.Category != category {
+				continue
+			}
+			mark := "✗"
+			switch {
+			case !check.Applicable:
+				mark = "-"
+			case check.Passed:
+				mark = "✓"
+			}
+			fmt.Printf("  %s %-18s %s\n", mark, check.ID, check.Rationale)
+		}
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Printf("\nWarnings:\n")
+		for _, w := range report.Warnings {
+			fmt.Printf("  ⚠ %s\n", w)
+		}
+	}
+}
+
+func init() {
+	readinessCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	readinessCmd.Flags().StringP("env", "e", "", "Environment overlay to score (e.g., dev, staging, prod)")
+	readinessCmd.Flags().Int("threshold", 0, "Exit non-zero if the score is below this (0-100, for CI pipelines)")
+	rootCmd.AddCommand(readinessCmd)
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// writesAnnotation marks commands that change the cluster, or exec into or
+// port-forward to its pods, with the RBAC permission they can't do without
+// (e.g. "patch deployments.apps"). They refuse to run in read-only mode, and
+// up front when the user doesn't have that permission.
+const writesAnnotation = "kbox.dev/writes"
+
+// readOnlyCommands are what still works with view-only access, for hints
+const readOnlyCommands = "status, logs, events, history, describe, top, graph, diff, render"
+
+// checkWriteAccess refuses a command that changes the cluster in read-only
+// mode, or when the user's RBAC role doesn't allow it. When kbox can't tell
+// (no cluster, or no permission to review its own permissions) the command
+// runs and the API server has the last word.
+func checkWriteAccess(cmd *cobra.Command) error {
+	needs, writes := cmd.Annotations[writesAnnotation]
+	if !writes {
+		return nil
+	}
+	// A dry run only reads, or sends server-side dry runs, which read-only
+	// mode and view-only users allow
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+	if k8s.ReadOnly() {
+		return output.WithCode(output.ErrForbidden, fmt.Errorf("'%s' can change the cluster, and kbox is running with --read-only\n  → Read-only commands still work: %s", cmd.CommandPath(), readOnlyCommands))
+	}
+	if k8s.NoCluster() {
+		return nil
+	}
+	perm, err := k8s.ParsePermission(needs)
+	if err != nil {
+		return err
+	}
+
+	kubeContext, namespace := writeTarget(cmd)
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext, Namespace: namespace})
+	if err != nil {
+		return nil
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	scope := fmt.Sprintf("in namespace %q", namespace)
+	if perm.Resource == "namespaces" {
+		namespace, scope = "", "in this cluster"
+	}
+	allowed, err := client.CanI(cmd.Context(), namespace, perm)
+	if err != nil || allowed {
+		return nil
+	}
+	return output.WithCode(output.ErrForbidden, fmt.Errorf("you can't %s %s, which '%s' needs\n  → Ask for write access, or use the read-only commands: %s", perm, scope, cmd.CommandPath(), readOnlyCommands))
+}
+
+// writeTarget returns the context and namespace a command will change: the
+// flags, else the selected environment's binding, else kbox.yaml's
+// namespace. Empty means the kubeconfig's.
+func writeTarget(cmd *cobra.Command) (string, string) {
+	kubeContext, _ := cmd.Flags().GetString("context")
+	namespace, _ := cmd.Flags().GetString("namespace")
+
+	path := ""
+	if f := cmd.Flags().Lookup("file"); f != nil && f.Value.String() != "" {
+		path = f.Value.String()
+	} else if found, err := config.NewLoader(".").FindConfigFile(); err == nil {
+		path = found
+	}
+	if path == "" {
+		return kubeContext, namespace
+	}
+	node, err := config.LoadYAMLWithComments(path)
+	if err != nil {
+		return kubeContext, namespace
+	}
+	root := config.GetRootDocument(node)
+	value := func(parent *yaml.Node, key string) string {
+		if v := config.FindMapKey(parent, key); v != nil {
+			return v.Value
+		}
+		return ""
+	}
+
+	if env := cmd.Flags().Lookup("env"); env != nil && env.Value.String() != "" {
+		override := config.FindMapKey(config.FindMapKey(root, "environments"), env.Value.String())
+		if !cmd.Flags().Changed("context") {
+			if c := value(override, "context"); c != "" {
+				kubeContext = c
+			}
+		}
+		if !cmd.Flags().Changed("namespace") {
+			if ns := value(override, "namespace"); ns != "" {
+				namespace = ns
+			}
+		}
+	}
+	if namespace == "" {
+		namespace = value(config.FindMapKey(root, "metadata"), "namespace")
+	}
+	return kubeContext, namespace
+}
+
+// forbiddenError explains an error the API server refused for lack of
+// permission
+func forbiddenError(err error) error {
+	if output.CodeOf(err) != output.ErrGeneric || !apierrors.IsForbidden(err) {
+		return err
+	}
+	return output.WithCode(output.ErrForbidden, fmt.Errorf("%w\n  → Your account can't make this change; ask for write access, or use the read-only commands: %s", err, readOnlyCommands))
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/golden"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// Diff colors
+const (
+	diffColorAdded   = "\033[32m" // Green
+	diffColorRemoved = "\033[31m" // Red
+	diffColorChanged = "\033[33m" // Yellow
+	diffColorReset   = "\033[0m"
+)
+
+func newReleasesDiffCmd() *cobra.Command {
+	var (
+		namespace string
+		appName   string
+		manifests bool
+		noColor   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <from> [to]",
+		Short: "Compare the configs of two releases",
+		Long: `Compare the configs two releases were deployed with, field by field:
+the image, replicas, env vars added or removed, and anything else that
+changed. Env values that look like secrets are redacted.
+
+Without [to], the release is compared with the latest one. With --manifests,
+both configs are rendered again and the manifests compared instead, with
+Secret, computed env and secret-looking ConfigMap values redacted.`,
+		Example: `  # What changed between revisions 4 and 7
+  kbox releases diff 4 7
+
+  # What changed since revision 4
+  kbox releases diff 4
+
+  # Compare the rendered manifests
+  kbox releases diff 4 7 --manifests`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var revisions []int
+			for _, arg := range args {
+				revision, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+				if err != nil || revision <= 0 {
+					return fmt.Errorf("invalid revision %q: must be a release number, e.g. 4", arg)
+				}
+				revisions = append(revisions, revision)
+			}
+
+			var err error
+			if appName, namespace, err = historyTarget(appName, namespace); err != nil {
+				return err
+			}
+			client, err := k8s.NewClient(k8s.ClientOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create Kubernetes client: %w", err)
+			}
+			store := release.NewStore(client.Clientset, namespace, appName)
+
+			from, err := store.Get(ctx, revisions[0])
+			if err != nil {
+				return fmt.Errorf("%w\n  → Run 'kbox history' to list the releases", err)
+			}
+			var to *release.Release
+			if len(revisions) == 2 {
+				to, err = store.Get(ctx, revisions[1])
+			} else {
+				to, err = store.GetLatest(ctx)
+			}
+			if err != nil {
+				return fmt.Errorf("%w\n  → Run 'kbox history' to list the releases", err)
+			}
+
+			var changes []config.ConfigChange
+			var diff string
+			if manifests {
+				diff, err = manifestDiff(from, to)
+			} else {
+				changes, err = configDiff(from, to)
+			}
+			if err != nil {
+				return err
+			}
+
+			if GetOutputFormat(cmd) == "json" {
+				result := map[string]interface{}{
+					"success": true,
+					"app":     appName,
+					"from":    from.Revision,
+					"to":      to.Revision,
+				}
+				if manifests {
+					result["diff"] = diff
+				} else {
+					if changes == nil {
+						changes = []config.ConfigChange{}
+					}
+					result["changes"] = changes
+				}
+				return json.NewEncoder(os.Stdout).Encode(result)
+			}
+
+			color := !noColor && output.ColorEnabled(os.Stdout)
+			fmt.Printf("Changes from %s to %s of %s (namespace: %s)\n\n",
+				release.FormatRevision(from.Revision), release.FormatRevision(to.Revision), appName, namespace)
+			if manifests {
+				if diff == "" {
+					fmt.Println("  No changes to the manifests")
+					return nil
+				}
+				printLineDiff(diff, color)
+				return nil
+			}
+			if len(changes) == 0 {
+				fmt.Println("  No changes to the config")
+				return nil
+			}
+			for _, c := range changes {
+				printConfigChange(c, color)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Kubernetes namespace")
+	cmd.Flags().StringVarP(&appName, "app", "a", "", "Application name (overrides kbox.yaml)")
+	cmd.Flags().BoolVar(&manifests, "manifests", false, "Compare the manifests the two configs render to")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Don't color the output on a terminal")
+
+	return cmd
+}
+
+// configDiff returns the fields of the releases' configs that differ
+func configDiff(from, to *release.Release) ([]config.ConfigChange, error) {
+	fromCfg, err := from.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", release.FormatRevision(from.Revision), err)
+	}
+	toCfg, err := to.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", release.FormatRevision(to.Revision), err)
+	}
+	return config.Diff(fromCfg, toCfg)
+}
+
+// manifestDiff renders the releases' configs again and returns the line diff
+// of their manifests, with secret values redacted as in the config diff
+func manifestDiff(from, to *release.Release) (string, error) {
+	var manifests []string
+	for _, r := range []*release.Release{from, to} {
+		cfg, err := r.GetConfig()
+		if err != nil {
+			return "", fmt.Errorf("release %s: %w", release.FormatRevision(r.Revision), err)
+		}
+		bundle, err := render.New(cfg).Render()
+		if err != nil {
+			return "", fmt.Errorf("failed to render release %s: %w", release.FormatRevision(r.Revision), err)
+		}
+		bundle.Redact()
+		for _, cm := range bundle.ConfigMaps {
+			for key, value := range cm.Data {
+				if config.LooksLikeSecret(key, value) {
+					cm.Data[key] = config.RedactedValue
+				}
+			}
+		}
+		var buf bytes.Buffer
+		if err := bundle.WriteYAML(&buf, bundle.AllObjects()); err != nil {
+			return "", err
+		}
+		manifests = append(manifests, buf.String())
+	}
+	return golden.Diff(manifests[0], manifests[1]), nil
+}
+
+// printConfigChange prints a field change, e.g. "~ spec.replicas: 2 → 3"
+func printConfigChange(c config.ConfigChange, color bool) {
+	var line, colorCode string
+	switch c.Action {
+	case config.ChangeAdded:
+		line, colorCode = fmt.Sprintf("+ %s: %s", c.Field, c.New), diffColorAdded
+	case config.ChangeRemoved:
+		line, colorCode = fmt.Sprintf("- %s: %s", c.Field, c.Old), diffColorRemoved
+	default:
+		line, colorCode = fmt.Sprintf("~ %s: %s → %s", c.Field, c.Old, c.New), diffColorChanged
+	}
+	if color {
+		line = colorCode + line + diffColorReset
+	}
+	fmt.Printf("  %s\n", line)
+}
+
+// printLineDiff prints a golden.Diff, coloring added and removed lines
+func printLineDiff(diff string, color bool) {
+	if !color {
+		fmt.Print(diff)
+		return
+	}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(diff, "\n"), "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "+"):
+			text = diffColorAdded + text + diffColorReset
+		case strings.HasPrefix(text, "-"):
+			text = diffColorRemoved + text + diffColorReset
+		case strings.HasPrefix(text, "@@"):
+			text = diffColorChanged + text + diffColorReset
+		}
+		fmt.Println(text)
+	}
+}
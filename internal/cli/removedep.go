@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// removeDependencyResources deletes a dependency's StatefulSet, Service and
+// Secret, and with deleteData its PersistentVolumeClaims. Only objects
+// labelled as that dependency are touched.
+func removeDependencyResources(cmd *cobra.Command, cfg *config.AppConfig, depType string, deleteData bool) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+	}
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext, Namespace: namespace})
+	if err != nil {
+		return output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err))
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+
+	ctx := cmd.Context()
+	name := fmt.Sprintf("%s-%s", cfg.Metadata.Name, depType)
+	claims, err := dependencyClaims(ctx, client, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to list PersistentVolumeClaims: %w", err)
+	}
+	if deleteData && len(claims) > 0 {
+		if err := confirmDeleteData(cmd, depType, namespace, claims); err != nil {
+			return err
+		}
+	}
+
+	progress := output.NewProgress(os.Stdout, IsCIMode(cmd))
+	deleted := 0
+	owned := func(labels map[string]string) bool {
+		return labels["kbox.dev/dependency"] == depType
+	}
+	remove := func(kind string, del func() error) error {
+		task := progress.Start(fmt.Sprintf("Deleting %s/%s", kind, name))
+		if err := del(); err != nil && !apierrors.IsNotFound(err) {
+			task.Fail(err)
+			return fmt.Errorf("failed to delete %s/%s: %w", kind, name, err)
+		}
+		task.Done(fmt.Sprintf("Deleted %s/%s", kind, name))
+		deleted++
+		return nil
+	}
+
+	if ss, err := client.Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil && owned(ss.Labels) {
+		if err := remove("StatefulSet", func() error {
+			return client.Clientset.AppsV1().StatefulSets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+	if svc, err := client.Clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil && owned(svc.Labels) {
+		if err := remove("Service", func() error {
+			return client.Clientset.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+	if secret, err := client.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil && owned(secret.Labels) {
+		if err := remove("Secret", func() error {
+			return client.Clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, claim := range claims {
+		if !deleteData {
+			fmt.Printf("  Kept PersistentVolumeClaim/%s (the %s data)\n", claim, depType)
+			continue
+		}
+		// The StatefulSet's pod has to go before its volume is released
+		task := progress.Start("Deleting PersistentVolumeClaim/" + claim)
+		pod := strings.TrimPrefix(claim, "data-")
+		if err := waitDeleted(ctx, func() error {
+			_, err := client.Clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+			return err
+		}); err != nil {
+			task.Fail(err)
+			return fmt.Errorf("pod %s didn't stop: %w", pod, err)
+		}
+		if err := client.Clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, claim, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			task.Fail(err)
+			return fmt.Errorf("failed to delete PersistentVolumeClaim/%s: %w", claim, err)
+		}
+		task.Done("Deleted PersistentVolumeClaim/" + claim)
+		deleted++
+	}
+	if !deleteData && len(claims) > 0 {
+		fmt.Printf("  → Re-run with --delete-data to delete it, or: kubectl delete pvc -n %s %s\n", namespace, strings.Join(claims, " "))
+	}
+
+	if deleted == 0 && len(claims) == 0 {
+		fmt.Printf("No %s resources found in namespace %q\n", name, namespace)
+	}
+
+	warnLiveDependencyEnv(ctx, client, namespace, cfg.Metadata.Name, name)
+	return nil
+}
+
+// dependencyClaims lists the claims the StatefulSet's volumeClaimTemplate
+// created: data-<name>-<ordinal>
+func dependencyClaims(ctx context.Context, client *k8s.Client, namespace, name string) ([]string, error) {
+	pvcs, err := client.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pattern := regexp.MustCompile("^data-" + regexp.QuoteMeta(name) + "-[0-9]+$")
+	var claims []string
+	for _, pvc := range pvcs.Items {
+		if pattern.MatchString(pvc.Name) {
+			claims = append(claims, pvc.Name)
+		}
+	}
+	return claims, nil
+}
+
+// confirmDeleteData asks the user to type the dependency's name before
+// deleting its data
+func confirmDeleteData(cmd *cobra.Command, depType, namespace string, claims []string) error {
+	details := []string{fmt.Sprintf("This will permanently delete the %s data in namespace %q:", depType, namespace)}
+	for _, claim := range claims {
+		details = append(details, "  - PersistentVolumeClaim/"+claim)
+	}
+	return confirm(cmd, confirmation{
+		Action:  fmt.Sprintf("--delete-data (deleting the %s data in %s)", depType, namespace),
+		Details: details,
+		Typed:   depType,
+	})
+}
+
+// warnDependencyEnv warns about env vars in kbox.yaml that still point at
+// a removed dependency
+func warnDependencyEnv(cfg *config.AppConfig, depType string) {
+	template, ok := dependencies.Get(depType)
+	if !ok {
+		return
+	}
+	name := fmt.Sprintf("%s-%s", cfg.Metadata.Name, depType)
+	refs := dependencies.EnvReferences(template, name, cfg.Spec.Env)
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Printf("\nWarning: these env vars in kbox.yaml still reference %s:\n", depType)
+	for _, ref := range refs {
+		fmt.Printf("  - %s=%s\n", ref, cfg.Spec.Env[ref])
+	}
+}
+
+// warnLiveDependencyEnv warns when the deployed app still reads env vars
+// from the removed dependency's Secret or Service: its pods won't start
+// again until it's redeployed without them
+func warnLiveDependencyEnv(ctx context.Context, client *k8s.Client, namespace, appName, name string) {
+	deployment, err := client.Clientset.AppsV1().Deployments(namespace).Get(ctx, appName, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+	var refs []string
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		for _, env := range c.Env {
+			fromSecret := env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name
+			if fromSecret || strings.Contains(env.Value, name) {
+				refs = append(refs, env.Name)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Printf("\nWarning: Deployment/%s still injects %s\n", appName, strings.Join(refs, ", "))
+	fmt.Printf("  → Its pods can't restart until you run 'kbox deploy'\n")
+}
@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/render"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var renderCmd = &cobra.Command{
@@ -20,8 +24,18 @@ Useful for previewing changes or piping to kubectl.
 Examples:
   kbox render                    # Render with default environment
   kbox render -e prod            # Render with prod environment overlay
-  kbox render -e dev | kubectl apply -f -  # Pipe to kubectl`,
-	RunE: runRender,
+  kbox render -e dev | kubectl apply -f -  # Pipe to kubectl
+  kbox render --show-merged      # Show kbox.yaml merged with kbox.d/*.yaml
+  kbox render -e prod --show-provenance  # Annotate each object with the kbox.yaml
+                                         # fields and overlay that produced it
+  kbox render --kind Deployment --name myapp  # Only some objects
+  kbox render -e prod --split-files out/  # One file per object, plus a kustomization.yaml
+  kbox render --set spec.image=myapp:abc123 --set spec.env.FEATURE_X=on
+
+On a terminal the YAML is highlighted and shown in a pager ($KBOX_PAGER,
+$PAGER or less); --no-pager and --no-color turn that off, as does piping it.`,
+	RunE:        runRender,
+	Annotations: map[string]string{offlineAnnotation: ""},
 }
 
 func runRender(cmd *cobra.Command, args []string) error {
@@ -29,12 +43,17 @@ func runRender(cmd *cobra.Command, args []string) error {
 	redact, _ := cmd.Flags().GetBool("redact")
 	configFile, _ := cmd.Flags().GetString("file")
 	showSummary, _ := cmd.Flags().GetBool("summary")
+	showProvenance, _ := cmd.Flags().GetBool("show-provenance")
 	outputFormat := GetOutputFormat(cmd)
 	ciMode := IsCIMode(cmd)
 
+	if showMerged, _ := cmd.Flags().GetBool("show-merged"); showMerged {
+		return showMergedConfig(configFile)
+	}
+
 	// If a specific file is provided, load it directly
 	if configFile != "" {
-		return renderFromFile(cmd, configFile, env, redact, showSummary, outputFormat, ciMode)
+		return renderFromFile(cmd, configFile, env, redact, showSummary, showProvenance, outputFormat, ciMode)
 	}
 
 	// Use current directory
@@ -51,6 +70,9 @@ func runRender(cmd *cobra.Command, args []string) error {
 		if !ciMode {
 			fmt.Fprintln(os.Stderr, "No kbox.yaml found, inferring from Dockerfile...")
 		}
+		if cfg, err = cfg.WithOverrides(configOverrides(cmd)); err != nil {
+			return output.WithCode(output.ErrConfig, err)
+		}
 
 		// Render inferred config
 		renderer := render.New(cfg)
@@ -58,11 +80,15 @@ func runRender(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to render: %w", err)
 		}
-
-		if !ciMode {
-			fmt.Fprintln(os.Stderr)
+		if showProvenance {
+			renderer.TraceProvenance(bundle, "", nil)
 		}
-		return bundle.ToYAML(os.Stdout)
+
+		return printBundle(cmd, bundle, redact, showSummary)
+	}
+
+	if path, err := loader.FindConfigFile(); err == nil && !ciMode && outputFormat != "json" {
+		printResourceNormalizations(path)
 	}
 
 	var bundle *render.Bundle
@@ -75,12 +101,16 @@ func runRender(cmd *cobra.Command, args []string) error {
 		}
 
 		// Apply environment overlay
+		overlay := multiCfg.OverlayFields(env)
 		if env != "" {
 			multiCfg = multiCfg.ForEnvironment(env)
 			if !ciMode {
 				fmt.Fprintf(os.Stderr, "Using environment: %s\n", env)
 			}
 		}
+		if multiCfg, err = multiCfg.WithOverrides(configOverrides(cmd)); err != nil {
+			return output.WithCode(output.ErrConfig, err)
+		}
 
 		// Render using multi-service renderer
 		renderer := render.NewMultiService(multiCfg)
@@ -88,6 +118,9 @@ func runRender(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to render: %w", err)
 		}
+		if showProvenance {
+			renderer.TraceProvenance(bundle, env, overlay)
+		}
 	} else {
 		// Handle single-service config
 		cfg, err := loader.Load()
@@ -98,7 +131,7 @@ func runRender(cmd *cobra.Command, args []string) error {
 		// Validate with warnings for security issues
 		warnings, err := config.ValidateWithWarnings(cfg)
 		if err != nil {
-			return fmt.Errorf("validation failed: %w", err)
+			return output.WithCode(output.ErrConfig, fmt.Errorf("validation failed: %w", err))
 		}
 		// Print warnings to stderr (unless JSON output or CI mode suppresses them)
 		if !ciMode && outputFormat != "json" {
@@ -108,16 +141,20 @@ func runRender(cmd *cobra.Command, args []string) error {
 		}
 
 		// Apply environment overlay
+		overlay := cfg.OverlayFields(env)
 		if env != "" {
 			cfg = cfg.ForEnvironment(env)
 			if !ciMode {
 				fmt.Fprintf(os.Stderr, "Using environment: %s\n", env)
 			}
 		}
+		if cfg, err = cfg.WithOverrides(configOverrides(cmd)); err != nil {
+			return output.WithCode(output.ErrConfig, err)
+		}
 
 		// Check if we have an image
 		if cfg.Spec.Image == "" && cfg.Spec.Build == nil {
-			return fmt.Errorf("no image specified and no build configuration\n  → Add 'image:' to kbox.yaml or use 'kbox up' for build+deploy")
+			return output.WithCode(output.ErrConfig, fmt.Errorf("no image specified and no build configuration\n  → Add 'image:' to kbox.yaml or use 'kbox up' for build+deploy"))
 		}
 
 		// If only build config, use a placeholder image
@@ -134,11 +171,29 @@ func runRender(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to render: %w", err)
 		}
+		if showProvenance {
+			renderer.TraceProvenance(bundle, env, overlay)
+		}
 	}
 
+	return printBundle(cmd, bundle, redact, showSummary)
+}
+
+// printBundle prints the rendered bundle as render's flags ask: a summary,
+// the objects --kind and --name select as YAML or JSON, or one file per
+// object in the --split-files directory
+func printBundle(cmd *cobra.Command, bundle *render.Bundle, redact, showSummary bool) error {
+	kinds, _ := cmd.Flags().GetStringSlice("kind")
+	names, _ := cmd.Flags().GetStringSlice("name")
+	splitDir, _ := cmd.Flags().GetString("split-files")
+	noPager, _ := cmd.Flags().GetBool("no-pager")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	outputFormat := GetOutputFormat(cmd)
+	ciMode := IsCIMode(cmd)
+
 	// Redact secrets if requested
 	if redact {
-		bundle = redactSecrets(bundle)
+		bundle.Redact()
 	}
 
 	// Show summary if requested
@@ -147,18 +202,88 @@ func runRender(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Output based on format
-	if !ciMode && outputFormat != "json" {
-		fmt.Fprintln(os.Stderr) // Blank line before YAML
+	objects := bundle.Select(kinds, names)
+	if len(objects) == 0 {
+		var all []string
+		for _, obj := range bundle.AllObjects() {
+			all = append(all, render.ObjectRef(obj))
+		}
+		var filters []string
+		if len(kinds) > 0 {
+			filters = append(filters, "--kind "+strings.Join(kinds, ","))
+		}
+		if len(names) > 0 {
+			filters = append(filters, "--name "+strings.Join(names, ","))
+		}
+		return output.WithCode(output.ErrConfig, fmt.Errorf("no rendered object matches %s\n  → Objects: %s",
+			strings.Join(filters, " "), strings.Join(all, ", ")))
+	}
+
+	if splitDir != "" {
+		files, err := bundle.WriteFiles(splitDir, objects)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", splitDir, err)
+		}
+		if !ciMode {
+			fmt.Fprintf(os.Stderr, "Wrote %d files to %s\n", len(files), splitDir)
+		}
+		return nil
 	}
 
 	// JSON output
 	if outputFormat == "json" {
-		return bundle.ToJSON(os.Stdout)
+		return bundle.WriteJSON(os.Stdout, objects)
 	}
 
-	// Default YAML output
-	return bundle.ToYAML(os.Stdout)
+	// YAML output, highlighted and paged on a terminal
+	if ciMode || !output.IsTerminal(os.Stdout) {
+		return bundle.WriteYAML(os.Stdout, objects)
+	}
+	fmt.Fprintln(os.Stderr) // Blank line before YAML
+	var buf bytes.Buffer
+	if err := bundle.WriteYAML(&buf, objects); err != nil {
+		return err
+	}
+	content := buf.Bytes()
+	if !noColor && output.ColorEnabled(os.Stdout) {
+		content = output.HighlightYAML(content)
+	}
+	if noPager {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+	return output.Page(os.Stdout, content)
+}
+
+// showMergedConfig prints the config as kbox loads it, merged from all its
+// documents and kbox.d fragments, each part annotated with its file
+func showMergedConfig(configFile string) error {
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, err)
+		}
+	}
+
+	node, err := config.MergedNode(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to read %s: %w", path, err))
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// printResourceNormalizations prints the resource values of the config at
+// path that kbox read as different Kubernetes quantities, e.g. 512MB as 512Mi
+func printResourceNormalizations(path string) {
+	for _, change := range config.ReadResourceNormalizations(path) {
+		fmt.Fprintf(os.Stderr, "Normalized %s\n", change)
+	}
 }
 
 // printBundleSummary prints a summary of resources in the bundle
@@ -171,6 +296,9 @@ func printBundleSummary(bundle *render.Bundle) {
 	if bundle.Deployment != nil {
 		fmt.Printf("  Deployment:      %s\n", bundle.Deployment.Name)
 	}
+	if bundle.AppStatefulSet != nil {
+		fmt.Printf("  StatefulSet:     %s\n", bundle.AppStatefulSet.Name)
+	}
 	if len(bundle.Services) > 0 {
 		fmt.Printf("  Services:        %d\n", len(bundle.Services))
 		for _, svc := range bundle.Services {
@@ -205,33 +333,8 @@ func printBundleSummary(bundle *render.Bundle) {
 	}
 }
 
-// redactSecrets replaces all secret data with redacted placeholders
-func redactSecrets(bundle *render.Bundle) *render.Bundle {
-	for _, secret := range bundle.Secrets {
-		// Redact existing Data entries
-		for key := range secret.Data {
-			secret.Data[key] = []byte("[REDACTED]")
-		}
-
-		// Convert StringData keys to redacted Data entries
-		// This ensures the YAML output shows data with [REDACTED] values
-		// even when secrets were created with StringData
-		if len(secret.StringData) > 0 {
-			if secret.Data == nil {
-				secret.Data = make(map[string][]byte)
-			}
-			for key := range secret.StringData {
-				secret.Data[key] = []byte("[REDACTED]")
-			}
-			// Clear StringData since we've moved keys to Data
-			secret.StringData = nil
-		}
-	}
-	return bundle
-}
-
 // renderFromFile loads and renders a specific config file
-func renderFromFile(cmd *cobra.Command, configFile, env string, redact, showSummary bool, outputFormat string, ciMode bool) error {
+func renderFromFile(cmd *cobra.Command, configFile, env string, redact, showSummary, showProvenance bool, outputFormat string, ciMode bool) error {
 	loader := config.NewLoader(".")
 
 	// Load config directly from file
@@ -239,11 +342,14 @@ func renderFromFile(cmd *cobra.Command, configFile, env string, redact, showSumm
 	if err != nil {
 		return fmt.Errorf("failed to load %s: %w", configFile, err)
 	}
+	if !ciMode && outputFormat != "json" {
+		printResourceNormalizations(configFile)
+	}
 
 	// Validate with warnings for security issues
 	warnings, err := config.ValidateWithWarnings(cfg)
 	if err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return output.WithCode(output.ErrConfig, fmt.Errorf("validation failed: %w", err))
 	}
 	// Print warnings to stderr
 	if !ciMode && outputFormat != "json" {
@@ -253,12 +359,16 @@ func renderFromFile(cmd *cobra.Command, configFile, env string, redact, showSumm
 	}
 
 	// Apply environment overlay
+	overlay := cfg.OverlayFields(env)
 	if env != "" {
 		cfg = cfg.ForEnvironment(env)
 		if !ciMode {
 			fmt.Fprintf(os.Stderr, "Using environment: %s\n", env)
 		}
 	}
+	if cfg, err = cfg.WithOverrides(configOverrides(cmd)); err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
 
 	// Render
 	renderer := render.New(cfg)
@@ -266,27 +376,25 @@ func renderFromFile(cmd *cobra.Command, configFile, env string, redact, showSumm
 	if err != nil {
 		return fmt.Errorf("failed to render: %w", err)
 	}
-
-	// Redact secrets if requested
-	if redact {
-		bundle = redactSecrets(bundle)
+	if showProvenance {
+		renderer.TraceProvenance(bundle, env, overlay)
 	}
 
-	// Show summary if requested
-	if showSummary {
-		printBundleSummary(bundle)
-		return nil
-	}
+	return printBundle(cmd, bundle, redact, showSummary)
+}
 
-	// Output
-	if !ciMode && outputFormat != "json" {
-		fmt.Fprintln(os.Stderr)
-	}
+// addOverrideFlags adds --set and --values, which override the config after
+// its environment overlay
+func addOverrideFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("set", nil, "Override a config value after the environment overlay, e.g. spec.replicas=5 (repeatable; escape a dot in a key as \\.)")
+	cmd.Flags().StringArray("values", nil, "Merge a YAML file shaped like kbox.yaml over the config (repeatable; --set wins)")
+}
 
-	if outputFormat == "json" {
-		return bundle.ToJSON(os.Stdout)
-	}
-	return bundle.ToYAML(os.Stdout)
+// configOverrides returns the --values and --set overrides of cmd
+func configOverrides(cmd *cobra.Command) config.Overrides {
+	values, _ := cmd.Flags().GetStringArray("values")
+	set, _ := cmd.Flags().GetStringArray("set")
+	return config.Overrides{ValuesFiles: values, Set: set}
 }
 
 func init() {
@@ -294,5 +402,13 @@ func init() {
 	renderCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
 	renderCmd.Flags().Bool("redact", false, "Redact secret values in output (for security)")
 	renderCmd.Flags().Bool("summary", false, "Show resource summary instead of full YAML")
+	renderCmd.Flags().Bool("show-merged", false, "Show the config merged from kbox.yaml and kbox.d/*.yaml, annotated with source files")
+	renderCmd.Flags().Bool("show-provenance", false, "Annotate each object with the kbox.yaml fields, environment overlay and dependency template it came from")
+	renderCmd.Flags().StringSlice("kind", nil, "Only print objects of these kinds (e.g., Deployment,Service)")
+	renderCmd.Flags().StringSlice("name", nil, "Only print objects with these names")
+	renderCmd.Flags().String("split-files", "", "Write one file per object, and a kustomization.yaml, to this directory")
+	renderCmd.Flags().Bool("no-pager", false, "Don't page the output on a terminal")
+	renderCmd.Flags().Bool("no-color", false, "Don't highlight the output on a terminal")
+	addOverrideFlags(renderCmd)
 	rootCmd.AddCommand(renderCmd)
 }
@@ -0,0 +1,283 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Bundle diagnostics into a tarball for bug reports",
+	Long: `Write a tarball to attach to a kbox bug report, with:
+
+  version.json   kbox version, and cluster version and capabilities
+  doctor.txt     The output of kbox doctor
+  kbox.yaml      The project's config, merged, with env values and
+                 anything that looks like a secret redacted
+  commands.log   The last kbox commands run on this machine, from
+                 ~/.kbox/logs (flag values that look like secrets redacted)
+
+Nothing is sent anywhere: kbox has no telemetry. Review the tarball before
+sharing it.
+
+Examples:
+  kbox report                          # Write kbox-report-<time>.tar.gz
+  kbox report --file /tmp/report.tar.gz`,
+	RunE: runReport,
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		path = fmt.Sprintf("kbox-report-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	files := map[string][]byte{}
+
+	versionJSON, err := json.MarshalIndent(versionInfo(cmd, true), "", "  ")
+	if err != nil {
+		return err
+	}
+	files["version.json"] = versionJSON
+
+	var doctor bytes.Buffer
+	for _, r := range doctorChecks(cmd) {
+		mark := "✓"
+		if !r.ok {
+			mark = "✗"
+		}
+		fmt.Fprintf(&doctor, "%s %s: %s\n", mark, r.name, r.message)
+	}
+	files["doctor.txt"] = doctor.Bytes()
+
+	if configPath, err := config.NewLoader(".").FindConfigFile(); err == nil {
+		redacted, err := redactedConfig(configPath)
+		if err != nil {
+			redacted = []byte(fmt.Sprintf("# failed to read %s: %v\n", configPath, err))
+		}
+		files["kbox.yaml"] = redacted
+	}
+
+	if log, err := os.ReadFile(commandLogPath()); err == nil {
+		files["commands.log"] = log
+	}
+
+	if err := writeTarball(path, files); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		names := make([]string, 0, len(files))
+		for _, name := range reportFiles {
+			if _, ok := files[name]; ok {
+				names = append(names, name)
+			}
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"file": path, "contents": names})
+	}
+	fmt.Printf("  ✓ Wrote %s\n", path)
+	fmt.Println("  → Review it, then attach it to an issue at https://github.com/bobbyrathoree/kbox/issues")
+	return nil
+}
+
+// reportFiles orders the tarball's files
+var reportFiles = []string{"version.json", "doctor.txt", "kbox.yaml", "commands.log"}
+
+func writeTarball(path string, files map[string][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range reportFiles {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		header := &tar.Header{Name: "kbox-report/" + name, Mode: 0644, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// sensitiveKey matches config keys and flags whose values are redacted
+var sensitiveKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|key|auth|credential|webhook|url|dsn)`)
+
+// redactedConfig returns the merged config at path with every env value,
+// and every value whose key looks sensitive, replaced
+func redactedConfig(path string) ([]byte, error) {
+	sources, err := config.ReadSources(path)
+	if err != nil {
+		return nil, err
+	}
+	tree := config.MergeSources(sources)
+	redactTree(tree, false)
+	return yaml.Marshal(tree)
+}
+
+func redactTree(v interface{}, redactAll bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactTree(child, redactAll || k == "env" || sensitiveKey.MatchString(k))
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactTree(child, redactAll)
+		}
+		return v
+	case nil:
+		return nil
+	default:
+		if redactAll {
+			return "[REDACTED]"
+		}
+		return v
+	}
+}
+
+// commandLogSize is how many commands the command log keeps
+const commandLogSize = 200
+
+func commandLogPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kbox", "logs", "commands.log")
+}
+
+// logCommand appends a command run to the local command log, for kbox
+// report. It is best-effort and never sent anywhere.
+func logCommand(cmd *cobra.Command, args []string, start time.Time, err error) {
+	if cmd == nil || strings.HasPrefix(cmd.Name(), "__complete") || cmd == reportCmd {
+		return
+	}
+	path := commandLogPath()
+	if path == "" {
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":       start.UTC().Format(time.RFC3339),
+		"command":    "kbox " + strings.Join(redactArgs(cmd, args), " "),
+		"durationMs": time.Since(start).Milliseconds(),
+		"version":    Version,
+	}
+	if err != nil {
+		entry["error"] = firstLine(err.Error())
+	}
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		return
+	}
+
+	var lines [][]byte
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+		}
+		f.Close()
+	}
+	lines = append(lines, line)
+	if len(lines) > commandLogSize {
+		lines = lines[len(lines)-commandLogSize:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600)
+}
+
+// sensitiveArgsAnnotation marks commands whose positional args may hold
+// secrets, e.g. a plugin's 'kbox secrets set KEY value'; the command log
+// keeps only their flags
+const sensitiveArgsAnnotation = "kbox.dev/sensitive-args"
+
+// redactArgs hides the values of flags that look sensitive, e.g.
+// --auth user:pass or --token=abc, NAME=value args whose name does, and
+// args that can carry anything: those after --, passed on to a container
+// or plugin, and the positional args of commands marked with
+// sensitiveArgsAnnotation
+func redactArgs(cmd *cobra.Command, args []string) []string {
+	hidePositional := false
+	var path []string
+	if cmd != nil {
+		_, hidePositional = cmd.Annotations[sensitiveArgsAnnotation]
+		// The command's own name and its parents' stay visible
+		path = strings.Fields(cmd.CommandPath())[1:]
+	}
+
+	redacted := make([]string, len(args))
+	hideNext, hideRest := false, false
+	for i, arg := range args {
+		switch {
+		case hideRest:
+			redacted[i] = "[REDACTED]"
+		case arg == "--":
+			redacted[i] = arg
+			hideRest = true
+		case hideNext:
+			redacted[i] = "[REDACTED]"
+			hideNext = false
+		case strings.HasPrefix(arg, "-") && sensitiveKey.MatchString(arg):
+			if name, _, ok := strings.Cut(arg, "="); ok {
+				redacted[i] = name + "=[REDACTED]"
+			} else {
+				redacted[i] = arg
+				hideNext = true
+			}
+		case strings.HasPrefix(arg, "-"):
+			redacted[i] = arg
+		case len(path) > 0 && arg == path[0]:
+			redacted[i] = arg
+			path = path[1:]
+		case hidePositional:
+			redacted[i] = "[REDACTED]"
+		default:
+			if name, _, ok := strings.Cut(arg, "="); ok && sensitiveKey.MatchString(name) {
+				redacted[i] = name + "=[REDACTED]"
+			} else {
+				redacted[i] = arg
+			}
+		}
+	}
+	return redacted
+}
+
+func init() {
+	reportCmd.Flags().String("file", "", "Path of the tarball (default: kbox-report-<time>.tar.gz)")
+	rootCmd.AddCommand(reportCmd)
+}
@@ -1,14 +1,16 @@
 package cli
 
 import (
-	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/format"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/notify"
 	"github.com/bobbyrathoree/kbox/internal/release"
 )
 
@@ -41,9 +43,10 @@ a rollback if needed.`,
 
   # Preview what would be rolled back
   kbox rollback --dry-run`,
-		Args: cobra.MaximumNArgs(1),
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			// Load config to get defaults
 			loader := config.NewLoader(".")
@@ -103,7 +106,7 @@ a rollback if needed.`,
 
 			fmt.Printf("Rolling back %s to revision %s\n", appName, release.FormatRevision(target.Revision))
 			fmt.Printf("  Image: %s\n", target.Image)
-			fmt.Printf("  Deployed: %s\n\n", formatRelativeTime(target.Timestamp))
+			fmt.Printf("  Deployed: %s\n\n", format.Ago(target.Timestamp))
 
 			if dryRun {
 				fmt.Println("(dry-run) No changes made")
@@ -113,10 +116,29 @@ a rollback if needed.`,
 			// Perform rollback
 			fmt.Println("Applying rollback...")
 
+			var notifications *config.NotificationsConfig
+			if cfg != nil {
+				notifications = cfg.Notifications
+			}
+			event := notify.Event{
+				Type:      notify.EventRollback,
+				App:       appName,
+				Context:   client.Context,
+				Namespace: namespace,
+				Revision:  target.Revision,
+				Image:     target.Image,
+			}
+			start := time.Now()
+
 			result, err := release.Rollback(ctx, client.Clientset, namespace, appName, opts)
+			event.Duration = time.Since(start)
 			if err != nil {
+				event.Error = firstLine(err.Error())
+				sendNotification(cmd, notifications, event)
 				return fmt.Errorf("rollback failed: %w", err)
 			}
+			event.Succeeded = true
+			sendNotification(cmd, notifications, event)
 
 			fmt.Println()
 			fmt.Printf("  ✓ %s\n", result.String())
@@ -131,6 +153,7 @@ a rollback if needed.`,
 	cmd.Flags().StringVarP(&appName, "app", "a", "", "Application name (overrides kbox.yaml)")
 	cmd.Flags().IntVar(&toRevision, "to", 0, "Revision number to rollback to (default: previous)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be rolled back without making changes")
+	addNotifyFlags(cmd)
 
 	return cmd
 }
@@ -1,11 +1,18 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/bobbyrathoree/kbox/internal/format"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/bobbyrathoree/kbox/internal/output"
 )
 
@@ -14,6 +21,10 @@ var (
 	Version   = "dev"
 	GitCommit = "unknown"
 	BuildDate = "unknown"
+
+	// ReleasePublicKey (base64 ed25519) verifies release signatures in
+	// 'kbox upgrade'; set at build time for release builds
+	ReleasePublicKey = ""
 )
 
 var rootCmd = &cobra.Command{
@@ -38,21 +49,198 @@ Get started:
   kbox doctor          # Check your setup`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		legacy, err := applyLegacyTimeout(cmd)
+		if err != nil {
+			return err
+		}
+		if timeout, _ := cmd.Root().PersistentFlags().GetDuration("timeout"); timeout > 0 && !legacy {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		noCluster, _ := cmd.Flags().GetBool("no-cluster")
+		_, offline := cmd.Annotations[offlineAnnotation]
+		k8s.SetNoCluster(noCluster || offline || os.Getenv("KBOX_NO_CLUSTER") == "1" || os.Getenv("KBOX_NO_CLUSTER") == "true")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+		k8s.SetReadOnly(readOnly || os.Getenv("KBOX_READ_ONLY") == "1" || os.Getenv("KBOX_READ_ONLY") == "true")
+		if err := startFakeCluster(cmd); err != nil {
+			return err
+		}
+		if kubeconfigs, _ := cmd.Flags().GetStringArray("kubeconfig"); len(kubeconfigs) > 0 {
+			k8s.SetKubeconfig(kubeconfigs)
+			// Tools kbox runs, such as kubectl and helm, read the same files
+			os.Setenv("KUBECONFIG", k8s.KubeconfigPath())
+		}
+		as, _ := cmd.Flags().GetString("as")
+		asGroups, _ := cmd.Flags().GetStringArray("as-group")
+		k8s.SetImpersonation(as, asGroups)
+		qps, _ := cmd.Flags().GetFloat32("kube-qps")
+		burst, _ := cmd.Flags().GetInt("kube-burst")
+		k8s.SetRateLimits(qps, burst)
+		// Read from the root: logs has its own --timestamps
+		timestamps, _ := cmd.Root().PersistentFlags().GetBool("timestamps")
+		format.SetAbsolute(timestamps)
+		warnVersionSkew(cmd)
+		warnDeprecatedConfig(cmd)
+		loadDependencyTemplates(cmd)
+		if err := applyProjectDefaults(cmd); err != nil {
+			return err
+		}
+		return checkWriteAccess(cmd)
+	},
+}
+
+// offlineAnnotation marks commands that work from local files only (render,
+// validate, lint, graph). They always run in no-cluster mode, so a change
+// that makes one of them build a cluster client fails instead of quietly
+// needing a cluster.
+const offlineAnnotation = "kbox.dev/offline"
+
+// startFakeCluster connects the command to the fake cluster when
+// --fake-cluster (or KBOX_FAKE_CLUSTER=1) is set, loading it from where the
+// previous command left it
+func startFakeCluster(cmd *cobra.Command) error {
+	fakeCluster, _ := cmd.Flags().GetBool("fake-cluster")
+	if !fakeCluster && os.Getenv("KBOX_FAKE_CLUSTER") != "1" && os.Getenv("KBOX_FAKE_CLUSTER") != "true" {
+		return nil
+	}
+	if k8s.NoCluster() || k8s.ActiveFakeCluster() != nil {
+		return nil
+	}
+	path, err := k8s.DefaultFakeClusterPath()
+	if err != nil {
+		return err
+	}
+	cluster, err := k8s.LoadFakeCluster(path)
+	if err != nil {
+		return output.WithCode(output.ErrCluster, err)
+	}
+	k8s.SetFakeCluster(cluster)
+	return nil
+}
+
+// stopFakeCluster saves the fake cluster, if the command used one, for the
+// next command
+func stopFakeCluster() {
+	cluster := k8s.ActiveFakeCluster()
+	if cluster == nil {
+		return
+	}
+	k8s.SetFakeCluster(nil)
+	if err := cluster.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 }
 
+// cancelTimeout releases the global --timeout's deadline once the command ends
+var cancelTimeout = context.CancelFunc(func() {})
+
+// Execute runs the CLI. Errors are printed to stderr, as a JSON object with
+// an errorCode when --output json is set; use output.ExitCode for the exit status.
+//
+// Commands run with a context that Ctrl+C (or SIGTERM) cancels, so cluster
+// requests in flight are aborted; a second Ctrl+C exits at once.
 func Execute() error {
-	if err := rootCmd.Execute(); err != nil {
+	registerPlugins(rootCmd)
+	registerDynamicCompletions(rootCmd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer func() { cancelTimeout() }()
+
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	stopFakeCluster()
+	if err != nil && cmd != nil {
+		err = abortedError(cmd, err)
+	}
+	err = forbiddenError(err)
+	logCommand(cmd, os.Args[1:], start, err)
+	if output.IsReported(err) {
+		// The command's own output has the error already
+		return err
+	}
+	if err != nil {
+		if cmd != nil && GetOutputFormat(cmd) == "json" {
+			json.NewEncoder(os.Stderr).Encode(map[string]interface{}{
+				"success":   false,
+				"error":     err.Error(),
+				"errorCode": output.CodeOf(err),
+			})
+			return err
+		}
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
 	return nil
 }
 
+// legacyTimeoutAnnotation names the flag a command's own --timeout became
+// when the global --timeout was added. On these commands --timeout keeps
+// its old meaning, with a warning, instead of silently bounding the whole
+// command.
+const legacyTimeoutAnnotation = "kbox.dev/legacy-timeout"
+
+// deprecateTimeout marks cmd's --timeout as the deprecated name of flag
+func deprecateTimeout(cmd *cobra.Command, flag string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[legacyTimeoutAnnotation] = flag
+}
+
+// applyLegacyTimeout moves a --timeout given to a command that had its own
+// to the flag that replaced it. It reports whether it did, in which case
+// the global timeout doesn't apply.
+func applyLegacyTimeout(cmd *cobra.Command) (bool, error) {
+	flag, ok := cmd.Annotations[legacyTimeoutAnnotation]
+	timeout := cmd.Flag("timeout")
+	if !ok || timeout == nil || !timeout.Changed {
+		return false, nil
+	}
+	if cmd.Flags().Changed(flag) {
+		return true, output.WithCode(output.ErrConfig, fmt.Errorf("--timeout and --%s both set\n  → For %s, --timeout is a deprecated name for --%s; drop it", flag, cmd.CommandPath(), flag))
+	}
+	if err := cmd.Flags().Set(flag, timeout.Value.String()); err != nil {
+		return true, err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s --timeout is deprecated and still means --%s; use --%s instead\n", cmd.CommandPath(), flag, flag)
+	return true, nil
+}
+
+// abortedError explains an error caused by Ctrl+C or the global --timeout:
+// the command stopped partway, and what it already changed stays changed
+func abortedError(cmd *cobra.Command, err error) error {
+	switch cmd.Context().Err() {
+	case context.Canceled:
+		return &output.CodedError{Code: output.ErrCancelled, Err: fmt.Errorf("operation cancelled: %w\n  → Changes made before the interrupt were kept; check with 'kbox status' and re-run the command to finish", err)}
+	case context.DeadlineExceeded:
+		timeout, _ := cmd.Root().PersistentFlags().GetDuration("timeout")
+		return &output.CodedError{Code: output.ErrTimeout, Err: fmt.Errorf("operation timed out after %s: %w\n  → Changes made before the timeout were kept; check with 'kbox status', or re-run with a longer --timeout", timeout, err)}
+	}
+	return err
+}
+
 func init() {
 	// Global flags can be added here
 	rootCmd.PersistentFlags().StringP("namespace", "n", "", "Kubernetes namespace (default: from kubeconfig)")
 	rootCmd.PersistentFlags().StringP("context", "", "", "Kubernetes context (default: current context)")
+	rootCmd.PersistentFlags().String("as", "", "User to impersonate, e.g. jane@example.com or system:serviceaccount:ci:deployer")
+	rootCmd.PersistentFlags().StringArray("as-group", nil, "Group to impersonate along with --as; repeat for several")
+	rootCmd.PersistentFlags().StringArray("kubeconfig", nil, "Kubeconfig file(s) to use instead of KUBECONFIG or ~/.kube/config; repeat or ':'-separate to merge")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().Bool("no-cluster", false, "Fail instead of contacting a cluster, for hermetic CI and offline work (also KBOX_NO_CLUSTER=1)")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Refuse anything that changes the cluster, for view-only access (also KBOX_READ_ONLY=1)")
+	rootCmd.PersistentFlags().Bool("fake-cluster", false, "Use an in-memory fake cluster, kept in ~/.kbox/fake-cluster.json, for tutorials and demos (also KBOX_FAKE_CLUSTER=1)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort the command after this long, e.g. 10m (default: no limit)")
+	rootCmd.PersistentFlags().Float32("kube-qps", 0, "Requests per second to the API server (default: 5)")
+	rootCmd.PersistentFlags().Int("kube-burst", 0, "Requests allowed in a burst above --kube-qps (default: 10)")
+	rootCmd.PersistentFlags().Bool("timestamps", false, "Show absolute timestamps, in your locale's date format, instead of relative times like \"3m ago\"")
 
 	// CI mode flags
 	rootCmd.PersistentFlags().Bool("ci", false, "CI mode: no prompts, clean exit codes, minimal output")
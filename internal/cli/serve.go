@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/server"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve deploy, render, status and preview over an HTTP API",
+	Long: `Serve kbox operations over an authenticated HTTP API, for a web UI or chatops bot.
+
+Each request runs the matching kbox command against the kbox.yaml in the current
+directory, so the API behaves exactly like the CLI. Responses are the command's
+JSON output; the HTTP status reflects its errorCode (422 config_error,
+502 cluster_unreachable, 504 rollout_timeout, 500 otherwise).
+
+Endpoints (all but /healthz need "Authorization: Bearer <token>"):
+  POST   /v1/render             {"env"}
+  POST   /v1/deploy             {"env", "dry_run", "prune", "timeout", "confirm"}
+  GET    /v1/status/{app}
+  GET    /v1/previews
+  POST   /v1/previews           {"name"}
+  DELETE /v1/previews/{name}
+
+Every request can pick its cluster with "context" and "namespace" (in the JSON
+body, or as query parameters for GET and DELETE). Deploys and preview changes
+run one at a time. Each request is written to the audit log as a JSON line,
+including the X-Kbox-Actor header (the user behind a bot).
+
+The token is read from --token-file or KBOX_SERVE_TOKEN.
+
+Examples:
+  KBOX_SERVE_TOKEN=... kbox serve
+  kbox serve --addr :8443 --token-file /etc/kbox/token --tls-cert cert.pem --tls-key key.pem
+  kbox serve --audit-log /var/log/kbox-audit.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	tokenFile, _ := cmd.Flags().GetString("token-file")
+	auditLog, _ := cmd.Flags().GetString("audit-log")
+	tlsCert, _ := cmd.Flags().GetString("tls-cert")
+	tlsKey, _ := cmd.Flags().GetString("tls-key")
+
+	token := os.Getenv("KBOX_SERVE_TOKEN")
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return fmt.Errorf("an API token is required\n  → Set KBOX_SERVE_TOKEN or pass --token-file")
+	}
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the kbox binary: %w", err)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	var audit io.Writer = os.Stderr
+	if auditLog != "" {
+		f, err := os.OpenFile(auditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer f.Close()
+		audit = f
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           server.New(token, &server.ExecRunner{Path: exe, Dir: dir}, audit),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		if tlsCert != "" {
+			errCh <- srv.ListenAndServeTLS(tlsCert, tlsKey)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	scheme := "http"
+	if tlsCert != "" {
+		scheme = "https"
+	}
+	fmt.Fprintf(os.Stderr, "Serving kbox API on %s://%s (project: %s)\n", scheme, addr, dir)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Let running deploys finish before exiting
+	fmt.Fprintln(os.Stderr, "Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().String("token-file", "", "File containing the API bearer token (default: $KBOX_SERVE_TOKEN)")
+	serveCmd.Flags().String("audit-log", "", "Append audit log lines to this file (default: stderr)")
+	serveCmd.Flags().String("tls-cert", "", "TLS certificate file")
+	serveCmd.Flags().String("tls-key", "", "TLS key file")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -4,55 +4,120 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
-	"github.com/bobbyrathoree/kbox/internal/debug"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/bobbyrathoree/kbox/internal/tunnel"
 )
 
 var shareCmd = &cobra.Command{
-	Use:   "share [app]",
+	Use:   "share [app|service...]",
 	Short: "Share app via public URL",
 	Long: `Create a public URL for your local Kubernetes application.
 
-Uses ngrok to tunnel traffic from a public URL to your app running
-in Kubernetes. Great for demos, testing webhooks, or sharing work.
+Tunnels traffic from a public URL to your app running in Kubernetes.
+Great for demos, testing webhooks, or sharing work.
 
-Authentication:
-  Set NGROK_AUTHTOKEN for longer sessions. Get a token at ngrok.com
-  Without auth, sessions are limited to ~2 hours.
+For a MultiApp kbox.yaml, every service is shared (or just the services
+named as arguments), with one tunnel per service. --path-routing instead
+serves all of them from one URL at /<service>/.
+
+Providers (--provider, or spec.share.provider in kbox.yaml):
+  ngrok        Default. Set NGROK_AUTHTOKEN for sessions longer than ~2 hours.
+  cloudflared  Quick Tunnel on trycloudflare.com, or a named tunnel with a
+               custom hostname (--tunnel and --hostname). Needs cloudflared.
+  localtunnel  No account or binary needed. --hostname requests a subdomain.
+
+Use --auth user:pass to require basic auth on the shared URL, and --inspect
+to print each request (method, path, status, latency) as it arrives.
 
 Examples:
-  kbox share              # Share app from kbox.yaml
-  kbox share myapp        # Share specific app
-  kbox share --port 3000  # Override target port`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runShare,
+  kbox share                              # Share app (or all services) from kbox.yaml
+  kbox share myapp                        # Share specific app
+  kbox share api web                      # Share two services of a MultiApp
+  kbox share --path-routing               # One URL: /api/, /web/, ...
+  kbox share --inspect                    # Watch incoming webhook requests
+  kbox share --port 3000                  # Override target port
+  kbox share --provider cloudflared       # Quick Tunnel
+  kbox share --provider cloudflared --tunnel demo --hostname demo.example.com
+  kbox share --provider localtunnel --auth demo:s3cret`,
+	RunE:        runShare,
+	Annotations: map[string]string{writesAnnotation: "create pods/portforward"},
 }
 
 func init() {
-	shareCmd.Flags().IntP("port", "p", 0, "Override target port")
+	shareCmd.Flags().IntP("port", "p", 0, "Override target port (single app only)")
 	shareCmd.Flags().String("token", "", "ngrok auth token (or set NGROK_AUTHTOKEN)")
+	shareCmd.Flags().String("provider", "", "Tunnel provider: ngrok, cloudflared, localtunnel (default: ngrok)")
+	shareCmd.Flags().String("hostname", "", "Custom hostname (cloudflared named tunnel) or subdomain (localtunnel)")
+	shareCmd.Flags().String("tunnel", "", "cloudflared named tunnel to run")
+	shareCmd.Flags().String("auth", "", "Require basic auth on the shared URL (user:pass)")
+	shareCmd.Flags().Bool("inspect", false, "Print method, path, status and latency of each request")
+	shareCmd.Flags().Bool("path-routing", false, "Share multiple services behind one URL at /<service>/")
+	addPodSelectorFlags(shareCmd)
 	rootCmd.AddCommand(shareCmd)
 }
 
+// shareTarget is one app or service to expose
+type shareTarget struct {
+	Name string // display name and path prefix (service name for MultiApp)
+	App  string // app label of the pods
+	Port int
+}
+
+// shareLink is a public URL and what it serves
+type shareLink struct {
+	Name string
+	URL  string
+}
+
 func runShare(cmd *cobra.Command, args []string) error {
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	portOverride, _ := cmd.Flags().GetInt("port")
 	authToken, _ := cmd.Flags().GetString("token")
+	basicAuth, _ := cmd.Flags().GetString("auth")
+	inspect, _ := cmd.Flags().GetBool("inspect")
+	pathRouting, _ := cmd.Flags().GetBool("path-routing")
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
 
-	// Determine app name and port from args or config
-	appName, targetPort, err := resolveShareTarget(args, portOverride)
+	// Determine what to share from args or config
+	targets, err := resolveShareTargets(args, portOverride)
 	if err != nil {
 		return err
 	}
+	if len(targets) > 1 && (sel.Selector != "" || sel.Deployment != "") {
+		return fmt.Errorf("--selector and --deployment pick the pods of one app, but %d services are shared\n  → Name the service to share, e.g. kbox share %s", len(targets), targets[0].Name)
+	}
+
+	shareCfg := resolveShareConfig(cmd)
+	provider, err := tunnel.NewProvider(shareCfg.Provider)
+	if err != nil {
+		return fmt.Errorf("%w\n  → Use --provider ngrok, cloudflared, or localtunnel", err)
+	}
+
+	multipleTunnels := len(targets) > 1 && !pathRouting
+	if multipleTunnels && (shareCfg.Hostname != "" || shareCfg.Tunnel != "") {
+		return fmt.Errorf("a custom hostname can only serve one tunnel\n  → Add --path-routing to share all services behind %s", shareCfg.Hostname)
+	}
+
+	var authUser, authPass string
+	if basicAuth != "" {
+		authUser, authPass, err = tunnel.ParseCredentials(basicAuth)
+		if err != nil {
+			return fmt.Errorf("invalid --auth: %w\n  → Use --auth user:pass", err)
+		}
+	}
 
 	// Create K8s client
 	client, err := k8s.NewClient(k8s.ClientOptions{
@@ -68,31 +133,6 @@ func runShare(cmd *cobra.Command, args []string) error {
 		ns = namespace
 	}
 
-	// Find pods for the app
-	pods, err := debug.FindPods(cmd.Context(), client.Clientset, ns, appName)
-	if err != nil {
-		return fmt.Errorf("no pods found for %q\n  -> Is the app deployed? Try 'kbox up' first", appName)
-	}
-
-	// Pick the first ready pod
-	var targetPod debug.PodInfo
-	for _, p := range pods {
-		if p.Ready {
-			targetPod = p
-			break
-		}
-	}
-	if targetPod.Name == "" {
-		targetPod = pods[0]
-		fmt.Fprintf(os.Stderr, "Warning: No ready pods found, using %s anyway\n", targetPod.Name)
-	}
-
-	// Find an available local port
-	localPort, err := findAvailablePort()
-	if err != nil {
-		return fmt.Errorf("failed to find available port: %w", err)
-	}
-
 	// Set up context with cancellation
 	ctx, cancel := context.WithCancel(cmd.Context())
 	defer cancel()
@@ -106,145 +146,231 @@ func runShare(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Start port-forward in background
-	stopPF := make(chan struct{}, 1)
-	readyPF := make(chan struct{})
-	pfErrCh := make(chan error, 1)
+	// Port-forward to each target; stopping ctx tears them all down
+	localPorts := make(map[string]int, len(targets))
+	for _, t := range targets {
+		localPort, err := findAvailablePort()
+		if err != nil {
+			return fmt.Errorf("failed to find available port: %w", err)
+		}
+		if err := forwardToApp(ctx, client, ns, t.App, sel, localPort, t.Port); err != nil {
+			return err
+		}
+		localPorts[t.Name] = localPort
+		fmt.Fprintf(os.Stderr, "Port-forward established: localhost:%d -> %s:%d\n", localPort, t.App, t.Port)
+	}
 
-	go func() {
-		opts := debug.PortForwardOptions{
-			LocalPort:  localPort,
-			RemotePort: targetPort,
-			StopCh:     stopPF,
-			ReadyCh:    readyPF,
-			Out:        nil, // Suppress output
-			ErrOut:     nil,
+	var inspector *tunnel.Inspector
+	if inspect {
+		inspector = tunnel.NewInspector(os.Stdout)
+	}
+
+	// front returns the local port a tunnel should point at, inserting the
+	// local proxy when routing, inspection or auth is needed
+	front := func(label string, handler http.Handler, directPort int) (int, error) {
+		if handler == nil && inspector == nil && authUser == "" {
+			return directPort, nil
 		}
-		pfErrCh <- debug.PortForward(ctx, client.Clientset, client.RestConfig, ns, targetPod.Name, opts)
-	}()
+		if handler == nil {
+			handler = tunnel.ReverseProxy(directPort)
+		}
+		if inspector != nil {
+			handler = inspector.Wrap(label, handler)
+		}
+		if authUser != "" {
+			handler = tunnel.BasicAuth(authUser, authPass, handler)
+		}
+		proxy, err := tunnel.NewProxy(handler)
+		if err != nil {
+			return 0, err
+		}
+		go func() {
+			<-ctx.Done()
+			proxy.Close()
+		}()
+		return proxy.Port(), nil
+	}
 
-	// Wait for port-forward to be ready
-	select {
-	case <-readyPF:
-		// Port-forward is ready
-	case err := <-pfErrCh:
-		return fmt.Errorf("port-forward failed: %w", err)
-	case <-ctx.Done():
-		return ctx.Err()
+	type tunnelSpec struct {
+		name string
+		port int
+	}
+	var specs []tunnelSpec
+	if len(targets) > 1 && pathRouting {
+		port, err := front("", tunnel.PathRouter(localPorts), 0)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, tunnelSpec{name: "", port: port})
+	} else {
+		for _, t := range targets {
+			label := ""
+			if len(targets) > 1 {
+				label = t.Name
+			}
+			port, err := front(label, nil, localPorts[t.Name])
+			if err != nil {
+				return err
+			}
+			specs = append(specs, tunnelSpec{name: t.Name, port: port})
+		}
+	}
+	if authUser != "" {
+		fmt.Fprintf(os.Stderr, "Basic auth enabled for user %q\n", authUser)
 	}
 
-	fmt.Fprintf(os.Stderr, "Port-forward established: localhost:%d -> %s:%d\n", localPort, targetPod.Name, targetPort)
-	fmt.Fprintln(os.Stderr, "Creating ngrok tunnel...")
+	fmt.Fprintf(os.Stderr, "Creating %s tunnel", provider.Name())
+	if len(specs) > 1 {
+		fmt.Fprintf(os.Stderr, "s (%d)", len(specs))
+	}
+	fmt.Fprintln(os.Stderr, "...")
 
-	// Create ngrok tunnel
-	provider := tunnel.NewNgrokProvider()
-	tunnelCfg := tunnel.Config{
-		LocalPort: localPort,
-		AuthToken: authToken,
-		AppName:   appName,
-		Namespace: ns,
+	var tunnels []tunnel.Tunnel
+	var links []shareLink
+	closeAll := func() {
+		for _, tun := range tunnels {
+			tun.Close()
+		}
 	}
+	for _, spec := range specs {
+		appName := spec.name
+		if appName == "" {
+			appName = targets[0].App
+		}
+		tun, err := provider.CreateTunnel(ctx, tunnel.Config{
+			LocalPort:  spec.port,
+			AuthToken:  authToken,
+			AppName:    appName,
+			Namespace:  ns,
+			Hostname:   shareCfg.Hostname,
+			TunnelName: shareCfg.Tunnel,
+		})
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to create tunnel: %w\n  -> %s", err, shareProviderHint(provider.Name()))
+		}
+		tunnels = append(tunnels, tun)
 
-	tun, err := provider.CreateTunnel(ctx, tunnelCfg)
-	if err != nil {
-		close(stopPF)
-		return fmt.Errorf("failed to create tunnel: %w\n  -> Set NGROK_AUTHTOKEN or sign up at ngrok.com", err)
+		if spec.name == "" {
+			// Path routing: one URL, one link per service path
+			for _, t := range targets {
+				links = append(links, shareLink{Name: t.Name, URL: strings.TrimSuffix(tun.URL(), "/") + "/" + t.Name + "/"})
+			}
+		} else {
+			links = append(links, shareLink{Name: spec.name, URL: tun.URL()})
+		}
 	}
 
-	// Display the public URL
-	printShareBox(tun.URL(), appName, ns)
+	// Display the public URLs
+	printShareBox(links, ns)
+	if inspector != nil {
+		fmt.Fprintln(os.Stderr, "Inspecting requests:")
+	}
 
-	// Wait for tunnel to close or context cancellation
+	// Wait for any tunnel to close or context cancellation
+	tunnelErr := make(chan error, len(tunnels))
+	for _, tun := range tunnels {
+		go func(tun tunnel.Tunnel) {
+			tunnelErr <- tun.Wait()
+		}(tun)
+	}
 	select {
-	case err := <-waitTunnel(tun):
-		close(stopPF)
+	case err := <-tunnelErr:
+		cancel()
+		closeAll()
 		if err != nil {
 			return fmt.Errorf("tunnel error: %w", err)
 		}
 	case <-ctx.Done():
-		tun.Close()
-		close(stopPF)
+		closeAll()
 	}
 
 	fmt.Fprintln(os.Stderr, "Share session ended")
 	return nil
 }
 
-// resolveShareTarget determines app name and port from args or config
-func resolveShareTarget(args []string, portOverride int) (string, int, error) {
+// resolveShareTargets determines the apps/services and ports to share from args or config
+func resolveShareTargets(args []string, portOverride int) ([]shareTarget, error) {
 	loader := config.NewLoader(".")
 
-	// If app name provided, try to get port from config
-	if len(args) > 0 {
-		appName := args[0]
-		port := portOverride
-
-		if port == 0 {
-			// Try to get port from multi-service config
-			isMulti, _ := loader.IsMultiService()
-			if isMulti {
-				cfg, err := loader.LoadMultiService()
-				if err == nil {
-					if svc, ok := cfg.Services[appName]; ok && svc.Port > 0 {
-						port = svc.Port
-					}
-				}
-			}
-		}
-
-		if port == 0 {
-			port = 8080 // Default
-		}
-
-		return appName, port, nil
-	}
-
-	// No args - try to load from kbox.yaml
-	isMulti, err := loader.IsMultiService()
-	if err != nil {
-		return "", 0, fmt.Errorf("no app specified and no kbox.yaml found\n  -> Run 'kbox share <app>' or 'kbox init' first")
-	}
-
-	if isMulti {
+	var multi *config.MultiServiceConfig
+	if isMulti, _ := loader.IsMultiService(); isMulti {
 		cfg, err := loader.LoadMultiService()
 		if err != nil {
-			return "", 0, fmt.Errorf("failed to load kbox.yaml: %w", err)
+			return nil, fmt.Errorf("failed to load kbox.yaml: %w", err)
 		}
-		if len(cfg.Services) == 0 {
-			return "", 0, fmt.Errorf("no services defined in kbox.yaml")
+		multi = cfg
+	}
+
+	var targets []shareTarget
+	switch {
+	case len(args) > 0:
+		for _, name := range args {
+			if multi != nil {
+				if svc, ok := multi.Services[name]; ok {
+					targets = append(targets, shareTarget{Name: name, App: multi.Metadata.Name + "-" + name, Port: svc.Port})
+					continue
+				}
+			}
+			targets = append(targets, shareTarget{Name: name, App: name})
 		}
-		// Use first service (get first key from map)
-		var firstName string
-		var firstSvc config.ServiceSpec
-		for name, svc := range cfg.Services {
-			firstName = name
-			firstSvc = svc
-			break
+	case multi != nil:
+		if len(multi.Services) == 0 {
+			return nil, fmt.Errorf("no services defined in kbox.yaml")
 		}
-		port := firstSvc.Port
-		if portOverride > 0 {
-			port = portOverride
+		for _, name := range multi.ServiceOrder() {
+			svc := multi.Services[name]
+			targets = append(targets, shareTarget{Name: name, App: multi.Metadata.Name + "-" + name, Port: svc.Port})
 		}
-		if port == 0 {
-			port = 8080
+	default:
+		cfg, err := loader.Load()
+		if err != nil {
+			return nil, fmt.Errorf("no app specified and no kbox.yaml found\n  -> Run 'kbox share <app>' or 'kbox init' first")
 		}
-		return firstName, port, nil
+		targets = append(targets, shareTarget{Name: cfg.Metadata.Name, App: cfg.Metadata.Name, Port: cfg.Spec.Port})
 	}
 
-	cfg, err := loader.Load()
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to load kbox.yaml: %w", err)
+	if portOverride > 0 {
+		if len(targets) > 1 {
+			return nil, fmt.Errorf("--port applies to a single app\n  → Name one service, e.g. 'kbox share %s --port %d'", targets[0].Name, portOverride)
+		}
+		targets[0].Port = portOverride
 	}
+	for i := range targets {
+		if targets[i].Port == 0 {
+			targets[i].Port = 8080 // Default
+		}
+	}
+	return targets, nil
+}
 
-	port := cfg.Spec.Port
-	if portOverride > 0 {
-		port = portOverride
+// resolveShareConfig merges share flags over spec.share from kbox.yaml
+func resolveShareConfig(cmd *cobra.Command) config.ShareConfig {
+	var share config.ShareConfig
+	if cfg, err := config.NewLoader(".").Load(); err == nil && cfg.Spec.Share != nil {
+		share = *cfg.Spec.Share
+	}
+	if v, _ := cmd.Flags().GetString("provider"); v != "" {
+		share.Provider = v
+	}
+	if v, _ := cmd.Flags().GetString("hostname"); v != "" {
+		share.Hostname = v
 	}
-	if port == 0 {
-		port = 8080
+	if v, _ := cmd.Flags().GetString("tunnel"); v != "" {
+		share.Tunnel = v
 	}
+	return share
+}
 
-	return cfg.Metadata.Name, port, nil
+// shareProviderHint suggests how to fix a failed tunnel for each provider
+func shareProviderHint(provider string) string {
+	switch provider {
+	case "cloudflared":
+		return "Install cloudflared (brew install cloudflared) and, for named tunnels, run 'cloudflared tunnel login'"
+	case "localtunnel":
+		return "The localtunnel server may be busy, or the subdomain taken; retry or try --provider cloudflared"
+	}
+	return "Set NGROK_AUTHTOKEN or sign up at ngrok.com"
 }
 
 // findAvailablePort finds an available local port
@@ -257,19 +383,15 @@ func findAvailablePort() (int, error) {
 	return listener.Addr().(*net.TCPAddr).Port, nil
 }
 
-// waitTunnel returns a channel that receives the tunnel wait result
-func waitTunnel(tun tunnel.Tunnel) <-chan error {
-	ch := make(chan error, 1)
-	go func() {
-		ch <- tun.Wait()
-	}()
-	return ch
-}
-
-// printShareBox displays the public URL in a nice box
-func printShareBox(url, appName, namespace string) {
-	// Box width
+// printShareBox displays the public URLs in a nice box
+func printShareBox(links []shareLink, namespace string) {
+	// Box width fits the longest line
 	width := 60
+	for _, l := range links {
+		if n := len(l.Name) + len(l.URL) + 8; n > width {
+			width = n
+		}
+	}
 
 	// Box borders
 	topBorder := "╔" + repeat("═", width-2) + "╗"
@@ -290,11 +412,19 @@ func printShareBox(url, appName, namespace string) {
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, topBorder)
 	fmt.Fprintln(os.Stderr, emptyLine)
-	fmt.Fprintln(os.Stderr, center(fmt.Sprintf("Sharing: %s/%s", namespace, appName)))
-	fmt.Fprintln(os.Stderr, emptyLine)
-	fmt.Fprintln(os.Stderr, center("Your app is now available at:"))
-	fmt.Fprintln(os.Stderr, emptyLine)
-	fmt.Fprintln(os.Stderr, center(url))
+	if len(links) == 1 {
+		fmt.Fprintln(os.Stderr, center(fmt.Sprintf("Sharing: %s/%s", namespace, links[0].Name)))
+		fmt.Fprintln(os.Stderr, emptyLine)
+		fmt.Fprintln(os.Stderr, center("Your app is now available at:"))
+		fmt.Fprintln(os.Stderr, emptyLine)
+		fmt.Fprintln(os.Stderr, center(links[0].URL))
+	} else {
+		fmt.Fprintln(os.Stderr, center(fmt.Sprintf("Sharing %d services in %s", len(links), namespace)))
+		fmt.Fprintln(os.Stderr, emptyLine)
+		for _, l := range links {
+			fmt.Fprintln(os.Stderr, center(fmt.Sprintf("%s → %s", l.Name, l.URL)))
+		}
+	}
 	fmt.Fprintln(os.Stderr, emptyLine)
 	fmt.Fprintln(os.Stderr, center("Press Ctrl+C to stop sharing"))
 	fmt.Fprintln(os.Stderr, emptyLine)
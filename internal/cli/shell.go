@@ -25,11 +25,14 @@ Unlike kubectl exec, kbox shell:
   - Selects a pod automatically if multiple exist
 
 Examples:
-  kbox shell myapp              # Shell into myapp
-  kbox shell myapp -c sidecar   # Shell into specific container
-  kbox shell myapp -- ls -la    # Run a command instead of shell`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: runShell,
+  kbox shell myapp                  # Shell into myapp
+  kbox shell myapp -c sidecar       # Shell into specific container
+  kbox shell myapp -- ls -la        # Run a command instead of shell
+  kbox shell myapp --pick newest    # Shell into the newest pod
+  kbox shell myapp -l track=canary  # Shell into a canary pod`,
+	Args:        cobra.MinimumNArgs(1),
+	RunE:        runShell,
+	Annotations: map[string]string{writesAnnotation: "create pods/exec"},
 }
 
 func runShell(cmd *cobra.Command, args []string) error {
@@ -38,6 +41,10 @@ func runShell(cmd *cobra.Command, args []string) error {
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
 	container, _ := cmd.Flags().GetString("container")
+	sel, err := podSelector(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Set up signal handling for graceful cancellation
 	ctx, cancel := context.WithCancel(cmd.Context())
@@ -64,23 +71,12 @@ func runShell(cmd *cobra.Command, args []string) error {
 		ns = namespace
 	}
 
-	// Find pods for the app
-	pods, err := debug.FindPods(ctx, client.Clientset, ns, appName)
+	// Find pods for the app and pick the first ready one
+	pods, err := debug.FindPodsWith(ctx, client.Clientset, ns, appName, sel)
 	if err != nil {
 		return err
 	}
-
-	// Pick the first ready pod, or just the first one
-	var targetPod debug.PodInfo
-	for _, p := range pods {
-		if p.Ready {
-			targetPod = p
-			break
-		}
-	}
-	if targetPod.Name == "" {
-		targetPod = pods[0]
-	}
+	targetPod := debug.TargetPod(pods)
 
 	// Determine the command to run
 	var shellCommand []string
@@ -117,5 +113,6 @@ func runShell(cmd *cobra.Command, args []string) error {
 
 func init() {
 	shellCmd.Flags().StringP("container", "c", "", "Container name (auto-detected if not specified)")
+	addPodSelectorFlags(shellCmd)
 	rootCmd.AddCommand(shellCmd)
 }
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/sleep"
+)
+
+var sleepCmd = &cobra.Command{
+	Use:   "sleep [app]",
+	Short: "Scale an app to zero to save cost",
+	Long: `Scale all of an app's Deployments and StatefulSets (dependencies included)
+to zero. Each one's replica count is kept in a kbox.dev/sleep-replicas
+annotation, so 'kbox wake' can restore it. Volumes and config are untouched.
+
+To sleep an environment on a schedule, e.g. overnight, set spec.sleepSchedule
+in kbox.yaml (or per environment) and deploy.
+
+Examples:
+  kbox sleep                   # Sleep the app in kbox.yaml
+  kbox sleep -e staging        # Sleep the staging environment
+  kbox sleep myapp -n dev`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSleep(cmd, args, sleep.Sleep, "Put to sleep", "already asleep")
+	},
+}
+
+var wakeCmd = &cobra.Command{
+	Use:   "wake [app]",
+	Short: "Restore an app put to sleep",
+	Long: `Scale an app's Deployments and StatefulSets back to the replicas they had
+before 'kbox sleep' (or the sleep schedule) scaled them to zero.
+
+Examples:
+  kbox wake                    # Wake the app in kbox.yaml
+  kbox wake -e staging         # Wake the staging environment
+  kbox wake myapp -n dev`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSleep(cmd, args, sleep.Wake, "Woke up", "not asleep")
+	},
+}
+
+// scaleFunc is sleep.Sleep or sleep.Wake
+type scaleFunc func(ctx context.Context, client kubernetes.Interface, namespace, app string) ([]sleep.Scaled, error)
+
+func runSleep(cmd *cobra.Command, args []string, scale scaleFunc, done, noop string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	env, _ := cmd.Flags().GetString("env")
+
+	appName, cfgNamespace, target, err := loadSleepTarget(env)
+	if len(args) > 0 {
+		appName = args[0]
+	} else if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w\n  → Pass the app name: kbox %s <app>", err, cmd.Name()))
+	}
+	if env != "" {
+		kubeContext, namespace = resolveEnvTarget(cmd, env, target, kubeContext, namespace)
+	}
+	if namespace == "" {
+		namespace = cfgNamespace
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err))
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+
+	scaled, err := scale(cmd.Context(), client.Clientset, namespace, appName)
+
+	if GetOutputFormat(cmd) == "json" {
+		result := map[string]interface{}{
+			"success":   err == nil,
+			"app":       appName,
+			"namespace": namespace,
+			"scaled":    scaled,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+		if encErr := json.NewEncoder(os.Stdout).Encode(result); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	for _, s := range scaled {
+		fmt.Printf("  ✓ %s %s (%d replicas)\n", done, s, s.Replicas)
+	}
+	if err != nil {
+		return err
+	}
+	if len(scaled) == 0 {
+		fmt.Printf("%s in %s is %s\n", appName, namespace, noop)
+	}
+	return nil
+}
+
+// loadSleepTarget reads the app name, namespace, and env's cluster binding
+// from kbox.yaml
+func loadSleepTarget(env string) (string, string, config.EnvTarget, error) {
+	loader := config.NewLoader(".")
+	if multi, err := loader.IsMultiService(); err == nil && multi {
+		cfg, err := loader.LoadMultiService()
+		if err != nil {
+			return "", "", config.EnvTarget{}, err
+		}
+		return cfg.Metadata.Name, cfg.Metadata.Namespace, cfg.EnvironmentTarget(env), nil
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return "", "", config.EnvTarget{}, err
+	}
+	return cfg.Metadata.Name, cfg.Metadata.Namespace, cfg.EnvironmentTarget(env), nil
+}
+
+func init() {
+	sleepCmd.Flags().StringP("env", "e", "", "Environment whose context/namespace binding to use")
+	wakeCmd.Flags().StringP("env", "e", "", "Environment whose context/namespace binding to use")
+	rootCmd.AddCommand(sleepCmd)
+	rootCmd.AddCommand(wakeCmd)
+}
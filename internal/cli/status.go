@@ -21,18 +21,29 @@ Displays:
   - Container issues (waiting, terminated)
   - Recent events (last hour)
 
+With --all-clusters, shows one row per cluster from the 'clusters:' list
+in kbox.yaml (the app name defaults to the one in kbox.yaml).
+
 Examples:
   kbox status myapp
-  kbox status myapp -n production`,
-	Args: cobra.ExactArgs(1),
+  kbox status myapp -n production
+  kbox status --all-clusters`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runStatus,
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	appName := args[0]
-
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
+	allClusters, _ := cmd.Flags().GetBool("all-clusters")
+
+	if allClusters {
+		return runFleetStatus(cmd, args, namespace)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("app name required\n  → Run 'kbox status <app>'")
+	}
+	appName := args[0]
 
 	// Create K8s client
 	client, err := k8s.NewClient(k8s.ClientOptions{
@@ -66,6 +77,37 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runFleetStatus shows the app's state across every cluster in kbox.yaml
+func runFleetStatus(cmd *cobra.Command, args []string, namespace string) error {
+	appName, cfgNamespace, clusters, err := loadFleet()
+	if err != nil {
+		return fmt.Errorf("failed to load kbox.yaml: %w\n  → --all-clusters reads the 'clusters:' list from kbox.yaml", err)
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters defined in kbox.yaml\n  → Add a 'clusters:' list with a name and context for each cluster")
+	}
+	if len(args) > 0 {
+		appName = args[0]
+	}
+	if namespace == "" {
+		namespace = cfgNamespace
+	}
+
+	statuses := fleetStatus(cmd.Context(), clusters, namespace, appName)
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":  true,
+			"app":      appName,
+			"clusters": statuses,
+		})
+	}
+
+	printFleetStatus(os.Stdout, appName, statuses)
+	return nil
+}
+
 func init() {
+	statusCmd.Flags().Bool("all-clusters", false, "Show status across all clusters in kbox.yaml")
 	rootCmd.AddCommand(statusCmd)
 }
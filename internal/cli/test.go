@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bobbyrathoree/kbox/internal/apply"
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/golden"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Check rendered manifests against golden files, without a cluster",
+	Long: `Render kbox.yaml for the base config and each environment, apply the
+result to an in-memory fake cluster, run kbox's checks, and compare the
+manifests with the golden files under .kbox/golden/.
+
+Each environment passes when:
+  - the config is valid and renders
+  - every resource applies to the fake cluster
+  - the pods meet the app's own securityProfile
+  - the manifests match the golden file (base.yaml, or <env>.yaml)
+
+Lint issues and validation warnings are reported, and fail the test with
+--strict. Secrets are redacted in golden files, so they're safe to commit.
+
+Commit .kbox/golden/ with kbox.yaml: a refactor that changes nothing passes,
+and one that does shows the manifest diff in review.
+
+Examples:
+  kbox test                    # Test the base config and every environment
+  kbox test -e prod            # Test only prod
+  kbox test --update           # Accept the current output as the golden files
+  kbox test --strict           # Also fail on lint issues (for CI)`,
+	RunE: runTest,
+}
+
+// envTest is the outcome of testing one environment
+type envTest struct {
+	// Env is the environment, or "" for the base config
+	Env    string `json:"env"`
+	Golden string `json:"golden"`
+	// Status is pass, fail, or updated (the golden file was written)
+	Status    string   `json:"status"`
+	Resources int      `json:"resources"`
+	Errors    []string `json:"errors,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Diff      string   `json:"diff,omitempty"`
+}
+
+func (t envTest) name() string {
+	if t.Env == "" {
+		return "base"
+	}
+	return t.Env
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	envs, _ := cmd.Flags().GetStringSlice("env")
+	update, _ := cmd.Flags().GetBool("update")
+	strict, _ := cmd.Flags().GetBool("strict")
+	goldenDir, _ := cmd.Flags().GetString("golden-dir")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+	ciMode := IsCIMode(cmd)
+
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.NewLoader(".").FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	// Golden files live next to the config they test
+	if !filepath.IsAbs(goldenDir) {
+		goldenDir = filepath.Join(filepath.Dir(path), goldenDir)
+	}
+
+	target, err := loadTestTarget(path)
+	if err != nil {
+		return err
+	}
+	if len(envs) == 0 {
+		envs = append([]string{""}, target.environments()...)
+	} else {
+		for _, env := range envs {
+			if !slices.Contains(target.environments(), env) {
+				return output.WithCode(output.ErrConfig, fmt.Errorf("environment %q is not defined in %s\n  → Defined environments: %s", env, path, strings.Join(target.environments(), ", ")))
+			}
+		}
+	}
+
+	if !ciMode && !jsonOutput {
+		fmt.Printf("Testing %s (%d configuration(s))\n", target.name(), len(envs))
+	}
+
+	var results []envTest
+	var failed []string
+	for _, env := range envs {
+		result := testEnvironment(cmd.Context(), target, env, golden.Path(goldenDir, env), update, strict)
+		results = append(results, result)
+		if result.Status == "fail" {
+			failed = append(failed, result.name())
+		}
+		if !jsonOutput {
+			printEnvTest(result)
+		}
+	}
+
+	if len(failed) > 0 {
+		err = output.WithCode(output.ErrTestsFailed, fmt.Errorf("kbox test failed for %s\n  → Fix the errors above; if the manifest changes are intended, run 'kbox test --update' and commit %s", strings.Join(failed, ", "), golden.DefaultDir))
+	}
+
+	if jsonOutput {
+		result := map[string]interface{}{
+			"passed": err == nil,
+			"tests":  results,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+		json.NewEncoder(os.Stdout).Encode(result)
+		if err != nil {
+			return output.Reported(err)
+		}
+		return nil
+	}
+	return err
+}
+
+// testEnvironment renders, applies, checks and compares one environment
+func testEnvironment(ctx context.Context, target *testTarget, env, goldenPath string, update, strict bool) envTest {
+	result := envTest{Env: env, Golden: goldenPath, Status: "fail"}
+
+	bundle, apps, warnings, err := target.render(env)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	bundle.Redact()
+
+	// Apply to an in-memory cluster: this catches objects the API would reject
+	// when encoding them, and failures in kbox's apply ordering. The fake
+	// cluster has no CRDs, so ServiceMonitors are only rendered.
+	applied, err := apply.NewEngine(fake.NewClientset(), nil).Apply(ctx, bundle)
+	for _, r := range applied.Resources {
+		switch {
+		case r.Err == nil:
+			result.Resources++
+		case r.Kind == "ServiceMonitor":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("ServiceMonitor/%s not applied: the fake cluster has no CRDs", r.Name))
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("apply %s/%s: %v", r.Kind, r.Name, r.Err))
+		}
+	}
+	if err != nil && len(result.Errors) == 0 {
+		result.Errors = append(result.Errors, fmt.Sprintf("apply: %v", err))
+	}
+
+	// The pods must meet the profile the app declares; with several services,
+	// the least strict one
+	level := config.SecurityProfileRestricted
+	for _, app := range apps {
+		if app.Spec.EffectiveSecurityProfile() == config.SecurityProfileBaseline {
+			level = config.SecurityProfileBaseline
+		}
+		for _, issue := range config.Lint(app) {
+			field := issue.Field
+			if target.multi != nil {
+				// Point at the service in kbox.yaml, not the AppConfig it converts to
+				service := strings.TrimPrefix(app.Metadata.Name, target.multi.Metadata.Name+"-")
+				field = "services." + service + strings.TrimPrefix(field, "spec")
+			}
+			warnings = append(warnings, fmt.Sprintf("%s: %s [%s]", field, issue.Message, issue.Rule))
+		}
+	}
+	for _, v := range bundle.PodSecurityViolations(level) {
+		result.Errors = append(result.Errors, fmt.Sprintf("violates the %s Pod Security Standard: %s", level, v))
+	}
+	if strict {
+		for _, w := range warnings {
+			result.Errors = append(result.Errors, "strict: "+w)
+		}
+	} else {
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.ToYAML(&buf); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to encode manifests: %v", err))
+		return result
+	}
+	got := buf.String()
+
+	if update {
+		if err := golden.Write(goldenPath, got); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to write %s: %v", goldenPath, err))
+			return result
+		}
+		if len(result.Errors) == 0 {
+			result.Status = "updated"
+		}
+		return result
+	}
+
+	want, ok, err := golden.Read(goldenPath)
+	switch {
+	case err != nil:
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to read %s: %v", goldenPath, err))
+	case !ok:
+		result.Errors = append(result.Errors, fmt.Sprintf("no golden file %s; run 'kbox test --update' to create it", goldenPath))
+	default:
+		if result.Diff = golden.Diff(want, got); result.Diff != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("manifests differ from %s", goldenPath))
+		}
+	}
+	if len(result.Errors) == 0 {
+		result.Status = "pass"
+	}
+	return result
+}
+
+func printEnvTest(t envTest) {
+	switch t.Status {
+	case "pass":
+		fmt.Printf("  ✓ %s: %d resource(s) applied, matches %s\n", t.name(), t.Resources, t.Golden)
+	case "updated":
+		fmt.Printf("  ✓ %s: %d resource(s) applied, wrote %s\n", t.name(), t.Resources, t.Golden)
+	default:
+		fmt.Printf("  ✗ %s\n", t.name())
+		for _, e := range t.Errors {
+			fmt.Printf("      %s\n", e)
+		}
+	}
+	if t.Diff != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(t.Diff, "\n"), "\n") {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+	for _, w := range t.Warnings {
+		fmt.Printf("    ⚠ %s\n", w)
+	}
+}
+
+// testTarget is the config kbox test renders: a single app or a
+// multi-service config
+type testTarget struct {
+	app   *config.AppConfig
+	multi *config.MultiServiceConfig
+}
+
+func loadTestTarget(path string) (*testTarget, error) {
+	loader := config.NewLoader(filepath.Dir(path))
+	if isMulti, _ := config.IsMultiService(path); isMulti {
+		cfg, err := loader.LoadMultiServiceFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return &testTarget{multi: cfg}, nil
+	}
+	cfg, err := loader.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w\n  → Run 'kbox validate' for details", path, err)
+	}
+	return &testTarget{app: cfg}, nil
+}
+
+func (t *testTarget) name() string {
+	if t.multi != nil {
+		return t.multi.Metadata.Name
+	}
+	return t.app.Metadata.Name
+}
+
+// environments returns the names of the config's environments, sorted
+func (t *testTarget) environments() []string {
+	var envs []string
+	if t.multi != nil {
+		for env := range t.multi.Environments {
+			envs = append(envs, env)
+		}
+	} else {
+		for env := range t.app.Environments {
+			envs = append(envs, env)
+		}
+	}
+	slices.Sort(envs)
+	return envs
+}
+
+// render renders an environment, returning the app configs it rendered
+// (one per service) and validation warnings
+func (t *testTarget) render(env string) (*render.Bundle, []*config.AppConfig, []string, error) {
+	if t.multi != nil {
+		cfg := t.multi.ForEnvironment(env)
+		if err := cfg.Validate(); err != nil {
+			return nil, nil, nil, fmt.Errorf("validation failed: %w", err)
+		}
+		bundle, err := render.NewMultiService(cfg).Render()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to render: %w", err)
+		}
+		var apps []*config.AppConfig
+		for _, name := range cfg.ServiceOrder() {
+			if app, err := cfg.ToAppConfig(name); err == nil {
+				apps = append(apps, app)
+			}
+		}
+		return bundle, apps, nil, nil
+	}
+
+	cfg := t.app.ForEnvironment(env)
+	warnings, err := config.ValidateWithWarnings(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("validation failed: %w", err)
+	}
+	// As with 'kbox render', a build-only config gets a placeholder image
+	if cfg.Spec.Image == "" && cfg.Spec.Build != nil {
+		cfg.Spec.Image = fmt.Sprintf("%s:latest", cfg.Metadata.Name)
+	}
+	bundle, err := render.New(cfg).Render()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to render: %w", err)
+	}
+	return bundle, []*config.AppConfig{cfg}, warnings, nil
+}
+
+func init() {
+	testCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	testCmd.Flags().StringSliceP("env", "e", nil, "Environments to test (default: the base config and every environment)")
+	testCmd.Flags().Bool("update", false, "Write the rendered manifests as the new golden files")
+	testCmd.Flags().Bool("strict", false, "Also fail on lint issues and validation warnings")
+	testCmd.Flags().String("golden-dir", golden.DefaultDir, "Directory of golden files, relative to kbox.yaml")
+	rootCmd.AddCommand(testCmd)
+}
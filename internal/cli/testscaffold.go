@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/scaffold"
+)
+
+var testScaffoldCmd = &cobra.Command{
+	Use:   "scaffold [path]",
+	Short: "Generate a Go end-to-end test that deploys the app to kind",
+	Long: `Generate a Go test file that deploys your app to a real cluster and
+checks it comes up:
+
+  1. Creates a kind cluster (kbox-e2e) unless it exists
+  2. Deploys the app into a fresh namespace: 'kbox up' when there's a
+     Dockerfile, 'kbox deploy' with the configured image otherwise
+  3. Waits for the rollout
+  4. GETs spec.healthCheck (or /) through a port-forward
+  5. Deletes the namespace, and the cluster if the test created it
+
+The file is yours to extend. It's behind the e2e build tag, so 'go test ./...'
+skips it; run it with 'go test -tags e2e'. It needs kind, kubectl, docker and
+kbox on PATH.
+
+The default path is test/e2e/<app>_e2e_test.go.`,
+	Example: `  kbox test scaffold
+  kbox test scaffold -e staging           # Deploy the staging overlay
+  kbox test scaffold e2e/deploy_test.go   # Write it somewhere else`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTestScaffold,
+}
+
+func runTestScaffold(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	force, _ := cmd.Flags().GetBool("force")
+
+	loader := config.NewLoader(".")
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = loader.FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	if isMulti, _ := config.IsMultiService(path); isMulti {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("kbox test scaffold supports single-app configs\n  → Run it in each service's directory with its own kbox.yaml"))
+	}
+	cfg, err := loader.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if env != "" {
+		if _, ok := cfg.Environments[env]; !ok {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("environment %q is not defined in %s", env, path))
+		}
+		cfg = cfg.ForEnvironment(env)
+	}
+
+	testPath := filepath.Join("test", "e2e", strings.ReplaceAll(cfg.Metadata.Name, "-", "_")+"_e2e_test.go")
+	if len(args) > 0 {
+		testPath = args[0]
+	}
+	if !strings.HasSuffix(testPath, "_test.go") {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("%s is not a test file\n  → Go test files end in _test.go", testPath))
+	}
+	if _, err := os.Stat(testPath); err == nil && !force {
+		return fmt.Errorf("%s already exists\n  → Use --force to overwrite it", testPath)
+	}
+
+	// The test runs kbox from the config's directory, relative to its own
+	testDir, err := filepath.Abs(filepath.Dir(testPath))
+	if err != nil {
+		return err
+	}
+	configDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	appDir, err := filepath.Rel(testDir, configDir)
+	if err != nil {
+		return err
+	}
+
+	// The package is named after its directory, if that's a valid name
+	pkg := strings.ReplaceAll(filepath.Base(testDir), "-", "_")
+	if !token.IsIdentifier(pkg) {
+		pkg = ""
+	}
+
+	_, statErr := os.Stat(filepath.Join(configDir, "Dockerfile"))
+	src, err := scaffold.E2ETest(scaffold.E2EOptions{
+		App:        cfg.Metadata.Name,
+		AppDir:     filepath.ToSlash(appDir),
+		Env:        env,
+		Port:       cfg.Spec.Port,
+		HealthPath: cfg.Spec.HealthCheck,
+		Build:      cfg.Spec.Build != nil || statErr == nil,
+		Package:    pkg,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(testPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(testPath), err)
+	}
+	if err := os.WriteFile(testPath, src, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", testPath, err)
+	}
+
+	fmt.Printf("  ✓ Wrote %s\n", testPath)
+	if !IsCIMode(cmd) {
+		fmt.Println()
+		fmt.Println("Run it with:")
+		fmt.Printf("  go test -tags e2e -v -timeout 15m ./%s\n", filepath.ToSlash(filepath.Dir(filepath.Clean(testPath))))
+	}
+	return nil
+}
+
+func init() {
+	testScaffoldCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	testScaffoldCmd.Flags().StringP("env", "e", "", "Environment overlay the test deploys")
+	testScaffoldCmd.Flags().Bool("force", false, "Overwrite an existing test file")
+	testCmd.AddCommand(testScaffoldCmd)
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top [app]",
+	Short: "Show live CPU and memory usage of an app",
+	Long: `Show a live-updating table of the app's pods and its dependencies
+with CPU, memory, restarts and age.
+
+Usage comes from the metrics API (metrics-server). If metrics-server is
+not installed, pod status is still shown with CPU/memory as "-".
+
+With --output json (or --once), a single snapshot is printed and kbox exits.
+
+Examples:
+  kbox top                  # Auto-detect app from kbox.yaml
+  kbox top myapp            # Specific app
+  kbox top myapp --once     # Print one snapshot and exit
+  kbox top -o json          # One-shot JSON for scripts`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTop,
+}
+
+func runTop(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	once, _ := cmd.Flags().GetBool("once")
+
+	appName := ""
+	if len(args) > 0 {
+		appName = args[0]
+	} else {
+		loader := config.NewLoader(".")
+		cfg, err := loader.Load()
+		if err != nil {
+			return fmt.Errorf("no app specified and no kbox.yaml found\n  → Run 'kbox top <app>' or run from a project directory")
+		}
+		appName = cfg.Metadata.Name
+		if namespace == "" {
+			namespace = cfg.Metadata.Namespace
+		}
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		pods, err := debug.GetAppUsage(ctx, client.Clientset, ns, appName)
+		metricsAvailable := true
+		if errors.Is(err, debug.ErrMetricsUnavailable) {
+			metricsAvailable = false
+		} else if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":          true,
+			"app":              appName,
+			"namespace":        ns,
+			"metricsAvailable": metricsAvailable,
+			"pods":             pods,
+		})
+	}
+
+	if once || IsCIMode(cmd) {
+		return printTopSnapshot(ctx, client, ns, appName, false)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := printTopSnapshot(ctx, client, ns, appName, true); err != nil {
+			return err
+		}
+
+		select {
+		case <-sigChan:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printTopSnapshot fetches usage once and prints the table, optionally clearing the screen first
+func printTopSnapshot(ctx context.Context, client *k8s.Client, namespace, appName string, clear bool) error {
+	pods, err := debug.GetAppUsage(ctx, client.Clientset, namespace, appName)
+	metricsMissing := errors.Is(err, debug.ErrMetricsUnavailable)
+	if err != nil && !metricsMissing {
+		return err
+	}
+
+	if clear {
+		// Move cursor home and clear screen
+		fmt.Print("\033[H\033[2J")
+	}
+
+	fmt.Printf("App: %s (namespace: %s, context: %s)  %s\n\n",
+		appName, namespace, client.Context, time.Now().Format("15:04:05"))
+
+	if len(pods) == 0 {
+		fmt.Println("No pods found")
+	} else {
+		debug.PrintUsage(os.Stdout, pods)
+	}
+
+	if metricsMissing {
+		fmt.Println()
+		fmt.Println("  ⚠ metrics-server not available, CPU/memory not shown")
+		fmt.Println("    → Install it: kubectl apply -f https://github.com/kubernetes-sigs/metrics-server/releases/latest/download/components.yaml")
+	}
+
+	if clear {
+		fmt.Println()
+		fmt.Println("Press Ctrl+C to exit")
+	}
+	return nil
+}
+
+func init() {
+	topCmd.Flags().Duration("interval", 2*time.Second, "Refresh interval")
+	topCmd.Flags().Bool("once", false, "Print a single snapshot and exit")
+	rootCmd.AddCommand(topCmd)
+}
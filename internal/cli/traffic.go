@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+var trafficCmd = &cobra.Command{
+	Use:   "traffic",
+	Short: "Show the share of ingress traffic sent to the canary",
+	Long: `Show the traffic split of the app: the percentage of ingress requests
+sent to the canary Deployment that spec.traffic.split deploys.
+
+Examples:
+  kbox traffic                 # Show the live split
+  kbox traffic -e prod
+  kbox traffic set 20%         # Send 20% of requests to the canary`,
+	Args: cobra.NoArgs,
+	RunE: runTraffic,
+}
+
+var trafficSetCmd = &cobra.Command{
+	Use:   "set <percent>",
+	Short: "Change the share of ingress traffic sent to the canary",
+	Long: `Change the percentage of ingress requests sent to the canary, live, by
+patching the canary Ingress. No pods restart.
+
+The next deploy sets the weight back to spec.traffic.split.weight, so
+update kbox.yaml once the split is where you want it.
+
+Examples:
+  kbox traffic set 20%         # Send 20% of requests to the canary
+  kbox traffic set 0 -e prod   # Send none, keeping the canary running
+  kbox traffic set 100%        # Send all of them`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runTrafficSet,
+	Annotations: map[string]string{writesAnnotation: "patch ingresses.networking.k8s.io"},
+}
+
+// trafficTarget is the app whose split kbox traffic reads or changes
+type trafficTarget struct {
+	app       string
+	namespace string
+	client    *k8s.Client
+}
+
+// connectTraffic loads the app from kbox.yaml and connects to the cluster
+// of env, or of --context/--namespace
+func connectTraffic(cmd *cobra.Command) (*trafficTarget, error) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	env, _ := cmd.Flags().GetString("env")
+
+	loader := config.NewLoader(".")
+	if isMulti, _ := loader.IsMultiService(); isMulti {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("a MultiApp's services have no traffic split\n  → spec.traffic.split is set on an App"))
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err))
+	}
+	if env != "" {
+		kubeContext, namespace = resolveEnvTarget(cmd, env, cfg.EnvironmentTarget(env), kubeContext, namespace)
+		cfg = cfg.ForEnvironment(env)
+	}
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return nil, output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err))
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	return &trafficTarget{app: cfg.Metadata.Name, namespace: namespace, client: client}, nil
+}
+
+func runTraffic(cmd *cobra.Command, args []string) error {
+	t, err := connectTraffic(cmd)
+	if err != nil {
+		return err
+	}
+
+	name := render.CanaryName(t.app)
+	ing, err := t.client.Clientset.NetworkingV1().Ingresses(t.namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return fmt.Errorf("%s in %s has no traffic split\n  → Set spec.traffic.split in kbox.yaml and deploy", t.app, t.namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read ingress %s: %w", name, err)
+	}
+	weight, _ := strconv.Atoi(ing.Annotations[render.AnnotationNginxCanaryWeight])
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"app":       t.app,
+			"namespace": t.namespace,
+			"canary":    name,
+			"weight":    weight,
+		})
+	}
+	fmt.Printf("%s: %d%% of requests to %s, %d%% to %s\n", t.app, weight, name, 100-weight, t.app)
+	return nil
+}
+
+func runTrafficSet(cmd *cobra.Command, args []string) error {
+	weight, err := parseWeight(args[0])
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	t, err := connectTraffic(cmd)
+	if err != nil {
+		return err
+	}
+
+	name := render.CanaryName(t.app)
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{render.AnnotationNginxCanaryWeight: strconv.Itoa(weight)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = t.client.Clientset.NetworkingV1().Ingresses(t.namespace).Patch(cmd.Context(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if k8serrors.IsNotFound(err) {
+		return fmt.Errorf("%s in %s has no traffic split\n  → Set spec.traffic.split in kbox.yaml and deploy", t.app, t.namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update ingress %s: %w", name, err)
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   true,
+			"app":       t.app,
+			"namespace": t.namespace,
+			"canary":    name,
+			"weight":    weight,
+		})
+	}
+	fmt.Printf("✓ %d%% of requests to %s, %d%% to %s\n", weight, name, 100-weight, t.app)
+	if !IsCIMode(cmd) {
+		fmt.Println("  The next deploy restores spec.traffic.split.weight; update kbox.yaml to keep this split")
+	}
+	return nil
+}
+
+// parseWeight reads a percentage written 20% or 20
+func parseWeight(s string) (int, error) {
+	weight, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || weight < 0 || weight > 100 {
+		return 0, fmt.Errorf("invalid weight %q: expected a percentage from 0 to 100\n  → kbox traffic set 20%%", s)
+	}
+	return weight, nil
+}
+
+func init() {
+	trafficCmd.PersistentFlags().StringP("env", "e", "", "Environment whose context/namespace binding to use")
+	trafficCmd.AddCommand(trafficSetCmd)
+	rootCmd.AddCommand(trafficCmd)
+}
@@ -7,17 +7,22 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bobbyrathoree/kbox/internal/apply"
+	"github.com/bobbyrathoree/kbox/internal/cache"
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/images"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/bobbyrathoree/kbox/internal/release"
 	"github.com/bobbyrathoree/kbox/internal/render"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var upCmd = &cobra.Command{
@@ -31,20 +36,36 @@ Works with just a Dockerfile:
   3. Loads it into your local cluster (kind/minikube)
   4. Deploys and streams logs
 
-If kbox.yaml exists, it will use that for additional configuration.
+If kbox.yaml exists, it will use that for additional configuration. For a
+MultiApp, every service with a build: section is built from its own context
+and Dockerfile, --build-concurrency at a time, with each build's output
+prefixed by its service; the services are deployed once all images built.
 
 Examples:
   kbox up              # Build and deploy current directory
   kbox up -e dev       # With environment overlay
-  kbox up --no-logs    # Deploy without streaming logs`,
-	RunE: runUp,
+  kbox up --no-logs    # Deploy without streaming logs
+  kbox up --force      # Rebuild and redeploy even if nothing changed
+
+Builds and deploys are cached in ~/.kbox/cache: when the build context
+(minus .dockerignore'd files) is unchanged the image is reused, and when the
+rendered manifests match what is running the deploy is skipped.`,
+	RunE:        runUp,
+	Annotations: map[string]string{writesAnnotation: "patch deployments.apps"},
 }
 
-func runUp(cmd *cobra.Command, args []string) error {
+func runUp(cmd *cobra.Command, args []string) (err error) {
 	env, _ := cmd.Flags().GetString("env")
 	noLogs, _ := cmd.Flags().GetBool("no-logs")
 	namespace, _ := cmd.Flags().GetString("namespace")
 	kubeContext, _ := cmd.Flags().GetString("context")
+	force, _ := cmd.Flags().GetBool("force")
+
+	status, err := startStatusWebhook(cmd, "up")
+	if err != nil {
+		return err
+	}
+	defer func() { finishStatusWebhook(status, err) }()
 
 	// Get working directory
 	workDir, err := os.Getwd()
@@ -55,6 +76,9 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	// Try to load config, or infer from Dockerfile
 	loader := config.NewLoader(workDir)
+	if isMulti, _ := loader.IsMultiService(); isMulti {
+		return runUpMulti(cmd, workDir, status)
+	}
 	cfg, err := loader.Load()
 	if err != nil {
 		// Infer from Dockerfile
@@ -73,10 +97,13 @@ func runUp(cmd *cobra.Command, args []string) error {
 	if cfg.Metadata.Name != "" {
 		appName = cfg.Metadata.Name
 	}
+	status.SetApp(appName)
 
-	// Apply environment overlay
+	// Apply environment overlay and its cluster binding
+	envTarget := cfg.EnvironmentTarget(env)
 	if env != "" {
 		cfg = cfg.ForEnvironment(env)
+		kubeContext, namespace = resolveEnvTarget(cmd, env, envTarget, kubeContext, namespace)
 		fmt.Printf("Using environment: %s\n", env)
 	}
 
@@ -99,68 +126,173 @@ func runUp(cmd *cobra.Command, args []string) error {
 		targetNS = client.Namespace
 	}
 
-	// Generate image tag
-	imageTag := fmt.Sprintf("%s:kbox-%d", appName, time.Now().Unix())
+	if envTarget.Protected {
+		if err := confirmProtectedEnv(cmd, env, client.Context, targetNS); err != nil {
+			return err
+		}
+	}
+
+	// Skip the build when the build context is unchanged since the last one.
+	// The cache is best-effort: any error just means a full build and deploy.
+	buildCache, _ := cache.Default()
+	useCache := buildCache != nil && !force
+	digest, digestErr := cache.ContextDigest(workDir)
+	var tagStrategy string
+	if cfg.Spec.Build != nil {
+		tagStrategy = cfg.Spec.Build.TagStrategy
+	}
+	// By default the tag is content-addressed: same sources, same tag, no
+	// spurious rollout
+	tag, err := images.BuildTag(cmd.Context(), tagStrategy, workDir, digest, time.Now())
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+	platforms := cfg.Spec.BuildPlatforms()
+	repository := appName
+	if cfg.Spec.Build != nil && cfg.Spec.Build.Repository != "" {
+		repository = cfg.Spec.Build.Repository
+	}
+	imageTag := platformTag(repository+":"+tag, platforms)
+
+	progress := output.NewProgress(os.Stdout, IsCIMode(cmd))
+	status.Stage("build", 0)
+	cachedBuild := false
+	buildKey := cache.BuildKey(repository, digest)
+	if useCache && digestErr == nil {
+		if entry, ok := buildCache.Build(buildKey); ok && entry.ImageID != "" && imageID(cmd.Context(), entry.Image) == entry.ImageID {
+			if entry.Image == imageTag {
+				cachedBuild = true
+			} else if exec.CommandContext(cmd.Context(), "docker", "tag", entry.Image, imageTag).Run() == nil {
+				// Same image, but the tag strategy names it differently now
+				cachedBuild = true
+			}
+		}
+		if cachedBuild {
+			fmt.Printf("  ✓ Image up to date: %s\n", imageTag)
+		}
+	}
+
+	if !cachedBuild {
+		// Build image
+		fmt.Printf("Building image: %s\n", imageTag)
+		buildStart := time.Now()
+		if err := buildImage(cmd.Context(), workDir, imageTag, platforms); err != nil {
+			if len(platforms) > 1 {
+				return fmt.Errorf("build failed: %w\n  → Multi-platform builds need docker buildx and an image name that can be pushed to a registry", err)
+			}
+			return fmt.Errorf("build failed: %w", err)
+		}
+		progress.Step("Image built", time.Since(buildStart))
 
-	// Build image
-	fmt.Printf("Building image: %s\n", imageTag)
-	if err := buildImage(cmd.Context(), workDir, imageTag); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+		if buildCache != nil && digestErr == nil {
+			if id := imageID(cmd.Context(), imageTag); id != "" {
+				buildCache.PutBuild(buildKey, cache.BuildEntry{Image: imageTag, ImageID: id, Built: time.Now()})
+			}
+		}
 	}
-	fmt.Println("  ✓ Image built")
 
-	// Load into cluster (detect kind/minikube)
-	if err := loadImage(cmd.Context(), client.Context, imageTag); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load image into cluster: %v\n", err)
-		fmt.Fprintf(os.Stderr, "If using a remote cluster, ensure the image is pushed to a registry.\n")
-	} else {
-		fmt.Println("  ✓ Image loaded into cluster")
+	// A cached image is pushed and signed again too: the registry or the
+	// signing setup may have changed since it was built
+	if build := cfg.Spec.Build; build != nil && (build.Repository != "" || build.SBOM) {
+		if err := publishImage(cmd.Context(), &cfg.Spec, imageTag, workDir, len(platforms) > 1 && !cachedBuild); err != nil {
+			return err
+		}
 	}
 
 	// Update config with built image
 	cfg.Spec.Image = imageTag
 
 	// Render
+	status.Stage("render", 40)
 	renderer := render.New(cfg)
 	bundle, err := renderer.Render()
 	if err != nil {
 		return fmt.Errorf("failed to render: %w", err)
 	}
 
-	// Deploy
-	fmt.Printf("\nDeploying to %s...\n", targetNS)
-	engine := apply.NewEngine(client.Clientset, os.Stdout)
-	result, err := engine.Apply(cmd.Context(), bundle)
-	if err != nil {
-		return err
+	// Skip the deploy when this exact bundle is already running
+	deployKey := cache.DeployKey(client.Context, targetNS, appName)
+	bundleDigest, _ := bundle.Digest()
+	upToDate := false
+	if useCache && bundleDigest != "" {
+		if entry, ok := buildCache.Deploy(deployKey); ok && entry.BundleDigest == bundleDigest && isRunningImage(cmd, client, targetNS, bundle, imageTag) {
+			upToDate = true
+		}
 	}
 
-	if len(result.Errors) > 0 {
-		for _, e := range result.Errors {
-			fmt.Fprintf(os.Stderr, "  Error: %v\n", e)
+	if upToDate {
+		fmt.Printf("\n✓ %s is already up to date in %s (use --force to redeploy)\n", appName, targetNS)
+	} else {
+		if err := checkSignature(cmd.Context(), &cfg.Spec, imageTag); err != nil {
+			return err
+		}
+		checkPlatform(cmd.Context(), client, &cfg.Spec, imageTag, os.Stderr)
+		if err := checkCapacity(cmd.Context(), client, targetNS, appName, bundle, force, os.Stderr); err != nil {
+			return err
 		}
-	}
 
-	// Wait for rollout
-	if bundle.Deployment != nil {
-		if err := engine.WaitForRollout(cmd.Context(), targetNS, bundle.Deployment.Name); err != nil {
-			return fmt.Errorf("rollout failed: %w", err)
+		// Load into cluster (detect kind/minikube)
+		if err := loadImage(cmd.Context(), client.Context, imageTag); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load image into cluster: %v\n", err)
+			fmt.Fprintf(os.Stderr, "If using a remote cluster, ensure the image is pushed to a registry.\n")
+		} else {
+			fmt.Println("  ✓ Image loaded into cluster")
 		}
-	}
 
-	// Save release to history
-	store := release.NewStore(client.Clientset, targetNS, appName)
-	revision, err := store.Save(cmd.Context(), cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to save release history: %v\n", err)
-	}
+		// Stamp the Deployment with where this release came from
+		store := release.NewStore(client.Clientset, targetNS, appName)
+		change := release.CurrentChange(cmd.Context(), ".")
+		if next, err := store.NextRevision(cmd.Context()); err == nil {
+			change.Revision = next
+		}
+		change.Cause = release.ChangeCause("up", imageTag, change)
+		bundle = bundle.WithChange(change)
+
+		// Deploy
+		status.Stage("apply", 50)
+		fmt.Printf("\nDeploying to %s...\n", targetNS)
+		engine := apply.NewEngine(client.Clientset, os.Stdout)
+		engine.SetProgress(progress)
+		result, err := engine.Apply(cmd.Context(), bundle)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Errors) > 0 {
+			for _, e := range result.Errors {
+				fmt.Fprintf(os.Stderr, "  Error: %v\n", e)
+			}
+		}
+
+		// Wait for rollout
+		status.Stage("rollout", 70)
+		for _, workload := range bundle.Workloads() {
+			if err := engine.WaitForRollout(cmd.Context(), targetNS, workload); err != nil {
+				return fmt.Errorf("rollout failed: %w", err)
+			}
+		}
+
+		// Remember the deployed bundle so an unchanged 'kbox up' is a no-op
+		if buildCache != nil && len(result.Errors) == 0 && bundleDigest != "" {
+			buildCache.PutDeploy(deployKey, cache.DeployEntry{BundleDigest: bundleDigest, Image: imageTag, Deployed: time.Now()})
+		}
+
+		// Save release to history
+		revision, err := store.SaveWithChange(cmd.Context(), cfg, change, bundle.ConfigVersions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save release history: %v\n", err)
+		}
 
-	fmt.Println()
-	fmt.Printf("✓ %s is running!\n", appName)
-	if revision > 0 {
-		fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(revision))
+		fmt.Println()
+		fmt.Printf("✓ %s is running!\n", appName)
+		if revision > 0 {
+			fmt.Printf("Release %s saved (rollback available)\n", release.FormatRevision(revision))
+		}
 	}
 
+	// The operation is done; streaming logs isn't part of it
+	finishStatusWebhook(status, nil)
+
 	// Stream logs unless disabled
 	if !noLogs {
 		fmt.Println("\nStreaming logs (Ctrl+C to stop)...")
@@ -197,15 +329,36 @@ func runUp(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func buildImage(ctx context.Context, workDir, tag string) error {
-	// Use docker build
-	cmd := exec.CommandContext(ctx, "docker", "build", "-t", tag, ".")
+func buildImage(ctx context.Context, workDir, tag string, platforms []string) error {
+	cmd := exec.CommandContext(ctx, "docker", dockerBuildArgs(tag, "", ".", platforms)...)
 	cmd.Dir = workDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+// imageID returns the local docker image ID for a tag, or "" if it doesn't exist
+func imageID(ctx context.Context, tag string) string {
+	out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", tag).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// isRunningImage checks the cluster still runs the bundle's Deployment with image,
+// so a rollback or manual edit since the last 'kbox up' triggers a redeploy
+func isRunningImage(cmd *cobra.Command, client *k8s.Client, namespace string, bundle *render.Bundle, image string) bool {
+	if bundle.Deployment == nil {
+		return false
+	}
+	dep, err := client.Clientset.AppsV1().Deployments(namespace).Get(cmd.Context(), bundle.Deployment.Name, metav1.GetOptions{})
+	if err != nil || len(dep.Spec.Template.Spec.Containers) == 0 {
+		return false
+	}
+	return dep.Spec.Template.Spec.Containers[0].Image == image
+}
+
 func loadImage(ctx context.Context, kubeContext, imageTag string) error {
 	// Detect if it's a kind cluster
 	if isKindCluster(kubeContext) {
@@ -255,5 +408,9 @@ func isMinikubeCluster(context string) bool {
 func init() {
 	upCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
 	upCmd.Flags().Bool("no-logs", false, "Don't stream logs after deploy")
+	upCmd.Flags().BoolP("yes", "y", false, "Skip confirmation for protected environments")
+	addStatusWebhookFlag(upCmd)
+	upCmd.Flags().Bool("force", false, "Rebuild and redeploy even if nothing changed or the capacity check fails")
+	upCmd.Flags().Int("build-concurrency", defaultBuildConcurrency, "MultiApp service images built at once")
 	rootCmd.AddCommand(upCmd)
 }
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/upgrade"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade kbox to the latest release",
+	Long: `Download the latest kbox release from GitHub and replace this binary with it.
+
+The binary is checked against the release's checksums.txt, and checksums.txt
+against its signature, before anything is installed. Builds without the
+release signing key (e.g. built from source) refuse to upgrade unless
+--skip-signature accepts a checksum-only check.
+Set GITHUB_TOKEN to avoid GitHub API rate limits.
+
+Examples:
+  kbox upgrade                 # Upgrade to the latest release
+  kbox upgrade --check         # Only report whether an upgrade is available
+  kbox upgrade --version v1.4.0`,
+	RunE: runUpgrade,
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+	skipSignature, _ := cmd.Flags().GetBool("skip-signature")
+	tag, _ := cmd.Flags().GetString("version")
+	outputFormat := GetOutputFormat(cmd)
+
+	client := upgrade.NewClient()
+	release, err := client.GetRelease(cmd.Context(), tag)
+	if err != nil {
+		return fmt.Errorf("failed to find the release: %w\n  → Check your connection, or set GITHUB_TOKEN if rate limited", err)
+	}
+
+	current, currentOK := upgrade.ParseVersion(Version)
+	latest, latestOK := upgrade.ParseVersion(release.Tag)
+	available := !currentOK || !latestOK || current != latest
+	if tag == "" && currentOK && latestOK {
+		available = current.Less(latest)
+	}
+
+	result := map[string]interface{}{
+		"current":   Version,
+		"release":   release.Tag,
+		"available": available,
+		"upgraded":  false,
+	}
+	report := func() error {
+		if outputFormat == "json" {
+			return json.NewEncoder(os.Stdout).Encode(result)
+		}
+		return nil
+	}
+
+	if !available {
+		if outputFormat != "json" {
+			fmt.Printf("kbox %s is up to date\n", Version)
+		}
+		return report()
+	}
+	if check {
+		if outputFormat != "json" {
+			fmt.Printf("kbox %s is available (this is %s)\n", release.Tag, Version)
+			fmt.Printf("  → Run 'kbox upgrade' to install it, or see %s\n", release.URL)
+		}
+		return report()
+	}
+
+	// The checksums come from the same release as the binary, so without
+	// the signature they only catch a corrupt download
+	if ReleasePublicKey == "" {
+		if !skipSignature {
+			return fmt.Errorf("this kbox build has no release signing key, so kbox %s can't be verified\n  → Download it from %s, or pass --skip-signature to install it checked against checksums.txt only", release.Tag, release.URL)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: not verifying the signature of kbox %s: this build has no release signing key\n", release.Tag)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the kbox binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to find the kbox binary: %w", err)
+	}
+
+	if outputFormat != "json" {
+		fmt.Printf("Downloading kbox %s...\n", release.Tag)
+	}
+	binary, err := client.Fetch(cmd.Context(), release, ReleasePublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to download kbox %s: %w\n  → Nothing was installed", release.Tag, err)
+	}
+	if err := upgrade.Install(exe, binary); err != nil {
+		return fmt.Errorf("failed to install kbox %s: %w\n  → If %s is not writable, re-run with sudo or reinstall from %s", release.Tag, err, exe, release.URL)
+	}
+
+	result["upgraded"] = true
+	if outputFormat != "json" {
+		fmt.Printf("  ✓ Upgraded kbox %s → %s (%s)\n", Version, release.Tag, exe)
+	}
+	return report()
+}
+
+// versionSkewInterval is how often the version skew warning is repeated
+const versionSkewInterval = 24 * time.Hour
+
+// warnVersionSkew warns, at most once a day, when kbox is older than the
+// minKboxVersion of the project's kbox.yaml. Development builds don't warn.
+func warnVersionSkew(cmd *cobra.Command) {
+	if cmd == upgradeCmd || cmd == versionCmd {
+		return
+	}
+	current, ok := upgrade.ParseVersion(Version)
+	if !ok {
+		return
+	}
+	path, err := config.NewLoader(".").FindConfigFile()
+	if err != nil {
+		return
+	}
+	required, ok := upgrade.ParseVersion(config.ReadMinKboxVersion(path))
+	if !ok || !current.Less(required) {
+		return
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	stamp := filepath.Join(home, ".kbox", "version-skew-warned")
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < versionSkewInterval {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %s requires kbox %s or newer, this is %s\n  → Run 'kbox upgrade'\n", filepath.Base(path), required, Version)
+	if err := os.MkdirAll(filepath.Dir(stamp), 0755); err == nil {
+		os.WriteFile(stamp, nil, 0644)
+		now := time.Now()
+		os.Chtimes(stamp, now, now)
+	}
+}
+
+func init() {
+	upgradeCmd.Flags().Bool("check", false, "Only check whether an upgrade is available")
+	upgradeCmd.Flags().Bool("skip-signature", false, "Install even though this build can't verify the release signature")
+	upgradeCmd.Flags().String("version", "", "Release to install, e.g. v1.4.0 (default: latest)")
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -0,0 +1,402 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bobbyrathoree/kbox/internal/apply"
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/preview"
+)
+
+var upgradeDepCmd = &cobra.Command{
+	Use:   "upgrade-dep <dependency> --to <version>",
+	Short: "Upgrade a dependency to a new version",
+	Long: `Move a dependency (postgres, redis, mongodb, mysql) to a new version: check
+that the new server can use the existing data, update its StatefulSet, wait
+for it to be ready, then update the version in kbox.yaml (comments kept).
+
+The image variant is kept: postgres 15-alpine upgraded --to 16 becomes
+16-alpine.
+
+Across versions the data can't move between in place (a postgres major
+upgrade, a downgrade), the upgrade stops unless --dump-restore is given: the
+data is dumped to a local file, the volume replaced with an empty one, and
+the dump restored into the new server. The dump file is kept. When kbox
+can't parse a version to tell, it stops too, unless --dump-restore or
+--force (upgrade in place anyway) is given.
+
+Examples:
+  kbox upgrade-dep redis --to 7.2
+  kbox upgrade-dep mysql --to 8.4 --backup     # Dump the data to a file first
+  kbox upgrade-dep postgres --to 16 --dump-restore --yes
+  kbox upgrade-dep postgres --to 16 -e staging`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDependencyTypes,
+	RunE:              runUpgradeDep,
+	Annotations:       map[string]string{writesAnnotation: "patch statefulsets.apps"},
+}
+
+// upgradeDepResult is kbox upgrade-dep's JSON output
+type upgradeDepResult struct {
+	Success    bool     `json:"success"`
+	Dependency string   `json:"dependency"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	InPlace    bool     `json:"inPlace"`
+	Notes      []string `json:"notes,omitempty"`
+	Backup     string   `json:"backup,omitempty"`
+	Deployed   bool     `json:"deployed"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func runUpgradeDep(cmd *cobra.Command, args []string) error {
+	depType := strings.ToLower(args[0])
+	to, _ := cmd.Flags().GetString("to")
+	env, _ := cmd.Flags().GetString("env")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	backup, _ := cmd.Flags().GetBool("backup")
+	backupFile, _ := cmd.Flags().GetString("backup-file")
+	dumpRestore, _ := cmd.Flags().GetBool("dump-restore")
+	force, _ := cmd.Flags().GetBool("force")
+	timeout, _ := cmd.Flags().GetDuration("ready-timeout")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+	quiet := IsCIMode(cmd) || jsonOutput
+
+	result := &upgradeDepResult{Dependency: depType}
+	finish := func(err error) error {
+		if !jsonOutput {
+			return err
+		}
+		result.Success = err == nil
+		if err != nil {
+			result.Error = err.Error()
+		}
+		json.NewEncoder(os.Stdout).Encode(result)
+		if err != nil {
+			return output.Reported(err)
+		}
+		return nil
+	}
+	logf := func(format string, a ...interface{}) {
+		if !quiet {
+			fmt.Printf(format, a...)
+		}
+	}
+
+	template, ok := dependencies.Get(depType)
+	if !ok {
+		return finish(fmt.Errorf("unsupported dependency: %s\n  → Supported: %v", depType, dependencies.SupportedTypes()))
+	}
+
+	loader := config.NewLoader(".")
+	configPath, err := loader.FindConfigFile()
+	if err != nil {
+		return finish(output.WithCode(output.ErrConfig, fmt.Errorf("failed to find kbox.yaml: %w", err)))
+	}
+	cfg, err := loader.LoadFile(configPath)
+	if err != nil {
+		return finish(output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err)))
+	}
+	var current *config.DependencyConfig
+	for i := range cfg.Spec.Dependencies {
+		if cfg.Spec.Dependencies[i].Type == depType {
+			current = &cfg.Spec.Dependencies[i]
+		}
+	}
+	if current == nil {
+		return finish(output.WithCode(output.ErrConfig, fmt.Errorf("%s is not a dependency in %s\n  → Run 'kbox add %s' to add it", depType, configPath, depType)))
+	}
+
+	// An environment can point the dependency at an external server
+	external := current.External != nil
+	if env != "" {
+		for _, dep := range cfg.ForEnvironment(env).Spec.Dependencies {
+			if dep.Type == depType {
+				external = dep.External != nil
+			}
+		}
+	}
+	if external {
+		return finish(output.WithCode(output.ErrConfig, fmt.Errorf("%s is external: kbox doesn't run its server\n  → Upgrade it with your database provider", depType)))
+	}
+
+	from := current.Version
+	if from == "" {
+		from = template.DefaultVersion
+	}
+	version := dependencies.UpgradeVersion(template, current.Version, to)
+	result.From, result.To = from, version
+	if version == from {
+		logf("%s is already at %s\n", depType, from)
+		result.InPlace = true
+		return finish(nil)
+	}
+
+	// Check the new server can use the data before touching anything
+	upgrade, err := dependencies.CheckUpgrade(depType, from, version)
+	result.InPlace, result.Notes = upgrade.InPlace, upgrade.Notes
+	logf("Upgrading %s %s → %s\n", depType, from, version)
+	if err != nil {
+		// Unknown versions may not be able to use the data in place
+		if !dumpRestore && !force {
+			return finish(output.WithCode(output.ErrPolicy, fmt.Errorf("can't tell whether %s %s can use the data of %s %s: %v\n  → Re-run with --dump-restore to move the data by dump and restore, or --force to upgrade in place anyway", depType, version, depType, from, err)))
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: can't check data compatibility: %v\n", err)
+		}
+	}
+	for _, note := range upgrade.Notes {
+		logf("  ⚠ %s\n", note)
+	}
+	if !upgrade.InPlace && !dumpRestore {
+		return finish(output.WithCode(output.ErrPolicy, fmt.Errorf("%s %s → %s can't run on the existing data\n  → Re-run with --dump-restore to dump the data, replace the volume, and restore the dump into %s %s", depType, from, version, depType, version)))
+	}
+
+	// Connect to the environment's cluster
+	target := cfg.EnvironmentTarget(env)
+	if env != "" {
+		kubeContext, namespace = resolveEnvTarget(cmd, env, target, kubeContext, namespace)
+	}
+	if namespace == "" {
+		namespace = cfg.Metadata.Namespace
+	}
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext, Namespace: namespace})
+	if err != nil {
+		return finish(output.WithCode(output.ErrCluster, fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)))
+	}
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	if target.Protected {
+		if err := confirmProtectedEnv(cmd, env, client.Context, namespace); err != nil {
+			return finish(err)
+		}
+	}
+	if dumpRestore {
+		if err := confirm(cmd, confirmation{
+			Action:  fmt.Sprintf("--dump-restore (deleting the %s volume in %s after dumping it)", depType, namespace),
+			Details: []string{fmt.Sprintf("This will replace the %s volume in namespace %q: its data is dumped, the volume deleted, and the dump restored into %s %s.", depType, namespace, depType, version)},
+			Typed:   depType,
+		}); err != nil {
+			return finish(err)
+		}
+	}
+
+	ctx := cmd.Context()
+	name := fmt.Sprintf("%s-%s", cfg.Metadata.Name, depType)
+	ss, err := client.Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Nothing deployed yet: the next deploy creates it at the new version
+		if err := saveDependencyVersion(configPath, depType, version); err != nil {
+			return finish(err)
+		}
+		logf("  ✓ Updated %s (StatefulSet/%s is not deployed in %s)\n", configPath, name, namespace)
+		logf("  → Run 'kbox deploy' to deploy %s %s\n", depType, version)
+		return finish(nil)
+	}
+	if err != nil {
+		return finish(fmt.Errorf("failed to get StatefulSet/%s: %w", name, err))
+	}
+	result.Deployed = true
+	pod := preview.DependencyPod(cfg.Metadata.Name, depType)
+
+	if backup || dumpRestore {
+		if backupFile == "" {
+			backupFile = fmt.Sprintf("%s-%s-%s.dump", name, from, time.Now().Format("20060102-150405"))
+		}
+		size, err := dumpDependency(ctx, client, namespace, pod, depType, template, backupFile)
+		if err != nil {
+			return finish(fmt.Errorf("backup failed: %w\n  → Nothing was changed", err))
+		}
+		result.Backup = backupFile
+		logf("  ✓ Backed up %s to %s (%.1f MB)\n", depType, backupFile, float64(size)/(1024*1024))
+	}
+
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	if dumpRestore {
+		if err := replaceDependencyVolume(ctx, client, namespace, name, pod); err != nil {
+			return finish(fmt.Errorf("failed to replace the %s volume: %w\n  → The data is in %s", depType, err, backupFile))
+		}
+		logf("  ✓ Replaced the %s volume\n", depType)
+	}
+
+	// Update the image (and the replicas --dump-restore scaled down)
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []map[string]interface{}{{"name": depType, "image": dependencies.ImageWithVersion(template, version)}},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return finish(err)
+	}
+	if _, err := client.Clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, body, metav1.PatchOptions{FieldManager: apply.FieldManager}); err != nil {
+		return finish(fmt.Errorf("failed to update StatefulSet/%s: %w", name, err))
+	}
+
+	var progressOut io.Writer = os.Stdout
+	if quiet {
+		progressOut = io.Discard
+	}
+	engine := apply.NewEngine(client.Clientset, progressOut)
+	engine.SetProgress(output.NewProgress(progressOut, quiet))
+	engine.SetTimeout(timeout)
+	if err := engine.WaitForRollout(ctx, namespace, name); err != nil {
+		err = fmt.Errorf("%s %s didn't become ready: %w\n  → Run 'kbox logs %s' to see why; kbox.yaml still has %s", depType, version, err, name, from)
+		if result.Backup != "" {
+			err = fmt.Errorf("%w\n  → The data from before the upgrade is in %s", err, result.Backup)
+		}
+		return finish(output.WithCode(output.ErrRolloutFailed, err))
+	}
+
+	if dumpRestore {
+		if err := restoreDependency(ctx, client, namespace, pod, depType, template, backupFile); err != nil {
+			return finish(fmt.Errorf("restore into %s %s failed: %w\n  → The dump is in %s", depType, version, err, backupFile))
+		}
+		logf("  ✓ Restored %s into %s %s\n", backupFile, depType, version)
+	}
+
+	if err := saveDependencyVersion(configPath, depType, version); err != nil {
+		return finish(fmt.Errorf("%s is at %s, but %w\n  → Set its version in kbox.yaml to %q", depType, version, err, version))
+	}
+	logf("  ✓ %s %s is ready; updated %s\n", depType, version, configPath)
+	return finish(nil)
+}
+
+// saveDependencyVersion writes a dependency's new version to kbox.yaml,
+// keeping its comments
+func saveDependencyVersion(path, depType, version string) error {
+	node, err := config.LoadYAMLWithComments(path)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if !config.SetDependencyVersion(config.GetRootDocument(node), depType, version) {
+		return fmt.Errorf("%s has no %s dependency to update", path, depType)
+	}
+	if err := config.SaveYAMLWithComments(path, node); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// dumpDependency writes the dependency's data to a local file with its
+// template's DumpCommand, and returns the dump's size
+func dumpDependency(ctx context.Context, client *k8s.Client, namespace, pod, depType string, template dependencies.Template, path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if err := debug.ExecStream(ctx, client.Clientset, client.RestConfig, namespace, pod, depType, template.DumpCommand, nil, f); err != nil {
+		// A partial dump is no backup
+		f.Close()
+		os.Remove(path)
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+// restoreDependency loads a dump file into the dependency
+func restoreDependency(ctx context.Context, client *k8s.Client, namespace, pod, depType string, template dependencies.Template, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := debug.ExecStream(ctx, client.Clientset, client.RestConfig, namespace, pod, depType, template.RestoreCommand, f, nil); err != nil {
+		return err
+	}
+	if len(template.ReloadCommand) > 0 {
+		// May end the container, which the StatefulSet restarts on the restored data
+		_ = debug.ExecStream(ctx, client.Clientset, client.RestConfig, namespace, pod, depType, template.ReloadCommand, nil, nil)
+	}
+	return nil
+}
+
+// replaceDependencyVolume scales a dependency's StatefulSet to zero and
+// deletes its pod's volume, so it starts on an empty one
+func replaceDependencyVolume(ctx context.Context, client *k8s.Client, namespace, name, pod string) error {
+	scale := []byte(`{"spec":{"replicas":0}}`)
+	if _, err := client.Clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, scale, metav1.PatchOptions{FieldManager: apply.FieldManager}); err != nil {
+		return err
+	}
+	if err := waitDeleted(ctx, func() error {
+		_, err := client.Clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		return err
+	}); err != nil {
+		return fmt.Errorf("pod %s didn't stop: %w", pod, err)
+	}
+
+	// The volumeClaimTemplate's claim for the only replica
+	pvc := "data-" + pod
+	if err := client.Clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvc, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := waitDeleted(ctx, func() error {
+		_, err := client.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvc, metav1.GetOptions{})
+		return err
+	}); err != nil {
+		return fmt.Errorf("PersistentVolumeClaim/%s wasn't deleted: %w", pvc, err)
+	}
+	return nil
+}
+
+// waitDeleted polls get until it returns NotFound, for up to two minutes
+func waitDeleted(ctx context.Context, get func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := get(); apierrors.IsNotFound(err) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func init() {
+	upgradeDepCmd.Flags().String("to", "", "Version to upgrade to (e.g. 16, or 16-bookworm for another image variant)")
+	upgradeDepCmd.Flags().StringP("env", "e", "", "Environment whose context/namespace binding to use")
+	upgradeDepCmd.Flags().Bool("backup", false, "Dump the data to a local file before upgrading")
+	upgradeDepCmd.Flags().String("backup-file", "", "Where to write the dump (default: <app>-<dependency>-<version>-<time>.dump)")
+	upgradeDepCmd.Flags().Bool("dump-restore", false, "Move the data by dump and restore, for versions that can't use it in place")
+	upgradeDepCmd.Flags().Bool("force", false, "Upgrade in place even when kbox can't tell whether the new version can use the data")
+	upgradeDepCmd.Flags().BoolP("yes", "y", false, "Confirm --dump-restore and protected environments")
+	upgradeDepCmd.Flags().Duration("ready-timeout", 5*time.Minute, "How long to wait for the upgraded dependency to be ready")
+	deprecateTimeout(upgradeDepCmd, "ready-timeout")
+	upgradeDepCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(upgradeDepCmd)
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/apply"
+	"github.com/bobbyrathoree/kbox/internal/cache"
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/images"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+	"github.com/bobbyrathoree/kbox/internal/notify"
+	"github.com/bobbyrathoree/kbox/internal/output"
+	"github.com/bobbyrathoree/kbox/internal/release"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// defaultBuildConcurrency is how many service images kbox up builds at once
+const defaultBuildConcurrency = 4
+
+// serviceBuild is the image of one MultiApp service that kbox up builds
+type serviceBuild struct {
+	service    string
+	image      string
+	context    string
+	dockerfile string
+	platforms  []string
+	err        error
+	elapsed    time.Duration
+}
+
+// runUpMulti is kbox up for a MultiApp: it builds the image of every
+// service with a build: section, several at once, and deploys the services
+// once all of them have built
+func runUpMulti(cmd *cobra.Command, workDir string, status *notify.StatusWebhook) error {
+	env, _ := cmd.Flags().GetString("env")
+	noLogs, _ := cmd.Flags().GetBool("no-logs")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+	force, _ := cmd.Flags().GetBool("force")
+	concurrency, _ := cmd.Flags().GetInt("build-concurrency")
+
+	multiCfg, err := config.NewLoader(workDir).LoadMultiService()
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load kbox.yaml: %w", err))
+	}
+	appName := multiCfg.Metadata.Name
+	status.SetApp(appName)
+
+	envTarget := multiCfg.EnvironmentTarget(env)
+	if env != "" {
+		multiCfg = multiCfg.ForEnvironment(env)
+		kubeContext, namespace = resolveEnvTarget(cmd, env, envTarget, kubeContext, namespace)
+		fmt.Printf("Using environment: %s\n", env)
+	}
+	if namespace != "" {
+		multiCfg.Metadata.Namespace = namespace
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
+	}
+	targetNS := multiCfg.Metadata.Namespace
+	if targetNS == "" {
+		targetNS = client.Namespace
+	}
+	if envTarget.Protected {
+		if err := confirmProtectedEnv(cmd, env, client.Context, targetNS); err != nil {
+			return err
+		}
+	}
+
+	builds, err := planServiceBuilds(cmd.Context(), multiCfg, workDir)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, err)
+	}
+
+	progress := output.NewProgress(os.Stdout, IsCIMode(cmd))
+	status.Stage("build", 0)
+	if len(builds) > 0 {
+		fmt.Printf("Building %d service images, %d at a time...\n", len(builds), min(concurrency, len(builds)))
+		buildServices(cmd.Context(), workDir, builds, concurrency, os.Stdout)
+
+		var failed []string
+		for _, b := range builds {
+			if b.err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", b.service, b.err))
+				continue
+			}
+			progress.Step(fmt.Sprintf("Built %s (%s)", b.service, b.image), b.elapsed)
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("build failed, so nothing was deployed:\n    - %s\n  → The [service] lines above have each build's output", strings.Join(failed, "\n    - "))
+		}
+
+		// Push, sign and attest each image as kbox up does for a single app
+		for _, b := range builds {
+			svc := multiCfg.Services[b.service]
+			if build := svc.Build; build.Repository != "" || build.SBOM {
+				spec := &config.AppSpec{Build: build, Signing: svc.Signing}
+				if err := publishImage(cmd.Context(), spec, b.image, workDir, len(b.platforms) > 1); err != nil {
+					return fmt.Errorf("%s: %w", b.service, err)
+				}
+			}
+		}
+	}
+
+	for _, b := range builds {
+		svc := multiCfg.Services[b.service]
+		svc.Image = b.image
+		multiCfg.Services[b.service] = svc
+	}
+
+	status.Stage("render", 40)
+	bundle, err := render.NewMultiService(multiCfg).Render()
+	if err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	if err := checkCapacity(cmd.Context(), client, targetNS, appName, bundle, force, os.Stderr); err != nil {
+		return err
+	}
+
+	for _, b := range builds {
+		if err := loadImage(cmd.Context(), client.Context, b.image); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load image %s into cluster: %v\n", b.image, err)
+			fmt.Fprintf(os.Stderr, "If using a remote cluster, ensure the image is pushed to a registry.\n")
+		}
+	}
+
+	change := release.CurrentChange(cmd.Context(), ".")
+	change.Cause = release.ChangeCause("up", appName, change)
+	bundle = bundle.WithChange(change)
+
+	status.Stage("apply", 50)
+	fmt.Printf("\nDeploying to %s...\n", targetNS)
+	engine := apply.NewEngine(client.Clientset, os.Stdout)
+	engine.SetProgress(progress)
+	result, err := engine.Apply(cmd.Context(), bundle)
+	if err != nil {
+		return err
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  Error: %v\n", e)
+	}
+
+	status.Stage("rollout", 70)
+	for _, dep := range bundle.Deployments {
+		if err := engine.WaitForRollout(cmd.Context(), targetNS, dep.Name); err != nil {
+			return fmt.Errorf("rollout failed: %w", err)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("✓ %s is running!\n", appName)
+
+	// The operation is done; streaming logs isn't part of it
+	finishStatusWebhook(status, nil)
+
+	if !noLogs {
+		streamServiceLogs(cmd, client, targetNS, appName, multiCfg.ServiceOrder())
+	}
+	return nil
+}
+
+// planServiceBuilds returns the images to build for the services with a
+// build: section, in dependency order. They share one tag, by the strategy
+// the services set, so an up's images go together.
+func planServiceBuilds(ctx context.Context, cfg *config.MultiServiceConfig, workDir string) ([]*serviceBuild, error) {
+	var builds []*serviceBuild
+	strategy := ""
+	for _, name := range cfg.ServiceOrder() {
+		build := cfg.Services[name].Build
+		if build == nil {
+			continue
+		}
+		if build.TagStrategy != "" && strategy != "" && build.TagStrategy != strategy {
+			return nil, fmt.Errorf("services.%s.build.tagStrategy is %s, but another service's is %s\n  → The images kbox up builds share a tag; use one strategy", name, build.TagStrategy, strategy)
+		}
+		if build.TagStrategy != "" {
+			strategy = build.TagStrategy
+		}
+		repository := cfg.Metadata.Name + "-" + name
+		if build.Repository != "" {
+			repository = build.Repository
+		}
+		buildCtx := build.Context
+		if buildCtx == "" {
+			buildCtx = "."
+		}
+		builds = append(builds, &serviceBuild{
+			service:    name,
+			image:      repository,
+			context:    buildCtx,
+			dockerfile: build.Dockerfile,
+			platforms:  build.Platforms,
+		})
+	}
+	if len(builds) == 0 {
+		return nil, nil
+	}
+
+	digest, _ := cache.ContextDigest(workDir)
+	tag, err := images.BuildTag(ctx, strategy, workDir, digest, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range builds {
+		b.image = platformTag(b.image+":"+tag, b.platforms)
+	}
+	return builds, nil
+}
+
+// buildServices builds the images, concurrency at a time, writing each
+// build's output to out a line at a time, prefixed with its service. Every
+// build runs to the end, so one failure doesn't hide another.
+func buildServices(ctx context.Context, workDir string, builds []*serviceBuild, concurrency int, out io.Writer) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var mu sync.Mutex // keeps the builds' lines whole
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, b := range builds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			w := &prefixWriter{mu: &mu, out: out, prefix: "[" + b.service + "] "}
+			start := time.Now()
+			cmd := exec.CommandContext(ctx, "docker", dockerBuildArgs(b.image, b.dockerfile, b.context, b.platforms)...)
+			cmd.Dir = workDir
+			cmd.Stdout = w
+			cmd.Stderr = w
+			b.err = cmd.Run()
+			b.elapsed = time.Since(start)
+			w.Flush()
+		}()
+	}
+	wg.Wait()
+}
+
+// prefixWriter writes complete lines to out, each with prefix, holding mu
+// so lines of concurrent writers don't interleave
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes what's left of an unterminated last line
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
+
+// streamServiceLogs follows the logs of every service of a MultiApp until
+// interrupted, as kbox logs --all-services does
+func streamServiceLogs(cmd *cobra.Command, client *k8s.Client, namespace, appName string, services []string) {
+	fmt.Println("\nStreaming logs (Ctrl+C to stop)...")
+	fmt.Println()
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	var pods []debug.PodInfo
+	for _, svc := range services {
+		// Services run as <app>-<service>, see MultiServiceConfig.ToAppConfig
+		svcPods, err := debug.FindPods(ctx, client.Clientset, namespace, appName+"-"+svc)
+		if err != nil {
+			continue
+		}
+		for i := range svcPods {
+			svcPods[i].Service = svc
+		}
+		pods = append(pods, svcPods...)
+	}
+	if len(pods) == 0 {
+		return
+	}
+	opts := debug.LogsOptions{
+		Follow:       true,
+		Timestamps:   true,
+		TailLines:    50,
+		AutoPrevious: true,
+		ShowEvents:   true,
+	}
+	debug.StreamLogs(ctx, client.Clientset, namespace, pods, opts, os.Stdout)
+}
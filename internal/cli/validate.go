@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +25,8 @@ Examples:
   kbox validate                    # Validate ./kbox.yaml
   kbox validate -f custom.yaml     # Validate specific file
   kbox validate --strict           # Fail on warnings (for CI)`,
-	RunE: runValidate,
+	RunE:        runValidate,
+	Annotations: map[string]string{offlineAnnotation: ""},
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
@@ -46,11 +48,12 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// Prepare result for output
 	result := struct {
-		Valid    bool     `json:"valid"`
-		Strict   bool     `json:"strict"`
-		Errors   []string `json:"errors,omitempty"`
-		Warnings []string `json:"warnings,omitempty"`
-		File     string   `json:"file"`
+		Valid     bool             `json:"valid"`
+		Strict    bool             `json:"strict"`
+		Errors    []string         `json:"errors,omitempty"`
+		Warnings  []string         `json:"warnings,omitempty"`
+		File      string           `json:"file"`
+		ErrorCode output.ErrorCode `json:"errorCode,omitempty"`
 	}{
 		Valid:  err == nil,
 		Strict: strict,
@@ -77,13 +80,16 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// JSON output
 	if outputFormat == "json" {
+		if !result.Valid {
+			result.ErrorCode = output.ErrConfig
+		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(result); err != nil {
 			return err
 		}
 		if !result.Valid {
-			return fmt.Errorf("validation failed")
+			return output.WithCode(output.ErrConfig, fmt.Errorf("validation failed"))
 		}
 		return nil
 	}
@@ -95,7 +101,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		for _, e := range result.Errors {
 			fmt.Printf("  Error: %s\n", e)
 		}
-		return fmt.Errorf("validation failed")
+		return output.WithCode(output.ErrConfig, fmt.Errorf("validation failed"))
 	}
 
 	// Config is syntactically valid
@@ -105,7 +111,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Warning: %s\n", w)
 		}
 		if strict {
-			return fmt.Errorf("validation failed: %d warning(s) in strict mode", len(result.Warnings))
+			return output.WithCode(output.ErrConfig, fmt.Errorf("validation failed: %d warning(s) in strict mode", len(result.Warnings)))
 		}
 	} else {
 		fmt.Println("  No warnings")
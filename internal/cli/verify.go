@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/images"
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [image]",
+	Short: "Check the signature and SBOM attestation of the image to deploy",
+	Long: `Verify the app image's cosign signature, and whether it has a signed SBOM
+attestation, against spec.signing in kbox.yaml: its public key, or for keyless
+signatures, the identity and OIDC issuer they must be issued to.
+
+The image defaults to the one kbox deploy would deploy (spec.image, after the
+environment overlay). Needs cosign on PATH.
+
+With signing.requireSigned: true, kbox deploy runs the same check and refuses
+an unsigned image. kbox up signs the image it builds when signing has a key
+or keyless is set.
+
+Examples:
+  kbox verify                          # The image kbox deploy would deploy
+  kbox verify -e prod                  # The prod environment's image
+  kbox verify ghcr.io/acme/api:v1.4.2  # Another image, with kbox.yaml's keys`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVerify,
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("file")
+	env, _ := cmd.Flags().GetString("env")
+	jsonOutput := GetOutputFormat(cmd) == "json"
+
+	loader := config.NewLoader(".")
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = loader.FindConfigFile(); err != nil {
+			return output.WithCode(output.ErrConfig, fmt.Errorf("%w\n  → Run 'kbox init' to create one", err))
+		}
+	}
+	cfg, err := config.NewLoader(filepath.Dir(path)).LoadFile(path)
+	if err != nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("failed to load %s: %w", path, err))
+	}
+	cfg = cfg.ForEnvironment(env)
+
+	if cfg.Spec.Signing == nil {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("no signing configuration in %s\n  → Add spec.signing with the public key (publicKey: cosign.pub) or keyless identity to verify against", path))
+	}
+	image := cfg.Spec.Image
+	if len(args) > 0 {
+		image = args[0]
+	}
+	if image == "" {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("no image to verify: %s has no spec.image\n  → Pass the image, e.g. 'kbox verify ghcr.io/acme/api:v1'", path))
+	}
+
+	result, err := images.Verify(cmd.Context(), cfg.Spec.Signing, image)
+	if err != nil {
+		return err
+	}
+	if !result.Signed {
+		err = output.WithCode(output.ErrPolicy, fmt.Errorf("%s has no valid signature: %s", image, result.Error))
+	}
+
+	if jsonOutput {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"verified":     err == nil,
+			"verification": result,
+		})
+		if err != nil {
+			return output.Reported(err)
+		}
+		return nil
+	}
+
+	fmt.Printf("Verifying %s\n", image)
+	if !result.Signed {
+		fmt.Println("  ✗ Not signed")
+		return err
+	}
+	fmt.Printf("  ✓ Signed (%s)\n", signer(cfg.Spec.Signing))
+	if result.SBOM {
+		fmt.Println("  ✓ SBOM attestation")
+	} else {
+		fmt.Println("  ⚠ No SBOM attestation (set build.sbom: true for kbox up to attach one)")
+	}
+	return nil
+}
+
+// signer describes who signatures are verified against
+func signer(s *config.SigningConfig) string {
+	if s.Keyless {
+		return fmt.Sprintf("keyless, %s via %s", s.Identity, s.Issuer)
+	}
+	return "key " + s.VerificationKey()
+}
+
+// checkSignature refuses image when the config requires signed images and
+// it has no valid signature
+func checkSignature(ctx context.Context, spec *config.AppSpec, image string) error {
+	if spec.Signing == nil || !spec.Signing.RequireSigned || image == "" {
+		return nil
+	}
+	result, err := images.Verify(ctx, spec.Signing, image)
+	if err != nil {
+		return output.WithCode(output.ErrPolicy, fmt.Errorf("signing.requireSigned is set, but the signature of %s can't be checked: %w", image, err))
+	}
+	if !result.Signed {
+		return output.WithCode(output.ErrPolicy, fmt.Errorf("signing.requireSigned is set and %s has no valid signature (%s)\n  → Sign it with 'cosign sign', or build it with 'kbox up' and signing.key or signing.keyless", image, result.Error))
+	}
+	return nil
+}
+
+// publishImage pushes an image kbox up built to build.repository, if set,
+// generates its SBOM, and signs it and attests the SBOM as spec.signing says.
+// SBOMs are kept in .kbox/sbom. pushed is set when the build already pushed
+// the image (multi-platform builds).
+func publishImage(ctx context.Context, spec *config.AppSpec, image, workDir string, pushed bool) error {
+	build := spec.Build
+	// Fail before pushing rather than after, when a tool is missing
+	if build.SBOM {
+		if err := images.RequireTool("syft"); err != nil {
+			return fmt.Errorf("build.sbom needs syft: %w", err)
+		}
+	}
+	if spec.Signing != nil && spec.Signing.Signs() && build.Repository != "" {
+		if err := images.RequireTool("cosign"); err != nil {
+			return fmt.Errorf("signing needs cosign: %w", err)
+		}
+	}
+	ref := image
+	if build.Repository != "" {
+		if !pushed {
+			fmt.Printf("Pushing %s\n", image)
+			push := exec.CommandContext(ctx, "docker", "push", image)
+			push.Stdout, push.Stderr = os.Stdout, os.Stderr
+			if err := push.Run(); err != nil {
+				return fmt.Errorf("failed to push %s: %w\n  → Run 'docker login %s'", image, err, strings.Split(build.Repository, "/")[0])
+			}
+		}
+		// Sign the digest, so the signature can't end up on another image
+		// pushed with the same tag
+		if digest, err := images.NewResolver().Resolve(ctx, image); err == nil {
+			ref = build.Repository + "@" + digest
+		}
+	}
+
+	var sbomPath string
+	if build.SBOM {
+		name := strings.NewReplacer("/", "_", ":", "_").Replace(image)
+		sbomPath = filepath.Join(workDir, ".kbox", "sbom", name+".spdx.json")
+		if err := images.GenerateSBOM(ctx, ref, sbomPath); err != nil {
+			return err
+		}
+		fmt.Printf("  ✓ SBOM written to %s\n", sbomPath)
+	}
+
+	if spec.Signing == nil || !spec.Signing.Signs() || build.Repository == "" {
+		return nil
+	}
+	if err := images.Sign(ctx, spec.Signing, ref); err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ Signed %s\n", ref)
+	if sbomPath != "" {
+		if err := images.Attest(ctx, spec.Signing, ref, sbomPath); err != nil {
+			return err
+		}
+		fmt.Println("  ✓ SBOM attestation attached")
+	}
+	return nil
+}
+
+func init() {
+	verifyCmd.Flags().StringP("file", "f", "", "Path to kbox.yaml (default: ./kbox.yaml)")
+	verifyCmd.Flags().StringP("env", "e", "", "Environment overlay to apply")
+	rootCmd.AddCommand(verifyCmd)
+}
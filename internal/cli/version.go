@@ -5,26 +5,32 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
 )
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print kbox version information",
+	Long: `Print kbox version information.
+
+With --detailed, also print the kbox.yaml apiVersions this kbox reads, and the
+current cluster's version and which optional APIs kbox features rely on
+(autoscaling, ServiceMonitors, cert-manager, ...) it serves.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		short, _ := cmd.Flags().GetBool("short")
+		detailed, _ := cmd.Flags().GetBool("detailed")
 		outputFormat := GetOutputFormat(cmd)
 
+		info := versionInfo(cmd, detailed)
+
 		// JSON output
 		if outputFormat == "json" {
-			json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
-				"version":   Version,
-				"gitCommit": GitCommit,
-				"buildDate": BuildDate,
-				"goVersion": runtime.Version(),
-				"platform":  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
-			})
+			json.NewEncoder(os.Stdout).Encode(info)
 			return
 		}
 
@@ -34,16 +40,70 @@ var versionCmd = &cobra.Command{
 			return
 		}
 
-		// Full text output
-		fmt.Printf("kbox version %s\n", Version)
-		fmt.Printf("  git commit: %s\n", GitCommit)
-		fmt.Printf("  build date: %s\n", BuildDate)
-		fmt.Printf("  go version: %s\n", runtime.Version())
-		fmt.Printf("  platform:   %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		printVersionInfo(info)
 	},
 }
 
+// versionInfo describes this kbox and, when detailed, the current cluster
+func versionInfo(cmd *cobra.Command, detailed bool) map[string]interface{} {
+	info := map[string]interface{}{
+		"version":   Version,
+		"gitCommit": GitCommit,
+		"buildDate": BuildDate,
+		"goVersion": runtime.Version(),
+		"platform":  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+	if !detailed {
+		return info
+	}
+
+	info["apiVersions"] = []string{config.DefaultAPIVersion}
+	info["kinds"] = []string{config.DefaultKind, config.MultiAppKind}
+
+	kubeContext, _ := cmd.Flags().GetString("context")
+	client, err := k8s.NewClient(k8s.ClientOptions{Context: kubeContext})
+	if err != nil {
+		info["cluster"] = map[string]interface{}{"error": firstLine(err.Error())}
+		return info
+	}
+	info["cluster"] = map[string]interface{}{
+		"context":      client.Context,
+		"version":      client.ServerVersion,
+		"capabilities": k8s.Capabilities(client.Clientset.Discovery()),
+	}
+	return info
+}
+
+func printVersionInfo(info map[string]interface{}) {
+	fmt.Printf("kbox version %s\n", info["version"])
+	fmt.Printf("  git commit: %s\n", info["gitCommit"])
+	fmt.Printf("  build date: %s\n", info["buildDate"])
+	fmt.Printf("  go version: %s\n", info["goVersion"])
+	fmt.Printf("  platform:   %s\n", info["platform"])
+
+	cluster, ok := info["cluster"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	fmt.Printf("  kbox.yaml:  apiVersion %s, kind %s\n",
+		strings.Join(info["apiVersions"].([]string), ", "), strings.Join(info["kinds"].([]string), ", "))
+	if errMsg, ok := cluster["error"]; ok {
+		fmt.Printf("  cluster:    not reachable (%s)\n", errMsg)
+		return
+	}
+	fmt.Printf("  cluster:    %s (context %s)\n", cluster["version"], cluster["context"])
+	fmt.Println("  capabilities:")
+	for _, c := range cluster["capabilities"].([]k8s.Capability) {
+		mark := "✓"
+		if !c.Available {
+			mark = "✗"
+		}
+		fmt.Printf("    %s %-26s %s\n", mark, c.GroupVersion, c.Feature)
+	}
+}
+
 func init() {
 	versionCmd.Flags().Bool("short", false, "Print just the version number")
+	versionCmd.Flags().Bool("detailed", false, "Also print supported kbox.yaml versions and cluster capabilities")
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/diagnose"
+	"github.com/bobbyrathoree/kbox/internal/k8s"
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why [app]",
+	Short: "Explain why an app is unhealthy",
+	Long: `Inspect pod states, container exits, probe failures, quota and
+scheduling errors, and explain the most likely root cause with suggested
+kbox.yaml fixes.
+
+Findings are ranked with the most likely cause first.
+
+Examples:
+  kbox why                 # Diagnose the app in kbox.yaml
+  kbox why myapp           # Diagnose a specific app
+  kbox why myapp -o json   # Machine-readable findings`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runWhy,
+}
+
+func runWhy(cmd *cobra.Command, args []string) error {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeContext, _ := cmd.Flags().GetString("context")
+
+	// kbox.yaml is optional, but when present it lets fixes reference the actual config
+	var cfg *config.AppConfig
+	if loaded, err := config.NewLoader(".").Load(); err == nil {
+		cfg = loaded
+	}
+
+	appName := ""
+	if len(args) > 0 {
+		appName = args[0]
+		if cfg != nil && cfg.Metadata.Name != appName {
+			cfg = nil
+		}
+	} else if cfg != nil {
+		appName = cfg.Metadata.Name
+	} else {
+		return fmt.Errorf("no app specified and no kbox.yaml found\n  → Run 'kbox why <app>' or run from a project directory")
+	}
+	if namespace == "" && cfg != nil {
+		namespace = cfg.Metadata.Namespace
+	}
+
+	client, err := k8s.NewClient(k8s.ClientOptions{
+		Context:   kubeContext,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w\n  → Run 'kbox doctor' to diagnose connection issues", err)
+	}
+
+	ns := client.Namespace
+	if namespace != "" {
+		ns = namespace
+	}
+
+	report, err := diagnose.Diagnose(cmd.Context(), client.Clientset, ns, appName, cfg)
+	if err != nil {
+		return err
+	}
+
+	if GetOutputFormat(cmd) == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":  true,
+			"app":      appName,
+			"healthy":  report.Healthy(),
+			"findings": report.Findings,
+		})
+	}
+
+	diagnose.Print(os.Stdout, report)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(whyCmd)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Capabilities kbox add can turn on in kbox.yaml, besides dependencies
+const (
+	CapabilityIngress   = "ingress"
+	CapabilityAutoscale = "autoscale"
+	CapabilityMetrics   = "metrics"
+)
+
+// Capabilities lists what kbox add can add besides dependencies
+var Capabilities = []string{CapabilityIngress, CapabilityAutoscale, CapabilityMetrics}
+
+// AddIngressNode enables spec.ingress in a kbox.yaml document, setting the
+// non-empty fields of ing and keeping the rest of an existing ingress
+func AddIngressNode(root *yaml.Node, ing IngressConfig) {
+	node := EnsureMapNode(ensureSpecNode(root), "ingress")
+	SetMapScalar(node, "enabled", "true", "!!bool")
+	if ing.Host != "" {
+		SetMapScalar(node, "host", ing.Host, "")
+	}
+	if ing.Path != "" {
+		SetMapScalar(node, "path", ing.Path, "")
+	}
+	if ing.IngressClass != "" {
+		SetMapScalar(node, "ingressClass", ing.IngressClass, "")
+	}
+	if ing.TLS != nil && ing.TLS.Enabled {
+		tls := EnsureMapNode(node, "tls")
+		SetMapScalar(tls, "enabled", "true", "!!bool")
+		if ing.TLS.SecretName != "" {
+			SetMapScalar(tls, "secretName", ing.TLS.SecretName, "")
+		}
+	}
+}
+
+// AddAutoscalingNode enables spec.autoscaling in a kbox.yaml document,
+// setting the non-zero fields of as. spec.replicas is removed, since the
+// HPA sets the count; the result reports whether there was one.
+func AddAutoscalingNode(root *yaml.Node, as AutoscalingConfig) (removedReplicas bool) {
+	spec := ensureSpecNode(root)
+	node := EnsureMapNode(spec, "autoscaling")
+	SetMapScalar(node, "enabled", "true", "!!bool")
+	if as.MinReplicas != 0 {
+		SetMapScalar(node, "minReplicas", strconv.Itoa(as.MinReplicas), "!!int")
+	}
+	if as.MaxReplicas != 0 {
+		SetMapScalar(node, "maxReplicas", strconv.Itoa(as.MaxReplicas), "!!int")
+	}
+	if as.TargetCPUUtilization != 0 {
+		SetMapScalar(node, "targetCPUUtilization", strconv.Itoa(as.TargetCPUUtilization), "!!int")
+	}
+	return RemoveMapKey(spec, "replicas")
+}
+
+// AddMetricsNode enables spec.metrics in a kbox.yaml document, setting the
+// non-empty fields of m
+func AddMetricsNode(root *yaml.Node, m MetricsConfig) {
+	node := EnsureMapNode(ensureSpecNode(root), "metrics")
+	SetMapScalar(node, "enabled", "true", "!!bool")
+	if m.Path != "" {
+		SetMapScalar(node, "path", m.Path, "")
+	}
+	if m.Port != "" {
+		SetMapScalar(node, "port", m.Port, "")
+	}
+	if m.Interval != "" {
+		SetMapScalar(node, "interval", m.Interval, "")
+	}
+}
+
+func ensureSpecNode(root *yaml.Node) *yaml.Node {
+	return EnsureMapNode(root, "spec")
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const capabilityDoc = `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: shop
+spec:
+  image: shop:v1
+  replicas: 3 # three is plenty
+  ingress:
+  # Resources below
+  resources:
+    cpu: 100m
+`
+
+func editDoc(t *testing.T, doc string, edit func(root *yaml.Node)) string {
+	t.Helper()
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &node); err != nil {
+		t.Fatal(err)
+	}
+	edit(GetRootDocument(&node))
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestAddIngressNode(t *testing.T) {
+	got := editDoc(t, capabilityDoc, func(root *yaml.Node) {
+		AddIngressNode(root, IngressConfig{Host: "shop.example.com", TLS: &TLSConfig{Enabled: true}})
+	})
+	for _, want := range []string{"  ingress:\n    enabled: true\n    host: shop.example.com\n    tls:\n      enabled: true\n", "# Resources below", "replicas: 3 # three is plenty"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	// Adding again updates the fields given and keeps the rest
+	got = editDoc(t, got, func(root *yaml.Node) {
+		AddIngressNode(root, IngressConfig{Path: "/api"})
+	})
+	if !strings.Contains(got, "host: shop.example.com\n    tls:") || !strings.Contains(got, "path: /api") {
+		t.Errorf("expected the host kept and the path added:\n%s", got)
+	}
+}
+
+func TestAddAutoscalingNode(t *testing.T) {
+	var removed bool
+	got := editDoc(t, capabilityDoc, func(root *yaml.Node) {
+		removed = AddAutoscalingNode(root, AutoscalingConfig{MinReplicas: 2, MaxReplicas: 10})
+	})
+	if !removed || strings.Contains(got, "replicas: 3") {
+		t.Errorf("expected spec.replicas to be removed:\n%s", got)
+	}
+	if !strings.Contains(got, "  autoscaling:\n    enabled: true\n    minReplicas: 2\n    maxReplicas: 10\n") {
+		t.Errorf("expected autoscaling:\n%s", got)
+	}
+
+	cfg := &AppConfig{}
+	if err := yaml.Unmarshal([]byte(got), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if as := cfg.Spec.Autoscaling; as == nil || !as.Enabled || as.MaxReplicas != 10 {
+		t.Errorf("expected the written YAML to parse as autoscaling, got %+v", as)
+	}
+}
+
+func TestAddMetricsNode(t *testing.T) {
+	got := editDoc(t, capabilityDoc, func(root *yaml.Node) {
+		AddMetricsNode(root, MetricsConfig{Path: "/stats", Interval: "15s"})
+	})
+	if !strings.Contains(got, "  metrics:\n    enabled: true\n    path: /stats\n    interval: 15s\n") {
+		t.Errorf("expected metrics:\n%s", got)
+	}
+}
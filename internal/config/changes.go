@@ -0,0 +1,84 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// ServiceChange is whether a set of changed paths affects a service of a
+// MultiApp, and why
+type ServiceChange struct {
+	Service  string
+	Affected bool
+	// Reason is the changed path that affects the service, or why none does
+	Reason string
+}
+
+// ChangedServices maps paths changed in the repo, relative to kbox.yaml's
+// directory, to the services they affect, in dependency order. A path
+// affects a service when it's in the service's build.context, its
+// Dockerfile, its envFromFile or one of its watchPaths. A change to the
+// config itself affects every service.
+func (c *MultiServiceConfig) ChangedServices(changed []string) []ServiceChange {
+	for _, p := range changed {
+		if isConfigPath(p) {
+			var all []ServiceChange
+			for _, name := range c.ServiceOrder() {
+				all = append(all, ServiceChange{Service: name, Affected: true, Reason: path.Clean(p) + " changed"})
+			}
+			return all
+		}
+	}
+
+	var changes []ServiceChange
+	for _, name := range c.ServiceOrder() {
+		change := ServiceChange{Service: name, Reason: "no changes in its paths"}
+		watched := c.Services[name].watchedPaths()
+		if len(watched) == 0 {
+			change.Reason = "image only, no paths to watch"
+		}
+	paths:
+		for _, p := range changed {
+			for _, w := range watched {
+				if underPath(p, w) {
+					change.Affected = true
+					change.Reason = path.Clean(p) + " changed"
+					break paths
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// watchedPaths returns the paths whose changes affect the service
+func (s ServiceSpec) watchedPaths() []string {
+	var paths []string
+	if s.Build != nil {
+		buildCtx := s.Build.Context
+		if buildCtx == "" {
+			buildCtx = "."
+		}
+		paths = append(paths, buildCtx)
+		if s.Build.Dockerfile != "" {
+			paths = append(paths, s.Build.Dockerfile)
+		}
+	}
+	if s.EnvFromFile != "" {
+		paths = append(paths, s.EnvFromFile)
+	}
+	return append(paths, s.WatchPaths...)
+}
+
+// isConfigPath reports whether p is kbox.yaml or one of its fragments
+func isConfigPath(p string) bool {
+	p = path.Clean(p)
+	return p == DefaultConfigFile || p == AlternateConfigFile || underPath(p, FragmentsDir)
+}
+
+// underPath reports whether p is root or inside it; "." holds everything
+func underPath(p, root string) bool {
+	p, root = path.Clean(p), path.Clean(strings.TrimPrefix(root, "./"))
+	return root == "." || p == root || strings.HasPrefix(p, root+"/")
+}
@@ -0,0 +1,47 @@
+package config
+
+import "testing"
+
+func TestChangedServices(t *testing.T) {
+	cfg := &MultiServiceConfig{
+		Metadata: Metadata{Name: "shop"},
+		Services: map[string]ServiceSpec{
+			"api":    {Build: &BuildConfig{Context: "./services/api"}, WatchPaths: []string{"libs/common"}},
+			"web":    {Build: &BuildConfig{Context: "services/web", Dockerfile: "docker/web.Dockerfile"}, DependsOn: []string{"api"}},
+			"worker": {Image: "worker:v1", EnvFromFile: "env/worker.yaml"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		changed  []string
+		affected []string
+	}{
+		{"nothing", nil, nil},
+		{"build context", []string{"services/api/main.go"}, []string{"api"}},
+		{"sibling prefix", []string{"services/apiv2/main.go"}, nil},
+		{"watch path", []string{"libs/common/log.go"}, []string{"api"}},
+		{"dockerfile", []string{"docker/web.Dockerfile"}, []string{"web"}},
+		{"env file", []string{"env/worker.yaml"}, []string{"worker"}},
+		{"config", []string{"README.md", "kbox.yaml"}, []string{"api", "worker", "web"}},
+		{"fragment", []string{"kbox.d/prod.yaml"}, []string{"api", "worker", "web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var affected []string
+			for _, c := range cfg.ChangedServices(tt.changed) {
+				if c.Affected {
+					affected = append(affected, c.Service)
+				}
+			}
+			if len(affected) != len(tt.affected) {
+				t.Fatalf("affected = %v, want %v", affected, tt.affected)
+			}
+			for i := range affected {
+				if affected[i] != tt.affected[i] {
+					t.Fatalf("affected = %v, want %v", affected, tt.affected)
+				}
+			}
+		})
+	}
+}
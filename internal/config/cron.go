@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Time zones for validating timeZone and previewing schedules on
+	// machines without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Schedule is a parsed CronJob schedule: five fields (minute, hour, day of
+// month, month, day of week) or a macro such as @daily, as Kubernetes
+// accepts them
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are set for "*" or "?": a schedule that restricts
+	// both days runs on either, one that restricts one runs on that one
+	domStar, dowStar bool
+	// every is the interval of an "@every <duration>" schedule
+	every time.Duration
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 6, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a CronJob schedule
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, fmt.Errorf("time zones in the schedule are not supported: set timeZone instead")
+	}
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("invalid @every interval in %q", spec)
+		}
+		return &Schedule{every: every}, nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := cronMacros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown macro %q (expected @yearly, @monthly, @weekly, @daily or @hourly)", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		if bits[i], err = parseCronField(field, cronFields[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges, and
+// steps, e.g. "1,15" or "9-17/2", into a bitset
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(term, "/")
+
+		start, end := f.min, f.max
+		if rangePart != "*" && rangePart != "?" {
+			low, high, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = cronValue(low, f); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if end, err = cronValue(high, f); err != nil {
+					return 0, err
+				}
+			case hasStep:
+				// "N/step" means every step from N
+				end = f.max
+			default:
+				end = start
+			}
+			if start > end {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rangePart)
+			}
+		}
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepPart)
+			}
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, f cronField) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %d is out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t the schedule runs, in t's location,
+// or the zero time if it never does (e.g. February 30th)
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Second)
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// NextRuns returns the next n times after t the schedule runs
+func (s *Schedule) NextRuns(t time.Time, n int) []time.Time {
+	var runs []time.Time
+	for len(runs) < n {
+		if t = s.Next(t); t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// validateSchedule checks a schedule and its time zone
+func validateSchedule(field, schedule, timeZone, zoneField string) ValidationErrors {
+	var errs ValidationErrors
+	if _, err := ParseSchedule(schedule); err != nil {
+		errs = append(errs, ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("invalid cron schedule %q: %v (e.g. \"0 20 * * 1-5\")", schedule, err),
+		})
+	}
+	if timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "Local" {
+			errs = append(errs, ValidationError{
+				Field:   zoneField,
+				Message: fmt.Sprintf("unknown time zone %q (expected an IANA name, e.g. \"Europe/Berlin\")", timeZone),
+			})
+		}
+	}
+	return errs
+}
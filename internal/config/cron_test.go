@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	valid := []string{"* * * * *", "0 20 * * 1-5", "*/15 9-17 * * MON-FRI", "0 0 1,15 * *", "30 2 * JAN,jul sun", "@daily", "@every 90m", "5/10 * ? * *"}
+	for _, spec := range valid {
+		if _, err := ParseSchedule(spec); err != nil {
+			t.Errorf("ParseSchedule(%q) = %v, want no error", spec, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"* * * *":           "expected 5 fields",
+		"60 * * * *":        "minute: 60 is out of range 0-59",
+		"0 0 * * 7":         "day of week: 7 is out of range 0-6",
+		"0 17-9 * * *":      "backwards",
+		"*/0 * * * *":       "invalid step",
+		"0 0 * * funday":    "invalid value",
+		"@fortnightly":      "unknown macro",
+		"TZ=UTC 0 0 * * *":  "set timeZone instead",
+		"@every 10 minutes": "invalid @every",
+	}
+	for spec, want := range invalid {
+		_, err := ParseSchedule(spec)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseSchedule(%q) = %v, want error containing %q", spec, err, want)
+		}
+	}
+}
+
+func TestScheduleNextRuns(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// Friday, 2026-03-27 21:30 in Berlin; clocks go forward on the 29th
+	now := time.Date(2026, 3, 27, 21, 30, 0, 0, berlin)
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"0 20 * * 1-5", []string{"2026-03-30 20:00", "2026-03-31 20:00", "2026-04-01 20:00"}},
+		{"*/20 21 * * *", []string{"2026-03-27 21:40", "2026-03-28 21:00", "2026-03-28 21:20"}},
+		// Both days restricted: the 1st of the month or any Sunday
+		{"0 0 1 * sun", []string{"2026-03-29 00:00", "2026-04-01 00:00", "2026-04-05 00:00"}},
+		// 02:30 doesn't exist on the 29th in Berlin, so the CronJob skips it
+		{"30 2 * * *", []string{"2026-03-28 02:30", "2026-03-30 02:30", "2026-03-31 02:30"}},
+		{"@monthly", []string{"2026-04-01 00:00", "2026-05-01 00:00", "2026-06-01 00:00"}},
+		{"0 0 30 2 *", nil},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		var got []string
+		for _, run := range schedule.NextRuns(now, 3) {
+			got = append(got, run.Format("2006-01-02 15:04"))
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%q: NextRuns() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_JobSchedule(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image: "myapp:v1",
+			Jobs: []JobConfig{
+				{Name: "report", Command: []string{"./report"}, Schedule: "0 6 * * 1", TimeZone: "America/New_York"},
+			},
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid job schedule, got: %v", err)
+	}
+
+	cfg.Spec.Jobs = append(cfg.Spec.Jobs,
+		JobConfig{Name: "typo", Command: []string{"./typo"}, Schedule: "* * * *"},
+		JobConfig{Name: "zone", Command: []string{"./zone"}, Schedule: "@hourly", TimeZone: "Mars/Olympus"},
+		JobConfig{Name: "migrate", Command: []string{"./migrate"}, TimeZone: "UTC"},
+	)
+	err := Validate(cfg)
+	for _, want := range []string{"spec.jobs[1].schedule", "spec.jobs[2].timeZone", "spec.jobs[3].timeZone: requires schedule"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Config change actions
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// RedactedValue replaces the values of env vars that look like secrets
+const RedactedValue = "[REDACTED]"
+
+// ConfigChange is one field that differs between two configs
+type ConfigChange struct {
+	// Field is the path of the field, e.g. "spec.env.LOG_LEVEL"
+	Field string `json:"field"`
+
+	// Action is added, removed, or changed
+	Action string `json:"action"`
+
+	// Old and New are the field's values, RedactedValue for secrets
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// Diff returns the fields that differ from old to new, by path: the image,
+// replicas and env vars added or removed, and any other field, down to
+// single list items. The values of env vars that look like secrets (by
+// name or value, as Lint checks) are redacted.
+func Diff(old, new *AppConfig) ([]ConfigChange, error) {
+	before, err := flattenConfig(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flattenConfig(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ConfigChange
+	for field, b := range before {
+		a, ok := after[field]
+		switch {
+		case !ok:
+			changes = append(changes, ConfigChange{Field: field, Action: ChangeRemoved, Old: b.shown()})
+		case a.value != b.value:
+			changes = append(changes, ConfigChange{Field: field, Action: ChangeChanged, Old: b.shown(), New: a.shown()})
+		}
+	}
+	for field, a := range after {
+		if _, ok := before[field]; !ok {
+			changes = append(changes, ConfigChange{Field: field, Action: ChangeAdded, New: a.shown()})
+		}
+	}
+	slices.SortFunc(changes, func(x, y ConfigChange) int {
+		if x.Field < y.Field {
+			return -1
+		}
+		if x.Field > y.Field {
+			return 1
+		}
+		return 0
+	})
+
+	// A secret changed on either side is redacted on both
+	for i, c := range changes {
+		if before[c.Field].secret || after[c.Field].secret {
+			if c.Old != "" {
+				changes[i].Old = RedactedValue
+			}
+			if c.New != "" {
+				changes[i].New = RedactedValue
+			}
+		}
+	}
+	return changes, nil
+}
+
+// leaf is a scalar field of a flattened config
+type leaf struct {
+	value  string
+	secret bool
+}
+
+func (l leaf) shown() string {
+	if l.secret {
+		return RedactedValue
+	}
+	return l.value
+}
+
+// flattenConfig maps the path of each scalar field of cfg to its value, as
+// the config serializes to JSON
+func flattenConfig(cfg *AppConfig) (map[string]leaf, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	leaves := make(map[string]leaf)
+	flatten("", tree, false, leaves)
+	return leaves, nil
+}
+
+func flatten(path string, node interface{}, env bool, leaves map[string]leaf) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if env {
+				s := scalarString(value)
+				leaves[joinPath(path, key)] = leaf{value: s, secret: LooksLikeSecret(key, s)}
+				continue
+			}
+			flatten(joinPath(path, key), value, key == "env", leaves)
+		}
+	case []interface{}:
+		for i, item := range node {
+			flatten(fmt.Sprintf("%s[%d]", path, i), item, false, leaves)
+		}
+	case nil:
+	default:
+		leaves[path] = leaf{value: scalarString(node)}
+	}
+}
+
+// scalarString formats a JSON scalar as it's written in kbox.yaml
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := NewDefaultConfig("myapp")
+	old.Spec.Image = "myapp:v1"
+	old.Spec.Replicas = 2
+	old.Spec.Env = map[string]string{
+		"LOG_LEVEL":   "info",
+		"OLD_FLAG":    "on",
+		"DB_PASSWORD": "hunter2",
+	}
+
+	new := NewDefaultConfig("myapp")
+	new.Spec.Image = "myapp:v2"
+	new.Spec.Replicas = 3
+	new.Spec.Env = map[string]string{
+		"LOG_LEVEL":   "info",
+		"FEATURE_X":   "on",
+		"DB_PASSWORD": "correct-horse",
+		"STRIPE_KEY2": "sk_live_abc",
+	}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ConfigChange{
+		{Field: "spec.env.DB_PASSWORD", Action: ChangeChanged, Old: RedactedValue, New: RedactedValue},
+		{Field: "spec.env.FEATURE_X", Action: ChangeAdded, New: "on"},
+		{Field: "spec.env.OLD_FLAG", Action: ChangeRemoved, Old: "on"},
+		{Field: "spec.env.STRIPE_KEY2", Action: ChangeAdded, New: RedactedValue},
+		{Field: "spec.image", Action: ChangeChanged, Old: "myapp:v1", New: "myapp:v2"},
+		{Field: "spec.replicas", Action: ChangeChanged, Old: "2", New: "3"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff =\n%+v\nwant\n%+v", changes, want)
+	}
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	cfg := NewDefaultConfig("myapp")
+	cfg.Spec.Resources = &ResourceConfig{Memory: "256Mi"}
+	changes, err := Diff(cfg, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiff_Lists(t *testing.T) {
+	old := NewDefaultConfig("myapp")
+	old.Spec.Command = []string{"serve", "--port=8080"}
+	new := NewDefaultConfig("myapp")
+	new.Spec.Command = []string{"serve"}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ConfigChange{{Field: "spec.command[1]", Action: ChangeRemoved, Old: "--port=8080"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff = %+v, want %+v", changes, want)
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FragmentsDir holds config fragments merged into kbox.yaml (e.g. one file
+// per service of a MultiApp), read from the directory of kbox.yaml
+const FragmentsDir = "kbox.d"
+
+// Source is one YAML document of a config split across files
+type Source struct {
+	// Name is the document's file relative to kbox.yaml's directory, with
+	// its position when the file holds several (e.g. "kbox.yaml#2")
+	Name string
+	Tree map[string]interface{}
+}
+
+// ReadConfig reads the config at path: the file itself when it is all there
+// is, or else its documents followed by those of kbox.d/*.yaml, merged. See
+// MergeSources. Configs for an older apiVersion are migrated to the current
+// one; see Migrations.
+func ReadConfig(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := ReadSources(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) <= 1 {
+		return migrateData(data)
+	}
+	merged, err := yaml.Marshal(MergeSources(sources))
+	if err != nil {
+		return nil, err
+	}
+	return migrateData(merged)
+}
+
+// ReadSources returns the documents of the config at path, in merge order:
+// the file's own, then those of each kbox.d/*.yaml (and *.yml) file next to
+// it, by file name
+func ReadSources(path string) ([]Source, error) {
+	sources, err := readDocuments(path, filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(filepath.Dir(path), FragmentsDir)
+	var fragments []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, matches...)
+	}
+	sort.Strings(fragments)
+
+	for _, fragment := range fragments {
+		docs, err := readDocuments(fragment, filepath.Join(FragmentsDir, filepath.Base(fragment)))
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, docs...)
+	}
+	return sources, nil
+}
+
+// readDocuments decodes each non-empty document of a YAML file
+func readDocuments(path, name string) ([]Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]interface{}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	sources := make([]Source, len(docs))
+	for i, doc := range docs {
+		sources[i] = Source{Name: name, Tree: doc}
+		if len(docs) > 1 {
+			sources[i].Name = fmt.Sprintf("%s#%d", name, i+1)
+		}
+	}
+	return sources, nil
+}
+
+// MergeSources merges the documents in order: maps merge key by key, and any
+// other value replaces the one before it. Environments' $strategy keys are
+// left for ForEnvironment.
+func MergeSources(sources []Source) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for _, source := range sources {
+		merged = mergeTrees(merged, source.Tree)
+	}
+	return merged
+}
+
+func mergeTrees(base, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range overlay {
+		baseMap, ok := result[k].(map[string]interface{})
+		overlayMap, isMap := v.(map[string]interface{})
+		if ok && isMap {
+			result[k] = mergeTrees(baseMap, overlayMap)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// MergedNode returns the merged config at path with each key annotated with
+// the file it comes from, where that differs from its parent's
+func MergedNode(path string) (*yaml.Node, error) {
+	sources, err := ReadSources(path)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := node.Encode(MergeSources(sources)); err != nil {
+		return nil, err
+	}
+	annotateSources(&node, nil, sources, "")
+	return &node, nil
+}
+
+// annotateSources comments each key of a mapping node with the last source
+// setting everything under it, unless that is the parent's (already
+// annotated) source; keys whose values come from several sources are left
+// for their children
+func annotateSources(node *yaml.Node, path []string, sources []Source, parent string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(append([]string(nil), path...), key.Value)
+		source := sourceOf(keyPath, value, sources)
+		if source != "" && source != parent {
+			key.LineComment = "# " + source
+		}
+		if source == "" {
+			annotateSources(value, keyPath, sources, parent)
+		} else {
+			annotateSources(value, keyPath, sources, source)
+		}
+	}
+}
+
+// sourceOf returns the source every leaf under path was last set by, or ""
+// if they were set by several
+func sourceOf(path []string, value *yaml.Node, sources []Source) string {
+	if value.Kind != yaml.MappingNode {
+		return lastSource(path, sources)
+	}
+	source := ""
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		child := sourceOf(append(append([]string(nil), path...), value.Content[i].Value), value.Content[i+1], sources)
+		if child == "" || (source != "" && child != source) {
+			return ""
+		}
+		source = child
+	}
+	if source == "" {
+		return lastSource(path, sources)
+	}
+	return source
+}
+
+// lastSource returns the last source that sets path
+func lastSource(path []string, sources []Source) string {
+	for i := len(sources) - 1; i >= 0; i-- {
+		if hasPath(sources[i].Tree, path) {
+			return sources[i].Name
+		}
+	}
+	return ""
+}
+
+func hasPath(tree map[string]interface{}, path []string) bool {
+	var v interface{} = tree
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if v, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFile_Fragments(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"kbox.yaml": `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: myapp
+spec:
+  image: myapp:v1
+  env:
+    LOG_LEVEL: info
+---
+spec:
+  replicas: 2
+`,
+		"kbox.d/10-db.yaml": `spec:
+  dependencies:
+    - type: postgres
+  env:
+    POOL_SIZE: "10"
+`,
+		"kbox.d/20-prod.yml": `environments:
+  prod:
+    replicas: 5
+`,
+		"kbox.d/notes.txt": "not config",
+	})
+
+	cfg, err := NewLoader(dir).Load()
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if cfg.Spec.Replicas != 2 {
+		t.Errorf("expected replicas 2 from the second document, got %d", cfg.Spec.Replicas)
+	}
+	if cfg.Spec.Env["LOG_LEVEL"] != "info" || cfg.Spec.Env["POOL_SIZE"] != "10" {
+		t.Errorf("expected env to merge, got %v", cfg.Spec.Env)
+	}
+	if len(cfg.Spec.Dependencies) != 1 || cfg.Spec.Dependencies[0].Type != "postgres" {
+		t.Errorf("expected postgres from kbox.d, got %v", cfg.Spec.Dependencies)
+	}
+	if prod := cfg.ForEnvironment("prod"); prod.Spec.Replicas != 5 {
+		t.Errorf("expected prod replicas 5, got %d", prod.Spec.Replicas)
+	}
+}
+
+func TestReadSources_Order(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"kbox.yaml":        "kind: MultiApp\n---\nmetadata:\n  name: a\n",
+		"kbox.d/b.yaml":    "metadata:\n  name: b\n",
+		"kbox.d/a.yaml":    "metadata:\n  name: c\n---\n",
+		"kbox.d/empty.yml": "",
+	})
+
+	sources, err := ReadSources(filepath.Join(dir, "kbox.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	want := "kbox.yaml#1,kbox.yaml#2,kbox.d/a.yaml,kbox.d/b.yaml"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("expected sources %s, got %s", want, got)
+	}
+
+	merged := MergeSources(sources)
+	if name := merged["metadata"].(map[string]interface{})["name"]; name != "b" {
+		t.Errorf("expected the last file to win, got name %v", name)
+	}
+
+	multi, err := IsMultiService(filepath.Join(dir, "kbox.yaml"))
+	if err != nil || !multi {
+		t.Errorf("expected a multi-service config, got %v (err: %v)", multi, err)
+	}
+}
+
+func TestReadConfig_SingleFileUnchanged(t *testing.T) {
+	content := "# comment\nkind: App\nmetadata:\n  name: myapp\n"
+	dir := writeConfigFiles(t, map[string]string{"kbox.yaml": content})
+
+	data, err := ReadConfig(filepath.Join(dir, "kbox.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("expected the file as is, got %q", data)
+	}
+}
+
+func TestReadConfig_FragmentError(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"kbox.yaml":       "kind: App\n",
+		"kbox.d/bad.yaml": "spec: [unclosed\n",
+	})
+
+	_, err := ReadConfig(filepath.Join(dir, "kbox.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "kbox.d/bad.yaml") {
+		t.Errorf("expected an error naming the fragment, got %v", err)
+	}
+}
+
+func TestMergedNode_Annotations(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"kbox.yaml": `kind: MultiApp
+metadata:
+  name: shop
+services:
+  api:
+    image: api:v1
+`,
+		"kbox.d/api.yaml": `services:
+  api:
+    replicas: 2
+`,
+		"kbox.d/worker.yaml": `services:
+  worker:
+    image: worker:v1
+`,
+	})
+
+	node, err := MergedNode(filepath.Join(dir, "kbox.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+
+	for _, want := range []string{
+		"kind: MultiApp # kbox.yaml",
+		"metadata: # kbox.yaml",
+		"image: api:v1 # kbox.yaml",
+		"replicas: 2 # kbox.d/api.yaml",
+		"worker: # kbox.d/worker.yaml",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "services: #") || strings.Contains(got, "name: shop #") {
+		t.Errorf("expected only keys from a different file than their parent to be annotated:\n%s", got)
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LintIssue is a best-practice problem in a config that is nonetheless valid
+type LintIssue struct {
+	// Rule identifies the check, e.g. "missing-resources"
+	Rule string `json:"rule"`
+
+	// Field is the path of the offending field, e.g. "spec.resources"
+	Field string `json:"field"`
+
+	// Message says what is wrong and how to fix it
+	Message string `json:"message"`
+
+	// Fixable is set when ApplyLintFixes can fix the issue without changing
+	// what gets deployed
+	Fixable bool `json:"fixable"`
+
+	fix func(root *yaml.Node)
+}
+
+var (
+	secretKeyPattern   = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|CREDENTIALS?)`)
+	secretValuePattern = regexp.MustCompile(`^(sk_live_|sk_test_|rk_live_|ghp_|gho_|github_pat_|glpat-|xox[abps]-|AKIA[0-9A-Z]{16}|-----BEGIN )`)
+)
+
+// Lint checks a config for best-practice issues that Validate lets through:
+// workloads without resources or a health check, unpinned images, a single
+// replica without a PodDisruptionBudget, replicas the HPA overrides, and env
+// values that look like secrets
+func Lint(config *AppConfig) []LintIssue {
+	var issues []LintIssue
+	spec := &config.Spec
+
+	if spec.Resources == nil {
+		issues = append(issues, LintIssue{
+			Rule:    "missing-resources",
+			Field:   "spec.resources",
+			Message: "no resource requests: kbox's defaults (128Mi memory, 100m CPU) may not fit the app; set them explicitly",
+			Fixable: true,
+			fix:     fixMissingResources,
+		})
+	}
+
+	if spec.HealthCheck == "" {
+		issues = append(issues, LintIssue{
+			Rule:    "missing-health-check",
+			Field:   "spec.healthCheck",
+			Message: "no health check: pods get traffic before the app is ready, and hung pods are never restarted; set an HTTP path such as /healthz",
+		})
+	}
+
+	if image := spec.Image; image != "" && !strings.Contains(image, "@") {
+		if strings.HasSuffix(image, ":latest") || !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+			issues = append(issues, LintIssue{
+				Rule:    "latest-tag",
+				Field:   "spec.image",
+				Message: fmt.Sprintf("image %q is not pinned: each node may run a different :latest; use a version tag or digest", image),
+			})
+		}
+	}
+
+	if spec.Replicas == 1 && spec.PDB == nil && (spec.Autoscaling == nil || !spec.Autoscaling.Enabled) {
+		issues = append(issues, LintIssue{
+			Rule:    "single-replica",
+			Field:   "spec.replicas",
+			Message: "a single replica without a PodDisruptionBudget goes down on every node drain; set replicas: 2 or more (kbox then adds a PDB)",
+		})
+	}
+
+	if spec.Replicas > 1 && spec.Autoscaling != nil && spec.Autoscaling.Enabled {
+		issues = append(issues, LintIssue{
+			Rule:    "replicas-with-autoscaling",
+			Field:   "spec.replicas",
+			Message: "replicas is ignored while autoscaling is enabled: the HPA sets the count between autoscaling.minReplicas and maxReplicas; remove it",
+			Fixable: true,
+			fix:     fixReplicasWithAutoscaling,
+		})
+	}
+
+	issues = append(issues, secretEnvIssues("spec.env", spec.Env)...)
+	envNames := make([]string, 0, len(config.Environments))
+	for name := range config.Environments {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		issues = append(issues, secretEnvIssues(fmt.Sprintf("environments.%s.env", name), config.Environments[name].Env)...)
+	}
+
+	return issues
+}
+
+// secretEnvIssues flags env vars whose name or value looks like a secret
+func secretEnvIssues(field string, env map[string]string) []LintIssue {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var issues []LintIssue
+	for _, k := range keys {
+		v := env[k]
+		if v == "" || strings.HasPrefix(v, "${") {
+			continue
+		}
+		if LooksLikeSecret(k, v) {
+			issues = append(issues, LintIssue{
+				Rule:    "secret-in-env",
+				Field:   field + "." + k,
+				Message: "looks like a secret: env values are stored in plain text in kbox.yaml and the Deployment; move it to spec.secrets (fromEnvFile or fromSops)",
+			})
+		}
+	}
+	return issues
+}
+
+// LooksLikeSecret reports whether an env var's name or value looks like a
+// secret's
+func LooksLikeSecret(name, value string) bool {
+	return secretKeyPattern.MatchString(name) || secretValuePattern.MatchString(value)
+}
+
+// ApplyLintFixes applies the fixable issues' fixes to a kbox.yaml document,
+// leaving its comments and the rest of it as they are, and returns how many
+// it applied
+func ApplyLintFixes(doc *yaml.Node, issues []LintIssue) int {
+	root := GetRootDocument(doc)
+	fixed := 0
+	for _, issue := range issues {
+		if issue.fix != nil {
+			issue.fix(root)
+			fixed++
+		}
+	}
+	return fixed
+}
+
+// fixMissingResources writes out the resources kbox uses when none are set,
+// so they are visible and easy to tune
+func fixMissingResources(root *yaml.Node) {
+	spec := FindMapKey(root, "spec")
+	if spec == nil {
+		spec = &yaml.Node{Kind: yaml.MappingNode}
+		AddMapKey(root, "spec", spec)
+	}
+	resources := &yaml.Node{Kind: yaml.MappingNode}
+	AddMapKey(resources, "memory", &yaml.Node{Kind: yaml.ScalarNode, Value: "128Mi"})
+	AddMapKey(resources, "cpu", &yaml.Node{Kind: yaml.ScalarNode, Value: "100m"})
+
+	// Replace an empty "resources:" rather than adding a second one
+	for i := 0; i+1 < len(spec.Content); i += 2 {
+		if spec.Content[i].Value == "resources" {
+			spec.Content[i+1] = resources
+			spec.Content[i].LineComment = lintFixComment
+			return
+		}
+	}
+	AddMapKey(spec, "resources", resources)
+	spec.Content[len(spec.Content)-2].LineComment = lintFixComment
+}
+
+// fixReplicasWithAutoscaling removes spec.replicas, which an enabled HPA
+// makes meaningless
+func fixReplicasWithAutoscaling(root *yaml.Node) {
+	spec := FindMapKey(root, "spec")
+	if spec == nil {
+		return
+	}
+	for i := 0; i+1 < len(spec.Content); i += 2 {
+		if spec.Content[i].Value == "replicas" {
+			spec.Content = append(spec.Content[:i], spec.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+const lintFixComment = "# kbox's defaults; limits are twice the requests"
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lintRules(issues []LintIssue) string {
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule+"@"+issue.Field)
+	}
+	return strings.Join(rules, ",")
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		spec AppSpec
+		envs map[string]EnvOverride
+		want string
+	}{
+		{
+			name: "clean",
+			spec: AppSpec{
+				Image:       "myapp:1.2.3",
+				Replicas:    2,
+				HealthCheck: "/healthz",
+				Resources:   &ResourceConfig{Memory: "256Mi", CPU: "100m"},
+				Env:         map[string]string{"LOG_LEVEL": "info", "DB_PASSWORD": "${DB_PASSWORD}"},
+			},
+		},
+		{
+			name: "everything missing",
+			spec: AppSpec{Image: "registry:5000/myapp", Replicas: 1},
+			want: "missing-resources@spec.resources,missing-health-check@spec.healthCheck,latest-tag@spec.image,single-replica@spec.replicas",
+		},
+		{
+			name: "autoscaled single replica",
+			spec: AppSpec{
+				Image:       "myapp@sha256:abc",
+				Replicas:    1,
+				HealthCheck: "/healthz",
+				Resources:   &ResourceConfig{CPU: "100m"},
+				Autoscaling: &AutoscalingConfig{Enabled: true, MaxReplicas: 5},
+			},
+		},
+		{
+			name: "replicas with autoscaling",
+			spec: AppSpec{
+				Image:       "myapp:1.2.3",
+				Replicas:    3,
+				HealthCheck: "/healthz",
+				Resources:   &ResourceConfig{CPU: "100m"},
+				Autoscaling: &AutoscalingConfig{Enabled: true, MaxReplicas: 5},
+			},
+			want: "replicas-with-autoscaling@spec.replicas",
+		},
+		{
+			name: "secrets in env",
+			spec: AppSpec{
+				Image:       "myapp:latest",
+				Replicas:    2,
+				HealthCheck: "/healthz",
+				Resources:   &ResourceConfig{CPU: "100m"},
+				Env:         map[string]string{"API_KEY": "abc123", "STRIPE": "sk_live_123", "EMPTY_TOKEN": ""},
+			},
+			envs: map[string]EnvOverride{
+				"prod": {Env: map[string]string{"GITHUB": "ghp_abcdef"}},
+			},
+			want: "latest-tag@spec.image,secret-in-env@spec.env.API_KEY,secret-in-env@spec.env.STRIPE,secret-in-env@environments.prod.env.GITHUB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AppConfig{Metadata: Metadata{Name: "myapp"}, Spec: tt.spec, Environments: tt.envs}
+			if got := lintRules(Lint(cfg)); got != tt.want {
+				t.Errorf("expected issues %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyLintFixes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kbox.yaml")
+	content := `# My app
+apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: myapp
+spec:
+  image: myapp:v1 # pinned
+  resources:
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoader(dir).LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := LoadYAMLWithComments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed := ApplyLintFixes(node, Lint(cfg)); fixed != 1 {
+		t.Errorf("expected 1 fix, got %d", fixed)
+	}
+	if err := SaveYAMLWithComments(path, node); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# My app", "image: myapp:v1 # pinned", "  resources: # kbox's defaults", "    memory: 128Mi"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+	if strings.Count(string(data), "resources:") != 1 {
+		t.Errorf("expected the empty resources key to be replaced:\n%s", data)
+	}
+
+	cfg, err = NewLoader(dir).LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(lintRules(Lint(cfg)), "missing-resources") {
+		t.Error("expected missing-resources to be fixed")
+	}
+}
+
+func TestApplyLintFixes_ReplicasWithAutoscaling(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kbox.yaml")
+	content := `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: myapp
+spec:
+  image: myapp:v1
+  replicas: 3
+  resources:
+    cpu: 100m
+  autoscaling:
+    enabled: true
+    maxReplicas: 5
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := NewLoader(dir).LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := LoadYAMLWithComments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed := ApplyLintFixes(node, Lint(cfg)); fixed != 1 {
+		t.Errorf("expected 1 fix, got %d", fixed)
+	}
+	if err := SaveYAMLWithComments(path, node); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "replicas: 3") || !strings.Contains(string(data), "maxReplicas: 5") {
+		t.Errorf("expected only spec.replicas removed:\n%s", data)
+	}
+}
@@ -16,6 +16,28 @@ const (
 	AlternateConfigFile = "kbox.yml"
 )
 
+// LoadError is a config file that's missing, unreadable or invalid
+type LoadError struct {
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidConfig marks the error as a config error for the CLI's exit codes
+func (e *LoadError) InvalidConfig() bool {
+	return true
+}
+
+func loadError(err error) error {
+	return &LoadError{Err: err}
+}
+
 // Loader handles loading and parsing kbox configuration
 type Loader struct {
 	workDir string
@@ -33,21 +55,22 @@ func NewLoader(workDir string) *Loader {
 func (l *Loader) Load() (*AppConfig, error) {
 	path, err := l.FindConfigFile()
 	if err != nil {
-		return nil, err
+		return nil, loadError(err)
 	}
 	return l.LoadFile(path)
 }
 
-// LoadFile loads config from a specific path
+// LoadFile loads config from a specific path, merged with any kbox.d fragments
+// next to it
 func (l *Loader) LoadFile(path string) (*AppConfig, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfig(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, loadError(fmt.Errorf("failed to read config file: %w", err))
 	}
 
 	var config AppConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w\n  → Check YAML syntax at https://yaml.org/spec/", err)
+		return nil, loadError(fmt.Errorf("failed to parse config file: %w\n  → Check YAML syntax at https://yaml.org/spec/", err))
 	}
 
 	// Apply defaults
@@ -55,7 +78,7 @@ func (l *Loader) LoadFile(path string) (*AppConfig, error) {
 
 	// Validate
 	if err := Validate(&config); err != nil {
-		return nil, err
+		return nil, loadError(err)
 	}
 
 	return &config, nil
@@ -105,7 +128,7 @@ func (l *Loader) IsMultiService() (bool, error) {
 func (l *Loader) LoadMultiService() (*MultiServiceConfig, error) {
 	path, err := l.FindConfigFile()
 	if err != nil {
-		return nil, err
+		return nil, loadError(err)
 	}
 	return l.LoadMultiServiceFile(path)
 }
@@ -114,12 +137,12 @@ func (l *Loader) LoadMultiService() (*MultiServiceConfig, error) {
 func (l *Loader) LoadMultiServiceFile(path string) (*MultiServiceConfig, error) {
 	cfg, err := LoadMultiService(path)
 	if err != nil {
-		return nil, err
+		return nil, loadError(err)
 	}
 
 	// Validate
 	if err := cfg.Validate(); err != nil {
-		return nil, err
+		return nil, loadError(err)
 	}
 
 	return cfg, nil
@@ -253,3 +253,39 @@ CMD ["node", "dist/index.js"]`,
 		})
 	}
 }
+
+func TestLoader_MeshShorthand(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: testapp
+spec:
+  image: testapp:v1
+  mesh: istio
+environments:
+  dev:
+    mesh: none
+  prod:
+    mesh:
+      excludeDependencies: true
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "kbox.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewLoader(tmpDir).Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if config.Spec.MeshProvider() != MeshIstio {
+		t.Errorf("expected mesh istio, got %+v", config.Spec.Mesh)
+	}
+	if got := config.ForEnvironment("dev").Spec.MeshProvider(); got != MeshNone {
+		t.Errorf("expected dev mesh none, got %q", got)
+	}
+	prod := config.ForEnvironment("prod").Spec.Mesh
+	if prod.Provider != MeshIstio || !prod.ExcludeDependencies {
+		t.Errorf("expected prod to merge into the istio mesh, got %+v", prod)
+	}
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StrategyKey chooses how an environment's map or list combines with the
+// base config's. In a map it is a key, `$strategy: replace`; in a list it is
+// the first item, `- $strategy: append`.
+const StrategyKey = "$strategy"
+
+// Merge strategies
+const (
+	StrategyMerge   = "merge"   // Maps (default): merge key by key
+	StrategyReplace = "replace" // Lists (default), or maps: take the environment's as is
+	StrategyAppend  = "append"  // Lists: the base's items, then the environment's
+)
+
+// envKeys are the keys of an environment that bind it to a cluster rather
+// than override the spec
+var envKeys = []string{"context", "namespace", "protected"}
+
+// mergeOverlay merges an environment overlay into base, a spec struct, and
+// decodes the result into out. Maps merge key by key, scalars (and null)
+// override, and lists replace unless they ask to be appended. The result
+// shares nothing with base. When strict, keys out doesn't have are an
+// error rather than ignored, as --set and --values want for mistyped paths.
+func mergeOverlay(base interface{}, overlay map[string]interface{}, out interface{}, strict bool) error {
+	data, err := yaml.Marshal(base)
+	if err != nil {
+		return err
+	}
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+
+	merged, err := deepMerge("", tree, overlay)
+	if err != nil {
+		return err
+	}
+	if data, err = yaml.Marshal(merged); err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict)
+	if err := decoder.Decode(out); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			// The line numbers are of the merged YAML, which no one sees
+			for i, e := range typeErr.Errors {
+				typeErr.Errors[i] = lineNumber.ReplaceAllString(e, "")
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+var lineNumber = regexp.MustCompile(`^line \d+: `)
+
+// deepMerge returns overlay merged into base, without modifying either
+func deepMerge(path string, base, overlay interface{}) (interface{}, error) {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		strategy := StrategyMerge
+		if s, ok := overlay[StrategyKey]; ok {
+			strategy, _ = s.(string)
+			if strategy != StrategyMerge && strategy != StrategyReplace {
+				return nil, fmt.Errorf("%s: invalid %s %v for a map (must be %s or %s)", pathOf(path), StrategyKey, s, StrategyMerge, StrategyReplace)
+			}
+		}
+		baseMap, ok := base.(map[string]interface{})
+		if !ok || strategy == StrategyReplace {
+			baseMap = nil
+		}
+
+		result := make(map[string]interface{}, len(baseMap)+len(overlay))
+		for k, v := range baseMap {
+			result[k] = v
+		}
+		for k, v := range overlay {
+			if k == StrategyKey {
+				continue
+			}
+			merged, err := deepMerge(joinPath(path, k), baseMap[k], v)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = merged
+		}
+		return result, nil
+
+	case []interface{}:
+		strategy := StrategyReplace
+		items := overlay
+		if len(items) > 0 {
+			if marker, ok := items[0].(map[string]interface{}); ok && len(marker) == 1 && marker[StrategyKey] != nil {
+				strategy, _ = marker[StrategyKey].(string)
+				if strategy != StrategyReplace && strategy != StrategyAppend {
+					return nil, fmt.Errorf("%s: invalid %s %v for a list (must be %s or %s)", pathOf(path), StrategyKey, marker[StrategyKey], StrategyReplace, StrategyAppend)
+				}
+				items = items[1:]
+			}
+		}
+
+		var result []interface{}
+		if baseList, ok := base.([]interface{}); ok && strategy == StrategyAppend {
+			result = append(result, baseList...)
+		}
+		for i, item := range items {
+			// Items are taken as is, but may hold strategy keys to strip
+			merged, err := deepMerge(fmt.Sprintf("%s[%d]", pathOf(path), i), nil, item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, merged)
+		}
+		return result, nil
+
+	default:
+		return overlay, nil
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathOf(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// UnmarshalJSON decodes the environment's overrides, and keeps them as
+// written as its Overlay, strategy keys included (kbox.yaml is decoded as
+// JSON)
+func (e *EnvOverride) UnmarshalJSON(data []byte) error {
+	type plain EnvOverride
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &e.Overlay); err != nil {
+		return err
+	}
+	for _, key := range envKeys {
+		delete(e.Overlay, key)
+	}
+	return nil
+}
+
+// UnmarshalYAML is UnmarshalJSON for configs decoded as YAML
+func (e *EnvOverride) UnmarshalYAML(node *yaml.Node) error {
+	type plain EnvOverride
+	if err := node.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	if err := node.Decode(&e.Overlay); err != nil {
+		return err
+	}
+	for _, key := range envKeys {
+		delete(e.Overlay, key)
+	}
+	return nil
+}
+
+// specOverlay returns the overlay the environment applies to the spec: its
+// Overlay as written in kbox.yaml, or else its fields
+func (e EnvOverride) specOverlay() (map[string]interface{}, error) {
+	if e.Overlay != nil {
+		return e.Overlay, nil
+	}
+	type plain EnvOverride
+	overlay, err := toOverlay(plain(e))
+	for _, key := range envKeys {
+		delete(overlay, key)
+	}
+	return overlay, err
+}
+
+// UnmarshalJSON decodes the service's overrides, and keeps them as written
+// as its Overlay
+func (o *ServiceEnvOverride) UnmarshalJSON(data []byte) error {
+	type plain ServiceEnvOverride
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &o.Overlay)
+}
+
+// specOverlay returns the overlay the environment applies to the service
+func (o ServiceEnvOverride) specOverlay() (map[string]interface{}, error) {
+	if o.Overlay != nil {
+		return o.Overlay, nil
+	}
+	type plain ServiceEnvOverride
+	return toOverlay(plain(o))
+}
+
+// toOverlay converts overrides set in code into an overlay
+func toOverlay(v interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	overlay := map[string]interface{}{}
+	return overlay, yaml.Unmarshal(data, &overlay)
+}
+
+// OverlayFields lists the spec fields the environment overrides, as dotted
+// paths such as "spec.env.LOG_LEVEL". Lists are listed whole.
+func (c *AppConfig) OverlayFields(env string) []string {
+	override, ok := c.Environments[env]
+	if env == "" || !ok {
+		return nil
+	}
+	overlay, err := override.specOverlay()
+	if err != nil {
+		return nil
+	}
+	return overlayPaths("spec", overlay)
+}
+
+// OverlayFields lists the service fields the environment overrides, as
+// dotted paths such as "services.api.replicas"
+func (c *MultiServiceConfig) OverlayFields(env string) []string {
+	override, ok := c.Environments[env]
+	if env == "" || !ok {
+		return nil
+	}
+	var paths []string
+	for name, svcOverride := range override.Services {
+		if _, ok := c.Services[name]; !ok {
+			continue
+		}
+		overlay, err := svcOverride.specOverlay()
+		if err != nil {
+			continue
+		}
+		paths = append(paths, overlayPaths("services."+name, overlay)...)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// overlayPaths returns the paths of the overlay's leaves under prefix, sorted
+func overlayPaths(prefix string, overlay map[string]interface{}) []string {
+	var paths []string
+	for key, value := range overlay {
+		if key == StrategyKey {
+			continue
+		}
+		path := prefix + "." + key
+		if m, ok := value.(map[string]interface{}); ok {
+			if leaves := overlayPaths(path, m); len(leaves) > 0 {
+				paths = append(paths, leaves...)
+				continue
+			}
+		}
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestForEnvironment_Golden merges the prod environment of each
+// testdata/environments/*.yaml into its spec, and compares the result with
+// the .golden file next to it. Run with -update to rewrite them.
+func TestForEnvironment_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "environments", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			cfg, err := NewLoader(".").LoadFile(path)
+			if err != nil {
+				t.Fatalf("failed to load: %v", err)
+			}
+			merged := cfg.ForEnvironment("prod")
+
+			got, err := yaml.Marshal(struct {
+				Metadata Metadata `yaml:"metadata"`
+				Spec     AppSpec  `yaml:"spec"`
+			}{merged.Metadata, merged.Spec})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(path, ".yaml") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("merged config differs from %s:\n--- got\n%s\n--- want\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestForEnvironment_NoAliasing(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image:     "myapp:v1",
+			Env:       map[string]string{"LOG_LEVEL": "info"},
+			Resources: &ResourceConfig{Memory: "256Mi", CPU: "100m"},
+			Command:   []string{"./server"},
+		},
+		Environments: map[string]EnvOverride{
+			"prod": {
+				Env:       map[string]string{"LOG_LEVEL": "warn"},
+				Resources: &ResourceConfig{CPU: "1"},
+			},
+		},
+	}
+
+	prod := cfg.ForEnvironment("prod")
+	prod.Spec.Env["EXTRA"] = "1"
+	prod.Spec.Command[0] = "./other"
+
+	if cfg.Spec.Env["LOG_LEVEL"] != "info" || cfg.Spec.Env["EXTRA"] != "" {
+		t.Errorf("base env was modified: %v", cfg.Spec.Env)
+	}
+	if cfg.Spec.Command[0] != "./server" {
+		t.Errorf("base command was modified: %v", cfg.Spec.Command)
+	}
+	if *cfg.Spec.Resources != (ResourceConfig{Memory: "256Mi", CPU: "100m"}) {
+		t.Errorf("base resources were modified: %+v", cfg.Spec.Resources)
+	}
+	if *prod.Spec.Resources != (ResourceConfig{Memory: "256Mi", CPU: "1"}) {
+		t.Errorf("expected resources to merge, got %+v", prod.Spec.Resources)
+	}
+
+	// Merging again gives the same result
+	if again := cfg.ForEnvironment("prod"); again.Spec.Env["EXTRA"] != "" {
+		t.Errorf("expected a fresh merge, got env %v", again.Spec.Env)
+	}
+}
+
+func TestDeepMerge_Strategies(t *testing.T) {
+	base := map[string]interface{}{
+		"list": []interface{}{"a", "b"},
+		"map":  map[string]interface{}{"x": 1, "w": 2},
+	}
+
+	tests := []struct {
+		name    string
+		overlay map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "list replaces by default",
+			overlay: map[string]interface{}{"list": []interface{}{"c"}},
+			want:    "list:\n    - c\nmap:\n    w: 2\n    x: 1\n",
+		},
+		{
+			name:    "list append",
+			overlay: map[string]interface{}{"list": []interface{}{map[string]interface{}{StrategyKey: "append"}, "c"}},
+			want:    "list:\n    - a\n    - b\n    - c\nmap:\n    w: 2\n    x: 1\n",
+		},
+		{
+			name:    "map merges by default",
+			overlay: map[string]interface{}{"map": map[string]interface{}{"w": 3, "z": 4}},
+			want:    "list:\n    - a\n    - b\nmap:\n    w: 3\n    x: 1\n    z: 4\n",
+		},
+		{
+			name:    "map replace",
+			overlay: map[string]interface{}{"map": map[string]interface{}{StrategyKey: "replace", "z": 4}},
+			want:    "list:\n    - a\n    - b\nmap:\n    z: 4\n",
+		},
+		{
+			name:    "null clears",
+			overlay: map[string]interface{}{"map": nil},
+			want:    "list:\n    - a\n    - b\nmap: null\n",
+		},
+		{
+			name:    "invalid list strategy",
+			overlay: map[string]interface{}{"list": []interface{}{map[string]interface{}{StrategyKey: "merge"}}},
+			wantErr: "list: invalid $strategy merge for a list",
+		},
+		{
+			name:    "invalid map strategy",
+			overlay: map[string]interface{}{"map": map[string]interface{}{StrategyKey: "append"}},
+			wantErr: "map: invalid $strategy append for a map",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := deepMerge("", base, tt.overlay)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := yaml.Marshal(merged)
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if len(base["list"].([]interface{})) != 2 || len(base["map"].(map[string]interface{})) != 2 {
+		t.Errorf("base was modified: %v", base)
+	}
+}
+
+func TestValidate_EnvironmentStrategy(t *testing.T) {
+	var cfg AppConfig
+	err := yaml.Unmarshal([]byte(`
+metadata:
+  name: myapp
+spec:
+  image: myapp:v1
+environments:
+  prod:
+    command:
+      - $strategy: prepend
+      - ./server
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.WithDefaults()
+
+	err = Validate(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "environments.prod: command: invalid $strategy prepend") {
+		t.Errorf("expected invalid strategy error, got %v", err)
+	}
+}
+
+func TestMultiServiceForEnvironment_DeepMerge(t *testing.T) {
+	cfg, err := ParseMultiService([]byte(`
+apiVersion: kbox.dev/v1
+kind: MultiApp
+metadata:
+  name: shop
+services:
+  api:
+    image: api:v1
+    port: 8080
+    env:
+      LOG_LEVEL: info
+    resources:
+      memory: 256Mi
+      cpu: 100m
+    dependsOn: [worker]
+  worker:
+    image: worker:v1
+environments:
+  prod:
+    services:
+      api:
+        replicas: 4
+        env:
+          SENTRY: "true"
+        resources:
+          cpu: 500m
+        dependsOn:
+          - $strategy: append
+          - cache
+  staging:
+    services:
+      api:
+        command:
+          - $strategy: prepend
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod := cfg.ForEnvironment("prod")
+	api := prod.Services["api"]
+	if api.Replicas != 4 {
+		t.Errorf("expected 4 replicas, got %d", api.Replicas)
+	}
+	if api.Env["LOG_LEVEL"] != "info" || api.Env["SENTRY"] != "true" {
+		t.Errorf("expected env to merge, got %v", api.Env)
+	}
+	if *api.Resources != (ResourceConfig{Memory: "256Mi", CPU: "500m"}) {
+		t.Errorf("expected resources to merge, got %+v", api.Resources)
+	}
+	if strings.Join(api.DependsOn, ",") != "worker,cache" {
+		t.Errorf("expected dependsOn to append, got %v", api.DependsOn)
+	}
+	if _, ok := cfg.Services["api"].Env["SENTRY"]; ok {
+		t.Error("base env was modified")
+	}
+
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "environments.staging: services.api: command: invalid $strategy prepend") {
+		t.Errorf("expected invalid strategy error, got %v", err)
+	}
+}
+
+func TestOverlayFields(t *testing.T) {
+	cfg := &AppConfig{
+		Environments: map[string]EnvOverride{
+			"prod": {Overlay: map[string]interface{}{
+				"replicas": 3,
+				"env":      map[string]interface{}{"LOG": "warn", "DEBUG": "0"},
+				"ingress":  map[string]interface{}{StrategyKey: StrategyReplace},
+				"jobs":     []interface{}{},
+			}},
+		},
+	}
+	got := strings.Join(cfg.OverlayFields("prod"), ",")
+	want := "spec.env.DEBUG,spec.env.LOG,spec.ingress,spec.jobs,spec.replicas"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if fields := cfg.OverlayFields("staging"); fields != nil {
+		t.Errorf("expected no fields for an unknown environment, got %v", fields)
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Service meshes
+const (
+	MeshIstio   = "istio"
+	MeshLinkerd = "linkerd"
+	MeshNone    = "none"
+)
+
+// MeshConfig sets up the app's pods for a service mesh. It may be written
+// as just the provider, `mesh: istio`.
+type MeshConfig struct {
+	// Provider is istio, linkerd, or none
+	Provider string `yaml:"provider" json:"provider"`
+
+	// ExcludeDependencies keeps the sidecar out of dependency StatefulSets,
+	// e.g. for databases whose protocols the mesh doesn't handle well
+	ExcludeDependencies bool `yaml:"excludeDependencies,omitempty" json:"excludeDependencies,omitempty"`
+}
+
+// UnmarshalJSON accepts the provider alone, or the full config
+func (m *MeshConfig) UnmarshalJSON(data []byte) error {
+	var provider string
+	if err := json.Unmarshal(data, &provider); err == nil {
+		*m = MeshConfig{Provider: provider}
+		return nil
+	}
+	type plain MeshConfig
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// UnmarshalYAML is UnmarshalJSON for configs decoded as YAML
+func (m *MeshConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*m = MeshConfig{Provider: node.Value}
+		return nil
+	}
+	type plain MeshConfig
+	return node.Decode((*plain)(m))
+}
+
+// MeshProvider returns the spec's mesh, or "" when it has none
+func (s *AppSpec) MeshProvider() string {
+	if s.Mesh == nil {
+		return ""
+	}
+	return s.Mesh.Provider
+}
+
+func validateMesh(spec *AppSpec) ValidationErrors {
+	switch spec.MeshProvider() {
+	case "", MeshIstio, MeshLinkerd, MeshNone:
+		return nil
+	}
+	return ValidationErrors{{
+		Field:   "spec.mesh.provider",
+		Message: fmt.Sprintf("unknown mesh %q (must be istio, linkerd, or none)", spec.Mesh.Provider),
+	}}
+}
+
+// meshWarnings flags mesh setups that commonly fail at admission
+func meshWarnings(spec *AppSpec) []string {
+	mesh := spec.MeshProvider()
+	if mesh != MeshIstio && mesh != MeshLinkerd {
+		return nil
+	}
+	var warnings []string
+	if spec.EffectiveSecurityProfile() == SecurityProfileRestricted {
+		warnings = append(warnings, fmt.Sprintf("namespaces enforcing the restricted Pod Security Standard reject %s's injected init container (it needs NET_ADMIN and NET_RAW) - install the %s CNI plugin there", mesh, mesh))
+	}
+	if spec.Platform == PlatformWindows {
+		warnings = append(warnings, fmt.Sprintf("%s does not inject sidecars into Windows pods", mesh))
+	}
+	return warnings
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ResourceMetadata holds annotations or labels for one kind of the app's
+// objects. Integrations such as the AWS load balancer controller,
+// Prometheus, Argo CD sync waves and Reloader are driven by them.
+type ResourceMetadata struct {
+	// Deployment is for the app's Deployment (or StatefulSet) and its
+	// process Deployments
+	Deployment map[string]string `yaml:"deployment,omitempty" json:"deployment,omitempty"`
+
+	// Pod is for the pod template; changing it restarts the pods
+	Pod map[string]string `yaml:"pod,omitempty" json:"pod,omitempty"`
+
+	// Service is for the app's Service
+	Service map[string]string `yaml:"service,omitempty" json:"service,omitempty"`
+
+	// Ingress is for the app's Ingress
+	Ingress map[string]string `yaml:"ingress,omitempty" json:"ingress,omitempty"`
+}
+
+// IsReservedLabel reports whether kbox sets the label itself. Pods are
+// selected by app, so a user value could orphan them.
+func IsReservedLabel(key string) bool {
+	switch key {
+	case "app", "app.kubernetes.io/name", "app.kubernetes.io/managed-by":
+		return true
+	}
+	return strings.HasPrefix(key, "kbox.dev/")
+}
+
+// validateMetadata checks metadata.labels and metadata.annotations, which
+// kbox stamps on every object it renders
+func validateMetadata(md Metadata) ValidationErrors {
+	errs := validateLabels(md.Labels, "metadata.labels")
+	errs = append(errs, validateAnnotations(md.Annotations, "metadata.annotations")...)
+	return errs
+}
+
+// validateResourceMetadata checks spec.annotations and spec.labels
+func validateResourceMetadata(spec *AppSpec) ValidationErrors {
+	var errs ValidationErrors
+	if a := spec.Annotations; a != nil {
+		errs = append(errs, validateAnnotations(a.Deployment, "spec.annotations.deployment")...)
+		errs = append(errs, validateAnnotations(a.Pod, "spec.annotations.pod")...)
+		errs = append(errs, validateAnnotations(a.Service, "spec.annotations.service")...)
+		errs = append(errs, validateAnnotations(a.Ingress, "spec.annotations.ingress")...)
+		if len(a.Ingress) > 0 && (spec.Ingress == nil || !spec.Ingress.Enabled) {
+			errs = append(errs, ValidationError{Field: "spec.annotations.ingress", Message: "requires ingress.enabled: true"})
+		}
+	}
+	if l := spec.Labels; l != nil {
+		errs = append(errs, validateLabels(l.Deployment, "spec.labels.deployment")...)
+		errs = append(errs, validateLabels(l.Pod, "spec.labels.pod")...)
+		errs = append(errs, validateLabels(l.Service, "spec.labels.service")...)
+		errs = append(errs, validateLabels(l.Ingress, "spec.labels.ingress")...)
+		if len(l.Ingress) > 0 && (spec.Ingress == nil || !spec.Ingress.Enabled) {
+			errs = append(errs, ValidationError{Field: "spec.labels.ingress", Message: "requires ingress.enabled: true"})
+		}
+	}
+	return errs
+}
+
+func validateLabels(labels map[string]string, field string) ValidationErrors {
+	var errs ValidationErrors
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("invalid key %q: %s", key, strings.Join(msgs, "; "))})
+			continue
+		}
+		if IsReservedLabel(key) {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("%q is set by kbox and can't be overridden", key)})
+			continue
+		}
+		if msgs := validation.IsValidLabelValue(labels[key]); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("invalid value for %q: %s", key, strings.Join(msgs, "; "))})
+		}
+	}
+	return errs
+}
+
+func validateAnnotations(annotations map[string]string, field string) ValidationErrors {
+	var errs ValidationErrors
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("invalid key %q: %s", key, strings.Join(msgs, "; "))})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migration upgrades a config document from one apiVersion to the next
+type Migration struct {
+	From string
+	To   string
+
+	// Migrate rewrites the document in place, keeping comments, and
+	// describes each change it made
+	Migrate func(doc *yaml.Node) []string
+}
+
+// Migrations are the upgrades between apiVersions, oldest first. A config
+// is upgraded through each in turn until it reaches DefaultAPIVersion.
+// kbox.dev/v1 is the only apiVersion so far; a schema change that renames or
+// reshapes fields adds its upgrade here when it bumps DefaultAPIVersion.
+var Migrations []Migration
+
+// IsDeprecatedAPIVersion reports whether version is an older apiVersion
+// that kbox still reads by migrating it
+func IsDeprecatedAPIVersion(version string) bool {
+	return slices.ContainsFunc(Migrations, func(m Migration) bool { return m.From == version })
+}
+
+// MigrateNode upgrades a config document to DefaultAPIVersion. It returns
+// the apiVersion the document was written for and what changed, or no
+// changes when it's current or a version kbox doesn't know (which Validate
+// reports).
+func MigrateNode(node *yaml.Node) (string, []string) {
+	root := GetRootDocument(node)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return "", nil
+	}
+	from := ""
+	if v := FindMapKey(root, "apiVersion"); v != nil {
+		from = v.Value
+	}
+
+	var changes []string
+	version := from
+	for _, m := range Migrations {
+		if m.From != version {
+			continue
+		}
+		changes = append(changes, m.Migrate(root)...)
+		SetMapScalar(root, "apiVersion", m.To, "")
+		changes = append(changes, fmt.Sprintf("apiVersion: %s → %s", m.From, m.To))
+		version = m.To
+	}
+	return from, changes
+}
+
+// ReadMigrations returns the apiVersion of the config at path and the
+// changes reading it as DefaultAPIVersion makes, none when it's current.
+// Errors reading the file are left for the loader to report.
+func ReadMigrations(path string) (string, []string) {
+	node, err := LoadYAMLWithComments(path)
+	if err != nil {
+		return "", nil
+	}
+	return MigrateNode(node)
+}
+
+// migrateData upgrades config data written for an older apiVersion, and
+// returns anything else unchanged
+func migrateData(data []byte) ([]byte, error) {
+	var header struct {
+		APIVersion string `yaml:"apiVersion"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil || !IsDeprecatedAPIVersion(header.APIVersion) {
+		// Parse errors are the loader's to report
+		return data, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	MigrateNode(&node)
+	return yaml.Marshal(&node)
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// withTestMigration registers an upgrade from kbox.dev/v0 that renames
+// spec.healthcheck to healthCheck, for the duration of the test
+func withTestMigration(t *testing.T) {
+	t.Helper()
+	saved := Migrations
+	t.Cleanup(func() { Migrations = saved })
+	Migrations = []Migration{{
+		From: "kbox.dev/v0",
+		To:   DefaultAPIVersion,
+		Migrate: func(root *yaml.Node) []string {
+			spec := FindMapKey(root, "spec")
+			if spec == nil || spec.Kind != yaml.MappingNode {
+				return nil
+			}
+			for i := 0; i+1 < len(spec.Content); i += 2 {
+				if spec.Content[i].Value == "healthcheck" {
+					spec.Content[i].Value = "healthCheck"
+					return []string{"spec.healthcheck → spec.healthCheck"}
+				}
+			}
+			return nil
+		},
+	}}
+}
+
+const v0Doc = `apiVersion: kbox.dev/v0
+kind: App
+metadata:
+  name: shop
+spec:
+  image: shop:v1
+  healthcheck: /healthz # probed every 10s
+`
+
+func TestMigrateNode(t *testing.T) {
+	withTestMigration(t)
+
+	var changes []string
+	var from string
+	got := editDoc(t, v0Doc, func(root *yaml.Node) {
+		from, changes = MigrateNode(root)
+	})
+	if from != "kbox.dev/v0" {
+		t.Errorf("from = %q, want kbox.dev/v0", from)
+	}
+	want := `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: shop
+spec:
+  image: shop:v1
+  healthCheck: /healthz # probed every 10s
+`
+	if got != want {
+		t.Errorf("migrated document:\n%s\nwant:\n%s", got, want)
+	}
+	wantChanges := []string{"spec.healthcheck → spec.healthCheck", "apiVersion: kbox.dev/v0 → kbox.dev/v1"}
+	if strings.Join(changes, "\n") != strings.Join(wantChanges, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(changes, "\n"), strings.Join(wantChanges, "\n"))
+	}
+}
+
+func TestMigrateNode_Current(t *testing.T) {
+	withTestMigration(t)
+
+	for _, doc := range []string{capabilityDoc, "apiVersion: kbox.dev/v9\nkind: App\n"} {
+		var changes []string
+		got := editDoc(t, doc, func(root *yaml.Node) {
+			_, changes = MigrateNode(root)
+		})
+		if len(changes) != 0 || got != doc {
+			t.Errorf("expected %q left alone, got changes %v and:\n%s", doc, changes, got)
+		}
+	}
+}
+
+func TestLoadFile_Migrates(t *testing.T) {
+	withTestMigration(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kbox.yaml")
+	if err := os.WriteFile(path, []byte(v0Doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoader(dir).LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.APIVersion != DefaultAPIVersion {
+		t.Errorf("APIVersion = %q, want %q", cfg.APIVersion, DefaultAPIVersion)
+	}
+	if cfg.Spec.HealthCheck != "/healthz" {
+		t.Errorf("healthcheck not migrated: %+v", cfg.Spec)
+	}
+
+	// The file itself is left alone; kbox migrate-config rewrites it
+	from, changes := ReadMigrations(path)
+	if from != "kbox.dev/v0" || len(changes) == 0 {
+		t.Errorf("ReadMigrations() = %q, %v", from, changes)
+	}
+}
@@ -2,14 +2,20 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
+
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
 )
 
 // IsMultiService checks if a kbox.yaml file defines a multi-service app
 func IsMultiService(path string) (bool, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfig(path)
 	if err != nil {
 		return false, err
 	}
@@ -29,7 +35,7 @@ func IsMultiServiceData(data []byte) (bool, error) {
 
 // LoadMultiService loads a multi-service configuration from a file
 func LoadMultiService(path string) (*MultiServiceConfig, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfig(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -90,6 +96,8 @@ func (c *MultiServiceConfig) Validate() error {
 		})
 	}
 
+	errs = append(errs, validateMetadata(c.Metadata)...)
+
 	// Must have at least one service
 	if len(c.Services) == 0 {
 		errs = append(errs, ValidationError{
@@ -118,6 +126,7 @@ func (c *MultiServiceConfig) Validate() error {
 				Message: "image or build required",
 			})
 		}
+		errs = append(errs, validateSigningAt("services."+name, svc.Signing, svc.Build)...)
 
 		// Validate port
 		if svc.Port < 0 || svc.Port > 65535 {
@@ -134,6 +143,29 @@ func (c *MultiServiceConfig) Validate() error {
 				Message: "must be non-negative",
 			})
 		}
+
+		errs = append(errs, validateService(svc.Service, svc.Port, fmt.Sprintf("services.%s.service", name))...)
+
+		if r := svc.Readiness; r != nil {
+			if r.Path != "" && !strings.HasPrefix(r.Path, "/") {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("services.%s.readiness.path", name),
+					Message: "must start with /",
+				})
+			}
+			if r.Port < 0 || r.Port > 65535 {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("services.%s.readiness.port", name),
+					Message: "must be between 0 and 65535",
+				})
+			}
+		}
+		if svc.WaitForDependencies && len(svc.DependsOn) == 0 {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("services.%s.waitForDependencies", name),
+				Message: "needs dependsOn: there's nothing to wait for",
+			})
+		}
 	}
 
 	// Validate dependsOn references
@@ -162,12 +194,92 @@ func (c *MultiServiceConfig) Validate() error {
 		})
 	}
 
+	for envName := range c.Environments {
+		if _, err := c.mergeEnvironment(envName); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("environments.%s", envName),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	errs = append(errs, validateSharedSecrets(c.Metadata.Name, c.SharedSecrets, c.Services)...)
+	errs = append(errs, validateClusters(c.Clusters)...)
+	errs = append(errs, validateMinKboxVersion(c.MinKboxVersion)...)
+	errs = append(errs, validateNotifications(c.Notifications)...)
+
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
 
+// validateSharedSecrets checks that shared secrets name valid env vars and
+// known services, that no service gets the same env var twice, and that
+// their Secrets don't take the name of one kbox generates for something else
+func validateSharedSecrets(app string, secrets map[string]SharedSecretConfig, services map[string]ServiceSpec) ValidationErrors {
+	var errs ValidationErrors
+	injected := make(map[string]string) // service/key -> the secret injecting it
+	for _, name := range slices.Sorted(maps.Keys(secrets)) {
+		secret := secrets[name]
+		field := fmt.Sprintf("sharedSecrets.%s", name)
+		if !IsValidName(name) {
+			errs = append(errs, ValidationError{Field: field, Message: "name must be lowercase alphanumeric with hyphens"})
+		}
+		if owner := generatedSecretOwner(name, services); owner != "" {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("its Secret %s-%s would have the name of %s; choose another name", app, name, owner)})
+		}
+		if len(secret.Keys) == 0 {
+			errs = append(errs, ValidationError{Field: field + ".keys", Message: "at least one key is required"})
+		}
+		if len(secret.Services) == 0 {
+			errs = append(errs, ValidationError{Field: field + ".services", Message: "at least one service is required"})
+		}
+		for _, key := range secret.Keys {
+			if msgs := validation.IsEnvVarName(key); len(msgs) > 0 {
+				errs = append(errs, ValidationError{Field: field + ".keys", Message: fmt.Sprintf("invalid env var name %q: %s", key, strings.Join(msgs, "; "))})
+			}
+		}
+		for _, svcName := range secret.Services {
+			svc, ok := services[svcName]
+			if !ok {
+				errs = append(errs, ValidationError{Field: field + ".services", Message: fmt.Sprintf("unknown service %q", svcName)})
+				continue
+			}
+			for _, key := range secret.Keys {
+				if other, dup := injected[svcName+"/"+key]; dup {
+					errs = append(errs, ValidationError{Field: field + ".keys", Message: fmt.Sprintf("%s is already injected into %s by sharedSecrets.%s", key, svcName, other)})
+				} else if _, set := svc.Env[key]; set {
+					errs = append(errs, ValidationError{Field: field + ".keys", Message: fmt.Sprintf("%s is also set in services.%s.env", key, svcName)})
+				}
+				injected[svcName+"/"+key] = name
+			}
+		}
+		if secret.RotateEvery != "" {
+			if every, err := time.ParseDuration(secret.RotateEvery); err != nil || every <= 0 {
+				errs = append(errs, ValidationError{Field: field + ".rotateEvery", Message: "must be a positive duration, e.g. 720h"})
+			}
+		}
+	}
+	return errs
+}
+
+// generatedSecretOwner returns what else kbox names <app>-<name>, the name
+// of a shared secret's Secret: a dependency's Secret or a service's. It
+// returns "" when the name is free.
+func generatedSecretOwner(name string, services map[string]ServiceSpec) string {
+	if dependencies.IsSupported(name) {
+		return fmt.Sprintf("the %s dependency's Secret", name)
+	}
+	for _, svcName := range slices.Sorted(maps.Keys(services)) {
+		switch name {
+		case svcName + "-secrets", svcName + "-sops-secrets", svcName + "-tls":
+			return fmt.Sprintf("a Secret of service %s", svcName)
+		}
+	}
+	return ""
+}
+
 // checkCircularDeps checks for circular dependencies using DFS
 func checkCircularDeps(services map[string]ServiceSpec) error {
 	visited := make(map[string]bool)
@@ -208,58 +320,66 @@ func checkCircularDeps(services map[string]ServiceSpec) error {
 	return nil
 }
 
-// ForEnvironment returns a config merged with environment-specific overrides
+// ForEnvironment returns a config merged with environment-specific overrides,
+// deep-merging each service's overrides into it as AppConfig.ForEnvironment
+// does. Overrides of unknown services are skipped.
 func (c *MultiServiceConfig) ForEnvironment(env string) *MultiServiceConfig {
-	if env == "" || c.Environments == nil {
+	result, err := c.mergeEnvironment(env)
+	if err != nil {
+		// Validate rejects overrides that don't merge
 		return c
 	}
+	return result
+}
+
+// mergeEnvironment is ForEnvironment, reporting overrides that don't merge
+func (c *MultiServiceConfig) mergeEnvironment(env string) (*MultiServiceConfig, error) {
+	if env == "" || c.Environments == nil {
+		return c, nil
+	}
 
 	override, ok := c.Environments[env]
 	if !ok {
-		return c
+		return c, nil
 	}
 
-	// Create a deep copy of the config
 	result := *c
 	result.Services = make(map[string]ServiceSpec)
 	for name, svc := range c.Services {
 		result.Services[name] = svc
 	}
 
-	// Apply per-service overrides
-	if override.Services != nil {
-		for name, svcOverride := range override.Services {
-			svc, ok := result.Services[name]
-			if !ok {
-				continue // Skip unknown services
-			}
-
-			// Apply overrides
-			if svcOverride.Replicas != nil {
-				svc.Replicas = *svcOverride.Replicas
-			}
-			if svcOverride.Image != "" {
-				svc.Image = svcOverride.Image
-			}
-			if svcOverride.Resources != nil {
-				svc.Resources = svcOverride.Resources
-			}
-
-			// Merge env vars
-			if len(svcOverride.Env) > 0 {
-				if svc.Env == nil {
-					svc.Env = make(map[string]string)
-				}
-				for k, v := range svcOverride.Env {
-					svc.Env[k] = v
-				}
-			}
+	if override.Namespace != "" {
+		result.Metadata.Namespace = override.Namespace
+	}
 
-			result.Services[name] = svc
+	// Apply per-service overrides
+	for name, svcOverride := range override.Services {
+		svc, ok := c.Services[name]
+		if !ok {
+			continue // Skip unknown services
+		}
+		overlay, err := svcOverride.specOverlay()
+		if err != nil {
+			return nil, err
 		}
+		var merged ServiceSpec
+		if err := mergeOverlay(svc, overlay, &merged, false); err != nil {
+			return nil, fmt.Errorf("services.%s: %w", name, err)
+		}
+		result.Services[name] = merged
 	}
 
-	return &result
+	return &result, nil
+}
+
+// EnvironmentTarget returns the context/namespace binding of an environment
+func (c *MultiServiceConfig) EnvironmentTarget(env string) EnvTarget {
+	override, ok := c.Environments[env]
+	if env == "" || !ok {
+		return EnvTarget{}
+	}
+	return EnvTarget{Context: override.Context, Namespace: override.Namespace, Protected: override.Protected}
 }
 
 // ToAppConfig converts a single service from MultiServiceConfig to AppConfig
@@ -274,32 +394,63 @@ func (c *MultiServiceConfig) ToAppConfig(serviceName string) (*AppConfig, error)
 		APIVersion: c.APIVersion,
 		Kind:       DefaultKind,
 		Metadata: Metadata{
-			Name:      fmt.Sprintf("%s-%s", c.Metadata.Name, serviceName),
-			Namespace: c.Metadata.Namespace,
-			Labels:    c.Metadata.Labels,
+			Name:        fmt.Sprintf("%s-%s", c.Metadata.Name, serviceName),
+			Namespace:   c.Metadata.Namespace,
+			Labels:      c.Metadata.Labels,
+			Annotations: c.Metadata.Annotations,
 		},
 		Spec: AppSpec{
 			Image:       svc.Image,
 			Build:       svc.Build,
+			Signing:     svc.Signing,
 			Port:        svc.Port,
 			Replicas:    svc.Replicas,
 			Env:         svc.Env,
+			EnvFromFile: svc.EnvFromFile,
 			HealthCheck: svc.HealthCheck,
 			Resources:   svc.Resources,
 			Command:     svc.Command,
 			Args:        svc.Args,
 			Service:     svc.Service,
+
+			RollOnConfigChange: svc.RollOnConfigChange,
 		},
 	}, nil
 }
 
+// ReadinessURL returns the URL services waiting for serviceName poll until
+// it's serving: its readiness endpoint, through its Service
+func (c *MultiServiceConfig) ReadinessURL(serviceName string) string {
+	svc := c.Services[serviceName]
+	path, port := svc.HealthCheck, svc.Port
+	if svc.Service != nil && svc.Service.Port > 0 {
+		port = svc.Service.Port
+	}
+	if r := svc.Readiness; r != nil {
+		if r.Path != "" {
+			path = r.Path
+		}
+		if r.Port > 0 {
+			port = r.Port
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("http://%s-%s:%d%s", c.Metadata.Name, serviceName, port, path)
+}
+
 // ServiceOrder returns services in dependency order (dependencies first)
 func (c *MultiServiceConfig) ServiceOrder() []string {
 	return topologicalSort(c.Services)
 }
 
-// topologicalSort returns services sorted by dependencies (Kahn's algorithm)
+// topologicalSort returns services sorted by dependencies (Kahn's algorithm).
+// Services that are ready at the same time come in name order, so the
+// rendered bundle is the same on every run.
 func topologicalSort(services map[string]ServiceSpec) []string {
+	names := slices.Sorted(maps.Keys(services))
+
 	// Build in-degree map
 	inDegree := make(map[string]int)
 	for name := range services {
@@ -321,7 +472,7 @@ func topologicalSort(services map[string]ServiceSpec) []string {
 
 	// Queue services with no dependencies
 	var queue []string
-	for name := range services {
+	for _, name := range names {
 		if depCount[name] == 0 {
 			queue = append(queue, name)
 		}
@@ -329,8 +480,8 @@ func topologicalSort(services map[string]ServiceSpec) []string {
 
 	// Build reverse adjacency list (who depends on me)
 	dependents := make(map[string][]string)
-	for name, svc := range services {
-		for _, dep := range svc.DependsOn {
+	for _, name := range names {
+		for _, dep := range services[name].DependsOn {
 			dependents[dep] = append(dependents[dep], name)
 		}
 	}
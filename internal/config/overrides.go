@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides are config values given on the command line, as Helm's --values
+// and --set are: files merged in order, then path=value assignments. They're
+// merged over the config after its environment overlay, so they win over
+// both kbox.yaml and the environment.
+type Overrides struct {
+	// ValuesFiles are YAML files shaped like kbox.yaml
+	ValuesFiles []string
+	// Set are assignments such as spec.replicas=5; a dot in a key is
+	// escaped as \.
+	Set []string
+}
+
+// IsEmpty reports whether there is nothing to override
+func (o Overrides) IsEmpty() bool {
+	return len(o.ValuesFiles) == 0 && len(o.Set) == 0
+}
+
+// overlay returns the overrides as one tree, in the order they apply
+func (o Overrides) overlay() (map[string]interface{}, error) {
+	overlay := map[string]interface{}{}
+	for _, file := range o.ValuesFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("--values: %w", err)
+		}
+		var tree map[string]interface{}
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, fmt.Errorf("--values %s: %w", file, err)
+		}
+		// A values file may be a whole kbox.yaml, whose header isn't overridable
+		delete(tree, "apiVersion")
+		delete(tree, "kind")
+		overlay = mergeTrees(overlay, tree)
+	}
+	for _, expr := range o.Set {
+		tree, err := parseSet(expr)
+		if err != nil {
+			return nil, err
+		}
+		overlay = mergeTrees(overlay, tree)
+	}
+	return overlay, nil
+}
+
+// parseSet turns path=value into a tree holding value at path. The value is
+// read as YAML, so numbers and booleans keep their type and lists can be
+// written [a, b], except where that would change how it reads (1.10 stays
+// the string "1.10").
+func parseSet(expr string) (map[string]interface{}, error) {
+	path, raw, ok := strings.Cut(expr, "=")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("--set %s: expected path=value, e.g. spec.replicas=5", expr)
+	}
+	keys := splitPath(path)
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("--set %s: empty key in %s", expr, path)
+		}
+		if strings.Contains(key, "[") {
+			return nil, fmt.Errorf("--set %s: list items can't be set one at a time\n  → Set the whole list, e.g. spec.command=[./server,--verbose]", expr)
+		}
+	}
+
+	var value interface{} = raw
+	var parsed interface{}
+	if raw != "" && yaml.Unmarshal([]byte(raw), &parsed) == nil {
+		switch parsed.(type) {
+		case map[string]interface{}, []interface{}:
+			value = parsed
+		case nil, string:
+		default:
+			if fmt.Sprint(parsed) == raw {
+				value = parsed
+			}
+		}
+	}
+
+	tree := map[string]interface{}{keys[len(keys)-1]: value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		tree = map[string]interface{}{keys[i]: tree}
+	}
+	return tree, nil
+}
+
+// splitPath splits a --set path at dots not escaped with a backslash
+func splitPath(path string) []string {
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
+// checkOverrideKeys rejects overrides of top-level keys other than allowed
+func checkOverrideKeys(overlay map[string]interface{}, allowed ...string) error {
+	for key := range overlay {
+		if key == "environments" {
+			return fmt.Errorf("overrides apply after the environment overlay, so they can't change environments\n  → Override the merged value instead, e.g. spec.replicas with -e prod")
+		}
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("can't override %s\n  → Overrides may set %s", key, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// WithOverrides returns the config with the overrides merged over its
+// metadata and spec, validated again. It's applied after ForEnvironment.
+func (c *AppConfig) WithOverrides(o Overrides) (*AppConfig, error) {
+	if o.IsEmpty() {
+		return c, nil
+	}
+	overlay, err := o.overlay()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOverrideKeys(overlay, "metadata", "spec"); err != nil {
+		return nil, err
+	}
+
+	type overridable struct {
+		Metadata Metadata `yaml:"metadata"`
+		Spec     AppSpec  `yaml:"spec"`
+	}
+	var merged overridable
+	if err := mergeOverlay(overridable{Metadata: c.Metadata, Spec: c.Spec}, overlay, &merged, true); err != nil {
+		return nil, fmt.Errorf("failed to apply overrides: %w", err)
+	}
+	result := *c
+	result.Metadata, result.Spec = merged.Metadata, merged.Spec
+	if err := Validate(&result); err != nil {
+		return nil, fmt.Errorf("config with overrides is invalid: %w", err)
+	}
+	return &result, nil
+}
+
+// WithOverrides returns the config with the overrides merged over its
+// metadata, services and sharedSecrets, validated again. It's applied after
+// ForEnvironment.
+func (c *MultiServiceConfig) WithOverrides(o Overrides) (*MultiServiceConfig, error) {
+	if o.IsEmpty() {
+		return c, nil
+	}
+	overlay, err := o.overlay()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOverrideKeys(overlay, "metadata", "services", "sharedSecrets"); err != nil {
+		return nil, err
+	}
+
+	type overridable struct {
+		Metadata      Metadata                      `yaml:"metadata"`
+		Services      map[string]ServiceSpec        `yaml:"services"`
+		SharedSecrets map[string]SharedSecretConfig `yaml:"sharedSecrets,omitempty"`
+	}
+	var merged overridable
+	if err := mergeOverlay(overridable{Metadata: c.Metadata, Services: c.Services, SharedSecrets: c.SharedSecrets}, overlay, &merged, true); err != nil {
+		return nil, fmt.Errorf("failed to apply overrides: %w", err)
+	}
+	result := *c
+	result.Metadata, result.Services, result.SharedSecrets = merged.Metadata, merged.Services, merged.SharedSecrets
+	if err := result.Validate(); err != nil {
+		return nil, fmt.Errorf("config with overrides is invalid: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithOverrides(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"kbox.yaml": `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: myapp
+spec:
+  image: myapp:v1
+  replicas: 2
+  env:
+    LOG_LEVEL: info
+environments:
+  prod:
+    replicas: 3
+`,
+		"ci.yaml": `spec:
+  image: myapp:1.10
+  env:
+    REGION: eu
+`,
+	})
+	cfg, err := NewLoader(dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = cfg.ForEnvironment("prod").WithOverrides(Overrides{
+		ValuesFiles: []string{filepath.Join(dir, "ci.yaml")},
+		Set:         []string{"spec.replicas=5", "spec.env.FEATURE_X=on", "spec.env.VERSION=1.10", "metadata.labels.team\\.io/owner=payments"},
+	})
+	if err != nil {
+		t.Fatalf("WithOverrides: %v", err)
+	}
+
+	if cfg.Spec.Replicas != 5 {
+		t.Errorf("replicas = %d, want 5 over the environment's 3", cfg.Spec.Replicas)
+	}
+	if cfg.Spec.Image != "myapp:1.10" {
+		t.Errorf("image = %q, want the values file's", cfg.Spec.Image)
+	}
+	for key, want := range map[string]string{"LOG_LEVEL": "info", "REGION": "eu", "FEATURE_X": "on", "VERSION": "1.10"} {
+		if got := cfg.Spec.Env[key]; got != want {
+			t.Errorf("env %s = %q, want %q", key, got, want)
+		}
+	}
+	if got := cfg.Metadata.Labels["team.io/owner"]; got != "payments" {
+		t.Errorf("escaped label key = %q, want payments (labels %v)", got, cfg.Metadata.Labels)
+	}
+}
+
+func TestWithOverrides_Errors(t *testing.T) {
+	cfg := &AppConfig{
+		APIVersion: DefaultAPIVersion,
+		Kind:       DefaultKind,
+		Metadata:   Metadata{Name: "myapp"},
+		Spec:       AppSpec{Image: "myapp:v1", Port: 8080, Replicas: 1},
+	}
+
+	tests := []struct {
+		name    string
+		set     string
+		wantErr string
+	}{
+		{"no value", "spec.replicas", "expected path=value"},
+		{"typo", "spec.replica=5", "field replica not found"},
+		{"wrong type", "spec.replicas=many", "cannot unmarshal"},
+		{"list item", "spec.command[0]=./server", "list items can't be set"},
+		{"environments", "environments.prod.replicas=3", "after the environment overlay"},
+		{"header", "kind=MultiApp", "can't override kind"},
+		{"invalid", "spec.replicas=-1", "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cfg.WithOverrides(Overrides{Set: []string{tt.set}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMultiServiceWithOverrides(t *testing.T) {
+	cfg := &MultiServiceConfig{
+		APIVersion: DefaultAPIVersion,
+		Kind:       MultiAppKind,
+		Metadata:   Metadata{Name: "shop"},
+		Services: map[string]ServiceSpec{
+			"api": {Image: "api:v1", Port: 8080},
+			"web": {Image: "web:v1", Port: 3000},
+		},
+	}
+	got, err := cfg.WithOverrides(Overrides{Set: []string{"services.api.image=api:abc123", "services.web.replicas=3"}})
+	if err != nil {
+		t.Fatalf("WithOverrides: %v", err)
+	}
+	if got.Services["api"].Image != "api:abc123" || got.Services["web"].Replicas != 3 || got.Services["web"].Image != "web:v1" {
+		t.Errorf("unexpected services %+v", got.Services)
+	}
+	if cfg.Services["api"].Image != "api:v1" {
+		t.Errorf("the original config changed")
+	}
+}
+
+func TestWithOverrides_EnvironmentsTestdata(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "environments", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		cfg, err := NewLoader(".").LoadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		// Every field of a rich spec must survive the strict round trip
+		if _, err := cfg.ForEnvironment("prod").WithOverrides(Overrides{Set: []string{"spec.replicas=4"}}); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
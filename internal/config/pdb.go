@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PDBConfig sets the PodDisruptionBudget kbox adds to apps running more
+// than one replica (default: maxUnavailable: 1). Set one of the two.
+type PDBConfig struct {
+	// MinAvailable pods during voluntary disruptions, a count or percentage
+	MinAvailable string `yaml:"minAvailable,omitempty" json:"minAvailable,omitempty"`
+
+	// MaxUnavailable pods during voluntary disruptions, a count or percentage
+	MaxUnavailable string `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+}
+
+// HasPDB reports whether the app gets a PodDisruptionBudget: it runs more
+// than one replica, or autoscales. A single replica gets none, since any
+// budget would either block node drains or allow the outage anyway.
+func (s *AppSpec) HasPDB() bool {
+	return s.Replicas > 1 || (s.Autoscaling != nil && s.Autoscaling.Enabled)
+}
+
+func validatePDB(pdb *PDBConfig) ValidationErrors {
+	if pdb == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if pdb.MinAvailable != "" && pdb.MaxUnavailable != "" {
+		errs = append(errs, ValidationError{
+			Field:   "spec.pdb",
+			Message: "set minAvailable or maxUnavailable, not both",
+		})
+	}
+	for _, f := range []struct{ value, field string }{
+		{pdb.MinAvailable, "spec.pdb.minAvailable"},
+		{pdb.MaxUnavailable, "spec.pdb.maxUnavailable"},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if _, err := parseCountOrPercent(f.value); err != nil {
+			errs = append(errs, ValidationError{Field: f.field, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// parseCountOrPercent parses a count (2) or a percentage (50%)
+func parseCountOrPercent(value string) (intstr.IntOrString, error) {
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n < 0 || n > 100 {
+			return intstr.IntOrString{}, fmt.Errorf("%q must be a percentage between 0%% and 100%%", value)
+		}
+		return intstr.FromString(value), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return intstr.IntOrString{}, fmt.Errorf("%q must be a pod count or a percentage such as 50%%", value)
+	}
+	return intstr.FromInt32(int32(n)), nil
+}
+
+// pdbWarnings reports budgets that are skipped or would block node drains
+func pdbWarnings(spec *AppSpec) []string {
+	pdb := spec.PDB
+	if pdb == nil || (pdb.MinAvailable == "" && pdb.MaxUnavailable == "") {
+		return nil
+	}
+	if !spec.HasPDB() {
+		return []string{"spec.pdb is skipped for a single replica: its budget would block every node drain - set replicas: 2 or more, or enable autoscaling"}
+	}
+
+	var blocks bool
+	if v, err := parseCountOrPercent(pdb.MaxUnavailable); pdb.MaxUnavailable != "" && err == nil {
+		blocks = (v.Type == intstr.Int && v.IntVal == 0) || v.StrVal == "0%"
+	}
+	if v, err := parseCountOrPercent(pdb.MinAvailable); pdb.MinAvailable != "" && err == nil {
+		// An autoscaled app may run more pods than a fixed minAvailable
+		autoscaled := spec.Autoscaling != nil && spec.Autoscaling.Enabled
+		blocks = v.StrVal == "100%" || (v.Type == intstr.Int && !autoscaled && int(v.IntVal) >= spec.Replicas)
+	}
+	if blocks {
+		return []string{"spec.pdb allows no pod to be evicted, so node drains and cluster upgrades will hang - allow at least one unavailable pod"}
+	}
+	return nil
+}
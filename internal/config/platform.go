@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Node operating systems and architectures kbox can target
+const (
+	PlatformLinux   = "linux"
+	PlatformWindows = "windows"
+	ArchAMD64       = "amd64"
+	ArchARM64       = "arm64"
+)
+
+// TargetPlatform returns the os/arch the app's pods run on, e.g. linux/arm64,
+// or "" when any Linux node will do. Windows nodes are amd64.
+func (s *AppSpec) TargetPlatform() string {
+	switch {
+	case s.Platform == PlatformWindows:
+		return PlatformWindows + "/" + ArchAMD64
+	case s.Arch != "":
+		return PlatformLinux + "/" + s.Arch
+	}
+	return ""
+}
+
+// BuildPlatforms returns the platforms to build the image for: build.platforms
+// if set (a multi-arch manifest list), otherwise the target platform if any
+func (s *AppSpec) BuildPlatforms() []string {
+	if s.Build != nil && len(s.Build.Platforms) > 0 {
+		return s.Build.Platforms
+	}
+	if p := s.TargetPlatform(); p != "" {
+		return []string{p}
+	}
+	return nil
+}
+
+func validatePlatform(spec *AppSpec) ValidationErrors {
+	var errs ValidationErrors
+	switch spec.Platform {
+	case "", PlatformLinux, PlatformWindows:
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "spec.platform",
+			Message: fmt.Sprintf("unknown platform %q (must be linux or windows)", spec.Platform),
+		})
+	}
+	switch spec.Arch {
+	case "", ArchAMD64, ArchARM64:
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "spec.arch",
+			Message: fmt.Sprintf("unknown arch %q (must be amd64 or arm64)", spec.Arch),
+		})
+	}
+	if spec.Platform == PlatformWindows && spec.Arch == ArchARM64 {
+		errs = append(errs, ValidationError{
+			Field:   "spec.arch",
+			Message: "Windows nodes are amd64 only",
+		})
+	}
+	if spec.Build != nil {
+		for i, p := range spec.Build.Platforms {
+			if nodeOS, arch, ok := strings.Cut(p, "/"); !ok || nodeOS == "" || arch == "" {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("spec.build.platforms[%d]", i),
+					Message: fmt.Sprintf("invalid platform %q (expected os/arch, e.g. linux/arm64)", p),
+				})
+			}
+		}
+		switch spec.Build.TagStrategy {
+		case "", TagStrategyGitSHA, TagStrategyTimestamp, TagStrategySemver:
+		default:
+			errs = append(errs, ValidationError{
+				Field:   "spec.build.tagStrategy",
+				Message: fmt.Sprintf("unknown tag strategy %q (must be gitsha, timestamp or semver)", spec.Build.TagStrategy),
+			})
+		}
+	}
+	return errs
+}
+
+// platformWarnings flags builds that won't produce an image the target nodes can run
+func platformWarnings(spec *AppSpec) []string {
+	target := spec.TargetPlatform()
+	if target == "" || spec.Build == nil || len(spec.Build.Platforms) == 0 {
+		return nil
+	}
+	if !slices.Contains(spec.Build.Platforms, target) {
+		return []string{fmt.Sprintf("build.platforms %v does not include %s - pods on %s nodes won't be able to run the image",
+			spec.Build.Platforms, target, target)}
+	}
+	return nil
+}
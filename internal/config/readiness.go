@@ -0,0 +1,295 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// Readiness categories
+const (
+	ReadinessReliability   = "reliability"
+	ReadinessSecurity      = "security"
+	ReadinessObservability = "observability"
+)
+
+// ReadinessCategories lists the categories in report order
+var ReadinessCategories = []string{ReadinessReliability, ReadinessSecurity, ReadinessObservability}
+
+// ReadinessCheck is one item of a production-readiness report
+type ReadinessCheck struct {
+	// ID identifies the check, e.g. "replicas"
+	ID string `json:"id"`
+
+	// Category is reliability, security, or observability
+	Category string `json:"category"`
+
+	// Weight is how many points the check is worth
+	Weight int `json:"weight"`
+
+	// Passed is set when the config meets the check
+	Passed bool `json:"passed"`
+
+	// Applicable is unset for checks that don't apply to the config, e.g.
+	// ingress TLS without an ingress; they don't count toward the score
+	Applicable bool `json:"applicable"`
+
+	// Rationale says what the config does and why it matters
+	Rationale string `json:"rationale"`
+}
+
+// ReadinessReport scores how ready a config is for production
+type ReadinessReport struct {
+	// Score is the points of the passed checks as a percentage of those of
+	// all applicable checks
+	Score int `json:"score"`
+
+	// Categories are the scores of each category
+	Categories map[string]int `json:"categories"`
+
+	// Checks in category order
+	Checks []ReadinessCheck `json:"checks"`
+
+	// Warnings are ValidateWithWarnings' warnings about the config
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Readiness scores the config's production readiness across reliability
+// (replicas, PodDisruptionBudget, probes, resources), security (non-root,
+// NetworkPolicy, pinned images, secrets, TLS) and observability (metrics,
+// tracing). policies are the NetworkPolicies the config renders to. It
+// returns Validate's error for invalid configs.
+func Readiness(config *AppConfig, policies []*networkingv1.NetworkPolicy) (*ReadinessReport, error) {
+	warnings, err := ValidateWithWarnings(config)
+	if err != nil {
+		return nil, err
+	}
+	spec := &config.Spec
+	checks := []ReadinessCheck{
+		checkReplicas(spec),
+		checkPDB(spec),
+		checkProbes(spec),
+		checkResources(spec),
+		checkNonRoot(spec),
+		checkNetworkPolicy(config.Metadata.Name, policies),
+		checkPinnedImage(spec),
+		checkSecretsInEnv(config),
+		checkIngressTLS(spec),
+		checkMetrics(spec),
+		checkTracing(spec),
+	}
+
+	report := &ReadinessReport{Categories: make(map[string]int), Checks: checks, Warnings: warnings}
+	report.Score = readinessScore(checks)
+	for _, category := range ReadinessCategories {
+		report.Categories[category] = readinessScore(slices.DeleteFunc(slices.Clone(checks), func(c ReadinessCheck) bool {
+			return c.Category != category
+		}))
+	}
+	return report, nil
+}
+
+// readinessScore returns the passed checks' share of the applicable checks'
+// weight, out of 100; 100 when none apply
+func readinessScore(checks []ReadinessCheck) int {
+	passed, total := 0, 0
+	for _, c := range checks {
+		if !c.Applicable {
+			continue
+		}
+		total += c.Weight
+		if c.Passed {
+			passed += c.Weight
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return passed * 100 / total
+}
+
+// minReplicas returns the fewest pods the app runs
+func minReplicas(spec *AppSpec) int {
+	if as := spec.Autoscaling; as != nil && as.Enabled {
+		return max(as.MinReplicas, 1)
+	}
+	return max(spec.Replicas, 1)
+}
+
+func checkReplicas(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "replicas", Category: ReadinessReliability, Weight: 3, Applicable: true}
+	if n := minReplicas(spec); n >= 2 {
+		c.Passed = true
+		c.Rationale = fmt.Sprintf("at least %d replicas, so one pod crashing or being evicted doesn't take the app down", n)
+	} else {
+		c.Rationale = "a single replica: every crash, node drain and eviction is an outage; set spec.replicas (or autoscaling.minReplicas) to 2 or more"
+	}
+	return c
+}
+
+func checkPDB(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "pdb", Category: ReadinessReliability, Weight: 2, Applicable: true}
+	if spec.HasPDB() && minReplicas(spec) >= 2 {
+		c.Passed = true
+		c.Rationale = "a PodDisruptionBudget keeps pods running while nodes are drained"
+	} else {
+		c.Rationale = "no PodDisruptionBudget that helps: kbox adds one from 2 replicas, so node drains can evict every pod at once"
+	}
+	return c
+}
+
+func checkProbes(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "probes", Category: ReadinessReliability, Weight: 3, Applicable: true}
+	if spec.HealthCheck != "" {
+		c.Passed = true
+		c.Rationale = fmt.Sprintf("readiness and liveness probes on %s keep traffic from unready pods and restart hung ones", spec.HealthCheck)
+	} else {
+		c.Rationale = "no probes: pods get traffic before they're ready and hung pods are never restarted; set spec.healthCheck"
+	}
+	return c
+}
+
+func checkResources(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "resources", Category: ReadinessReliability, Weight: 2, Applicable: true}
+	if res := spec.Resources; res != nil && res.Memory != "" && res.CPU != "" {
+		c.Passed = true
+		c.Rationale = fmt.Sprintf("requests of %s memory and %s CPU let the scheduler place pods where they fit", res.Memory, res.CPU)
+	} else {
+		c.Rationale = "no memory and CPU requests: kbox's defaults may starve the app or waste the nodes; set spec.resources"
+	}
+	return c
+}
+
+func checkNonRoot(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "non-root", Category: ReadinessSecurity, Weight: 3, Applicable: true}
+	sc := spec.SecurityContext
+	switch {
+	case sc != nil && sc.RunAsUser != nil && *sc.RunAsUser == 0:
+		c.Rationale = "spec.securityContext.runAsUser is 0: a container escape gets root on the node"
+	case sc != nil && sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot:
+		c.Rationale = "spec.securityContext.runAsNonRoot is false: the image may run as root"
+	case spec.EffectiveSecurityProfile() == SecurityProfileBaseline:
+		// The baseline profile renders neither runAsNonRoot nor the dropped
+		// capabilities
+		c.Rationale = "securityProfile is baseline: containers run as the image's user, which may be root, with the runtime's default capabilities; use the restricted profile"
+	default:
+		c.Passed = true
+		c.Rationale = "containers run as a non-root user, with privilege escalation off and all capabilities dropped"
+	}
+	return c
+}
+
+func checkNetworkPolicy(app string, policies []*networkingv1.NetworkPolicy) ReadinessCheck {
+	c := ReadinessCheck{ID: "network-policy", Category: ReadinessSecurity, Weight: 2, Applicable: true}
+	for _, np := range policies {
+		if np.Spec.PodSelector.MatchLabels["app"] != app {
+			continue
+		}
+		ingress := slices.Contains(np.Spec.PolicyTypes, networkingv1.PolicyTypeIngress)
+		egress := slices.Contains(np.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		switch {
+		case !ingress || !egress:
+			c.Rationale = fmt.Sprintf("NetworkPolicy %s doesn't restrict both ingress and egress", np.Name)
+		case slices.ContainsFunc(np.Spec.Ingress, func(r networkingv1.NetworkPolicyIngressRule) bool { return len(r.From) == 0 && len(r.Ports) == 0 }),
+			slices.ContainsFunc(np.Spec.Egress, func(r networkingv1.NetworkPolicyEgressRule) bool { return len(r.To) == 0 && len(r.Ports) == 0 }):
+			c.Rationale = fmt.Sprintf("NetworkPolicy %s has a rule that allows all traffic", np.Name)
+		default:
+			c.Passed = true
+			c.Rationale = fmt.Sprintf("NetworkPolicy %s limits the app's pods to %d ingress and %d egress rules", np.Name, len(np.Spec.Ingress), len(np.Spec.Egress))
+		}
+		return c
+	}
+	c.Rationale = "no NetworkPolicy selects the app's pods: any pod in the cluster can reach them"
+	return c
+}
+
+func checkPinnedImage(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "pinned-image", Category: ReadinessSecurity, Weight: 3, Applicable: true}
+	var latest []string
+	for _, dep := range spec.Dependencies {
+		if dep.External == nil && dep.Version == "latest" {
+			latest = append(latest, dep.Type)
+		}
+	}
+	image := spec.Image
+	switch {
+	case image != "" && !strings.Contains(image, "@") &&
+		(strings.HasSuffix(image, ":latest") || !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":")):
+		c.Rationale = fmt.Sprintf("image %q is not pinned: nodes may run different builds, and rollbacks can't go back; use a version tag or digest", image)
+	case len(latest) > 0:
+		c.Rationale = fmt.Sprintf("version latest for %s: a restart can upgrade the dependency across major versions; pin its version", strings.Join(latest, ", "))
+	case image == "":
+		c.Passed = true
+		c.Rationale = "the image is built by kbox up, which tags each build"
+	case strings.Contains(image, "@"):
+		c.Passed = true
+		c.Rationale = "the image is pinned to a digest, so every pod runs the same, verified build"
+	default:
+		c.Passed = true
+		c.Rationale = fmt.Sprintf("image %q is pinned to a version tag", image)
+	}
+	return c
+}
+
+func checkSecretsInEnv(config *AppConfig) ReadinessCheck {
+	c := ReadinessCheck{ID: "no-secrets-in-env", Category: ReadinessSecurity, Weight: 2, Applicable: true}
+	var fields []string
+	for _, issue := range Lint(config) {
+		if issue.Rule == "secret-in-env" {
+			fields = append(fields, issue.Field)
+		}
+	}
+	if len(fields) == 0 {
+		c.Passed = true
+		c.Rationale = "no env values look like secrets"
+	} else {
+		c.Rationale = fmt.Sprintf("%s look like secrets stored in plain text; move them to spec.secrets", strings.Join(fields, ", "))
+	}
+	return c
+}
+
+func checkIngressTLS(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "ingress-tls", Category: ReadinessSecurity, Weight: 2}
+	ing := spec.Ingress
+	if ing == nil || !ing.Enabled {
+		c.Rationale = "no ingress"
+		return c
+	}
+	c.Applicable = true
+	if ing.TLS != nil && ing.TLS.Enabled {
+		c.Passed = true
+		c.Rationale = "the ingress serves HTTPS"
+	} else {
+		c.Rationale = "the ingress serves plain HTTP; set spec.ingress.tls.enabled"
+	}
+	return c
+}
+
+func checkMetrics(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "metrics", Category: ReadinessObservability, Weight: 2, Applicable: true}
+	if spec.Metrics != nil && spec.Metrics.Enabled {
+		c.Passed = true
+		c.Rationale = "a ServiceMonitor has Prometheus scrape the app's metrics"
+	} else {
+		c.Rationale = "no metrics scraping: set spec.metrics.enabled (or run kbox add metrics) to alert on more than pod restarts"
+	}
+	return c
+}
+
+// tracingEnv are the env vars that point OpenTelemetry SDKs at a collector
+var tracingEnv = []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"}
+
+func checkTracing(spec *AppSpec) ReadinessCheck {
+	c := ReadinessCheck{ID: "tracing", Category: ReadinessObservability, Weight: 1, Applicable: true}
+	for _, name := range tracingEnv {
+		if spec.Env[name] != "" {
+			c.Passed = true
+			c.Rationale = fmt.Sprintf("%s sends traces to %s", name, spec.Env[name])
+			return c
+		}
+	}
+	c.Rationale = "no tracing: set OTEL_EXPORTER_OTLP_ENDPOINT in spec.env to follow requests across services"
+	return c
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// appPolicy is a NetworkPolicy like the one kbox renders for app
+func appPolicy(app string) []*networkingv1.NetworkPolicy {
+	return []*networkingv1.NetworkPolicy{{
+		ObjectMeta: metav1.ObjectMeta{Name: app},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}}},
+			}},
+			Egress: []networkingv1.NetworkPolicyEgressRule{{
+				To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kbox.dev/app": app}}}},
+			}},
+		},
+	}}
+}
+
+func readinessCheck(t *testing.T, report *ReadinessReport, id string) ReadinessCheck {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.ID == id {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", id, report.Checks)
+	return ReadinessCheck{}
+}
+
+func TestReadiness_MinimalConfig(t *testing.T) {
+	cfg := NewDefaultConfig("myapp")
+	cfg.Spec.Image = "myapp:latest"
+
+	report, err := Readiness(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"replicas", "pdb", "probes", "resources", "network-policy", "pinned-image", "metrics", "tracing"} {
+		if c := readinessCheck(t, report, id); c.Passed || c.Rationale == "" {
+			t.Errorf("%s: want failed with a rationale, got %+v", id, c)
+		}
+	}
+	if c := readinessCheck(t, report, "ingress-tls"); c.Applicable {
+		t.Errorf("ingress-tls should not apply without an ingress: %+v", c)
+	}
+	if report.Score >= 50 {
+		t.Errorf("score = %d, want below 50", report.Score)
+	}
+	if len(report.Warnings) == 0 {
+		t.Error("expected the :latest warning")
+	}
+}
+
+func TestReadiness_ProductionConfig(t *testing.T) {
+	cfg := NewDefaultConfig("myapp")
+	cfg.Spec.Image = "myapp:v1.2.3"
+	cfg.Spec.Replicas = 3
+	cfg.Spec.HealthCheck = "/healthz"
+	cfg.Spec.Resources = &ResourceConfig{Memory: "256Mi", CPU: "250m"}
+	cfg.Spec.Metrics = &MetricsConfig{Enabled: true}
+	cfg.Spec.Env = map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://otel-collector:4317"}
+	cfg.Spec.Ingress = &IngressConfig{Enabled: true, Host: "myapp.example.com", TLS: &TLSConfig{Enabled: true}}
+
+	report, err := Readiness(cfg, appPolicy("myapp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Score != 100 {
+		t.Errorf("score = %d, want 100; checks: %+v", report.Score, report.Checks)
+	}
+	for _, category := range ReadinessCategories {
+		if report.Categories[category] != 100 {
+			t.Errorf("%s = %d, want 100", category, report.Categories[category])
+		}
+	}
+}
+
+func TestReadiness_SecurityFailures(t *testing.T) {
+	cfg := NewDefaultConfig("myapp")
+	cfg.Spec.Image = "myapp:v1"
+	root := int64(0)
+	cfg.Spec.SecurityProfile = SecurityProfileBaseline
+	cfg.Spec.SecurityContext = &SecurityContextConfig{RunAsUser: &root}
+	cfg.Spec.Env = map[string]string{"DB_PASSWORD": "hunter2"}
+	cfg.Spec.Ingress = &IngressConfig{Enabled: true, Host: "myapp.example.com"}
+	cfg.Spec.Dependencies = []DependencyConfig{{Type: "postgres", Version: "latest"}}
+	open := appPolicy("myapp")
+	open[0].Spec.Egress = append(open[0].Spec.Egress, networkingv1.NetworkPolicyEgressRule{})
+
+	report, err := Readiness(cfg, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"non-root", "network-policy", "pinned-image", "no-secrets-in-env", "ingress-tls"} {
+		if c := readinessCheck(t, report, id); c.Passed || !c.Applicable {
+			t.Errorf("%s: want failed, got %+v", id, c)
+		}
+	}
+	if c := readinessCheck(t, report, "no-secrets-in-env"); !strings.Contains(c.Rationale, "spec.env.DB_PASSWORD") {
+		t.Errorf("rationale should name the variable: %q", c.Rationale)
+	}
+}
+
+func TestReadiness_BaselineProfile(t *testing.T) {
+	cfg := NewDefaultConfig("myapp")
+	cfg.Spec.Image = "myapp:v1"
+	cfg.Spec.SecurityProfile = SecurityProfileBaseline
+
+	report, err := Readiness(cfg, appPolicy("myapp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := readinessCheck(t, report, "non-root"); c.Passed || !strings.Contains(c.Rationale, "baseline") {
+		t.Errorf("non-root should fail for the baseline profile: %+v", c)
+	}
+}
+
+func TestReadiness_InvalidConfig(t *testing.T) {
+	cfg := NewDefaultConfig("MyApp")
+	if _, err := Readiness(cfg, nil); err == nil {
+		t.Error("expected a validation error")
+	}
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// memoryUnits maps the byte units people write to Kubernetes suffixes. Like
+// Docker, kbox reads KB, MB, GB and TB as binary units.
+var memoryUnits = map[string]string{
+	"b":  "",
+	"k":  "Ki",
+	"kb": "Ki", "ki": "Ki", "kib": "Ki",
+	"mb": "Mi", "mi": "Mi", "mib": "Mi",
+	"g":  "Gi",
+	"gb": "Gi", "gi": "Gi", "gib": "Gi",
+	"t":  "Ti",
+	"tb": "Ti", "ti": "Ti", "tib": "Ti",
+}
+
+var (
+	memoryPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+	cpuPattern    = regexp.MustCompile(`(?i)^([0-9]+(?:\.[0-9]+)?)\s*(cores?|cpus?|vcpus?|millicores?)?$`)
+)
+
+// NormalizeMemory returns a memory size as a Kubernetes quantity, reading
+// friendlier forms like "512MB" or "1.5 GB" as "512Mi" and "1.5Gi".
+// Quantities Kubernetes accepts are returned unchanged, and anything else as
+// written, for Validate to report.
+func NormalizeMemory(value string) string {
+	value = strings.TrimSpace(value)
+	if _, err := resource.ParseQuantity(value); err == nil {
+		return value
+	}
+	m := memoryPattern.FindStringSubmatch(value)
+	if m == nil {
+		return value
+	}
+	suffix, ok := memoryUnits[strings.ToLower(m[2])]
+	if !ok {
+		return value
+	}
+	return m[1] + suffix
+}
+
+// NormalizeCPU returns a CPU amount as a Kubernetes quantity, reading cores
+// written as a fraction ("0.5", "1.5 cores") as millicores ("500m",
+// "1500m") and "2 vCPU" or "250 millicores" as "2" and "250m". Other
+// quantities are returned unchanged, and anything else as written.
+func NormalizeCPU(value string) string {
+	value = strings.TrimSpace(value)
+	m := cpuPattern.FindStringSubmatch(value)
+	if m == nil {
+		return value
+	}
+	if strings.HasPrefix(strings.ToLower(m[2]), "milli") {
+		if strings.Contains(m[1], ".") {
+			return value
+		}
+		return m[1] + "m"
+	}
+	if !strings.Contains(m[1], ".") {
+		return m[1]
+	}
+	cores, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return value
+	}
+	millis := cores * 1000
+	if millis != math.Trunc(millis) {
+		// Finer than Kubernetes' millicore precision
+		return m[1]
+	}
+	if int64(millis)%1000 == 0 {
+		return strconv.FormatInt(int64(millis)/1000, 10)
+	}
+	return strconv.FormatInt(int64(millis), 10) + "m"
+}
+
+// normalize rewrites the resources as Kubernetes quantities
+func (r *ResourceConfig) normalize() {
+	r.Memory = NormalizeMemory(r.Memory)
+	r.MemoryLimit = NormalizeMemory(r.MemoryLimit)
+	r.CPU = NormalizeCPU(r.CPU)
+	r.CPULimit = NormalizeCPU(r.CPULimit)
+}
+
+// quantity is a resource value written as a string or a number
+type quantity string
+
+func (q *quantity) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*q = quantity(number)
+		return nil
+	}
+	return json.Unmarshal(data, (*string)(q))
+}
+
+// UnmarshalJSON accepts numbers as well as strings, e.g. `cpu: 0.5`, and
+// normalizes the friendlier units NormalizeMemory and NormalizeCPU read
+func (r *ResourceConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Memory      quantity `json:"memory"`
+		CPU         quantity `json:"cpu"`
+		MemoryLimit quantity `json:"memoryLimit"`
+		CPULimit    quantity `json:"cpuLimit"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = ResourceConfig{
+		Memory:      string(raw.Memory),
+		CPU:         string(raw.CPU),
+		MemoryLimit: string(raw.MemoryLimit),
+		CPULimit:    string(raw.CPULimit),
+	}
+	r.normalize()
+	return nil
+}
+
+// UnmarshalYAML is UnmarshalJSON for configs decoded as YAML
+func (r *ResourceConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ResourceConfig
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.normalize()
+	return nil
+}
+
+// resourceFields are the ResourceConfig keys and how each is normalized
+var resourceFields = map[string]func(string) string{
+	"memory":      NormalizeMemory,
+	"memoryLimit": NormalizeMemory,
+	"cpu":         NormalizeCPU,
+	"cpuLimit":    NormalizeCPU,
+}
+
+// ReadResourceNormalizations returns the resource values of the config at
+// path that loading it rewrites as Kubernetes quantities, e.g.
+// "spec.resources.memory: 512MB → 512Mi". Errors reading the file are left
+// for the loader to report.
+func ReadResourceNormalizations(path string) []string {
+	data, err := ReadConfig(path)
+	if err != nil {
+		return nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil
+	}
+	root := GetRootDocument(&node)
+	if root == nil {
+		return nil
+	}
+	return resourceNormalizations("", root)
+}
+
+// resourceNormalizations walks node for resources mappings, at any depth, and
+// describes the values normalizing them changes
+func resourceNormalizations(path string, node *yaml.Node) []string {
+	var changes []string
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			field := joinPath(path, key)
+			if key == "resources" && value.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					name, v := value.Content[j].Value, value.Content[j+1]
+					normalize, ok := resourceFields[name]
+					if !ok || v.Kind != yaml.ScalarNode {
+						continue
+					}
+					if normalized := normalize(v.Value); normalized != strings.TrimSpace(v.Value) {
+						changes = append(changes, fmt.Sprintf("%s.%s: %s → %s", field, name, v.Value, normalized))
+					}
+				}
+				continue
+			}
+			changes = append(changes, resourceNormalizations(field, value)...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			changes = append(changes, resourceNormalizations(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	}
+	return changes
+}
+
+// Below this much memory a request or limit is almost certainly missing its
+// unit, e.g. "512" (bytes) or "512m" (millibytes) for 512Mi
+var minSensibleMemory = resource.MustParse("4Mi")
+
+// Above this many cores a CPU value is almost certainly missing its "m",
+// e.g. "500" for 500m
+var maxSensibleCPU = resource.MustParse("128")
+
+// resourceWarnings flags resource values that are valid quantities but
+// unlikely to be what was meant
+func resourceWarnings(res *ResourceConfig, field string) []string {
+	if res == nil {
+		return nil
+	}
+	var warnings []string
+	for _, check := range []struct {
+		value, name string
+	}{{res.Memory, "memory"}, {res.MemoryLimit, "memoryLimit"}} {
+		q, err := resource.ParseQuantity(check.value)
+		if err == nil && !q.IsZero() && q.Cmp(minSensibleMemory) < 0 {
+			warnings = append(warnings, fmt.Sprintf("%s.%s %s is only %d bytes - did you mean %sMi?", field, check.name, check.value, q.Value(), strings.TrimRight(check.value, "m")))
+		}
+	}
+	for _, check := range []struct {
+		value, name string
+	}{{res.CPU, "cpu"}, {res.CPULimit, "cpuLimit"}} {
+		q, err := resource.ParseQuantity(check.value)
+		if err == nil && q.Cmp(maxSensibleCPU) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s.%s %s is %s cores - did you mean %sm?", field, check.name, check.value, q.String(), check.value))
+		}
+	}
+	return warnings
+}
+
+// resourcesWarnings flags the unlikely resource values of the spec, its
+// processes and its dependencies
+func resourcesWarnings(spec *AppSpec) []string {
+	warnings := resourceWarnings(spec.Resources, "spec.resources")
+	for i, dep := range spec.Dependencies {
+		warnings = append(warnings, resourceWarnings(dep.Resources, fmt.Sprintf("spec.dependencies[%d].resources", i))...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(spec.Processes)) {
+		warnings = append(warnings, resourceWarnings(spec.Processes[name].Resources, "spec.processes."+name+".resources")...)
+	}
+	return warnings
+}
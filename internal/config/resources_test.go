@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMemory(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"512Mi", "512Mi"},
+		{"1G", "1G"},
+		{"512MB", "512Mi"},
+		{"512mb", "512Mi"},
+		{"1.5 GB", "1.5Gi"},
+		{"2GiB", "2Gi"},
+		{"512 Mi", "512Mi"},
+		{"1024B", "1024"},
+		{"lots", "lots"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMemory(tt.in); got != tt.want {
+			t.Errorf("NormalizeMemory(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCPU(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"100m", "100m"},
+		{"2", "2"},
+		{"0.5", "500m"},
+		{"1.5 cores", "1500m"},
+		{"2 vCPU", "2"},
+		{"1.0", "1"},
+		{"250 millicores", "250m"},
+		{"0.0005", "0.0005"},
+		{"fast", "fast"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCPU(tt.in); got != tt.want {
+			t.Errorf("NormalizeCPU(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFile_NormalizesResources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kbox.yaml")
+	content := `apiVersion: kbox.dev/v1
+kind: App
+metadata:
+  name: testapp
+spec:
+  image: testapp:v1
+  resources:
+    memory: 512MB
+    memoryLimit: 1GB
+    cpu: 0.5
+    cpuLimit: 1
+  dependencies:
+    - type: redis
+      resources:
+        memory: 128 MB
+environments:
+  prod:
+    resources:
+      cpu: 1.5
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoader(dir).LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := ResourceConfig{Memory: "512Mi", MemoryLimit: "1Gi", CPU: "500m", CPULimit: "1"}
+	if *cfg.Spec.Resources != want {
+		t.Errorf("resources = %+v, want %+v", *cfg.Spec.Resources, want)
+	}
+	if got := cfg.Spec.Dependencies[0].Resources.Memory; got != "128Mi" {
+		t.Errorf("dependency memory = %q, want 128Mi", got)
+	}
+	if got := cfg.ForEnvironment("prod").Spec.Resources.CPU; got != "1500m" {
+		t.Errorf("prod cpu = %q, want 1500m", got)
+	}
+
+	changes := ReadResourceNormalizations(path)
+	for _, want := range []string{
+		"spec.resources.memory: 512MB → 512Mi",
+		"spec.resources.cpu: 0.5 → 500m",
+		"spec.dependencies[0].resources.memory: 128 MB → 128Mi",
+		"environments.prod.resources.cpu: 1.5 → 1500m",
+	} {
+		if !slices.Contains(changes, want) {
+			t.Errorf("normalizations %q missing %q", changes, want)
+		}
+	}
+	if slices.ContainsFunc(changes, func(c string) bool { return strings.Contains(c, "cpuLimit") }) {
+		t.Errorf("cpuLimit 1 is already a quantity, got %q", changes)
+	}
+}
+
+func TestValidateWithWarnings_UnlikelyResources(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image:     "myapp:v1",
+			Resources: &ResourceConfig{Memory: "512m", CPU: "500"},
+		},
+	}
+	warnings, err := ValidateWithWarnings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"spec.resources.memory 512m", "did you mean 512Mi?", "spec.resources.cpu 500", "did you mean 500m?"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings %q missing %q", warnings, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import "fmt"
+
+// RolloutConfig tunes how a deploy replaces the app's pods. The defaults
+// are Kubernetes': up to 25% extra pods (maxSurge) and 25% unavailable.
+// Namespaces with tight quotas want maxSurge: 0 and maxUnavailable: 1, so a
+// rollout never needs room for more pods than the app already runs.
+type RolloutConfig struct {
+	// MaxSurge is how many pods above the replica count may exist during a
+	// rollout, a count or percentage (default: 25%)
+	MaxSurge string `yaml:"maxSurge,omitempty" json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is how many pods may be unavailable during a rollout,
+	// a count or percentage (default: 25%)
+	MaxUnavailable string `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+
+	// MinReadySeconds a new pod must stay ready before it counts as
+	// available (default: 0)
+	MinReadySeconds int `yaml:"minReadySeconds,omitempty" json:"minReadySeconds,omitempty"`
+
+	// ProgressDeadlineSeconds after which a stalled rollout is reported as
+	// failed (default: 600)
+	ProgressDeadlineSeconds int `yaml:"progressDeadlineSeconds,omitempty" json:"progressDeadlineSeconds,omitempty"`
+
+	// RevisionHistoryLimit is how many old ReplicaSets to keep for
+	// kubectl rollout undo (default: 10)
+	RevisionHistoryLimit *int `yaml:"revisionHistoryLimit,omitempty" json:"revisionHistoryLimit,omitempty"`
+}
+
+func validateRollout(spec *AppSpec) ValidationErrors {
+	r := spec.Rollout
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	zero := 0
+	for _, f := range []struct{ value, field string }{
+		{r.MaxSurge, "spec.rollout.maxSurge"},
+		{r.MaxUnavailable, "spec.rollout.maxUnavailable"},
+	} {
+		if f.value == "" {
+			continue
+		}
+		v, err := parseCountOrPercent(f.value)
+		if err != nil {
+			errs = append(errs, ValidationError{Field: f.field, Message: err.Error()})
+			continue
+		}
+		if v.String() == "0" || v.String() == "0%" {
+			zero++
+		}
+	}
+	if zero == 2 {
+		errs = append(errs, ValidationError{
+			Field:   "spec.rollout",
+			Message: "maxSurge and maxUnavailable can't both be 0: the rollout could never replace a pod",
+		})
+	}
+	if r.MinReadySeconds < 0 {
+		errs = append(errs, ValidationError{Field: "spec.rollout.minReadySeconds", Message: "must not be negative"})
+	}
+	if r.ProgressDeadlineSeconds < 0 {
+		errs = append(errs, ValidationError{Field: "spec.rollout.progressDeadlineSeconds", Message: "must not be negative"})
+	} else if r.ProgressDeadlineSeconds > 0 && r.ProgressDeadlineSeconds <= r.MinReadySeconds {
+		errs = append(errs, ValidationError{
+			Field:   "spec.rollout.progressDeadlineSeconds",
+			Message: fmt.Sprintf("must be greater than minReadySeconds (%d)", r.MinReadySeconds),
+		})
+	}
+	if r.RevisionHistoryLimit != nil && *r.RevisionHistoryLimit < 0 {
+		errs = append(errs, ValidationError{Field: "spec.rollout.revisionHistoryLimit", Message: "must not be negative"})
+	}
+	if spec.IsStatefulSet() && (r.MaxSurge != "" || r.MaxUnavailable != "" || r.ProgressDeadlineSeconds != 0) {
+		errs = append(errs, ValidationError{
+			Field:   "spec.rollout",
+			Message: "a statefulset replaces its pods one at a time; only minReadySeconds and revisionHistoryLimit apply",
+		})
+	}
+	return errs
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+)
+
 // AppConfig represents the full kbox.yaml configuration
 type AppConfig struct {
 	APIVersion   string            `yaml:"apiVersion" json:"apiVersion"`
@@ -7,6 +12,54 @@ type AppConfig struct {
 	Metadata     Metadata          `yaml:"metadata" json:"metadata"`
 	Spec         AppSpec           `yaml:"spec" json:"spec"`
 	Environments map[string]EnvOverride `yaml:"environments,omitempty" json:"environments,omitempty"`
+	Clusters     []ClusterConfig        `yaml:"clusters,omitempty" json:"clusters,omitempty"`
+	Notifications *NotificationsConfig  `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+
+	// MinKboxVersion is the oldest kbox the config is meant for (e.g. "v1.4");
+	// older clients warn
+	MinKboxVersion string `yaml:"minKboxVersion,omitempty" json:"minKboxVersion,omitempty"`
+}
+
+// NotificationsConfig posts deploy, rollback and preview events to chat or webhooks
+type NotificationsConfig struct {
+	// Enabled turns notifications off when false (default: true); --notify/--no-notify override it
+	Enabled *bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// Slack posts to a Slack incoming webhook
+	Slack *WebhookConfig `yaml:"slack,omitempty" json:"slack,omitempty"`
+
+	// Teams posts to a Microsoft Teams incoming webhook
+	Teams *WebhookConfig `yaml:"teams,omitempty" json:"teams,omitempty"`
+
+	// Webhook posts the event as JSON to any URL
+	Webhook *WebhookConfig `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+
+	// Events limits which events are sent: deploy, rollback, preview (default: all)
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Template is a Go template for the message text (e.g., "{{.App}} {{.Status}}")
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+// WebhookConfig is an incoming webhook endpoint
+type WebhookConfig struct {
+	// URL of the webhook; ${VAR} references are expanded from the environment
+	URL string `yaml:"url" json:"url"`
+}
+
+// ClusterConfig is one cluster of a multi-cluster fleet (used by --all-clusters)
+type ClusterConfig struct {
+	// Name identifies the cluster in output (e.g., "us-east")
+	Name string `yaml:"name" json:"name"`
+
+	// Context is the kubeconfig context for the cluster
+	Context string `yaml:"context" json:"context"`
+
+	// Region is informational (e.g., "us-east-1")
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
+	// Weight is the cluster's relative traffic share, reported for external load balancers
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
 }
 
 // Metadata contains app identification
@@ -14,6 +67,9 @@ type Metadata struct {
 	Name      string            `yaml:"name" json:"name"`
 	Namespace string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
 	Labels    map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Annotations are set on every object kbox renders for the app
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
 }
 
 // AppSpec defines the application specification
@@ -24,15 +80,25 @@ type AppSpec struct {
 	// Build configuration for building images
 	Build *BuildConfig `yaml:"build,omitempty" json:"build,omitempty"`
 
+	// Signing configures how kbox up signs the image it builds and how
+	// kbox verify and kbox deploy check the image's signature
+	Signing *SigningConfig `yaml:"signing,omitempty" json:"signing,omitempty"`
+
 	// Port the application listens on (default: 8080)
 	Port int `yaml:"port,omitempty" json:"port,omitempty"`
 
-	// Replicas count (default: 1)
+	// Replicas count (default: 1). Ignored when autoscaling is enabled: the
+	// HPA owns the count, so deploys leave it alone
 	Replicas int `yaml:"replicas,omitempty" json:"replicas,omitempty"`
 
-	// Env variables
+	// Env variables. Values may use the helpers ${b64:...}, ${file:./path}
+	// and ${json:./file.json#.key}, evaluated at render time.
 	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
 
+	// EnvFromFile loads env variables from a YAML or JSON map; env wins
+	// over it for variables set in both
+	EnvFromFile string `yaml:"envFromFile,omitempty" json:"envFromFile,omitempty"`
+
 	// Secrets configuration
 	Secrets *SecretsConfig `yaml:"secrets,omitempty" json:"secrets,omitempty"`
 
@@ -48,6 +114,9 @@ type AppSpec struct {
 	// Ingress configuration
 	Ingress *IngressConfig `yaml:"ingress,omitempty" json:"ingress,omitempty"`
 
+	// Traffic splits the ingress traffic with a second Deployment
+	Traffic *TrafficConfig `yaml:"traffic,omitempty" json:"traffic,omitempty"`
+
 	// Include raw manifest files
 	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
 
@@ -78,8 +147,112 @@ type AppSpec struct {
 	// PDB configuration for PodDisruptionBudget
 	PDB *PDBConfig `yaml:"pdb,omitempty" json:"pdb,omitempty"`
 
+	// Rollout tunes the rolling update: surge, unavailability, readiness
+	// and history
+	Rollout *RolloutConfig `yaml:"rollout,omitempty" json:"rollout,omitempty"`
+
+	// Annotations per kind of object, on top of metadata.annotations
+	Annotations *ResourceMetadata `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+
+	// Labels per kind of object, on top of metadata.labels
+	Labels *ResourceMetadata `yaml:"labels,omitempty" json:"labels,omitempty"`
+
 	// Metrics configuration for Prometheus ServiceMonitor
 	Metrics *MetricsConfig `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+
+	// Tests are smoke tests run after rollout as a go/no-go gate
+	Tests []SmokeTestConfig `yaml:"tests,omitempty" json:"tests,omitempty"`
+
+	// SleepSchedule scales the app to zero and back on a schedule
+	SleepSchedule *SleepScheduleConfig `yaml:"sleepSchedule,omitempty" json:"sleepSchedule,omitempty"`
+
+	// Share configures the tunnel used by 'kbox share'
+	Share *ShareConfig `yaml:"share,omitempty" json:"share,omitempty"`
+
+	// RollOnConfigChange restarts pods when their ConfigMaps or Secrets change (default: true)
+	RollOnConfigChange *bool `yaml:"rollOnConfigChange,omitempty" json:"rollOnConfigChange,omitempty"`
+
+	// ConfigVersioning renders ConfigMaps and Secrets as immutable objects
+	// named after a hash of their content, so each release keeps its own copy
+	ConfigVersioning bool `yaml:"configVersioning,omitempty" json:"configVersioning,omitempty"`
+
+	// Workload runs the app as a Deployment (default) or a StatefulSet.
+	// StatefulSet replicas get stable names and their own volumes.
+	Workload string `yaml:"workload,omitempty" json:"workload,omitempty"`
+
+	// Processes run the app image as more than one process type, like a
+	// Procfile. "web" customizes the app's own Deployment, which serves
+	// traffic; every other process (worker, scheduler, ...) gets its own
+	// Deployment without a Service or Ingress.
+	Processes map[string]ProcessConfig `yaml:"processes,omitempty" json:"processes,omitempty"`
+
+	// SecurityContext overrides the hardened pod and container defaults
+	SecurityContext *SecurityContextConfig `yaml:"securityContext,omitempty" json:"securityContext,omitempty"`
+
+	// SecurityProfile is the Pod Security Standard the app's pods meet:
+	// restricted (default) or baseline
+	SecurityProfile string `yaml:"securityProfile,omitempty" json:"securityProfile,omitempty"`
+
+	// Platform is the node OS to run on: linux (default) or windows
+	Platform string `yaml:"platform,omitempty" json:"platform,omitempty"`
+
+	// Arch is the node CPU architecture to run on: amd64 or arm64 (default: any)
+	Arch string `yaml:"arch,omitempty" json:"arch,omitempty"`
+
+	// Mesh is the service mesh whose sidecar the pods get: istio, linkerd,
+	// or none to opt out of a namespace's automatic injection
+	Mesh *MeshConfig `yaml:"mesh,omitempty" json:"mesh,omitempty"`
+}
+
+// WebProcess is the process type that serves traffic
+const WebProcess = "web"
+
+// ProcessConfig is one process type of the app
+type ProcessConfig struct {
+	// Command to run (required except for web, which defaults to spec.command)
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Args for the command
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// Replicas for this process (defaults to 1; web defaults to spec.replicas)
+	Replicas *int `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+
+	// Resources for this process (defaults to spec.resources)
+	Resources *ResourceConfig `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// Workload kinds for AppSpec.Workload
+const (
+	WorkloadDeployment  = "deployment"
+	WorkloadStatefulSet = "statefulset"
+)
+
+// IsStatefulSet reports whether the app runs as a StatefulSet
+func (s *AppSpec) IsStatefulSet() bool {
+	return s.Workload == WorkloadStatefulSet
+}
+
+// HasEnv reports whether the app sets env variables, in env or envFromFile
+func (s *AppSpec) HasEnv() bool {
+	return len(s.Env) > 0 || s.EnvFromFile != ""
+}
+
+// RollsOnConfigChange reports whether config and secret changes should roll the pods
+func (s *AppSpec) RollsOnConfigChange() bool {
+	return s.RollOnConfigChange == nil || *s.RollOnConfigChange
+}
+
+// ShareConfig configures public URLs created by 'kbox share'
+type ShareConfig struct {
+	// Provider is the tunnel provider: ngrok (default), cloudflared, or localtunnel
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// Hostname is a custom hostname (cloudflared named tunnel) or subdomain (localtunnel)
+	Hostname string `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+
+	// Tunnel is the cloudflared named tunnel to run (required for a custom hostname)
+	Tunnel string `yaml:"tunnel,omitempty" json:"tunnel,omitempty"`
 }
 
 // DependencyConfig defines a managed dependency like postgres or redis
@@ -95,6 +268,37 @@ type DependencyConfig struct {
 
 	// Resources for the dependency container
 	Resources *ResourceConfig `yaml:"resources,omitempty" json:"resources,omitempty"`
+
+	// Config tunes the server, passed as its command-line options:
+	// postgresql.conf settings for postgres (max_connections), redis.conf
+	// directives for redis (maxmemory-policy), and mysqld or mongod options
+	// (character-set-server, wiredTigerCacheSizeGB)
+	Config map[string]string `yaml:"config,omitempty" json:"config,omitempty"`
+
+	// Env variables for the dependency's container, e.g. an image's own
+	// settings such as POSTGRES_INITDB_ARGS
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// External points the app at a server kbox doesn't run (e.g. RDS or
+	// Cloud SQL) instead of deploying one in the cluster
+	External *ExternalDependencyConfig `yaml:"external,omitempty" json:"external,omitempty"`
+}
+
+// ExternalDependencyConfig is a dependency's server outside the cluster.
+// The connection env vars (PGHOST, PGPORT, ...) are rendered from Host and
+// Port; credentials and URLs come from the SecretRef Secret's keys of the
+// same names (PGUSER, PGPASSWORD, DATABASE_URL, ...). Without a Host, every
+// env var comes from the Secret.
+type ExternalDependencyConfig struct {
+	// Host is the server's hostname or IP
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+
+	// Port is the server's port (default: the dependency's usual port)
+	Port int32 `yaml:"port,omitempty" json:"port,omitempty"`
+
+	// SecretRef is an existing Secret in the app's namespace with the
+	// connection env vars as keys
+	SecretRef string `yaml:"secretRef,omitempty" json:"secretRef,omitempty"`
 }
 
 // VolumeConfig defines a volume mount for the app
@@ -150,12 +354,6 @@ type AutoscalingConfig struct {
 	TargetCPUUtilization int  `yaml:"targetCPUUtilization,omitempty" json:"targetCPUUtilization,omitempty"`
 }
 
-// PDBConfig defines PodDisruptionBudget settings
-type PDBConfig struct {
-	MinAvailable   string `yaml:"minAvailable,omitempty" json:"minAvailable,omitempty"`
-	MaxUnavailable string `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
-}
-
 // MetricsConfig configures Prometheus metrics and ServiceMonitor generation
 type MetricsConfig struct {
 	// Enabled creates a ServiceMonitor for Prometheus scraping
@@ -188,6 +386,10 @@ type JobConfig struct {
 	// Schedule in cron format (makes this a CronJob)
 	Schedule string `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 
+	// TimeZone the schedule is in, e.g. "Europe/Berlin" (default: the
+	// cluster's, usually UTC)
+	TimeZone string `yaml:"timeZone,omitempty" json:"timeZone,omitempty"`
+
 	// RunBefore specifies when to run (e.g., "deploy" for pre-deploy hooks)
 	RunBefore string `yaml:"runBefore,omitempty" json:"runBefore,omitempty"`
 
@@ -199,6 +401,64 @@ type JobConfig struct {
 
 	// TTLSecondsAfterFinished limits the lifetime of finished jobs
 	TTLSecondsAfterFinished *int32 `yaml:"ttlSecondsAfterFinished,omitempty" json:"ttlSecondsAfterFinished,omitempty"`
+
+	// HistoryLimit is how many finished runs to keep (default 5). Older runs
+	// from 'kbox job run' and 'kbox job retry' are deleted; for a CronJob it
+	// caps both its successful and failed Jobs.
+	HistoryLimit *int32 `yaml:"historyLimit,omitempty" json:"historyLimit,omitempty"`
+}
+
+// DefaultJobHistoryLimit is the number of finished runs kept per job
+const DefaultJobHistoryLimit = 5
+
+// RunsToKeep returns the job's history limit
+func (j *JobConfig) RunsToKeep() int32 {
+	if j.HistoryLimit != nil {
+		return *j.HistoryLimit
+	}
+	return DefaultJobHistoryLimit
+}
+
+// SmokeTestConfig defines a post-deploy check. Exactly one of HTTP or Exec must be set.
+type SmokeTestConfig struct {
+	// Name of the test (shown in output)
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// HTTP performs a request against the app through a port-forward
+	HTTP *HTTPTestConfig `yaml:"http,omitempty" json:"http,omitempty"`
+
+	// Exec runs a command inside a ready app container
+	Exec *ExecTestConfig `yaml:"exec,omitempty" json:"exec,omitempty"`
+
+	// Timeout for the test including retries (default: 30s)
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// HTTPTestConfig defines an HTTP smoke test
+type HTTPTestConfig struct {
+	// Path to request (default: /)
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Port on the container (default: app port)
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+
+	// Method is the HTTP method (default: GET)
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+
+	// ExpectStatus is the expected response status (default: 200)
+	ExpectStatus int `yaml:"expectStatus,omitempty" json:"expectStatus,omitempty"`
+
+	// ExpectBody is a substring the response body must contain
+	ExpectBody string `yaml:"expectBody,omitempty" json:"expectBody,omitempty"`
+}
+
+// ExecTestConfig defines a command smoke test
+type ExecTestConfig struct {
+	// Command to run; the test passes when it exits 0
+	Command []string `yaml:"command" json:"command"`
+
+	// ExpectOutput is a substring stdout must contain
+	ExpectOutput string `yaml:"expectOutput,omitempty" json:"expectOutput,omitempty"`
 }
 
 // BuildConfig defines how to build the image
@@ -214,8 +474,33 @@ type BuildConfig struct {
 
 	// Args for build-time variables
 	Args map[string]string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// Platforms builds a multi-arch image (manifest list) for these os/arch
+	// pairs, e.g. [linux/amd64, linux/arm64]. Requires docker buildx and a
+	// registry to push to.
+	Platforms []string `yaml:"platforms,omitempty" json:"platforms,omitempty"`
+
+	// TagStrategy is how kbox up tags the image it builds: gitsha (the
+	// commit), timestamp (the build time), or semver (the latest git tag,
+	// e.g. v1.4.2). Default: a hash of the build context.
+	TagStrategy string `yaml:"tagStrategy,omitempty" json:"tagStrategy,omitempty"`
+
+	// Repository kbox up pushes the image to, e.g. ghcr.io/acme/api. Signing
+	// needs it, as signatures are stored in the registry next to the image.
+	Repository string `yaml:"repository,omitempty" json:"repository,omitempty"`
+
+	// SBOM generates an SPDX software bill of materials of the image with
+	// syft, and attaches it as a signed attestation when signing is set up
+	SBOM bool `yaml:"sbom,omitempty" json:"sbom,omitempty"`
 }
 
+// Tag strategies for images built by kbox up (build.tagStrategy)
+const (
+	TagStrategyGitSHA    = "gitsha"
+	TagStrategyTimestamp = "timestamp"
+	TagStrategySemver    = "semver"
+)
+
 // SecretsConfig defines secret sources
 type SecretsConfig struct {
 	// FromEnvFile loads secrets from a .env file (simple, v0.1)
@@ -242,7 +527,7 @@ type ResourceConfig struct {
 
 // ServiceConfig defines service configuration
 type ServiceConfig struct {
-	// Type of service (ClusterIP, NodePort, LoadBalancer)
+	// Type of service (ClusterIP, NodePort, LoadBalancer, ExternalName)
 	Type string `yaml:"type,omitempty" json:"type,omitempty"`
 
 	// Port to expose (default: same as app port)
@@ -250,6 +535,98 @@ type ServiceConfig struct {
 
 	// TargetPort on the container (default: app port)
 	TargetPort int `yaml:"targetPort,omitempty" json:"targetPort,omitempty"`
+
+	// ClusterIP "None" makes a headless ClusterIP Service: DNS returns the
+	// pod IPs instead of a virtual IP. The API server doesn't allow changing
+	// it on an existing Service, so it must be deleted first.
+	ClusterIP string `yaml:"clusterIP,omitempty" json:"clusterIP,omitempty"`
+
+	// ExternalName is the DNS name an ExternalName Service is an alias (CNAME) for
+	ExternalName string `yaml:"externalName,omitempty" json:"externalName,omitempty"`
+
+	// SessionAffinity sends each client to the same pod (None or ClientIP)
+	SessionAffinity string `yaml:"sessionAffinity,omitempty" json:"sessionAffinity,omitempty"`
+
+	// SessionAffinityTimeout is how long, in seconds, a ClientIP affinity
+	// lasts (default: 10800)
+	SessionAffinityTimeout int32 `yaml:"sessionAffinityTimeout,omitempty" json:"sessionAffinityTimeout,omitempty"`
+
+	// ExternalTrafficPolicy Local keeps external traffic on the node it
+	// arrives at, preserving client IPs (NodePort and LoadBalancer only)
+	ExternalTrafficPolicy string `yaml:"externalTrafficPolicy,omitempty" json:"externalTrafficPolicy,omitempty"`
+
+	// Annotations for the Service, e.g. a cloud provider's internal load
+	// balancer annotation
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+
+	// DNS publishes a record for the Service's load balancer through external-dns
+	DNS *ServiceDNSConfig `yaml:"dns,omitempty" json:"dns,omitempty"`
+
+	// Ports are more ports the Service exposes alongside the app's, e.g. a
+	// game server's UDP port
+	Ports []ServicePortConfig `yaml:"ports,omitempty" json:"ports,omitempty"`
+
+	// HealthCheck is what a LoadBalancer's health checks probe. Cloud load
+	// balancers can't health check UDP, so with UDP ports it defaults to the
+	// app's port (at healthCheck's path, if set).
+	HealthCheck *ServiceHealthCheckConfig `yaml:"healthCheck,omitempty" json:"healthCheck,omitempty"`
+}
+
+// ServicePortConfig is a non-HTTP port of the Service
+type ServicePortConfig struct {
+	// Name of the port (default: <protocol>-<port>, e.g. udp-7777)
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Port the Service listens on
+	Port int `yaml:"port" json:"port"`
+
+	// TargetPort on the container (default: port)
+	TargetPort int `yaml:"targetPort,omitempty" json:"targetPort,omitempty"`
+
+	// Protocol is TCP (default) or UDP
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+}
+
+// PortName returns the port's name, defaulting to <protocol>-<port>
+func (p ServicePortConfig) PortName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s-%d", strings.ToLower(p.PortProtocol()), p.Port)
+}
+
+// PortProtocol returns the port's protocol, defaulting to TCP
+func (p ServicePortConfig) PortProtocol() string {
+	if p.Protocol == "" {
+		return "TCP"
+	}
+	return p.Protocol
+}
+
+// ContainerPort returns the port on the container, defaulting to Port
+func (p ServicePortConfig) ContainerPort() int {
+	if p.TargetPort != 0 {
+		return p.TargetPort
+	}
+	return p.Port
+}
+
+// ServiceHealthCheckConfig is a LoadBalancer's health check
+type ServiceHealthCheckConfig struct {
+	// Port is the Service's TCP port to probe (default: the app's)
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+
+	// Path makes it an HTTP check; without one it's a TCP connect
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// ServiceDNSConfig is the record external-dns creates for a Service
+type ServiceDNSConfig struct {
+	// Hostname to point at the Service (e.g. api.example.com)
+	Hostname string `yaml:"hostname" json:"hostname"`
+
+	// TTL of the record in seconds (default: external-dns's)
+	TTL int `yaml:"ttl,omitempty" json:"ttl,omitempty"`
 }
 
 // IngressConfig defines ingress configuration
@@ -271,6 +648,10 @@ type IngressConfig struct {
 
 	// Annotations for the ingress
 	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+
+	// DNS has external-dns create the record for Host, pointing at the
+	// ingress's load balancer
+	DNS bool `yaml:"dns,omitempty" json:"dns,omitempty"`
 }
 
 // TLSConfig for ingress TLS
@@ -285,13 +666,71 @@ type TLSConfig struct {
 	ClusterIssuer string `yaml:"clusterIssuer,omitempty" json:"clusterIssuer,omitempty"`
 }
 
-// OverrideConfig allows overriding generated resources
+// OverrideConfig patches generated resources after rendering, for settings
+// kbox.yaml has no field for
 type OverrideConfig struct {
-	// Deployment overrides merged into generated deployment
+	// Deployment is a strategic merge patch for the app's Deployment,
+	// shorthand for a patch targeting it
 	Deployment map[string]interface{} `yaml:"deployment,omitempty" json:"deployment,omitempty"`
 
-	// Service overrides merged into generated service
+	// Service is a strategic merge patch for the app's Service
 	Service map[string]interface{} `yaml:"service,omitempty" json:"service,omitempty"`
+
+	// Patches apply, in order, to any rendered object they target
+	Patches []PatchConfig `yaml:"patches,omitempty" json:"patches,omitempty"`
+}
+
+// PatchConfig is a patch for the rendered objects matching its target
+type PatchConfig struct {
+	// Target selects the objects to patch
+	Target PatchTarget `yaml:"target" json:"target"`
+
+	// StrategicMerge is a strategic merge patch, as kubectl patch applies:
+	// lists of containers, env, ports, ... merge by name
+	StrategicMerge map[string]interface{} `yaml:"strategicMerge,omitempty" json:"strategicMerge,omitempty"`
+
+	// JSONPatch is an RFC 6902 JSON patch
+	JSONPatch []JSONPatchOperation `yaml:"jsonPatch,omitempty" json:"jsonPatch,omitempty"`
+}
+
+// PatchTarget selects rendered objects by kind and, optionally, name
+type PatchTarget struct {
+	// Kind of the objects, e.g. "Deployment"
+	Kind string `yaml:"kind" json:"kind"`
+
+	// Name of the object (default: every object of the kind)
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+}
+
+// JSONPatchOperation is one operation of an RFC 6902 JSON patch
+type JSONPatchOperation struct {
+	// Op is add, remove, replace, move, copy, or test
+	Op string `yaml:"op" json:"op"`
+
+	// Path is a JSON pointer, e.g. /spec/template/spec/dnsPolicy
+	Path string `yaml:"path" json:"path"`
+
+	// Value for add, replace, and test
+	Value interface{} `yaml:"value,omitempty" json:"value,omitempty"`
+
+	// From for move and copy
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+}
+
+// AllPatches returns the overrides as patches: the deployment and service
+// shorthands first, then Patches
+func (o *OverrideConfig) AllPatches(app string) []PatchConfig {
+	if o == nil {
+		return nil
+	}
+	var patches []PatchConfig
+	if len(o.Deployment) > 0 {
+		patches = append(patches, PatchConfig{Target: PatchTarget{Kind: "Deployment", Name: app}, StrategicMerge: o.Deployment})
+	}
+	if len(o.Service) > 0 {
+		patches = append(patches, PatchConfig{Target: PatchTarget{Kind: "Service", Name: app}, StrategicMerge: o.Service})
+	}
+	return append(patches, o.Patches...)
 }
 
 // EnvOverride defines environment-specific overrides
@@ -310,6 +749,29 @@ type EnvOverride struct {
 
 	// Ingress override
 	Ingress *IngressConfig `yaml:"ingress,omitempty" json:"ingress,omitempty"`
+
+	// SleepSchedule override, e.g. to sleep only dev and staging overnight
+	SleepSchedule *SleepScheduleConfig `yaml:"sleepSchedule,omitempty" json:"sleepSchedule,omitempty"`
+
+	// Context binds the environment to a kubeconfig context (e.g., "prod-cluster")
+	Context string `yaml:"context,omitempty" json:"context,omitempty"`
+
+	// Namespace binds the environment to a namespace
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// Protected requires confirmation (or --yes) before deploying
+	Protected bool `yaml:"protected,omitempty" json:"protected,omitempty"`
+
+	// Overlay is the environment's spec overrides as written in kbox.yaml,
+	// which may set any spec field, not only those above
+	Overlay map[string]interface{} `yaml:"-" json:"-"`
+}
+
+// EnvTarget is the cluster binding of an environment
+type EnvTarget struct {
+	Context   string
+	Namespace string
+	Protected bool
 }
 
 // MultiServiceConfig represents a multi-service kbox.yaml configuration
@@ -319,12 +781,41 @@ type MultiServiceConfig struct {
 	Metadata     Metadata                      `yaml:"metadata" json:"metadata"`
 	Services     map[string]ServiceSpec        `yaml:"services" json:"services"`
 	Environments map[string]MultiEnvOverride   `yaml:"environments,omitempty" json:"environments,omitempty"`
+	Clusters     []ClusterConfig               `yaml:"clusters,omitempty" json:"clusters,omitempty"`
+	Notifications *NotificationsConfig         `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	MinKboxVersion string                      `yaml:"minKboxVersion,omitempty" json:"minKboxVersion,omitempty"`
+	SharedSecrets map[string]SharedSecretConfig `yaml:"sharedSecrets,omitempty" json:"sharedSecrets,omitempty"`
+}
+
+// SharedSecretConfig is a Secret of generated values, such as a token
+// services use to call each other, that kbox creates on the first deploy and
+// keeps until it's rotated
+type SharedSecretConfig struct {
+	// Keys are generated, and injected into each of the services as env vars
+	// of the same name
+	Keys []string `yaml:"keys" json:"keys"`
+
+	// Services the keys are injected into
+	Services []string `yaml:"services" json:"services"`
+
+	// RotateEvery regenerates the keys on the first deploy after this long,
+	// e.g. 720h, rolling the services (default: only with --rotate-secret)
+	RotateEvery string `yaml:"rotateEvery,omitempty" json:"rotateEvery,omitempty"`
 }
 
 // MultiEnvOverride defines environment-specific overrides for multi-service apps
 type MultiEnvOverride struct {
 	// Services contains per-service overrides
 	Services map[string]ServiceEnvOverride `yaml:"services,omitempty" json:"services,omitempty"`
+
+	// Context binds the environment to a kubeconfig context
+	Context string `yaml:"context,omitempty" json:"context,omitempty"`
+
+	// Namespace binds the environment to a namespace
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// Protected requires confirmation (or --yes) before deploying
+	Protected bool `yaml:"protected,omitempty" json:"protected,omitempty"`
 }
 
 // ServiceEnvOverride defines environment-specific overrides for a single service
@@ -340,6 +831,10 @@ type ServiceEnvOverride struct {
 
 	// Image override
 	Image string `yaml:"image,omitempty" json:"image,omitempty"`
+
+	// Overlay is the service's overrides as written in kbox.yaml, which may
+	// set any service field, not only those above
+	Overlay map[string]interface{} `yaml:"-" json:"-"`
 }
 
 // ServiceSpec defines a single service in a multi-service app
@@ -347,6 +842,10 @@ type ServiceSpec struct {
 	// Build configuration for building images
 	Build *BuildConfig `yaml:"build,omitempty" json:"build,omitempty"`
 
+	// Signing configures how kbox up signs the service's image after pushing
+	// it to build.repository
+	Signing *SigningConfig `yaml:"signing,omitempty" json:"signing,omitempty"`
+
 	// Image is the container image
 	Image string `yaml:"image,omitempty" json:"image,omitempty"`
 
@@ -356,12 +855,28 @@ type ServiceSpec struct {
 	// Replicas count
 	Replicas int `yaml:"replicas,omitempty" json:"replicas,omitempty"`
 
-	// Env variables
+	// Env variables, which may use the same helpers as spec.env
 	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
 
+	// EnvFromFile loads env variables from a YAML or JSON map
+	EnvFromFile string `yaml:"envFromFile,omitempty" json:"envFromFile,omitempty"`
+
+	// WatchPaths are paths, besides build.context and envFromFile, whose
+	// changes affect the service for kbox deploy --changed-since
+	WatchPaths []string `yaml:"watchPaths,omitempty" json:"watchPaths,omitempty"`
+
 	// DependsOn lists services this one depends on
 	DependsOn []string `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
 
+	// WaitForDependencies holds the service's pods in an init container
+	// until every dependsOn service answers its readiness endpoint, so it
+	// starts once they're serving rather than once they're applied
+	WaitForDependencies bool `yaml:"waitForDependencies,omitempty" json:"waitForDependencies,omitempty"`
+
+	// Readiness is the endpoint services waiting for this one poll
+	// (default: healthCheck, else "/", on the service's port)
+	Readiness *ReadinessEndpoint `yaml:"readiness,omitempty" json:"readiness,omitempty"`
+
 	// HealthCheck path
 	HealthCheck string `yaml:"healthCheck,omitempty" json:"healthCheck,omitempty"`
 
@@ -376,6 +891,19 @@ type ServiceSpec struct {
 
 	// Service configuration
 	Service *ServiceConfig `yaml:"service,omitempty" json:"service,omitempty"`
+
+	// RollOnConfigChange restarts pods when their ConfigMap changes (default: true)
+	RollOnConfigChange *bool `yaml:"rollOnConfigChange,omitempty" json:"rollOnConfigChange,omitempty"`
+}
+
+// ReadinessEndpoint is an HTTP endpoint that answers 2xx once a service is
+// serving
+type ReadinessEndpoint struct {
+	// Path to GET (default: the service's healthCheck, else "/")
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Port of the Service to poll (default: the service's port)
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
 }
 
 // Defaults for the config
@@ -419,46 +947,53 @@ func (c *AppConfig) WithDefaults() *AppConfig {
 	return c
 }
 
-// ForEnvironment returns a config merged with environment-specific overrides
+// ForEnvironment returns a config merged with environment-specific overrides.
+// The environment's overrides are deep-merged into the spec: maps merge,
+// scalars override, and lists replace the base's, or add to it with
+// `- $strategy: append` as their first item. The result shares no maps,
+// lists, or pointers with c.
 func (c *AppConfig) ForEnvironment(env string) *AppConfig {
-	if env == "" || c.Environments == nil {
+	result, err := c.mergeEnvironment(env)
+	if err != nil {
+		// Validate rejects overrides that don't merge, so only configs
+		// built in code get here
 		return c
 	}
+	return result
+}
 
-	override, ok := c.Environments[env]
-	if !ok {
-		return c
+// mergeEnvironment is ForEnvironment, reporting overrides that don't merge
+func (c *AppConfig) mergeEnvironment(env string) (*AppConfig, error) {
+	if env == "" || c.Environments == nil {
+		return c, nil
 	}
 
-	// Create a copy
-	result := *c
-
-	// Apply overrides
-	if override.Replicas != nil {
-		result.Spec.Replicas = *override.Replicas
+	override, ok := c.Environments[env]
+	if !ok {
+		return c, nil
 	}
 
-	if override.Image != "" {
-		result.Spec.Image = override.Image
+	overlay, err := override.specOverlay()
+	if err != nil {
+		return nil, err
 	}
-
-	if override.Resources != nil {
-		result.Spec.Resources = override.Resources
+	result := *c
+	result.Spec = AppSpec{}
+	if err := mergeOverlay(c.Spec, overlay, &result.Spec, false); err != nil {
+		return nil, err
 	}
 
-	if override.Ingress != nil {
-		result.Spec.Ingress = override.Ingress
+	if override.Namespace != "" {
+		result.Metadata.Namespace = override.Namespace
 	}
+	return &result, nil
+}
 
-	// Merge env vars
-	if len(override.Env) > 0 {
-		if result.Spec.Env == nil {
-			result.Spec.Env = make(map[string]string)
-		}
-		for k, v := range override.Env {
-			result.Spec.Env[k] = v
-		}
+// EnvironmentTarget returns the context/namespace binding of an environment
+func (c *AppConfig) EnvironmentTarget(env string) EnvTarget {
+	override, ok := c.Environments[env]
+	if env == "" || !ok {
+		return EnvTarget{}
 	}
-
-	return &result
+	return EnvTarget{Context: override.Context, Namespace: override.Namespace, Protected: override.Protected}
 }
@@ -95,3 +95,36 @@ func TestForEnvironment(t *testing.T) {
 		t.Errorf("expected NEW_VAR to be added")
 	}
 }
+
+func TestAppConfig_EnvironmentTarget(t *testing.T) {
+	config := &AppConfig{
+		Metadata: Metadata{Name: "myapp", Namespace: "dev"},
+		Spec:     AppSpec{Image: "myapp:v1"},
+		Environments: map[string]EnvOverride{
+			"prod": {
+				Context:   "prod-cluster",
+				Namespace: "myapp-prod",
+				Protected: true,
+			},
+			"staging": {},
+		},
+	}
+
+	target := config.EnvironmentTarget("prod")
+	if target.Context != "prod-cluster" || target.Namespace != "myapp-prod" || !target.Protected {
+		t.Errorf("unexpected prod target: %+v", target)
+	}
+	if target := config.EnvironmentTarget("staging"); target != (EnvTarget{}) {
+		t.Errorf("expected empty target for staging, got %+v", target)
+	}
+	if target := config.EnvironmentTarget("missing"); target != (EnvTarget{}) {
+		t.Errorf("expected empty target for unknown env, got %+v", target)
+	}
+
+	if ns := config.ForEnvironment("prod").Metadata.Namespace; ns != "myapp-prod" {
+		t.Errorf("expected prod namespace myapp-prod, got %q", ns)
+	}
+	if ns := config.ForEnvironment("staging").Metadata.Namespace; ns != "dev" {
+		t.Errorf("expected staging to keep namespace dev, got %q", ns)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Security profiles, named after the Pod Security Standards levels they satisfy
+const (
+	SecurityProfileRestricted = "restricted"
+	SecurityProfileBaseline   = "baseline"
+)
+
+// SecurityContextConfig overrides kbox's hardened pod and container defaults
+type SecurityContextConfig struct {
+	// RunAsUser is the UID the containers run as (default: 1000)
+	RunAsUser *int64 `yaml:"runAsUser,omitempty" json:"runAsUser,omitempty"`
+
+	// RunAsGroup is the primary GID of the containers (default: 1000)
+	RunAsGroup *int64 `yaml:"runAsGroup,omitempty" json:"runAsGroup,omitempty"`
+
+	// FSGroup owns mounted volumes (default: 1000)
+	FSGroup *int64 `yaml:"fsGroup,omitempty" json:"fsGroup,omitempty"`
+
+	// RunAsNonRoot refuses to start containers running as root
+	// (default: true for the restricted profile)
+	RunAsNonRoot *bool `yaml:"runAsNonRoot,omitempty" json:"runAsNonRoot,omitempty"`
+
+	// SeccompProfile is RuntimeDefault (default), Localhost/<profile>, or Unconfined
+	SeccompProfile string `yaml:"seccompProfile,omitempty" json:"seccompProfile,omitempty"`
+
+	// ReadOnlyRootFilesystem makes the container filesystem read-only (default: true)
+	ReadOnlyRootFilesystem *bool `yaml:"readOnlyRootFilesystem,omitempty" json:"readOnlyRootFilesystem,omitempty"`
+
+	// Capabilities to add back, e.g. NET_BIND_SERVICE to listen on ports below 1024
+	Capabilities []string `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+}
+
+// EffectiveSecurityProfile returns the spec's security profile, defaulting to restricted
+func (s *AppSpec) EffectiveSecurityProfile() string {
+	if s.SecurityProfile == "" {
+		return SecurityProfileRestricted
+	}
+	return s.SecurityProfile
+}
+
+// BaselineCapabilities are the capabilities the baseline Pod Security Standard allows adding
+var BaselineCapabilities = []string{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+	"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+}
+
+// validateSecurity checks the security profile and that securityContext
+// overrides don't break the profile's Pod Security Standard
+func validateSecurity(spec *AppSpec) ValidationErrors {
+	var errs ValidationErrors
+	profile := spec.EffectiveSecurityProfile()
+	if profile != SecurityProfileRestricted && profile != SecurityProfileBaseline {
+		return append(errs, ValidationError{
+			Field:   "spec.securityProfile",
+			Message: fmt.Sprintf("unknown profile %q (must be restricted or baseline)", spec.SecurityProfile),
+		})
+	}
+
+	sc := spec.SecurityContext
+	if sc == nil {
+		return nil
+	}
+	violation := func(field, message string) {
+		errs = append(errs, ValidationError{
+			Field:   "spec.securityContext." + field,
+			Message: fmt.Sprintf("%s (not allowed by the %s profile)", message, profile),
+		})
+	}
+
+	switch {
+	case sc.SeccompProfile == "Unconfined":
+		violation("seccompProfile", "Unconfined seccomp")
+	case sc.SeccompProfile != "" && sc.SeccompProfile != "RuntimeDefault" && !isLocalhostSeccomp(sc.SeccompProfile):
+		errs = append(errs, ValidationError{
+			Field:   "spec.securityContext.seccompProfile",
+			Message: fmt.Sprintf("unknown seccomp profile %q (must be RuntimeDefault, Localhost/<profile>, or Unconfined)", sc.SeccompProfile),
+		})
+	}
+
+	for _, c := range sc.Capabilities {
+		switch {
+		case profile == SecurityProfileRestricted && c != "NET_BIND_SERVICE":
+			violation("capabilities", fmt.Sprintf("capability %s", c))
+		case profile == SecurityProfileBaseline && !slices.Contains(BaselineCapabilities, c):
+			violation("capabilities", fmt.Sprintf("capability %s", c))
+		}
+	}
+
+	if profile == SecurityProfileRestricted {
+		if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+			violation("runAsNonRoot", "running as root")
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violation("runAsUser", "UID 0")
+		}
+	}
+	return errs
+}
+
+// isLocalhostSeccomp reports whether p names a node-local seccomp profile, Localhost/<file>
+func isLocalhostSeccomp(p string) bool {
+	file, ok := strings.CutPrefix(p, "Localhost/")
+	return ok && file != ""
+}
@@ -0,0 +1,86 @@
+package config
+
+import "strings"
+
+// SigningConfig configures cosign signatures of the app image: kbox up signs
+// the image it builds with Key or keylessly, and kbox verify and kbox deploy
+// check the signature with PublicKey, or against Identity and Issuer
+type SigningConfig struct {
+	// Key is the cosign private key kbox up signs with: a file (e.g.
+	// cosign.key, with its password in COSIGN_PASSWORD) or a KMS URI
+	// (e.g. awskms:///alias/kbox)
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+
+	// PublicKey verifies signatures (default: Key with a .pub extension, or
+	// Key itself for a KMS URI)
+	PublicKey string `yaml:"publicKey,omitempty" json:"publicKey,omitempty"`
+
+	// Keyless signs with a short-lived Sigstore certificate for the OIDC
+	// identity of the user or CI job, instead of a key
+	Keyless bool `yaml:"keyless,omitempty" json:"keyless,omitempty"`
+
+	// Identity is the signer keyless signatures must be issued to, e.g.
+	// an email or https://github.com/acme/api/.github/workflows/release.yml@refs/heads/main
+	Identity string `yaml:"identity,omitempty" json:"identity,omitempty"`
+
+	// Issuer is the OIDC issuer of keyless signatures, e.g.
+	// https://token.actions.githubusercontent.com
+	Issuer string `yaml:"issuer,omitempty" json:"issuer,omitempty"`
+
+	// RequireSigned refuses to deploy an image without a valid signature
+	RequireSigned bool `yaml:"requireSigned,omitempty" json:"requireSigned,omitempty"`
+}
+
+// VerificationKey returns the key signatures are verified with, or "" for
+// keyless signatures
+func (s *SigningConfig) VerificationKey() string {
+	if s.PublicKey != "" {
+		return s.PublicKey
+	}
+	if s.Key == "" || strings.Contains(s.Key, "://") {
+		return s.Key
+	}
+	return strings.TrimSuffix(s.Key, ".key") + ".pub"
+}
+
+// Signs reports whether kbox up signs the image it builds
+func (s *SigningConfig) Signs() bool {
+	return s.Key != "" || s.Keyless
+}
+
+func validateSigning(spec *AppSpec) ValidationErrors {
+	return validateSigningAt("spec", spec.Signing, spec.Build)
+}
+
+// validateSigningAt validates the signing config of a spec or a service at path
+func validateSigningAt(path string, s *SigningConfig, build *BuildConfig) ValidationErrors {
+	if s == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if s.Keyless && s.Key != "" {
+		errs = append(errs, ValidationError{
+			Field:   path + ".signing.keyless",
+			Message: "set either key or keyless, not both",
+		})
+	}
+	if s.Keyless && (s.Identity == "" || s.Issuer == "") {
+		errs = append(errs, ValidationError{
+			Field:   path + ".signing.identity",
+			Message: "keyless signatures are verified against an identity and issuer; set both",
+		})
+	}
+	if !s.Keyless && s.VerificationKey() == "" {
+		errs = append(errs, ValidationError{
+			Field:   path + ".signing.key",
+			Message: "key or publicKey is required unless keyless is set",
+		})
+	}
+	if s.Signs() && build != nil && build.Repository == "" {
+		errs = append(errs, ValidationError{
+			Field:   path + ".build.repository",
+			Message: "required to sign the image kbox up builds, as signatures are stored in the registry",
+		})
+	}
+	return errs
+}
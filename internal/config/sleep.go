@@ -0,0 +1,22 @@
+package config
+
+// SleepScheduleConfig scales the app to zero and back on a schedule, e.g.
+// overnight and at weekends for dev and staging environments
+type SleepScheduleConfig struct {
+	// Sleep is when to scale to zero, in cron format (e.g., "0 20 * * 1-5")
+	Sleep string `yaml:"sleep" json:"sleep"`
+
+	// Wake is when to restore the replicas, in cron format (e.g., "0 7 * * 1-5")
+	Wake string `yaml:"wake" json:"wake"`
+
+	// TimeZone the schedules are in (default: the cluster's, usually UTC)
+	TimeZone string `yaml:"timeZone,omitempty" json:"timeZone,omitempty"`
+
+	// Image runs kubectl for the scaling (default: alpine/k8s, pinned)
+	Image string `yaml:"image,omitempty" json:"image,omitempty"`
+}
+
+func validateSleepSchedule(s *SleepScheduleConfig) ValidationErrors {
+	errs := validateSchedule("spec.sleepSchedule.sleep", s.Sleep, s.TimeZone, "spec.sleepSchedule.timeZone")
+	return append(errs, validateSchedule("spec.sleepSchedule.wake", s.Wake, "", "")...)
+}
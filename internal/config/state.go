@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// StateDir is the per-project directory for local kbox state
+	StateDir = ".kbox"
+
+	// StateFile is the per-project state file, relative to the project root
+	StateFile = ".kbox/state"
+)
+
+// ProjectState holds per-project defaults that take precedence over kubeconfig.
+// It is written by 'kbox ctx' and 'kbox ns' and is local to a checkout.
+type ProjectState struct {
+	Context   string `yaml:"context,omitempty" json:"context,omitempty"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+// IsEmpty returns true if no defaults are set
+func (s *ProjectState) IsEmpty() bool {
+	return s.Context == "" && s.Namespace == ""
+}
+
+// LoadState reads the project state from workDir.
+// A missing state file is not an error and yields an empty state.
+func LoadState(workDir string) (*ProjectState, error) {
+	path := filepath.Join(workDir, StateFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ProjectState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var state ProjectState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// SaveState writes the project state to workDir, removing the file when the state is empty
+func SaveState(workDir string, state *ProjectState) error {
+	path := filepath.Join(workDir, StateFile)
+	if state == nil || state.IsEmpty() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(workDir, StateDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", StateDir, err)
+	}
+
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectState_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Missing file yields an empty state
+	state, err := LoadState(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !state.IsEmpty() {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+
+	want := &ProjectState{Context: "kind-dev", Namespace: "team-a"}
+	if err := SaveState(tmpDir, want); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	got, err := LoadState(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// Saving an empty state removes the file
+	if err := SaveState(tmpDir, &ProjectState{}); err != nil {
+		t.Fatalf("failed to clear state: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, StateFile)); !os.IsNotExist(err) {
+		t.Errorf("expected state file to be removed, stat err: %v", err)
+	}
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, StateDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, StateFile), []byte("context: [unclosed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(tmpDir); err == nil {
+		t.Error("expected parse error for invalid state file")
+	}
+}
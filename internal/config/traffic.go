@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrafficConfig shapes how the ingress traffic reaches the app
+type TrafficConfig struct {
+	// Split sends a share of the ingress traffic to a second Deployment
+	// running another image, through the ingress controller
+	Split *TrafficSplit `yaml:"split,omitempty" json:"split,omitempty"`
+}
+
+// TrafficSplit is an A/B split of the ingress traffic: weight percent of the
+// requests go to a canary Deployment running canaryImage, the rest to the
+// app. It's done with NGINX Ingress canary annotations, so it needs no mesh
+// and no canary strategy; 'kbox traffic set' changes the weight live.
+type TrafficSplit struct {
+	// CanaryImage is the image the second Deployment runs
+	CanaryImage string `yaml:"canaryImage" json:"canaryImage"`
+
+	// Weight is the percentage of requests sent to the canary (0-100)
+	Weight int `yaml:"weight" json:"weight"`
+
+	// Replicas of the canary Deployment (default: 1)
+	Replicas *int `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+}
+
+// TrafficSplit returns the spec's traffic split, or nil when it has none
+func (s *AppSpec) TrafficSplit() *TrafficSplit {
+	if s.Traffic == nil {
+		return nil
+	}
+	return s.Traffic.Split
+}
+
+func validateTraffic(spec *AppSpec) ValidationErrors {
+	split := spec.TrafficSplit()
+	if split == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if split.CanaryImage == "" {
+		errs = append(errs, ValidationError{Field: "spec.traffic.split.canaryImage", Message: "is required"})
+	}
+	if split.Weight < 0 || split.Weight > 100 {
+		errs = append(errs, ValidationError{Field: "spec.traffic.split.weight", Message: fmt.Sprintf("must be a percentage from 0 to 100, got %d", split.Weight)})
+	}
+	if split.Replicas != nil && *split.Replicas < 1 {
+		errs = append(errs, ValidationError{Field: "spec.traffic.split.replicas", Message: "must be at least 1: the ingress would send the canary's share to no pods"})
+	}
+	if spec.Ingress == nil || !spec.Ingress.Enabled {
+		errs = append(errs, ValidationError{Field: "spec.traffic.split", Message: "splits the ingress traffic, so it needs spec.ingress.enabled"})
+	}
+	if spec.IsStatefulSet() {
+		errs = append(errs, ValidationError{Field: "spec.traffic.split", Message: "needs the app to run as a Deployment"})
+	}
+	// The split is NGINX Ingress canary annotations; other controllers, such
+	// as Traefik, ignore them and would send every request to the app
+	if spec.Ingress != nil {
+		if class := spec.Ingress.IngressClass; class != "" && !strings.Contains(class, "nginx") {
+			errs = append(errs, ValidationError{Field: "spec.traffic.split", Message: fmt.Sprintf("needs NGINX Ingress, which reads its canary annotations; ingress class %q would send all traffic to the app", class)})
+		}
+	}
+	return errs
+}
@@ -2,9 +2,15 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // ValidationError represents a config validation error
@@ -64,6 +70,8 @@ func Validate(config *AppConfig) error {
 		})
 	}
 
+	errs = append(errs, validateMetadata(config.Metadata)...)
+
 	// Check image or build
 	if config.Spec.Image == "" && config.Spec.Build == nil {
 		errs = append(errs, ValidationError{
@@ -88,17 +96,38 @@ func Validate(config *AppConfig) error {
 		})
 	}
 
-	// Check service type
-	if config.Spec.Service != nil && config.Spec.Service.Type != "" {
-		validTypes := map[string]bool{
-			"ClusterIP":    true,
-			"NodePort":     true,
-			"LoadBalancer": true,
+	errs = append(errs, validateService(config.Spec.Service, config.Spec.Port, "spec.service")...)
+
+	// Check workload
+	switch config.Spec.Workload {
+	case "", WorkloadDeployment, WorkloadStatefulSet:
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "spec.workload",
+			Message: fmt.Sprintf("unknown workload %q (must be deployment or statefulset)", config.Spec.Workload),
+		})
+	}
+
+	// Check processes
+	for _, name := range slices.Sorted(maps.Keys(config.Spec.Processes)) {
+		proc := config.Spec.Processes[name]
+		field := "spec.processes." + name
+		if !IsValidName(name) {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: "must be lowercase alphanumeric with hyphens, max 63 chars",
+			})
 		}
-		if !validTypes[config.Spec.Service.Type] {
+		if name != WebProcess && len(proc.Command) == 0 {
 			errs = append(errs, ValidationError{
-				Field:   "spec.service.type",
-				Message: "must be ClusterIP, NodePort, or LoadBalancer",
+				Field:   field + ".command",
+				Message: "required for processes other than web",
+			})
+		}
+		if proc.Replicas != nil && *proc.Replicas < 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".replicas",
+				Message: "must be non-negative",
 			})
 		}
 	}
@@ -121,6 +150,34 @@ func Validate(config *AppConfig) error {
 				Message: "must be non-negative",
 			})
 		}
+		if _, err := config.mergeEnvironment(envName); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("environments.%s", envName),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	errs = append(errs, validateSecurity(&config.Spec)...)
+	errs = append(errs, validatePlatform(&config.Spec)...)
+	errs = append(errs, validateMesh(&config.Spec)...)
+	errs = append(errs, validateSigning(&config.Spec)...)
+	if config.Spec.SleepSchedule != nil {
+		errs = append(errs, validateSleepSchedule(config.Spec.SleepSchedule)...)
+	}
+	errs = append(errs, validateClusters(config.Clusters)...)
+	errs = append(errs, validateMinKboxVersion(config.MinKboxVersion)...)
+	errs = append(errs, validateNotifications(config.Notifications)...)
+
+	if share := config.Spec.Share; share != nil {
+		switch share.Provider {
+		case "", "ngrok", "cloudflared", "localtunnel":
+		default:
+			errs = append(errs, ValidationError{
+				Field:   "spec.share.provider",
+				Message: fmt.Sprintf("unknown provider %q (must be ngrok, cloudflared, or localtunnel)", share.Provider),
+			})
+		}
 	}
 
 	// Validate resource quantities
@@ -166,12 +223,379 @@ func Validate(config *AppConfig) error {
 		}
 	}
 
+	errs = append(errs, validateDependencies(config.Spec.Dependencies)...)
+	errs = append(errs, validateAutoscaling(config.Spec.Autoscaling)...)
+	errs = append(errs, validatePDB(config.Spec.PDB)...)
+	errs = append(errs, validateRollout(&config.Spec)...)
+	errs = append(errs, validateTraffic(&config.Spec)...)
+	errs = append(errs, validateResourceMetadata(&config.Spec)...)
+
+	// Validate smoke tests
+	errs = append(errs, validateSmokeTests(config.Spec.Tests)...)
+	for i, job := range config.Spec.Jobs {
+		field := fmt.Sprintf("spec.jobs[%d]", i)
+		if job.Schedule != "" {
+			errs = append(errs, validateSchedule(field+".schedule", job.Schedule, job.TimeZone, field+".timeZone")...)
+		} else if job.TimeZone != "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".timeZone",
+				Message: "requires schedule",
+			})
+		}
+		if job.HistoryLimit != nil && *job.HistoryLimit < 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".historyLimit",
+				Message: "must not be negative",
+			})
+		}
+	}
+	errs = append(errs, validateOverrides(config.Spec.Overrides)...)
+
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
 
+// validateSmokeTests checks that each test has exactly one check type and sane values
+func validateSmokeTests(tests []SmokeTestConfig) ValidationErrors {
+	var errs ValidationErrors
+	for i, t := range tests {
+		field := fmt.Sprintf("spec.tests[%d]", i)
+		if (t.HTTP == nil) == (t.Exec == nil) {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: "exactly one of http or exec is required",
+			})
+			continue
+		}
+		if t.HTTP != nil {
+			if t.HTTP.Port < 0 || t.HTTP.Port > 65535 {
+				errs = append(errs, ValidationError{
+					Field:   field + ".http.port",
+					Message: "must be between 0 and 65535",
+				})
+			}
+			if t.HTTP.ExpectStatus != 0 && (t.HTTP.ExpectStatus < 100 || t.HTTP.ExpectStatus > 599) {
+				errs = append(errs, ValidationError{
+					Field:   field + ".http.expectStatus",
+					Message: "must be a valid HTTP status code",
+				})
+			}
+		}
+		if t.Exec != nil && len(t.Exec.Command) == 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".exec.command",
+				Message: "required",
+			})
+		}
+		if t.Timeout != "" {
+			if _, err := time.ParseDuration(t.Timeout); err != nil {
+				errs = append(errs, ValidationError{
+					Field:   field + ".timeout",
+					Message: fmt.Sprintf("invalid duration %q", t.Timeout),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// validateOverrides checks that each patch has a target and exactly one body
+func validateOverrides(o *OverrideConfig) ValidationErrors {
+	if o == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	for i, p := range o.Patches {
+		field := fmt.Sprintf("spec.overrides.patches[%d]", i)
+		if p.Target.Kind == "" {
+			errs = append(errs, ValidationError{Field: field + ".target.kind", Message: "required"})
+		}
+		if (len(p.StrategicMerge) == 0) == (len(p.JSONPatch) == 0) {
+			errs = append(errs, ValidationError{Field: field, Message: "exactly one of strategicMerge or jsonPatch is required"})
+		}
+		for j, op := range p.JSONPatch {
+			opField := fmt.Sprintf("%s.jsonPatch[%d]", field, j)
+			switch op.Op {
+			case "add", "remove", "replace", "move", "copy", "test":
+			default:
+				errs = append(errs, ValidationError{Field: opField + ".op", Message: fmt.Sprintf("unknown op %q (must be add, remove, replace, move, copy, or test)", op.Op)})
+			}
+			if !strings.HasPrefix(op.Path, "/") {
+				errs = append(errs, ValidationError{Field: opField + ".path", Message: "must be a JSON pointer starting with /"})
+			}
+			if (op.Op == "move" || op.Op == "copy") && op.From == "" {
+				errs = append(errs, ValidationError{Field: opField + ".from", Message: "required"})
+			}
+		}
+	}
+	return errs
+}
+
+// validateService checks the Service settings against its type: each only
+// applies to some types. appPort is the app's port, the Service's http port
+// unless it sets its own.
+func validateService(svc *ServiceConfig, appPort int, field string) ValidationErrors {
+	if svc == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	svcType := svc.Type
+	switch svcType {
+	case "":
+		svcType = "ClusterIP"
+	case "ClusterIP", "NodePort", "LoadBalancer", "ExternalName":
+	default:
+		errs = append(errs, ValidationError{
+			Field:   field + ".type",
+			Message: "must be ClusterIP, NodePort, LoadBalancer, or ExternalName",
+		})
+	}
+
+	switch {
+	case svc.ClusterIP == "":
+	case svc.ClusterIP != "None":
+		errs = append(errs, ValidationError{Field: field + ".clusterIP", Message: fmt.Sprintf("unsupported value %q (only None, for a headless Service)", svc.ClusterIP)})
+	case svcType != "ClusterIP":
+		errs = append(errs, ValidationError{Field: field + ".clusterIP", Message: fmt.Sprintf("None requires type ClusterIP, not %s", svcType)})
+	}
+
+	if svcType == "ExternalName" {
+		if svc.ExternalName == "" {
+			errs = append(errs, ValidationError{Field: field + ".externalName", Message: "required for type ExternalName"})
+		} else if msgs := validation.IsDNS1123Subdomain(strings.TrimSuffix(svc.ExternalName, ".")); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field + ".externalName", Message: fmt.Sprintf("invalid DNS name %q: %s", svc.ExternalName, strings.Join(msgs, "; "))})
+		}
+	} else if svc.ExternalName != "" {
+		errs = append(errs, ValidationError{Field: field + ".externalName", Message: fmt.Sprintf("requires type ExternalName, not %s", svcType)})
+	}
+
+	switch svc.SessionAffinity {
+	case "", "None":
+	case "ClientIP":
+		if svcType == "ExternalName" || svc.ClusterIP == "None" {
+			errs = append(errs, ValidationError{Field: field + ".sessionAffinity", Message: "ClientIP needs a Service kube-proxy balances (not headless or ExternalName)"})
+		}
+	default:
+		errs = append(errs, ValidationError{Field: field + ".sessionAffinity", Message: "must be None or ClientIP"})
+	}
+	if svc.SessionAffinityTimeout != 0 {
+		if svc.SessionAffinity != "ClientIP" {
+			errs = append(errs, ValidationError{Field: field + ".sessionAffinityTimeout", Message: "requires sessionAffinity ClientIP"})
+		} else if svc.SessionAffinityTimeout < 1 || svc.SessionAffinityTimeout > 86400 {
+			errs = append(errs, ValidationError{Field: field + ".sessionAffinityTimeout", Message: "must be between 1 and 86400 seconds"})
+		}
+	}
+
+	switch svc.ExternalTrafficPolicy {
+	case "":
+	case "Cluster", "Local":
+		if svcType != "NodePort" && svcType != "LoadBalancer" {
+			errs = append(errs, ValidationError{Field: field + ".externalTrafficPolicy", Message: fmt.Sprintf("requires type NodePort or LoadBalancer, not %s", svcType)})
+		}
+	default:
+		errs = append(errs, ValidationError{Field: field + ".externalTrafficPolicy", Message: "must be Cluster or Local"})
+	}
+
+	if dns := svc.DNS; dns != nil {
+		if dns.Hostname == "" {
+			errs = append(errs, ValidationError{Field: field + ".dns.hostname", Message: "required"})
+		} else if msgs := validation.IsDNS1123Subdomain(strings.TrimPrefix(strings.TrimSuffix(dns.Hostname, "."), "*.")); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field + ".dns.hostname", Message: fmt.Sprintf("invalid DNS name %q: %s", dns.Hostname, strings.Join(msgs, "; "))})
+		}
+		if dns.TTL < 0 {
+			errs = append(errs, ValidationError{Field: field + ".dns.ttl", Message: "must not be negative"})
+		}
+		// external-dns publishes load balancer and node addresses, or pod
+		// IPs for headless Services; a ClusterIP is only reachable in-cluster
+		if svcType == "ExternalName" || (svcType == "ClusterIP" && svc.ClusterIP != "None") {
+			errs = append(errs, ValidationError{Field: field + ".dns", Message: fmt.Sprintf("requires type LoadBalancer or NodePort, or a headless Service, not %s", svcType)})
+		}
+	}
+
+	errs = append(errs, validateServicePorts(svc, appPort, svcType, field)...)
+
+	for _, key := range slices.Sorted(maps.Keys(svc.Annotations)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: field + ".annotations", Message: fmt.Sprintf("invalid key %q: %s", key, strings.Join(msgs, "; "))})
+		}
+	}
+	return errs
+}
+
+// validateServicePorts checks the extra ports are unique and the health
+// check probes a TCP one
+func validateServicePorts(svc *ServiceConfig, appPort int, svcType, field string) ValidationErrors {
+	var errs ValidationErrors
+	httpPort := appPort
+	if svc.Port != 0 {
+		httpPort = svc.Port
+	}
+	names := map[string]bool{"http": true}
+	ports := map[string]bool{fmt.Sprintf("%d/TCP", httpPort): true}
+	tcpPorts := map[int]bool{httpPort: true}
+	for i, p := range svc.Ports {
+		portField := fmt.Sprintf("%s.ports[%d]", field, i)
+		if p.Port < 1 || p.Port > 65535 {
+			errs = append(errs, ValidationError{Field: portField + ".port", Message: "must be between 1 and 65535"})
+		}
+		if p.TargetPort < 0 || p.TargetPort > 65535 {
+			errs = append(errs, ValidationError{Field: portField + ".targetPort", Message: "must be between 1 and 65535"})
+		}
+		switch p.Protocol {
+		case "", "TCP", "UDP":
+		default:
+			errs = append(errs, ValidationError{Field: portField + ".protocol", Message: "must be TCP or UDP"})
+		}
+		name := p.PortName()
+		if msgs := validation.IsValidPortName(name); len(msgs) > 0 {
+			errs = append(errs, ValidationError{Field: portField + ".name", Message: fmt.Sprintf("invalid port name %q: %s", name, strings.Join(msgs, "; "))})
+		} else if names[name] {
+			errs = append(errs, ValidationError{Field: portField + ".name", Message: fmt.Sprintf("duplicate port name %q", name)})
+		}
+		names[name] = true
+		key := fmt.Sprintf("%d/%s", p.Port, p.PortProtocol())
+		if ports[key] {
+			errs = append(errs, ValidationError{Field: portField + ".port", Message: fmt.Sprintf("duplicate port %s", key)})
+		}
+		ports[key] = true
+		if p.PortProtocol() == "TCP" {
+			tcpPorts[p.Port] = true
+		}
+	}
+
+	if hc := svc.HealthCheck; hc != nil {
+		if svcType != "LoadBalancer" {
+			errs = append(errs, ValidationError{Field: field + ".healthCheck", Message: fmt.Sprintf("requires type LoadBalancer, not %s", svcType)})
+		}
+		if hc.Port != 0 && !tcpPorts[hc.Port] {
+			errs = append(errs, ValidationError{Field: field + ".healthCheck.port", Message: fmt.Sprintf("%d is not one of the Service's TCP ports", hc.Port)})
+		}
+		if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+			errs = append(errs, ValidationError{Field: field + ".healthCheck.path", Message: "must start with /"})
+		}
+	}
+	return errs
+}
+
+// dependencyConfigKey matches dependencies[].config setting names, which
+// become command-line options, so they can't smuggle in others
+var dependencyConfigKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// validateDependencies checks dependencies' config and env names, and that
+// external dependencies say where the server is
+func validateDependencies(deps []DependencyConfig) ValidationErrors {
+	var errs ValidationErrors
+	for i, dep := range deps {
+		field := fmt.Sprintf("spec.dependencies[%d]", i)
+		for _, key := range slices.Sorted(maps.Keys(dep.Config)) {
+			if !dependencyConfigKey.MatchString(key) {
+				errs = append(errs, ValidationError{Field: field + ".config." + key, Message: "invalid setting name (letters, digits, '_', '-' and '.')"})
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(dep.Env)) {
+			if msgs := validation.IsEnvVarName(name); len(msgs) > 0 {
+				errs = append(errs, ValidationError{Field: field + ".env." + name, Message: fmt.Sprintf("invalid env var name: %s", strings.Join(msgs, "; "))})
+			}
+		}
+
+		ext := dep.External
+		if ext == nil {
+			continue
+		}
+		if ext.Host == "" && ext.SecretRef == "" {
+			errs = append(errs, ValidationError{Field: field + ".external", Message: "requires host or secretRef"})
+		}
+		if ext.Port < 0 || ext.Port > 65535 {
+			errs = append(errs, ValidationError{Field: field + ".external.port", Message: fmt.Sprintf("port %d out of range (1-65535)", ext.Port)})
+		}
+		if ext.Port != 0 && ext.Host == "" {
+			errs = append(errs, ValidationError{Field: field + ".external.port", Message: "requires host"})
+		}
+		if ext.SecretRef != "" && !IsValidName(ext.SecretRef) {
+			errs = append(errs, ValidationError{Field: field + ".external.secretRef", Message: fmt.Sprintf("invalid Secret name %q", ext.SecretRef)})
+		}
+	}
+	return errs
+}
+
+// dependencyWarnings reports settings external dependencies don't use
+func dependencyWarnings(deps []DependencyConfig) []string {
+	var warnings []string
+	for i, dep := range deps {
+		if dep.External == nil {
+			continue
+		}
+		if dep.Version != "" || dep.Storage != "" || dep.Resources != nil || len(dep.Config) > 0 || len(dep.Env) > 0 {
+			warnings = append(warnings, fmt.Sprintf("spec.dependencies[%d]: version, storage, resources, config and env are ignored for an external %s", i, dep.Type))
+		}
+		if dep.External.SecretRef == "" {
+			warnings = append(warnings, fmt.Sprintf("spec.dependencies[%d]: external %s has no secretRef - its password and URL env vars won't be set", i, dep.Type))
+		}
+	}
+	return warnings
+}
+
+// validateClusters checks that fleet clusters are named uniquely and have a context
+func validateClusters(clusters []ClusterConfig) ValidationErrors {
+	var errs ValidationErrors
+	seen := make(map[string]bool)
+	for i, c := range clusters {
+		field := fmt.Sprintf("clusters[%d]", i)
+		if c.Name == "" {
+			errs = append(errs, ValidationError{Field: field + ".name", Message: "required"})
+		} else if seen[c.Name] {
+			errs = append(errs, ValidationError{Field: field + ".name", Message: fmt.Sprintf("duplicate cluster %q", c.Name)})
+		}
+		seen[c.Name] = true
+		if c.Context == "" {
+			errs = append(errs, ValidationError{Field: field + ".context", Message: "required"})
+		}
+		if c.Weight < 0 {
+			errs = append(errs, ValidationError{Field: field + ".weight", Message: "must be non-negative"})
+		}
+	}
+	return errs
+}
+
+// validateNotifications checks that each channel has a URL and the template parses
+func validateNotifications(n *NotificationsConfig) ValidationErrors {
+	if n == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	channels := []struct {
+		hook  *WebhookConfig
+		field string
+	}{
+		{n.Slack, "notifications.slack.url"},
+		{n.Teams, "notifications.teams.url"},
+		{n.Webhook, "notifications.webhook.url"},
+	}
+	for _, c := range channels {
+		if c.hook != nil && c.hook.URL == "" {
+			errs = append(errs, ValidationError{Field: c.field, Message: "required"})
+		}
+	}
+	for i, e := range n.Events {
+		switch e {
+		case "deploy", "rollback", "preview":
+		default:
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("notifications.events[%d]", i),
+				Message: fmt.Sprintf("unknown event %q (must be deploy, rollback, or preview)", e),
+			})
+		}
+	}
+	if n.Template != "" {
+		if _, err := template.New("notification").Parse(n.Template); err != nil {
+			errs = append(errs, ValidationError{Field: "notifications.template", Message: err.Error()})
+		}
+	}
+	return errs
+}
+
 // validateQuantity validates a Kubernetes resource quantity string
 func validateQuantity(value, field string) *ValidationError {
 	if value == "" {
@@ -199,6 +623,12 @@ func ValidateWithWarnings(config *AppConfig) ([]string, error) {
 		}
 	}
 
+	warnings = append(warnings, platformWarnings(&config.Spec)...)
+	warnings = append(warnings, meshWarnings(&config.Spec)...)
+	warnings = append(warnings, dependencyWarnings(config.Spec.Dependencies)...)
+	warnings = append(warnings, pdbWarnings(&config.Spec)...)
+	warnings = append(warnings, resourcesWarnings(&config.Spec)...)
+
 	// Run standard validation
 	if err := Validate(config); err != nil {
 		return warnings, err
@@ -233,3 +663,28 @@ func IsValidName(name string) bool {
 
 	return true
 }
+
+// validateAutoscaling checks the HPA's replica bounds and CPU target
+func validateAutoscaling(as *AutoscalingConfig) ValidationErrors {
+	if as == nil || !as.Enabled {
+		return nil
+	}
+	var errs ValidationErrors
+	if as.MinReplicas < 0 {
+		errs = append(errs, ValidationError{Field: "spec.autoscaling.minReplicas", Message: "must not be negative"})
+	}
+	if as.MaxReplicas < 0 {
+		errs = append(errs, ValidationError{Field: "spec.autoscaling.maxReplicas", Message: "must not be negative"})
+	}
+	minReplicas := max(as.MinReplicas, 1)
+	if as.MaxReplicas > 0 && as.MaxReplicas < minReplicas {
+		errs = append(errs, ValidationError{
+			Field:   "spec.autoscaling.maxReplicas",
+			Message: fmt.Sprintf("must be at least minReplicas (%d)", minReplicas),
+		})
+	}
+	if as.TargetCPUUtilization < 0 {
+		errs = append(errs, ValidationError{Field: "spec.autoscaling.targetCPUUtilization", Message: "must be a positive percentage"})
+	}
+	return errs
+}
@@ -406,3 +406,772 @@ func TestValidate_RequestExceedsLimit(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_SmokeTests(t *testing.T) {
+	base := func(tests ...SmokeTestConfig) *AppConfig {
+		return &AppConfig{
+			Metadata: Metadata{Name: "myapp"},
+			Spec:     AppSpec{Image: "myapp:v1", Tests: tests},
+		}
+	}
+
+	valid := base(
+		SmokeTestConfig{Name: "health", HTTP: &HTTPTestConfig{Path: "/healthz", ExpectStatus: 200}},
+		SmokeTestConfig{Exec: &ExecTestConfig{Command: []string{"true"}}, Timeout: "10s"},
+	)
+	if err := Validate(valid); err != nil {
+		t.Errorf("expected valid smoke tests, got: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		test  SmokeTestConfig
+		field string
+	}{
+		{"neither check", SmokeTestConfig{Name: "empty"}, "spec.tests[0]"},
+		{"both checks", SmokeTestConfig{HTTP: &HTTPTestConfig{}, Exec: &ExecTestConfig{Command: []string{"true"}}}, "spec.tests[0]"},
+		{"bad status", SmokeTestConfig{HTTP: &HTTPTestConfig{ExpectStatus: 42}}, "spec.tests[0].http.expectStatus"},
+		{"empty command", SmokeTestConfig{Exec: &ExecTestConfig{}}, "spec.tests[0].exec.command"},
+		{"bad timeout", SmokeTestConfig{HTTP: &HTTPTestConfig{}, Timeout: "soon"}, "spec.tests[0].timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(base(tt.test))
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("expected error about %s, got: %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Clusters(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec:     AppSpec{Image: "myapp:v1"},
+		Clusters: []ClusterConfig{
+			{Name: "us-east", Context: "prod-us-east", Region: "us-east-1", Weight: 70},
+			{Name: "eu-west", Context: "prod-eu-west", Weight: 30},
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid clusters, got: %v", err)
+	}
+
+	cfg.Clusters = []ClusterConfig{
+		{Name: "us-east", Context: "prod-us-east"},
+		{Name: "us-east"},
+		{Context: "other", Weight: -1},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, field := range []string{`duplicate cluster "us-east"`, "clusters[1].context", "clusters[2].name", "clusters[2].weight"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected error about %s, got: %v", field, err)
+		}
+	}
+}
+
+func TestValidate_Notifications(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec:     AppSpec{Image: "myapp:v1"},
+		Notifications: &NotificationsConfig{
+			Slack:    &WebhookConfig{URL: "${SLACK_WEBHOOK_URL}"},
+			Events:   []string{"deploy", "rollback"},
+			Template: "{{.App}} {{.Status}}",
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid notifications, got: %v", err)
+	}
+
+	cfg.Notifications = &NotificationsConfig{
+		Teams:    &WebhookConfig{},
+		Events:   []string{"scale"},
+		Template: "{{.App",
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, field := range []string{"notifications.teams.url", "notifications.events[0]", "notifications.template"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected error about %s, got: %v", field, err)
+		}
+	}
+}
+
+func TestValidate_ShareProvider(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec:     AppSpec{Image: "myapp:v1", Share: &ShareConfig{Provider: "cloudflared"}},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid share provider, got: %v", err)
+	}
+
+	cfg.Spec.Share.Provider = "serveo"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "spec.share.provider") {
+		t.Errorf("expected share provider error, got: %v", err)
+	}
+}
+
+func TestValidate_Workload(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec:     AppSpec{Image: "myapp:v1", Workload: WorkloadStatefulSet},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid workload, got: %v", err)
+	}
+
+	cfg.Spec.Workload = "daemonset"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "spec.workload") {
+		t.Errorf("expected workload error, got: %v", err)
+	}
+}
+
+func TestValidate_Processes(t *testing.T) {
+	replicas := 2
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image: "myapp:v1",
+			Processes: map[string]ProcessConfig{
+				"web":    {Replicas: &replicas},
+				"worker": {Command: []string{"bundle", "exec", "sidekiq"}},
+			},
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid processes, got: %v", err)
+	}
+
+	cfg.Spec.Processes["Scheduler"] = ProcessConfig{}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "spec.processes.Scheduler:") || !strings.Contains(err.Error(), "spec.processes.Scheduler.command") {
+		t.Errorf("expected process name and command errors, got: %v", err)
+	}
+}
+
+func TestValidate_SecurityContext(t *testing.T) {
+	root := int64(0)
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image:           "myapp:v1",
+			SecurityContext: &SecurityContextConfig{RunAsUser: &root, Capabilities: []string{"CHOWN"}},
+		},
+	}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "spec.securityContext.runAsUser") || !strings.Contains(err.Error(), "spec.securityContext.capabilities") {
+		t.Errorf("expected restricted profile violations, got: %v", err)
+	}
+
+	cfg.Spec.SecurityProfile = SecurityProfileBaseline
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected root and CHOWN to be allowed by baseline, got: %v", err)
+	}
+
+	cfg.Spec.SecurityContext.Capabilities = []string{"SYS_ADMIN"}
+	cfg.Spec.SecurityContext.SeccompProfile = "Unconfined"
+	err = Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "capability SYS_ADMIN") || !strings.Contains(err.Error(), "Unconfined seccomp") {
+		t.Errorf("expected baseline violations, got: %v", err)
+	}
+
+	cfg.Spec.SecurityContext = nil
+	cfg.Spec.SecurityProfile = "privileged"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "spec.securityProfile") {
+		t.Errorf("expected unknown profile error, got: %v", err)
+	}
+}
+
+func TestValidate_Platform(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec:     AppSpec{Image: "myapp:v1", Arch: ArchARM64},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid arch, got: %v", err)
+	}
+	if got := cfg.Spec.BuildPlatforms(); len(got) != 1 || got[0] != "linux/arm64" {
+		t.Errorf("expected build for linux/arm64, got %v", got)
+	}
+
+	cfg.Spec.Platform = PlatformWindows
+	cfg.Spec.Build = &BuildConfig{Platforms: []string{"linux"}}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "amd64 only") || !strings.Contains(err.Error(), "spec.build.platforms[0]") {
+		t.Errorf("expected arch and build platform errors, got: %v", err)
+	}
+
+	cfg.Spec.Platform = ""
+	cfg.Spec.Build.Platforms = []string{"linux/amd64"}
+	warnings, err := ValidateWithWarnings(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) == 0 || !strings.Contains(strings.Join(warnings, "\n"), "does not include linux/arm64") {
+		t.Errorf("expected build platform mismatch warning, got %v", warnings)
+	}
+}
+
+func TestValidate_SleepSchedule(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image:         "myapp:v1",
+			SleepSchedule: &SleepScheduleConfig{Sleep: "0 20 * * 1-5", Wake: "@daily"},
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid sleep schedule, got: %v", err)
+	}
+
+	cfg.Spec.SleepSchedule.Wake = "7am"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "spec.sleepSchedule.wake") {
+		t.Errorf("expected wake schedule error, got: %v", err)
+	}
+}
+
+func TestValidate_MinKboxVersion(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata:       Metadata{Name: "myapp"},
+		Spec:           AppSpec{Image: "myapp:v1"},
+		MinKboxVersion: "v1.4",
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid minKboxVersion, got: %v", err)
+	}
+
+	cfg.MinKboxVersion = "latest"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "minKboxVersion") {
+		t.Errorf("expected minKboxVersion error, got: %v", err)
+	}
+}
+
+func TestValidate_OverridePatches(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image: "myapp:v1",
+			Overrides: &OverrideConfig{Patches: []PatchConfig{{
+				Target:    PatchTarget{Kind: "Deployment"},
+				JSONPatch: []JSONPatchOperation{{Op: "add", Path: "/spec/paused", Value: true}},
+			}}},
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid patches, got: %v", err)
+	}
+
+	cfg.Spec.Overrides.Patches = append(cfg.Spec.Overrides.Patches,
+		PatchConfig{Target: PatchTarget{Kind: "Service"}},
+		PatchConfig{Target: PatchTarget{Kind: "Service"}, JSONPatch: []JSONPatchOperation{{Op: "upsert", Path: "spec"}}},
+	)
+	err := Validate(cfg)
+	for _, want := range []string{"patches[1]: exactly one of", "patches[2].jsonPatch[0].op", "patches[2].jsonPatch[0].path"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+}
+
+func TestValidate_JobHistoryLimit(t *testing.T) {
+	limit := int32(-1)
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image: "myapp:v1",
+			Jobs:  []JobConfig{{Name: "migrate", Command: []string{"./migrate"}, HistoryLimit: &limit}},
+		},
+	}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "spec.jobs[0].historyLimit") {
+		t.Errorf("expected historyLimit error, got: %v", err)
+	}
+
+	limit = 0
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected historyLimit 0 to be valid, got: %v", err)
+	}
+	if got := cfg.Spec.Jobs[0].RunsToKeep(); got != 0 {
+		t.Errorf("RunsToKeep() = %d, want 0", got)
+	}
+}
+
+func TestValidate_ExternalDependency(t *testing.T) {
+	cfg := &AppConfig{
+		Metadata: Metadata{Name: "myapp"},
+		Spec: AppSpec{
+			Image: "myapp:v1",
+			Dependencies: []DependencyConfig{
+				{Type: "postgres", External: &ExternalDependencyConfig{}},
+				{Type: "redis", External: &ExternalDependencyConfig{Port: 6379, SecretRef: "Redis_Creds"}},
+			},
+		},
+	}
+	err := Validate(cfg)
+	for _, want := range []string{"spec.dependencies[0].external: requires host or secretRef", "spec.dependencies[1].external.port: requires host", "spec.dependencies[1].external.secretRef"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+
+	cfg.Spec.Dependencies = []DependencyConfig{
+		{Type: "postgres", Storage: "10Gi", External: &ExternalDependencyConfig{Host: "db.internal", Port: 5432}},
+	}
+	warnings, err := ValidateWithWarnings(cfg)
+	if err != nil {
+		t.Fatalf("expected a valid external dependency, got: %v", err)
+	}
+	joined := strings.Join(warnings, "\n")
+	if !strings.Contains(joined, "ignored for an external postgres") || !strings.Contains(joined, "no secretRef") {
+		t.Errorf("expected ignored-settings and secretRef warnings, got: %v", warnings)
+	}
+}
+
+func TestValidate_ServiceOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		service ServiceConfig
+		wantErr string
+	}{
+		{"headless", ServiceConfig{ClusterIP: "None"}, ""},
+		{"headless LoadBalancer", ServiceConfig{Type: "LoadBalancer", ClusterIP: "None"}, "spec.service.clusterIP"},
+		{"cluster IP address", ServiceConfig{ClusterIP: "10.0.0.10"}, "spec.service.clusterIP"},
+		{"external name", ServiceConfig{Type: "ExternalName", ExternalName: "db.example.com"}, ""},
+		{"external name missing", ServiceConfig{Type: "ExternalName"}, "spec.service.externalName: required"},
+		{"external name invalid", ServiceConfig{Type: "ExternalName", ExternalName: "not a host"}, "invalid DNS name"},
+		{"external name without type", ServiceConfig{ExternalName: "db.example.com"}, "requires type ExternalName"},
+		{"affinity", ServiceConfig{SessionAffinity: "ClientIP", SessionAffinityTimeout: 300}, ""},
+		{"affinity headless", ServiceConfig{ClusterIP: "None", SessionAffinity: "ClientIP"}, "spec.service.sessionAffinity"},
+		{"affinity unknown", ServiceConfig{SessionAffinity: "Cookie"}, "must be None or ClientIP"},
+		{"affinity timeout without affinity", ServiceConfig{SessionAffinityTimeout: 300}, "requires sessionAffinity ClientIP"},
+		{"traffic policy", ServiceConfig{Type: "LoadBalancer", ExternalTrafficPolicy: "Local"}, ""},
+		{"traffic policy ClusterIP", ServiceConfig{ExternalTrafficPolicy: "Local"}, "requires type NodePort or LoadBalancer"},
+		{"annotations", ServiceConfig{Type: "LoadBalancer", Annotations: map[string]string{"networking.gke.io/load-balancer-type": "Internal"}}, ""},
+		{"annotation key", ServiceConfig{Annotations: map[string]string{"bad key": "x"}}, "spec.service.annotations"},
+		{"dns", ServiceConfig{Type: "LoadBalancer", DNS: &ServiceDNSConfig{Hostname: "api.example.com", TTL: 60}}, ""},
+		{"dns headless", ServiceConfig{ClusterIP: "None", DNS: &ServiceDNSConfig{Hostname: "*.pods.example.com"}}, ""},
+		{"dns ClusterIP", ServiceConfig{DNS: &ServiceDNSConfig{Hostname: "api.example.com"}}, "requires type LoadBalancer or NodePort"},
+		{"dns hostname missing", ServiceConfig{Type: "LoadBalancer", DNS: &ServiceDNSConfig{}}, "spec.service.dns.hostname: required"},
+		{"ports", ServiceConfig{Type: "LoadBalancer", Ports: []ServicePortConfig{{Port: 7777, Protocol: "UDP"}, {Port: 7777}}}, ""},
+		{"port duplicate", ServiceConfig{Ports: []ServicePortConfig{{Name: "game", Port: 8080}}}, "duplicate port 8080/TCP"},
+		{"port name duplicate", ServiceConfig{Ports: []ServicePortConfig{{Name: "http", Port: 9000}}}, "duplicate port name"},
+		{"port name too long", ServiceConfig{Ports: []ServicePortConfig{{Name: "a-very-long-port-name", Port: 9000}}}, "invalid port name"},
+		{"port protocol", ServiceConfig{Ports: []ServicePortConfig{{Port: 9000, Protocol: "SCTP"}}}, "must be TCP or UDP"},
+		{"port range", ServiceConfig{Ports: []ServicePortConfig{{Port: 70000}}}, "spec.service.ports[0].port"},
+		{"health check", ServiceConfig{Type: "LoadBalancer", HealthCheck: &ServiceHealthCheckConfig{Port: 8080, Path: "/healthz"}}, ""},
+		{"health check ClusterIP", ServiceConfig{HealthCheck: &ServiceHealthCheckConfig{}}, "requires type LoadBalancer"},
+		{"health check UDP port", ServiceConfig{Type: "LoadBalancer", Ports: []ServicePortConfig{{Port: 7777, Protocol: "UDP"}}, HealthCheck: &ServiceHealthCheckConfig{Port: 7777}}, "not one of the Service's TCP ports"},
+		{"dns hostname invalid", ServiceConfig{Type: "LoadBalancer", DNS: &ServiceDNSConfig{Hostname: "api_example.com"}}, "invalid DNS name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := tt.service
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080, Service: &svc},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TagStrategy(t *testing.T) {
+	for strategy, wantErr := range map[string]bool{"": false, "gitsha": false, "semver": false, "weekly": true} {
+		cfg := &AppConfig{
+			Metadata: Metadata{Name: "myapp"},
+			Spec:     AppSpec{Port: 8080, Build: &BuildConfig{Dockerfile: "Dockerfile", TagStrategy: strategy}},
+		}
+		err := Validate(cfg)
+		if wantErr && (err == nil || !strings.Contains(err.Error(), "spec.build.tagStrategy")) {
+			t.Errorf("tagStrategy %q: expected a spec.build.tagStrategy error, got %v", strategy, err)
+		}
+		if !wantErr && err != nil {
+			t.Errorf("tagStrategy %q: expected valid, got %v", strategy, err)
+		}
+	}
+}
+
+func TestValidate_Signing(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		build   *BuildConfig
+		signing SigningConfig
+		wantErr string
+	}{
+		{"key", "myapp:v1", nil, SigningConfig{Key: "cosign.key", RequireSigned: true}, ""},
+		{"public key only", "myapp:v1", nil, SigningConfig{PublicKey: "cosign.pub", RequireSigned: true}, ""},
+		{"keyless", "myapp:v1", nil, SigningConfig{Keyless: true, Identity: "ci@acme.dev", Issuer: "https://accounts.google.com"}, ""},
+		{"no key", "myapp:v1", nil, SigningConfig{RequireSigned: true}, "spec.signing.key"},
+		{"key and keyless", "myapp:v1", nil, SigningConfig{Key: "cosign.key", Keyless: true, Identity: "a", Issuer: "b"}, "not both"},
+		{"keyless without identity", "myapp:v1", nil, SigningConfig{Keyless: true}, "spec.signing.identity"},
+		{"build without repository", "", &BuildConfig{}, SigningConfig{Key: "cosign.key"}, "spec.build.repository"},
+		{"build with repository", "", &BuildConfig{Repository: "ghcr.io/acme/myapp", SBOM: true}, SigningConfig{Key: "cosign.key"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signing := tt.signing
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: tt.image, Build: tt.build, Port: 8080, Signing: &signing},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if got := (&SigningConfig{Key: "awskms:///alias/kbox"}).VerificationKey(); got != "awskms:///alias/kbox" {
+		t.Errorf("VerificationKey() of a KMS key = %q", got)
+	}
+}
+
+func TestValidate_ServiceSigning(t *testing.T) {
+	cfg := &MultiServiceConfig{
+		Metadata: Metadata{Name: "shop"},
+		Services: map[string]ServiceSpec{
+			"api": {Build: &BuildConfig{}, Port: 8080, Signing: &SigningConfig{Key: "cosign.key"}},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "services.api.build.repository") {
+		t.Errorf("expected a services.api.build.repository error, got: %v", err)
+	}
+
+	cfg.Services["api"].Build.Repository = "ghcr.io/acme/api"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid, got: %v", err)
+	}
+	app, _ := cfg.ToAppConfig("api")
+	if app.Spec.Signing == nil {
+		t.Error("expected ToAppConfig to keep the service's signing")
+	}
+}
+
+func TestValidate_PDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    int
+		autoscaling bool
+		pdb         PDBConfig
+		wantErr     string
+		wantWarning string
+	}{
+		{name: "min available count", replicas: 3, pdb: PDBConfig{MinAvailable: "2"}},
+		{name: "max unavailable percentage", replicas: 3, pdb: PDBConfig{MaxUnavailable: "25%"}},
+		{name: "both", replicas: 3, pdb: PDBConfig{MinAvailable: "1", MaxUnavailable: "1"}, wantErr: "not both"},
+		{name: "not a number", replicas: 3, pdb: PDBConfig{MinAvailable: "half"}, wantErr: "spec.pdb.minAvailable"},
+		{name: "percentage over 100", replicas: 3, pdb: PDBConfig{MaxUnavailable: "150%"}, wantErr: "between 0% and 100%"},
+		{name: "single replica", replicas: 1, pdb: PDBConfig{MinAvailable: "1"}, wantWarning: "skipped for a single replica"},
+		{name: "single replica autoscaled", replicas: 1, autoscaling: true, pdb: PDBConfig{MinAvailable: "1"}},
+		{name: "min available all replicas", replicas: 3, pdb: PDBConfig{MinAvailable: "3"}, wantWarning: "node drains"},
+		{name: "min available all percent", replicas: 3, pdb: PDBConfig{MinAvailable: "100%"}, wantWarning: "node drains"},
+		{name: "max unavailable zero", replicas: 3, pdb: PDBConfig{MaxUnavailable: "0"}, wantWarning: "node drains"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb := tt.pdb
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080, Replicas: tt.replicas, PDB: &pdb},
+			}
+			if tt.autoscaling {
+				cfg.Spec.Autoscaling = &AutoscalingConfig{Enabled: true, MaxReplicas: 5}
+			}
+			warnings, err := ValidateWithWarnings(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+			joined := strings.Join(warnings, "\n")
+			if tt.wantWarning == "" && strings.Contains(joined, "spec.pdb") {
+				t.Errorf("expected no pdb warning, got %q", joined)
+			}
+			if tt.wantWarning != "" && !strings.Contains(joined, tt.wantWarning) {
+				t.Errorf("expected a warning containing %q, got %q", tt.wantWarning, joined)
+			}
+		})
+	}
+}
+
+func TestValidate_Rollout(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	tests := []struct {
+		name     string
+		workload string
+		rollout  RolloutConfig
+		wantErr  string
+	}{
+		{name: "no surge", rollout: RolloutConfig{MaxSurge: "0", MaxUnavailable: "1"}},
+		{name: "percentages", rollout: RolloutConfig{MaxSurge: "50%", MaxUnavailable: "0%"}},
+		{name: "timings", rollout: RolloutConfig{MinReadySeconds: 10, ProgressDeadlineSeconds: 300, RevisionHistoryLimit: intPtr(3)}},
+		{name: "both zero", rollout: RolloutConfig{MaxSurge: "0", MaxUnavailable: "0%"}, wantErr: "can't both be 0"},
+		{name: "not a number", rollout: RolloutConfig{MaxSurge: "lots"}, wantErr: "spec.rollout.maxSurge"},
+		{name: "negative count", rollout: RolloutConfig{MaxUnavailable: "-1"}, wantErr: "spec.rollout.maxUnavailable"},
+		{name: "deadline before ready", rollout: RolloutConfig{MinReadySeconds: 60, ProgressDeadlineSeconds: 30}, wantErr: "greater than minReadySeconds"},
+		{name: "negative history", rollout: RolloutConfig{RevisionHistoryLimit: intPtr(-1)}, wantErr: "spec.rollout.revisionHistoryLimit"},
+		{name: "statefulset timings", workload: WorkloadStatefulSet, rollout: RolloutConfig{MinReadySeconds: 10, RevisionHistoryLimit: intPtr(2)}},
+		{name: "statefulset surge", workload: WorkloadStatefulSet, rollout: RolloutConfig{MaxSurge: "1"}, wantErr: "one at a time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollout := tt.rollout
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080, Workload: tt.workload, Rollout: &rollout},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Metadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*AppConfig)
+		wantErr string
+	}{
+		{name: "labels and annotations", modify: func(c *AppConfig) {
+			c.Metadata.Labels = map[string]string{"team": "payments"}
+			c.Metadata.Annotations = map[string]string{"argocd.argoproj.io/sync-wave": "1"}
+			c.Spec.Annotations = &ResourceMetadata{Pod: map[string]string{"prometheus.io/scrape": "true"}}
+			c.Spec.Labels = &ResourceMetadata{Service: map[string]string{"tier": "web"}}
+		}},
+		{name: "reserved label", modify: func(c *AppConfig) {
+			c.Metadata.Labels = map[string]string{"app": "other"}
+		}, wantErr: "set by kbox"},
+		{name: "reserved kbox prefix", modify: func(c *AppConfig) {
+			c.Spec.Labels = &ResourceMetadata{Deployment: map[string]string{"kbox.dev/app": "other"}}
+		}, wantErr: "spec.labels.deployment"},
+		{name: "invalid label value", modify: func(c *AppConfig) {
+			c.Spec.Labels = &ResourceMetadata{Pod: map[string]string{"team": "pay ments"}}
+		}, wantErr: "invalid value"},
+		{name: "invalid annotation key", modify: func(c *AppConfig) {
+			c.Metadata.Annotations = map[string]string{"bad key": "x"}
+		}, wantErr: "metadata.annotations"},
+		{name: "ingress annotations without ingress", modify: func(c *AppConfig) {
+			c.Spec.Annotations = &ResourceMetadata{Ingress: map[string]string{"alb.ingress.kubernetes.io/scheme": "internal"}}
+		}, wantErr: "requires ingress.enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080},
+			}
+			tt.modify(cfg)
+			err := Validate(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Autoscaling(t *testing.T) {
+	tests := []struct {
+		name    string
+		as      AutoscalingConfig
+		wantErr string
+	}{
+		{name: "bounds", as: AutoscalingConfig{Enabled: true, MinReplicas: 2, MaxReplicas: 10, TargetCPUUtilization: 70}},
+		{name: "defaults", as: AutoscalingConfig{Enabled: true}},
+		{name: "disabled ignores bounds", as: AutoscalingConfig{MinReplicas: 8, MaxReplicas: 6}},
+		{name: "max below min", as: AutoscalingConfig{Enabled: true, MinReplicas: 8, MaxReplicas: 6}, wantErr: "at least minReplicas (8)"},
+		{name: "negative min", as: AutoscalingConfig{Enabled: true, MinReplicas: -1, MaxReplicas: 3}, wantErr: "spec.autoscaling.minReplicas"},
+		{name: "negative cpu", as: AutoscalingConfig{Enabled: true, MaxReplicas: 3, TargetCPUUtilization: -5}, wantErr: "spec.autoscaling.targetCPUUtilization"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := tt.as
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080, Autoscaling: &as},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_DependencyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		dep     DependencyConfig
+		wantErr string
+	}{
+		{name: "settings", dep: DependencyConfig{Type: "postgres", Config: map[string]string{"max_connections": "200", "shared_buffers": "256MB"}, Env: map[string]string{"POSTGRES_INITDB_ARGS": "--data-checksums"}}},
+		{name: "dashed setting", dep: DependencyConfig{Type: "redis", Config: map[string]string{"maxmemory-policy": "allkeys-lru"}}},
+		{name: "option injection", dep: DependencyConfig{Type: "postgres", Config: map[string]string{"-c ssl": "off"}}, wantErr: "spec.dependencies[0].config.-c ssl"},
+		{name: "bad env name", dep: DependencyConfig{Type: "mysql", Env: map[string]string{"1BAD": "x"}}, wantErr: "spec.dependencies[0].env.1BAD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AppConfig{
+				Metadata: Metadata{Name: "myapp"},
+				Spec:     AppSpec{Image: "myapp:v1", Port: 8080, Dependencies: []DependencyConfig{tt.dep}},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_MultiServiceReadiness(t *testing.T) {
+	tests := []struct {
+		name    string
+		web     ServiceSpec
+		api     ServiceSpec
+		wantErr string
+	}{
+		{name: "wait with readiness", web: ServiceSpec{Image: "web:v1", DependsOn: []string{"api"}, WaitForDependencies: true}, api: ServiceSpec{Image: "api:v1", Readiness: &ReadinessEndpoint{Path: "/ready", Port: 9090}}},
+		{name: "nothing to wait for", web: ServiceSpec{Image: "web:v1", WaitForDependencies: true}, api: ServiceSpec{Image: "api:v1"}, wantErr: "services.web.waitForDependencies"},
+		{name: "relative path", web: ServiceSpec{Image: "web:v1"}, api: ServiceSpec{Image: "api:v1", Readiness: &ReadinessEndpoint{Path: "ready"}}, wantErr: "services.api.readiness.path"},
+		{name: "bad port", web: ServiceSpec{Image: "web:v1"}, api: ServiceSpec{Image: "api:v1", Readiness: &ReadinessEndpoint{Port: 70000}}, wantErr: "services.api.readiness.port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &MultiServiceConfig{
+				Metadata: Metadata{Name: "shop"},
+				Services: map[string]ServiceSpec{"web": tt.web, "api": tt.api},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_SharedSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]SharedSecretConfig
+		wantErr string
+	}{
+		{name: "valid", secrets: map[string]SharedSecretConfig{"internal-api": {Keys: []string{"INTERNAL_API_TOKEN"}, Services: []string{"api", "web"}, RotateEvery: "720h"}}},
+		{name: "unknown service", secrets: map[string]SharedSecretConfig{"internal-api": {Keys: []string{"TOKEN"}, Services: []string{"admin"}}}, wantErr: `unknown service "admin"`},
+		{name: "bad key", secrets: map[string]SharedSecretConfig{"internal-api": {Keys: []string{"1TOKEN"}, Services: []string{"api"}}}, wantErr: "sharedSecrets.internal-api.keys"},
+		{name: "no keys", secrets: map[string]SharedSecretConfig{"internal-api": {Services: []string{"api"}}}, wantErr: "at least one key"},
+		{name: "env clash", secrets: map[string]SharedSecretConfig{"internal-api": {Keys: []string{"LOG_LEVEL"}, Services: []string{"api"}}}, wantErr: "also set in services.api.env"},
+		{name: "injected twice", secrets: map[string]SharedSecretConfig{
+			"a": {Keys: []string{"TOKEN"}, Services: []string{"web"}},
+			"b": {Keys: []string{"TOKEN"}, Services: []string{"web"}},
+		}, wantErr: "already injected into web by sharedSecrets.a"},
+		{name: "bad rotation", secrets: map[string]SharedSecretConfig{"internal-api": {Keys: []string{"TOKEN"}, Services: []string{"api"}, RotateEvery: "30d"}}, wantErr: "sharedSecrets.internal-api.rotateEvery"},
+		{name: "dependency name", secrets: map[string]SharedSecretConfig{"postgres": {Keys: []string{"TOKEN"}, Services: []string{"api"}}}, wantErr: "shop-postgres would have the name of the postgres dependency's Secret"},
+		{name: "service secret name", secrets: map[string]SharedSecretConfig{"api-secrets": {Keys: []string{"TOKEN"}, Services: []string{"api"}}}, wantErr: "a Secret of service api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &MultiServiceConfig{
+				Metadata: Metadata{Name: "shop"},
+				Services: map[string]ServiceSpec{
+					"api": {Image: "api:v1", Env: map[string]string{"LOG_LEVEL": "info"}},
+					"web": {Image: "web:v1"},
+				},
+				SharedSecrets: tt.secrets,
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TrafficSplit(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		name     string
+		split    *TrafficSplit
+		ingress  bool
+		workload string
+		class    string
+		wantErr  string
+	}{
+		{name: "valid", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20}, ingress: true},
+		{name: "no canary image", split: &TrafficSplit{Weight: 20}, ingress: true, wantErr: "spec.traffic.split.canaryImage"},
+		{name: "weight over 100", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 120}, ingress: true, wantErr: "from 0 to 100"},
+		{name: "no replicas", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20, Replicas: &zero}, ingress: true, wantErr: "spec.traffic.split.replicas"},
+		{name: "no ingress", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20}, wantErr: "needs spec.ingress.enabled"},
+		{name: "statefulset", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20}, ingress: true, workload: WorkloadStatefulSet, wantErr: "as a Deployment"},
+		{name: "nginx class", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20}, ingress: true, class: "nginx"},
+		{name: "traefik class", split: &TrafficSplit{CanaryImage: "myapp:v2", Weight: 20}, ingress: true, class: "traefik", wantErr: "needs NGINX Ingress"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AppConfig{
+				APIVersion: DefaultAPIVersion,
+				Kind:       "App",
+				Metadata:   Metadata{Name: "myapp"},
+				Spec: AppSpec{
+					Image:    "myapp:v1",
+					Workload: tt.workload,
+					Traffic:  &TrafficConfig{Split: tt.split},
+					Ingress:  &IngressConfig{Enabled: tt.ingress, Host: "myapp.example.com", IngressClass: tt.class},
+				},
+			}
+			err := Validate(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
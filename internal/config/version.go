@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+)
+
+// kboxVersionPattern matches versions such as "v1.4", "1.4.2" or "v1.4.2-rc.1"
+var kboxVersionPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}([-+][0-9A-Za-z.-]+)?$`)
+
+func validateMinKboxVersion(version string) ValidationErrors {
+	if version == "" || kboxVersionPattern.MatchString(version) {
+		return nil
+	}
+	return ValidationErrors{{
+		Field:   "minKboxVersion",
+		Message: fmt.Sprintf("invalid version %q (expected e.g. \"v1.4\" or \"1.4.2\")", version),
+	}}
+}
+
+// ReadMinKboxVersion returns the minKboxVersion of the config at path
+// without loading the rest of it, so it works for configs this kbox can't
+// parse yet. It returns "" when there is none or the file can't be read.
+func ReadMinKboxVersion(path string) string {
+	data, err := ReadConfig(path)
+	if err != nil {
+		return ""
+	}
+	var header struct {
+		MinKboxVersion string `json:"minKboxVersion"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return ""
+	}
+	return header.MinKboxVersion
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -22,11 +23,16 @@ func LoadYAMLWithComments(path string) (*yaml.Node, error) {
 
 // SaveYAMLWithComments writes a YAML node back to file, preserving comments
 func SaveYAMLWithComments(path string, node *yaml.Node) error {
-	data, err := yaml.Marshal(node)
-	if err != nil {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) // As kbox init writes it, and most kbox.yaml files are
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return os.WriteFile(path, buf.Bytes(), 0644)
 }
 
 // FindMapKey finds a key in a YAML mapping node and returns its value node
@@ -53,6 +59,58 @@ func AddMapKey(node *yaml.Node, key string, value *yaml.Node) {
 	)
 }
 
+// EnsureMapNode returns the mapping at key in node, creating it, or
+// replacing an empty value such as "ingress:", when there isn't one
+func EnsureMapNode(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+		if node.Content[i+1].Kind != yaml.MappingNode {
+			node.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode}
+		}
+		return node.Content[i+1]
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	AddMapKey(node, key, value)
+	return value
+}
+
+// SetMapScalar sets key in a mapping node to a scalar, keeping the comments
+// of an existing value. tag is the YAML tag, such as "!!bool" or "!!int";
+// "" writes a string, quoted when it would read as another type.
+func SetMapScalar(node *yaml.Node, key, value, tag string) {
+	if tag == "" {
+		tag = "!!str"
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			existing := node.Content[i+1]
+			if existing.Kind != yaml.ScalarNode {
+				node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+				return
+			}
+			existing.Value, existing.Tag, existing.Style = value, tag, 0
+			return
+		}
+	}
+	AddMapKey(node, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+}
+
+// RemoveMapKey removes key from a mapping node, and reports whether it was there
+func RemoveMapKey(node *yaml.Node, key string) bool {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // AddToSequence adds an item to a YAML sequence node
 func AddToSequence(seq *yaml.Node, item *yaml.Node) {
 	if seq == nil || seq.Kind != yaml.SequenceNode {
@@ -159,3 +217,26 @@ func EnsureDependenciesNode(root *yaml.Node) *yaml.Node {
 
 	return depsNode
 }
+
+// SetDependencyVersion sets the version of the depType dependency in
+// spec.dependencies, and reports whether there is one. Versions are written
+// as strings, quoted when they look like numbers (e.g. "16").
+func SetDependencyVersion(root *yaml.Node, depType, version string) bool {
+	deps := FindMapKey(FindMapKey(root, "spec"), "dependencies")
+	if deps == nil || deps.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range deps.Content {
+		if typeNode := FindMapKey(item, "type"); typeNode == nil || typeNode.Value != depType {
+			continue
+		}
+		if versionNode := FindMapKey(item, "version"); versionNode != nil {
+			versionNode.Value = version
+			versionNode.Tag = "!!str"
+		} else {
+			AddMapKey(item, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: version})
+		}
+		return true
+	}
+	return false
+}
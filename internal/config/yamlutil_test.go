@@ -416,3 +416,34 @@ spec:
 		t.Error("postgres dependency should still exist")
 	}
 }
+
+func TestSetDependencyVersion(t *testing.T) {
+	content := `spec:
+  dependencies:
+    # The app's database
+    - type: postgres
+      version: "15" # pinned for the 2024 data
+    - type: redis
+`
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		t.Fatal(err)
+	}
+	root := GetRootDocument(&node)
+	if !SetDependencyVersion(root, "postgres", "16") || !SetDependencyVersion(root, "redis", "7") {
+		t.Fatal("expected both dependencies to be found")
+	}
+	if SetDependencyVersion(root, "mysql", "8") {
+		t.Error("expected mysql not to be found")
+	}
+
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`version: "16" # pinned for the 2024 data`, "# The app's database", `version: "7"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
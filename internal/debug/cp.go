@@ -0,0 +1,231 @@
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// CopySpec is one side of a kbox cp: a local path, or a path in a pod
+type CopySpec struct {
+	Pod  string // app or pod name; empty for a local path
+	Path string
+}
+
+// IsRemote reports whether the spec refers to a pod
+func (s CopySpec) IsRemote() bool {
+	return s.Pod != ""
+}
+
+// ParseCopySpec parses "<pod>:<path>" or a local path.
+// Like kubectl cp, anything with a slash before the first colon is local.
+func ParseCopySpec(arg string) (CopySpec, error) {
+	pod, p, ok := strings.Cut(arg, ":")
+	if !ok || strings.ContainsAny(pod, `/\`) {
+		return CopySpec{Path: arg}, nil
+	}
+	if pod == "" {
+		return CopySpec{}, fmt.Errorf("invalid copy spec %q: missing pod name before ':'", arg)
+	}
+	if p == "" {
+		return CopySpec{}, fmt.Errorf("invalid copy spec %q: missing path after ':'", arg)
+	}
+	return CopySpec{Pod: pod, Path: p}, nil
+}
+
+// CopyToPod copies a local file or directory to remotePath in a pod container.
+// A remotePath ending in "/" is treated as a directory to copy into.
+func CopyToPod(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName, container, localPath, remotePath string) error {
+	if _, err := os.Stat(localPath); err != nil {
+		return err
+	}
+
+	destDir, name := path.Split(remotePath)
+	if name == "" {
+		name = filepath.Base(localPath)
+	}
+	if destDir == "" {
+		destDir = "."
+	}
+
+	// Stream the tar archive straight into tar running in the container
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(writer, localPath, name))
+	}()
+
+	var stderr bytes.Buffer
+	err := execInPod(ctx, client, config, namespace, podName, ShellOptions{
+		Container: container,
+		Command:   []string{"tar", "-xmf", "-", "-C", destDir},
+		Stdin:     reader,
+		Stdout:    io.Discard,
+		Stderr:    &stderr,
+	})
+	reader.Close()
+	if err != nil {
+		return copyExecError(err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFromPod copies a file or directory at remotePath in a pod container to localPath.
+// If localPath is an existing directory, the copy is placed inside it.
+func CopyFromPod(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName, container, remotePath, localPath string) error {
+	remotePath = path.Clean(remotePath)
+	srcDir, name := path.Split(remotePath)
+	if srcDir == "" {
+		srcDir = "."
+	}
+
+	if fi, err := os.Stat(localPath); err == nil && fi.IsDir() {
+		localPath = filepath.Join(localPath, name)
+	}
+
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	go func() {
+		err := execInPod(ctx, client, config, namespace, podName, ShellOptions{
+			Container: container,
+			Command:   []string{"tar", "-cf", "-", "-C", srcDir, name},
+			Stdout:    writer,
+			Stderr:    &stderr,
+		})
+		if err != nil {
+			err = copyExecError(err, stderr.String())
+		}
+		writer.CloseWithError(err)
+	}()
+
+	err := extractTar(reader, name, localPath)
+	reader.Close()
+	return err
+}
+
+// copyExecError explains the common failure of a container without tar
+func copyExecError(err error, stderr string) error {
+	stderr = strings.TrimSpace(stderr)
+	if strings.Contains(stderr, "not found") || strings.Contains(err.Error(), "executable file not found") {
+		return fmt.Errorf("tar is not available in the container\n  → kbox cp needs tar in the image (distroless images don't include it)")
+	}
+	if stderr != "" {
+		return fmt.Errorf("%w: %s", err, stderr)
+	}
+	return err
+}
+
+// writeTar archives localPath (a file or directory tree) with its root renamed to name
+func writeTar(w io.Writer, localPath, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(localPath, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractTar writes the entries under root in the archive to dest, refusing any
+// entry that would land outside dest. Symlinks are skipped.
+func extractTar(r io.Reader, root, dest string) error {
+	dest = filepath.Clean(dest)
+	tr := tar.NewReader(r)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		var rel string
+		switch {
+		case name == root:
+			rel = ""
+		case strings.HasPrefix(name, root+"/"):
+			rel = strings.TrimPrefix(name, root+"/")
+		default:
+			continue
+		}
+		found = true
+
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if target != dest && !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to extract %q outside %s", hdr.Name, dest)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s: no such file or directory", root)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCopySpec(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    CopySpec
+		wantErr bool
+	}{
+		{"myapp:/tmp/heap.hprof", CopySpec{Pod: "myapp", Path: "/tmp/heap.hprof"}, false},
+		{"./fixtures", CopySpec{Path: "./fixtures"}, false},
+		{"dir/a:b", CopySpec{Path: "dir/a:b"}, false},
+		{"heap.hprof", CopySpec{Path: "heap.hprof"}, false},
+		{":/tmp", CopySpec{}, true},
+		{"myapp:", CopySpec{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseCopySpec(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "nested"), 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(src, "nested", "b.txt"), []byte("b"), 0600)
+
+	var buf bytes.Buffer
+	if err := writeTar(&buf, src, "fixtures"); err != nil {
+		t.Fatalf("writeTar failed: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := extractTar(&buf, "fixtures", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dest, "a.txt")); string(data) != "a" {
+		t.Errorf("expected a.txt contents, got %q", data)
+	}
+	fi, err := os.Stat(filepath.Join(dest, "nested", "b.txt"))
+	if err != nil {
+		t.Fatalf("expected nested file: %v", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", fi.Mode().Perm())
+	}
+}
+
+func TestExtractTar_SingleFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "heap.hprof")
+	os.WriteFile(src, []byte("dump"), 0644)
+
+	var buf bytes.Buffer
+	if err := writeTar(&buf, src, "heap.hprof"); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "copy.hprof")
+	if err := extractTar(&buf, "heap.hprof", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "dump" {
+		t.Errorf("expected copied file, got %q", data)
+	}
+}
+
+func TestExtractTar_IgnoresTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "out/a.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("a"))
+	tw.WriteHeader(&tar.Header{Name: "out/../../evil", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	base := t.TempDir()
+	dest := filepath.Join(base, "nested", "out")
+	if err := extractTar(&buf, "out", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); err != nil {
+		t.Errorf("expected a.txt: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "evil")); err == nil {
+		t.Error("traversal entry was written")
+	}
+}
+
+func TestExtractTar_MissingRoot(t *testing.T) {
+	var buf bytes.Buffer
+	tar.NewWriter(&buf).Close()
+	if err := extractTar(&buf, "heap.hprof", t.TempDir()); err == nil {
+		t.Error("expected error for an archive without the requested path")
+	}
+}
@@ -0,0 +1,277 @@
+package debug
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/format"
+)
+
+// maxTraceLines caps how many lines of a stack trace are kept
+const maxTraceLines = 80
+
+// CrashInfo describes the most recent container crash of an app
+type CrashInfo struct {
+	Pod        string      `json:"pod"`
+	Container  string      `json:"container"`
+	ExitCode   int32       `json:"exitCode"`
+	Reason     string      `json:"reason,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	FinishedAt time.Time   `json:"finishedAt"`
+	Restarts   int32       `json:"restarts"`
+	Logs       []string    `json:"logs,omitempty"`
+	Trace      *StackTrace `json:"trace,omitempty"`
+}
+
+// StackTrace is a stack trace or panic block extracted from logs
+type StackTrace struct {
+	Language string   `json:"language"` // go, python, node, java
+	Lines    []string `json:"lines"`
+}
+
+// FindLastCrash returns the most recent terminated container across the given pods,
+// or nil if none of them has crashed.
+func FindLastCrash(ctx context.Context, client kubernetes.Interface, namespace string, pods []PodInfo) (*CrashInfo, error) {
+	var latest *CrashInfo
+	for _, p := range pods {
+		pod, err := client.CoreV1().Pods(namespace).Get(ctx, p.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s: %w", p.Name, err)
+		}
+		if crash := lastCrashInPod(pod); crash != nil {
+			if latest == nil || crash.FinishedAt.After(latest.FinishedAt) {
+				latest = crash
+			}
+		}
+	}
+	return latest, nil
+}
+
+// lastCrashInPod returns the most recent non-zero termination of any container in the pod
+func lastCrashInPod(pod *corev1.Pod) *CrashInfo {
+	var latest *CrashInfo
+	for _, cs := range pod.Status.ContainerStatuses {
+		t := cs.LastTerminationState.Terminated
+		if cs.State.Terminated != nil {
+			t = cs.State.Terminated
+		}
+		if t == nil || (t.ExitCode == 0 && t.Reason != "OOMKilled") {
+			continue
+		}
+		if latest != nil && !t.FinishedAt.After(latest.FinishedAt) {
+			continue
+		}
+		latest = &CrashInfo{
+			Pod:        pod.Name,
+			Container:  cs.Name,
+			ExitCode:   t.ExitCode,
+			Reason:     t.Reason,
+			Message:    t.Message,
+			FinishedAt: t.FinishedAt.Time,
+			Restarts:   cs.RestartCount,
+		}
+	}
+	return latest
+}
+
+// FetchCrashLogs loads the logs of the crashed container instance and extracts its stack trace.
+// A container that is still terminated (not yet restarted) has its logs as the current instance.
+func FetchCrashLogs(ctx context.Context, client kubernetes.Interface, namespace string, crash *CrashInfo, tailLines int64) error {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, crash.Pod, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s: %w", crash.Pod, err)
+	}
+
+	previous := true
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == crash.Container && cs.State.Terminated != nil {
+			previous = false
+		}
+	}
+
+	req := client.CoreV1().Pods(namespace).GetLogs(crash.Pod, &corev1.PodLogOptions{
+		Container: crash.Container,
+		Previous:  previous,
+		TailLines: &tailLines,
+	})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch logs of crashed container: %w", err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	buf := make([]byte, 0, 1024*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		crash.Logs = append(crash.Logs, scanner.Text())
+	}
+
+	crash.Trace = ExtractStackTrace(crash.Logs)
+	return scanner.Err()
+}
+
+var (
+	goPanicStart     = regexp.MustCompile(`^(panic: |fatal error: )`)
+	goFrame          = regexp.MustCompile(`^(goroutine \d+ \[|\t|\S+\(.*\)$|exit status \d+|\[signal |created by )`)
+	pythonStart      = regexp.MustCompile(`^Traceback \(most recent call last\):`)
+	pythonException  = regexp.MustCompile(`^[A-Za-z_][\w.]*(Error|Exception|Exit|Interrupt|Warning)\b`)
+	jsFrame          = regexp.MustCompile(`^\s+at\s`)
+	nodeLocation     = regexp.MustCompile(`\.(js|mjs|cjs|ts):\d+$`)
+	jsErrorLine      = regexp.MustCompile(`^(Uncaught )?([A-Z]\w*)?(Error|Exception)(\s*\[[\w_]+\])?(:|$)`)
+	javaExceptionTop = regexp.MustCompile(`^(Exception in thread "[^"]*" )?([a-z][\w$]*\.)+[A-Z][\w$]*(Exception|Error|Throwable)(: .*)?$`)
+	javaContinuation = regexp.MustCompile(`^(\s+at\s|\s*\.\.\. \d+ more|Caused by: |\s+Suppressed: )`)
+)
+
+// ExtractStackTrace finds the last stack trace or panic block in the log lines.
+// Go, Python, Node.js and Java formats are recognised. Returns nil when none is found.
+func ExtractStackTrace(lines []string) *StackTrace {
+	var best *StackTrace
+	bestStart := -1
+
+	consider := func(start int, trace *StackTrace) {
+		if trace != nil && start >= bestStart {
+			best, bestStart = trace, start
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case goPanicStart.MatchString(line):
+			consider(i, extractGo(lines, i))
+		case pythonStart.MatchString(line):
+			consider(i, extractPython(lines, i))
+		case javaExceptionTop.MatchString(line) && i+1 < len(lines) && jsFrame.MatchString(lines[i+1]) && isJavaFrame(lines[i+1]):
+			consider(i, extractJava(lines, i))
+		case jsErrorLine.MatchString(line) && i+1 < len(lines) && jsFrame.MatchString(lines[i+1]):
+			consider(i, extractNode(lines, i))
+		}
+	}
+	return best
+}
+
+func extractGo(lines []string, start int) *StackTrace {
+	end := start + 1
+	for end < len(lines) && (lines[end] == "" || goFrame.MatchString(lines[end])) {
+		end++
+	}
+	return newTrace("go", lines[start:end])
+}
+
+func extractPython(lines []string, start int) *StackTrace {
+	end := start + 1
+	for end < len(lines) {
+		line := lines[end]
+		end++
+		// The exception line ends the traceback; chained tracebacks continue after it
+		if !strings.HasPrefix(line, " ") && pythonException.MatchString(line) {
+			break
+		}
+	}
+	return newTrace("python", lines[start:end])
+}
+
+func extractNode(lines []string, start int) *StackTrace {
+	// Uncaught exceptions are preceded by "file.js:LINE", the source line and a caret
+	from := start
+	for k := start - 1; k >= 0 && k >= start-5; k-- {
+		if nodeLocation.MatchString(lines[k]) {
+			from = k
+			break
+		}
+	}
+	end := start + 1
+	for end < len(lines) && jsFrame.MatchString(lines[end]) {
+		end++
+	}
+	return newTrace("node", lines[from:end])
+}
+
+func extractJava(lines []string, start int) *StackTrace {
+	end := start + 1
+	for end < len(lines) && javaContinuation.MatchString(lines[end]) {
+		end++
+	}
+	return newTrace("java", lines[start:end])
+}
+
+// isJavaFrame distinguishes "at com.example.Foo.bar(Foo.java:10)" from Node frames
+func isJavaFrame(line string) bool {
+	return strings.Contains(line, ".java:") || strings.Contains(line, "(Native Method)") || strings.Contains(line, "(Unknown Source)")
+}
+
+func newTrace(language string, lines []string) *StackTrace {
+	lines = trimBlank(lines)
+	if len(lines) == 0 {
+		return nil
+	}
+	if len(lines) > maxTraceLines {
+		lines = append(append([]string{}, lines[:maxTraceLines]...), fmt.Sprintf("... (%d more lines)", len(lines)-maxTraceLines))
+	}
+	return &StackTrace{Language: language, Lines: lines}
+}
+
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// PrintCrash writes the crash summary, highlighted stack trace and final log lines
+func PrintCrash(w io.Writer, crash *CrashInfo, contextLines int) {
+	reason := crash.Reason
+	if reason == "" {
+		reason = "Error"
+	}
+	fmt.Fprintf(w, "\033[1mLast crash:\033[0m %s (container %s)\n", crash.Pod, crash.Container)
+	fmt.Fprintf(w, "  Exit code: %d (%s)\n", crash.ExitCode, reason)
+	if !crash.FinishedAt.IsZero() {
+		fmt.Fprintf(w, "  Crashed:   %s\n", format.Ago(crash.FinishedAt))
+	}
+	fmt.Fprintf(w, "  Restarts:  %d\n", crash.Restarts)
+	if crash.Message != "" {
+		fmt.Fprintf(w, "  Message:   %s\n", strings.TrimSpace(crash.Message))
+	}
+	fmt.Fprintln(w)
+
+	if crash.Trace != nil {
+		fmt.Fprintf(w, "\033[31m=== Stack trace (%s) ===\033[0m\n", crash.Trace.Language)
+		for _, line := range crash.Trace.Lines {
+			fmt.Fprintf(w, "\033[31m%s\033[0m\n", line)
+		}
+		fmt.Fprintln(w)
+	} else if crash.Reason == "OOMKilled" {
+		fmt.Fprintln(w, "No stack trace: the container was killed for exceeding its memory limit.")
+		fmt.Fprintln(w)
+	} else {
+		fmt.Fprintln(w, "No stack trace found in the crashed container's logs.")
+		fmt.Fprintln(w)
+	}
+
+	if len(crash.Logs) == 0 {
+		fmt.Fprintln(w, "(crashed container produced no logs)")
+		return
+	}
+	tail := crash.Logs
+	if contextLines > 0 && len(tail) > contextLines {
+		tail = tail[len(tail)-contextLines:]
+	}
+	fmt.Fprintf(w, "\033[33m=== Last %d log lines before crash ===\033[0m\n", len(tail))
+	for _, line := range tail {
+		fmt.Fprintln(w, line)
+	}
+}
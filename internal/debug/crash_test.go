@@ -0,0 +1,158 @@
+package debug
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExtractStackTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		logs      string
+		language  string
+		firstLine string
+		lastLine  string
+	}{
+		{
+			name: "go panic",
+			logs: `2024/01/01 server starting
+panic: runtime error: index out of range [5] with length 3
+
+goroutine 1 [running]:
+main.handler(...)
+	/app/main.go:12
+github.com/acme/app/server.(*Server).Serve(0xc000010000)
+	/app/server/server.go:40 +0x1d
+exit status 2`,
+			language:  "go",
+			firstLine: "panic: runtime error: index out of range [5] with length 3",
+			lastLine:  "exit status 2",
+		},
+		{
+			name: "python traceback",
+			logs: `INFO starting worker
+Traceback (most recent call last):
+  File "/app/main.py", line 10, in <module>
+    main()
+  File "/app/main.py", line 6, in main
+    raise ValueError("bad config")
+ValueError: bad config
+INFO shutting down`,
+			language:  "python",
+			firstLine: "Traceback (most recent call last):",
+			lastLine:  "ValueError: bad config",
+		},
+		{
+			name: "node uncaught exception",
+			logs: `listening on 3000
+/app/server.js:10
+    throw new Error('boom');
+    ^
+
+Error: boom
+    at Object.<anonymous> (/app/server.js:10:11)
+    at Module._compile (node:internal/modules/cjs/loader:1256:14)`,
+			language:  "node",
+			firstLine: "/app/server.js:10",
+			lastLine:  "    at Module._compile (node:internal/modules/cjs/loader:1256:14)",
+		},
+		{
+			name: "java exception with cause",
+			logs: `Started Application in 2.1 seconds
+Exception in thread "main" java.lang.IllegalStateException: failed to start
+	at com.acme.App.main(App.java:20)
+Caused by: java.io.IOException: connection refused
+	at com.acme.Db.connect(Db.java:42)
+	... 1 more`,
+			language:  "java",
+			firstLine: `Exception in thread "main" java.lang.IllegalStateException: failed to start`,
+			lastLine:  "\t... 1 more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := ExtractStackTrace(strings.Split(tt.logs, "\n"))
+			if trace == nil {
+				t.Fatal("expected a stack trace")
+			}
+			if trace.Language != tt.language {
+				t.Errorf("expected language %s, got %s", tt.language, trace.Language)
+			}
+			if trace.Lines[0] != tt.firstLine {
+				t.Errorf("expected first line %q, got %q", tt.firstLine, trace.Lines[0])
+			}
+			if last := trace.Lines[len(trace.Lines)-1]; last != tt.lastLine {
+				t.Errorf("expected last line %q, got %q", tt.lastLine, last)
+			}
+		})
+	}
+
+	t.Run("returns the last trace", func(t *testing.T) {
+		logs := []string{
+			"Traceback (most recent call last):",
+			"  File \"a.py\", line 1",
+			"KeyError: 'x'",
+			"retrying",
+			"panic: second failure",
+			"",
+			"goroutine 1 [running]:",
+			"main.main()",
+		}
+		trace := ExtractStackTrace(logs)
+		if trace == nil || trace.Language != "go" {
+			t.Fatalf("expected the later go panic, got %+v", trace)
+		}
+	})
+
+	t.Run("no trace", func(t *testing.T) {
+		if trace := ExtractStackTrace([]string{"starting", "listening on :8080", "error: db unreachable"}); trace != nil {
+			t.Errorf("expected no trace, got %+v", trace)
+		}
+	})
+}
+
+func TestLastCrashInPod(t *testing.T) {
+	older := metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1"},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "sidecar",
+					RestartCount: 1,
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 1, FinishedAt: older,
+					}},
+				},
+				{
+					Name:         "myapp",
+					RestartCount: 4,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 2, Reason: "Error", FinishedAt: newer,
+					}},
+				},
+			},
+		},
+	}
+
+	crash := lastCrashInPod(pod)
+	if crash == nil {
+		t.Fatal("expected a crash")
+	}
+	if crash.Container != "myapp" || crash.ExitCode != 2 || crash.Restarts != 4 {
+		t.Errorf("expected most recent crash of myapp, got %+v", crash)
+	}
+
+	healthy := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "myapp", Ready: true}}}}
+	if crash := lastCrashInPod(healthy); crash != nil {
+		t.Errorf("expected no crash for healthy pod, got %+v", crash)
+	}
+}
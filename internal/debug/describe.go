@@ -0,0 +1,651 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/format"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// describeKinds maps the kinds kbox describe accepts, and their short
+// names, to the Kubernetes kind
+var describeKinds = map[string]string{
+	"deployment":              "Deployment",
+	"deploy":                  "Deployment",
+	"pod":                     "Pod",
+	"po":                      "Pod",
+	"horizontalpodautoscaler": "HorizontalPodAutoscaler",
+	"hpa":                     "HorizontalPodAutoscaler",
+	"statefulset":             "StatefulSet",
+	"sts":                     "StatefulSet",
+}
+
+// describeEventLimit is how many of an object's most recent events are shown
+const describeEventLimit = 20
+
+// Description is a focused, kubectl describe-like view of one object
+type Description struct {
+	Kind       string              `json:"kind"`
+	Name       string              `json:"name"`
+	Namespace  string              `json:"namespace"`
+	Created    time.Time           `json:"created"`
+	Fields     []DescribeField     `json:"fields"`
+	Conditions []DescribeCondition `json:"conditions,omitempty"`
+	Related    []RelatedObject     `json:"related,omitempty"`
+	Events     []EventInfo         `json:"events"`
+}
+
+// DescribeField is one line of a Description's spec and status highlights
+type DescribeField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DescribeCondition is a status condition of the described object
+type DescribeCondition struct {
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	LastTransition time.Time `json:"lastTransition"`
+}
+
+// RelatedObject is an object the described one owns, is owned by, or uses
+type RelatedObject struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
+
+// ParseDescribeRef splits a kind/name reference. The kind may be a short
+// name (deploy, po, hpa, sts) and the name may be empty.
+func ParseDescribeRef(ref string) (kind, name string, err error) {
+	kindPart, name, _ := strings.Cut(ref, "/")
+	kind, ok := describeKinds[strings.ToLower(kindPart)]
+	if !ok {
+		return "", "", fmt.Errorf("can't describe %q: kind must be deployment, pod, hpa or statefulset", kindPart)
+	}
+	return kind, name, nil
+}
+
+// Describe builds the Description of an object of an app. An empty name
+// means the app's own object: its Deployment or HPA, or the pod sel picks.
+// A StatefulSet may be named by its dependency type (postgres for
+// <app>-postgres).
+func Describe(ctx context.Context, client kubernetes.Interface, namespace, appName, kind, name string, sel PodSelector) (*Description, error) {
+	if name == "" {
+		if appName == "" {
+			return nil, fmt.Errorf("%s name required", kind)
+		}
+		name = appName
+		if kind == "Pod" {
+			pods, err := FindPodsWith(ctx, client, namespace, appName, sel)
+			if err != nil {
+				return nil, err
+			}
+			name = TargetPod(pods).Name
+		}
+	}
+
+	var d *Description
+	var err error
+	switch kind {
+	case "Deployment":
+		d, err = describeDeployment(ctx, client, namespace, name)
+	case "Pod":
+		d, err = describePod(ctx, client, namespace, name)
+	case "HorizontalPodAutoscaler":
+		d, err = describeHPA(ctx, client, namespace, name)
+	case "StatefulSet":
+		d, err = describeStatefulSet(ctx, client, namespace, name)
+		if apierrors.IsNotFound(err) && appName != "" && !strings.HasPrefix(name, appName+"-") {
+			d, err = describeStatefulSet(ctx, client, namespace, appName+"-"+name)
+		}
+	default:
+		return nil, fmt.Errorf("can't describe kind %q", kind)
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%s %q not found in namespace %q", kind, name, namespace)
+		}
+		return nil, fmt.Errorf("failed to get %s %q: %w", kind, name, err)
+	}
+
+	d.Events, err = objectEvents(ctx, client, namespace, d.Kind, d.Name)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func describeDeployment(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Description, error) {
+	dep, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	d := newDescription("Deployment", &dep.ObjectMeta)
+
+	replicas := fmt.Sprintf("%d updated | %d ready | %d available",
+		dep.Status.UpdatedReplicas, dep.Status.ReadyReplicas, dep.Status.AvailableReplicas)
+	if dep.Spec.Replicas != nil {
+		replicas = fmt.Sprintf("%d desired | %s", *dep.Spec.Replicas, replicas)
+	}
+	d.add("Replicas", replicas)
+	strategy := string(dep.Spec.Strategy.Type)
+	if ru := dep.Spec.Strategy.RollingUpdate; ru != nil && ru.MaxSurge != nil && ru.MaxUnavailable != nil {
+		strategy += fmt.Sprintf(" (max surge %s, max unavailable %s)", ru.MaxSurge.String(), ru.MaxUnavailable.String())
+	}
+	d.add("Strategy", strategy)
+	if dep.Spec.Selector != nil {
+		d.add("Selector", metav1.FormatLabelSelector(dep.Spec.Selector))
+	}
+	if line := formatChange(render.ChangeInfoFrom(dep.Annotations)); line != "" {
+		d.add("Release", line)
+	}
+	d.addContainers(dep.Spec.Template.Spec.Containers)
+	d.addConditions(len(dep.Status.Conditions), func(i int) DescribeCondition {
+		c := dep.Status.Conditions[i]
+		return DescribeCondition{Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message, LastTransition: c.LastTransitionTime.Time}
+	})
+
+	// ReplicaSets, newest revision first, then their pods
+	rsList, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var replicaSets []appsv1.ReplicaSet
+	for _, rs := range rsList.Items {
+		if owner := metav1.GetControllerOf(&rs); owner != nil && owner.UID == dep.UID {
+			replicaSets = append(replicaSets, rs)
+		}
+	}
+	revision := func(rs appsv1.ReplicaSet) int {
+		n, _ := strconv.Atoi(rs.Annotations["deployment.kubernetes.io/revision"])
+		return n
+	}
+	sort.Slice(replicaSets, func(i, j int) bool {
+		return revision(replicaSets[i]) > revision(replicaSets[j])
+	})
+	for _, rs := range replicaSets {
+		var desired int32
+		if rs.Spec.Replicas != nil {
+			desired = *rs.Spec.Replicas
+		}
+		status := fmt.Sprintf("%d/%d ready", rs.Status.ReadyReplicas, desired)
+		if rev := rs.Annotations["deployment.kubernetes.io/revision"]; rev != "" {
+			status = "revision " + rev + ", " + status
+		}
+		d.relate("ReplicaSet", rs.Name, status)
+	}
+	pods, err := deploymentPods(ctx, client, namespace, name, "")
+	if err != nil {
+		return nil, err
+	}
+	d.relatePods(pods)
+
+	if err := d.relateHPA(ctx, client, namespace, "Deployment", name); err != nil {
+		return nil, err
+	}
+	if err := d.relateServices(ctx, client, namespace, dep.Spec.Template.Labels); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func describePod(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Description, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	d := newDescription("Pod", &pod.ObjectMeta)
+
+	status := string(pod.Status.Phase)
+	if pod.DeletionTimestamp != nil {
+		status = "Terminating"
+	}
+	d.add("Status", status)
+	if pod.Spec.NodeName != "" {
+		d.add("Node", pod.Spec.NodeName)
+	}
+	if pod.Status.PodIP != "" {
+		d.add("IP", pod.Status.PodIP)
+	}
+	if pod.Status.QOSClass != "" {
+		d.add("QoS", string(pod.Status.QOSClass))
+	}
+	d.addContainers(pod.Spec.Containers)
+	for _, cs := range pod.Status.ContainerStatuses {
+		d.add("State ("+cs.Name+")", containerState(cs))
+	}
+	d.addConditions(len(pod.Status.Conditions), func(i int) DescribeCondition {
+		c := pod.Status.Conditions[i]
+		return DescribeCondition{Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message, LastTransition: c.LastTransitionTime.Time}
+	})
+
+	// The owner chain: ReplicaSet and its Deployment, or a StatefulSet
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		d.relate(owner.Kind, owner.Name, "owner")
+		if owner.Kind == "ReplicaSet" {
+			rs, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if err == nil {
+				if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+					d.relate(rsOwner.Kind, rsOwner.Name, "owner of "+owner.Name)
+				}
+			}
+		}
+	}
+	for _, vol := range pod.Spec.Volumes {
+		switch {
+		case vol.PersistentVolumeClaim != nil:
+			d.relate("PersistentVolumeClaim", vol.PersistentVolumeClaim.ClaimName, "volume "+vol.Name)
+		case vol.ConfigMap != nil:
+			d.relate("ConfigMap", vol.ConfigMap.Name, "volume "+vol.Name)
+		case vol.Secret != nil:
+			d.relate("Secret", vol.Secret.SecretName, "volume "+vol.Name)
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, from := range c.EnvFrom {
+			switch {
+			case from.ConfigMapRef != nil:
+				d.relate("ConfigMap", from.ConfigMapRef.Name, "env of "+c.Name)
+			case from.SecretRef != nil:
+				d.relate("Secret", from.SecretRef.Name, "env of "+c.Name)
+			}
+		}
+	}
+	return d, nil
+}
+
+func describeHPA(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Description, error) {
+	hpa, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	d := newDescription("HorizontalPodAutoscaler", &hpa.ObjectMeta)
+
+	target := hpa.Spec.ScaleTargetRef
+	d.add("Target", target.Kind+"/"+target.Name)
+	minReplicas := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	d.add("Replicas", fmt.Sprintf("%d current | %d desired | min %d | max %d",
+		hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas, minReplicas, hpa.Spec.MaxReplicas))
+	for i, metric := range hpa.Spec.Metrics {
+		var current *autoscalingv2.MetricStatus
+		if i < len(hpa.Status.CurrentMetrics) {
+			current = &hpa.Status.CurrentMetrics[i]
+		}
+		name, value := formatHPAMetric(metric, current)
+		d.add("Metric ("+name+")", value)
+	}
+	if hpa.Status.LastScaleTime != nil {
+		d.add("Last scaled", format.Ago(hpa.Status.LastScaleTime.Time))
+	}
+	d.addConditions(len(hpa.Status.Conditions), func(i int) DescribeCondition {
+		c := hpa.Status.Conditions[i]
+		return DescribeCondition{Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message, LastTransition: c.LastTransitionTime.Time}
+	})
+
+	switch target.Kind {
+	case "Deployment":
+		if dep, err := client.AppsV1().Deployments(namespace).Get(ctx, target.Name, metav1.GetOptions{}); err == nil {
+			d.relate("Deployment", dep.Name, fmt.Sprintf("%d/%d ready", dep.Status.ReadyReplicas, dep.Status.Replicas))
+		} else {
+			d.relate("Deployment", target.Name, "not found")
+		}
+	case "StatefulSet":
+		if sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, target.Name, metav1.GetOptions{}); err == nil {
+			d.relate("StatefulSet", sts.Name, fmt.Sprintf("%d/%d ready", sts.Status.ReadyReplicas, sts.Status.Replicas))
+		} else {
+			d.relate("StatefulSet", target.Name, "not found")
+		}
+	}
+	return d, nil
+}
+
+// formatHPAMetric names a metric and formats its current value against its
+// target, e.g. "cpu", "45% / 70%"
+func formatHPAMetric(spec autoscalingv2.MetricSpec, current *autoscalingv2.MetricStatus) (string, string) {
+	name := strings.ToLower(string(spec.Type))
+	var target autoscalingv2.MetricTarget
+	var value *autoscalingv2.MetricValueStatus
+	switch {
+	case spec.Resource != nil:
+		name, target = string(spec.Resource.Name), spec.Resource.Target
+		if current != nil && current.Resource != nil {
+			value = &current.Resource.Current
+		}
+	case spec.ContainerResource != nil:
+		name, target = spec.ContainerResource.Container+"/"+string(spec.ContainerResource.Name), spec.ContainerResource.Target
+		if current != nil && current.ContainerResource != nil {
+			value = &current.ContainerResource.Current
+		}
+	case spec.Pods != nil:
+		name, target = spec.Pods.Metric.Name, spec.Pods.Target
+		if current != nil && current.Pods != nil {
+			value = &current.Pods.Current
+		}
+	case spec.Object != nil:
+		name, target = spec.Object.Metric.Name, spec.Object.Target
+		if current != nil && current.Object != nil {
+			value = &current.Object.Current
+		}
+	case spec.External != nil:
+		name, target = spec.External.Metric.Name, spec.External.Target
+		if current != nil && current.External != nil {
+			value = &current.External.Current
+		}
+	}
+
+	got := "<unknown>"
+	var want string
+	switch {
+	case target.AverageUtilization != nil:
+		want = fmt.Sprintf("%d%%", *target.AverageUtilization)
+		if value != nil && value.AverageUtilization != nil {
+			got = fmt.Sprintf("%d%%", *value.AverageUtilization)
+		}
+	case target.AverageValue != nil:
+		want = target.AverageValue.String()
+		if value != nil && value.AverageValue != nil {
+			got = value.AverageValue.String()
+		}
+	case target.Value != nil:
+		want = target.Value.String()
+		if value != nil && value.Value != nil {
+			got = value.Value.String()
+		}
+	}
+	return name, got + " / " + want
+}
+
+func describeStatefulSet(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Description, error) {
+	sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	d := newDescription("StatefulSet", &sts.ObjectMeta)
+
+	var desired int32 = 1
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+	d.add("Replicas", fmt.Sprintf("%d desired | %d current | %d updated | %d ready",
+		desired, sts.Status.CurrentReplicas, sts.Status.UpdatedReplicas, sts.Status.ReadyReplicas))
+	d.add("Update strategy", string(sts.Spec.UpdateStrategy.Type))
+	if dep := sts.Labels["kbox.dev/dependency"]; dep != "" {
+		d.add("Dependency", dep+" of "+sts.Labels["kbox.dev/app"])
+	}
+	d.addContainers(sts.Spec.Template.Spec.Containers)
+	for _, pvc := range sts.Spec.VolumeClaimTemplates {
+		storage := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		value := storage.String()
+		if pvc.Spec.StorageClassName != nil {
+			value += " (" + *pvc.Spec.StorageClassName + ")"
+		}
+		d.add("Storage ("+pvc.Name+")", value)
+	}
+	d.addConditions(len(sts.Status.Conditions), func(i int) DescribeCondition {
+		c := sts.Status.Conditions[i]
+		return DescribeCondition{Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message, LastTransition: c.LastTransitionTime.Time}
+	})
+
+	if sts.Spec.ServiceName != "" {
+		status := "found"
+		if _, err := client.CoreV1().Services(namespace).Get(ctx, sts.Spec.ServiceName, metav1.GetOptions{}); err != nil {
+			status = "not found"
+		}
+		d.relate("Service", sts.Spec.ServiceName, status)
+	}
+
+	podList, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.UID == sts.UID {
+			pods = append(pods, pod)
+		}
+	}
+	d.relatePods(pods)
+
+	// Claims are named <template>-<statefulset>-<ordinal>
+	pvcs, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs.Items {
+		for _, tmpl := range sts.Spec.VolumeClaimTemplates {
+			if strings.HasPrefix(pvc.Name, tmpl.Name+"-"+sts.Name+"-") {
+				d.relate("PersistentVolumeClaim", pvc.Name, string(pvc.Status.Phase))
+			}
+		}
+	}
+
+	return d, d.relateHPA(ctx, client, namespace, "StatefulSet", name)
+}
+
+func newDescription(kind string, meta *metav1.ObjectMeta) *Description {
+	return &Description{
+		Kind:      kind,
+		Name:      meta.Name,
+		Namespace: meta.Namespace,
+		Created:   meta.CreationTimestamp.Time,
+	}
+}
+
+func (d *Description) add(name, value string) {
+	d.Fields = append(d.Fields, DescribeField{Name: name, Value: value})
+}
+
+func (d *Description) relate(kind, name, status string) {
+	d.Related = append(d.Related, RelatedObject{Kind: kind, Name: name, Status: status})
+}
+
+// addConditions adds n conditions, each built by get; the API types of
+// Deployments, pods, HPAs and StatefulSets differ only in their type
+func (d *Description) addConditions(n int, get func(i int) DescribeCondition) {
+	for i := 0; i < n; i++ {
+		d.Conditions = append(d.Conditions, get(i))
+	}
+}
+
+// addContainers adds each container's image and resources
+func (d *Description) addContainers(containers []corev1.Container) {
+	for _, c := range containers {
+		d.add("Image ("+c.Name+")", c.Image)
+		if res := formatResources(c.Resources); res != "" {
+			d.add("Resources ("+c.Name+")", res)
+		}
+	}
+}
+
+// formatResources summarizes requests and limits, e.g.
+// "requests cpu=100m,memory=128Mi; limits memory=256Mi"
+func formatResources(r corev1.ResourceRequirements) string {
+	format := func(list corev1.ResourceList) string {
+		var parts []string
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := list[name]; ok {
+				parts = append(parts, string(name)+"="+q.String())
+			}
+		}
+		return strings.Join(parts, ",")
+	}
+	var parts []string
+	if s := format(r.Requests); s != "" {
+		parts = append(parts, "requests "+s)
+	}
+	if s := format(r.Limits); s != "" {
+		parts = append(parts, "limits "+s)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// containerState summarizes a container status, e.g. "Running, ready, 2 restarts"
+func containerState(cs corev1.ContainerStatus) string {
+	var state string
+	switch {
+	case cs.State.Running != nil:
+		state = "Running since " + cs.State.Running.StartedAt.Format(time.RFC3339)
+	case cs.State.Waiting != nil:
+		state = "Waiting (" + cs.State.Waiting.Reason + ")"
+	case cs.State.Terminated != nil:
+		state = fmt.Sprintf("Terminated (%s, exit code %d)", cs.State.Terminated.Reason, cs.State.Terminated.ExitCode)
+	default:
+		state = "Unknown"
+	}
+	ready := "not ready"
+	if cs.Ready {
+		ready = "ready"
+	}
+	return fmt.Sprintf("%s, %s, %d restarts", state, ready, cs.RestartCount)
+}
+
+func (d *Description) relatePods(pods []corev1.Pod) {
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	for i := range pods {
+		info := podToPodInfo(&pods[i])
+		status := info.Status
+		if pods[i].DeletionTimestamp != nil {
+			status = "Terminating"
+		}
+		if info.Ready {
+			status += ", ready"
+		}
+		if info.Restarts > 0 {
+			status += fmt.Sprintf(", %d restarts", info.Restarts)
+		}
+		d.relate("Pod", info.Name, status)
+	}
+}
+
+// relateHPA adds the HPAs scaling the object
+func (d *Description) relateHPA(ctx context.Context, client kubernetes.Interface, namespace, kind, name string) error {
+	hpas, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, hpa := range hpas.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		if ref.Kind == kind && ref.Name == name {
+			d.relate("HorizontalPodAutoscaler", hpa.Name, fmt.Sprintf("%d-%d replicas", ptrOr(hpa.Spec.MinReplicas, 1), hpa.Spec.MaxReplicas))
+		}
+	}
+	return nil
+}
+
+// relateServices adds the Services selecting pods with podLabels
+func (d *Description) relateServices(ctx context.Context, client kubernetes.Interface, namespace string, podLabels map[string]string) error {
+	services, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, svc := range services.Items {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		var ports []string
+		for _, p := range svc.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+		d.relate("Service", svc.Name, strings.TrimSpace(string(svc.Spec.Type)+" "+strings.Join(ports, ",")))
+	}
+	return nil
+}
+
+func ptrOr(p *int32, def int32) int32 {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// objectEvents returns the most recent events of one object, oldest first
+func objectEvents(ctx context.Context, client kubernetes.Interface, namespace, kind, name string) ([]EventInfo, error) {
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	result := []EventInfo{}
+	for i := range events.Items {
+		e := &events.Items[i]
+		if e.InvolvedObject.Kind == kind && e.InvolvedObject.Name == name {
+			result = append(result, toEventInfo(e))
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastSeen.Before(result[j].LastSeen)
+	})
+	if len(result) > describeEventLimit {
+		result = result[len(result)-describeEventLimit:]
+	}
+	return result, nil
+}
+
+// PrintDescription writes a Description in a kubectl describe-like layout
+func PrintDescription(w io.Writer, d *Description) {
+	fmt.Fprintf(w, "%s/%s (namespace: %s)\n", d.Kind, d.Name, d.Namespace)
+	if !d.Created.IsZero() {
+		fmt.Fprintf(w, "Age: %s\n", format.Age(d.Created))
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range d.Fields {
+		fmt.Fprintf(tw, "  %s:\t%s\n", f.Name, f.Value)
+	}
+	tw.Flush()
+
+	if len(d.Conditions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Conditions:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  TYPE\tSTATUS\tREASON\tMESSAGE")
+		for _, c := range d.Conditions {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, c.Message)
+		}
+		tw.Flush()
+	}
+
+	if len(d.Related) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Related:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, r := range d.Related {
+			fmt.Fprintf(tw, "  %s/%s\t%s\n", r.Kind, r.Name, r.Status)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w)
+	if len(d.Events) == 0 {
+		fmt.Fprintln(w, "Events: none")
+		return
+	}
+	fmt.Fprintln(w, "Events:")
+	for _, e := range d.Events {
+		fmt.Fprint(w, "  ")
+		PrintEvent(w, e)
+	}
+}
@@ -0,0 +1,160 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseDescribeRef(t *testing.T) {
+	tests := []struct {
+		ref, kind, name string
+	}{
+		{"deployment", "Deployment", ""},
+		{"deploy/web", "Deployment", "web"},
+		{"po/web-abc", "Pod", "web-abc"},
+		{"HPA", "HorizontalPodAutoscaler", ""},
+		{"sts/postgres", "StatefulSet", "postgres"},
+	}
+	for _, tt := range tests {
+		kind, name, err := ParseDescribeRef(tt.ref)
+		if err != nil || kind != tt.kind || name != tt.name {
+			t.Errorf("ParseDescribeRef(%q) = %q, %q, %v, want %q, %q", tt.ref, kind, name, err, tt.kind, tt.name)
+		}
+	}
+	if _, _, err := ParseDescribeRef("configmap/web"); err == nil {
+		t.Error("expected an error for an unsupported kind")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	controller := true
+	web := map[string]string{"app": "web"}
+	replicas := int32(2)
+	minReplicas := int32(2)
+	utilization := int32(70)
+
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "deploy-web"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: web},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: web},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "web:v2"}}},
+				},
+			},
+			Status: appsv1.DeploymentStatus{
+				ReadyReplicas: 2,
+				Conditions:    []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"}},
+			},
+		},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1", Namespace: "default", UID: "rs-1", Labels: web,
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": "9"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deploy-web", Controller: &controller}},
+		}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-2", Namespace: "default", UID: "rs-2", Labels: web,
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": "10"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deploy-web", Controller: &controller}},
+		}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-2-abc", Namespace: "default", Labels: web,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-2", UID: "rs-2", Controller: &controller}},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "web", Image: "web:v2",
+					EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-env"}}}},
+				}},
+			},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "web", Ready: true, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: web, Type: corev1.ServiceTypeClusterIP, Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}}},
+		},
+		&autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    5,
+				Metrics: []autoscalingv2.MetricSpec{{
+					Type:     autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{Name: corev1.ResourceCPU, Target: autoscalingv2.MetricTarget{AverageUtilization: &utilization}},
+				}},
+			},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-postgres", Namespace: "default", Labels: map[string]string{"kbox.dev/dependency": "postgres", "kbox.dev/app": "web"}},
+			Spec:       appsv1.StatefulSetSpec{ServiceName: "web-postgres"},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: "web"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "ScalingReplicaSet",
+			Message:        "Scaled up replica set web-2 to 2",
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.2", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "HorizontalPodAutoscaler", Name: "web"},
+			Reason:         "SuccessfulRescale",
+		},
+	)
+	ctx := context.Background()
+
+	describe := func(kind, name string) *Description {
+		t.Helper()
+		d, err := Describe(ctx, client, "default", "web", kind, name, PodSelector{})
+		if err != nil {
+			t.Fatalf("Describe(%s/%s): %v", kind, name, err)
+		}
+		return d
+	}
+	has := func(d *Description, want ...string) {
+		t.Helper()
+		var buf bytes.Buffer
+		PrintDescription(&buf, d)
+		for _, w := range want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s/%s description lacks %q:\n%s", d.Kind, d.Name, w, buf.String())
+			}
+		}
+	}
+
+	dep := describe("Deployment", "")
+	if len(dep.Events) != 1 || dep.Events[0].Reason != "ScalingReplicaSet" {
+		t.Errorf("Deployment events = %+v, want only its own", dep.Events)
+	}
+	if dep.Related[0].Name != "web-2" {
+		t.Errorf("expected the newest ReplicaSet (revision 10) first, got %s", dep.Related[0].Name)
+	}
+	has(dep, "2 desired", "Image (web):", "web:v2", "Available", "ReplicaSet/web-2", "revision 10",
+		"Pod/web-2-abc", "HorizontalPodAutoscaler/web", "2-5 replicas", "Service/web", "ClusterIP 80/TCP")
+
+	pod := describe("Pod", "")
+	has(pod, "Pod/web-2-abc", "Waiting (CrashLoopBackOff)", "ReplicaSet/web-2", "Deployment/web", "ConfigMap/web-env")
+
+	has(describe("HorizontalPodAutoscaler", ""), "Deployment/web", "min 2 | max 5", "Metric (cpu):", "<unknown> / 70%")
+	has(describe("StatefulSet", "postgres"), "StatefulSet/web-postgres", "postgres of web", "Service/web-postgres", "not found")
+
+	if _, err := Describe(ctx, client, "default", "web", "StatefulSet", "redis", PodSelector{}); err == nil ||
+		!strings.Contains(err.Error(), `StatefulSet "redis" not found`) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// appEventKinds are the involved object kinds that belong to an app's workloads
+var appEventKinds = map[string]bool{
+	"Deployment":              true,
+	"ReplicaSet":              true,
+	"Pod":                     true,
+	"StatefulSet":             true,
+	"Service":                 true,
+	"Ingress":                 true,
+	"HorizontalPodAutoscaler": true,
+	"PodDisruptionBudget":     true,
+	"Job":                     true,
+	"CronJob":                 true,
+	"PersistentVolumeClaim":   true,
+}
+
+// EventsOptions configures event listing and watching
+type EventsOptions struct {
+	WarningsOnly bool          // Only include Warning events
+	Since        time.Duration // Only include events seen within this window (0 = all)
+}
+
+// ListAppEvents returns de-duplicated events for an app's workloads, oldest first
+func ListAppEvents(ctx context.Context, client kubernetes.Interface, namespace, appName string, opts EventsOptions) ([]EventInfo, error) {
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return filterAppEvents(events.Items, appWorkloadNames(ctx, client, namespace, appName), opts, time.Now()), nil
+}
+
+// WatchAppEvents streams new events for an app's workloads until ctx is cancelled
+func WatchAppEvents(ctx context.Context, client kubernetes.Interface, namespace, appName string, opts EventsOptions, fn func(EventInfo)) error {
+	// Identical updates (same event, same count) are reported only once
+	seen := make(map[string]bool)
+	workloads := appWorkloadNames(ctx, client, namespace, appName)
+	return watchEventStream(ctx, client, namespace, func(e *corev1.Event) {
+		if !matchesAppEvent(e, workloads, opts, time.Now()) {
+			return
+		}
+		key := fmt.Sprintf("%s/%d", e.UID, e.Count)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		fn(toEventInfo(e))
+	})
+}
+
+// watchEventStream watches namespace events and invokes fn for each added or modified event
+func watchEventStream(ctx context.Context, client kubernetes.Interface, namespace string, fn func(*corev1.Event)) error {
+	watcher, err := client.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			if e, ok := event.Object.(*corev1.Event); ok {
+				fn(e)
+			}
+		}
+	}
+}
+
+// appWorkloadNames returns the names of the Deployments, StatefulSets, Jobs,
+// CronJobs and PersistentVolumeClaims kbox deployed for the app, found by
+// its ownership label. The app's own name is always included, so its events
+// still show when it's gone or can't be listed.
+func appWorkloadNames(ctx context.Context, client kubernetes.Interface, namespace, appName string) map[string]bool {
+	names := map[string]bool{appName: true}
+	opts := metav1.ListOptions{LabelSelector: render.OwnershipSelector(appName)}
+	if list, err := client.AppsV1().Deployments(namespace).List(ctx, opts); err == nil {
+		for _, o := range list.Items {
+			names[o.Name] = true
+		}
+	}
+	if list, err := client.AppsV1().StatefulSets(namespace).List(ctx, opts); err == nil {
+		for _, o := range list.Items {
+			names[o.Name] = true
+		}
+	}
+	if list, err := client.BatchV1().Jobs(namespace).List(ctx, opts); err == nil {
+		for _, o := range list.Items {
+			names[o.Name] = true
+		}
+	}
+	if list, err := client.BatchV1().CronJobs(namespace).List(ctx, opts); err == nil {
+		for _, o := range list.Items {
+			names[o.Name] = true
+		}
+	}
+	if list, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts); err == nil {
+		for _, o := range list.Items {
+			names[o.Name] = true
+		}
+	}
+	return names
+}
+
+// filterAppEvents selects app events, merges duplicates and sorts them oldest first
+func filterAppEvents(items []corev1.Event, workloads map[string]bool, opts EventsOptions, now time.Time) []EventInfo {
+	merged := make(map[string]*EventInfo)
+	var order []string
+
+	for i := range items {
+		e := &items[i]
+		if !matchesAppEvent(e, workloads, opts, now) {
+			continue
+		}
+
+		info := toEventInfo(e)
+		key := strings.Join([]string{info.Kind, info.Object, info.Type, info.Reason, info.Message}, "\x00")
+		if existing, ok := merged[key]; ok {
+			existing.Count += info.Count
+			if info.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = info.LastSeen
+			}
+			if !info.FirstSeen.IsZero() && info.FirstSeen.Before(existing.FirstSeen) {
+				existing.FirstSeen = info.FirstSeen
+			}
+			continue
+		}
+		merged[key] = &info
+		order = append(order, key)
+	}
+
+	result := make([]EventInfo, 0, len(order))
+	for _, key := range order {
+		result = append(result, *merged[key])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastSeen.Before(result[j].LastSeen)
+	})
+	return result
+}
+
+// matchesAppEvent reports whether an event belongs to the app and passes the filters.
+// Objects match when they are one of the app's workloads (see appWorkloadNames)
+// or named the way Kubernetes names the objects a workload creates.
+func matchesAppEvent(e *corev1.Event, workloads map[string]bool, opts EventsOptions, now time.Time) bool {
+	if !appEventKinds[e.InvolvedObject.Kind] {
+		return false
+	}
+	if !workloadObject(e.InvolvedObject.Kind, e.InvolvedObject.Name, workloads) {
+		return false
+	}
+	if opts.WarningsOnly && e.Type != corev1.EventTypeWarning {
+		return false
+	}
+	if opts.Since > 0 && now.Sub(eventTime(e)) > opts.Since {
+		return false
+	}
+	return true
+}
+
+// workloadObject reports whether name is one of workloads or an object a
+// controller generated for one: <deployment>-<hash> ReplicaSets and their
+// <deployment>-<hash>-<suffix> pods, <statefulset>-<ordinal> pods,
+// <job>-<suffix> pods, <cronjob>-<time> Jobs and their pods, and the
+// <template>-<statefulset>-<ordinal> claims of StatefulSet volumes. An app
+// whose name merely starts with another's doesn't match: the rest of
+// "web-api-<hash>" isn't a generated name, so web doesn't get web-api's events.
+func workloadObject(kind, name string, workloads map[string]bool) bool {
+	if workloads[name] {
+		return true
+	}
+	if kind == "PersistentVolumeClaim" {
+		if base, ordinal, ok := cutLast(name, "-"); ok && numeric(ordinal) {
+			for i := range len(base) {
+				if base[i] == '-' && workloads[base[i+1:]] {
+					return true
+				}
+			}
+		}
+	}
+	for i := range len(name) {
+		if name[i] != '-' || !workloads[name[:i]] {
+			continue
+		}
+		rest := strings.Split(name[i+1:], "-")
+		switch {
+		case len(rest) == 1:
+			// ReplicaSet hash, pod suffix, StatefulSet ordinal or CronJob Job
+			if generatedSuffix(rest[0]) || numeric(rest[0]) {
+				return true
+			}
+		case len(rest) == 2:
+			// Pod of a ReplicaSet or of a CronJob's Job
+			if (generatedSuffix(rest[0]) || numeric(rest[0])) && generatedSuffix(rest[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// generatedSuffix reports whether s looks like a pod-template hash or a
+// generated name suffix: Kubernetes draws both from the same alphabet,
+// without vowels, so that they never spell words
+func generatedSuffix(s string) bool {
+	if len(s) < 5 || len(s) > 10 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("bcdfghjklmnpqrstvwxz2456789", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// numeric reports whether s is a StatefulSet ordinal or a CronJob's
+// scheduled time
+func numeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// eventTime returns the best available timestamp for an event
+func eventTime(e *corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func toEventInfo(e *corev1.Event) EventInfo {
+	count := e.Count
+	if count == 0 {
+		count = 1
+	}
+	first := e.FirstTimestamp.Time
+	if first.IsZero() {
+		first = eventTime(e)
+	}
+	return EventInfo{
+		Type:      e.Type,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Count:     count,
+		LastSeen:  eventTime(e),
+		FirstSeen: first,
+		Kind:      e.InvolvedObject.Kind,
+		Object:    e.InvolvedObject.Name,
+	}
+}
+
+// PrintEvent writes a single event as a table row
+func PrintEvent(w io.Writer, e EventInfo) {
+	typeColor := "\033[32m" // Green for Normal
+	if e.Type == corev1.EventTypeWarning {
+		typeColor = "\033[33m" // Yellow for Warning
+	}
+	fmt.Fprintf(w, "%s  %s%-7s\033[0m %s/%s  %s: %s",
+		e.LastSeen.Format("15:04:05"), typeColor, e.Type, e.Kind, e.Object, e.Reason, e.Message)
+	if e.Count > 1 {
+		fmt.Fprintf(w, " (x%d)", e.Count)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,118 @@
+package debug
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+func makeEvent(kind, name, eventType, reason, message string, count int32, last time.Time) corev1.Event {
+	return corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Count:          count,
+		FirstTimestamp: metav1.NewTime(last.Add(-time.Minute)),
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func TestFilterAppEvents(t *testing.T) {
+	now := time.Now()
+	items := []corev1.Event{
+		makeEvent("Pod", "myapp-7d9f8c6b5-x2k4p", "Warning", "BackOff", "Back-off restarting", 2, now.Add(-2*time.Minute)),
+		makeEvent("Deployment", "myapp", "Normal", "ScalingReplicaSet", "Scaled up to 2", 1, now.Add(-5*time.Minute)),
+		// Duplicate of the first event from a second event object
+		makeEvent("Pod", "myapp-7d9f8c6b5-x2k4p", "Warning", "BackOff", "Back-off restarting", 3, now.Add(-time.Minute)),
+		// Different app sharing a prefix without a dash
+		makeEvent("Pod", "myapp2-xyz", "Warning", "Failed", "nope", 1, now),
+		// Unrelated kind
+		makeEvent("Node", "myapp-node", "Normal", "Ready", "ok", 1, now),
+		// Too old
+		makeEvent("Pod", "myapp-7d9f8c6b5-q8w2z", "Normal", "Pulled", "old", 1, now.Add(-3*time.Hour)),
+		makeEvent("HorizontalPodAutoscaler", "myapp", "Normal", "SuccessfulRescale", "New size: 3", 1, now.Add(-30*time.Second)),
+	}
+
+	t.Run("merges duplicates and sorts oldest first", func(t *testing.T) {
+		result := filterAppEvents(items, map[string]bool{"myapp": true}, EventsOptions{Since: time.Hour}, now)
+		if len(result) != 3 {
+			t.Fatalf("expected 3 events, got %d: %+v", len(result), result)
+		}
+		if result[0].Kind != "Deployment" {
+			t.Errorf("expected deployment event first, got %s", result[0].Kind)
+		}
+		if result[1].Reason != "BackOff" || result[1].Count != 5 {
+			t.Errorf("expected merged BackOff with count 5, got %+v", result[1])
+		}
+		if !result[1].LastSeen.Equal(now.Add(-time.Minute)) {
+			t.Errorf("expected merged LastSeen to be the newest, got %v", result[1].LastSeen)
+		}
+		if result[2].Kind != "HorizontalPodAutoscaler" {
+			t.Errorf("expected HPA event last, got %s", result[2].Kind)
+		}
+	})
+
+	t.Run("warnings only", func(t *testing.T) {
+		result := filterAppEvents(items, map[string]bool{"myapp": true}, EventsOptions{WarningsOnly: true, Since: time.Hour}, now)
+		if len(result) != 1 || result[0].Type != "Warning" {
+			t.Errorf("expected only the warning event, got %+v", result)
+		}
+	})
+
+	t.Run("since zero includes old events", func(t *testing.T) {
+		result := filterAppEvents(items, map[string]bool{"myapp": true}, EventsOptions{}, now)
+		if len(result) != 4 {
+			t.Errorf("expected 4 events without time window, got %d", len(result))
+		}
+	})
+}
+
+func TestFilterAppEvents_PrefixedApp(t *testing.T) {
+	now := time.Now()
+	items := []corev1.Event{
+		makeEvent("Deployment", "web", "Normal", "ScalingReplicaSet", "web", 1, now),
+		makeEvent("ReplicaSet", "web-5c8b9d7f4", "Normal", "SuccessfulCreate", "web", 1, now),
+		makeEvent("Pod", "web-5c8b9d7f4-7xk2p", "Normal", "Pulled", "web", 1, now),
+		makeEvent("Pod", "web-migrate-4z7qv", "Normal", "Started", "web job", 1, now),
+		makeEvent("Pod", "web-postgres-0", "Normal", "Started", "web dependency", 1, now),
+		makeEvent("PersistentVolumeClaim", "data-web-postgres-0", "Normal", "Provisioning", "web dependency", 1, now),
+		// web-api is another app
+		makeEvent("Deployment", "web-api", "Normal", "ScalingReplicaSet", "web-api", 1, now),
+		makeEvent("ReplicaSet", "web-api-6f4d8b9c7", "Normal", "SuccessfulCreate", "web-api", 1, now),
+		makeEvent("Pod", "web-api-6f4d8b9c7-m4n8q", "Warning", "BackOff", "web-api", 1, now),
+	}
+	workloads := map[string]bool{"web": true, "web-migrate": true, "web-postgres": true}
+
+	for _, e := range filterAppEvents(items, workloads, EventsOptions{}, now) {
+		if e.Message != "web" && !strings.HasPrefix(e.Message, "web ") {
+			t.Errorf("web got an event of %s: %s/%s", e.Message, e.Kind, e.Object)
+		}
+	}
+	if got := filterAppEvents(items, workloads, EventsOptions{}, now); len(got) != 6 {
+		t.Errorf("expected web's 6 events, got %d: %+v", len(got), got)
+	}
+	if got := filterAppEvents(items, map[string]bool{"web-api": true}, EventsOptions{}, now); len(got) != 3 {
+		t.Errorf("expected web-api's 3 events, got %d: %+v", len(got), got)
+	}
+}
+
+func TestAppWorkloadNames(t *testing.T) {
+	owned := map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "web"}
+	client := fake.NewSimpleClientset(
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-migrate", Namespace: "default", Labels: owned}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-api-migrate", Namespace: "default", Labels: map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "web-api"}}},
+	)
+	names := appWorkloadNames(context.Background(), client, "default", "web")
+	if !names["web"] || !names["web-migrate"] || names["web-api-migrate"] {
+		t.Errorf("appWorkloadNames() = %v", names)
+	}
+}
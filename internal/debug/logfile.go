@@ -0,0 +1,237 @@
+package debug
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LineWriter receives the log lines StreamLogs outputs, e.g. a LogFile
+type LineWriter interface {
+	WriteLine(line LogLine) error
+}
+
+// Log file formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json" // JSON lines, one LogLine per line
+)
+
+// DefaultLogMaxFiles is how many rotated log files are kept
+const DefaultLogMaxFiles = 5
+
+// LogFileOptions configures a LogFile
+type LogFileOptions struct {
+	// Format is LogFormatText or LogFormatJSON
+	Format string
+	// MaxSize rotates the file when a line would take it past this many
+	// bytes; 0 never rotates
+	MaxSize int64
+	// MaxFiles is how many rotated files (app.log.1, app.log.2, ...) to keep
+	MaxFiles int
+}
+
+// LogFile writes log lines to a file, without colors and with full
+// timestamps, rotating it by size: app.log becomes app.log.1, app.log.1
+// becomes app.log.2, and so on up to MaxFiles
+type LogFile struct {
+	path string
+	opts LogFileOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenLogFile opens path for appending, creating it and its directory
+func OpenLogFile(path string, opts LogFileOptions) (*LogFile, error) {
+	if opts.Format == "" {
+		opts.Format = LogFormatText
+	}
+	if opts.Format != LogFormatText && opts.Format != LogFormatJSON {
+		return nil, fmt.Errorf("unknown log file format %q (want %s or %s)", opts.Format, LogFormatText, LogFormatJSON)
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultLogMaxFiles
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	l := &LogFile{path: path, opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LogFile) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// WriteLine appends a line, rotating the file first if it would grow past
+// MaxSize
+func (l *LogFile) WriteLine(line LogLine) error {
+	data, err := encodeLogLine(line, l.opts.Format)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.opts.MaxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.opts.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return err
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest, and starts a new
+// file at path
+func (l *LogFile) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.opts.MaxFiles))
+	for i := l.opts.MaxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the file
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// encodeLogLine formats a line for a file: JSON, or the terminal format
+// without colors and with the date
+func encodeLogLine(line LogLine, format string) ([]byte, error) {
+	if format == LogFormatJSON {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return []byte(fmt.Sprintf("%s [%s] %s\n", line.Timestamp.Format(time.RFC3339Nano), line.Source, line.Message)), nil
+}
+
+// SessionInfo describes a recorded logs session
+type SessionInfo struct {
+	App       string    `json:"app"`
+	Namespace string    `json:"namespace"`
+	Pods      []string  `json:"pods"`
+	Started   time.Time `json:"started"`
+}
+
+// sessionHeader is the first line of a recording
+type sessionHeader struct {
+	Session SessionInfo `json:"session"`
+}
+
+// NewRecorder creates (or replaces) a recording of a logs session at path,
+// for LogsOptions.Recorder and ReadSession: a JSON header line describing
+// the session, then one LogLine per line
+func NewRecorder(path string, info SessionInfo) (*LogFile, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	file, err := OpenLogFile(path, LogFileOptions{Format: LogFormatJSON})
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(sessionHeader{Session: info})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.file.Write(append(header, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadSession reads a session recorded with NewRecorder, or a JSON log file
+// (which has no header, so info is nil)
+func ReadSession(r io.Reader) (info *SessionInfo, lines []LogLine, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		if n == 1 {
+			var header sessionHeader
+			if err := json.Unmarshal(data, &header); err == nil && header.Session.App != "" {
+				info = &header.Session
+				continue
+			}
+		}
+		var line LogLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			return nil, nil, fmt.Errorf("line %d: not a kbox logs recording: %w", n, err)
+		}
+		lines = append(lines, line)
+	}
+	return info, lines, scanner.Err()
+}
+
+// Replay prints recorded lines to w as StreamLogs did. speed paces them by
+// their timestamps: 1 is real time, 10 ten times faster, and 0 prints
+// everything at once.
+func Replay(ctx context.Context, lines []LogLine, w io.Writer, opts LogsOptions, speed float64) {
+	sources := make(map[string]bool)
+	for _, line := range lines {
+		if !line.IsEvent {
+			sources[line.Source] = true
+		}
+	}
+
+	for i, line := range lines {
+		if line.Source == eventSource && !opts.ShowEvents {
+			continue
+		}
+		if speed > 0 && i > 0 {
+			if gap := line.Timestamp.Sub(lines[i-1].Timestamp); gap > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Duration(float64(gap) / speed)):
+				}
+			}
+		}
+		formatLine(w, line, opts, len(sources) > 1)
+	}
+}
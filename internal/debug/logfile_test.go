@@ -0,0 +1,103 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "myapp.log")
+	f, err := OpenLogFile(path, LogFileOptions{MaxSize: 100, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("OpenLogFile: %v", err)
+	}
+	ts := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	// Each line is 50 bytes, so every file holds two
+	for i := 0; i < 7; i++ {
+		line := LogLine{Timestamp: ts, Source: "pod/abc12", Message: strings.Repeat("x", 50-len("2024-01-14T10:00:00Z [pod/abc12] \n"))}
+		if err := f.WriteLine(line); err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+	}
+	f.Close()
+
+	for file, lines := range map[string]int{path: 1, path + ".1": 2, path + ".2": 2} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if got := strings.Count(string(data), "\n"); got != lines {
+			t.Errorf("%s holds %d lines, want %d", filepath.Base(file), got, lines)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 rotated files to be kept")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "2024-01-14T10:00:00Z [pod/abc12] xxx") {
+		t.Errorf("unexpected text line: %q", data)
+	}
+
+	if _, err := OpenLogFile(path, LogFileOptions{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incident.jsonl")
+	started := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	recorder, err := NewRecorder(path, SessionInfo{App: "myapp", Namespace: "shop", Pods: []string{"myapp-1", "myapp-2"}, Started: started})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	recorded := []LogLine{
+		{Timestamp: started, Source: "pod/abc12", Message: "listening on :8080"},
+		{Timestamp: started.Add(time.Second), Source: eventSource, Message: "BackOff: restarting failed container", IsEvent: true},
+		{Timestamp: started.Add(2 * time.Second), Source: "pod/def34", Message: "panic: nil map"},
+	}
+	for _, line := range recorded {
+		if err := recorder.WriteLine(line); err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+	}
+	recorder.Close()
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	info, lines, err := ReadSession(f)
+	if err != nil {
+		t.Fatalf("ReadSession: %v", err)
+	}
+	if info == nil || info.App != "myapp" || info.Namespace != "shop" || len(info.Pods) != 2 || !info.Started.Equal(started) {
+		t.Errorf("session info = %+v", info)
+	}
+	if len(lines) != 3 || lines[1] != recorded[1] || !lines[0].Timestamp.Equal(started) {
+		t.Fatalf("lines = %+v", lines)
+	}
+
+	var out bytes.Buffer
+	Replay(context.Background(), lines, &out, LogsOptions{Timestamps: true}, 0)
+	got := out.String()
+	if !strings.Contains(got, "10:00:00 listening on :8080") || !strings.Contains(got, "panic: nil map") {
+		t.Errorf("replay output:\n%s", got)
+	}
+	if strings.Contains(got, "BackOff") {
+		t.Errorf("events were replayed without ShowEvents:\n%s", got)
+	}
+
+	out.Reset()
+	Replay(context.Background(), lines, &out, LogsOptions{ShowEvents: true}, 1000)
+	if !strings.Contains(out.String(), "BackOff: restarting failed container") {
+		t.Errorf("replay with events:\n%s", out.String())
+	}
+
+	if _, _, err := ReadSession(strings.NewReader("not json\n")); err == nil {
+		t.Error("expected an error for a file that isn't a recording")
+	}
+}
@@ -5,32 +5,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"slices"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
 // LogLine represents a single log line with metadata
 type LogLine struct {
-	Timestamp time.Time
-	Source    string // "pod/name" or "k8s/event"
-	Message   string
-	IsEvent   bool
+	Timestamp time.Time `json:"time"`
+	Source    string    `json:"source"` // "pod/name" or "k8s/event"
+	Message   string    `json:"message"`
+	IsEvent   bool      `json:"event,omitempty"`
+	// Service is the pod's MultiApp service, which colors its lines
+	Service string `json:"service,omitempty"`
 }
 
+// eventSource is the Source of K8s event lines
+const eventSource = "k8s/event"
+
 // LogsOptions configures log streaming
 type LogsOptions struct {
 	Follow       bool
 	Timestamps   bool
-	TailLines    int64
+	TailLines    int64 // -1 for all lines
 	Previous     bool
 	AutoPrevious bool // Auto-fetch previous if container is restarting
 	ShowEvents   bool // Interleave K8s events (the killer feature)
+
+	// SinceTime starts the logs, and the K8s events, at this time if set
+	SinceTime time.Time
+
+	// Files also receive each line printed, e.g. a LogFile
+	Files []LineWriter
+	// Recorder receives every line, K8s events included even without
+	// ShowEvents, for a replayable session
+	Recorder LineWriter
 }
 
 // DefaultLogsOptions returns sensible defaults
@@ -45,7 +60,7 @@ func DefaultLogsOptions() LogsOptions {
 }
 
 // StreamLogs streams logs from multiple pods with event interleaving
-func StreamLogs(ctx context.Context, client *kubernetes.Clientset, namespace string, pods []PodInfo, opts LogsOptions, output io.Writer) error {
+func StreamLogs(ctx context.Context, client kubernetes.Interface, namespace string, pods []PodInfo, opts LogsOptions, output io.Writer) error {
 	if len(pods) == 0 {
 		return fmt.Errorf("no pods to stream logs from")
 	}
@@ -64,11 +79,11 @@ func StreamLogs(ctx context.Context, client *kubernetes.Clientset, namespace str
 	}
 
 	// Start event watching if enabled
-	if opts.ShowEvents {
+	if opts.ShowEvents || opts.Recorder != nil {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			watchEvents(ctx, client, namespace, pods, lines)
+			watchEvents(ctx, client, namespace, pods, opts.SinceTime, lines)
 		}()
 	}
 
@@ -78,15 +93,37 @@ func StreamLogs(ctx context.Context, client *kubernetes.Clientset, namespace str
 		close(lines)
 	}()
 
-	// Output lines as they come
+	// Output lines as they come. A file that fails to write (e.g. a full
+	// disk) is reported once and dropped, and streaming goes on.
+	files := opts.Files
+	if opts.Recorder != nil {
+		files = append(slices.Clone(files), opts.Recorder)
+	}
 	for line := range lines {
-		formatLine(output, line, opts, len(pods) > 1)
+		shown := opts.ShowEvents || line.Source != eventSource
+		if shown {
+			formatLine(output, line, opts, len(pods) > 1)
+		}
+		for i := 0; i < len(files); i++ {
+			if shown || files[i] == opts.Recorder {
+				if err := files[i].WriteLine(line); err != nil {
+					formatLine(output, LogLine{
+						Timestamp: time.Now(),
+						Source:    "kbox",
+						Message:   fmt.Sprintf("[kbox] Stopped writing logs to a file: %v", err),
+						IsEvent:   true,
+					}, opts, len(pods) > 1)
+					files = slices.Delete(files, i, i+1)
+					i--
+				}
+			}
+		}
 	}
 
 	return nil
 }
 
-func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInfo, opts LogsOptions, lines chan<- LogLine) {
+func streamPodLogs(ctx context.Context, client kubernetes.Interface, pod PodInfo, opts LogsOptions, lines chan<- LogLine) {
 	// Check if we should fetch previous logs
 	shouldGetPrevious := opts.Previous
 	if opts.AutoPrevious && !opts.Previous {
@@ -95,7 +132,8 @@ func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInf
 			shouldGetPrevious = true
 			lines <- LogLine{
 				Timestamp: time.Now(),
-				Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+				Source:    podSource(pod),
+				Service:   pod.Service,
 				Message:   fmt.Sprintf("[kbox] Container is restarting (restarts=%d), fetching previous logs first", pod.Restarts),
 				IsEvent:   true,
 			}
@@ -108,19 +146,26 @@ func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInf
 	}
 
 	// Stream current logs
-	tailLines := opts.TailLines
-	req := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+	logOpts := &corev1.PodLogOptions{
 		Container:  pod.ContainerName,
 		Follow:     opts.Follow,
 		Timestamps: true, // Always get timestamps for ordering
-		TailLines:  &tailLines,
-	})
+	}
+	if opts.TailLines >= 0 {
+		tailLines := opts.TailLines
+		logOpts.TailLines = &tailLines
+	}
+	if !opts.SinceTime.IsZero() {
+		logOpts.SinceTime = &metav1.Time{Time: opts.SinceTime}
+	}
+	req := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts)
 
 	stream, err := req.Stream(ctx)
 	if err != nil {
 		lines <- LogLine{
 			Timestamp: time.Now(),
-			Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+			Source:    podSource(pod),
+			Service:   pod.Service,
 			Message:   fmt.Sprintf("[kbox] Failed to stream logs: %v", err),
 			IsEvent:   true,
 		}
@@ -138,7 +183,8 @@ func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInf
 		ts, msg := parseLogLine(line)
 		lines <- LogLine{
 			Timestamp: ts,
-			Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+			Source:    podSource(pod),
+			Service:   pod.Service,
 			Message:   msg,
 		}
 	}
@@ -147,14 +193,16 @@ func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInf
 		if errors.Is(err, bufio.ErrTooLong) {
 			lines <- LogLine{
 				Timestamp: time.Now(),
-				Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+				Source:    podSource(pod),
+				Service:   pod.Service,
 				Message:   "[kbox] Warning: log line exceeded 1MB, truncated",
 				IsEvent:   true,
 			}
 		} else {
 			lines <- LogLine{
 				Timestamp: time.Now(),
-				Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+				Source:    podSource(pod),
+				Service:   pod.Service,
 				Message:   fmt.Sprintf("[kbox] Log stream error: %v", err),
 				IsEvent:   true,
 			}
@@ -162,14 +210,17 @@ func streamPodLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInf
 	}
 }
 
-func fetchPreviousLogs(ctx context.Context, client *kubernetes.Clientset, pod PodInfo, opts LogsOptions, lines chan<- LogLine) {
-	tailLines := opts.TailLines
-	req := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+func fetchPreviousLogs(ctx context.Context, client kubernetes.Interface, pod PodInfo, opts LogsOptions, lines chan<- LogLine) {
+	logOpts := &corev1.PodLogOptions{
 		Container:  pod.ContainerName,
 		Previous:   true,
 		Timestamps: true,
-		TailLines:  &tailLines,
-	})
+	}
+	if opts.TailLines >= 0 {
+		tailLines := opts.TailLines
+		logOpts.TailLines = &tailLines
+	}
+	req := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts)
 
 	stream, err := req.Stream(ctx)
 	if err != nil {
@@ -195,7 +246,8 @@ func fetchPreviousLogs(ctx context.Context, client *kubernetes.Clientset, pod Po
 		ts, msg := parseLogLine(line)
 		lines <- LogLine{
 			Timestamp: ts,
-			Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+			Source:    podSource(pod),
+			Service:   pod.Service,
 			Message:   msg,
 		}
 	}
@@ -204,14 +256,16 @@ func fetchPreviousLogs(ctx context.Context, client *kubernetes.Clientset, pod Po
 		if errors.Is(err, bufio.ErrTooLong) {
 			lines <- LogLine{
 				Timestamp: time.Now(),
-				Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+				Source:    podSource(pod),
+				Service:   pod.Service,
 				Message:   "[kbox] Warning: log line exceeded 1MB, truncated",
 				IsEvent:   true,
 			}
 		} else {
 			lines <- LogLine{
 				Timestamp: time.Now(),
-				Source:    fmt.Sprintf("pod/%s", shortName(pod.Name)),
+				Source:    podSource(pod),
+				Service:   pod.Service,
 				Message:   fmt.Sprintf("[kbox] Log stream error: %v", err),
 				IsEvent:   true,
 			}
@@ -226,44 +280,28 @@ func fetchPreviousLogs(ctx context.Context, client *kubernetes.Clientset, pod Po
 	}
 }
 
-func watchEvents(ctx context.Context, client *kubernetes.Clientset, namespace string, pods []PodInfo, lines chan<- LogLine) {
+func watchEvents(ctx context.Context, client kubernetes.Interface, namespace string, pods []PodInfo, since time.Time, lines chan<- LogLine) {
 	// Build a set of pod names to filter events
 	podNames := make(map[string]bool)
 	for _, p := range pods {
 		podNames[p.Name] = true
 	}
 
-	// Watch events in the namespace
-	watcher, err := client.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{})
-	if err != nil {
-		return
-	}
-	defer watcher.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
+	_ = watchEventStream(ctx, client, namespace, func(e *corev1.Event) {
+		// Filter to events for our pods
+		if e.InvolvedObject.Kind != "Pod" || !podNames[e.InvolvedObject.Name] {
 			return
-		case event, ok := <-watcher.ResultChan():
-			if !ok {
-				return
-			}
-
-			if event.Type == watch.Added || event.Type == watch.Modified {
-				if e, ok := event.Object.(*corev1.Event); ok {
-					// Filter to events for our pods
-					if e.InvolvedObject.Kind == "Pod" && podNames[e.InvolvedObject.Name] {
-						lines <- LogLine{
-							Timestamp: e.LastTimestamp.Time,
-							Source:    "k8s/event",
-							Message:   fmt.Sprintf("%s: %s", e.Reason, e.Message),
-							IsEvent:   true,
-						}
-					}
-				}
-			}
 		}
-	}
+		if !since.IsZero() && e.LastTimestamp.Time.Before(since) {
+			return
+		}
+		lines <- LogLine{
+			Timestamp: e.LastTimestamp.Time,
+			Source:    eventSource,
+			Message:   fmt.Sprintf("%s: %s", e.Reason, e.Message),
+			IsEvent:   true,
+		}
+	})
 }
 
 // parseLogLine extracts timestamp and message from a log line
@@ -287,10 +325,13 @@ func formatLine(w io.Writer, line LogLine, opts LogsOptions, multiPod bool) {
 	var prefix string
 
 	if multiPod || line.IsEvent {
-		// Color coding: events in yellow, pods in cyan
-		if line.IsEvent {
+		// Color coding: events in yellow, pods in cyan, or their service's color
+		switch {
+		case line.IsEvent:
 			prefix = fmt.Sprintf("\033[33m[%-12s]\033[0m ", line.Source) // Yellow
-		} else {
+		case line.Service != "":
+			prefix = fmt.Sprintf("%s[%-12s]\033[0m ", serviceColor(line.Service), line.Source)
+		default:
 			prefix = fmt.Sprintf("\033[36m[%-12s]\033[0m ", line.Source) // Cyan
 		}
 	}
@@ -303,6 +344,36 @@ func formatLine(w io.Writer, line LogLine, opts LogsOptions, multiPod bool) {
 	fmt.Fprintf(w, "%s%s%s\n", prefix, timestamp, line.Message)
 }
 
+// serviceColors are the colors of services' log lines, leaving out yellow
+// for events and red
+var serviceColors = []string{
+	"\033[36m", // Cyan
+	"\033[35m", // Magenta
+	"\033[32m", // Green
+	"\033[34m", // Blue
+	"\033[96m", // Bright cyan
+	"\033[95m", // Bright magenta
+	"\033[92m", // Bright green
+	"\033[94m", // Bright blue
+}
+
+// serviceColor returns a service's color, derived from its name so it stays
+// the same from one run to the next
+func serviceColor(service string) string {
+	h := fnv.New32a()
+	h.Write([]byte(service))
+	return serviceColors[h.Sum32()%uint32(len(serviceColors))]
+}
+
+// podSource is the Source of a pod's lines: pod/<suffix>, or
+// <service>/<suffix> for a MultiApp service's pod
+func podSource(pod PodInfo) string {
+	if pod.Service != "" {
+		return pod.Service + "/" + shortName(pod.Name)
+	}
+	return "pod/" + shortName(pod.Name)
+}
+
 // shortName returns the last part of a pod name (after the last dash)
 // e.g., "myapp-6d4f5c7b8d-abc12" -> "abc12"
 func shortName(name string) string {
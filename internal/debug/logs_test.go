@@ -1,6 +1,8 @@
 package debug
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -103,3 +105,37 @@ func TestDefaultLogsOptions(t *testing.T) {
 		t.Errorf("default tail lines should be 100, got %d", opts.TailLines)
 	}
 }
+
+// TestServiceLines checks aggregated MultiApp logs: lines are prefixed with
+// their service and colored per service, the same color on every run
+func TestServiceLines(t *testing.T) {
+	api := PodInfo{Name: "shop-api-6d4f5c7b8d-abc12", Service: "api"}
+	if got := podSource(api); got != "api/abc12" {
+		t.Errorf("podSource = %q, want api/abc12", got)
+	}
+	if got := podSource(PodInfo{Name: "shop-6d4f5c7b8d-abc12"}); got != "pod/abc12" {
+		t.Errorf("podSource without a service = %q", got)
+	}
+
+	if serviceColor("api") != serviceColor("api") {
+		t.Error("a service's color should be stable")
+	}
+	colors := map[string]bool{}
+	for _, svc := range []string{"api", "worker", "web", "billing", "search"} {
+		colors[serviceColor(svc)] = true
+	}
+	if len(colors) < 2 {
+		t.Error("services should get different colors")
+	}
+
+	var buf bytes.Buffer
+	formatLine(&buf, LogLine{Source: "api/abc12", Service: "api", Message: "GET /health"}, LogsOptions{}, true)
+	if want := serviceColor("api") + "[api/abc12   ]\033[0m GET /health\n"; buf.String() != want {
+		t.Errorf("formatLine = %q, want %q", buf.String(), want)
+	}
+	buf.Reset()
+	formatLine(&buf, LogLine{Source: eventSource, Service: "api", Message: "BackOff", IsEvent: true}, LogsOptions{}, true)
+	if !strings.HasPrefix(buf.String(), "\033[33m") {
+		t.Errorf("events should stay yellow: %q", buf.String())
+	}
+}
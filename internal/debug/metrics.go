@@ -0,0 +1,230 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/format"
+)
+
+// metricsAPIPath is the base path of the metrics.k8s.io API served by metrics-server
+const metricsAPIPath = "/apis/metrics.k8s.io/v1beta1"
+
+// ErrMetricsUnavailable is returned when the cluster has no metrics API (metrics-server not installed)
+var ErrMetricsUnavailable = errors.New("metrics API not available")
+
+// ResourceUsage is the current CPU and memory usage of a pod
+type ResourceUsage struct {
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+// PodUsage combines pod status with its live resource usage
+type PodUsage struct {
+	Name        string    `json:"name"`
+	Component   string    `json:"component"`
+	Status      string    `json:"status"`
+	Ready       bool      `json:"ready"`
+	Restarts    int32     `json:"restarts"`
+	Created     time.Time `json:"-"`
+	AgeSeconds  int64     `json:"ageSeconds"`
+	CPUMillis   int64     `json:"cpuMillis"`
+	MemoryBytes int64     `json:"memoryBytes"`
+	HasMetrics  bool      `json:"hasMetrics"`
+}
+
+// MetricsClient reads pod usage from the metrics.k8s.io API.
+// It talks to the API through the core REST client so no extra
+// client libraries are needed.
+type MetricsClient struct {
+	client kubernetes.Interface
+}
+
+// NewMetricsClient creates a metrics client sharing the given clientset's transport
+func NewMetricsClient(client kubernetes.Interface) *MetricsClient {
+	return &MetricsClient{client: client}
+}
+
+// podMetricsList mirrors the subset of metrics.k8s.io/v1beta1 PodMetricsList we use
+type podMetricsList struct {
+	Items []podMetrics `json:"items"`
+}
+
+type podMetrics struct {
+	Metadata   metav1.ObjectMeta  `json:"metadata"`
+	Containers []containerMetrics `json:"containers"`
+}
+
+type containerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+// PodUsage returns usage keyed by pod name for pods matching the label selector
+func (m *MetricsClient) PodUsage(ctx context.Context, namespace, selector string) (map[string]ResourceUsage, error) {
+	req := m.client.CoreV1().RESTClient().Get().
+		AbsPath(metricsAPIPath, "namespaces", namespace, "pods")
+	if selector != "" {
+		req = req.Param("labelSelector", selector)
+	}
+
+	data, err := req.DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+			return nil, ErrMetricsUnavailable
+		}
+		return nil, fmt.Errorf("failed to query metrics API: %w", err)
+	}
+
+	return parsePodMetrics(data)
+}
+
+// parsePodMetrics decodes a PodMetricsList and sums container usage per pod
+func parsePodMetrics(data []byte) (map[string]ResourceUsage, error) {
+	var list podMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode pod metrics: %w", err)
+	}
+
+	usage := make(map[string]ResourceUsage, len(list.Items))
+	for _, item := range list.Items {
+		var u ResourceUsage
+		for _, c := range item.Containers {
+			if cpu, ok := c.Usage[corev1.ResourceCPU]; ok {
+				u.CPUMillis += cpu.MilliValue()
+			}
+			if mem, ok := c.Usage[corev1.ResourceMemory]; ok {
+				u.MemoryBytes += mem.Value()
+			}
+		}
+		usage[item.Metadata.Name] = u
+	}
+	return usage, nil
+}
+
+// GetAppUsage returns status and live usage for the app's pods and its dependencies.
+// Pods are returned even when the metrics API is unavailable; in that case the
+// returned error is ErrMetricsUnavailable and HasMetrics is false on every pod.
+func GetAppUsage(ctx context.Context, client kubernetes.Interface, namespace, appName string) ([]PodUsage, error) {
+	selectors := []string{
+		fmt.Sprintf("app=%s", appName),
+		fmt.Sprintf("kbox.dev/app=%s", appName),
+	}
+
+	var pods []corev1.Pod
+	seen := make(map[string]bool)
+	for _, selector := range selectors {
+		list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		for _, pod := range list.Items {
+			if seen[pod.Name] {
+				continue
+			}
+			seen[pod.Name] = true
+			pods = append(pods, pod)
+		}
+	}
+
+	// Ask for the same pods only, not the whole namespace: it's cheaper in
+	// shared namespaces, and RBAC may only cover the app's pods
+	metrics := NewMetricsClient(client)
+	usage := make(map[string]ResourceUsage)
+	var metricsErr error
+	for _, selector := range selectors {
+		u, err := metrics.PodUsage(ctx, namespace, selector)
+		if err != nil {
+			metricsErr = err
+			break
+		}
+		maps.Copy(usage, u)
+	}
+
+	return buildPodUsage(pods, usage, appName, time.Now()), metricsErr
+}
+
+// buildPodUsage merges pod state with metrics, sorting the app first and dependencies after
+func buildPodUsage(pods []corev1.Pod, usage map[string]ResourceUsage, appName string, now time.Time) []PodUsage {
+	result := make([]PodUsage, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		info := podToPodInfo(pod)
+
+		component := appName
+		if dep := pod.Labels["kbox.dev/dependency"]; dep != "" {
+			component = dep
+		}
+
+		age := now.Sub(pod.CreationTimestamp.Time)
+		pu := PodUsage{
+			Name:       pod.Name,
+			Component:  component,
+			Status:     info.Status,
+			Ready:      info.Ready,
+			Restarts:   info.Restarts,
+			Created:    pod.CreationTimestamp.Time,
+			AgeSeconds: int64(age.Seconds()),
+		}
+		if u, ok := usage[pod.Name]; ok {
+			pu.CPUMillis = u.CPUMillis
+			pu.MemoryBytes = u.MemoryBytes
+			pu.HasMetrics = true
+		}
+		result = append(result, pu)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		iApp := result[i].Component == appName
+		jApp := result[j].Component == appName
+		if iApp != jApp {
+			return iApp
+		}
+		if result[i].Component != result[j].Component {
+			return result[i].Component < result[j].Component
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// PrintUsage writes a kubectl-top style table of pod usage
+func PrintUsage(w io.Writer, pods []PodUsage) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POD\tCOMPONENT\tSTATUS\tCPU\tMEMORY\tRESTARTS\tAGE")
+	for _, p := range pods {
+		cpu, mem := "-", "-"
+		if p.HasMetrics {
+			cpu = FormatCPU(p.CPUMillis)
+			mem = FormatMemory(p.MemoryBytes)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			p.Name, p.Component, p.Status, cpu, mem, p.Restarts, format.Age(p.Created))
+	}
+	tw.Flush()
+}
+
+// FormatCPU formats millicores the way kubectl top does (e.g. "250m")
+func FormatCPU(millis int64) string {
+	return resource.NewMilliQuantity(millis, resource.DecimalSI).String()
+}
+
+// FormatMemory formats bytes as mebibytes (e.g. "128Mi")
+func FormatMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", bytes/(1024*1024))
+}
@@ -0,0 +1,158 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestParsePodMetrics(t *testing.T) {
+	data := []byte(`{
+		"kind": "PodMetricsList",
+		"items": [
+			{
+				"metadata": {"name": "myapp-abc"},
+				"containers": [
+					{"name": "myapp", "usage": {"cpu": "150m", "memory": "64Mi"}},
+					{"name": "sidecar", "usage": {"cpu": "25000000n", "memory": "16Mi"}}
+				]
+			},
+			{
+				"metadata": {"name": "myapp-postgres-0"},
+				"containers": [
+					{"name": "postgres", "usage": {"cpu": "1", "memory": "256Mi"}}
+				]
+			}
+		]
+	}`)
+
+	usage, err := parsePodMetrics(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	app := usage["myapp-abc"]
+	if app.CPUMillis != 175 {
+		t.Errorf("expected 175m CPU summed across containers, got %d", app.CPUMillis)
+	}
+	if app.MemoryBytes != 80*1024*1024 {
+		t.Errorf("expected 80Mi memory, got %d", app.MemoryBytes)
+	}
+
+	pg := usage["myapp-postgres-0"]
+	if pg.CPUMillis != 1000 {
+		t.Errorf("expected 1000m CPU, got %d", pg.CPUMillis)
+	}
+
+	if _, err := parsePodMetrics([]byte("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestBuildPodUsage(t *testing.T) {
+	now := time.Now()
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "myapp-redis-0",
+				Labels:            map[string]string{"kbox.dev/dependency": "redis", "kbox.dev/app": "myapp"},
+				CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "myapp-xyz",
+				Labels:            map[string]string{"app": "myapp"},
+				CreationTimestamp: metav1.NewTime(now.Add(-5 * time.Minute)),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "myapp"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "myapp", Ready: true, RestartCount: 3},
+				},
+			},
+		},
+	}
+	usage := map[string]ResourceUsage{
+		"myapp-xyz": {CPUMillis: 42, MemoryBytes: 10 * 1024 * 1024},
+	}
+
+	result := buildPodUsage(pods, usage, "myapp", now)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 pods, got %d", len(result))
+	}
+
+	// App pods sort before dependencies
+	if result[0].Name != "myapp-xyz" || result[0].Component != "myapp" {
+		t.Errorf("expected app pod first, got %+v", result[0])
+	}
+	if !result[0].HasMetrics || result[0].CPUMillis != 42 || result[0].Restarts != 3 {
+		t.Errorf("unexpected app usage: %+v", result[0])
+	}
+	if result[1].Component != "redis" {
+		t.Errorf("expected redis component, got %q", result[1].Component)
+	}
+	if result[1].HasMetrics {
+		t.Error("dependency without metrics should report HasMetrics=false")
+	}
+
+	var buf bytes.Buffer
+	PrintUsage(&buf, result)
+	out := buf.String()
+	if !strings.Contains(out, "42m") || !strings.Contains(out, "10Mi") {
+		t.Errorf("expected formatted usage in table, got:\n%s", out)
+	}
+}
+
+func TestFormatUsage(t *testing.T) {
+	if got := FormatCPU(250); got != "250m" {
+		t.Errorf("FormatCPU(250) = %q", got)
+	}
+	if got := FormatCPU(2000); got != "2" {
+		t.Errorf("FormatCPU(2000) = %q", got)
+	}
+	if got := FormatMemory(512 * 1024 * 1024); got != "512Mi" {
+		t.Errorf("FormatMemory = %q", got)
+	}
+}
+
+func TestGetAppUsageSelectsAppPods(t *testing.T) {
+	var mu sync.Mutex
+	var selectors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Path, metricsAPIPath) {
+			mu.Lock()
+			selectors = append(selectors, r.URL.Query().Get("labelSelector"))
+			mu.Unlock()
+			w.Write([]byte(`{"kind": "PodMetricsList", "items": []}`))
+			return
+		}
+		w.Write([]byte(`{"kind": "PodList", "apiVersion": "v1", "items": []}`))
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetAppUsage(context.Background(), client, "default", "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if len(selectors) == 0 || slices.Contains(selectors, "") {
+		t.Errorf("expected every metrics query to select the app's pods, got %q", selectors)
+	}
+}
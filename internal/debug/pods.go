@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -18,6 +21,57 @@ type PodInfo struct {
 	Ready         bool
 	Restarts      int32
 	Status        string
+	// Service is the MultiApp service the pod runs, when logs are
+	// aggregated across services
+	Service string
+	// Created is when the pod was created
+	Created time.Time
+}
+
+// Pod picks for PodSelector.Pick
+const (
+	PickNewest = "newest"
+	PickOldest = "oldest"
+)
+
+// PodSelector chooses which pods of an app the debug helpers use. The zero
+// value finds the app's pods by its labels.
+type PodSelector struct {
+	// Selector is a label selector used instead of the app's labels
+	Selector string
+	// Deployment selects the pods owned by this Deployment through its
+	// ReplicaSets, narrowed by Selector if set
+	Deployment string
+	// Pick keeps only the newest or oldest pod (PickNewest, PickOldest)
+	Pick string
+	// IncludeTerminating keeps pods that are shutting down
+	IncludeTerminating bool
+}
+
+// Validate checks the selector and pick
+func (s PodSelector) Validate() error {
+	if s.Selector != "" {
+		if _, err := labels.Parse(s.Selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", s.Selector, err)
+		}
+	}
+	if s.Pick != "" && s.Pick != PickNewest && s.Pick != PickOldest {
+		return fmt.Errorf("unknown pod pick %q (want %s or %s)", s.Pick, PickNewest, PickOldest)
+	}
+	return nil
+}
+
+// describe names the pods the selector looks for, for errors
+func (s PodSelector) describe(appName string) string {
+	switch {
+	case s.Deployment != "" && s.Selector != "":
+		return fmt.Sprintf("deployment %q matching %q", s.Deployment, s.Selector)
+	case s.Deployment != "":
+		return fmt.Sprintf("deployment %q", s.Deployment)
+	case s.Selector != "":
+		return fmt.Sprintf("selector %q", s.Selector)
+	}
+	return fmt.Sprintf("%q", appName)
 }
 
 // FindPods finds pods matching an app name in a namespace
@@ -25,7 +79,81 @@ type PodInfo struct {
 // 1. Direct pod name match
 // 2. Deployment/Service name via app label
 // 3. kbox.dev/app label
-func FindPods(ctx context.Context, client *kubernetes.Clientset, namespace, appName string) ([]PodInfo, error) {
+// Pods that are shutting down are skipped.
+func FindPods(ctx context.Context, client kubernetes.Interface, namespace, appName string) ([]PodInfo, error) {
+	return FindPodsWith(ctx, client, namespace, appName, PodSelector{})
+}
+
+// FindPodsWith finds an app's pods as sel says, sorted by name
+func FindPodsWith(ctx context.Context, client kubernetes.Interface, namespace, appName string, sel PodSelector) ([]PodInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	var err error
+	switch {
+	case sel.Deployment != "":
+		pods, err = deploymentPods(ctx, client, namespace, sel.Deployment, sel.Selector)
+	case sel.Selector != "":
+		pods, err = listPods(ctx, client, namespace, sel.Selector)
+	default:
+		pods, err = appPods(ctx, client, namespace, appName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var allPods []PodInfo
+	terminating := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp != nil && !sel.IncludeTerminating {
+			terminating++
+			continue
+		}
+		allPods = append(allPods, podToPodInfo(&pods[i]))
+	}
+
+	if len(allPods) == 0 {
+		if terminating > 0 {
+			return nil, fmt.Errorf("no pods found for %s in namespace %q (%d shutting down)", sel.describe(appName), namespace, terminating)
+		}
+		return nil, fmt.Errorf("no pods found for %s in namespace %q", sel.describe(appName), namespace)
+	}
+
+	// Sort by name for consistent output
+	sort.Slice(allPods, func(i, j int) bool {
+		return allPods[i].Name < allPods[j].Name
+	})
+
+	switch sel.Pick {
+	case PickNewest, PickOldest:
+		picked := allPods[0]
+		for _, p := range allPods[1:] {
+			if sel.Pick == PickNewest && p.Created.After(picked.Created) ||
+				sel.Pick == PickOldest && p.Created.Before(picked.Created) {
+				picked = p
+			}
+		}
+		allPods = []PodInfo{picked}
+	}
+
+	return allPods, nil
+}
+
+// TargetPod picks the pod to exec into or forward to: the first ready pod,
+// or the first one if none is ready
+func TargetPod(pods []PodInfo) PodInfo {
+	for _, p := range pods {
+		if p.Ready {
+			return p
+		}
+	}
+	return pods[0]
+}
+
+// appPods finds an app's pods by its labels, falling back to pod names
+func appPods(ctx context.Context, client kubernetes.Interface, namespace, appName string) ([]corev1.Pod, error) {
 	// Try to find pods with various label selectors
 	selectors := []string{
 		fmt.Sprintf("app=%s", appName),
@@ -33,7 +161,7 @@ func FindPods(ctx context.Context, client *kubernetes.Clientset, namespace, appN
 		fmt.Sprintf("kbox.dev/app=%s", appName),
 	}
 
-	var allPods []PodInfo
+	var allPods []corev1.Pod
 	seen := make(map[string]bool)
 
 	for _, selector := range selectors {
@@ -49,9 +177,7 @@ func FindPods(ctx context.Context, client *kubernetes.Clientset, namespace, appN
 				continue
 			}
 			seen[pod.Name] = true
-
-			info := podToPodInfo(&pod)
-			allPods = append(allPods, info)
+			allPods = append(allPods, pod)
 		}
 	}
 
@@ -67,23 +193,63 @@ func FindPods(ctx context.Context, client *kubernetes.Clientset, namespace, appN
 			if len(pod.Name) >= len(appName) && pod.Name[:len(appName)] == appName {
 				// Verify it's actually a match (app-xyz matches, app2-xyz doesn't)
 				if len(pod.Name) == len(appName) || pod.Name[len(appName)] == '-' {
-					info := podToPodInfo(&pod)
-					allPods = append(allPods, info)
+					allPods = append(allPods, pod)
 				}
 			}
 		}
 	}
 
-	if len(allPods) == 0 {
-		return nil, fmt.Errorf("no pods found for %q in namespace %q", appName, namespace)
+	return allPods, nil
+}
+
+// listPods lists the pods matching a label selector
+func listPods(ctx context.Context, client kubernetes.Interface, namespace, selector string) ([]corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
+	return pods.Items, nil
+}
 
-	// Sort by name for consistent output
-	sort.Slice(allPods, func(i, j int) bool {
-		return allPods[i].Name < allPods[j].Name
-	})
+// deploymentPods finds the pods a Deployment owns: those whose controller is
+// one of the Deployment's ReplicaSets. Pods of every revision are included,
+// so a rollout in progress shows both.
+func deploymentPods(ctx context.Context, client kubernetes.Interface, namespace, name, selector string) ([]corev1.Pod, error) {
+	deploy, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %q: %w", name, err)
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("deployment %q has an invalid selector: %w", name, err)
+	}
+	listSelector := podSelector.String()
+	if selector != "" {
+		listSelector += "," + selector
+	}
 
-	return allPods, nil
+	replicaSets, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	owned := make(map[types.UID]bool)
+	for _, rs := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&rs); owner != nil && owner.UID == deploy.UID {
+			owned[rs.UID] = true
+		}
+	}
+
+	pods, err := listPods(ctx, client, namespace, listSelector)
+	if err != nil {
+		return nil, err
+	}
+	var result []corev1.Pod
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owned[owner.UID] {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
 }
 
 func podToPodInfo(pod *corev1.Pod) PodInfo {
@@ -91,6 +257,7 @@ func podToPodInfo(pod *corev1.Pod) PodInfo {
 		Name:      pod.Name,
 		Namespace: pod.Namespace,
 		Status:    string(pod.Status.Phase),
+		Created:   pod.CreationTimestamp.Time,
 	}
 
 	// Get the main container (first one, or the one that's not an init container)
@@ -112,7 +279,7 @@ func podToPodInfo(pod *corev1.Pod) PodInfo {
 
 // GetPodContainer returns the container name to use for a pod
 // Prefers the main app container over sidecars
-func GetPodContainer(ctx context.Context, client *kubernetes.Clientset, namespace, podName string) (string, error) {
+func GetPodContainer(ctx context.Context, client kubernetes.Interface, namespace, podName string) (string, error) {
 	pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get pod: %w", err)
@@ -146,7 +313,7 @@ func GetPodContainer(ctx context.Context, client *kubernetes.Clientset, namespac
 }
 
 // IsContainerRestarting checks if a pod's container is in a restart loop
-func IsContainerRestarting(ctx context.Context, client *kubernetes.Clientset, namespace, podName, containerName string) (bool, error) {
+func IsContainerRestarting(ctx context.Context, client kubernetes.Interface, namespace, podName, containerName string) (bool, error) {
 	pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return false, err
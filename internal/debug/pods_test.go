@@ -1,10 +1,17 @@
 package debug
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 // TestPodInfoExtraction tests converting K8s pods to our PodInfo
@@ -96,3 +103,96 @@ func TestShellFallbackLogic(t *testing.T) {
 	// Note: Actual ephemeral container testing requires a real cluster
 	// This tests the data structures and options are correct
 }
+
+func TestFindPodsWith(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	controller := true
+	pod := func(name string, age time.Duration, labels map[string]string, owner types.UID) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            labels,
+				CreationTimestamp: metav1.NewTime(base.Add(-age)),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		}
+		if owner != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: owner, Controller: &controller}}
+		}
+		return p
+	}
+
+	web := map[string]string{"app": "web"}
+	canary := map[string]string{"app": "web", "track": "canary"}
+	terminating := pod("web-old", 3*time.Hour, web, "rs-1")
+	terminating.DeletionTimestamp = &metav1.Time{Time: base}
+	terminating.Finalizers = []string{"kbox.dev/test"}
+
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "deploy-web"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+		},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1", Namespace: "default", UID: "rs-1", Labels: web,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deploy-web", Controller: &controller}},
+		}},
+		pod("web-a", 2*time.Hour, web, "rs-1"),
+		pod("web-b", time.Hour, canary, "rs-1"),
+		pod("web-c", 30*time.Minute, canary, "rs-other"),
+		terminating,
+	)
+
+	tests := []struct {
+		name    string
+		sel     PodSelector
+		want    []string
+		wantErr string
+	}{
+		{name: "app labels skip terminating pods", want: []string{"web-a", "web-b", "web-c"}},
+		{name: "include terminating", sel: PodSelector{IncludeTerminating: true}, want: []string{"web-a", "web-b", "web-c", "web-old"}},
+		{name: "selector", sel: PodSelector{Selector: "track=canary"}, want: []string{"web-b", "web-c"}},
+		{name: "deployment", sel: PodSelector{Deployment: "web"}, want: []string{"web-a", "web-b"}},
+		{name: "deployment and selector", sel: PodSelector{Deployment: "web", Selector: "track=canary"}, want: []string{"web-b"}},
+		{name: "newest", sel: PodSelector{Pick: PickNewest}, want: []string{"web-c"}},
+		{name: "oldest", sel: PodSelector{Pick: PickOldest}, want: []string{"web-a"}},
+		{name: "oldest including terminating", sel: PodSelector{Pick: PickOldest, IncludeTerminating: true}, want: []string{"web-old"}},
+		{name: "no match", sel: PodSelector{Selector: "track=blue"}, wantErr: `no pods found for selector "track=blue"`},
+		{name: "missing deployment", sel: PodSelector{Deployment: "api"}, wantErr: `failed to get deployment "api"`},
+		{name: "bad selector", sel: PodSelector{Selector: "track in (a"}, wantErr: "invalid selector"},
+		{name: "bad pick", sel: PodSelector{Pick: "random"}, wantErr: `unknown pod pick "random"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pods, err := FindPodsWith(context.Background(), client, "default", "web", tt.sel)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, p := range pods {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("pods = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetPod(t *testing.T) {
+	pods := []PodInfo{{Name: "a"}, {Name: "b", Ready: true}, {Name: "c", Ready: true}}
+	if got := TargetPod(pods); got.Name != "b" {
+		t.Errorf("TargetPod = %s, want the first ready pod b", got.Name)
+	}
+	if got := TargetPod(pods[:1]); got.Name != "a" {
+		t.Errorf("TargetPod = %s, want a when none is ready", got.Name)
+	}
+}
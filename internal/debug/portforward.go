@@ -25,7 +25,7 @@ type PortForwardOptions struct {
 }
 
 // PortForward sets up port forwarding to a pod
-func PortForward(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, namespace, podName string, opts PortForwardOptions) error {
+func PortForward(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName string, opts PortForwardOptions) error {
 	// Build the URL for port forwarding
 	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace, podName)
 	hostIP := config.Host
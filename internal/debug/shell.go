@@ -1,10 +1,12 @@
 package debug
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -47,7 +49,7 @@ type ExecResult struct {
 // 1. Direct exec with /bin/bash
 // 2. Direct exec with /bin/sh
 // 3. Ephemeral debug container (for distroless)
-func Shell(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, namespace, podName string, opts ShellOptions) (*ExecResult, error) {
+func Shell(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName string, opts ShellOptions) (*ExecResult, error) {
 	result := &ExecResult{}
 
 	// Get container name if not specified
@@ -83,7 +85,40 @@ func Shell(ctx context.Context, client *kubernetes.Clientset, config *rest.Confi
 	return execEphemeral(ctx, client, config, namespace, podName, containerName, opts)
 }
 
-func execInPod(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, namespace, podName string, opts ShellOptions) error {
+// Exec runs a non-interactive command in a pod container and returns its output
+func Exec(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName, container string, command []string) (stdout, stderr string, err error) {
+	var outBuf, errBuf bytes.Buffer
+	err = execInPod(ctx, client, config, namespace, podName, ShellOptions{
+		Container: container,
+		Command:   command,
+		Stdout:    &outBuf,
+		Stderr:    &errBuf,
+	})
+	return outBuf.String(), errBuf.String(), err
+}
+
+// ExecStream runs a non-interactive command in a pod container, streaming
+// stdin into it and its output to stdout (either may be nil). The command's
+// stderr is added to the error if it fails.
+func ExecStream(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName, container string, command []string, stdin io.Reader, stdout io.Writer) error {
+	var errBuf bytes.Buffer
+	err := execInPod(ctx, client, config, namespace, podName, ShellOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     stdin,
+		Stdout:    stdout,
+		Stderr:    &errBuf,
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(errBuf.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func execInPod(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName string, opts ShellOptions) error {
 	req := client.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(podName).
@@ -113,7 +148,7 @@ func execInPod(ctx context.Context, client *kubernetes.Clientset, config *rest.C
 	return exec.StreamWithContext(ctx, streamOpts)
 }
 
-func execEphemeral(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, namespace, podName, targetContainer string, opts ShellOptions) (*ExecResult, error) {
+func execEphemeral(ctx context.Context, client kubernetes.Interface, config *rest.Config, namespace, podName, targetContainer string, opts ShellOptions) (*ExecResult, error) {
 	result := &ExecResult{UsedEphemeral: true}
 
 	// Generate ephemeral container name
@@ -163,7 +198,7 @@ func execEphemeral(ctx context.Context, client *kubernetes.Clientset, config *re
 	return result, err
 }
 
-func waitForEphemeralContainer(ctx context.Context, client *kubernetes.Clientset, namespace, podName, containerName string) error {
+func waitForEphemeralContainer(ctx context.Context, client kubernetes.Interface, namespace, podName, containerName string) error {
 	for i := 0; i < 30; i++ {
 		pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
@@ -5,25 +5,32 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/format"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 // AppStatus contains comprehensive status information for an app
 type AppStatus struct {
 	Name       string
 	Namespace  string
-	Deployment *DeploymentStatus
+	Deployment *DeploymentStatus // The app's Deployment or StatefulSet; see Kind
+	CronJobs   []CronJobStatus
 	Pods       []PodStatus
 	Events     []EventInfo
 }
 
-// DeploymentStatus contains deployment-level status
+// DeploymentStatus contains workload-level status. Kind is "Deployment" or
+// "StatefulSet"; the field keeps its name so -o json output doesn't change
 type DeploymentStatus struct {
+	Kind              string
 	Name              string
 	Replicas          int32
 	ReadyReplicas     int32
@@ -32,6 +39,17 @@ type DeploymentStatus struct {
 	Strategy          string
 	Image             string
 	CreatedAt         time.Time
+	// Change is read from the change-tracking annotations kbox stamps at deploy time
+	Change render.ChangeInfo
+}
+
+// CronJobStatus contains the status of one of the app's scheduled jobs
+type CronJobStatus struct {
+	Name         string
+	Schedule     string
+	Suspended    bool
+	Active       int
+	LastSchedule time.Time
 }
 
 // PodStatus contains pod-level status
@@ -41,6 +59,7 @@ type PodStatus struct {
 	Ready      bool
 	Restarts   int32
 	Age        time.Duration
+	Created    time.Time `json:"-"` // For display; Age stays in the JSON
 	IP         string
 	Node       string
 	Containers []ContainerStatus
@@ -63,24 +82,32 @@ type EventInfo struct {
 	Count     int32
 	LastSeen  time.Time
 	FirstSeen time.Time
+	Kind      string // Involved object kind (e.g. Pod)
+	Object    string // Involved object name
 }
 
 // GetAppStatus retrieves comprehensive status for an app
-func GetAppStatus(ctx context.Context, client *kubernetes.Clientset, namespace, appName string) (*AppStatus, error) {
+func GetAppStatus(ctx context.Context, client kubernetes.Interface, namespace, appName string) (*AppStatus, error) {
 	status := &AppStatus{
 		Name:      appName,
 		Namespace: namespace,
 	}
 
-	// Try to find deployment
-	deployment, err := findDeployment(ctx, client, namespace, appName)
-	if err == nil && deployment != nil {
+	// Find the app's workload: a Deployment, or a StatefulSet for workload: statefulset
+	if deployment, err := findDeployment(ctx, client, namespace, appName); err == nil {
 		status.Deployment = deploymentToStatus(deployment)
+	} else if sts, err := findStatefulSet(ctx, client, namespace, appName); err == nil {
+		status.Deployment = statefulSetToStatus(sts)
+	}
+
+	// Scheduled jobs
+	if cronJobs, err := findCronJobs(ctx, client, namespace, appName); err == nil {
+		status.CronJobs = cronJobs
 	}
 
-	// Find pods
+	// Find pods. CronJobs between runs and workloads scaled to zero have none
 	pods, err := FindPods(ctx, client, namespace, appName)
-	if err != nil {
+	if err != nil && status.Deployment == nil && len(status.CronJobs) == 0 {
 		return nil, err
 	}
 
@@ -102,7 +129,7 @@ func GetAppStatus(ctx context.Context, client *kubernetes.Clientset, namespace,
 	return status, nil
 }
 
-func findDeployment(ctx context.Context, client *kubernetes.Clientset, namespace, appName string) (*appsv1.Deployment, error) {
+func findDeployment(ctx context.Context, client kubernetes.Interface, namespace, appName string) (*appsv1.Deployment, error) {
 	// Try direct name match
 	dep, err := client.AppsV1().Deployments(namespace).Get(ctx, appName, metav1.GetOptions{})
 	if err == nil {
@@ -124,8 +151,55 @@ func findDeployment(ctx context.Context, client *kubernetes.Clientset, namespace
 	return nil, fmt.Errorf("deployment not found")
 }
 
+func findStatefulSet(ctx context.Context, client kubernetes.Interface, namespace, appName string) (*appsv1.StatefulSet, error) {
+	sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, appName, metav1.GetOptions{})
+	if err == nil {
+		return sts, nil
+	}
+
+	list, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", appName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list.Items) > 0 {
+		return &list.Items[0], nil
+	}
+
+	return nil, fmt.Errorf("statefulset not found")
+}
+
+// findCronJobs lists the CronJobs kbox renders for the app's scheduled jobs
+func findCronJobs(ctx context.Context, client kubernetes.Interface, namespace, appName string) ([]CronJobStatus, error) {
+	list, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", appName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CronJobStatus
+	for _, cj := range list.Items {
+		s := CronJobStatus{
+			Name:      cj.Name,
+			Schedule:  cj.Spec.Schedule,
+			Suspended: cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+			Active:    len(cj.Status.Active),
+		}
+		if cj.Status.LastScheduleTime != nil {
+			s.LastSchedule = cj.Status.LastScheduleTime.Time
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
 func deploymentToStatus(dep *appsv1.Deployment) *DeploymentStatus {
 	status := &DeploymentStatus{
+		Kind:              "Deployment",
 		Name:              dep.Name,
 		Replicas:          *dep.Spec.Replicas,
 		ReadyReplicas:     dep.Status.ReadyReplicas,
@@ -133,6 +207,7 @@ func deploymentToStatus(dep *appsv1.Deployment) *DeploymentStatus {
 		AvailableReplicas: dep.Status.AvailableReplicas,
 		Strategy:          string(dep.Spec.Strategy.Type),
 		CreatedAt:         dep.CreationTimestamp.Time,
+		Change:            render.ChangeInfoFrom(dep.Annotations),
 	}
 
 	// Get image from first container
@@ -143,18 +218,42 @@ func deploymentToStatus(dep *appsv1.Deployment) *DeploymentStatus {
 	return status
 }
 
-func getPodStatus(ctx context.Context, client *kubernetes.Clientset, namespace, podName string) (*PodStatus, error) {
+func statefulSetToStatus(sts *appsv1.StatefulSet) *DeploymentStatus {
+	status := &DeploymentStatus{
+		Kind:              "StatefulSet",
+		Name:              sts.Name,
+		Replicas:          1,
+		ReadyReplicas:     sts.Status.ReadyReplicas,
+		UpdatedReplicas:   sts.Status.UpdatedReplicas,
+		AvailableReplicas: sts.Status.AvailableReplicas,
+		Strategy:          string(sts.Spec.UpdateStrategy.Type),
+		CreatedAt:         sts.CreationTimestamp.Time,
+		Change:            render.ChangeInfoFrom(sts.Annotations),
+	}
+	if sts.Spec.Replicas != nil {
+		status.Replicas = *sts.Spec.Replicas
+	}
+
+	if len(sts.Spec.Template.Spec.Containers) > 0 {
+		status.Image = sts.Spec.Template.Spec.Containers[0].Image
+	}
+
+	return status
+}
+
+func getPodStatus(ctx context.Context, client kubernetes.Interface, namespace, podName string) (*PodStatus, error) {
 	pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	status := &PodStatus{
-		Name:   pod.Name,
-		Phase:  string(pod.Status.Phase),
-		IP:     pod.Status.PodIP,
-		Node:   pod.Spec.NodeName,
-		Age:    time.Since(pod.CreationTimestamp.Time),
+		Name:    pod.Name,
+		Phase:   string(pod.Status.Phase),
+		IP:      pod.Status.PodIP,
+		Node:    pod.Spec.NodeName,
+		Age:     time.Since(pod.CreationTimestamp.Time),
+		Created: pod.CreationTimestamp.Time,
 	}
 
 	// Get container statuses
@@ -192,7 +291,7 @@ func getPodStatus(ctx context.Context, client *kubernetes.Clientset, namespace,
 	return status, nil
 }
 
-func getAppEvents(ctx context.Context, client *kubernetes.Clientset, namespace, appName string, pods []PodInfo) ([]EventInfo, error) {
+func getAppEvents(ctx context.Context, client kubernetes.Interface, namespace, appName string, pods []PodInfo) ([]EventInfo, error) {
 	// Get events for the namespace
 	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -240,6 +339,28 @@ func getAppEvents(ctx context.Context, client *kubernetes.Clientset, namespace,
 	return result, nil
 }
 
+// formatChange summarizes a Deployment's change annotations, e.g. "v3 from main@1a2b3c4 by alice"
+func formatChange(c render.ChangeInfo) string {
+	var parts []string
+	if c.Revision > 0 {
+		parts = append(parts, fmt.Sprintf("v%d", c.Revision))
+	}
+	if c.GitSHA != "" {
+		ref := c.GitSHA
+		if len(ref) > 7 {
+			ref = ref[:7]
+		}
+		if c.GitBranch != "" {
+			ref = c.GitBranch + "@" + ref
+		}
+		parts = append(parts, "from "+ref)
+	}
+	if c.DeployedBy != "" {
+		parts = append(parts, "by "+c.DeployedBy)
+	}
+	return strings.Join(parts, " ")
+}
+
 // PrintStatus writes formatted status to output
 func PrintStatus(w io.Writer, status *AppStatus) {
 	fmt.Fprintf(w, "App: %s (namespace: %s)\n", status.Name, status.Namespace)
@@ -248,12 +369,35 @@ func PrintStatus(w io.Writer, status *AppStatus) {
 	// Deployment info
 	if status.Deployment != nil {
 		d := status.Deployment
-		fmt.Fprintln(w, "Deployment:")
+		fmt.Fprintf(w, "%s:\n", d.Kind)
 		fmt.Fprintf(w, "  Replicas: %d/%d ready, %d updated, %d available\n",
 			d.ReadyReplicas, d.Replicas, d.UpdatedReplicas, d.AvailableReplicas)
 		fmt.Fprintf(w, "  Strategy: %s\n", d.Strategy)
 		fmt.Fprintf(w, "  Image: %s\n", d.Image)
-		fmt.Fprintf(w, "  Age: %s\n", formatDuration(time.Since(d.CreatedAt)))
+		if line := formatChange(d.Change); line != "" {
+			fmt.Fprintf(w, "  Release: %s\n", line)
+		}
+		fmt.Fprintf(w, "  Age: %s\n", format.Age(d.CreatedAt))
+		fmt.Fprintln(w)
+	}
+
+	// Scheduled jobs
+	if len(status.CronJobs) > 0 {
+		fmt.Fprintln(w, "CronJobs:")
+		for _, cj := range status.CronJobs {
+			last := "never"
+			if !cj.LastSchedule.IsZero() {
+				last = format.Ago(cj.LastSchedule)
+			}
+			fmt.Fprintf(w, "  %s: %s, last run %s", cj.Name, cj.Schedule, last)
+			if cj.Active > 0 {
+				fmt.Fprintf(w, ", %d active", cj.Active)
+			}
+			if cj.Suspended {
+				fmt.Fprint(w, " (suspended)")
+			}
+			fmt.Fprintln(w)
+		}
 		fmt.Fprintln(w)
 	}
 
@@ -265,7 +409,7 @@ func PrintStatus(w io.Writer, status *AppStatus) {
 			readyStr = "not ready"
 		}
 		fmt.Fprintf(w, "  %s: %s (%s), restarts=%d, age=%s\n",
-			p.Name, p.Phase, readyStr, p.Restarts, formatDuration(p.Age))
+			p.Name, p.Phase, readyStr, p.Restarts, format.Age(p.Created))
 
 		// Show container issues
 		for _, c := range p.Containers {
@@ -292,22 +436,9 @@ func PrintStatus(w io.Writer, status *AppStatus) {
 			if e.Count > 1 {
 				fmt.Fprintf(w, " (x%d)", e.Count)
 			}
-			fmt.Fprintf(w, " [%s ago]\n", formatDuration(time.Since(e.LastSeen)))
+			fmt.Fprintf(w, " [%s]\n", format.Ago(e.LastSeen))
 		}
 	} else {
 		fmt.Fprintln(w, "Recent Events: none")
 	}
 }
-
-func formatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
-}
@@ -0,0 +1,65 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodStatusJSONKeepsAge(t *testing.T) {
+	data, err := json.Marshal(PodStatus{Name: "myapp-abc", Age: time.Minute, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Age":60000000000`) || strings.Contains(string(data), "Created") {
+		t.Errorf("kbox status -o json changed its pod fields: %s", data)
+	}
+}
+
+func TestGetAppStatus_StatefulSetAndCronJobs(t *testing.T) {
+	replicas := int32(3)
+	labels := map[string]string{"app": "db"}
+	client := fake.NewSimpleClientset(
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: labels},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:       &replicas,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+			},
+			Status: appsv1.StatefulSetStatus{ReadyReplicas: 2},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-backup", Namespace: "default", Labels: labels},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 3 * * *"},
+		},
+	)
+
+	status, err := GetAppStatus(context.Background(), client, "default", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := status.Deployment
+	if d == nil || d.Kind != "StatefulSet" || d.Replicas != 3 || d.ReadyReplicas != 2 || d.Strategy != "RollingUpdate" {
+		t.Fatalf("workload status = %+v", d)
+	}
+	if len(status.CronJobs) != 1 || status.CronJobs[0].Schedule != "0 3 * * *" {
+		t.Fatalf("CronJobs = %+v", status.CronJobs)
+	}
+
+	var buf bytes.Buffer
+	PrintStatus(&buf, status)
+	out := buf.String()
+	for _, want := range []string{"StatefulSet:\n", "Replicas: 2/3 ready", "db-backup: 0 3 * * *, last run never"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintStatus missing %q:\n%s", want, out)
+		}
+	}
+}
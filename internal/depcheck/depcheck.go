@@ -0,0 +1,386 @@
+// Package depcheck checks that an app's dependencies accept connections
+// with the credentials injected into the app, from a probe pod in its
+// namespace
+package depcheck
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+)
+
+// ProbeImage checks the port of dependencies whose template has no
+// CheckCommand
+const ProbeImage = "busybox:1.36.1"
+
+// LabelProbe marks the probe pod, with the app's name
+const LabelProbe = "kbox.dev/check-deps"
+
+// Deadline bounds the probe pod's run, image pulls included
+const Deadline = 2 * time.Minute
+
+// waitTimeout is how long Run waits for the probe pod: a little longer than
+// Deadline, which only counts from when a node starts the pod, so it's no
+// help while the pod is unschedulable
+var waitTimeout = Deadline + 30*time.Second
+
+// Status is the outcome of checking one dependency
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusAuthFailed  Status = "auth failed"
+	StatusUnreachable Status = "unreachable"
+	StatusFailed      Status = "failed"
+	StatusSkipped     Status = "skipped"
+)
+
+// Target is a dependency to check and the container that checks it
+type Target struct {
+	Dependency string
+	Image      string
+	Command    []string
+	RunAsUser  int64
+	// Note qualifies an ok result, e.g. when only the port was checked
+	Note string
+	// Skip is why the dependency can't be checked, if it can't
+	Skip string
+}
+
+// Result is the outcome of checking a dependency
+type Result struct {
+	Dependency string `json:"dependency"`
+	Status     Status `json:"status"`
+	// LatencyMs is how long the check took, connecting included
+	LatencyMs int    `json:"latencyMs"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Targets returns the app's dependencies to check, in kbox.yaml's order.
+// Each is checked with its template's CheckCommand, in its own image, or
+// else by connecting to its port.
+func Targets(cfg *config.AppConfig) []Target {
+	var targets []Target
+	for _, dep := range cfg.Spec.Dependencies {
+		template, ok := dependencies.Get(dep.Type)
+		if !ok {
+			targets = append(targets, Target{Dependency: dep.Type, Skip: "unknown dependency type"})
+			continue
+		}
+		runAsUser := template.RunAsUser
+		if runAsUser == 0 {
+			runAsUser = 1000
+		}
+		if len(template.CheckCommand) > 0 {
+			targets = append(targets, Target{
+				Dependency: dep.Type,
+				Image:      dependencies.ImageWithVersion(template, dep.Version),
+				Command:    template.CheckCommand,
+				RunAsUser:  runAsUser,
+			})
+			continue
+		}
+
+		host, port := cfg.Metadata.Name+"-"+dep.Type, template.DefaultPort
+		if ext := dep.External; ext != nil {
+			host = ext.Host
+			if ext.Port != 0 {
+				port = ext.Port
+			}
+		}
+		if host == "" {
+			targets = append(targets, Target{Dependency: dep.Type, Skip: "its host is in a Secret and its template has no checkCommand"})
+			continue
+		}
+		targets = append(targets, Target{
+			Dependency: dep.Type,
+			Image:      ProbeImage,
+			Command:    []string{"sh", "-c", `nc -z -w 5 "$0" "$1" || { echo "can't connect to $0:$1"; exit 1; }`, host, strconv.Itoa(int(port))},
+			RunAsUser:  1000,
+			Note:       "port open; credentials not checked (the template has no checkCommand)",
+		})
+	}
+	return targets
+}
+
+// timed runs the command in "$@" and prints its output, then how it exited
+// and how long it took, in milliseconds. date +%N isn't in every image's
+// date, so /proc/uptime's hundredths stand in for it.
+const timed = `now() {
+  t=$(date +%s%N 2>/dev/null)
+  case "$t" in
+    ''|*N) read -r t _ </proc/uptime; t=$(( ${t%.*}${t#*.} * 10000000 )) ;;
+  esac
+  echo "$t"
+}
+start=$(now)
+"$@" 2>&1
+code=$?
+end=$(now)
+echo "kbox-check exit=$code ms=$(( (end - start) / 1000000 ))"`
+
+// ProbePod returns the pod that checks targets, one container each. The
+// containers get the env vars of app's first container, so they connect
+// with exactly the credentials the app has.
+func ProbePod(appName, namespace string, app *corev1.PodSpec, targets []Target) *corev1.Pod {
+	deadline := int64(Deadline.Seconds())
+	noToken := false
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: appName + "-check-deps-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "kbox",
+				LabelProbe:                     appName,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:        &deadline,
+			AutomountServiceAccountToken: &noToken,
+			ImagePullSecrets:             app.ImagePullSecrets,
+			SecurityContext: &corev1.PodSecurityContext{
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+		},
+	}
+
+	var env []corev1.EnvVar
+	var envFrom []corev1.EnvFromSource
+	if len(app.Containers) > 0 {
+		env, envFrom = app.Containers[0].Env, app.Containers[0].EnvFrom
+	}
+	for _, t := range targets {
+		if t.Skip != "" {
+			continue
+		}
+		nonRoot, noEscalation := true, false
+		uid := t.RunAsUser
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+			Name:    t.Dependency,
+			Image:   t.Image,
+			Command: append([]string{"sh", "-c", timed, "check"}, t.Command...),
+			Env:     env,
+			EnvFrom: envFrom,
+			SecurityContext: &corev1.SecurityContext{
+				RunAsNonRoot:             &nonRoot,
+				RunAsUser:                &uid,
+				RunAsGroup:               &uid,
+				AllowPrivilegeEscalation: &noEscalation,
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		})
+	}
+	return pod
+}
+
+var marker = regexp.MustCompile(`(?m)^kbox-check exit=(\d+) ms=(-?\d+)\s*$`)
+
+// ParseResult reads the outcome of target's check from its container's log
+func ParseResult(t Target, log string) (Result, bool) {
+	m := marker.FindStringSubmatchIndex(log)
+	if m == nil {
+		return Result{Dependency: t.Dependency}, false
+	}
+	exit, _ := strconv.Atoi(log[m[2]:m[3]])
+	ms, _ := strconv.Atoi(log[m[4]:m[5]])
+	out := log[:m[0]]
+
+	result := Result{Dependency: t.Dependency, LatencyMs: max(ms, 0)}
+	if exit == 0 {
+		result.Status, result.Message = StatusOK, t.Note
+		return result, true
+	}
+	result.Status, result.Message = Classify(out), lastLine(out)
+	if result.Message == "" {
+		result.Message = fmt.Sprintf("check exited with %d", exit)
+	}
+	return result, true
+}
+
+// authErrors and networkErrors are how the clients of the built-in
+// dependencies, and busybox, report rejected credentials and unreachable
+// servers
+var (
+	authErrors = []string{
+		"password authentication failed", "authentication failed", "wrongpass", "noauth",
+		"access denied", "access_refused", "not authorized", "invalid password", "auth failed",
+	}
+	networkErrors = []string{
+		"no response", "could not translate host name", "name or service not known", "bad address",
+		"unknown mysql server host", "can't connect", "could not connect", "connection refused",
+		"econnrefused", "enotfound", "no route to host", "timed out", "timeout", "server selection",
+	}
+)
+
+// Classify tells rejected credentials and unreachable servers apart from
+// other failures, by the check's output
+func Classify(output string) Status {
+	lower := strings.ToLower(output)
+	for _, s := range authErrors {
+		if strings.Contains(lower, s) {
+			return StatusAuthFailed
+		}
+	}
+	for _, s := range networkErrors {
+		if strings.Contains(lower, s) {
+			return StatusUnreachable
+		}
+	}
+	return StatusFailed
+}
+
+// lastLine returns the last non-empty line of out, where clients put the
+// error
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// stuck are the reasons a container waits that it won't recover from
+// within the deadline
+var stuck = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
+
+// Run creates the probe pod, waits for its checks and returns their
+// results, in targets' order. The pod is deleted when Run returns.
+func Run(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, targets []Target) ([]Result, error) {
+	created, err := client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the probe pod: %w", err)
+	}
+	defer func() {
+		grace := int64(0)
+		cleanup, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = client.CoreV1().Pods(created.Namespace).Delete(cleanup, created.Name, metav1.DeleteOptions{GracePeriodSeconds: &grace})
+	}()
+
+	finished, timedOut, err := wait(ctx, client, created)
+	if err != nil {
+		return nil, err
+	}
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, s := range finished.Status.ContainerStatuses {
+		statuses[s.Name] = s
+	}
+
+	var results []Result
+	for _, t := range targets {
+		if t.Skip != "" {
+			results = append(results, Result{Dependency: t.Dependency, Status: StatusSkipped, Message: t.Skip})
+			continue
+		}
+		status, started := statuses[t.Dependency]
+		if !started {
+			results = append(results, Result{Dependency: t.Dependency, Status: StatusFailed, Message: notStartedMessage(finished)})
+			continue
+		}
+		if status.State.Waiting != nil {
+			message := waitingMessage(status.State.Waiting)
+			if timedOut {
+				message = fmt.Sprintf("still %s after %s", message, waitTimeout)
+			}
+			results = append(results, Result{Dependency: t.Dependency, Status: StatusFailed, Message: message})
+			continue
+		}
+		raw, err := client.CoreV1().Pods(created.Namespace).GetLogs(created.Name, &corev1.PodLogOptions{Container: t.Dependency}).Do(ctx).Raw()
+		if err != nil {
+			results = append(results, Result{Dependency: t.Dependency, Status: StatusFailed, Message: fmt.Sprintf("failed to read the check's output: %v", err)})
+			continue
+		}
+		result, ok := ParseResult(t, string(raw))
+		if !ok {
+			result.Status, result.Message = StatusUnreachable, fmt.Sprintf("no answer within %s", Deadline)
+			if term := status.State.Terminated; term != nil && term.Reason != "" && term.Reason != "Error" {
+				result.Status, result.Message = StatusFailed, "check container "+term.Reason
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// wait polls the probe pod until every container has finished or is stuck,
+// or the pod is past its deadline. A pod still unfinished after waitTimeout
+// is returned as it is, with timedOut set.
+func wait(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod) (*corev1.Pod, bool, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	last := pod
+	for {
+		current, err := client.CoreV1().Pods(pod.Namespace).Get(waitCtx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return last, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to read the probe pod: %w", err)
+		}
+		last = current
+		finished := 0
+		for _, s := range current.Status.ContainerStatuses {
+			if s.State.Terminated != nil || (s.State.Waiting != nil && stuck[s.State.Waiting.Reason]) {
+				finished++
+			}
+		}
+		phase := current.Status.Phase
+		if finished == len(current.Spec.Containers) || phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+			return current, false, nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, false, ctx.Err()
+			}
+			return current, true, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitingMessage explains a container that never started. A missing Secret
+// or key the app's env refers to shows up here.
+func waitingMessage(w *corev1.ContainerStateWaiting) string {
+	if w.Message == "" {
+		return w.Reason
+	}
+	return w.Reason + ": " + w.Message
+}
+
+// notStartedMessage explains a probe pod whose containers never started,
+// such as one no node could schedule
+func notStartedMessage(pod *corev1.Pod) string {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Message != "" {
+			if c.Reason != "" {
+				return fmt.Sprintf("probe pod not scheduled (%s): %s", c.Reason, c.Message)
+			}
+			return "probe pod not scheduled: " + c.Message
+		}
+	}
+	if pod.Status.Message != "" {
+		return "probe pod didn't start: " + pod.Status.Message
+	}
+	if pod.Status.Phase == corev1.PodPending || pod.Status.Phase == "" {
+		return fmt.Sprintf("probe pod still Pending after %s", waitTimeout)
+	}
+	return "probe pod didn't start"
+}
@@ -0,0 +1,217 @@
+package depcheck
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+)
+
+func TestTargets(t *testing.T) {
+	if err := dependencies.Register("queue", dependencies.Template{Image: "my-queue", DefaultVersion: "1", DefaultPort: 5672}); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(dependencies.Registry, "queue")
+
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Dependencies: []config.DependencyConfig{
+				{Type: "postgres", Version: "16"},
+				{Type: "queue"},
+				{Type: "redis", External: &config.ExternalDependencyConfig{SecretRef: "redis-creds"}},
+			},
+		},
+	}
+	targets := Targets(cfg)
+	if len(targets) != 3 {
+		t.Fatalf("expected 3 targets, got %d", len(targets))
+	}
+
+	pg := targets[0]
+	if pg.Image != "postgres:16" || pg.RunAsUser != 70 || !strings.Contains(strings.Join(pg.Command, " "), "psql") {
+		t.Errorf("expected postgres checked with psql in its own image, got %+v", pg)
+	}
+	queue := targets[1]
+	if queue.Image != ProbeImage || !slices.Contains(queue.Command, "myapp-queue") || !slices.Contains(queue.Command, "5672") || queue.Note == "" {
+		t.Errorf("expected the queue's port checked, got %+v", queue)
+	}
+	if targets[2].Skip != "" {
+		t.Errorf("external redis has a checkCommand, so it should be checked, got %q", targets[2].Skip)
+	}
+}
+
+func TestProbePod(t *testing.T) {
+	app := &corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		Containers: []corev1.Container{{
+			Name: "myapp",
+			Env: []corev1.EnvVar{{
+				Name:      "DATABASE_URL",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "myapp-postgres"}, Key: "DATABASE_URL"}},
+			}},
+		}},
+	}
+	targets := []Target{
+		{Dependency: "postgres", Image: "postgres:16", Command: []string{"pg_isready"}, RunAsUser: 70},
+		{Dependency: "queue", Skip: "no way to check"},
+	}
+	pod := ProbePod("myapp", "shop", app, targets)
+
+	if pod.Namespace != "shop" || pod.Labels[LabelProbe] != "myapp" || !strings.HasPrefix(pod.GenerateName, "myapp-check-deps-") {
+		t.Errorf("unexpected metadata %+v", pod.ObjectMeta)
+	}
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever || pod.Spec.ActiveDeadlineSeconds == nil {
+		t.Error("expected a run-once pod with a deadline")
+	}
+	if len(pod.Spec.Containers) != 1 {
+		t.Fatalf("expected a container for the checkable dependency only, got %d", len(pod.Spec.Containers))
+	}
+	c := pod.Spec.Containers[0]
+	if c.Name != "postgres" || c.Command[len(c.Command)-1] != "pg_isready" {
+		t.Errorf("expected the check wrapped in the timer, got %v", c.Command)
+	}
+	if len(c.Env) != 1 || c.Env[0].ValueFrom.SecretKeyRef.Name != "myapp-postgres" {
+		t.Errorf("expected the app's env, got %v", c.Env)
+	}
+	if *c.SecurityContext.RunAsUser != 70 || !*c.SecurityContext.RunAsNonRoot {
+		t.Errorf("expected to run as the dependency's user, got %+v", c.SecurityContext)
+	}
+	if pod.Spec.ImagePullSecrets[0].Name != "registry" {
+		t.Error("expected the app's image pull secrets")
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	target := Target{Dependency: "postgres"}
+	tests := []struct {
+		name    string
+		log     string
+		status  Status
+		latency int
+		message string
+	}{
+		{name: "ok", log: "myapp-postgres:5432 - accepting connections\n1\nkbox-check exit=0 ms=12\n", status: StatusOK, latency: 12},
+		{
+			name:    "auth",
+			log:     "myapp-postgres:5432 - accepting connections\npsql: error: connection to server at \"myapp-postgres\" (10.0.0.5), port 5432 failed: FATAL:  password authentication failed for user \"postgres\"\nkbox-check exit=2 ms=31\n",
+			status:  StatusAuthFailed,
+			latency: 31,
+			message: "password authentication failed",
+		},
+		{name: "unreachable", log: "myapp-postgres:5432 - no response\nkbox-check exit=2 ms=5004\n", status: StatusUnreachable, latency: 5004, message: "no response"},
+		{name: "redis wrong password", log: "WRONGPASS invalid username-password pair or user is disabled.\nkbox-check exit=1 ms=3\n", status: StatusAuthFailed, latency: 3},
+		{name: "other", log: "sh: psql: not found\nkbox-check exit=127 ms=1\n", status: StatusFailed, latency: 1, message: "not found"},
+		{name: "silent failure", log: "kbox-check exit=3 ms=2\n", status: StatusFailed, latency: 2, message: "exited with 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ParseResult(target, tt.log)
+			if !ok {
+				t.Fatal("expected a result")
+			}
+			if result.Status != tt.status || result.LatencyMs != tt.latency {
+				t.Errorf("got %s in %dms, want %s in %dms", result.Status, result.LatencyMs, tt.status, tt.latency)
+			}
+			if !strings.Contains(result.Message, tt.message) {
+				t.Errorf("expected %q in message, got %q", tt.message, result.Message)
+			}
+		})
+	}
+
+	if _, ok := ParseResult(target, "myapp-postgres:5432 - no response\n"); ok {
+		t.Error("a check killed before it finished has no result")
+	}
+	result, _ := ParseResult(Target{Dependency: "queue", Note: "port open"}, "kbox-check exit=0 ms=4\n")
+	if result.Message != "port open" {
+		t.Errorf("expected the target's note on success, got %q", result.Message)
+	}
+}
+
+func TestRun_ContainerNeverStarts(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "postgres",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "CreateContainerConfigError",
+					Message: `secret "myapp-postgres" not found`,
+				}},
+			}}},
+		}, nil
+	})
+
+	targets := []Target{
+		{Dependency: "postgres", Image: "postgres:16", Command: []string{"pg_isready"}},
+		{Dependency: "queue", Skip: "no way to check"},
+	}
+	pod := ProbePod("myapp", "shop", &corev1.PodSpec{}, targets)
+	pod.Name = "myapp-check-deps-abcde"
+	results, err := Run(context.Background(), client, pod, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != StatusFailed || !strings.Contains(results[0].Message, `secret "myapp-postgres" not found`) {
+		t.Errorf("expected the missing secret reported, got %+v", results[0])
+	}
+	if results[1].Status != StatusSkipped {
+		t.Errorf("expected queue skipped, got %+v", results[1])
+	}
+	pods, _ := client.CoreV1().Pods("shop").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("expected the probe pod deleted, got %d pods", len(pods.Items))
+	}
+}
+
+func TestRun_Unschedulable(t *testing.T) {
+	defer func(timeout time.Duration) { waitTimeout = timeout }(waitTimeout)
+	waitTimeout = 1500 * time.Millisecond
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 3 Insufficient memory.",
+				}},
+			},
+		}, nil
+	})
+
+	targets := []Target{{Dependency: "postgres", Image: "postgres:16", Command: []string{"pg_isready"}}}
+	pod := ProbePod("myapp", "shop", &corev1.PodSpec{}, targets)
+	pod.Name = "myapp-check-deps-abcde"
+	results, err := Run(context.Background(), client, pod, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != StatusFailed || !strings.Contains(results[0].Message, "Unschedulable") {
+		t.Errorf("expected the unschedulable pod reported, got %+v", results)
+	}
+}
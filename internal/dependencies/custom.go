@@ -0,0 +1,185 @@
+package dependencies
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LocalDir holds a project's custom dependency templates, relative to
+// kbox.yaml. Each <type>.yaml file in it adds a dependency type.
+const LocalDir = ".kbox/dependencies"
+
+// builtins are the types kbox ships; custom templates can't replace them
+var builtins = SupportedTypes()
+
+// typeName matches dependency type names: they name Kubernetes objects
+// (<app>-<type>) and containers
+var typeName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// TemplateFile is a custom dependency template as written in
+// .kbox/dependencies/<type>.yaml. Env values may use the {{.Service}},
+// {{.Port}} and {{.Password}} placeholders; env vars with a password are
+// injected into the app from the dependency's Secret.
+type TemplateFile struct {
+	// Name is the dependency type (default: the file name)
+	Name string `json:"name,omitempty"`
+
+	Image   string `json:"image"`
+	Version string `json:"version"`
+	Port    int32  `json:"port"`
+	Storage string `json:"storage,omitempty"`
+
+	DataPath     string `json:"dataPath,omitempty"`
+	RunAsUser    int64  `json:"runAsUser,omitempty"`
+	WritableRoot bool   `json:"writableRoot,omitempty"`
+
+	Env        map[string]string `json:"env,omitempty"`
+	SecretKeys []string          `json:"secretKeys,omitempty"`
+
+	// ConfigArgs are the server arguments for each dependencies[].config
+	// setting, with {{.Key}} and {{.Value}} placeholders
+	ConfigArgs []string `json:"configArgs,omitempty"`
+
+	// Command replaces the image's entrypoint and arguments
+	Command        []string `json:"command,omitempty"`
+	HealthCheck    []string `json:"healthCheck,omitempty"`
+	CheckCommand   []string `json:"checkCommand,omitempty"`
+	ConnectCommand []string `json:"connectCommand,omitempty"`
+	DumpCommand    []string `json:"dumpCommand,omitempty"`
+	RestoreCommand []string `json:"restoreCommand,omitempty"`
+	ReloadCommand  []string `json:"reloadCommand,omitempty"`
+}
+
+// ParseTemplate parses and checks a custom template. name is used when the
+// file doesn't set one.
+func ParseTemplate(data []byte, name string) (string, Template, error) {
+	var f TemplateFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return "", Template{}, fmt.Errorf("invalid template: %w", err)
+	}
+	if f.Name != "" {
+		name = f.Name
+	}
+	name = strings.ToLower(name)
+
+	var problems []string
+	if !typeName.MatchString(name) {
+		problems = append(problems, fmt.Sprintf("name %q must be lowercase alphanumeric with hyphens", name))
+	}
+	if f.Image == "" {
+		problems = append(problems, "image is required")
+	}
+	if f.Version == "" {
+		problems = append(problems, "version is required")
+	}
+	if f.Port < 1 || f.Port > 65535 {
+		problems = append(problems, "port must be between 1 and 65535")
+	}
+	if f.DataPath != "" && !strings.HasPrefix(f.DataPath, "/") {
+		problems = append(problems, "dataPath must be absolute")
+	}
+	if f.RunAsUser < 0 {
+		problems = append(problems, "runAsUser must not be negative")
+	}
+	usesPassword := false
+	for _, v := range f.Env {
+		usesPassword = usesPassword || strings.Contains(v, "{{.Password}}")
+	}
+	if usesPassword && len(f.SecretKeys) == 0 {
+		problems = append(problems, "env uses {{.Password}}, so secretKeys must name the server's password env var")
+	}
+	if len(f.ConfigArgs) > 0 && !strings.Contains(strings.Join(f.ConfigArgs, " "), "{{.Key}}") {
+		problems = append(problems, "configArgs must use {{.Key}}")
+	}
+	if len(problems) > 0 {
+		return "", Template{}, fmt.Errorf("invalid template %s: %s", name, strings.Join(problems, "; "))
+	}
+
+	storage := f.Storage
+	if storage == "" {
+		storage = "1Gi"
+	}
+	return name, Template{
+		Image:          f.Image,
+		DefaultVersion: f.Version,
+		DefaultPort:    f.Port,
+		DefaultStorage: storage,
+		DataPath:       f.DataPath,
+		RunAsUser:      f.RunAsUser,
+		WritableRoot:   f.WritableRoot,
+		EnvVars:        f.Env,
+		SecretKeys:     f.SecretKeys,
+		HealthCheck:    f.HealthCheck,
+		CheckCommand:   f.CheckCommand,
+		ConnectCommand: f.ConnectCommand,
+		CommandArgs:    f.Command,
+		ConfigArgs:     f.ConfigArgs,
+		DumpCommand:    f.DumpCommand,
+		RestoreCommand: f.RestoreCommand,
+		ReloadCommand:  f.ReloadCommand,
+	}, nil
+}
+
+// Register adds a custom dependency type. Built-in types can't be replaced.
+func Register(name string, template Template) error {
+	if slices.Contains(builtins, name) {
+		return fmt.Errorf("%s is a built-in dependency and can't be replaced", name)
+	}
+	Registry[name] = template
+	return nil
+}
+
+// IsBuiltin reports whether kbox ships the dependency type
+func IsBuiltin(depType string) bool {
+	return slices.Contains(builtins, strings.ToLower(depType))
+}
+
+// LoadDir registers the custom templates in dir (*.yaml and *.yml) and
+// returns their types. A missing dir has none. Templates that fail to load
+// are skipped, so one bad file doesn't hide the others; the error joins
+// theirs, each naming its file.
+func LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var loaded []string
+	var errs []error
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		name, template, err := ParseTemplate(data, strings.TrimSuffix(e.Name(), ext))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if slices.Contains(loaded, name) {
+			errs = append(errs, fmt.Errorf("%s: dependency %s is defined twice", path, name))
+			continue
+		}
+		if err := Register(name, template); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		loaded = append(loaded, name)
+	}
+	return loaded, errors.Join(errs...)
+}
@@ -0,0 +1,102 @@
+package dependencies
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const queueTemplate = `image: registry.example.com/queue
+version: "2.3"
+port: 5672
+dataPath: /var/lib/queue
+runAsUser: 1001
+env:
+  QUEUE_URL: "amqp://app:{{.Password}}@{{.Service}}:{{.Port}}"
+  QUEUE_HOST: "{{.Service}}"
+secretKeys: [QUEUE_PASSWORD]
+healthCheck: [queue-ctl, ping]
+`
+
+func TestParseTemplate(t *testing.T) {
+	name, template, err := ParseTemplate([]byte(queueTemplate), "Queue")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	if name != "queue" {
+		t.Errorf("expected the file name as the type, got %q", name)
+	}
+	if template.DefaultPort != 5672 || template.DefaultStorage != "1Gi" || template.DataPath != "/var/lib/queue" || template.RunAsUser != 1001 {
+		t.Errorf("unexpected template: %+v", template)
+	}
+
+	tests := []struct {
+		name, data, wantErr string
+	}{
+		{"unknown field", queueTemplate + "ports: [1]\n", "unknown field"},
+		{"no image", "version: \"1\"\nport: 80\n", "image is required"},
+		{"bad port", "image: q\nversion: \"1\"\nport: 0\n", "port must be"},
+		{"relative data path", "image: q\nversion: \"1\"\nport: 80\ndataPath: data\n", "dataPath must be absolute"},
+		{"password without secret key", "image: q\nversion: \"1\"\nport: 80\nenv:\n  URL: \"{{.Password}}\"\n", "secretKeys"},
+		{"bad name", "name: My_Queue\nimage: q\nversion: \"1\"\nport: 80\n", "lowercase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseTemplate([]byte(tt.data), "queue"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if loaded, err := LoadDir(filepath.Join(dir, "missing")); err != nil || loaded != nil {
+		t.Fatalf("expected a missing dir to load nothing, got %v, %v", loaded, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "queue.yaml"), []byte(queueTemplate), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a template"), 0644)
+	t.Cleanup(func() { delete(Registry, "queue") })
+
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != "queue" {
+		t.Errorf("expected queue to be loaded, got %v", loaded)
+	}
+	if !IsSupported("queue") || IsBuiltin("queue") {
+		t.Error("expected queue to be a supported custom dependency")
+	}
+
+	os.WriteFile(filepath.Join(dir, "postgres.yaml"), []byte(queueTemplate), 0644)
+	loaded, err = LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "built-in") || !strings.Contains(err.Error(), "postgres.yaml") {
+		t.Errorf("expected replacing postgres to fail, got: %v", err)
+	}
+	if !slices.Contains(loaded, "queue") {
+		t.Errorf("expected queue to load despite postgres.yaml, got %v", loaded)
+	}
+	if postgres, _ := Get("postgres"); postgres.Image != "postgres" {
+		t.Error("the built-in postgres template was replaced")
+	}
+}
+
+func TestEnvReferences(t *testing.T) {
+	postgres, _ := Get("postgres")
+	env := map[string]string{
+		"DB_HOST":       "myapp-postgres.default.svc",
+		"READ_URL":      "$(DATABASE_URL)?sslmode=disable",
+		"LEGACY_URL":    "${PGHOST}:5432",
+		"LOG_LEVEL":     "debug",
+		"OTHER_SERVICE": "myapp-redis",
+	}
+	got := EnvReferences(postgres, "myapp-postgres", env)
+	want := []string{"DB_HOST", "LEGACY_URL", "READ_URL"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("EnvReferences() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package dependencies
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxTemplateSize caps a fetched template; templates are a few hundred bytes
+const maxTemplateSize = 1 << 20
+
+// Source is where kbox add --from fetches a custom template
+type Source struct {
+	// Kind is git, oci, http or file
+	Kind string
+	// URL is the repository, artifact reference, URL or file path
+	URL string
+	// Path is the template file inside a git repository or OCI artifact
+	// (default: <type>.yaml)
+	Path string
+	// Ref is the git branch or tag (default: the repository's default branch)
+	Ref string
+}
+
+// ParseSource parses a template source:
+//
+//	git::https://github.com/acme/kbox-templates.git//queue.yaml?ref=v1
+//	oci://ghcr.io/acme/kbox-templates/queue:v1
+//	https://example.com/templates/queue.yaml
+//	./templates/queue.yaml
+func ParseSource(source string) (Source, error) {
+	switch {
+	case source == "":
+		return Source{}, fmt.Errorf("empty template source")
+	case strings.HasPrefix(source, "git::"):
+		s := Source{Kind: "git", URL: strings.TrimPrefix(source, "git::")}
+		if url, ref, ok := strings.Cut(s.URL, "?ref="); ok {
+			s.URL, s.Ref = url, ref
+		}
+		// The path follows a double slash after the scheme's, if any
+		// (git@github.com:acme/templates.git has none)
+		scheme, rest, hasScheme := strings.Cut(s.URL, "://")
+		if !hasScheme {
+			scheme, rest = "", s.URL
+		}
+		if repo, path, ok := strings.Cut(rest, "//"); ok {
+			s.URL, s.Path = repo, path
+			if hasScheme {
+				s.URL = scheme + "://" + repo
+			}
+		}
+		if s.URL == "" {
+			return Source{}, fmt.Errorf("invalid git source %q: no repository", source)
+		}
+		return s, nil
+	case strings.HasPrefix(source, "oci://"):
+		s := Source{Kind: "oci", URL: strings.TrimPrefix(source, "oci://")}
+		if ref, path, ok := strings.Cut(s.URL, "//"); ok {
+			s.URL, s.Path = ref, path
+		}
+		if s.URL == "" {
+			return Source{}, fmt.Errorf("invalid OCI source %q: no reference", source)
+		}
+		return s, nil
+	case strings.HasPrefix(source, "https://"), strings.HasPrefix(source, "http://"):
+		return Source{Kind: "http", URL: source}, nil
+	default:
+		return Source{Kind: "file", URL: source}, nil
+	}
+}
+
+// Fetch returns the template for depType from source. Git repositories
+// are cloned with git and OCI artifacts pulled with oras.
+func Fetch(ctx context.Context, source, depType string) ([]byte, error) {
+	s, err := ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	path := s.Path
+	if path == "" {
+		path = depType + ".yaml"
+	}
+
+	switch s.Kind {
+	case "git":
+		dir, err := os.MkdirTemp("", "kbox-template-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if s.Ref != "" {
+			args = append(args, "--branch", s.Ref)
+		}
+		if err := run(ctx, "git", append(args, s.URL, dir)...); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", s.URL, err)
+		}
+		return readTemplate(dir, path)
+	case "oci":
+		dir, err := os.MkdirTemp("", "kbox-template-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		if _, err := exec.LookPath("oras"); err != nil {
+			return nil, fmt.Errorf("oras not found\n  → Install it: https://oras.land/docs/installation")
+		}
+		if err := run(ctx, "oras", "pull", "--output", dir, s.URL); err != nil {
+			return nil, fmt.Errorf("failed to pull %s: %w", s.URL, err)
+		}
+		return readTemplate(dir, path)
+	case "http":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: %s", s.URL, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxTemplateSize))
+	default:
+		return os.ReadFile(s.URL)
+	}
+}
+
+// readTemplate reads path inside a fetched directory, refusing paths that
+// leave it
+func readTemplate(dir, path string) ([]byte, error) {
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if rel, err := filepath.Rel(dir, full); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("template path %s is outside the source", path)
+	}
+	data, err := os.ReadFile(full)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found in the source\n  → Name the file: <source>//path/to/template.yaml", path)
+	}
+	return data, err
+}
+
+// run runs a fetch tool, returning an error with the last line it printed
+// to stderr
+func run(ctx context.Context, tool string, args ...string) error {
+	cmd := exec.CommandContext(ctx, tool, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			return fmt.Errorf("%s: %s", err, last)
+		}
+		return err
+	}
+	return nil
+}
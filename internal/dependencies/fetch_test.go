@@ -0,0 +1,88 @@
+package dependencies
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   Source
+	}{
+		{"git::https://github.com/acme/templates.git//queue.yaml?ref=v1", Source{Kind: "git", URL: "https://github.com/acme/templates.git", Path: "queue.yaml", Ref: "v1"}},
+		{"git::https://github.com/acme/templates.git", Source{Kind: "git", URL: "https://github.com/acme/templates.git"}},
+		{"git::git@github.com:acme/templates.git//deps/queue.yaml", Source{Kind: "git", URL: "git@github.com:acme/templates.git", Path: "deps/queue.yaml"}},
+		{"oci://ghcr.io/acme/templates/queue:v1", Source{Kind: "oci", URL: "ghcr.io/acme/templates/queue:v1"}},
+		{"https://example.com/queue.yaml", Source{Kind: "http", URL: "https://example.com/queue.yaml"}},
+		{"./templates/queue.yaml", Source{Kind: "file", URL: "./templates/queue.yaml"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSource(tt.source)
+		if err != nil {
+			t.Errorf("ParseSource(%q): %v", tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSource(%q) = %+v, want %+v", tt.source, got, tt.want)
+		}
+	}
+	if _, err := ParseSource("git::?ref=v1"); err == nil {
+		t.Error("expected a git source without a repository to fail")
+	}
+}
+
+func TestFetch_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/queue.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(queueTemplate))
+	}))
+	defer server.Close()
+
+	data, err := Fetch(context.Background(), server.URL+"/queue.yaml", "queue")
+	if err != nil || string(data) != queueTemplate {
+		t.Fatalf("expected the template, got %q, %v", data, err)
+	}
+	if _, err := Fetch(context.Background(), server.URL+"/missing.yaml", "queue"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got: %v", err)
+	}
+}
+
+func TestFetch_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, "deps"), 0755)
+	os.WriteFile(filepath.Join(repo, "deps", "queue.yaml"), []byte(queueTemplate), 0644)
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "templates"},
+		{"tag", "v1"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	data, err := Fetch(context.Background(), "git::file://"+repo+"//deps/queue.yaml?ref=v1", "queue")
+	if err != nil || string(data) != queueTemplate {
+		t.Fatalf("expected the template, got %q, %v", data, err)
+	}
+	if _, err := Fetch(context.Background(), "git::file://"+repo, "queue"); err == nil || !strings.Contains(err.Error(), "queue.yaml not found") {
+		t.Errorf("expected the default path to be missing, got: %v", err)
+	}
+	if _, err := Fetch(context.Background(), "git::file://"+repo+"//../outside.yaml", "queue"); err == nil || !strings.Contains(err.Error(), "outside the source") {
+		t.Errorf("expected a path outside the repository to fail, got: %v", err)
+	}
+}
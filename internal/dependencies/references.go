@@ -0,0 +1,28 @@
+package dependencies
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// EnvReferences returns the env vars, by name, whose values still point at
+// a dependency: they mention its Service (serviceName) or one of the env
+// vars it injects, as $(VAR) or ${VAR}. They break once it's removed.
+func EnvReferences(template Template, serviceName string, env map[string]string) []string {
+	var refs []string
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		value := env[name]
+		if strings.Contains(value, serviceName) {
+			refs = append(refs, name)
+			continue
+		}
+		for injected := range template.EnvVars {
+			if strings.Contains(value, "$("+injected+")") || strings.Contains(value, "${"+injected+"}") {
+				refs = append(refs, name)
+				break
+			}
+		}
+	}
+	return refs
+}
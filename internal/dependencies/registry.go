@@ -4,6 +4,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -12,7 +15,8 @@ type Template struct {
 	// Image base name
 	Image string
 
-	// DefaultVersion when not specified
+	// DefaultVersion when not specified. Built-in types get theirs from the
+	// versions manifest; see Versions.
 	DefaultVersion string
 
 	// DefaultPort for the service
@@ -21,8 +25,20 @@ type Template struct {
 	// DefaultStorage size
 	DefaultStorage string
 
+	// DataPath is where the server keeps its data; the volume is mounted
+	// there (default: /data)
+	DataPath string
+
+	// RunAsUser is the UID the image's server runs as, also used for the
+	// group and fsGroup. 0 runs as kbox's default UID, 1000.
+	RunAsUser int64
+
+	// WritableRoot leaves the root filesystem writable, for servers that
+	// write outside DataPath
+	WritableRoot bool
+
 	// EnvVars to inject into the app
-	// Supports {{.Service}} and {{.Password}} placeholders
+	// Supports {{.Service}}, {{.Port}} and {{.Password}} placeholders
 	EnvVars map[string]string
 
 	// SecretKeys are environment variables for the dependency pod
@@ -32,80 +48,132 @@ type Template struct {
 	// HealthCheck command for readiness probe
 	HealthCheck []string
 
+	// CheckCommand connects to the server from another pod, with the env
+	// vars injected into the app, for kbox check-deps. It fails when the
+	// server can't be reached or rejects the credentials, printing why.
+	CheckCommand []string
+
 	// ConnectCommand for kbox db connect
 	ConnectCommand []string
 
 	// CommandArgs are arguments to pass to the container command
 	// Used for databases that need password arguments (e.g., redis --requirepass)
 	CommandArgs []string
+
+	// ConfigArgs are the server arguments for one dependencies[].config
+	// setting, with {{.Key}} and {{.Value}} placeholders (e.g. "-c",
+	// "{{.Key}}={{.Value}}" for postgres). Without them the dependency
+	// takes no config.
+	ConfigArgs []string
+
+	// DumpCommand writes the data to stdout, for kbox preview create --clone-data-from
+	DumpCommand []string
+
+	// RestoreCommand loads DumpCommand's output from stdin
+	RestoreCommand []string
+
+	// ReloadCommand makes the server pick up restored data, for servers that
+	// only read it on startup. It may end the container, so errors are ignored.
+	ReloadCommand []string
 }
 
 // Registry maps dependency types to their templates
 var Registry = map[string]Template{
 	"postgres": {
 		Image:          "postgres",
-		DefaultVersion: "15-alpine",
 		DefaultPort:    5432,
 		DefaultStorage: "1Gi",
+		DataPath:       "/var/lib/postgresql/data",
+		RunAsUser:      70,
+		WritableRoot:   true,
 		EnvVars: map[string]string{
-			"DATABASE_URL": "postgres://postgres:{{.Password}}@{{.Service}}:5432/postgres",
+			"DATABASE_URL": "postgres://postgres:{{.Password}}@{{.Service}}:{{.Port}}/postgres",
 			"PGHOST":       "{{.Service}}",
-			"PGPORT":       "5432",
+			"PGPORT":       "{{.Port}}",
 			"PGUSER":       "postgres",
 			"PGPASSWORD":   "{{.Password}}",
 			"PGDATABASE":   "postgres",
 		},
 		SecretKeys:     []string{"POSTGRES_PASSWORD"},
 		HealthCheck:    []string{"pg_isready", "-U", "postgres"},
+		CheckCommand:   []string{"sh", "-c", `pg_isready -t 5 && PGCONNECT_TIMEOUT=5 psql -X -tA -c 'SELECT 1'`},
 		ConnectCommand: []string{"psql", "-U", "postgres"},
+		DumpCommand:    []string{"pg_dump", "-U", "postgres", "--clean", "--if-exists", "--no-owner", "--no-privileges", "postgres"},
+		RestoreCommand: []string{"psql", "-U", "postgres", "-q", "-v", "ON_ERROR_STOP=1", "-o", "/dev/null", "postgres"},
+		// The image's entrypoint runs postgres with arguments starting with "-"
+		ConfigArgs: []string{"-c", "{{.Key}}={{.Value}}"},
 	},
 	"redis": {
 		Image:          "redis",
-		DefaultVersion: "7-alpine",
 		DefaultPort:    6379,
 		DefaultStorage: "1Gi",
+		DataPath:       "/data",
+		RunAsUser:      999,
+		WritableRoot:   true,
 		EnvVars: map[string]string{
-			"REDIS_URL":      "redis://:{{.Password}}@{{.Service}}:6379",
+			"REDIS_URL":      "redis://:{{.Password}}@{{.Service}}:{{.Port}}",
 			"REDIS_HOST":     "{{.Service}}",
-			"REDIS_PORT":     "6379",
+			"REDIS_PORT":     "{{.Port}}",
 			"REDIS_PASSWORD": "{{.Password}}",
 		},
 		SecretKeys:     []string{"REDIS_PASSWORD"},
 		HealthCheck:    []string{"redis-cli", "-a", "$(REDIS_PASSWORD)", "ping"},
+		// redis-cli exits 0 on error replies such as WRONGPASS
+		CheckCommand:   []string{"sh", "-c", `reply=$(redis-cli -u "$REDIS_URL" --no-auth-warning ping 2>&1); echo "$reply"; [ "$reply" = PONG ]`},
 		ConnectCommand: []string{"redis-cli", "-a", "$(REDIS_PASSWORD)"},
 		CommandArgs:    []string{"redis-server", "--requirepass", "$(REDIS_PASSWORD)"},
+		ConfigArgs:     []string{"--{{.Key}}", "{{.Value}}"},
+		// Redis reads dump.rdb from its data dir on startup, so the restore
+		// swaps the file in and shuts down without saving over it
+		DumpCommand:    []string{"sh", "-c", `redis-cli -a "$REDIS_PASSWORD" --no-auth-warning --rdb /data/kbox-clone.rdb >/dev/null && cat /data/kbox-clone.rdb && rm -f /data/kbox-clone.rdb`},
+		RestoreCommand: []string{"sh", "-c", `redis-cli -a "$REDIS_PASSWORD" --no-auth-warning config set save "" >/dev/null && cat >/data/kbox-clone.rdb && mv /data/kbox-clone.rdb /data/dump.rdb`},
+		ReloadCommand:  []string{"sh", "-c", `redis-cli -a "$REDIS_PASSWORD" --no-auth-warning shutdown nosave`},
 	},
 	"mongodb": {
 		Image:          "mongo",
-		DefaultVersion: "6",
 		DefaultPort:    27017,
 		DefaultStorage: "1Gi",
+		DataPath:       "/data/db",
+		RunAsUser:      999,
+		WritableRoot:   true,
 		EnvVars: map[string]string{
-			"MONGODB_URL":      "mongodb://root:{{.Password}}@{{.Service}}:27017",
+			"MONGODB_URL":      "mongodb://root:{{.Password}}@{{.Service}}:{{.Port}}",
 			"MONGODB_HOST":     "{{.Service}}",
-			"MONGODB_PORT":     "27017",
+			"MONGODB_PORT":     "{{.Port}}",
 			"MONGODB_USER":     "root",
 			"MONGODB_PASSWORD": "{{.Password}}",
 		},
 		SecretKeys:     []string{"MONGO_INITDB_ROOT_PASSWORD"},
 		HealthCheck:    []string{"mongosh", "-u", "root", "-p", "$(MONGO_INITDB_ROOT_PASSWORD)", "--eval", "db.adminCommand('ping')"},
+		CheckCommand:   []string{"sh", "-c", `mongosh "$MONGODB_URL" --quiet --eval 'db.adminCommand({ping: 1}).ok'`},
 		ConnectCommand: []string{"mongosh", "-u", "root", "-p", "$(MONGO_INITDB_ROOT_PASSWORD)"},
+		DumpCommand:    []string{"sh", "-c", `mongodump --quiet --archive -u root -p "$MONGO_INITDB_ROOT_PASSWORD" --authenticationDatabase admin`},
+		RestoreCommand: []string{"sh", "-c", `mongorestore --quiet --archive --drop --nsExclude 'admin.*' --nsExclude 'config.*' -u root -p "$MONGO_INITDB_ROOT_PASSWORD" --authenticationDatabase admin`},
+		ConfigArgs:     []string{"--{{.Key}}={{.Value}}"},
 	},
 	"mysql": {
 		Image:          "mysql",
-		DefaultVersion: "8",
 		DefaultPort:    3306,
 		DefaultStorage: "1Gi",
+		DataPath:       "/var/lib/mysql",
+		RunAsUser:      999,
+		WritableRoot:   true,
 		EnvVars: map[string]string{
-			"DATABASE_URL":  "mysql://root:{{.Password}}@{{.Service}}:3306/mysql",
+			"DATABASE_URL":  "mysql://root:{{.Password}}@{{.Service}}:{{.Port}}/mysql",
 			"MYSQL_HOST":    "{{.Service}}",
-			"MYSQL_PORT":    "3306",
+			"MYSQL_PORT":    "{{.Port}}",
 			"MYSQL_USER":    "root",
 			"MYSQL_PASSWORD": "{{.Password}}",
 		},
 		SecretKeys:     []string{"MYSQL_ROOT_PASSWORD"},
 		HealthCheck:    []string{"mysqladmin", "ping", "-h", "localhost"},
+		// Not mysqladmin ping, which succeeds with the wrong password
+		CheckCommand:   []string{"sh", "-c", `mysql -h "$MYSQL_HOST" -P "$MYSQL_PORT" -u "$MYSQL_USER" -p"$MYSQL_PASSWORD" --connect-timeout=5 -e 'SELECT 1'`},
 		ConnectCommand: []string{"mysql", "-u", "root", "-p"},
+		// System databases are left out: they hold the target's own root password
+		DumpCommand:    []string{"sh", "-c", `mysqldump -uroot -p"$MYSQL_ROOT_PASSWORD" --single-transaction --routines --databases $(mysql -uroot -p"$MYSQL_ROOT_PASSWORD" -N -e 'SHOW DATABASES' | grep -Ev '^(mysql|sys|information_schema|performance_schema)$')`},
+		RestoreCommand: []string{"sh", "-c", `mysql -uroot -p"$MYSQL_ROOT_PASSWORD"`},
+		ConfigArgs:     []string{"--{{.Key}}={{.Value}}"},
 	},
 }
 
@@ -115,13 +183,9 @@ func Get(depType string) (Template, bool) {
 	return t, ok
 }
 
-// SupportedTypes returns all supported dependency types
+// SupportedTypes returns all supported dependency types, sorted
 func SupportedTypes() []string {
-	types := make([]string, 0, len(Registry))
-	for t := range Registry {
-		types = append(types, t)
-	}
-	return types
+	return slices.Sorted(maps.Keys(Registry))
 }
 
 // IsSupported checks if a dependency type is supported
@@ -139,16 +203,30 @@ func GeneratePassword() string {
 
 // RenderEnvVars renders environment variable values with placeholders replaced
 func RenderEnvVars(template Template, serviceName, password string) map[string]string {
+	return renderEnvVars(template, serviceName, int(template.DefaultPort), password)
+}
+
+// RenderLocalEnvVars renders environment variables pointing at a local port-forward
+// of the dependency, for apps running outside the cluster
+func RenderLocalEnvVars(template Template, localPort int, password string) map[string]string {
+	return renderEnvVars(template, "localhost", localPort, password)
+}
+
+func renderEnvVars(template Template, host string, port int, password string) map[string]string {
 	result := make(map[string]string)
 	for k, v := range template.EnvVars {
-		rendered := v
-		rendered = strings.ReplaceAll(rendered, "{{.Service}}", serviceName)
-		rendered = strings.ReplaceAll(rendered, "{{.Password}}", password)
-		result[k] = rendered
+		result[k] = renderValue(v, host, port, password)
 	}
 	return result
 }
 
+// renderValue replaces the placeholders in a single env var value
+func renderValue(value, host string, port int, password string) string {
+	value = strings.ReplaceAll(value, "{{.Service}}", host)
+	value = strings.ReplaceAll(value, "{{.Port}}", strconv.Itoa(port))
+	return strings.ReplaceAll(value, "{{.Password}}", password)
+}
+
 // EnvVarSecretInfo holds information about how an env var should reference a secret
 type EnvVarSecretInfo struct {
 	SecretName string
@@ -163,13 +241,12 @@ func RenderEnvVarsWithSecretRefs(template Template, serviceName, secretName, pas
 	secretEnvVars = make(map[string]EnvVarSecretInfo)
 	secretData = make(map[string]string)
 
+	port := int(template.DefaultPort)
 	for k, v := range template.EnvVars {
 		if strings.Contains(v, "{{.Password}}") {
 			// This env var contains a password - store the rendered value in the secret
 			// and reference it with secretKeyRef
-			rendered := v
-			rendered = strings.ReplaceAll(rendered, "{{.Service}}", serviceName)
-			rendered = strings.ReplaceAll(rendered, "{{.Password}}", password)
+			rendered := renderValue(v, serviceName, port, password)
 
 			// Store in secret data with the env var name as the key
 			secretData[k] = rendered
@@ -181,13 +258,39 @@ func RenderEnvVarsWithSecretRefs(template Template, serviceName, secretName, pas
 			}
 		} else {
 			// No password - render as plaintext
-			rendered := strings.ReplaceAll(v, "{{.Service}}", serviceName)
-			plainEnvVars[k] = rendered
+			plainEnvVars[k] = renderValue(v, serviceName, port, "")
 		}
 	}
 	return plainEnvVars, secretEnvVars, secretData
 }
 
+// RenderExternalEnvVars splits env vars for a server outside the cluster.
+// Those naming only its host and port are rendered when host is set; the
+// rest (users, passwords, URLs) reference the secretName Secret's keys of
+// the same name. Without a Secret, env vars with passwords are left out and
+// fixed values such as PGUSER keep the template's.
+func RenderExternalEnvVars(template Template, host string, port int, secretName string) (plainEnvVars map[string]string, secretEnvVars map[string]EnvVarSecretInfo) {
+	plainEnvVars = make(map[string]string)
+	secretEnvVars = make(map[string]EnvVarSecretInfo)
+	if port == 0 {
+		port = int(template.DefaultPort)
+	}
+
+	for k, v := range template.EnvVars {
+		hasPassword := strings.Contains(v, "{{.Password}}")
+		hasAddress := strings.Contains(v, "{{.Service}}") || strings.Contains(v, "{{.Port}}")
+		switch {
+		case host != "" && hasAddress && !hasPassword:
+			plainEnvVars[k] = renderValue(v, host, port, "")
+		case secretName != "":
+			secretEnvVars[k] = EnvVarSecretInfo{SecretName: secretName, SecretKey: k}
+		case !hasPassword && !hasAddress:
+			plainEnvVars[k] = v
+		}
+	}
+	return plainEnvVars, secretEnvVars
+}
+
 // ImageWithVersion returns the full image reference
 func ImageWithVersion(template Template, version string) string {
 	if version == "" {
@@ -0,0 +1,27 @@
+package dependencies
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ConfigArgs renders a dependency's config settings as server arguments,
+// by key so they come out the same on every render
+func ConfigArgs(depType string, template Template, config map[string]string) ([]string, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+	if len(template.ConfigArgs) == 0 {
+		return nil, fmt.Errorf("%s doesn't take config settings\n  → Set them through env instead, if its image reads any", depType)
+	}
+	var args []string
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		r := strings.NewReplacer("{{.Key}}", key, "{{.Value}}", config[key])
+		for _, arg := range template.ConfigArgs {
+			args = append(args, r.Replace(arg))
+		}
+	}
+	return args, nil
+}
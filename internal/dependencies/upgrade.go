@@ -0,0 +1,116 @@
+package dependencies
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// inPlaceMajors is how many major versions each server can move up at once
+// on its existing data. 0 means any major upgrade needs a dump and restore;
+// -1 means any upgrade works in place.
+var inPlaceMajors = map[string]int{
+	// Each major has its own data directory format (pg_upgrade aside)
+	"postgres": 0,
+	// In-place upgrades go one major (or LTS series) at a time
+	"mysql": 1,
+	// Each major starts only on the previous one's featureCompatibilityVersion
+	"mongodb": 1,
+	// Newer servers load older RDB and AOF files
+	"redis": -1,
+}
+
+// Upgrade is what moving a dependency from one version to another means for
+// its data
+type Upgrade struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Major is set when the major version changes
+	Major bool `json:"major"`
+	// InPlace is set when the new server can start on the old one's data.
+	// When it can't, the data has to be dumped and restored into a fresh
+	// volume.
+	InPlace bool `json:"inPlace"`
+	// Notes explain why it can't, or what to check when it can
+	Notes []string `json:"notes,omitempty"`
+}
+
+// UpgradeVersion returns the version to move a dependency to: to, with the
+// current version's variant kept (e.g. "16" from "15-alpine" is
+// "16-alpine"), as switching base images can change collation and locales
+func UpgradeVersion(template Template, current, to string) string {
+	if current == "" {
+		current = template.DefaultVersion
+	}
+	if _, variant, ok := strings.Cut(current, "-"); ok && !strings.Contains(to, "-") {
+		return to + "-" + variant
+	}
+	return to
+}
+
+// CheckUpgrade checks moving depType from version from to version to
+// (after UpgradeVersion). Versions that don't start with a number, such as
+// "latest", can't be checked.
+func CheckUpgrade(depType, from, to string) (Upgrade, error) {
+	template, ok := Get(depType)
+	if !ok {
+		return Upgrade{}, fmt.Errorf("unsupported dependency: %s", depType)
+	}
+	if from == "" {
+		from = template.DefaultVersion
+	}
+	u := Upgrade{From: from, To: to, InPlace: true}
+
+	fromMajor, fromMinor, err := parseVersion(from)
+	if err != nil {
+		return u, err
+	}
+	toMajor, toMinor, err := parseVersion(to)
+	if err != nil {
+		return u, err
+	}
+	u.Major = toMajor != fromMajor
+
+	switch {
+	case toMajor < fromMajor || (toMajor == fromMajor && toMinor < fromMinor):
+		u.InPlace = false
+		u.Notes = append(u.Notes, fmt.Sprintf("%s %s can't read data written by %s %s: downgrades need a dump and restore", depType, to, depType, from))
+	case !u.Major:
+	case inPlaceMajors[depType] == 0:
+		u.InPlace = false
+		u.Notes = append(u.Notes, fmt.Sprintf("%s %d can't start on a %s %d data directory: major upgrades need a dump and restore", depType, toMajor, depType, fromMajor))
+	case inPlaceMajors[depType] > 0 && toMajor-fromMajor > inPlaceMajors[depType]:
+		u.InPlace = false
+		u.Notes = append(u.Notes, fmt.Sprintf("%s upgrades in place one major version at a time: go through each version from %d to %d, or dump and restore", depType, fromMajor, toMajor))
+	}
+
+	if u.InPlace && u.Major {
+		switch depType {
+		case "mongodb":
+			u.Notes = append(u.Notes, fmt.Sprintf("Set featureCompatibilityVersion to \"%d.0\" first: db.adminCommand({setFeatureCompatibilityVersion: \"%d.0\"})", fromMajor, fromMajor))
+		case "mysql":
+			u.Notes = append(u.Notes, "The server upgrades its data dictionary on first start, and can't be downgraded after")
+		case "redis":
+			u.Notes = append(u.Notes, "Once the new server saves, older servers can't load its data")
+		}
+	}
+	return u, nil
+}
+
+// parseVersion reads the major and minor numbers of a version such as
+// "15-alpine", "8.4" or "7.0.12"
+func parseVersion(version string) (int, int, error) {
+	numbers, _, _ := strings.Cut(version, "-")
+	parts := strings.Split(numbers, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't compare version %q", version)
+	}
+	minor := 0
+	if len(parts) > 1 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("can't compare version %q", version)
+		}
+	}
+	return major, minor, nil
+}
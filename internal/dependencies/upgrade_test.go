@@ -0,0 +1,59 @@
+package dependencies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpgradeVersion(t *testing.T) {
+	postgres, _ := Get("postgres")
+	tests := []struct {
+		current, to, want string
+	}{
+		{"", "16", "16-alpine"},
+		{"15-alpine", "16", "16-alpine"},
+		{"15-bookworm", "16", "16-bookworm"},
+		{"15-alpine", "16-bookworm", "16-bookworm"},
+		{"15", "16", "16"},
+	}
+	for _, tt := range tests {
+		if got := UpgradeVersion(postgres, tt.current, tt.to); got != tt.want {
+			t.Errorf("UpgradeVersion(%q, %q) = %q, want %q", tt.current, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCheckUpgrade(t *testing.T) {
+	tests := []struct {
+		depType, from, to string
+		major, inPlace    bool
+		note              string
+	}{
+		{"postgres", "15-alpine", "15.6-alpine", false, true, ""},
+		{"postgres", "15-alpine", "16-alpine", true, false, "need a dump and restore"},
+		{"postgres", "16", "15", true, false, "downgrades"},
+		{"mysql", "8", "8.4", false, true, ""},
+		{"mysql", "8.4", "8.0", false, false, "downgrades"},
+		{"mysql", "8", "9", true, true, "data dictionary"},
+		{"mongodb", "6", "7", true, true, "featureCompatibilityVersion"},
+		{"mongodb", "5", "7", true, false, "one major version at a time"},
+		{"redis", "6-alpine", "7-alpine", true, true, "older servers"},
+	}
+	for _, tt := range tests {
+		u, err := CheckUpgrade(tt.depType, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("CheckUpgrade(%s, %s, %s): %v", tt.depType, tt.from, tt.to, err)
+		}
+		if u.Major != tt.major || u.InPlace != tt.inPlace {
+			t.Errorf("%s %s → %s: major=%v inPlace=%v, want %v %v", tt.depType, tt.from, tt.to, u.Major, u.InPlace, tt.major, tt.inPlace)
+		}
+		notes := strings.Join(u.Notes, "\n")
+		if (tt.note == "") != (notes == "") || !strings.Contains(notes, tt.note) {
+			t.Errorf("%s %s → %s: notes %q, want %q", tt.depType, tt.from, tt.to, notes, tt.note)
+		}
+	}
+
+	if _, err := CheckUpgrade("postgres", "latest", "16"); err == nil {
+		t.Error("expected an error comparing \"latest\"")
+	}
+}
@@ -0,0 +1,193 @@
+package dependencies
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// VersionsFile pins a project's dependency default versions, relative to
+// kbox.yaml. It has the layout of the embedded manifest and overrides it.
+const VersionsFile = ".kbox/versions.yaml"
+
+//go:embed versions.yaml
+var embeddedVersions []byte
+
+// Versions is a versions manifest: the default version of each dependency
+// type, and what's known about its release series
+type Versions struct {
+	// Defaults maps dependency types to the version used when kbox.yaml
+	// doesn't set one
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	// Releases maps dependency types to their release series
+	Releases map[string][]Release `json:"releases,omitempty"`
+}
+
+// Release is a release series of a dependency, such as postgres 15 or
+// mysql 8.0
+type Release struct {
+	// Series is the version prefix the series' tags start with
+	Series string `json:"series"`
+
+	// EOL is the date (YYYY-MM-DD) the series stops getting fixes
+	EOL string `json:"eol,omitempty"`
+
+	// Advisories are known vulnerabilities in the series
+	Advisories []Advisory `json:"advisories,omitempty"`
+}
+
+// Advisory is a known vulnerability, fixed from a version of its series on
+type Advisory struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Fixed    string `json:"fixed"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+// releases are the known release series, by dependency type
+var releases = map[string][]Release{}
+
+func init() {
+	var v Versions
+	if err := yaml.Unmarshal(embeddedVersions, &v); err != nil {
+		panic(fmt.Sprintf("invalid embedded versions manifest: %v", err))
+	}
+	if err := ApplyVersions(v); err != nil {
+		panic(fmt.Sprintf("invalid embedded versions manifest: %v", err))
+	}
+}
+
+// ApplyVersions sets the default versions in v and merges its release
+// series into the known ones, replacing series of the same name
+func ApplyVersions(v Versions) error {
+	// Check everything first so a bad entry leaves the registry as it was
+	for depType, version := range v.Defaults {
+		if _, ok := Registry[depType]; !ok {
+			return fmt.Errorf("unknown dependency %q in defaults", depType)
+		}
+		if version == "" {
+			return fmt.Errorf("empty default version for %s", depType)
+		}
+	}
+	for depType, series := range v.Releases {
+		for _, r := range series {
+			if r.Series == "" {
+				return fmt.Errorf("%s: release without a series", depType)
+			}
+			if r.EOL != "" {
+				if _, err := time.Parse(time.DateOnly, r.EOL); err != nil {
+					return fmt.Errorf("%s %s: invalid eol %q (expected YYYY-MM-DD)", depType, r.Series, r.EOL)
+				}
+			}
+		}
+	}
+
+	for depType, version := range v.Defaults {
+		template := Registry[depType]
+		template.DefaultVersion = version
+		Registry[depType] = template
+	}
+	for depType, series := range v.Releases {
+		for _, r := range series {
+			i := slices.IndexFunc(releases[depType], func(known Release) bool { return known.Series == r.Series })
+			if i >= 0 {
+				releases[depType][i] = r
+			} else {
+				releases[depType] = append(releases[depType], r)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadVersions applies a project's versions file. A missing file changes
+// nothing.
+func LoadVersions(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var v Versions
+	if err := yaml.UnmarshalStrict(data, &v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := ApplyVersions(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// VersionNotice is a reason not to run a dependency version
+type VersionNotice struct {
+	// Kind is "eol" or "advisory"
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// CheckVersion returns what's known against running version of depType
+// (its default when "") at now: its series' end of life, and critical
+// advisories it isn't fixed for. Floating tags such as "7-alpine" get fixes
+// as they're published, so only versions as precise as the fix are checked
+// against advisories.
+func CheckVersion(depType, version string, now time.Time) []VersionNotice {
+	if version == "" {
+		if template, ok := Get(depType); ok {
+			version = template.DefaultVersion
+		}
+	}
+	parts := versionParts(version)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	var notices []VersionNotice
+	for _, r := range releases[depType] {
+		series := versionParts(r.Series)
+		if len(series) == 0 || len(parts) < len(series) || !slices.Equal(parts[:len(series)], series) {
+			continue
+		}
+		if eol, err := time.Parse(time.DateOnly, r.EOL); err == nil && !now.Before(eol) {
+			notices = append(notices, VersionNotice{
+				Kind:    "eol",
+				Message: fmt.Sprintf("%s %s reached end of life on %s", depType, r.Series, r.EOL),
+			})
+		}
+		for _, a := range r.Advisories {
+			fixed := versionParts(a.Fixed)
+			if a.Severity != "critical" || len(parts) < len(fixed) || slices.Compare(parts[:len(fixed)], fixed) >= 0 {
+				continue
+			}
+			message := fmt.Sprintf("%s %s has critical advisory %s, fixed in %s", depType, version, a.ID, a.Fixed)
+			if a.Summary != "" {
+				message += ": " + a.Summary
+			}
+			notices = append(notices, VersionNotice{Kind: "advisory", Message: message})
+		}
+	}
+	return notices
+}
+
+// versionParts returns the numbers a version starts with: 15 and 4 for
+// "15.4-alpine"; none for tags such as "latest"
+func versionParts(version string) []int {
+	version, _, _ = strings.Cut(version, "-")
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
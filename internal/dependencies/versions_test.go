@@ -0,0 +1,129 @@
+package dependencies
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedVersions(t *testing.T) {
+	for _, depType := range builtins {
+		if Registry[depType].DefaultVersion == "" {
+			t.Errorf("%s has no default version in versions.yaml", depType)
+		}
+	}
+}
+
+// TestDefaultsSupported fails once a default version reaches end of life
+// or has a critical advisory, so the manifest is updated before kbox ships it
+func TestDefaultsSupported(t *testing.T) {
+	for _, depType := range builtins {
+		for _, notice := range CheckVersion(depType, "", time.Now()) {
+			t.Errorf("default %s %s: %s", depType, Registry[depType].DefaultVersion, notice.Message)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		depType string
+		version string
+		want    []string // notice kinds
+	}{
+		{"postgres", "12-alpine", []string{"eol"}},
+		{"postgres", "16.4", nil},
+		{"mysql", "8.0.39", []string{"eol"}},
+		{"mysql", "8", nil},               // Floating, now 8.4
+		{"mongodb", "6", []string{"eol"}},
+		{"mongodb", "8", nil}, // The default
+		{"redis", "7.2.10", []string{"advisory"}},
+		{"redis", "7.2.11", nil},
+		{"redis", "7.2", nil}, // Floating: gets the fix
+		{"redis", "7-alpine", nil},
+		{"redis", "latest", nil},
+		{"unknown", "1.0", nil},
+	}
+	for _, tt := range tests {
+		var kinds []string
+		for _, n := range CheckVersion(tt.depType, tt.version, now) {
+			kinds = append(kinds, n.Kind)
+		}
+		if strings.Join(kinds, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("CheckVersion(%s, %q) = %v, want %v", tt.depType, tt.version, kinds, tt.want)
+		}
+	}
+
+	// Before the end of life date there's nothing to say
+	if notices := CheckVersion("postgres", "14", now); len(notices) != 0 {
+		t.Errorf("postgres 14 isn't EOL until 2026-11-12, got %v", notices)
+	}
+	if notices := CheckVersion("postgres", "14", now.AddDate(0, 1, 0)); len(notices) != 1 {
+		t.Errorf("postgres 14 should be EOL a month later, got %v", notices)
+	}
+}
+
+func TestLoadVersions(t *testing.T) {
+	defer restoreVersions(t)()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "versions.yaml")
+	if err := LoadVersions(path); err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+
+	os.WriteFile(path, []byte(`defaults:
+  postgres: 16.4-alpine
+releases:
+  postgres:
+    - series: "16"
+      advisories:
+        - id: CVE-0000-0001
+          severity: critical
+          fixed: "16.5"
+`), 0644)
+	if err := LoadVersions(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := Registry["postgres"].DefaultVersion; got != "16.4-alpine" {
+		t.Errorf("DefaultVersion = %q, want the pinned 16.4-alpine", got)
+	}
+	notices := CheckVersion("postgres", "", time.Now())
+	if len(notices) != 1 || !strings.Contains(notices[0].Message, "CVE-0000-0001") {
+		t.Errorf("expected the project's advisory, got %v", notices)
+	}
+
+	for _, bad := range []string{"defaults:\n  nosuchdb: \"1\"\n", "unknownKey: true\n", "releases:\n  redis:\n    - series: \"7\"\n      eol: soon\n"} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if err := LoadVersions(path); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+
+	// A bad entry leaves the rest of the file unapplied
+	os.WriteFile(path, []byte("defaults:\n  redis: \"8\"\n  nosuchdb: \"1\"\n"), 0644)
+	if err := LoadVersions(path); err == nil {
+		t.Fatal("expected an error for nosuchdb")
+	}
+	if got := Registry["redis"].DefaultVersion; got == "8" {
+		t.Error("redis default applied from a file that failed to load")
+	}
+}
+
+// restoreVersions snapshots the registry defaults and release series, and
+// returns a func restoring them
+func restoreVersions(t *testing.T) func() {
+	t.Helper()
+	registry := maps.Clone(Registry)
+	known := map[string][]Release{}
+	for depType, series := range releases {
+		known[depType] = append([]Release(nil), series...)
+	}
+	return func() {
+		Registry = registry
+		releases = known
+	}
+}
@@ -0,0 +1,463 @@
+// Package diagnose explains why an app is unhealthy.
+//
+// It inspects pod container statuses and recent events, matches them against
+// known failure patterns, and returns ranked findings with suggested kbox.yaml fixes.
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+)
+
+// Finding scores: higher means more likely to be the root cause
+const (
+	ScoreImage       = 100
+	ScoreConfigRef   = 95
+	ScoreOOM         = 90
+	ScoreCommand     = 90
+	ScoreQuota       = 85
+	ScoreReadOnlyFS  = 85
+	ScoreProbe       = 80
+	ScoreScheduling  = 75
+	ScoreCrash       = 60
+	ScorePendingPods = 20
+)
+
+// Finding is a single probable cause with an explanation and fixes
+type Finding struct {
+	Score       int      `json:"score"`
+	Title       string   `json:"title"`
+	Explanation string   `json:"explanation"`
+	Fixes       []string `json:"fixes,omitempty"`
+	Objects     []string `json:"objects,omitempty"`
+}
+
+// Report is the result of a diagnosis
+type Report struct {
+	App      string    `json:"app"`
+	Findings []Finding `json:"findings"`
+}
+
+// Healthy returns true when no problems were found
+func (r *Report) Healthy() bool {
+	return len(r.Findings) == 0
+}
+
+// Snapshot is the cluster state the analyzer works on
+type Snapshot struct {
+	AppName string
+	Pods    []corev1.Pod
+	Events  []debug.EventInfo
+	// Config is the app's kbox.yaml, if available; used to tailor fixes
+	Config *config.AppConfig
+}
+
+// Diagnose gathers pod and event state for an app and analyzes it
+func Diagnose(ctx context.Context, client kubernetes.Interface, namespace, appName string, cfg *config.AppConfig) (*Report, error) {
+	snap, err := Gather(ctx, client, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	snap.Config = cfg
+	return Analyze(snap), nil
+}
+
+// Gather collects the app's pods and recent events
+func Gather(ctx context.Context, client kubernetes.Interface, namespace, appName string) (*Snapshot, error) {
+	snap := &Snapshot{AppName: appName}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", appName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	snap.Pods = pods.Items
+
+	events, err := debug.ListAppEvents(ctx, client, namespace, appName, debug.EventsOptions{Since: time.Hour})
+	if err != nil {
+		return nil, err
+	}
+	snap.Events = events
+
+	return snap, nil
+}
+
+// Analyze matches the snapshot against known failure patterns and ranks findings
+func Analyze(snap *Snapshot) *Report {
+	a := &analyzer{snap: snap, byTitle: make(map[string]*Finding)}
+
+	for i := range snap.Pods {
+		a.checkPod(&snap.Pods[i])
+	}
+	for _, e := range snap.Events {
+		a.checkEvent(e)
+	}
+
+	report := &Report{App: snap.AppName}
+	for _, title := range a.order {
+		report.Findings = append(report.Findings, *a.byTitle[title])
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Score > report.Findings[j].Score
+	})
+	return report
+}
+
+// analyzer accumulates findings, merging repeats of the same problem across pods
+type analyzer struct {
+	snap    *Snapshot
+	byTitle map[string]*Finding
+	order   []string
+}
+
+func (a *analyzer) add(f Finding, object string) {
+	if existing, ok := a.byTitle[f.Title]; ok {
+		if object != "" && !contains(existing.Objects, object) {
+			existing.Objects = append(existing.Objects, object)
+		}
+		return
+	}
+	if object != "" {
+		f.Objects = []string{object}
+	}
+	a.byTitle[f.Title] = &f
+	a.order = append(a.order, f.Title)
+}
+
+func (a *analyzer) spec() *config.AppSpec {
+	if a.snap.Config == nil {
+		return nil
+	}
+	return &a.snap.Config.Spec
+}
+
+func (a *analyzer) checkPod(pod *corev1.Pod) {
+	object := "Pod/" + pod.Name
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil {
+			switch w.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+				a.add(imageFinding(cs.Image, w.Message), object)
+			case "CreateContainerConfigError":
+				a.add(configRefFinding(w.Message), object)
+			case "CrashLoopBackOff":
+				a.checkTermination(cs, object)
+			}
+		}
+		if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+			a.checkTermination(cs, object)
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending && len(statuses) == 0 {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Message != "" {
+				a.add(schedulingFinding(c.Message), object)
+				return
+			}
+		}
+		a.add(Finding{
+			Score:       ScorePendingPods,
+			Title:       "Pods are pending",
+			Explanation: "Pods have been created but no containers are running yet.",
+			Fixes:       []string{"Run 'kbox events' to see what the scheduler is waiting on"},
+		}, object)
+	}
+}
+
+// checkTermination explains the last termination of a crashing container
+func (a *analyzer) checkTermination(cs corev1.ContainerStatus, object string) {
+	t := cs.LastTerminationState.Terminated
+	if cs.State.Terminated != nil {
+		t = cs.State.Terminated
+	}
+	if t == nil {
+		a.add(crashFinding(cs.Name, 0, ""), object)
+		return
+	}
+
+	switch {
+	case t.Reason == "OOMKilled":
+		a.add(a.oomFinding(cs.Name), object)
+	case strings.Contains(strings.ToLower(t.Message), "read-only file system"):
+		a.add(readOnlyFSFinding(), object)
+	case t.ExitCode == 126 || t.ExitCode == 127:
+		a.add(a.commandFinding(cs.Name, t.ExitCode), object)
+	default:
+		a.add(crashFinding(cs.Name, t.ExitCode, t.Message), object)
+	}
+}
+
+func (a *analyzer) checkEvent(e debug.EventInfo) {
+	object := e.Kind + "/" + e.Object
+	msg := e.Message
+	lower := strings.ToLower(msg)
+
+	switch e.Reason {
+	case "Unhealthy":
+		if f, ok := a.probeFinding(msg); ok {
+			a.add(f, object)
+		}
+	case "FailedCreate":
+		if strings.Contains(lower, "exceeded quota") || strings.Contains(lower, "must specify") {
+			a.add(quotaFinding(msg), object)
+		}
+	case "FailedScheduling":
+		a.add(schedulingFinding(msg), object)
+	case "Failed":
+		if strings.Contains(lower, "read-only file system") {
+			a.add(readOnlyFSFinding(), object)
+		}
+		if strings.Contains(lower, "pull") && strings.Contains(lower, "image") {
+			a.add(imageFinding("", msg), object)
+		}
+	}
+}
+
+func imageFinding(image, message string) Finding {
+	f := Finding{
+		Score:       ScoreImage,
+		Title:       "Image cannot be pulled",
+		Explanation: "The container image could not be pulled by the node.",
+	}
+	if image != "" {
+		f.Explanation = fmt.Sprintf("The image %q could not be pulled by the node.", image)
+	}
+
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "not found") || strings.Contains(lower, "manifest unknown"):
+		f.Fixes = append(f.Fixes, "Check that the tag exists in the registry and spec.image is spelled correctly")
+	case strings.Contains(lower, "unauthorized") || strings.Contains(lower, "denied") || strings.Contains(lower, "authentication"):
+		f.Fixes = append(f.Fixes, "The registry requires credentials: create an image pull secret in the namespace")
+	default:
+		f.Fixes = append(f.Fixes, "Check spec.image and that the cluster can reach the registry")
+	}
+	f.Fixes = append(f.Fixes, "For local clusters (kind/minikube), use 'kbox up' to build and load the image")
+	if message != "" {
+		f.Explanation += " " + firstLine(message)
+	}
+	return f
+}
+
+func configRefFinding(message string) Finding {
+	f := Finding{
+		Score:       ScoreConfigRef,
+		Title:       "Container references missing configuration",
+		Explanation: "The container cannot start because a referenced ConfigMap or Secret is missing. " + firstLine(message),
+	}
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "secret") {
+		f.Fixes = append(f.Fixes, "Check spec.secrets in kbox.yaml (fromEnvFile/fromSops) and redeploy so the Secret is created")
+	} else {
+		f.Fixes = append(f.Fixes, "Check volumes[].configMap references in kbox.yaml")
+	}
+	return f
+}
+
+func (a *analyzer) oomFinding(container string) Finding {
+	f := Finding{
+		Score:       ScoreOOM,
+		Title:       "Container killed for exceeding its memory limit",
+		Explanation: fmt.Sprintf("Container %q was OOMKilled: it used more memory than its limit allows.", container),
+	}
+	limit := "the current limit"
+	if spec := a.spec(); spec != nil && spec.Resources != nil {
+		if spec.Resources.MemoryLimit != "" {
+			limit = spec.Resources.MemoryLimit
+		} else if spec.Resources.Memory != "" {
+			limit = "2x " + spec.Resources.Memory
+		}
+	}
+	f.Fixes = []string{
+		fmt.Sprintf("Raise spec.resources.memoryLimit above %s (e.g. double it)", limit),
+		"Run 'kbox top' to see actual memory usage",
+	}
+	return f
+}
+
+func (a *analyzer) commandFinding(container string, exitCode int32) Finding {
+	f := Finding{
+		Score: ScoreCommand,
+		Title: "Container command not found or not executable",
+		Explanation: fmt.Sprintf("Container %q exited with code %d, which means the entrypoint could not be executed.",
+			container, exitCode),
+	}
+	if spec := a.spec(); spec != nil && len(spec.Command) > 0 {
+		f.Fixes = append(f.Fixes, fmt.Sprintf("spec.command is %q: check the binary exists in the image and is executable", strings.Join(spec.Command, " ")))
+	} else {
+		f.Fixes = append(f.Fixes, "Check the image ENTRYPOINT/CMD, or set spec.command in kbox.yaml")
+	}
+	return f
+}
+
+func readOnlyFSFinding() Finding {
+	return Finding{
+		Score:       ScoreReadOnlyFS,
+		Title:       "App writes to a read-only root filesystem",
+		Explanation: "kbox runs containers with a read-only root filesystem by default, and the app tried to write to it.",
+		Fixes: []string{
+			"Add a writable emptyDir volume where the app writes, e.g.:\n" +
+				"      volumes:\n" +
+				"        - name: tmp\n" +
+				"          mountPath: /tmp\n" +
+				"          emptyDir: true",
+		},
+	}
+}
+
+func crashFinding(container string, exitCode int32, message string) Finding {
+	f := Finding{
+		Score:       ScoreCrash,
+		Title:       "Container is crashing",
+		Explanation: fmt.Sprintf("Container %q keeps exiting", container),
+		Fixes:       []string{"Run 'kbox logs --previous' to see output from the crashed container"},
+	}
+	if exitCode != 0 {
+		f.Explanation += fmt.Sprintf(" with code %d", exitCode)
+	}
+	f.Explanation += "."
+	if message != "" {
+		f.Explanation += " " + firstLine(message)
+	}
+	return f
+}
+
+// probeFinding explains liveness/readiness probe failures using the configured health check and port
+func (a *analyzer) probeFinding(message string) (Finding, bool) {
+	lower := strings.ToLower(message)
+	probe := ""
+	switch {
+	case strings.HasPrefix(lower, "liveness probe failed"):
+		probe = "Liveness"
+	case strings.HasPrefix(lower, "readiness probe failed"):
+		probe = "Readiness"
+	case strings.HasPrefix(lower, "startup probe failed"):
+		probe = "Startup"
+	default:
+		return Finding{}, false
+	}
+
+	path, port := "", 0
+	if spec := a.spec(); spec != nil {
+		path, port = spec.HealthCheck, spec.Port
+	}
+
+	f := Finding{Score: ScoreProbe}
+	switch {
+	case strings.Contains(lower, "statuscode: 404"):
+		f.Title = fmt.Sprintf("%s probe path returns 404", probe)
+		if path != "" {
+			f.Explanation = fmt.Sprintf("The %s probe is hitting %s but the app returns 404 there.", strings.ToLower(probe), path)
+			f.Fixes = []string{fmt.Sprintf("Set spec.healthCheck to the path your app actually serves (currently %s)", path)}
+		} else {
+			f.Explanation = fmt.Sprintf("The %s probe path returns 404.", strings.ToLower(probe))
+			f.Fixes = []string{"Set spec.healthCheck to the path your app actually serves"}
+		}
+	case strings.Contains(lower, "connection refused"):
+		f.Title = fmt.Sprintf("%s probe cannot connect to the app", probe)
+		if port != 0 {
+			f.Explanation = fmt.Sprintf("Nothing is listening on port %d inside the container.", port)
+			f.Fixes = []string{fmt.Sprintf("Make sure spec.port (%d) matches the port the app listens on", port)}
+		} else {
+			f.Explanation = "Nothing is listening on the probed port inside the container."
+			f.Fixes = []string{"Make sure spec.port matches the port the app listens on"}
+		}
+		f.Fixes = append(f.Fixes, "Check the app binds to 0.0.0.0, not 127.0.0.1")
+	case strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded"):
+		f.Title = fmt.Sprintf("%s probe times out", probe)
+		f.Explanation = "The health endpoint did not answer in time; the app may be slow to start or overloaded."
+		f.Fixes = []string{"Make the health endpoint cheap, or raise spec.resources.cpu if the app is CPU-starved"}
+	default:
+		f.Title = fmt.Sprintf("%s probe failing", probe)
+		f.Explanation = firstLine(message)
+		f.Fixes = []string{"Check spec.healthCheck and spec.port in kbox.yaml"}
+	}
+	return f, true
+}
+
+func quotaFinding(message string) Finding {
+	return Finding{
+		Score:       ScoreQuota,
+		Title:       "Namespace resource quota prevents pod creation",
+		Explanation: firstLine(message),
+		Fixes: []string{
+			"Lower spec.replicas or spec.resources to fit the quota",
+			"Ask a cluster admin to raise the ResourceQuota for this namespace",
+		},
+	}
+}
+
+func schedulingFinding(message string) Finding {
+	f := Finding{
+		Score:       ScoreScheduling,
+		Title:       "Pods cannot be scheduled",
+		Explanation: firstLine(message),
+	}
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "insufficient cpu") || strings.Contains(lower, "insufficient memory"):
+		f.Title = "Not enough cluster capacity for the requested resources"
+		f.Fixes = []string{"Lower spec.resources.cpu/memory requests, or add nodes to the cluster"}
+	case strings.Contains(lower, "persistentvolumeclaim"):
+		f.Title = "Pods are waiting on storage"
+		f.Fixes = []string{"Check the cluster has a default StorageClass for volumes[].size and dependency storage"}
+	case strings.Contains(lower, "taint") || strings.Contains(lower, "node selector") || strings.Contains(lower, "affinity"):
+		f.Fixes = []string{"No node matches the pod's placement constraints; check node labels and taints"}
+	default:
+		f.Fixes = []string{"Run 'kbox events --warnings-only' for scheduler details"}
+	}
+	return f
+}
+
+// Print writes a human-readable ranked diagnosis
+func Print(w io.Writer, report *Report) {
+	if report.Healthy() {
+		fmt.Fprintf(w, "No problems found for %s\n", report.App)
+		return
+	}
+
+	fmt.Fprintf(w, "Diagnosis for %s:\n\n", report.App)
+	for i, f := range report.Findings {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, f.Title)
+		fmt.Fprintf(w, "     %s\n", f.Explanation)
+		if len(f.Objects) > 0 {
+			fmt.Fprintf(w, "     Affected: %s\n", strings.Join(f.Objects, ", "))
+		}
+		for _, fix := range f.Fixes {
+			fmt.Fprintf(w, "     → %s\n", fix)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
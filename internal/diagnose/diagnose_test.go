@@ -0,0 +1,155 @@
+package diagnose
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+)
+
+func podWithStatus(name string, cs corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{cs},
+		},
+	}
+}
+
+func TestAnalyze_ImagePull(t *testing.T) {
+	snap := &Snapshot{
+		AppName: "myapp",
+		Pods: []corev1.Pod{
+			podWithStatus("myapp-1", corev1.ContainerStatus{
+				Name:  "myapp",
+				Image: "registry.io/myapp:v9",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: `manifest for registry.io/myapp:v9 not found: manifest unknown`,
+				}},
+			}),
+			podWithStatus("myapp-2", corev1.ContainerStatus{
+				Name:  "myapp",
+				Image: "registry.io/myapp:v9",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+			}),
+		},
+	}
+
+	report := Analyze(snap)
+	if len(report.Findings) != 1 {
+		t.Fatalf("expected findings merged across pods, got %d", len(report.Findings))
+	}
+	f := report.Findings[0]
+	if f.Title != "Image cannot be pulled" {
+		t.Errorf("unexpected title %q", f.Title)
+	}
+	if len(f.Objects) != 2 {
+		t.Errorf("expected both pods listed, got %v", f.Objects)
+	}
+	if !strings.Contains(strings.Join(f.Fixes, "\n"), "tag exists") {
+		t.Errorf("expected tag fix, got %v", f.Fixes)
+	}
+}
+
+func TestAnalyze_OOMUsesConfiguredLimit(t *testing.T) {
+	snap := &Snapshot{
+		AppName: "myapp",
+		Config: &config.AppConfig{Spec: config.AppSpec{
+			Resources: &config.ResourceConfig{Memory: "128Mi", MemoryLimit: "256Mi"},
+		}},
+		Pods: []corev1.Pod{
+			podWithStatus("myapp-1", corev1.ContainerStatus{
+				Name:                 "myapp",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+			}),
+		},
+	}
+
+	report := Analyze(snap)
+	if len(report.Findings) != 1 || report.Findings[0].Score != ScoreOOM {
+		t.Fatalf("expected OOM finding, got %+v", report.Findings)
+	}
+	if !strings.Contains(report.Findings[0].Fixes[0], "256Mi") {
+		t.Errorf("expected fix to mention configured limit, got %v", report.Findings[0].Fixes)
+	}
+}
+
+func TestAnalyze_ProbePathMismatch(t *testing.T) {
+	snap := &Snapshot{
+		AppName: "myapp",
+		Config:  &config.AppConfig{Spec: config.AppSpec{HealthCheck: "/health", Port: 8080}},
+		Events: []debug.EventInfo{
+			{Type: "Warning", Reason: "Unhealthy", Kind: "Pod", Object: "myapp-1",
+				Message: "Liveness probe failed: HTTP probe failed with statuscode: 404"},
+			{Type: "Warning", Reason: "Unhealthy", Kind: "Pod", Object: "myapp-1",
+				Message: "Readiness probe failed: Get \"http://10.0.0.1:8080/health\": dial tcp 10.0.0.1:8080: connect: connection refused"},
+		},
+	}
+
+	report := Analyze(snap)
+	if len(report.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", report.Findings)
+	}
+	if !strings.Contains(report.Findings[0].Explanation, "/health") {
+		t.Errorf("expected explanation to name the probe path, got %q", report.Findings[0].Explanation)
+	}
+	if !strings.Contains(report.Findings[1].Fixes[0], "8080") {
+		t.Errorf("expected port fix, got %v", report.Findings[1].Fixes)
+	}
+}
+
+func TestAnalyze_Ranking(t *testing.T) {
+	snap := &Snapshot{
+		AppName: "myapp",
+		Pods: []corev1.Pod{
+			podWithStatus("myapp-1", corev1.ContainerStatus{
+				Name:                 "myapp",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+			}),
+		},
+		Events: []debug.EventInfo{
+			{Reason: "FailedCreate", Kind: "ReplicaSet", Object: "myapp-abc",
+				Message: `pods "myapp-abc-x" is forbidden: exceeded quota: compute, requested: limits.memory=256Mi`},
+		},
+	}
+
+	report := Analyze(snap)
+	if len(report.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(report.Findings))
+	}
+	if report.Findings[0].Score != ScoreQuota || report.Findings[1].Score != ScoreCrash {
+		t.Errorf("expected quota ranked above crash, got %q then %q", report.Findings[0].Title, report.Findings[1].Title)
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, report)
+	if !strings.Contains(buf.String(), "1. Namespace resource quota") {
+		t.Errorf("unexpected print output:\n%s", buf.String())
+	}
+}
+
+func TestAnalyze_Healthy(t *testing.T) {
+	snap := &Snapshot{
+		AppName: "myapp",
+		Pods: []corev1.Pod{
+			podWithStatus("myapp-1", corev1.ContainerStatus{
+				Name:  "myapp",
+				Ready: true,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}),
+		},
+	}
+	report := Analyze(snap)
+	if !report.Healthy() {
+		t.Errorf("expected healthy report, got %+v", report.Findings)
+	}
+}
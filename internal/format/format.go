@@ -0,0 +1,103 @@
+// Package format renders times and durations for people: compact ages such
+// as "3m", relative times such as "3m ago", and absolute timestamps in the
+// user's locale when they ask for them with --timestamps
+package format
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// absolute makes Age and Ago print timestamps instead of relative times
+var absolute bool
+
+// SetAbsolute turns absolute timestamps on or off for Age and Ago
+func SetAbsolute(on bool) {
+	absolute = on
+}
+
+// now is the clock Age and Ago measure from, replaced in tests
+var now = time.Now
+
+// Duration formats d compactly in its largest whole unit: "45s", "3m",
+// "2h" or "4d". Negative durations, from clock skew, count as zero.
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// Age returns how old something created at t is, e.g. "3m", for AGE
+// columns; with absolute timestamps on, it returns when it was created
+func Age(t time.Time) string {
+	if absolute {
+		return Timestamp(t)
+	}
+	return Duration(now().Sub(t))
+}
+
+// Ago returns how long ago t was, e.g. "3m ago", or "just now" within the
+// last minute; with absolute timestamps on, it returns t itself
+func Ago(t time.Time) string {
+	if absolute {
+		return Timestamp(t)
+	}
+	d := now().Sub(t)
+	if d < time.Minute {
+		return "just now"
+	}
+	return Duration(d) + " ago"
+}
+
+// Timestamp returns t in local time, laid out the way the user's locale
+// writes dates: "2006-01-02 15:04:05" unless LC_ALL, LC_TIME or LANG name
+// one with another order
+func Timestamp(t time.Time) string {
+	return t.Local().Format(layout(locale()))
+}
+
+// locale returns the locale times are formatted for, by POSIX precedence
+func locale() string {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// layout returns the timestamp layout for a locale such as "de_DE.UTF-8".
+// Only the date order and separators vary; times are always 24-hour except
+// in the US.
+func layout(loc string) string {
+	loc, _, _ = strings.Cut(loc, ".")
+	loc, _, _ = strings.Cut(loc, "@")
+	lang, region, _ := strings.Cut(strings.ReplaceAll(loc, "-", "_"), "_")
+
+	switch {
+	case lang == "en" && region == "US":
+		return "01/02/2006 3:04:05 PM"
+	case lang == "en" && (region == "CA" || region == ""):
+		return "2006-01-02 15:04:05"
+	case lang == "en", lang == "fr", lang == "es", lang == "it", lang == "pt":
+		return "02/01/2006 15:04:05"
+	case lang == "de", lang == "ru", lang == "pl", lang == "cs", lang == "fi", lang == "nb", lang == "tr":
+		return "02.01.2006 15:04:05"
+	case lang == "nl":
+		return "02-01-2006 15:04:05"
+	case lang == "ja", lang == "zh", lang == "ko":
+		return "2006/01/02 15:04:05"
+	}
+	return "2006-01-02 15:04:05"
+}
@@ -0,0 +1,85 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{-time.Second, "0s"},
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 59*time.Second, "3m"},
+		{2*time.Hour + 5*time.Minute, "2h"},
+		{4*24*time.Hour + time.Hour, "4d"},
+	}
+	for _, tt := range tests {
+		if got := Duration(tt.d); got != tt.want {
+			t.Errorf("Duration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestAgeAndAgo(t *testing.T) {
+	fixed := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+	t.Setenv("LC_ALL", "C")
+
+	if got := Ago(fixed.Add(-30 * time.Second)); got != "just now" {
+		t.Errorf("Ago(30s) = %q, want just now", got)
+	}
+	if got := Ago(fixed.Add(-3 * time.Minute)); got != "3m ago" {
+		t.Errorf("Ago(3m) = %q, want 3m ago", got)
+	}
+	if got := Age(fixed.Add(-2 * time.Hour)); got != "2h" {
+		t.Errorf("Age(2h) = %q, want 2h", got)
+	}
+
+	SetAbsolute(true)
+	defer SetAbsolute(false)
+	created := fixed.Add(-3 * time.Minute)
+	want := created.Local().Format("2006-01-02 15:04:05")
+	if got := Ago(created); got != want {
+		t.Errorf("absolute Ago = %q, want %q", got, want)
+	}
+	if got := Age(created); got != want {
+		t.Errorf("absolute Age = %q, want %q", got, want)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", "2006-01-02 15:04:05"},
+		{"C", "2006-01-02 15:04:05"},
+		{"POSIX", "2006-01-02 15:04:05"},
+		{"en_US.UTF-8", "01/02/2006 3:04:05 PM"},
+		{"en_GB.UTF-8", "02/01/2006 15:04:05"},
+		{"en-CA", "2006-01-02 15:04:05"},
+		{"de_DE.UTF-8@euro", "02.01.2006 15:04:05"},
+		{"fr_FR", "02/01/2006 15:04:05"},
+		{"ja_JP.UTF-8", "2006/01/02 15:04:05"},
+		{"sv_SE.UTF-8", "2006-01-02 15:04:05"},
+	}
+	for _, tt := range tests {
+		if got := layout(tt.locale); got != tt.want {
+			t.Errorf("layout(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestTimestampLocale(t *testing.T) {
+	ts := time.Date(2026, 3, 14, 15, 9, 26, 0, time.Local)
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_TIME", "de_DE.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := Timestamp(ts); got != "14.03.2026 15:09:26" {
+		t.Errorf("Timestamp with LC_TIME=de_DE = %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+// Package golden compares rendered manifests with golden files checked into
+// the repo, so a kbox.yaml refactor shows up as a reviewable diff
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDir is where kbox test keeps golden files, relative to kbox.yaml
+const DefaultDir = ".kbox/golden"
+
+// contextLines is how many unchanged lines Diff shows around a change
+const contextLines = 3
+
+// Path returns the golden file for an environment; the base config (no
+// environment) is base.yaml
+func Path(dir, env string) string {
+	if env == "" {
+		env = "base"
+	}
+	return filepath.Join(dir, env+".yaml")
+}
+
+// Read returns a golden file's contents, and false if it doesn't exist
+func Read(path string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// Write creates or replaces a golden file, creating its directory
+func Write(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Diff returns a unified-style line diff from want to got, or "" if they are
+// equal. Removed lines start with "-", added lines with "+".
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a, b := splitLines(want), splitLines(got)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:], b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte // ' ', '-' or '+'
+		text string
+		num  int // line number in want, for hunk headers
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i], i + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i], i + 1})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j], i + 1})
+			j++
+		}
+	}
+
+	// Print changed lines with some context, separating distant hunks
+	var sb strings.Builder
+	last := -1
+	for k, l := range lines {
+		near := false
+		for n := max(0, k-contextLines); n <= min(len(lines)-1, k+contextLines); n++ {
+			near = near || lines[n].op != ' '
+		}
+		if !near {
+			continue
+		}
+		if last == -1 || k > last+1 {
+			fmt.Fprintf(&sb, "@@ line %d @@\n", l.num)
+		}
+		fmt.Fprintf(&sb, "%c %s\n", l.op, l.text)
+		last = k
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,55 @@
+package golden
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	if got := Diff("a\nb\n", "a\nb\n"); got != "" {
+		t.Errorf("equal inputs should have no diff, got %q", got)
+	}
+
+	want := "kind: Deployment\nspec:\n  replicas: 2\n  image: web:v1\n"
+	got := "kind: Deployment\nspec:\n  replicas: 3\n  image: web:v1\n"
+	diff := Diff(want, got)
+	for _, line := range []string{"@@ line 1 @@", "-   replicas: 2", "+   replicas: 3", "  kind: Deployment"} {
+		if !strings.Contains(diff, line+"\n") {
+			t.Errorf("expected %q in diff:\n%s", line, diff)
+		}
+	}
+}
+
+func TestDiff_SeparatesDistantHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		a = append(a, "same")
+		b = append(b, "same")
+	}
+	a[1], b[1] = "old top", "new top"
+	a[18], b[18] = "old bottom", "new bottom"
+
+	diff := Diff(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if n := strings.Count(diff, "@@"); n != 4 {
+		t.Errorf("expected two hunks, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ line 1 @@") || !strings.Contains(diff, "@@ line 16 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", diff)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DefaultDir)
+
+	if _, ok, err := Read(Path(dir, "")); ok || err != nil {
+		t.Fatalf("Read() of a missing file = %v, %v; want not found", ok, err)
+	}
+	if err := Write(Path(dir, "prod"), "kind: Service\n"); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, ok, err := Read(filepath.Join(dir, "prod.yaml"))
+	if err != nil || !ok || got != "kind: Service\n" {
+		t.Errorf("Read() = %q, %v, %v", got, ok, err)
+	}
+}
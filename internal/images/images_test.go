@@ -0,0 +1,178 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Registry: dockerHub, Repository: "library/nginx", Tag: "latest"}},
+		{"postgres:16-alpine", Reference{Registry: dockerHub, Repository: "library/postgres", Tag: "16-alpine"}},
+		{"bitnami/redis:7.2", Reference{Registry: dockerHub, Repository: "bitnami/redis", Tag: "7.2"}},
+		{"docker.io/library/redis:7", Reference{Registry: dockerHub, Repository: "library/redis", Tag: "7"}},
+		{"ghcr.io/acme/api:v1", Reference{Registry: "ghcr.io", Repository: "acme/api", Tag: "v1"}},
+		{"localhost:5000/api", Reference{Registry: "localhost:5000", Repository: "api", Tag: "latest"}},
+		{"ghcr.io/acme/api@sha256:abc", Reference{Registry: "ghcr.io", Repository: "acme/api", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("ParseReference(%q) error: %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const digest = "sha256:1f2e3d"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:acme/api:pull" {
+				t.Errorf("unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token": "secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/acme/api/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	r := &Resolver{Client: server.Client()}
+
+	got, err := r.Resolve(context.Background(), host+"/acme/api:v1")
+	if err != nil || got != digest {
+		t.Errorf("Resolve() = %q, %v; want %s", got, err, digest)
+	}
+	if _, err := r.Resolve(context.Background(), host+"/acme/api:missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found for a missing tag, got %v", err)
+	}
+	// A pinned image needs no request
+	if got, err := r.Resolve(context.Background(), "unreachable.invalid/api@sha256:abc"); err != nil || got != "sha256:abc" {
+		t.Errorf("Resolve() of a pinned image = %q, %v", got, err)
+	}
+}
+
+func TestBuildTag(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)
+
+	if got, _ := BuildTag(ctx, "", t.TempDir(), "0123456789abcdef", now); got != "kbox-0123456789ab" {
+		t.Errorf("default tag = %q, want the context hash", got)
+	}
+	if got, _ := BuildTag(ctx, config.TagStrategyTimestamp, t.TempDir(), "", now); got != "20260314-101500" {
+		t.Errorf("timestamp tag = %q", got)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=kbox", "-c", "user.email=kbox@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	gitCmd("init", "-q")
+	os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644)
+	gitCmd("add", ".")
+	gitCmd("commit", "-qm", "init")
+
+	if _, err := BuildTag(ctx, config.TagStrategySemver, dir, "", now); err == nil {
+		t.Error("semver without a version tag should fail")
+	}
+	gitCmd("tag", "v1.4.2")
+	if got, err := BuildTag(ctx, config.TagStrategySemver, dir, "", now); err != nil || got != "v1.4.2" {
+		t.Errorf("semver tag = %q, %v; want v1.4.2", got, err)
+	}
+
+	got, err := BuildTag(ctx, config.TagStrategyGitSHA, dir, "feedface00", now)
+	if err != nil || !strings.HasPrefix(got, "kbox-") || len(got) != len("kbox-")+12 {
+		t.Errorf("gitsha tag = %q, %v", got, err)
+	}
+	os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644)
+	if dirty, _ := BuildTag(ctx, config.TagStrategyGitSHA, dir, "feedface00", now); dirty != got+"-dirty-feedface" {
+		t.Errorf("gitsha tag with uncommitted changes = %q, want %s-dirty-feedface", dirty, got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// A fake cosign that accepts signatures of the "signed" image, and SBOM
+	// attestations of the "attested" one, logging its arguments
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+for arg; do last=$arg; done
+case "$1 $last" in
+  "verify registry.test/signed:v1"|"verify registry.test/attested:v1"|"verify-attestation registry.test/attested:v1") exit 0 ;;
+esac
+echo "Error: no matching signatures" >&2
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "cosign"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx := context.Background()
+	signing := &config.SigningConfig{Key: "keys/cosign.key"}
+	tests := []struct {
+		image        string
+		signed, sbom bool
+	}{
+		{"registry.test/unsigned:v1", false, false},
+		{"registry.test/signed:v1", true, false},
+		{"registry.test/attested:v1", true, true},
+	}
+	for _, tt := range tests {
+		got, err := Verify(ctx, signing, tt.image)
+		if err != nil {
+			t.Fatalf("Verify(%s) error: %v", tt.image, err)
+		}
+		if got.Signed != tt.signed || got.SBOM != tt.sbom {
+			t.Errorf("Verify(%s) = %+v, want signed=%v sbom=%v", tt.image, got, tt.signed, tt.sbom)
+		}
+		if !tt.signed && !strings.Contains(got.Error, "no matching signatures") {
+			t.Errorf("expected cosign's error for %s, got %q", tt.image, got.Error)
+		}
+	}
+
+	args, _ := os.ReadFile(log)
+	if !strings.Contains(string(args), "verify --key keys/cosign.pub registry.test/signed:v1") {
+		t.Errorf("expected verification with the public key, got:\n%s", args)
+	}
+
+	keyless := &config.SigningConfig{Keyless: true, Identity: "ci@acme.dev", Issuer: "https://token.actions.githubusercontent.com"}
+	if got := strings.Join(verifyArgs(keyless, "verify"), " "); got != "verify --certificate-identity ci@acme.dev --certificate-oidc-issuer https://token.actions.githubusercontent.com" {
+		t.Errorf("keyless verify args = %q", got)
+	}
+	if got := strings.Join(signArgs(keyless, "sign"), " "); got != "sign --yes" {
+		t.Errorf("keyless sign args = %q", got)
+	}
+}
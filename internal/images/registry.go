@@ -0,0 +1,270 @@
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// dockerHub is where images without a registry host are pulled from
+	dockerHub         = "registry-1.docker.io"
+	dockerHubIndex    = "docker.io"
+	dockerHubAuthKey  = "https://index.docker.io/v1/"
+	defaultTag        = "latest"
+	resolveTimeout    = 10 * time.Second
+	manifestMediaType = "application/vnd.oci.image.index.v1+json, " +
+		"application/vnd.docker.distribution.manifest.list.v2+json, " +
+		"application/vnd.oci.image.manifest.v1+json, " +
+		"application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// Reference is a parsed image reference, e.g. ghcr.io/acme/api:v1
+type Reference struct {
+	// Registry is the registry host, e.g. ghcr.io or registry-1.docker.io
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference as docker does: a first path
+// component with a dot or port, or localhost, is a registry host, otherwise
+// the image is on Docker Hub (under library/ for official images)
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name, digest, _ := strings.Cut(image, "@")
+	ref.Digest = digest
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return ref, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	host, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry, ref.Repository = host, rest
+	} else {
+		ref.Registry, ref.Repository = dockerHub, name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+	if ref.Registry == dockerHubIndex {
+		ref.Registry = dockerHub
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// Resolver resolves image tags to digests with HEAD requests to the registry
+// API, authenticating like docker pull: anonymously, or with the credentials
+// 'docker login' stored in ~/.docker/config.json
+type Resolver struct {
+	Client *http.Client
+	// Credentials returns the base64 user:password for a registry host, or ""
+	Credentials func(registry string) string
+}
+
+// NewResolver returns a Resolver using docker's stored credentials
+func NewResolver() *Resolver {
+	return &Resolver{
+		Client:      &http.Client{Timeout: resolveTimeout},
+		Credentials: dockerCredentials,
+	}
+}
+
+// Resolve returns the digest an image's tag points to. An image pinned by
+// digest resolves to that digest without a request.
+func (r *Resolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	scheme := "https"
+	if host, _, _ := strings.Cut(ref.Registry, ":"); host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.Registry, ref.Repository, ref.Tag)
+
+	resp, err := r.head(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		auth, err := r.authorize(ctx, ref, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = r.head(ctx, manifestURL, auth); err != nil {
+			return "", err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%s not found in %s", ref.Repository+":"+ref.Tag, ref.Registry)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("not authorized to read %s from %s\n  → Run 'docker login %s'", ref.Repository, ref.Registry, ref.Registry)
+	default:
+		return "", fmt.Errorf("registry %s returned %s", ref.Registry, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry %s didn't return a digest for %s", ref.Registry, image)
+	}
+	return digest, nil
+}
+
+func (r *Resolver) head(ctx context.Context, url, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestMediaType)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorize answers a registry's 401 challenge: Basic with stored
+// credentials, or a Bearer token from the registry's token service
+func (r *Resolver) authorize(ctx context.Context, ref Reference, challenge string) (string, error) {
+	var creds string
+	if r.Credentials != nil {
+		creds = r.Credentials(ref.Registry)
+	}
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") {
+		if creds == "" {
+			return "", fmt.Errorf("%s requires a login\n  → Run 'docker login %s'", ref.Registry, ref.Registry)
+		}
+		return "Basic " + creds, nil
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry %s asked for unsupported auth %q", ref.Registry, scheme)
+	}
+
+	values := parseChallenge(params)
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("registry %s sent an invalid auth challenge", ref.Registry)
+	}
+	query := realm.Query()
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if creds != "" {
+		req.Header.Set("Authorization", "Basic "+creds)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get a token for %s: %s", ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to read token for %s: %w", ref.Registry, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses the key="value" pairs of a WWW-Authenticate header
+func parseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+	for params != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+		params = rest
+	}
+	return values
+}
+
+// dockerCredentials returns the credentials 'docker login' stored inline in
+// ~/.docker/config.json. Credentials in a helper (credsStore) aren't read.
+func dockerCredentials(registry string) string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return ""
+	}
+	keys := []string{registry, "https://" + registry}
+	if registry == dockerHub {
+		keys = append(keys, dockerHubAuthKey, dockerHubIndex)
+	}
+	for _, key := range keys {
+		if auth := cfg.Auths[key].Auth; auth != "" {
+			if _, err := base64.StdEncoding.DecodeString(auth); err == nil {
+				return auth
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// SBOMPredicateType is the attestation type of the SBOMs kbox attaches
+const SBOMPredicateType = "spdxjson"
+
+// Verification is the outcome of checking an image's signature and SBOM
+// attestation with cosign
+type Verification struct {
+	Image  string `json:"image"`
+	Signed bool   `json:"signed"`
+	// SBOM is set when the image has a valid SBOM attestation
+	SBOM  bool   `json:"sbom"`
+	Error string `json:"error,omitempty"`
+}
+
+// toolURLs are where to get the tools kbox runs for the supply chain
+var toolURLs = map[string]string{
+	"syft":   "https://github.com/anchore/syft#installation",
+	"cosign": "https://docs.sigstore.dev/cosign/system_config/installation/",
+}
+
+// RequireTool returns an error saying how to install tool (syft or cosign)
+// when it's not on PATH
+func RequireTool(tool string) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s not found in PATH\n  → Install %s: %s", tool, tool, toolURLs[tool])
+	}
+	return nil
+}
+
+// GenerateSBOM writes an SPDX JSON SBOM of image to path with syft. The image
+// is read from the local docker daemon if it's there, otherwise the registry.
+func GenerateSBOM(ctx context.Context, image, path string) error {
+	out, err := run(ctx, "syft", image, "-o", SBOMPredicateType, "-q")
+	if err != nil {
+		return fmt.Errorf("failed to generate SBOM of %s: %w", image, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// Sign signs image, which must be in a registry, with cosign
+func Sign(ctx context.Context, s *config.SigningConfig, image string) error {
+	if _, err := run(ctx, "cosign", append(signArgs(s, "sign"), image)...); err != nil {
+		return fmt.Errorf("failed to sign %s: %w", image, err)
+	}
+	return nil
+}
+
+// Attest attaches the SBOM at path to image as a signed attestation
+func Attest(ctx context.Context, s *config.SigningConfig, image, path string) error {
+	args := append(signArgs(s, "attest"), "--type", SBOMPredicateType, "--predicate", path, image)
+	if _, err := run(ctx, "cosign", args...); err != nil {
+		return fmt.Errorf("failed to attest the SBOM of %s: %w", image, err)
+	}
+	return nil
+}
+
+// Verify checks image's signature, and whether it has an SBOM attestation,
+// against the key or keyless identity of s. The error is set when cosign
+// can't run; an image that fails verification has Signed unset.
+func Verify(ctx context.Context, s *config.SigningConfig, image string) (Verification, error) {
+	result := Verification{Image: image}
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return result, fmt.Errorf("cosign not found\n  → Install it: https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+	if _, err := run(ctx, "cosign", append(verifyArgs(s, "verify"), image)...); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Signed = true
+
+	_, err := run(ctx, "cosign", append(verifyArgs(s, "verify-attestation"), "--type", SBOMPredicateType, image)...)
+	result.SBOM = err == nil
+	return result, nil
+}
+
+// signArgs returns the cosign arguments that sign with s's key, or keylessly
+func signArgs(s *config.SigningConfig, command string) []string {
+	args := []string{command, "--yes"}
+	if !s.Keyless {
+		args = append(args, "--key", s.Key)
+	}
+	return args
+}
+
+// verifyArgs returns the cosign arguments that verify against s's public
+// key, or its keyless identity
+func verifyArgs(s *config.SigningConfig, command string) []string {
+	args := []string{command}
+	if s.Keyless {
+		args = append(args, "--certificate-identity", s.Identity, "--certificate-oidc-issuer", s.Issuer)
+	} else {
+		args = append(args, "--key", s.VerificationKey())
+	}
+	return args
+}
+
+// run runs a supply chain tool, returning its stdout, or an error with the
+// last line it printed to stderr
+func run(ctx context.Context, tool string, args ...string) ([]byte, error) {
+	if err := RequireTool(tool); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, tool, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			return nil, fmt.Errorf("%s: %s", err, last)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+// Package images tags the images kbox builds and resolves image tags to
+// digests through the registry API
+package images
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// semverTag matches a git tag that is a semantic version, e.g. v1.4.2
+var semverTag = regexp.MustCompile(`^v?\d+\.\d+\.\d+`)
+
+// BuildTag returns the tag kbox up gives the image it builds from dir.
+// contextDigest is the hash of the build context, or "" if it couldn't be
+// computed; the default strategy tags by it, so unchanged sources reuse the
+// tag and don't roll out again.
+//
+//   - gitsha: the commit, e.g. kbox-1a2b3c4d5e6f; with uncommitted changes
+//     the context hash is appended, as different sources need different tags
+//   - timestamp: the build time in UTC, e.g. 20260314-101500
+//   - semver: the latest version tag, e.g. v1.4.2, or v1.4.2-3-g1a2b3c4 for
+//     commits after it, as git describe prints it
+func BuildTag(ctx context.Context, strategy, dir, contextDigest string, now time.Time) (string, error) {
+	switch strategy {
+	case config.TagStrategyGitSHA:
+		sha, err := git(ctx, dir, "rev-parse", "--short=12", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("tagStrategy gitsha needs a git commit: %w", err)
+		}
+		tag := "kbox-" + sha
+		if status, _ := git(ctx, dir, "status", "--porcelain"); status != "" && contextDigest != "" {
+			tag += "-dirty-" + contextDigest[:min(8, len(contextDigest))]
+		}
+		return tag, nil
+
+	case config.TagStrategyTimestamp:
+		return now.UTC().Format("20060102-150405"), nil
+
+	case config.TagStrategySemver:
+		for _, pattern := range []string{"v[0-9]*.[0-9]*.[0-9]*", "[0-9]*.[0-9]*.[0-9]*"} {
+			version, err := git(ctx, dir, "describe", "--tags", "--match", pattern, "--dirty", "--abbrev=7")
+			if err == nil && semverTag.MatchString(version) {
+				return version, nil
+			}
+		}
+		return "", fmt.Errorf("tagStrategy semver needs a version tag such as v1.0.0 on this commit or an ancestor\n  → Run 'git tag v0.1.0'")
+	}
+
+	if contextDigest != "" {
+		return "kbox-" + contextDigest[:min(12, len(contextDigest))], nil
+	}
+	return fmt.Sprintf("kbox-%d", now.Unix()), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
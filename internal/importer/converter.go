@@ -150,11 +150,27 @@ func extractFromServices(services []*corev1.Service, cfg *config.AppConfig) {
 		matchingSvc = services[0]
 	}
 
-	// Extract service type
+	// Extract service type and the options kbox.yaml can express
+	svcConfig := &config.ServiceConfig{
+		ExternalName:          matchingSvc.Spec.ExternalName,
+		ExternalTrafficPolicy: string(matchingSvc.Spec.ExternalTrafficPolicy),
+	}
 	if matchingSvc.Spec.Type != "" && matchingSvc.Spec.Type != corev1.ServiceTypeClusterIP {
-		cfg.Spec.Service = &config.ServiceConfig{
-			Type: string(matchingSvc.Spec.Type),
-		}
+		svcConfig.Type = string(matchingSvc.Spec.Type)
+	}
+	if matchingSvc.Spec.ClusterIP == corev1.ClusterIPNone {
+		svcConfig.ClusterIP = corev1.ClusterIPNone
+	}
+	if matchingSvc.Spec.SessionAffinity == corev1.ServiceAffinityClientIP {
+		svcConfig.SessionAffinity = string(corev1.ServiceAffinityClientIP)
+	}
+	// Cluster is the API server's default for NodePort and LoadBalancer
+	if svcConfig.ExternalTrafficPolicy == string(corev1.ServiceExternalTrafficPolicyCluster) {
+		svcConfig.ExternalTrafficPolicy = ""
+	}
+	if svcConfig.Type != "" || svcConfig.ClusterIP != "" || svcConfig.ExternalName != "" ||
+		svcConfig.SessionAffinity != "" || svcConfig.ExternalTrafficPolicy != "" {
+		cfg.Spec.Service = svcConfig
 	}
 
 	// If service has a different port than the container port, record it
@@ -0,0 +1,311 @@
+package intercept
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LabelIntercept marks the proxy resources with the intercepted app
+	LabelIntercept = "kbox.dev/intercept"
+
+	// AnnotationReplicas records the Deployment's replicas before the intercept
+	AnnotationReplicas = "kbox.dev/intercept-replicas"
+
+	// AnnotationSelector records the Service's selector before the intercept
+	AnnotationSelector = "kbox.dev/intercept-selector"
+
+	// ProxyImage serves the intercept proxy
+	ProxyImage = "nginx:1.27-alpine"
+)
+
+// Manager swaps a Service's endpoints to a proxy that forwards to a tunnel,
+// and restores the original Service and Deployment afterwards.
+//
+// The original state is kept in annotations on the Service and Deployment, so
+// an intercept left behind by a crashed session can still be restored.
+type Manager struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewManager creates a new intercept manager
+func NewManager(client kubernetes.Interface, namespace string) *Manager {
+	return &Manager{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// ProxyName returns the name of the proxy resources for an app
+func ProxyName(name string) string {
+	return name + "-intercept"
+}
+
+// Active reports whether the app's Service is currently intercepted
+func (m *Manager) Active(ctx context.Context, name string) (bool, error) {
+	svc, err := m.client.CoreV1().Services(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	_, ok := svc.Annotations[AnnotationSelector]
+	return ok, nil
+}
+
+// Deploy creates the proxy that forwards the Service's ports to upstream
+func (m *Manager) Deploy(ctx context.Context, name, upstream string) error {
+	svc, err := m.client.CoreV1().Services(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s: %w", name, err)
+	}
+	if _, ok := svc.Annotations[AnnotationSelector]; ok {
+		return fmt.Errorf("service %s is already intercepted\n  → Run 'kbox intercept %s --restore' to reset it", name, name)
+	}
+	if _, err := m.client.AppsV1().Deployments(m.namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", name, err)
+	}
+
+	conf, err := nginxConfig(servicePorts(svc), upstream)
+	if err != nil {
+		return err
+	}
+
+	proxyName := ProxyName(name)
+	labels := map[string]string{
+		"app":                          proxyName,
+		"app.kubernetes.io/managed-by": "kbox",
+		LabelIntercept:                 name,
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyName,
+			Namespace: m.namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{"default.conf": conf},
+	}
+	if _, err := m.client.CoreV1().ConfigMaps(m.namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create intercept config: %w", err)
+	}
+
+	ports := servicePorts(svc)
+	containerPorts := make([]corev1.ContainerPort, 0, len(ports))
+	for _, p := range ports {
+		containerPorts = append(containerPorts, corev1.ContainerPort{ContainerPort: p})
+	}
+
+	replicas := int32(1)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyName,
+			Namespace: m.namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": proxyName},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "proxy",
+						Image: ProxyImage,
+						Ports: containerPorts,
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "config",
+							MountPath: "/etc/nginx/conf.d",
+						}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(int(ports[0]))},
+							},
+							PeriodSeconds: 2,
+						},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: proxyName},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	if _, err := m.client.AppsV1().Deployments(m.namespace).Create(ctx, deploy, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create intercept proxy: %w", err)
+	}
+	return nil
+}
+
+// WaitReady waits until the proxy has a ready pod
+func (m *Manager) WaitReady(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		deploy, err := m.client.AppsV1().Deployments(m.namespace).Get(ctx, ProxyName(name), metav1.GetOptions{})
+		if err == nil && deploy.Status.ReadyReplicas > 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the intercept proxy to be ready")
+		case <-ticker.C:
+		}
+	}
+}
+
+// Swap points the Service at the proxy and scales the original Deployment to zero,
+// recording the original selector and replicas for Restore
+func (m *Manager) Swap(ctx context.Context, name string) error {
+	svc, err := m.client.CoreV1().Services(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s: %w", name, err)
+	}
+	selector, err := json.Marshal(svc.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	svc.Annotations[AnnotationSelector] = string(selector)
+	svc.Spec.Selector = map[string]string{"app": ProxyName(name)}
+	if _, err := m.client.CoreV1().Services(m.namespace).Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", name, err)
+	}
+
+	deploy, err := m.client.AppsV1().Deployments(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", name, err)
+	}
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	if deploy.Annotations == nil {
+		deploy.Annotations = map[string]string{}
+	}
+	deploy.Annotations[AnnotationReplicas] = strconv.Itoa(int(replicas))
+	zero := int32(0)
+	deploy.Spec.Replicas = &zero
+	if _, err := m.client.AppsV1().Deployments(m.namespace).Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale down deployment %s: %w", name, err)
+	}
+	return nil
+}
+
+// Restore puts back the original Service selector and Deployment replicas and
+// removes the proxy. It is safe to call on a partially started or finished intercept.
+func (m *Manager) Restore(ctx context.Context, name string) error {
+	var errs []string
+
+	svc, err := m.client.CoreV1().Services(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("get service: %v", err))
+	} else if raw, ok := svc.Annotations[AnnotationSelector]; ok {
+		var selector map[string]string
+		if err := json.Unmarshal([]byte(raw), &selector); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid %s annotation: %v", AnnotationSelector, err))
+		} else {
+			svc.Spec.Selector = selector
+			delete(svc.Annotations, AnnotationSelector)
+			if _, err := m.client.CoreV1().Services(m.namespace).Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+				errs = append(errs, fmt.Sprintf("restore service: %v", err))
+			}
+		}
+	}
+
+	deploy, err := m.client.AppsV1().Deployments(m.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("get deployment: %v", err))
+	} else if raw, ok := deploy.Annotations[AnnotationReplicas]; ok {
+		replicas, err := strconv.Atoi(raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid %s annotation: %v", AnnotationReplicas, err))
+		} else {
+			r := int32(replicas)
+			deploy.Spec.Replicas = &r
+			delete(deploy.Annotations, AnnotationReplicas)
+			if _, err := m.client.AppsV1().Deployments(m.namespace).Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+				errs = append(errs, fmt.Sprintf("restore deployment: %v", err))
+			}
+		}
+	}
+
+	proxyName := ProxyName(name)
+	if err := m.client.AppsV1().Deployments(m.namespace).Delete(ctx, proxyName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("delete proxy: %v", err))
+	}
+	if err := m.client.CoreV1().ConfigMaps(m.namespace).Delete(ctx, proxyName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("delete proxy config: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to restore %s: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// servicePorts returns the pod ports the Service sends traffic to
+func servicePorts(svc *corev1.Service) []int32 {
+	var ports []int32
+	for _, p := range svc.Spec.Ports {
+		port := p.Port
+		if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal != 0 {
+			port = p.TargetPort.IntVal
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// nginxConfig builds a proxy config that forwards every port to upstream.
+// The extra headers skip the interstitial pages of the free tunnel providers.
+func nginxConfig(ports []int32, upstream string) (string, error) {
+	if len(ports) == 0 {
+		return "", fmt.Errorf("service has no ports to intercept")
+	}
+	u, err := url.Parse(upstream)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid tunnel URL %q", upstream)
+	}
+
+	var b strings.Builder
+	b.WriteString("server {\n")
+	for _, p := range ports {
+		fmt.Fprintf(&b, "    listen %d;\n", p)
+	}
+	fmt.Fprintf(&b, `    location / {
+        proxy_pass %s://%s;
+        proxy_ssl_server_name on;
+        proxy_http_version 1.1;
+        proxy_set_header Host %s;
+        proxy_set_header X-Forwarded-Host $host;
+        proxy_set_header bypass-tunnel-reminder 1;
+        proxy_set_header ngrok-skip-browser-warning 1;
+    }
+}
+`, u.Scheme, u.Host, u.Host)
+	return b.String(), nil
+}
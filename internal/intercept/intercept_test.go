@@ -0,0 +1,105 @@
+package intercept
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newInterceptFixture() *fake.Clientset {
+	replicas := int32(3)
+	return fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-api", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "myapp-api"},
+				Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-api", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+	)
+}
+
+func TestInterceptSwapAndRestore(t *testing.T) {
+	ctx := context.Background()
+	client := newInterceptFixture()
+	m := NewManager(client, "default")
+
+	if err := m.Deploy(ctx, "myapp-api", "https://abc.loca.lt"); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "myapp-api-intercept", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected proxy config: %v", err)
+	}
+	if !strings.Contains(cm.Data["default.conf"], "listen 8080;") || !strings.Contains(cm.Data["default.conf"], "proxy_pass https://abc.loca.lt;") {
+		t.Errorf("unexpected proxy config:\n%s", cm.Data["default.conf"])
+	}
+
+	if err := m.Swap(ctx, "myapp-api"); err != nil {
+		t.Fatalf("swap failed: %v", err)
+	}
+	svc, _ := client.CoreV1().Services("default").Get(ctx, "myapp-api", metav1.GetOptions{})
+	if svc.Spec.Selector["app"] != "myapp-api-intercept" {
+		t.Errorf("expected service to select the proxy, got %v", svc.Spec.Selector)
+	}
+	deploy, _ := client.AppsV1().Deployments("default").Get(ctx, "myapp-api", metav1.GetOptions{})
+	if *deploy.Spec.Replicas != 0 {
+		t.Errorf("expected deployment scaled to 0, got %d", *deploy.Spec.Replicas)
+	}
+	if active, _ := m.Active(ctx, "myapp-api"); !active {
+		t.Error("expected intercept to be active")
+	}
+
+	// A second intercept of the same service is refused
+	if err := m.Deploy(ctx, "myapp-api", "https://other.loca.lt"); err == nil {
+		t.Error("expected error intercepting an intercepted service")
+	}
+
+	if err := m.Restore(ctx, "myapp-api"); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	svc, _ = client.CoreV1().Services("default").Get(ctx, "myapp-api", metav1.GetOptions{})
+	if svc.Spec.Selector["app"] != "myapp-api" || svc.Annotations[AnnotationSelector] != "" {
+		t.Errorf("expected original selector restored, got %v", svc.Spec.Selector)
+	}
+	deploy, _ = client.AppsV1().Deployments("default").Get(ctx, "myapp-api", metav1.GetOptions{})
+	if *deploy.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas restored, got %d", *deploy.Spec.Replicas)
+	}
+	if _, err := client.AppsV1().Deployments("default").Get(ctx, "myapp-api-intercept", metav1.GetOptions{}); err == nil {
+		t.Error("expected proxy deployment to be deleted")
+	}
+
+	// Restoring again is a no-op
+	if err := m.Restore(ctx, "myapp-api"); err != nil {
+		t.Errorf("expected second restore to succeed: %v", err)
+	}
+}
+
+func TestNginxConfig(t *testing.T) {
+	if _, err := nginxConfig(nil, "https://abc.loca.lt"); err == nil {
+		t.Error("expected error without ports")
+	}
+	if _, err := nginxConfig([]int32{8080}, "not a url"); err == nil {
+		t.Error("expected error for invalid upstream")
+	}
+	conf, err := nginxConfig([]int32{8080, 9090}, "https://abc.trycloudflare.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"listen 8080;", "listen 9090;", "proxy_set_header Host abc.trycloudflare.com;"} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("expected %q in config:\n%s", want, conf)
+		}
+	}
+}
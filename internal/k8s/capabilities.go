@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"k8s.io/client-go/discovery"
+)
+
+// Capability is an API a kbox feature depends on
+type Capability struct {
+	// GroupVersion is the API, e.g. "autoscaling/v2"
+	GroupVersion string `json:"groupVersion"`
+
+	// Feature is what kbox uses it for
+	Feature string `json:"feature"`
+
+	Available bool `json:"available"`
+}
+
+// capabilities are the APIs behind optional kbox features
+var capabilities = []Capability{
+	{GroupVersion: "autoscaling/v2", Feature: "autoscaling (HorizontalPodAutoscaler)"},
+	{GroupVersion: "policy/v1", Feature: "PodDisruptionBudgets"},
+	{GroupVersion: "batch/v1", Feature: "jobs and sleep schedules (CronJobs)"},
+	{GroupVersion: "networking.k8s.io/v1", Feature: "ingress and NetworkPolicies"},
+	{GroupVersion: "metrics.k8s.io/v1beta1", Feature: "kbox top (metrics-server)"},
+	{GroupVersion: "monitoring.coreos.com/v1", Feature: "metrics (ServiceMonitor)"},
+	{GroupVersion: "cert-manager.io/v1", Feature: "ingress TLS (cert-manager)"},
+}
+
+// Capabilities reports which of the APIs behind kbox features the cluster
+// serves. A failed discovery reports everything unavailable.
+func Capabilities(client discovery.DiscoveryInterface) []Capability {
+	served := make(map[string]bool)
+	if groups, err := client.ServerGroups(); err == nil {
+		for _, group := range groups.Groups {
+			for _, version := range group.Versions {
+				served[version.GroupVersion] = true
+			}
+		}
+	}
+
+	result := make([]Capability, len(capabilities))
+	for i, c := range capabilities {
+		c.Available = served[c.GroupVersion]
+		result[i] = c
+	}
+	return result
+}
@@ -1,23 +1,31 @@
 package k8s
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
 )
 
 // Client wraps a Kubernetes clientset with context information
 type Client struct {
-	Clientset     *kubernetes.Clientset
+	Clientset     kubernetes.Interface
 	RestConfig    *rest.Config
 	Context       string
 	Namespace     string
 	ServerVersion string
+
+	// dynamic is the dynamic client of a fake cluster, which can't be
+	// created from RestConfig
+	dynamic dynamic.Interface
 }
 
 // ClientOptions configures how to build the client
@@ -26,9 +34,133 @@ type ClientOptions struct {
 	Namespace string
 }
 
+// rateLimits are the client-side QPS and burst of the clients NewClient
+// creates; zero keeps client-go's defaults (5 QPS, burst 10)
+var rateLimits struct {
+	qps   float32
+	burst int
+}
+
+// SetRateLimits sets the client-side rate limit of the clients NewClient
+// creates from now on. Large deploys may need more than client-go's default
+// of 5 requests per second.
+func SetRateLimits(qps float32, burst int) {
+	rateLimits.qps = qps
+	rateLimits.burst = burst
+}
+
+// impersonation is the user and groups clients act as; empty acts as the
+// kubeconfig user
+var impersonation rest.ImpersonationConfig
+
+// SetImpersonation makes the clients NewClient creates from now on act as
+// user and groups, like kubectl --as and --as-group, so requests are
+// authorized with their RBAC permissions. The kubeconfig user needs the
+// impersonate permission.
+func SetImpersonation(user string, groups []string) {
+	impersonation = rest.ImpersonationConfig{UserName: user, Groups: groups}
+}
+
+// configure applies the rate limits, impersonation and read-only mode set
+// for all clients
+func configure(restConfig *rest.Config) error {
+	if rateLimits.qps > 0 {
+		restConfig.QPS = rateLimits.qps
+	}
+	if rateLimits.burst > 0 {
+		restConfig.Burst = rateLimits.burst
+	}
+	if impersonation.UserName == "" && len(impersonation.Groups) > 0 {
+		return output.WithCode(output.ErrConfig, fmt.Errorf("--as-group needs --as: the API server only impersonates groups along with a user\n  → Add --as, e.g. --as system:serviceaccount:ci:deployer"))
+	}
+	if impersonation.UserName != "" {
+		restConfig.Impersonate = impersonation
+	}
+	if readOnly {
+		restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return readOnlyTransport{next: rt}
+		})
+	}
+	return nil
+}
+
+// ErrNoCluster is the error of building a client in no-cluster mode
+var ErrNoCluster = errors.New("kbox is running with --no-cluster, but this needs a cluster")
+
+// noCluster makes NewClient fail, so offline commands provably never reach
+// a cluster
+var noCluster bool
+
+// SetNoCluster turns no-cluster mode on or off: while on, NewClient returns
+// ErrNoCluster instead of reading kubeconfig or contacting an API server
+func SetNoCluster(on bool) {
+	noCluster = on
+}
+
+// NoCluster reports whether no-cluster mode is on
+func NoCluster() bool {
+	return noCluster
+}
+
 // NewClient creates a new Kubernetes client
 func NewClient(opts ClientOptions) (*Client, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if noCluster {
+		return nil, output.WithCode(output.ErrCluster, fmt.Errorf("%w\n  → Drop --no-cluster (or unset KBOX_NO_CLUSTER) to run commands that talk to a cluster", ErrNoCluster))
+	}
+	if fakeCluster != nil {
+		return fakeCluster.client(opts), nil
+	}
+	var restConfig *rest.Config
+	var context, namespace string
+	var err error
+	if InCluster() {
+		restConfig, context, namespace, err = inClusterConfig(opts)
+	} else {
+		restConfig, context, namespace, err = kubeconfigClientConfig(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := configure(restConfig); err != nil {
+		return nil, err
+	}
+
+	// Create clientset
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, output.WithCode(output.ErrCluster, fmt.Errorf("failed to create kubernetes client: %w", err))
+	}
+
+	// Get server version
+	serverVersion := "unknown"
+	if version, err := clientset.Discovery().ServerVersion(); err == nil {
+		serverVersion = version.GitVersion
+	}
+
+	return &Client{
+		Clientset:     clientset,
+		RestConfig:    restConfig,
+		Context:       context,
+		Namespace:     namespace,
+		ServerVersion: serverVersion,
+	}, nil
+}
+
+// DynamicClient creates a dynamic client for CRD operations
+func (c *Client) DynamicClient() (dynamic.Interface, error) {
+	if c.dynamic != nil {
+		return c.dynamic, nil
+	}
+	return dynamic.NewForConfig(c.RestConfig)
+}
+
+// kubeconfigClientConfig builds the rest config for opts from kubeconfig,
+// returning it with the context and namespace it resolves to
+func kubeconfigClientConfig(opts ClientOptions) (*rest.Config, string, string, error) {
+	loadingRules, err := loadingRules()
+	if err != nil {
+		return nil, "", "", output.WithCode(output.ErrCluster, err)
+	}
 	configOverrides := &clientcmd.ConfigOverrides{}
 
 	if opts.Context != "" {
@@ -40,7 +172,7 @@ func NewClient(opts ClientOptions) (*Client, error) {
 	// Get the raw config to determine context and namespace
 	rawConfig, err := kubeConfig.RawConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		return nil, "", "", output.WithCode(output.ErrCluster, fmt.Errorf("failed to load kubeconfig: %w", err))
 	}
 
 	// Determine the actual context being used
@@ -48,6 +180,9 @@ func NewClient(opts ClientOptions) (*Client, error) {
 	if context == "" {
 		context = rawConfig.CurrentContext
 	}
+	if err := checkExecPlugin(&rawConfig, context); err != nil {
+		return nil, "", "", output.WithCode(output.ErrCluster, err)
+	}
 
 	// Determine namespace
 	namespace := opts.Namespace
@@ -63,53 +198,27 @@ func NewClient(opts ClientOptions) (*Client, error) {
 	// Build rest config
 	restConfig, err := kubeConfig.ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build rest config: %w", err)
-	}
-
-	// Create clientset
-	clientset, err := kubernetes.NewForConfig(restConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
-	}
-
-	// Get server version
-	serverVersion := "unknown"
-	if version, err := clientset.Discovery().ServerVersion(); err == nil {
-		serverVersion = version.GitVersion
+		return nil, "", "", output.WithCode(output.ErrCluster, fmt.Errorf("failed to build rest config: %w", err))
 	}
-
-	return &Client{
-		Clientset:     clientset,
-		RestConfig:    restConfig,
-		Context:       context,
-		Namespace:     namespace,
-		ServerVersion: serverVersion,
-	}, nil
+	return restConfig, context, namespace, nil
 }
 
-// DynamicClient creates a dynamic client for CRD operations
-func (c *Client) DynamicClient() (dynamic.Interface, error) {
-	return dynamic.NewForConfig(c.RestConfig)
-}
-
-// KubeconfigPath returns the path to the kubeconfig file
-func KubeconfigPath() string {
-	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
-		return kubeconfig
+// inClusterConfig builds the rest config for opts from the service account
+// of the pod kbox runs in
+func inClusterConfig(opts ClientOptions) (*rest.Config, string, string, error) {
+	if opts.Context != "" {
+		return nil, "", "", output.WithCode(output.ErrCluster, fmt.Errorf("no kubeconfig to find context %q in: kbox is running in a pod, with its service account\n  → Drop --context, or pass --kubeconfig", opts.Context))
 	}
-	home, err := os.UserHomeDir()
+	restConfig, err := rest.InClusterConfig()
 	if err != nil {
-		return ""
+		return nil, "", "", output.WithCode(output.ErrCluster, fmt.Errorf("failed to use the pod's service account: %w\n  → Mount a kubeconfig and pass --kubeconfig, or set automountServiceAccountToken", err))
 	}
-	return filepath.Join(home, ".kube", "config")
-}
-
-// HasKubeconfig checks if a kubeconfig file exists
-func HasKubeconfig() bool {
-	path := KubeconfigPath()
-	if path == "" {
-		return false
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+		if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			namespace = strings.TrimSpace(string(data))
+		}
 	}
-	_, err := os.Stat(path)
-	return err == nil
+	return restConfig, InClusterContext, namespace, nil
 }
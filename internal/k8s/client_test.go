@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+func TestNoCluster(t *testing.T) {
+	SetNoCluster(true)
+	defer SetNoCluster(false)
+
+	// A kubeconfig that would otherwise be read must not be
+	t.Setenv("KUBECONFIG", "/nonexistent/kubeconfig")
+	_, err := NewClient(ClientOptions{Context: "prod"})
+	if !errors.Is(err, ErrNoCluster) {
+		t.Fatalf("expected ErrNoCluster, got %v", err)
+	}
+	if output.CodeOf(err) != output.ErrCluster {
+		t.Errorf("expected a cluster error code, got %q", output.CodeOf(err))
+	}
+	if _, err := LoadRawConfig(); !errors.Is(err, ErrNoCluster) {
+		t.Errorf("expected LoadRawConfig to refuse, got %v", err)
+	}
+}
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: %[1]s
+contexts:
+- name: %[1]s
+  context: {cluster: %[1]s, user: %[1]s, namespace: %[2]s}
+clusters:
+- name: %[1]s
+  cluster: {server: "https://%[1]s.example.com:6443"}
+users:
+- name: %[1]s
+  user: %[3]s
+`
+
+func writeKubeconfig(t *testing.T, name, namespace, user string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testKubeconfig, name, namespace, user)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKubeconfigFlagMergesFiles(t *testing.T) {
+	dev := writeKubeconfig(t, "dev", "team-a", "{token: abc}")
+	prod := writeKubeconfig(t, "prod", "payments", "{token: def}")
+	t.Setenv("KUBECONFIG", "/nonexistent/kubeconfig")
+	SetKubeconfig([]string{dev + string(os.PathListSeparator) + prod})
+	defer SetKubeconfig(nil)
+
+	// The first file's current context wins; the second's contexts are there too
+	restConfig, context, namespace, err := kubeconfigClientConfig(ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if context != "dev" || namespace != "team-a" || restConfig.Host != "https://dev.example.com:6443" {
+		t.Errorf("expected the dev context, got %s/%s at %s", context, namespace, restConfig.Host)
+	}
+	restConfig, _, namespace, err = kubeconfigClientConfig(ClientOptions{Context: "prod"})
+	if err != nil || namespace != "payments" || restConfig.Host != "https://prod.example.com:6443" {
+		t.Errorf("expected the prod context from the second file, got %v (%v)", restConfig, err)
+	}
+
+	SetKubeconfig([]string{dev, filepath.Join(t.TempDir(), "missing")})
+	if _, _, _, err := kubeconfigClientConfig(ClientOptions{}); err == nil || !strings.Contains(err.Error(), "--kubeconfig") {
+		t.Errorf("expected an error for a missing --kubeconfig file, got %v", err)
+	}
+}
+
+func TestMissingExecPlugin(t *testing.T) {
+	path := writeKubeconfig(t, "gke", "default", "{exec: {apiVersion: client.authentication.k8s.io/v1beta1, command: gke-gcloud-auth-plugin}}")
+	t.Setenv("PATH", t.TempDir())
+	SetKubeconfig([]string{path})
+	defer SetKubeconfig(nil)
+
+	_, _, _, err := kubeconfigClientConfig(ClientOptions{})
+	if err == nil || !strings.Contains(err.Error(), "gcloud components install gke-gcloud-auth-plugin") {
+		t.Errorf("expected an install hint for the missing plugin, got %v", err)
+	}
+}
+
+func TestInCluster(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	if !InCluster() {
+		t.Error("expected a pod without kubeconfig to use its service account")
+	}
+	if _, _, _, err := inClusterConfig(ClientOptions{Context: "prod"}); err == nil {
+		t.Error("expected --context to be refused in a pod without kubeconfig")
+	}
+
+	t.Setenv("KUBECONFIG", writeKubeconfig(t, "dev", "default", "{token: abc}"))
+	if InCluster() {
+		t.Error("expected a kubeconfig to win over the service account")
+	}
+}
+
+func TestImpersonation(t *testing.T) {
+	defer SetImpersonation("", nil)
+
+	SetImpersonation("system:serviceaccount:ci:deployer", []string{"team-x"})
+	restConfig := &rest.Config{}
+	if err := configure(restConfig); err != nil {
+		t.Fatal(err)
+	}
+	if restConfig.Impersonate.UserName != "system:serviceaccount:ci:deployer" || len(restConfig.Impersonate.Groups) != 1 {
+		t.Errorf("expected impersonation to be set, got %+v", restConfig.Impersonate)
+	}
+
+	SetImpersonation("", []string{"team-x"})
+	if err := configure(&rest.Config{}); output.CodeOf(err) != output.ErrConfig {
+		t.Errorf("expected --as-group without --as to be a config error, got %v", err)
+	}
+}
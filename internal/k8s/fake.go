@@ -0,0 +1,608 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// FakeClusterContext is the context name clients of the fake cluster report
+const FakeClusterContext = "kbox-fake"
+
+// fakeNodeName is the one node of the fake cluster, which runs every pod
+const fakeNodeName = "kbox-fake-node"
+
+// fakeFieldManager owns the fields the fake cluster's controllers set
+const fakeFieldManager = "kbox-fake-cluster"
+
+// fakeResource is an API resource the fake cluster serves
+type fakeResource struct {
+	gvr        schema.GroupVersionResource
+	kind       string
+	namespaced bool
+}
+
+func (r fakeResource) gvk() schema.GroupVersionKind {
+	return r.gvr.GroupVersion().WithKind(r.kind)
+}
+
+// fakeResources are the resources the fake cluster serves and saves: those
+// kbox deploys, and those its controllers create
+var fakeResources = []fakeResource{
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, kind: "Namespace"},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, kind: "Node"},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, kind: "Pod", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "services"}, kind: "Service", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, kind: "ConfigMap", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, kind: "Secret", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, kind: "ServiceAccount", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, kind: "PersistentVolumeClaim", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "events"}, kind: "Event", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "resourcequotas"}, kind: "ResourceQuota", namespaced: true},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "limitranges"}, kind: "LimitRange", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, kind: "Deployment", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, kind: "StatefulSet", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, kind: "ReplicaSet", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, kind: "DaemonSet", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, kind: "Job", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, kind: "CronJob", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, kind: "HorizontalPodAutoscaler", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}, kind: "PodDisruptionBudget", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, kind: "Ingress", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, kind: "NetworkPolicy", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, kind: "Role", namespaced: true},
+	{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, kind: "RoleBinding", namespaced: true},
+}
+
+// fakeResourceFor returns the fake cluster's resource gvr, if it serves it
+func fakeResourceFor(gvr schema.GroupVersionResource) (fakeResource, bool) {
+	for _, r := range fakeResources {
+		if r.gvr == gvr {
+			return r, true
+		}
+	}
+	return fakeResource{}, false
+}
+
+// FakeCluster is an in-memory cluster for tutorials, demos and tests: a fake
+// clientset whose controllers roll out Deployments, StatefulSets and Jobs
+// the way a real cluster's would, one pod at a time, without running any
+// containers. Exec, attach and port-forwards fail, and pod logs are a
+// placeholder.
+type FakeCluster struct {
+	clientset *fake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+
+	// path is the file the cluster is saved to on Close; empty keeps it in
+	// memory only
+	path string
+
+	// step is how often the controllers make progress
+	step time.Duration
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewFakeCluster starts an empty in-memory cluster with one ready node and
+// the default namespace
+func NewFakeCluster() *FakeCluster {
+	return newFakeCluster(nil, "")
+}
+
+// DefaultFakeClusterPath returns where kbox --fake-cluster keeps the fake
+// cluster between commands: ~/.kbox/fake-cluster.json
+func DefaultFakeClusterPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".kbox", "fake-cluster.json"), nil
+}
+
+// LoadFakeCluster starts the fake cluster saved at path, or an empty one if
+// there is none yet, and saves it back there on Close. Rollouts a previous
+// command left running are completed first, as if the time between the
+// commands had passed.
+func LoadFakeCluster(path string) (*FakeCluster, error) {
+	objects, err := readFakeCluster(path)
+	if err != nil {
+		return nil, err
+	}
+	c := newFakeCluster(objects, path)
+	c.settle()
+	return c, nil
+}
+
+func newFakeCluster(objects []runtime.Object, path string) *FakeCluster {
+	cs := fake.NewClientset(objects...)
+	c := &FakeCluster{
+		clientset: cs,
+		path:      path,
+		step:      400 * time.Millisecond,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	c.seed()
+
+	disco := cs.Discovery().(*fakediscovery.FakeDiscovery)
+	disco.Resources = fakeAPIResources()
+	disco.FakedServerVersion = &version.Info{Major: "1", Minor: "35", GitVersion: "v1.35.0+kbox-fake", Platform: "linux/amd64"}
+
+	// Reactors run in reverse order of being prepended: read-only mode
+	// first, then access reviews, then object metadata
+	cs.PrependReactor("*", "*", c.reactMetadata)
+	cs.PrependReactor("create", "selfsubjectaccessreviews", reactAccessReview)
+	cs.PrependReactor("*", "*", reactReadOnly)
+
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, r := range fakeResources {
+		listKinds[r.gvr] = r.kind + "List"
+	}
+	c.dynamic = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	c.dynamic.PrependReactor("*", "*", c.reactDynamic)
+
+	go c.run()
+	return c
+}
+
+// seed adds the node and default namespace every cluster has
+func (c *FakeCluster) seed() {
+	tracker := c.clientset.Tracker()
+	now := metav1.Now()
+	if _, err := tracker.Get(fakeGVR("namespaces"), "", "default"); apierrors.IsNotFound(err) {
+		_ = tracker.Add(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", UID: uuid.NewUUID(), CreationTimestamp: now},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		})
+	}
+	if _, err := tracker.Get(fakeGVR("nodes"), "", fakeNodeName); apierrors.IsNotFound(err) {
+		capacity := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}
+		_ = tracker.Add(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fakeNodeName,
+				UID:               uuid.NewUUID(),
+				CreationTimestamp: now,
+				Labels:            map[string]string{"kubernetes.io/hostname": fakeNodeName, "kubernetes.io/os": "linux"},
+			},
+			Status: corev1.NodeStatus{
+				Capacity:    capacity,
+				Allocatable: capacity,
+				Conditions: []corev1.NodeCondition{{
+					Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady",
+					LastHeartbeatTime: now, LastTransitionTime: now,
+				}},
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+				NodeInfo:  corev1.NodeSystemInfo{KubeletVersion: "v1.35.0+kbox-fake", OperatingSystem: "linux", Architecture: "amd64"},
+			},
+		})
+	}
+}
+
+// fakeGVR returns the GroupVersionResource of a resource the fake cluster serves
+func fakeGVR(resource string) schema.GroupVersionResource {
+	for _, r := range fakeResources {
+		if r.gvr.Resource == resource {
+			return r.gvr
+		}
+	}
+	panic("fake cluster doesn't serve " + resource)
+}
+
+// fakeAPIResources lists the fake cluster's resources for discovery
+func fakeAPIResources() []*metav1.APIResourceList {
+	verbs := metav1.Verbs{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"}
+	byGroupVersion := make(map[string]*metav1.APIResourceList)
+	var lists []*metav1.APIResourceList
+	for _, r := range fakeResources {
+		gv := r.gvr.GroupVersion().String()
+		list, ok := byGroupVersion[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			byGroupVersion[gv] = list
+			lists = append(lists, list)
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name: r.gvr.Resource, Kind: r.kind, Namespaced: r.namespaced, Verbs: verbs,
+		})
+	}
+	return append(lists, &metav1.APIResourceList{
+		GroupVersion: "authorization.k8s.io/v1",
+		APIResources: []metav1.APIResource{{Name: "selfsubjectaccessreviews", Kind: "SelfSubjectAccessReview", Verbs: metav1.Verbs{"create"}}},
+	})
+}
+
+// reactReadOnly refuses writes in read-only mode, as readOnlyTransport
+// does for real clusters
+func reactReadOnly(action k8stesting.Action) (bool, runtime.Object, error) {
+	if !readOnly {
+		return false, nil, nil
+	}
+	switch action.GetVerb() {
+	case "get", "list", "watch":
+		return false, nil, nil
+	}
+	if action.GetVerb() == "create" && action.GetResource().Resource == "selfsubjectaccessreviews" {
+		return false, nil, nil
+	}
+	return true, nil, output.WithCode(output.ErrForbidden, fmt.Errorf("%w (%s %s)", ErrReadOnly, action.GetVerb(), action.GetResource().Resource))
+}
+
+// reactAccessReview allows everything: the fake cluster has no RBAC
+func reactAccessReview(action k8stesting.Action) (bool, runtime.Object, error) {
+	review := action.(k8stesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview).DeepCopy()
+	review.Status = authv1.SubjectAccessReviewStatus{Allowed: true, Reason: "the fake cluster has no RBAC"}
+	return true, review, nil
+}
+
+// reactMetadata does what the API server does to the metadata of objects it
+// stores: generate names, and set the UID and creation time of new objects
+// and bump their generation whenever their spec changes
+func (c *FakeCluster) reactMetadata(action k8stesting.Action) (bool, runtime.Object, error) {
+	switch action.GetVerb() {
+	case "create", "update", "patch":
+	default:
+		return false, nil, nil
+	}
+	if action.GetSubresource() != "" {
+		return false, nil, nil
+	}
+	if _, ok := fakeResourceFor(action.GetResource()); !ok {
+		return false, nil, nil
+	}
+	tracker := c.clientset.Tracker()
+	gvr, ns := action.GetResource(), action.GetNamespace()
+
+	var name string
+	switch a := action.(type) {
+	case k8stesting.CreateActionImpl:
+		a.Object = a.Object.DeepCopyObject()
+		obj, err := meta.Accessor(a.Object)
+		if err != nil {
+			return true, nil, err
+		}
+		if obj.GetName() == "" && obj.GetGenerateName() != "" {
+			obj.SetName(obj.GetGenerateName() + rand.String(5))
+		}
+		name = obj.GetName()
+		action = a
+	case k8stesting.UpdateActionImpl:
+		obj, err := meta.Accessor(a.GetObject())
+		if err != nil {
+			return true, nil, err
+		}
+		name = obj.GetName()
+	case k8stesting.PatchActionImpl:
+		name = a.GetName()
+	}
+
+	old, err := tracker.Get(gvr, ns, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return true, nil, err
+	}
+	_, obj, err := k8stesting.ObjectReaction(tracker)(action)
+	if err != nil || obj == nil {
+		return true, obj, err
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return true, nil, err
+	}
+	if old == nil {
+		m.SetUID(uuid.NewUUID())
+		m.SetCreationTimestamp(metav1.Now())
+		m.SetGeneration(1)
+	} else {
+		oldMeta, _ := meta.Accessor(old)
+		m.SetUID(oldMeta.GetUID())
+		m.SetCreationTimestamp(oldMeta.GetCreationTimestamp())
+		m.SetGeneration(oldMeta.GetGeneration())
+		if !sameSpec(old, obj) {
+			m.SetGeneration(oldMeta.GetGeneration() + 1)
+		}
+	}
+	if err := tracker.Update(gvr, obj, ns, metav1.UpdateOptions{FieldManager: fakeFieldManager}); err != nil {
+		return true, nil, err
+	}
+	obj, err = tracker.Get(gvr, ns, name)
+	return true, obj, err
+}
+
+// sameSpec reports whether two versions of an object have the same spec
+func sameSpec(a, b runtime.Object) bool {
+	ua, errA := runtime.DefaultUnstructuredConverter.ToUnstructured(a)
+	ub, errB := runtime.DefaultUnstructuredConverter.ToUnstructured(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return apiequality.Semantic.DeepEqual(ua["spec"], ub["spec"])
+}
+
+// reactDynamic serves the dynamic client from the typed clientset, so both
+// see the same objects. Resources the fake cluster doesn't serve, such as
+// the CRDs of operators, are not found, as on a cluster without them.
+func (c *FakeCluster) reactDynamic(action k8stesting.Action) (bool, runtime.Object, error) {
+	r, ok := fakeResourceFor(action.GetResource())
+	if !ok {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	}
+
+	switch a := action.(type) {
+	case k8stesting.CreateActionImpl:
+		typed, err := fromUnstructured(a.GetObject(), r.gvk())
+		if err != nil {
+			return true, nil, err
+		}
+		a.Object = typed
+		action = a
+	case k8stesting.UpdateActionImpl:
+		typed, err := fromUnstructured(a.GetObject(), r.gvk())
+		if err != nil {
+			return true, nil, err
+		}
+		a.Object = typed
+		action = a
+	}
+
+	obj, err := c.clientset.Invokes(action, nil)
+	if err != nil || obj == nil {
+		return true, nil, err
+	}
+	if meta.IsListType(obj) {
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(r.gvr.GroupVersion().WithKind(r.kind + "List"))
+		for _, item := range items {
+			u, err := toUnstructured(item, r.gvk())
+			if err != nil {
+				return true, nil, err
+			}
+			list.Items = append(list.Items, *u)
+		}
+		return true, list, nil
+	}
+	u, err := toUnstructured(obj, r.gvk())
+	return true, u, err
+}
+
+// toUnstructured converts a typed object to an unstructured one of kind gvk
+func toUnstructured(obj runtime.Object, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+// fromUnstructured converts an unstructured object to the typed one of kind gvk
+func fromUnstructured(obj runtime.Object, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil
+	}
+	typed, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+		return nil, err
+	}
+	typed.GetObjectKind().SetGroupVersionKind(gvk)
+	return typed, nil
+}
+
+// client returns a Client of the fake cluster. Its RestConfig points
+// nowhere: exec, attach and port-forwards fail with an error saying why.
+func (c *FakeCluster) client(opts ClientOptions) *Client {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	restConfig := &rest.Config{Host: "https://kbox-fake-cluster.invalid"}
+	restConfig.Wrap(func(http.RoundTripper) http.RoundTripper { return fakeTransport{} })
+
+	// The fake clientset has no REST client, which exec and copying files
+	// build their requests with
+	coreConfig := rest.CopyConfig(restConfig)
+	coreConfig.APIPath = "/api"
+	coreConfig.GroupVersion = &corev1.SchemeGroupVersion
+	coreConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	var clientset kubernetes.Interface = c.clientset
+	if restClient, err := rest.RESTClientFor(coreConfig); err == nil {
+		clientset = fakeClientset{Clientset: c.clientset, core: fakeCoreV1{CoreV1Interface: c.clientset.CoreV1(), restClient: restClient}}
+	}
+
+	serverVersion := "unknown"
+	if v, err := c.clientset.Discovery().ServerVersion(); err == nil {
+		serverVersion = v.GitVersion
+	}
+	return &Client{
+		Clientset:     clientset,
+		RestConfig:    restConfig,
+		Context:       FakeClusterContext,
+		Namespace:     namespace,
+		ServerVersion: serverVersion,
+		dynamic:       c.dynamic,
+	}
+}
+
+// Clientset returns the fake cluster's clientset
+func (c *FakeCluster) Clientset() *fake.Clientset {
+	return c.clientset
+}
+
+// Dynamic returns the fake cluster's dynamic client, which shares the
+// clientset's objects
+func (c *FakeCluster) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+// fakeClientset is the fake clientset with a CoreV1 REST client
+type fakeClientset struct {
+	*fake.Clientset
+	core fakeCoreV1
+}
+
+func (c fakeClientset) CoreV1() corev1client.CoreV1Interface {
+	return c.core
+}
+
+type fakeCoreV1 struct {
+	corev1client.CoreV1Interface
+	restClient rest.Interface
+}
+
+func (c fakeCoreV1) RESTClient() rest.Interface {
+	return c.restClient
+}
+
+// fakeTransport fails the requests that can't go through the fake
+// clientset: those streaming to and from containers
+type fakeTransport struct{}
+
+func (fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, output.WithCode(output.ErrCluster, fmt.Errorf("the fake cluster runs no containers to connect to (%s %s)\n  → Drop --fake-cluster (or unset KBOX_FAKE_CLUSTER) to exec, copy files or port-forward", req.Method, req.URL.Path))
+}
+
+// Close stops the fake cluster's controllers and, if it was loaded from a
+// file, saves it there with its rollouts completed
+func (c *FakeCluster) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+		if c.path != "" {
+			c.settle()
+			c.closeErr = c.save(c.path)
+		}
+	})
+	return c.closeErr
+}
+
+// fakeClusterFile is how the fake cluster is saved: a kubectl List of its
+// objects
+type fakeClusterFile struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+// save writes every object of the cluster to path
+func (c *FakeCluster) save(path string) error {
+	c.clientset.Lock()
+	defer c.clientset.Unlock()
+
+	file := fakeClusterFile{APIVersion: "v1", Kind: "List", Items: []json.RawMessage{}}
+	tracker := c.clientset.Tracker()
+	for _, r := range fakeResources {
+		list, err := tracker.List(r.gvr, r.gvk(), "")
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", r.gvr.Resource, err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			item = item.DeepCopyObject()
+			item.GetObjectKind().SetGroupVersionKind(r.gvk())
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			file.Items = append(file.Items, data)
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to save fake cluster: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save fake cluster: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readFakeCluster reads the objects of the fake cluster saved at path; none
+// if there is no file yet
+func readFakeCluster(path string) ([]runtime.Object, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake cluster: %w", err)
+	}
+	var file fakeClusterFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read fake cluster %s: %w\n  → Delete it to start over with an empty fake cluster", path, err)
+	}
+	decoder := scheme.Codecs.UniversalDeserializer()
+	objects := make([]runtime.Object, 0, len(file.Items))
+	for _, item := range file.Items {
+		obj, _, err := decoder.Decode(item, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fake cluster %s: %w\n  → Delete it to start over with an empty fake cluster", path, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// fakeCluster is the cluster NewClient connects to in fake-cluster mode
+var fakeCluster *FakeCluster
+
+// SetFakeCluster makes the clients NewClient creates from now on clients of
+// c instead of a real cluster; nil turns fake-cluster mode off
+func SetFakeCluster(c *FakeCluster) {
+	fakeCluster = c
+}
+
+// ActiveFakeCluster returns the cluster of fake-cluster mode, or nil
+func ActiveFakeCluster() *FakeCluster {
+	return fakeCluster
+}
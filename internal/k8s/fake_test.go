@@ -0,0 +1,244 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func fakeDeployment(name, image string, replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: image}}},
+			},
+		},
+	}
+}
+
+func TestFakeCluster_Deployment(t *testing.T) {
+	ctx := context.Background()
+	cluster := NewFakeCluster()
+	defer cluster.Close()
+	deployments := cluster.Clientset().AppsV1().Deployments("default")
+
+	if _, err := deployments.Create(ctx, fakeDeployment("myapp", "myapp:v1", 3), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cluster.settle()
+
+	dep, _ := deployments.Get(ctx, "myapp", metav1.GetOptions{})
+	if dep.Generation != 1 || dep.Status.ObservedGeneration != 1 || dep.Status.ReadyReplicas != 3 || dep.Status.UpdatedReplicas != 3 {
+		t.Fatalf("expected 3 ready replicas of generation 1, got generation %d, status %+v", dep.Generation, dep.Status)
+	}
+	pods, _ := cluster.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{LabelSelector: "app=myapp"})
+	if len(pods.Items) != 3 {
+		t.Fatalf("expected 3 pods, got %d", len(pods.Items))
+	}
+	status := pods.Items[0].Status
+	if status.Phase != corev1.PodRunning || !status.ContainerStatuses[0].Ready || !strings.HasPrefix(status.ContainerStatuses[0].ImageID, "myapp:v1@sha256:") {
+		t.Errorf("expected a running pod of myapp:v1, got %+v", status)
+	}
+
+	// A new template rolls out to a new ReplicaSet, replacing every pod
+	dep.Spec.Template.Spec.Containers[0].Image = "myapp:v2"
+	if _, err := deployments.Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	dep, _ = deployments.Get(ctx, "myapp", metav1.GetOptions{})
+	if dep.Generation != 2 || dep.Status.ObservedGeneration != 1 {
+		t.Fatalf("expected generation 2 not observed yet, got %d/%d", dep.Generation, dep.Status.ObservedGeneration)
+	}
+	cluster.settle()
+	pods, _ = cluster.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{LabelSelector: "app=myapp"})
+	if len(pods.Items) != 3 {
+		t.Fatalf("expected 3 pods after the rollout, got %d", len(pods.Items))
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.Containers[0].Image != "myapp:v2" {
+			t.Errorf("expected only myapp:v2 pods, got %s running %s", pod.Name, pod.Spec.Containers[0].Image)
+		}
+	}
+	replicaSets, _ := cluster.Clientset().AppsV1().ReplicaSets("default").List(ctx, metav1.ListOptions{})
+	if len(replicaSets.Items) != 2 {
+		t.Errorf("expected the old ReplicaSet kept at 0, got %d ReplicaSets", len(replicaSets.Items))
+	}
+
+	// Deleting the Deployment deletes its ReplicaSets and pods
+	if err := deployments.Delete(ctx, "myapp", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cluster.settle()
+	pods, _ = cluster.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	replicaSets, _ = cluster.Clientset().AppsV1().ReplicaSets("default").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 || len(replicaSets.Items) != 0 {
+		t.Errorf("expected everything garbage collected, got %d pods, %d ReplicaSets", len(pods.Items), len(replicaSets.Items))
+	}
+}
+
+func TestFakeCluster_StatefulSetAndJob(t *testing.T) {
+	ctx := context.Background()
+	cluster := NewFakeCluster()
+	defer cluster.Close()
+
+	replicas := int32(2)
+	labels := map[string]string{"app": "db"}
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db", Image: "postgres:16"}}},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+				},
+			}},
+		},
+	}
+	if _, err := cluster.Clientset().AppsV1().StatefulSets("default").Create(ctx, ss, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers:    []corev1.Container{{Name: "migrate", Image: "myapp:v1"}},
+		}}},
+	}
+	if _, err := cluster.Clientset().BatchV1().Jobs("default").Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cluster.settle()
+
+	ss, _ = cluster.Clientset().AppsV1().StatefulSets("default").Get(ctx, "db", metav1.GetOptions{})
+	if ss.Status.ReadyReplicas != 2 || ss.Status.CurrentRevision != ss.Status.UpdateRevision {
+		t.Errorf("expected 2 ready replicas of the current revision, got %+v", ss.Status)
+	}
+	for _, name := range []string{"db-0", "db-1"} {
+		if _, err := cluster.Clientset().CoreV1().Pods("default").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("expected pod %s: %v", name, err)
+		}
+	}
+	pvc, err := cluster.Clientset().CoreV1().PersistentVolumeClaims("default").Get(ctx, "data-db-0", metav1.GetOptions{})
+	if err != nil || pvc.Status.Phase != corev1.ClaimBound {
+		t.Errorf("expected claim data-db-0 bound, got %v (%v)", pvc, err)
+	}
+
+	job, _ = cluster.Clientset().BatchV1().Jobs("default").Get(ctx, "migrate", metav1.GetOptions{})
+	if !jobFinished(job) || job.Status.Succeeded != 1 {
+		t.Errorf("expected the job complete, got %+v", job.Status)
+	}
+}
+
+func TestFakeCluster_Client(t *testing.T) {
+	ctx := context.Background()
+	cluster := NewFakeCluster()
+	defer cluster.Close()
+	SetFakeCluster(cluster)
+	defer SetFakeCluster(nil)
+
+	client, err := NewClient(ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Context != FakeClusterContext || client.Namespace != "default" || !strings.Contains(client.ServerVersion, "kbox-fake") {
+		t.Errorf("unexpected client %+v", client)
+	}
+	if allowed, err := client.CanI(ctx, "default", Permission{Verb: "create", Resource: "deployments", Group: "apps"}); err != nil || !allowed {
+		t.Errorf("expected everything allowed, got %v (%v)", allowed, err)
+	}
+	for _, c := range Capabilities(client.Clientset.Discovery()) {
+		want := c.GroupVersion != "metrics.k8s.io/v1beta1" && c.GroupVersion != "monitoring.coreos.com/v1" && c.GroupVersion != "cert-manager.io/v1"
+		if c.Available != want {
+			t.Errorf("expected %s available=%v", c.GroupVersion, want)
+		}
+	}
+
+	// The dynamic client sees the typed clientset's objects
+	if _, err := client.Clientset.AppsV1().Deployments("default").Create(ctx, fakeDeployment("myapp", "myapp:v1", 1), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	dyn, err := client.DynamicClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	list, err := dyn.Resource(deployments).Namespace("default").List(ctx, metav1.ListOptions{LabelSelector: "app!=other"})
+	if err != nil || len(list.Items) != 1 || list.Items[0].GetName() != "myapp" {
+		t.Fatalf("expected myapp listed, got %v (%v)", list, err)
+	}
+	if err := dyn.Resource(deployments).Namespace("default").Delete(ctx, "myapp", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Clientset.AppsV1().Deployments("default").Get(ctx, "myapp", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected myapp deleted, got %v", err)
+	}
+	monitors := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+	if _, err := dyn.Resource(monitors).Namespace("default").Get(ctx, "myapp", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected CRDs not found, got %v", err)
+	}
+
+	// Read-only mode refuses writes, as it does on real clusters
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "x"}}, metav1.CreateOptions{})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		t.Errorf("expected reads allowed, got %v", err)
+	}
+}
+
+func TestFakeCluster_SaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fake-cluster.json")
+
+	cluster, err := LoadFakeCluster(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cluster.Clientset().AppsV1().Deployments("default").Create(ctx, fakeDeployment("myapp", "myapp:v1", 2), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err = LoadFakeCluster(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	dep, err := cluster.Clientset().AppsV1().Deployments("default").Get(ctx, "myapp", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Status.ReadyReplicas != 2 || dep.UID == "" {
+		t.Errorf("expected the saved rollout complete, got %+v", dep.Status)
+	}
+	if _, err := cluster.Clientset().CoreV1().Nodes().Get(ctx, fakeNodeName, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the node kept: %v", err)
+	}
+}
@@ -0,0 +1,845 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// run runs the fake cluster's controllers every step until Close
+func (c *FakeCluster) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.step)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.reconcile()
+		}
+	}
+}
+
+// settle runs the controllers until there's nothing left for them to do
+func (c *FakeCluster) settle() {
+	for i := 0; i < 1000 && c.reconcile(); i++ {
+	}
+}
+
+// reconcile runs each controller once, reporting whether any changed the
+// cluster. The clientset is locked meanwhile, so the controllers' reads and
+// writes don't interleave with requests.
+func (c *FakeCluster) reconcile() bool {
+	c.clientset.Lock()
+	defer c.clientset.Unlock()
+
+	s := &fakeControllers{tracker: c.clientset.Tracker(), now: metav1.Now()}
+	changed := false
+	// The kubelet goes first, so pods created this step stay Pending until
+	// the next one
+	for _, controller := range []func() bool{s.kubelet, s.deployments, s.statefulSets, s.jobs, s.services, s.claims, s.collectGarbage} {
+		if controller() {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// fakeControllers are the controllers of the fake cluster, and its kubelet,
+// acting directly on its object tracker
+type fakeControllers struct {
+	tracker k8stesting.ObjectTracker
+	now     metav1.Time
+}
+
+// fakeList lists every object of resource, typed as T
+func fakeList[T runtime.Object](s *fakeControllers, resource string) []T {
+	list, err := s.tracker.List(fakeGVR(resource), fakeResourceKind(resource), "")
+	if err != nil {
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if typed, ok := item.(T); ok {
+			result = append(result, typed)
+		}
+	}
+	return result
+}
+
+// fakeResourceKind returns the kind of a resource the fake cluster serves
+func fakeResourceKind(resource string) schema.GroupVersionKind {
+	r, _ := fakeResourceFor(fakeGVR(resource))
+	return r.gvk()
+}
+
+func (s *fakeControllers) create(resource string, obj runtime.Object) bool {
+	m, _ := meta.Accessor(obj)
+	return s.tracker.Create(fakeGVR(resource), obj, m.GetNamespace(), metav1.CreateOptions{FieldManager: fakeFieldManager}) == nil
+}
+
+func (s *fakeControllers) update(resource string, obj runtime.Object) bool {
+	m, _ := meta.Accessor(obj)
+	return s.tracker.Update(fakeGVR(resource), obj, m.GetNamespace(), metav1.UpdateOptions{FieldManager: fakeFieldManager}) == nil
+}
+
+func (s *fakeControllers) delete(resource, namespace, name string) bool {
+	return s.tracker.Delete(fakeGVR(resource), namespace, name) == nil
+}
+
+// kubelet starts the containers of pending pods and the ephemeral
+// containers added to running ones, and completes the pods of run-once
+// containers once they're running
+func (s *fakeControllers) kubelet() bool {
+	changed := false
+	for _, pod := range fakeList[*corev1.Pod](s, "pods") {
+		switch pod.Status.Phase {
+		case corev1.PodPending, "":
+			s.startPod(pod)
+		case corev1.PodRunning:
+			if n := len(pod.Status.EphemeralContainerStatuses); n < len(pod.Spec.EphemeralContainers) {
+				for _, c := range pod.Spec.EphemeralContainers[n:] {
+					pod.Status.EphemeralContainerStatuses = append(pod.Status.EphemeralContainerStatuses,
+						fakeContainerStatus(corev1.Container(c.EphemeralContainerCommon), s.now))
+				}
+			} else if pod.Spec.RestartPolicy == corev1.RestartPolicyAlways || pod.Spec.RestartPolicy == "" {
+				continue
+			} else {
+				s.completePod(pod)
+			}
+		default:
+			continue
+		}
+		if s.update("pods", pod) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// startPod marks pod running, with all its containers started and ready
+func (s *fakeControllers) startPod(pod *corev1.Pod) {
+	pod.Spec.NodeName = fakeNodeName
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.HostIP = "10.0.0.10"
+	pod.Status.PodIP = fakePodIP(pod.Namespace + "/" + pod.Name)
+	pod.Status.PodIPs = []corev1.PodIP{{IP: pod.Status.PodIP}}
+	pod.Status.StartTime = &s.now
+	pod.Status.Conditions = fakePodConditions(s.now, corev1.ConditionTrue, "")
+
+	pod.Status.InitContainerStatuses = nil
+	for _, c := range pod.Spec.InitContainers {
+		status := fakeContainerStatus(c, s.now)
+		if c.RestartPolicy == nil || *c.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+			status.Ready = false
+			status.Started = ptrTo(false)
+			status.State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Reason: "Completed", StartedAt: s.now, FinishedAt: s.now, ContainerID: status.ContainerID,
+			}}
+		}
+		pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, status)
+	}
+	pod.Status.ContainerStatuses = nil
+	for _, c := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, fakeContainerStatus(c, s.now))
+	}
+}
+
+// completePod marks pod succeeded, with all its containers exited with 0
+func (s *fakeControllers) completePod(pod *corev1.Pod) {
+	pod.Status.Phase = corev1.PodSucceeded
+	pod.Status.Conditions = fakePodConditions(s.now, corev1.ConditionFalse, "PodCompleted")
+	for i, status := range pod.Status.ContainerStatuses {
+		startedAt := s.now
+		if status.State.Running != nil {
+			startedAt = status.State.Running.StartedAt
+		}
+		status.Ready = false
+		status.Started = ptrTo(false)
+		status.State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			Reason: "Completed", StartedAt: startedAt, FinishedAt: s.now, ContainerID: status.ContainerID,
+		}}
+		pod.Status.ContainerStatuses[i] = status
+	}
+}
+
+// fakePodConditions returns the conditions of a scheduled, initialized pod
+// whose containers are ready (or not, with reason)
+func fakePodConditions(now metav1.Time, ready corev1.ConditionStatus, reason string) []corev1.PodCondition {
+	return []corev1.PodCondition{
+		{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		{Type: corev1.ContainersReady, Status: ready, Reason: reason, LastTransitionTime: now},
+		{Type: corev1.PodReady, Status: ready, Reason: reason, LastTransitionTime: now},
+	}
+}
+
+// fakeContainerStatus returns the status of c running since now
+func fakeContainerStatus(c corev1.Container, now metav1.Time) corev1.ContainerStatus {
+	digest := sha256.Sum256([]byte(c.Image))
+	imageID := c.Image + "@sha256:" + hex.EncodeToString(digest[:])
+	if strings.Contains(c.Image, "@") {
+		imageID = c.Image
+	}
+	return corev1.ContainerStatus{
+		Name:        c.Name,
+		Image:       c.Image,
+		ImageID:     imageID,
+		ContainerID: "containerd://" + hex.EncodeToString(digest[:16]) + rand.String(8),
+		Ready:       true,
+		Started:     ptrTo(true),
+		State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}},
+	}
+}
+
+// fakePodIP returns a pod IP for key in 10.244.0.0/16
+func fakePodIP(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	n := h.Sum32()
+	return fmt.Sprintf("10.244.%d.%d", n>>8%256, n%254+1)
+}
+
+// pendingPod returns a new pod of template, owned by owner and scheduled
+// but not started yet
+func (s *fakeControllers) pendingPod(namespace, name string, template *corev1.PodTemplateSpec, labels map[string]string, owner metav1.OwnerReference) *corev1.Pod {
+	podLabels := maps.Clone(template.Labels)
+	if podLabels == nil {
+		podLabels = make(map[string]string)
+	}
+	maps.Copy(podLabels, labels)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            podLabels,
+			Annotations:       maps.Clone(template.Annotations),
+			UID:               uuid.NewUUID(),
+			CreationTimestamp: s.now,
+			Generation:        1,
+			OwnerReferences:   []metav1.OwnerReference{owner},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	pod.Spec.NodeName = fakeNodeName
+	pod.Status.Phase = corev1.PodPending
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: s.now}}
+	for _, c := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:  c.Name,
+			Image: c.Image,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		})
+	}
+	return pod
+}
+
+// controllerRef returns the owner reference a controller sets on what it creates
+func controllerRef(owner metav1.Object, apiVersion, kind string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         apiVersion,
+		Kind:               kind,
+		Name:               owner.GetName(),
+		UID:                owner.GetUID(),
+		Controller:         ptrTo(true),
+		BlockOwnerDeletion: ptrTo(true),
+	}
+}
+
+// controlledBy reports whether obj's controller is owner
+func controlledBy(obj, owner metav1.Object) bool {
+	ref := metav1.GetControllerOf(obj)
+	return ref != nil && ref.UID == owner.GetUID()
+}
+
+// podReady reports whether pod is running and ready
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// templateHash hashes a pod template, like the pod-template-hash label of
+// ReplicaSets and the revisions of StatefulSets
+func templateHash(template *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(template)
+	h := fnv.New32a()
+	h.Write(data)
+	return rand.SafeEncodeString(strconv.FormatUint(uint64(h.Sum32()), 10))
+}
+
+// deployments rolls out each Deployment: it creates a ReplicaSet for its
+// current template, adds that ReplicaSet's pods one at a time, each once
+// the one before is ready, and removes an old pod for each new one ready
+func (s *fakeControllers) deployments() bool {
+	changed := false
+	replicaSets := fakeList[*appsv1.ReplicaSet](s, "replicasets")
+	pods := fakeList[*corev1.Pod](s, "pods")
+	for _, dep := range fakeList[*appsv1.Deployment](s, "deployments") {
+		if s.rollOutDeployment(dep, replicaSets, pods) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s *fakeControllers) rollOutDeployment(dep *appsv1.Deployment, allReplicaSets []*appsv1.ReplicaSet, allPods []*corev1.Pod) bool {
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	hash := templateHash(&dep.Spec.Template)
+
+	var current *appsv1.ReplicaSet
+	var replicaSets []*appsv1.ReplicaSet
+	revision := 0
+	for _, rs := range allReplicaSets {
+		if rs.Namespace != dep.Namespace || !controlledBy(rs, dep) {
+			continue
+		}
+		replicaSets = append(replicaSets, rs)
+		if n, _ := strconv.Atoi(rs.Annotations["deployment.kubernetes.io/revision"]); n > revision {
+			revision = n
+		}
+		if rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash {
+			current = rs
+		}
+	}
+
+	changed := false
+	if current == nil {
+		labels := maps.Clone(dep.Spec.Template.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[appsv1.DefaultDeploymentUniqueLabelKey] = hash
+		template := dep.Spec.Template.DeepCopy()
+		template.Labels = maps.Clone(labels)
+		selector := dep.Spec.Selector.DeepCopy()
+		if selector == nil {
+			selector = &metav1.LabelSelector{}
+		}
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = make(map[string]string)
+		}
+		selector.MatchLabels[appsv1.DefaultDeploymentUniqueLabelKey] = hash
+		current = &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              dep.Name + "-" + hash,
+				Namespace:         dep.Namespace,
+				Labels:            labels,
+				Annotations:       map[string]string{"deployment.kubernetes.io/revision": strconv.Itoa(revision + 1)},
+				UID:               uuid.NewUUID(),
+				CreationTimestamp: s.now,
+				Generation:        1,
+				OwnerReferences:   []metav1.OwnerReference{controllerRef(dep, "apps/v1", "Deployment")},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: &replicas, Selector: selector, Template: *template},
+		}
+		if !s.create("replicasets", current) {
+			return false
+		}
+		replicaSets = append(replicaSets, current)
+		changed = true
+	}
+
+	podsOf := make(map[string][]*corev1.Pod)
+	for _, pod := range allPods {
+		if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "ReplicaSet" && pod.Namespace == dep.Namespace {
+			podsOf[string(ref.UID)] = append(podsOf[string(ref.UID)], pod)
+		}
+	}
+	newPods := podsOf[string(current.UID)]
+	var oldPods []*corev1.Pod
+	for _, rs := range replicaSets {
+		if rs != current {
+			oldPods = append(oldPods, podsOf[string(rs.UID)]...)
+		}
+	}
+	newReady := 0
+	for _, pod := range newPods {
+		if podReady(pod) {
+			newReady++
+		}
+	}
+
+	// One step of a rolling update: scale the new ReplicaSet up by one pod
+	// once the previous one is ready, and retire an old pod for each new
+	// one ready
+	switch {
+	case int32(len(newPods)) > replicas:
+		last := newPods[len(newPods)-1]
+		if s.delete("pods", last.Namespace, last.Name) {
+			newPods = newPods[:len(newPods)-1]
+			changed = true
+		}
+	case newReady == len(newPods):
+		if len(oldPods) > 0 && int32(newReady+len(oldPods)) > replicas {
+			old := oldPods[0]
+			if s.delete("pods", old.Namespace, old.Name) {
+				oldPods = oldPods[1:]
+				changed = true
+			}
+		}
+		if int32(len(newPods)) < replicas {
+			pod := s.pendingPod(dep.Namespace, current.Name+"-"+rand.String(5), &current.Spec.Template,
+				nil, controllerRef(current, "apps/v1", "ReplicaSet"))
+			if s.create("pods", pod) {
+				newPods = append(newPods, pod)
+				changed = true
+			}
+		}
+	}
+
+	// ReplicaSet status
+	for _, rs := range replicaSets {
+		pods := podsOf[string(rs.UID)]
+		if rs == current {
+			pods = newPods
+		} else {
+			pods = slices.DeleteFunc(slices.Clone(pods), func(p *corev1.Pod) bool { return !slices.Contains(oldPods, p) })
+		}
+		want := rs.DeepCopy()
+		if rs == current {
+			want.Spec.Replicas = &replicas
+		} else {
+			want.Spec.Replicas = ptrTo(int32(len(pods)))
+		}
+		ready := int32(countReady(pods))
+		want.Status = appsv1.ReplicaSetStatus{
+			Replicas:             int32(len(pods)),
+			FullyLabeledReplicas: int32(len(pods)),
+			ReadyReplicas:        ready,
+			AvailableReplicas:    ready,
+			ObservedGeneration:   rs.Generation,
+		}
+		if !apiequality.Semantic.DeepEqual(rs.Spec, want.Spec) || !apiequality.Semantic.DeepEqual(rs.Status, want.Status) {
+			if s.update("replicasets", want) {
+				changed = true
+			}
+		}
+	}
+
+	// Deployment status
+	ready := int32(countReady(newPods) + countReady(oldPods))
+	total := int32(len(newPods) + len(oldPods))
+	done := int32(len(newPods)) == replicas && int32(newReady) == replicas && len(oldPods) == 0
+	want := dep.DeepCopy()
+	want.Status = appsv1.DeploymentStatus{
+		ObservedGeneration:  dep.Generation,
+		Replicas:            total,
+		UpdatedReplicas:     int32(len(newPods)),
+		ReadyReplicas:       ready,
+		AvailableReplicas:   ready,
+		UnavailableReplicas: max(0, replicas-ready),
+	}
+	available := fakeCondition(string(appsv1.DeploymentAvailable), ready >= replicas, "MinimumReplicasAvailable", "MinimumReplicasUnavailable",
+		"Deployment has minimum availability.", "Deployment does not have minimum availability.")
+	progressing := fakeCondition(string(appsv1.DeploymentProgressing), true, "ReplicaSetUpdated", "",
+		fmt.Sprintf("ReplicaSet %q is progressing.", current.Name), "")
+	if done {
+		progressing.Reason = "NewReplicaSetAvailable"
+		progressing.Message = fmt.Sprintf("ReplicaSet %q has successfully progressed.", current.Name)
+	}
+	for _, cond := range []metav1.Condition{available, progressing} {
+		want.Status.Conditions = append(want.Status.Conditions, appsv1.DeploymentCondition{
+			Type:               appsv1.DeploymentConditionType(cond.Type),
+			Status:             corev1.ConditionStatus(cond.Status),
+			Reason:             cond.Reason,
+			Message:            cond.Message,
+			LastUpdateTime:     s.now,
+			LastTransitionTime: s.now,
+		})
+	}
+	// Keep the times of conditions that haven't changed
+	for i, cond := range want.Status.Conditions {
+		for _, old := range dep.Status.Conditions {
+			if old.Type == cond.Type && old.Status == cond.Status && old.Reason == cond.Reason && old.Message == cond.Message {
+				want.Status.Conditions[i] = old
+			}
+		}
+	}
+	if !apiequality.Semantic.DeepEqual(dep.Status, want.Status) {
+		if s.update("deployments", want) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// fakeCondition returns a condition that is True with trueReason and
+// trueMessage when ok, else False with the others
+func fakeCondition(typ string, ok bool, trueReason, falseReason, trueMessage, falseMessage string) metav1.Condition {
+	if ok {
+		return metav1.Condition{Type: typ, Status: metav1.ConditionTrue, Reason: trueReason, Message: trueMessage}
+	}
+	return metav1.Condition{Type: typ, Status: metav1.ConditionFalse, Reason: falseReason, Message: falseMessage}
+}
+
+func countReady(pods []*corev1.Pod) int {
+	n := 0
+	for _, pod := range pods {
+		if podReady(pod) {
+			n++
+		}
+	}
+	return n
+}
+
+// statefulSets rolls out each StatefulSet: it creates its pods in order,
+// each once the one before is ready, with their volume claims, then
+// replaces pods of an old revision one at a time, highest ordinal first
+func (s *fakeControllers) statefulSets() bool {
+	changed := false
+	pods := fakeList[*corev1.Pod](s, "pods")
+	for _, ss := range fakeList[*appsv1.StatefulSet](s, "statefulsets") {
+		if s.rollOutStatefulSet(ss, pods) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s *fakeControllers) rollOutStatefulSet(ss *appsv1.StatefulSet, allPods []*corev1.Pod) bool {
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	revision := ss.Name + "-" + templateHash(&ss.Spec.Template)
+
+	byOrdinal := make(map[int]*corev1.Pod)
+	for _, pod := range allPods {
+		if pod.Namespace != ss.Namespace || !controlledBy(pod, ss) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(pod.Name, ss.Name+"-")); err == nil {
+			byOrdinal[n] = pod
+		}
+	}
+	ordinals := slices.Sorted(maps.Keys(byOrdinal))
+
+	changed := false
+	allReady := true
+	for _, pod := range byOrdinal {
+		if !podReady(pod) {
+			allReady = false
+		}
+	}
+	if allReady {
+		missing := -1
+		for i := 0; i < int(replicas); i++ {
+			if byOrdinal[i] == nil {
+				missing = i
+				break
+			}
+		}
+		switch {
+		case missing >= 0:
+			pod := s.statefulSetPod(ss, missing, revision)
+			if s.create("pods", pod) {
+				byOrdinal[missing] = pod
+				changed = true
+			}
+		case len(ordinals) > 0 && ordinals[len(ordinals)-1] >= int(replicas):
+			last := ordinals[len(ordinals)-1]
+			if s.delete("pods", ss.Namespace, byOrdinal[last].Name) {
+				delete(byOrdinal, last)
+				changed = true
+			}
+		default:
+			for i := len(ordinals) - 1; i >= 0; i-- {
+				pod := byOrdinal[ordinals[i]]
+				if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+					if s.delete("pods", ss.Namespace, pod.Name) {
+						delete(byOrdinal, ordinals[i])
+						changed = true
+					}
+					break
+				}
+			}
+		}
+	}
+
+	var ready, updated int32
+	for _, pod := range byOrdinal {
+		if podReady(pod) {
+			ready++
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == revision {
+			updated++
+		}
+	}
+	want := ss.DeepCopy()
+	want.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: ss.Generation,
+		Replicas:           int32(len(byOrdinal)),
+		ReadyReplicas:      ready,
+		AvailableReplicas:  ready,
+		UpdatedReplicas:    updated,
+		CurrentRevision:    ss.Status.CurrentRevision,
+		UpdateRevision:     revision,
+	}
+	if want.Status.CurrentRevision == "" || (updated == replicas && ready == replicas) {
+		want.Status.CurrentRevision = revision
+	}
+	for _, pod := range byOrdinal {
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == want.Status.CurrentRevision {
+			want.Status.CurrentReplicas++
+		}
+	}
+	if !apiequality.Semantic.DeepEqual(ss.Status, want.Status) {
+		if s.update("statefulsets", want) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// statefulSetPod returns the pending pod with ordinal of ss, creating its
+// volume claims if they don't exist yet
+func (s *fakeControllers) statefulSetPod(ss *appsv1.StatefulSet, ordinal int, revision string) *corev1.Pod {
+	name := fmt.Sprintf("%s-%d", ss.Name, ordinal)
+	template := ss.Spec.Template.DeepCopy()
+	for _, claimTemplate := range ss.Spec.VolumeClaimTemplates {
+		claimName := claimTemplate.Name + "-" + name
+		if _, err := s.tracker.Get(fakeGVR("persistentvolumeclaims"), ss.Namespace, claimName); err != nil {
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              claimName,
+					Namespace:         ss.Namespace,
+					Labels:            maps.Clone(ss.Spec.Selector.MatchLabels),
+					UID:               uuid.NewUUID(),
+					CreationTimestamp: s.now,
+				},
+				Spec:   *claimTemplate.Spec.DeepCopy(),
+				Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+			}
+			s.create("persistentvolumeclaims", claim)
+		}
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name:         claimTemplate.Name,
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}},
+		})
+	}
+	pod := s.pendingPod(ss.Namespace, name, template, map[string]string{
+		appsv1.ControllerRevisionHashLabelKey: revision,
+		appsv1.StatefulSetPodNameLabel:        name,
+	}, controllerRef(ss, "apps/v1", "StatefulSet"))
+	pod.Spec.Hostname = name
+	pod.Spec.Subdomain = ss.Spec.ServiceName
+	return pod
+}
+
+// jobs runs each Job that hasn't finished: it creates one pod, and marks
+// the Job complete once that pod succeeds
+func (s *fakeControllers) jobs() bool {
+	changed := false
+	pods := fakeList[*corev1.Pod](s, "pods")
+	for _, job := range fakeList[*batchv1.Job](s, "jobs") {
+		if jobFinished(job) || (job.Spec.Suspend != nil && *job.Spec.Suspend) {
+			continue
+		}
+		var owned []*corev1.Pod
+		for _, pod := range pods {
+			if pod.Namespace == job.Namespace && controlledBy(pod, job) {
+				owned = append(owned, pod)
+			}
+		}
+
+		want := job.DeepCopy()
+		if want.Status.StartTime == nil {
+			want.Status.StartTime = &s.now
+		}
+		if len(owned) == 0 {
+			pod := s.pendingPod(job.Namespace, job.Name+"-"+rand.String(5), &job.Spec.Template, map[string]string{
+				batchv1.JobNameLabel:       job.Name,
+				batchv1.ControllerUidLabel: string(job.UID),
+				"job-name":                 job.Name,
+				"controller-uid":           string(job.UID),
+			}, controllerRef(job, "batch/v1", "Job"))
+			if s.create("pods", pod) {
+				owned = append(owned, pod)
+				changed = true
+			}
+		}
+
+		want.Status.Active, want.Status.Succeeded = 0, 0
+		for _, pod := range owned {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				want.Status.Succeeded++
+			} else {
+				want.Status.Active++
+			}
+		}
+		want.Status.Ready = ptrTo(int32(countReady(owned)))
+		if want.Status.Succeeded > 0 {
+			want.Status.Active = 0
+			want.Status.CompletionTime = &s.now
+			want.Status.Conditions = append(want.Status.Conditions,
+				batchv1.JobCondition{Type: batchv1.JobSuccessCriteriaMet, Status: corev1.ConditionTrue, Reason: "CompletionsReached", LastProbeTime: s.now, LastTransitionTime: s.now},
+				batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, Reason: "CompletionsReached", LastProbeTime: s.now, LastTransitionTime: s.now},
+			)
+		}
+		if !apiequality.Semantic.DeepEqual(job.Status, want.Status) {
+			if s.update("jobs", want) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// jobFinished reports whether job is complete or failed
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// services allocates cluster IPs, and external IPs to LoadBalancer Services
+func (s *fakeControllers) services() bool {
+	changed := false
+	for _, svc := range fakeList[*corev1.Service](s, "services") {
+		want := svc.DeepCopy()
+		key := svc.Namespace + "/" + svc.Name
+		if want.Spec.ClusterIP == "" {
+			h := fnv.New32a()
+			h.Write([]byte(key))
+			n := h.Sum32()
+			want.Spec.ClusterIP = fmt.Sprintf("10.96.%d.%d", n>>8%256, n%254+1)
+			want.Spec.ClusterIPs = []string{want.Spec.ClusterIP}
+		}
+		if want.Spec.Type == corev1.ServiceTypeLoadBalancer && len(want.Status.LoadBalancer.Ingress) == 0 {
+			// 192.0.2.0/24 is reserved for documentation
+			h := fnv.New32a()
+			h.Write([]byte(key))
+			want.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: fmt.Sprintf("192.0.2.%d", h.Sum32()%254+1)}}
+		}
+		if !apiequality.Semantic.DeepEqual(svc, want) {
+			if s.update("services", want) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// claims binds pending PersistentVolumeClaims to volumes of the size they request
+func (s *fakeControllers) claims() bool {
+	changed := false
+	for _, pvc := range fakeList[*corev1.PersistentVolumeClaim](s, "persistentvolumeclaims") {
+		if pvc.Status.Phase == corev1.ClaimBound {
+			continue
+		}
+		want := pvc.DeepCopy()
+		want.Spec.VolumeName = "pvc-" + string(pvc.UID)
+		want.Status = corev1.PersistentVolumeClaimStatus{
+			Phase:       corev1.ClaimBound,
+			AccessModes: pvc.Spec.AccessModes,
+			Capacity:    pvc.Spec.Resources.Requests,
+		}
+		if s.update("persistentvolumeclaims", want) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// collectGarbage deletes objects whose owners are all gone, as the garbage
+// collector does with background propagation
+func (s *fakeControllers) collectGarbage() bool {
+	changed := false
+	for _, r := range fakeResources {
+		if !r.namespaced {
+			continue
+		}
+		list, err := s.tracker.List(r.gvr, r.gvk(), "")
+		if err != nil {
+			continue
+		}
+		items, _ := meta.ExtractList(list)
+		for _, item := range items {
+			obj, err := meta.Accessor(item)
+			if err != nil || len(obj.GetOwnerReferences()) == 0 {
+				continue
+			}
+			if !s.ownersGone(obj) {
+				continue
+			}
+			if s.delete(r.gvr.Resource, obj.GetNamespace(), obj.GetName()) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// ownersGone reports whether none of obj's owners exist. Owners of kinds
+// the fake cluster doesn't serve are assumed to exist.
+func (s *fakeControllers) ownersGone(obj metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return false
+		}
+		var owner *fakeResource
+		for i, r := range fakeResources {
+			if r.kind == ref.Kind && r.gvr.Group == gv.Group {
+				owner = &fakeResources[i]
+				break
+			}
+		}
+		if owner == nil {
+			return false
+		}
+		ns := obj.GetNamespace()
+		if !owner.namespaced {
+			ns = ""
+		}
+		existing, err := s.tracker.Get(owner.gvr, ns, ref.Name)
+		if err != nil {
+			continue
+		}
+		if m, err := meta.Accessor(existing); err == nil && (ref.UID == "" || m.GetUID() == ref.UID) {
+			return false
+		}
+	}
+	return true
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,192 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// InClusterContext is the context name of a client that uses the service
+// account of the pod kbox runs in
+const InClusterContext = "in-cluster"
+
+// serviceAccountNamespaceFile holds the namespace of the pod kbox runs in
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// kubeconfigPaths are the kubeconfig files from --kubeconfig; empty means
+// KUBECONFIG or ~/.kube/config
+var kubeconfigPaths []string
+
+// SetKubeconfig sets the kubeconfig files to load instead of KUBECONFIG or
+// ~/.kube/config. Like KUBECONFIG, several files are merged, the first to
+// set a context, cluster or user winning.
+func SetKubeconfig(paths []string) {
+	kubeconfigPaths = nil
+	for _, p := range paths {
+		kubeconfigPaths = append(kubeconfigPaths, filepath.SplitList(p)...)
+	}
+}
+
+// loadingRules returns the rules for finding kubeconfig. Files passed to
+// SetKubeconfig must exist; KUBECONFIG's are skipped when missing, as
+// kubectl does.
+func loadingRules() (*clientcmd.ClientConfigLoadingRules, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(kubeconfigPaths) == 0 {
+		return rules, nil
+	}
+	for _, p := range kubeconfigPaths {
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: %w\n  → Check the --kubeconfig path", p, err)
+		}
+	}
+	rules.Precedence = kubeconfigPaths
+	return rules, nil
+}
+
+// KubeconfigPaths returns the kubeconfig files kbox reads, in precedence order
+func KubeconfigPaths() []string {
+	if len(kubeconfigPaths) > 0 {
+		return kubeconfigPaths
+	}
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return filepath.SplitList(kubeconfig)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// KubeconfigPath returns the kubeconfig files kbox reads, as a list like KUBECONFIG
+func KubeconfigPath() string {
+	return strings.Join(KubeconfigPaths(), string(os.PathListSeparator))
+}
+
+// HasKubeconfig checks if any of the kubeconfig files exists
+func HasKubeconfig() bool {
+	for _, path := range KubeconfigPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// InCluster reports whether clients should use the service account of the
+// pod kbox runs in: there's no kubeconfig, and Kubernetes set the pod's
+// API server address
+func InCluster() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != "" && !HasKubeconfig()
+}
+
+// execPluginHints says how to install common exec credential plugins
+var execPluginHints = map[string]string{
+	"gke-gcloud-auth-plugin": "Install it with 'gcloud components install gke-gcloud-auth-plugin'",
+	"aws":                    "Install the AWS CLI: https://aws.amazon.com/cli/",
+	"aws-iam-authenticator":  "Install it: https://github.com/kubernetes-sigs/aws-iam-authenticator",
+	"kubelogin":              "Install it with 'az aks install-cli'",
+	"kubectl-oidc_login":     "Install it with 'kubectl krew install oidc-login'",
+	"doctl":                  "Install doctl: https://docs.digitalocean.com/reference/doctl/how-to/install/",
+}
+
+// checkExecPlugin fails early, with install instructions, when the user of
+// context authenticates with an exec credential plugin that isn't installed.
+// client-go would otherwise fail on the first request with a bare
+// "executable not found".
+func checkExecPlugin(raw *clientcmdapi.Config, context string) error {
+	kubeContext, ok := raw.Contexts[context]
+	if !ok {
+		return nil
+	}
+	user, ok := raw.AuthInfos[kubeContext.AuthInfo]
+	if !ok || user.Exec == nil || user.Exec.Command == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(user.Exec.Command); err == nil {
+		return nil
+	}
+	hint := user.Exec.InstallHint
+	if hint == "" {
+		hint = execPluginHints[filepath.Base(user.Exec.Command)]
+	}
+	if hint == "" {
+		hint = "Install it, or add its directory to PATH"
+	}
+	return fmt.Errorf("context %q authenticates with the credential plugin %q, which isn't installed\n  → %s", context, user.Exec.Command, strings.TrimSpace(hint))
+}
+
+// LoadRawConfig loads the merged kubeconfig without connecting to a cluster
+func LoadRawConfig() (*clientcmdapi.Config, error) {
+	if noCluster {
+		return nil, ErrNoCluster
+	}
+	loadingRules, err := loadingRules()
+	if err != nil {
+		return nil, err
+	}
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	raw, err := kubeConfig.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// ListContexts returns the sorted context names from kubeconfig
+func ListContexts() ([]string, error) {
+	raw, err := LoadRawConfig()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ContextNamespaces returns the distinct namespaces referenced by kubeconfig contexts
+func ContextNamespaces() ([]string, error) {
+	raw, err := LoadRawConfig()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, ctx := range raw.Contexts {
+		if ctx.Namespace != "" && !seen[ctx.Namespace] {
+			seen[ctx.Namespace] = true
+			namespaces = append(namespaces, ctx.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// CurrentContext returns the current context name from kubeconfig
+func CurrentContext() (string, error) {
+	raw, err := LoadRawConfig()
+	if err != nil {
+		return "", err
+	}
+	return raw.CurrentContext, nil
+}
+
+// HasContext reports whether kubeconfig defines the named context
+func HasContext(name string) (bool, error) {
+	raw, err := LoadRawConfig()
+	if err != nil {
+		return false, err
+	}
+	_, ok := raw.Contexts[name]
+	return ok, nil
+}
@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// ErrReadOnly is the error of a request that would change the cluster in
+// read-only mode
+var ErrReadOnly = errors.New("kbox is running with --read-only, but this changes the cluster")
+
+// readOnly makes the clients NewClient creates refuse requests that change
+// the cluster
+var readOnly bool
+
+// SetReadOnly turns read-only mode on or off: while on, the clients NewClient
+// creates refuse every request that could change the cluster (writes, exec,
+// attach and port-forwards) with ErrReadOnly, before it's sent
+func SetReadOnly(on bool) {
+	readOnly = on
+}
+
+// ReadOnly reports whether read-only mode is on
+func ReadOnly() bool {
+	return readOnly
+}
+
+// readOnlyTransport passes on the requests a view-only user could make
+type readOnlyTransport struct {
+	next http.RoundTripper
+}
+
+func (t readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !allowedReadOnly(req) {
+		return nil, output.WithCode(output.ErrForbidden, fmt.Errorf("%w (%s %s)", ErrReadOnly, req.Method, req.URL.Path))
+	}
+	return t.next.RoundTrip(req)
+}
+
+// allowedReadOnly reports whether req is allowed in read-only mode: reads,
+// server-side dry runs, and reviews of the user's own permissions. Exec,
+// attach and port-forward are refused even when upgraded from a GET.
+func allowedReadOnly(req *http.Request) bool {
+	path := req.URL.Path
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		for _, sub := range []string{"/exec", "/attach", "/portforward"} {
+			if strings.HasSuffix(path, sub) {
+				return false
+			}
+		}
+		return true
+	}
+	if req.URL.Query().Get("dryRun") == metav1.DryRunAll {
+		return true
+	}
+	return req.Method == http.MethodPost &&
+		(strings.HasSuffix(path, "/selfsubjectaccessreviews") ||
+			strings.HasSuffix(path, "/selfsubjectrulesreviews") ||
+			strings.HasSuffix(path, "/selfsubjectreviews"))
+}
+
+// Permission is an RBAC permission: a verb on a resource, such as "patch
+// deployments" or "create pods/exec"
+type Permission struct {
+	Verb     string
+	Resource string
+	Group    string
+}
+
+// String returns the permission the way kubectl auth can-i takes it
+func (p Permission) String() string {
+	resource, sub, _ := strings.Cut(p.Resource, "/")
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if sub != "" {
+		resource += "/" + sub
+	}
+	return p.Verb + " " + resource
+}
+
+// ParsePermission parses a permission written as "<verb> <resource>[.<group>][/<subresource>]",
+// e.g. "patch deployments.apps" or "create pods/portforward"
+func ParsePermission(s string) (Permission, error) {
+	verb, resource, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok || verb == "" || resource == "" {
+		return Permission{}, fmt.Errorf("invalid permission %q (expected \"<verb> <resource>\")", s)
+	}
+	resource, sub, _ := strings.Cut(resource, "/")
+	resource, group, _ := strings.Cut(resource, ".")
+	if sub != "" {
+		resource += "/" + sub
+	}
+	return Permission{Verb: verb, Resource: resource, Group: group}, nil
+}
+
+// CanI asks the API server whether the client's user has perm in namespace
+func (c *Client) CanI(ctx context.Context, namespace string, perm Permission) (bool, error) {
+	resource, sub, _ := strings.Cut(perm.Resource, "/")
+	review := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        perm.Verb,
+				Group:       perm.Group,
+				Resource:    resource,
+				Subresource: sub,
+			},
+		},
+	}
+	result, err := c.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+func TestReadOnly(t *testing.T) {
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	restConfig := &rest.Config{Host: server.URL}
+	if err := configure(restConfig); err != nil {
+		t.Fatal(err)
+	}
+	client, err := rest.HTTPClientFor(restConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		allowed      bool
+	}{
+		{http.MethodGet, "/apis/apps/v1/namespaces/default/deployments/myapp", true},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/myapp-1/log", true},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/default/deployments/myapp?dryRun=All", true},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", true},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/default/deployments/myapp", false},
+		{http.MethodDelete, "/api/v1/namespaces/default/pods/myapp-1", false},
+		{http.MethodPost, "/api/v1/namespaces/default/pods/myapp-1/exec", false},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/myapp-1/portforward", false},
+	}
+	for _, tt := range tests {
+		sent = nil
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if tt.allowed && (err != nil || len(sent) != 1) {
+			t.Errorf("%s %s: expected it to be sent, got %v", tt.method, tt.path, err)
+		}
+		if !tt.allowed {
+			if !errors.Is(err, ErrReadOnly) || output.CodeOf(err) != output.ErrForbidden {
+				t.Errorf("%s %s: expected ErrReadOnly, got %v", tt.method, tt.path, err)
+			}
+			if len(sent) != 0 {
+				t.Errorf("%s %s: expected it never to reach the server", tt.method, tt.path)
+			}
+		}
+	}
+}
+
+func TestParsePermission(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Permission
+	}{
+		{"patch deployments.apps", Permission{Verb: "patch", Resource: "deployments", Group: "apps"}},
+		{"create pods/exec", Permission{Verb: "create", Resource: "pods/exec"}},
+		{"create ingresses.networking.k8s.io", Permission{Verb: "create", Resource: "ingresses", Group: "networking.k8s.io"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePermission(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePermission(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+	if _, err := ParsePermission("deployments"); err == nil {
+		t.Error("expected a permission without a verb to be refused")
+	}
+}
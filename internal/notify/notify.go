@@ -0,0 +1,192 @@
+// Package notify posts deploy, rollback and preview events to Slack, Teams or a webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// Event types
+const (
+	EventDeploy         = "deploy"
+	EventRollback       = "rollback"
+	EventPreviewCreate  = "preview_create"
+	EventPreviewDestroy = "preview_destroy"
+)
+
+// DefaultTemplate is the message text when kbox.yaml doesn't set notifications.template
+const DefaultTemplate = `{{if .Succeeded}}✅{{else}}❌{{end}} {{.Action}} of {{.App}}` +
+	`{{with .Preview}} preview {{.}}{{end}}{{with .Env}} to {{.}}{{end}}{{with .Cluster}} ({{.}}){{end}} {{.Status}}` +
+	`{{with .Revision}} · revision {{.}}{{end}}{{with .Image}} · {{.}}{{end}}{{with .Duration}} · {{.}}{{end}}` +
+	`{{with .Error}}
+{{.}}{{end}}`
+
+// sendTimeout bounds each webhook request
+const sendTimeout = 10 * time.Second
+
+// Event is something worth telling the team about
+type Event struct {
+	Type      string        `json:"type"`
+	App       string        `json:"app"`
+	Env       string        `json:"env,omitempty"`
+	Cluster   string        `json:"cluster,omitempty"`
+	Context   string        `json:"context,omitempty"`
+	Namespace string        `json:"namespace,omitempty"`
+	Preview   string        `json:"preview,omitempty"`
+	Revision  int           `json:"revision,omitempty"`
+	Image     string        `json:"image,omitempty"`
+	Duration  time.Duration `json:"-"`
+	Succeeded bool          `json:"succeeded"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Status is "succeeded" or "failed"
+func (e Event) Status() string {
+	if e.Succeeded {
+		return "succeeded"
+	}
+	return "failed"
+}
+
+// Action is the event type as a capitalized phrase (e.g., "Preview create")
+func (e Event) Action() string {
+	action := strings.ReplaceAll(e.Type, "_", " ")
+	if action == "" {
+		return ""
+	}
+	return strings.ToUpper(action[:1]) + action[1:]
+}
+
+// webhookPayload is the JSON body sent to a generic webhook
+type webhookPayload struct {
+	Event
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Notifier sends events to the channels of a notifications config
+type Notifier struct {
+	cfg    *config.NotificationsConfig
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// New creates a notifier for cfg
+func New(cfg *config.NotificationsConfig) (*Notifier, error) {
+	text := cfg.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notifications.template: %w", err)
+	}
+	return &Notifier{cfg: cfg, tmpl: tmpl, client: &http.Client{Timeout: sendTimeout}}, nil
+}
+
+// Wants reports whether the config subscribes to events of this type
+func (n *Notifier) Wants(eventType string) bool {
+	if len(n.cfg.Events) == 0 {
+		return true
+	}
+	group := strings.SplitN(eventType, "_", 2)[0] // preview_create → preview
+	for _, e := range n.cfg.Events {
+		if e == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Message renders the message text for an event
+func (n *Notifier) Message(e Event) (string, error) {
+	if e.Duration > time.Second {
+		e.Duration = e.Duration.Round(time.Second)
+	}
+	var buf bytes.Buffer
+	if err := n.tmpl.Execute(&buf, e); err != nil {
+		return "", fmt.Errorf("failed to render notification: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Send posts the event to every configured channel. A failing channel doesn't
+// stop the others; all failures are returned together.
+func (n *Notifier) Send(ctx context.Context, e Event) error {
+	if !n.Wants(e.Type) {
+		return nil
+	}
+	msg, err := n.Message(e)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if n.cfg.Slack != nil {
+		errs = append(errs, n.post(ctx, "slack", n.cfg.Slack.URL, map[string]string{"text": msg}))
+	}
+	if n.cfg.Teams != nil {
+		color := "2EB886"
+		if !e.Succeeded {
+			color = "D63232"
+		}
+		errs = append(errs, n.post(ctx, "teams", n.cfg.Teams.URL, map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    fmt.Sprintf("%s of %s %s", e.Action(), e.App, e.Status()),
+			"themeColor": color,
+			"text":       strings.ReplaceAll(msg, "\n", "\n\n"), // Teams needs a blank line for a break
+		}))
+	}
+	if n.cfg.Webhook != nil {
+		errs = append(errs, n.post(ctx, "webhook", n.cfg.Webhook.URL, webhookPayload{
+			Event:      e,
+			Status:     e.Status(),
+			DurationMs: e.Duration.Milliseconds(),
+			Message:    msg,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a JSON payload to a webhook URL
+func (n *Notifier) post(ctx context.Context, channel, webhookURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(webhookURL), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: invalid webhook URL", channel)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The URL usually embeds a secret token; keep it out of the message
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", channel, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("%s: HTTP %d: %s", channel, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// recorder is a webhook endpoint that keeps the bodies posted to it
+type recorder struct {
+	*httptest.Server
+	bodies []map[string]interface{}
+}
+
+func newRecorder(t *testing.T, status int) *recorder {
+	r := &recorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid JSON body %q: %v", data, err)
+		}
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+var deployEvent = Event{
+	Type:      EventDeploy,
+	App:       "myapp",
+	Env:       "prod",
+	Revision:  7,
+	Image:     "myapp:v2",
+	Duration:  83400 * time.Millisecond,
+	Succeeded: true,
+}
+
+func TestDefaultMessage(t *testing.T) {
+	n, err := New(&config.NotificationsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := n.Message(deployEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "✅ Deploy of myapp to prod succeeded · revision 7 · myapp:v2 · 1m23s"
+	if msg != want {
+		t.Errorf("expected %q, got %q", want, msg)
+	}
+
+	failed := Event{Type: EventRollback, App: "myapp", Error: "rollout timed out"}
+	msg, _ = n.Message(failed)
+	if msg != "❌ Rollback of myapp failed\nrollout timed out" {
+		t.Errorf("unexpected failure message %q", msg)
+	}
+}
+
+func TestSendToAllChannels(t *testing.T) {
+	slack := newRecorder(t, http.StatusOK)
+	teams := newRecorder(t, http.StatusOK)
+	hook := newRecorder(t, http.StatusOK)
+	t.Setenv("KBOX_TEST_HOOK", hook.URL)
+
+	n, err := New(&config.NotificationsConfig{
+		Slack:    &config.WebhookConfig{URL: slack.URL},
+		Teams:    &config.WebhookConfig{URL: teams.URL},
+		Webhook:  &config.WebhookConfig{URL: "${KBOX_TEST_HOOK}/deploys"},
+		Template: "{{.App}} {{.Status}} in {{.Env}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(context.Background(), deployEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(slack.bodies) != 1 || slack.bodies[0]["text"] != "myapp succeeded in prod" {
+		t.Errorf("unexpected slack payload: %v", slack.bodies)
+	}
+	if len(teams.bodies) != 1 || teams.bodies[0]["@type"] != "MessageCard" || teams.bodies[0]["text"] != "myapp succeeded in prod" {
+		t.Errorf("unexpected teams payload: %v", teams.bodies)
+	}
+	if len(hook.bodies) != 1 {
+		t.Fatalf("expected one webhook call, got %d", len(hook.bodies))
+	}
+	body := hook.bodies[0]
+	if body["type"] != "deploy" || body["status"] != "succeeded" || body["revision"] != float64(7) ||
+		body["duration_ms"] != float64(83400) || body["message"] != "myapp succeeded in prod" {
+		t.Errorf("unexpected webhook payload: %v", body)
+	}
+}
+
+func TestSendFiltersEvents(t *testing.T) {
+	slack := newRecorder(t, http.StatusOK)
+	n, _ := New(&config.NotificationsConfig{
+		Slack:  &config.WebhookConfig{URL: slack.URL},
+		Events: []string{"preview"},
+	})
+
+	n.Send(context.Background(), deployEvent)
+	n.Send(context.Background(), Event{Type: EventPreviewCreate, App: "myapp", Preview: "pr-12", Succeeded: true})
+
+	if len(slack.bodies) != 1 || !strings.Contains(slack.bodies[0]["text"].(string), "preview pr-12") {
+		t.Errorf("expected only the preview event, got %v", slack.bodies)
+	}
+}
+
+func TestSendReportsFailures(t *testing.T) {
+	slack := newRecorder(t, http.StatusForbidden)
+	hook := newRecorder(t, http.StatusOK)
+	n, _ := New(&config.NotificationsConfig{
+		Slack:   &config.WebhookConfig{URL: slack.URL},
+		Webhook: &config.WebhookConfig{URL: hook.URL},
+	})
+
+	err := n.Send(context.Background(), deployEvent)
+	if err == nil || !strings.Contains(err.Error(), "slack: HTTP 403") {
+		t.Errorf("expected slack failure, got %v", err)
+	}
+	if len(hook.bodies) != 1 {
+		t.Errorf("expected the webhook to be called despite the slack failure")
+	}
+}
@@ -0,0 +1,248 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Status event types
+const (
+	StatusStage  = "stage"  // a stage of the operation started or completed
+	StatusTask   = "task"   // a task within a stage, such as applying one resource
+	StatusResult = "result" // the operation finished; always the last event
+)
+
+// statusQueue is how many events may wait to be posted before new task
+// events are dropped. Stage and result events are never dropped.
+const statusQueue = 256
+
+// StatusEvent is a progress event of a long-running operation, posted as
+// JSON to a status webhook
+type StatusEvent struct {
+	// RunID is the same for every event of one operation
+	RunID     string `json:"run_id"`
+	Operation string `json:"operation"`
+	App       string `json:"app,omitempty"`
+	Type      string `json:"type"`
+	// Stage is the stage the event belongs to
+	Stage string `json:"stage,omitempty"`
+	// Task names a task event's task, e.g. "Deployment/myapp"
+	Task string `json:"task,omitempty"`
+	// Status is started, completed, warning or failed; for the result,
+	// succeeded or failed
+	Status string `json:"status"`
+	// Percent is how far the operation is, 0 to 100
+	Percent   int       `json:"percent"`
+	Message   string    `json:"message,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+}
+
+// StatusWebhook posts the progress of one operation to a URL: each stage as
+// it starts and completes, the tasks within it, and the final result. Events
+// are posted in order from the background, so a slow endpoint doesn't slow
+// the operation down; delivery failures never fail it. A nil *StatusWebhook
+// does nothing.
+type StatusWebhook struct {
+	url    string
+	client *http.Client
+	events chan StatusEvent
+	done   chan struct{}
+
+	mu        sync.Mutex
+	operation string
+	runID     string
+	app       string
+	start     time.Time
+	stage     string
+	percent   int
+	finished  bool
+	dropped   int
+
+	// errMu guards errs apart from mu, which is held while waiting for room
+	// in the queue
+	errMu sync.Mutex
+	errs  []error
+}
+
+// NewStatusWebhook starts posting the events of operation (e.g. "deploy")
+// to webhookURL, in which environment variables are expanded
+func NewStatusWebhook(webhookURL, operation string) (*StatusWebhook, error) {
+	webhookURL = os.ExpandEnv(webhookURL)
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid status webhook URL (expected http:// or https://)")
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	w := &StatusWebhook{
+		url:       webhookURL,
+		client:    &http.Client{Timeout: sendTimeout},
+		events:    make(chan StatusEvent, statusQueue),
+		done:      make(chan struct{}),
+		operation: operation,
+		runID:     hex.EncodeToString(id),
+		start:     time.Now(),
+	}
+	go w.run()
+	return w, nil
+}
+
+// SetApp sets the app named in the events from now on
+func (w *StatusWebhook) SetApp(app string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.app = app
+}
+
+// Stage completes the current stage, if any, and starts the next one at
+// percent
+func (w *StatusWebhook) Stage(name string, percent int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return
+	}
+	if w.stage != "" {
+		w.send(StatusEvent{Type: StatusStage, Stage: w.stage, Status: "completed", Percent: percent}, true)
+	}
+	w.stage, w.percent = name, percent
+	w.send(StatusEvent{Type: StatusStage, Stage: name, Status: "started", Percent: percent}, true)
+}
+
+// Task reports a task of the current stage starting or finishing. Tasks
+// are dropped rather than queued without limit when the endpoint is slow.
+func (w *StatusWebhook) Task(name, status, message string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return
+	}
+	w.send(StatusEvent{Type: StatusTask, Stage: w.stage, Task: name, Status: status, Percent: w.percent, Message: message}, false)
+}
+
+// Finish completes or fails the current stage, posts the result, and waits
+// up to the send timeout for the queued events to be delivered. It returns
+// the delivery errors, for a warning.
+func (w *StatusWebhook) Finish(err error) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	if w.finished {
+		w.mu.Unlock()
+		return nil
+	}
+	result := StatusEvent{Type: StatusResult, Status: "succeeded", Percent: 100}
+	if err != nil {
+		result.Status, result.Percent, result.Error = "failed", w.percent, err.Error()
+	}
+	if w.stage != "" {
+		stage := StatusEvent{Type: StatusStage, Stage: w.stage, Status: "completed", Percent: result.Percent}
+		if err != nil {
+			stage.Status, stage.Error = "failed", err.Error()
+		}
+		w.send(stage, true)
+	}
+	w.send(result, true)
+	w.finished = true
+	close(w.events)
+	dropped := w.dropped
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(sendTimeout):
+		return errors.New("timed out posting status events")
+	}
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	if dropped > 0 {
+		w.errs = append(w.errs, fmt.Errorf("%d task events dropped: the endpoint was too slow", dropped))
+	}
+	return errors.Join(w.errs...)
+}
+
+// send stamps and queues an event. Callers hold w.mu.
+func (w *StatusWebhook) send(e StatusEvent, wait bool) {
+	e.RunID, e.Operation, e.App = w.runID, w.operation, w.app
+	e.Time = time.Now().UTC()
+	e.ElapsedMs = time.Since(w.start).Milliseconds()
+	if wait {
+		w.events <- e
+		return
+	}
+	select {
+	case w.events <- e:
+	default:
+		w.dropped++
+	}
+}
+
+// run posts the queued events in order until the queue is closed
+func (w *StatusWebhook) run() {
+	defer close(w.done)
+	failed := false
+	for e := range w.events {
+		// After a failure only the result is attempted, so a dead endpoint
+		// costs one timeout rather than one per event
+		if failed && e.Type != StatusResult {
+			continue
+		}
+		if err := w.post(e); err != nil {
+			failed = true
+			w.errMu.Lock()
+			w.errs = append(w.errs, err)
+			w.errMu.Unlock()
+		}
+	}
+}
+
+// post sends one event
+func (w *StatusWebhook) post(e StatusEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// The URL may embed a secret token; keep it out of the message
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("status webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status webhook: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
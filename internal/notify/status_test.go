@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusWebhook(t *testing.T) {
+	rec := newRecorder(t, http.StatusOK)
+
+	w, err := NewStatusWebhook(rec.URL, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetApp("myapp")
+	w.Stage("render", 0)
+	w.Stage("apply", 30)
+	w.Task("Deployment/myapp", "started", "")
+	w.Task("Deployment/myapp", "completed", "")
+	w.Stage("rollout", 60)
+	if err := w.Finish(nil); err != nil {
+		t.Fatalf("Finish() error: %v", err)
+	}
+
+	var got []string
+	for _, b := range rec.bodies {
+		got = append(got, fmt.Sprintf("%s %v %v %v %v", b["type"], b["stage"], b["task"], b["status"], b["percent"]))
+		if b["app"] != "myapp" || b["operation"] != "deploy" || b["run_id"] != rec.bodies[0]["run_id"] {
+			t.Errorf("expected every event to name the run, got %v", b)
+		}
+	}
+	want := []string{
+		"stage render <nil> started 0",
+		"stage render <nil> completed 30",
+		"stage apply <nil> started 30",
+		"task apply Deployment/myapp started 30",
+		"task apply Deployment/myapp completed 30",
+		"stage apply <nil> completed 60",
+		"stage rollout <nil> started 60",
+		"stage rollout <nil> completed 100",
+		"result <nil> <nil> succeeded 100",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected events:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Nothing is sent after the result
+	w.Stage("late", 100)
+	if len(rec.bodies) != len(want) {
+		t.Error("expected no events after Finish")
+	}
+}
+
+func TestStatusWebhookFailure(t *testing.T) {
+	rec := newRecorder(t, http.StatusOK)
+
+	w, _ := NewStatusWebhook(rec.URL, "preview_create")
+	w.Stage("apply", 20)
+	w.Finish(errors.New("quota exceeded"))
+
+	last := rec.bodies[len(rec.bodies)-1]
+	if last["type"] != StatusResult || last["status"] != "failed" || last["error"] != "quota exceeded" || last["percent"] != float64(20) {
+		t.Errorf("expected a failed result at the stage's percent, got %v", last)
+	}
+	if stage := rec.bodies[len(rec.bodies)-2]; stage["status"] != "failed" {
+		t.Errorf("expected the stage to fail, got %v", stage)
+	}
+}
+
+func TestStatusWebhookDeliveryErrors(t *testing.T) {
+	rec := newRecorder(t, http.StatusInternalServerError)
+
+	w, _ := NewStatusWebhook(rec.URL, "deploy")
+	w.Stage("render", 0)
+	w.Stage("apply", 30)
+	err := w.Finish(nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Errorf("expected the delivery error, got %v", err)
+	}
+	// After the first failure only the result is attempted
+	if len(rec.bodies) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(rec.bodies))
+	}
+
+	if _, err := NewStatusWebhook("ftp://example.com", "deploy"); err == nil {
+		t.Error("expected a non-HTTP URL to be refused")
+	}
+	var nilWebhook *StatusWebhook
+	nilWebhook.Stage("render", 0)
+	if err := nilWebhook.Finish(nil); err != nil {
+		t.Errorf("expected a nil webhook to do nothing, got %v", err)
+	}
+}
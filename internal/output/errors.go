@@ -0,0 +1,171 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+)
+
+// ErrorCode is a machine-readable failure class, reported as "errorCode" in
+// JSON output and mapped to a distinct process exit code
+type ErrorCode string
+
+const (
+	ErrGeneric        ErrorCode = "error"               // exit 1
+	ErrConfig         ErrorCode = "config_error"        // exit 2: kbox.yaml missing or invalid
+	ErrCluster        ErrorCode = "cluster_unreachable" // exit 3: kubeconfig or API server problem
+	ErrRolloutTimeout ErrorCode = "rollout_timeout"     // exit 4
+	ErrPolicy         ErrorCode = "policy_violation"    // exit 5
+	ErrPartialApply   ErrorCode = "partial_apply"       // exit 6: some resources failed to apply
+	ErrRolloutFailed  ErrorCode = "rollout_failed"      // exit 7: crashing pods, stalled rollout
+	ErrTestsFailed    ErrorCode = "tests_failed"        // exit 8: smoke tests failed
+	ErrJobFailed      ErrorCode = "job_failed"          // exit 9: a deploy Job (e.g. a migration) failed
+	ErrForbidden      ErrorCode = "forbidden"           // exit 10: --read-only, or no RBAC permission to make the change
+	ErrTimeout        ErrorCode = "timeout"             // exit 124: the global --timeout ran out
+	ErrCancelled      ErrorCode = "cancelled"           // exit 130: interrupted
+)
+
+var exitCodes = map[ErrorCode]int{
+	ErrGeneric:        1,
+	ErrConfig:         2,
+	ErrCluster:        3,
+	ErrRolloutTimeout: 4,
+	ErrPolicy:         5,
+	ErrPartialApply:   6,
+	ErrRolloutFailed:  7,
+	ErrTestsFailed:    8,
+	ErrJobFailed:      9,
+	ErrForbidden:      10,
+	ErrTimeout:        124,
+	ErrCancelled:      130,
+}
+
+// ExitCode returns the process exit code for an error code
+func (c ErrorCode) ExitCode() int {
+	if code, ok := exitCodes[c]; ok {
+		return code
+	}
+	return 1
+}
+
+// CodeForExit returns the ErrorCode a kbox process exited with (e.g. a
+// subprocess run by kbox serve), or "" for exit code 0
+func CodeForExit(exitCode int) ErrorCode {
+	if exitCode == 0 {
+		return ""
+	}
+	for code, exit := range exitCodes {
+		if exit == exitCode {
+			return code
+		}
+	}
+	return ErrGeneric
+}
+
+// CodedError attaches an ErrorCode to an error
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode tags err with code. An error that already carries a code keeps it,
+// so the most specific cause wins (a rollout timeout stays a timeout when
+// wrapped as a failed deploy).
+func WithCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *CodedError
+	var invalid invalidConfig
+	if errors.As(err, &coded) || errors.As(err, &invalid) && invalid.InvalidConfig() {
+		return err
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// invalidConfig is implemented by the config package's errors, which are
+// ErrConfig without config depending on output
+type invalidConfig interface {
+	error
+	InvalidConfig() bool
+}
+
+// ReportedError is an error a command already reported, e.g. in its JSON
+// result or a plugin's own output. The CLI exits with its code without
+// printing it again; a non-zero Status overrides the exit code.
+type ReportedError struct {
+	Err    error
+	Status int
+}
+
+func (e *ReportedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReportedError) Unwrap() error {
+	return e.Err
+}
+
+// Reported marks err as already reported
+func Reported(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ReportedError{Err: err}
+}
+
+// IsReported returns whether err was already reported
+func IsReported(err error) bool {
+	var reported *ReportedError
+	return errors.As(err, &reported)
+}
+
+// CodeOf returns the ErrorCode for err, or "" for nil. Untagged errors
+// from cancelled or expired contexts are ErrCancelled and ErrTimeout, those
+// from unreachable API servers ErrCluster; anything else is ErrGeneric.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	var invalid invalidConfig
+	if errors.As(err, &invalid) && invalid.InvalidConfig() {
+		return ErrConfig
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrCancelled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var urlErr *url.Error
+	var opErr *net.OpError
+	if errors.As(err, &urlErr) || errors.As(err, &opErr) {
+		return ErrCluster
+	}
+	return ErrGeneric
+}
+
+// ExitCode returns the process exit code for err (0 for nil)
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var reported *ReportedError
+	if errors.As(err, &reported) && reported.Status != 0 {
+		return reported.Status
+	}
+	return CodeOf(err).ExitCode()
+}
@@ -0,0 +1,83 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	timeout := WithCode(ErrRolloutTimeout, errors.New("timeout waiting for rollout"))
+
+	tests := []struct {
+		name string
+		err  error
+		code ErrorCode
+		exit int
+	}{
+		{"nil", nil, "", 0},
+		{"untagged", errors.New("boom"), ErrGeneric, 1},
+		{"tagged", WithCode(ErrConfig, errors.New("bad yaml")), ErrConfig, 2},
+		{"wrapped", fmt.Errorf("rollout failed: %w", timeout), ErrRolloutTimeout, 4},
+		{"inner code wins", WithCode(ErrPartialApply, timeout), ErrRolloutTimeout, 4},
+		{"job failed", WithCode(ErrJobFailed, errors.New("job migrate failed")), ErrJobFailed, 9},
+		{"forbidden", WithCode(ErrForbidden, errors.New("read-only")), ErrForbidden, 10},
+		{"cancelled", fmt.Errorf("apply: %w", context.Canceled), ErrCancelled, 130},
+		{"timed out", fmt.Errorf("delete: %w", context.DeadlineExceeded), ErrTimeout, 124},
+		{"unreachable", &url.Error{Op: "Get", URL: "https://10.0.0.1:6443", Err: errors.New("connection refused")}, ErrCluster, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.code {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.code)
+			}
+			if got := ExitCode(tt.err); got != tt.exit {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.exit)
+			}
+			if got := CodeForExit(tt.exit); got != tt.code {
+				t.Errorf("CodeForExit(%d) = %q, want %q", tt.exit, got, tt.code)
+			}
+		})
+	}
+}
+
+func TestWithCodeKeepsMessage(t *testing.T) {
+	base := errors.New("pod web-1: CrashLoopBackOff")
+	err := WithCode(ErrRolloutFailed, base)
+	if err.Error() != base.Error() || !errors.Is(err, base) {
+		t.Errorf("expected the tagged error to read and unwrap as the original, got %v", err)
+	}
+	if WithCode(ErrConfig, nil) != nil {
+		t.Error("expected WithCode(nil) to be nil")
+	}
+}
+
+type configErr struct{ error }
+
+func (configErr) InvalidConfig() bool { return true }
+
+func TestCodeOfConfigErrors(t *testing.T) {
+	err := fmt.Errorf("deploy: %w", configErr{errors.New("spec.port: must be between 1 and 65535")})
+	if got := CodeOf(err); got != ErrConfig {
+		t.Errorf("CodeOf() = %q, want %q", got, ErrConfig)
+	}
+	if got := CodeOf(WithCode(ErrCluster, err)); got != ErrConfig {
+		t.Errorf("CodeOf(WithCode()) = %q, want the config error's code", got)
+	}
+}
+
+func TestReported(t *testing.T) {
+	err := Reported(WithCode(ErrRolloutTimeout, errors.New("timeout waiting for rollout")))
+	if !IsReported(err) || ExitCode(err) != 4 {
+		t.Errorf("expected a reported rollout timeout, got %v (exit %d)", err, ExitCode(err))
+	}
+	if Reported(nil) != nil || IsReported(errors.New("boom")) {
+		t.Error("expected only Reported errors to be reported")
+	}
+	plugin := &ReportedError{Err: errors.New("exit status 42"), Status: 42}
+	if got := ExitCode(fmt.Errorf("plugin: %w", plugin)); got != 42 {
+		t.Errorf("ExitCode() = %d, want the plugin's status 42", got)
+	}
+}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// YAML highlighting colors
+const (
+	colorReset  = "\033[0m"
+	colorKey    = "\033[36m" // Cyan
+	colorString = "\033[32m" // Green
+	colorScalar = "\033[33m" // Yellow: numbers, booleans, null
+	colorMuted  = "\033[90m" // Comments and document separators
+)
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// ColorEnabled reports whether output to f should be colored: it is a
+// terminal and NO_COLOR isn't set
+func ColorEnabled(f *os.File) bool {
+	return IsTerminal(f) && os.Getenv("NO_COLOR") == ""
+}
+
+var (
+	// yamlKeyLine is an indented key, optionally a list item's, and the rest
+	yamlKeyLine = regexp.MustCompile(`^(\s*(?:- )*)([^\s#'"-][^:]*|"[^"]*"|'[^']*'):( |$)(.*)$`)
+	// yamlItemLine is a list item holding a scalar
+	yamlItemLine = regexp.MustCompile(`^(\s*- )(.*)$`)
+	yamlScalar   = regexp.MustCompile(`^(-?[0-9][0-9_.eE+-]*|true|false|null|~)$`)
+)
+
+// HighlightYAML colors a YAML stream for a terminal: keys, strings, other
+// scalars, comments and document separators. The contents of block scalars
+// (|, >) are left as they are.
+func HighlightYAML(data []byte) []byte {
+	var out bytes.Buffer
+	blockIndent := -1 // indentation of the key whose block scalar we are in
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		text := strings.TrimRight(line, "\n")
+		newline := line[len(text):]
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+
+		if blockIndent >= 0 {
+			if strings.TrimSpace(text) == "" || indent > blockIndent {
+				out.WriteString(line)
+				continue
+			}
+			blockIndent = -1
+		}
+
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case text == "---" || strings.HasPrefix(trimmed, "#"):
+			out.WriteString(colorMuted + text + colorReset + newline)
+		case yamlKeyLine.MatchString(text):
+			m := yamlKeyLine.FindStringSubmatch(text)
+			out.WriteString(m[1] + colorKey + m[2] + colorReset + ":" + m[3] + highlightScalar(m[4]) + newline)
+			if strings.HasPrefix(m[4], "|") || strings.HasPrefix(m[4], ">") {
+				blockIndent = len(m[1])
+			}
+		case yamlItemLine.MatchString(text):
+			m := yamlItemLine.FindStringSubmatch(text)
+			out.WriteString(m[1] + highlightScalar(m[2]) + newline)
+		default:
+			out.WriteString(line)
+		}
+	}
+	return out.Bytes()
+}
+
+// highlightScalar colors a value following a key or list dash
+func highlightScalar(value string) string {
+	switch {
+	case value == "" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") ||
+		value == "{}" || value == "[]":
+		return value
+	case yamlScalar.MatchString(value):
+		return colorScalar + value + colorReset
+	}
+	return colorString + value + colorReset
+}
+
+// Page writes content to out through the user's pager ($KBOX_PAGER, $PAGER,
+// or less) when out is a terminal, or straight to out otherwise. less exits
+// at once if content fits on one screen. Setting the pager to "" or "cat"
+// disables it.
+func Page(out *os.File, content []byte) error {
+	pager, set := os.LookupEnv("KBOX_PAGER")
+	if !set {
+		pager, set = os.LookupEnv("PAGER")
+	}
+	if !set {
+		pager = "less"
+	}
+	args := strings.Fields(pager)
+	if !IsTerminal(out) || len(args) == 0 || args[0] == "cat" {
+		_, err := out.Write(content)
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Quit if one screen, pass colors through, don't clear the screen
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		// No pager installed
+		_, err := io.Copy(out, bytes.NewReader(content))
+		return err
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightYAML(t *testing.T) {
+	input := `# comment
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: web
+data:
+  replicas: 3
+  enabled: true
+  script: |
+    name: not-a-key
+    echo hi
+  hosts:
+    - web.example.com
+---
+kind: Service
+`
+	got := string(HighlightYAML([]byte(input)))
+
+	for _, want := range []string{
+		colorMuted + "# comment" + colorReset + "\n",
+		colorKey + "apiVersion" + colorReset + ": " + colorString + "v1" + colorReset + "\n",
+		"  " + colorKey + "app.kubernetes.io/name" + colorReset + ": ",
+		colorKey + "replicas" + colorReset + ": " + colorScalar + "3" + colorReset,
+		colorKey + "enabled" + colorReset + ": " + colorScalar + "true" + colorReset,
+		colorKey + "script" + colorReset + ": |\n    name: not-a-key\n    echo hi\n",
+		"    - " + colorString + "web.example.com" + colorReset + "\n",
+		colorMuted + "---" + colorReset + "\n",
+		colorKey + "metadata" + colorReset + ":\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	// Without the escape codes it is the input unchanged
+	plain := got
+	for _, code := range []string{colorReset, colorKey, colorString, colorScalar, colorMuted} {
+		plain = strings.ReplaceAll(plain, code, "")
+	}
+	if plain != input {
+		t.Errorf("highlighting changed the text:\n%s", plain)
+	}
+}
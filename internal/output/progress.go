@@ -0,0 +1,220 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// spinnerFrames are drawn in turn next to each running task
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often running tasks are redrawn
+const spinnerInterval = 100 * time.Millisecond
+
+// Progress renders the progress of concurrent tasks (resources being applied,
+// rollouts, prunes). On a terminal every running task gets its own spinner line
+// and is replaced by a ✓, ✗ or ⚠ line with its duration when it finishes. When
+// output is not a terminal only the final lines are printed, and in CI mode
+// only failures and warnings are.
+type Progress struct {
+	out   io.Writer
+	live  bool // redraw spinners in place
+	quiet bool // CI mode: only failures and warnings
+
+	mu      sync.Mutex
+	running []*Task
+	drawn   int // lines of spinners currently on screen
+	frame   int
+	ticking bool
+}
+
+// TaskEvent is a task starting or finishing, as reported to the progress hook
+type TaskEvent struct {
+	Label string
+	// Status is "started", "completed", "warning" or "failed"
+	Status  string
+	Message string
+	Elapsed time.Duration
+}
+
+// progressHook is told about the tasks of every Progress
+var progressHook func(TaskEvent)
+
+// SetProgressHook makes every Progress report its tasks to fn (nil stops
+// it), for consumers such as a status webhook that can't read the terminal
+// output. fn is called synchronously and mustn't block.
+func SetProgressHook(fn func(TaskEvent)) {
+	progressHook = fn
+}
+
+// report passes a task event to the progress hook, if any
+func report(e TaskEvent) {
+	if hook := progressHook; hook != nil {
+		hook(e)
+	}
+}
+
+// Task is one unit of work tracked by a Progress
+type Task struct {
+	p      *Progress
+	label  string
+	status string
+	start  time.Time
+}
+
+// NewProgress creates a progress renderer writing to out (nil discards).
+// Spinners are only drawn when out is a terminal and ciMode is off.
+func NewProgress(out io.Writer, ciMode bool) *Progress {
+	if out == nil {
+		out = io.Discard
+	}
+	live := false
+	if f, ok := out.(*os.File); ok && !ciMode {
+		live = term.IsTerminal(int(f.Fd()))
+	}
+	return &Progress{out: out, live: live, quiet: ciMode}
+}
+
+// Start begins a task shown as label until it finishes
+func (p *Progress) Start(label string) *Task {
+	t := &Task{p: p, label: label, start: time.Now()}
+	report(TaskEvent{Label: label, Status: "started"})
+	if !p.live {
+		return t
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = append(p.running, t)
+	p.redraw()
+	if !p.ticking {
+		p.ticking = true
+		go p.tick()
+	}
+	return t
+}
+
+// Step records a step that has already finished, such as a build whose own
+// output went to the terminal
+func (p *Progress) Step(label string, d time.Duration) {
+	report(TaskEvent{Label: label, Status: "completed", Elapsed: d})
+	p.finish(nil, fmt.Sprintf("  ✓ %s%s", label, formatElapsed(d)), false)
+}
+
+// Printf prints an informational line above the running tasks
+func (p *Progress) Printf(format string, args ...interface{}) {
+	p.finish(nil, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"), false)
+}
+
+// Update changes the status shown next to a running task's label
+func (t *Task) Update(status string) {
+	t.p.mu.Lock()
+	defer t.p.mu.Unlock()
+	t.status = status
+}
+
+// Done finishes the task successfully. msg replaces the label when not empty.
+func (t *Task) Done(msg string) {
+	if msg == "" {
+		msg = t.label
+	}
+	report(TaskEvent{Label: t.label, Status: "completed", Message: msg, Elapsed: time.Since(t.start)})
+	t.p.finish(t, fmt.Sprintf("  ✓ %s%s", msg, formatElapsed(time.Since(t.start))), false)
+}
+
+// Warn finishes the task with a non-fatal problem
+func (t *Task) Warn(msg string) {
+	report(TaskEvent{Label: t.label, Status: "warning", Message: msg, Elapsed: time.Since(t.start)})
+	t.p.finish(t, fmt.Sprintf("  ⚠ %s (%s)", t.label, msg), true)
+}
+
+// Fail finishes the task with an error
+func (t *Task) Fail(err error) {
+	report(TaskEvent{Label: t.label, Status: "failed", Message: fmt.Sprint(err), Elapsed: time.Since(t.start)})
+	t.p.finish(t, fmt.Sprintf("  ✗ %s: %v", t.label, err), true)
+}
+
+// finish removes t (if any) from the running tasks and prints line above them
+func (p *Progress) finish(t *Task, line string, important bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if t != nil {
+		for i, r := range p.running {
+			if r == t {
+				p.running = append(p.running[:i], p.running[i+1:]...)
+				break
+			}
+		}
+	}
+	if p.quiet && !important {
+		return
+	}
+
+	p.clear()
+	fmt.Fprintln(p.out, line)
+	p.redraw()
+}
+
+// tick animates the spinners until no task is running
+func (p *Progress) tick() {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.mu.Lock()
+		if len(p.running) == 0 {
+			p.clear()
+			p.ticking = false
+			p.mu.Unlock()
+			return
+		}
+		p.frame++
+		p.redraw()
+		p.mu.Unlock()
+	}
+}
+
+// clear erases the spinner lines. Callers hold p.mu.
+func (p *Progress) clear() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.drawn)
+		p.drawn = 0
+	}
+}
+
+// redraw draws a spinner line per running task. Callers hold p.mu.
+func (p *Progress) redraw() {
+	if !p.live {
+		return
+	}
+	p.clear()
+	frame := spinnerFrames[p.frame%len(spinnerFrames)]
+	for _, t := range p.running {
+		line := fmt.Sprintf("  %s %s", frame, t.label)
+		if t.status != "" {
+			line += " — " + t.status
+		}
+		fmt.Fprintf(p.out, "%s%s\n", line, formatElapsed(time.Since(t.start)))
+	}
+	p.drawn = len(p.running)
+}
+
+// formatElapsed formats a duration as a " (1.2s)" suffix
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d < time.Second:
+		return fmt.Sprintf(" (%dms)", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf(" (%.1fs)", d.Seconds())
+	default:
+		return fmt.Sprintf(" (%s)", d.Round(time.Second))
+	}
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressPlain(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, false)
+
+	task := p.Start("Deployment/web")
+	task.Update("1/2 pods ready")
+	task.Done("")
+	p.Start("Service/web").Fail(errors.New("forbidden"))
+	p.Start("ServiceMonitor/web").Warn("skipped: CRD not installed")
+	p.Printf("    → Pod web-1 ready")
+	p.Step("Image built", 1500*time.Millisecond)
+
+	got := buf.String()
+	for _, want := range []string{
+		"  ✓ Deployment/web (",
+		"  ✗ Service/web: forbidden\n",
+		"  ⚠ ServiceMonitor/web (skipped: CRD not installed)\n",
+		"    → Pod web-1 ready\n",
+		"  ✓ Image built (1.5s)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+	// Without a terminal nothing is redrawn in place
+	if strings.Contains(got, "\x1b[") || strings.Contains(got, "pods ready") {
+		t.Errorf("expected plain output, got %q", got)
+	}
+}
+
+func TestProgressQuietInCI(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, true)
+
+	p.Start("Deployment/web").Done("")
+	p.Printf("    → Pod web-1 ready")
+	p.Start("Service/web").Fail(errors.New("forbidden"))
+
+	if got := buf.String(); got != "  ✗ Service/web: forbidden\n" {
+		t.Errorf("expected only the failure in CI mode, got %q", got)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "",
+		250 * time.Millisecond:  " (250ms)",
+		2500 * time.Millisecond: " (2.5s)",
+		90 * time.Second:        " (1m30s)",
+	}
+	for d, want := range tests {
+		if got := formatElapsed(d); got != want {
+			t.Errorf("formatElapsed(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestProgressNilWriter(t *testing.T) {
+	p := NewProgress(nil, false)
+	p.Start("Ingress/web").Done("")
+}
+
+func TestProgressHook(t *testing.T) {
+	var events []string
+	SetProgressHook(func(e TaskEvent) {
+		events = append(events, e.Status+" "+e.Label+" "+e.Message)
+	})
+	defer SetProgressHook(nil)
+
+	// Reported even when nothing is printed
+	p := NewProgress(nil, true)
+	p.Start("Deployment/web").Done("Deployment/web ready")
+	p.Start("Service/web").Fail(errors.New("forbidden"))
+	p.Step("Image built", time.Second)
+
+	want := []string{
+		"started Deployment/web ",
+		"completed Deployment/web Deployment/web ready",
+		"started Service/web ",
+		"failed Service/web forbidden",
+		"completed Image built ",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected events:\n%s", strings.Join(events, "\n"))
+	}
+}
@@ -9,22 +9,111 @@ import (
 
 // DeployResult represents the result of a deploy operation
 type DeployResult struct {
-	Success    bool             `json:"success"`
-	App        string           `json:"app"`
-	Namespace  string           `json:"namespace"`
-	Context    string           `json:"context,omitempty"`
-	Resources  []ResourceResult `json:"resources"`
-	Revision   int              `json:"revision,omitempty"`
+	Success   bool             `json:"success"`
+	App       string           `json:"app"`
+	Namespace string           `json:"namespace"`
+	Context   string           `json:"context,omitempty"`
+	Resources []ResourceResult `json:"resources"`
+	Revision  int              `json:"revision,omitempty"`
+	Tests     []TestResult     `json:"tests,omitempty"`
+	Jobs      []JobResult      `json:"jobs,omitempty"`
+	// LoadBalancer is the external IP or hostname of a LoadBalancer Service
+	LoadBalancer string `json:"load_balancer,omitempty"`
+	// Inventory summarizes what was deployed, for compliance tooling
+	Inventory *Inventory `json:"inventory,omitempty"`
+	// Reverted has what a failed transactional deploy undid, per resource
+	// (action restored or deleted)
+	Reverted   []ResourceResult `json:"reverted,omitempty"`
+	RolledBack int              `json:"rolled_back_to,omitempty"`
+	Diagnosis  []Diagnosis      `json:"diagnosis,omitempty"`
 	Error      string           `json:"error,omitempty"`
+	ErrorCode  ErrorCode        `json:"errorCode,omitempty"`
 	DurationMs int64            `json:"duration_ms"`
 }
 
+// FleetDeployResult aggregates a deploy across multiple clusters
+type FleetDeployResult struct {
+	Success    bool                  `json:"success"`
+	App        string                `json:"app"`
+	Parallel   bool                  `json:"parallel"`
+	Clusters   []ClusterDeployResult `json:"clusters"`
+	Error      string                `json:"error,omitempty"`
+	ErrorCode  ErrorCode             `json:"errorCode,omitempty"`
+	DurationMs int64                 `json:"duration_ms"`
+}
+
+// ClusterDeployResult is the deploy result for one cluster of a fleet
+type ClusterDeployResult struct {
+	Cluster string `json:"cluster"`
+	Region  string `json:"region,omitempty"`
+	Weight  int    `json:"weight,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	DeployResult
+}
+
+// Diagnosis is a probable root cause of a failed deploy
+type Diagnosis struct {
+	Title       string   `json:"title"`
+	Explanation string   `json:"explanation"`
+	Fixes       []string `json:"fixes,omitempty"`
+}
+
+// TestResult represents the outcome of a post-deploy smoke test
+type TestResult struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // http, exec
+	Passed     bool   `json:"passed"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// JobResult represents a Job run by a deploy, e.g. a migration
+type JobResult struct {
+	Name       string `json:"name"` // job name in kbox.yaml
+	Job        string `json:"job"`  // Job object name
+	Pod        string `json:"pod,omitempty"`
+	Succeeded  bool   `json:"succeeded"`
+	ExitCode   *int32 `json:"exit_code,omitempty"`
+	Logs       string `json:"logs,omitempty"`
+	LogFile    string `json:"log_file,omitempty"` // under --artifacts-dir
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Inventory summarizes a deploy's manifests: what they create, what they
+// request from the scheduler, and what they run
+type Inventory struct {
+	// Kinds counts the objects of each kind, e.g. {"Deployment": 1}
+	Kinds map[string]int `json:"kinds"`
+	Total int            `json:"total"`
+	// CPURequests and MemoryRequests are the requests of the long-running
+	// workloads at full scale (an HPA's maxReplicas)
+	CPURequests    string           `json:"cpu_requests,omitempty"`
+	MemoryRequests string           `json:"memory_requests,omitempty"`
+	Images         []InventoryImage `json:"images"`
+	// Secrets are the names of the Secrets the manifests create or the pods
+	// read; values are never included
+	Secrets     []string `json:"secrets"`
+	SecretCount int      `json:"secret_count"`
+}
+
+// InventoryImage is a container image the manifests run
+type InventoryImage struct {
+	Image string `json:"image"`
+	// Digest is the image's sha256 digest, when it's pinned or the cluster
+	// reports the digest it pulled
+	Digest string `json:"digest,omitempty"`
+	// Workloads run the image, as Kind/name
+	Workloads []string `json:"workloads"`
+}
+
 // ResourceResult represents the result of applying a single resource
 type ResourceResult struct {
-	Kind   string `json:"kind"`
-	Name   string `json:"name"`
-	Action string `json:"action"` // created, updated, unchanged
-	Error  string `json:"error,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Action     string `json:"action"` // created, updated, unchanged, failed, deleted, restored
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
 }
 
 // PreviewResult represents the result of a preview operation
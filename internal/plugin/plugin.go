@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Prefix is the executable name prefix of plugins on PATH (kbox-<name>)
+const Prefix = "kbox-"
+
+// Plugin is an external command that adds a kbox subcommand
+type Plugin struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Path        string   `json:"path"`
+	Args        []string `json:"args,omitempty"`     // Prepended to the user's arguments
+	Source      string   `json:"source"`             // "path" or the manifest file
+	Shadowed    []string `json:"shadowed,omitempty"` // Later matches hidden by this one
+}
+
+// Manifest declares a plugin in ~/.kbox/plugins/<name>.yaml, for plugins
+// that aren't on PATH or need a description or fixed arguments
+type Manifest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Command     string   `json:"command"` // Executable, relative to the manifest's directory or on PATH
+	Args        []string `json:"args,omitempty"`
+}
+
+// Context is what a plugin receives as JSON on stdin: the parsed kbox.yaml
+// and the cluster kbox would target. The same values are in KBOX_* env vars.
+type Context struct {
+	Name        string      `json:"name"`
+	Args        []string    `json:"args"`
+	KboxVersion string      `json:"kbox_version"`
+	KboxPath    string      `json:"kbox_path,omitempty"`
+	KubeContext string      `json:"kube_context,omitempty"`
+	Namespace   string      `json:"namespace,omitempty"`
+	ConfigFile  string      `json:"config_file,omitempty"`
+	Config      interface{} `json:"config,omitempty"`
+}
+
+// DefaultManifestDir returns ~/.kbox/plugins
+func DefaultManifestDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kbox", "plugins")
+}
+
+// Discover finds plugins declared by manifests in manifestDir and executables
+// named kbox-<name> on pathEnv (a PATH-style list). Manifests win over PATH,
+// and earlier PATH entries over later ones, like a shell lookup. Manifests
+// that can't be read or are invalid are skipped; their errors, which name
+// the file, are returned alongside the plugins found.
+func Discover(manifestDir, pathEnv string) ([]Plugin, []error) {
+	found := map[string]*Plugin{}
+	var names []string
+	add := func(p Plugin) {
+		if existing, ok := found[p.Name]; ok {
+			existing.Shadowed = append(existing.Shadowed, p.Path)
+			return
+		}
+		found[p.Name] = &p
+		names = append(names, p.Name)
+	}
+
+	var skipped []error
+	if manifestDir != "" {
+		var manifests []Plugin
+		manifests, skipped = loadManifests(manifestDir)
+		for _, p := range manifests {
+			add(p)
+		}
+	}
+
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // Missing PATH entries are common
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || name == "" || entry.IsDir() {
+				continue
+			}
+			name = strings.TrimSuffix(name, filepath.Ext(name)) // kbox-foo.exe
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			add(Plugin{Name: name, Path: path, Source: "path"})
+		}
+	}
+
+	sort.Strings(names)
+	plugins := make([]Plugin, 0, len(names))
+	for _, name := range names {
+		plugins = append(plugins, *found[name])
+	}
+	return plugins, skipped
+}
+
+// loadManifests reads every *.yaml manifest in dir, returning an error for
+// each one that it skips
+func loadManifests(dir string) ([]Plugin, []error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, []error{err}
+	}
+	sort.Strings(files)
+
+	var plugins []Plugin
+	var skipped []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("failed to read plugin manifest %s: %w", file, err))
+			continue
+		}
+		var m Manifest
+		if err := yaml.UnmarshalStrict(data, &m); err != nil {
+			skipped = append(skipped, fmt.Errorf("invalid plugin manifest %s: %w", file, err))
+			continue
+		}
+		if m.Name == "" {
+			m.Name = strings.TrimSuffix(filepath.Base(file), ".yaml")
+		}
+		if m.Command == "" {
+			skipped = append(skipped, fmt.Errorf("invalid plugin manifest %s: command is required", file))
+			continue
+		}
+
+		path := m.Command
+		if !filepath.IsAbs(path) && strings.ContainsRune(path, filepath.Separator) {
+			path = filepath.Join(dir, path)
+		} else if !filepath.IsAbs(path) {
+			if resolved, err := exec.LookPath(path); err == nil {
+				path = resolved
+			}
+		}
+		plugins = append(plugins, Plugin{
+			Name:        m.Name,
+			Description: m.Description,
+			Path:        path,
+			Args:        m.Args,
+			Source:      file,
+		})
+	}
+	return plugins, skipped
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return info.Mode()&0o111 != 0 || filepath.Ext(path) == ".exe"
+}
+
+// Env returns the KBOX_* variables describing c
+func (c *Context) Env() []string {
+	env := []string{
+		"KBOX_PLUGIN_NAME=" + c.Name,
+		"KBOX_VERSION=" + c.KboxVersion,
+	}
+	for name, value := range map[string]string{
+		"KBOX_BIN":         c.KboxPath,
+		"KBOX_CONTEXT":     c.KubeContext,
+		"KBOX_NAMESPACE":   c.Namespace,
+		"KBOX_CONFIG_FILE": c.ConfigFile,
+	} {
+		if value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	sort.Strings(env[2:])
+	return env
+}
+
+// Run executes p with args, passing c as JSON on stdin and in the environment.
+// The plugin's exit status is returned as an *exec.ExitError.
+func (p *Plugin) Run(ctx context.Context, c *Context, args []string, stdout, stderr io.Writer) error {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin context: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.Path, append(append([]string{}, p.Args...), args...)...)
+	cmd.Stdin = bytes.NewReader(append(payload, '\n'))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), c.Env()...)
+	return cmd.Run()
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverOnPath(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(first, "kbox-costreport"), "#!/bin/sh\n", 0o755)
+	writeFile(t, filepath.Join(second, "kbox-costreport"), "#!/bin/sh\n", 0o755)
+	writeFile(t, filepath.Join(second, "kbox-compliance"), "#!/bin/sh\n", 0o755)
+	writeFile(t, filepath.Join(second, "kbox-notes"), "not executable", 0o644)
+	writeFile(t, filepath.Join(second, "kubectl-foo"), "#!/bin/sh\n", 0o755)
+
+	plugins, skipped := Discover("", strings.Join([]string{first, "/nonexistent", second}, string(os.PathListSeparator)))
+	if len(skipped) != 0 {
+		t.Fatalf("Discover skipped: %v", skipped)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "compliance" || plugins[1].Name != "costreport" {
+		t.Fatalf("expected compliance and costreport, got %+v", plugins)
+	}
+
+	// The first PATH entry wins, like a shell lookup
+	cost := plugins[1]
+	if cost.Path != filepath.Join(first, "kbox-costreport") {
+		t.Errorf("expected plugin from first PATH entry, got %s", cost.Path)
+	}
+	if len(cost.Shadowed) != 1 || cost.Shadowed[0] != filepath.Join(second, "kbox-costreport") {
+		t.Errorf("expected shadowed plugin to be reported, got %v", cost.Shadowed)
+	}
+}
+
+func TestDiscoverManifest(t *testing.T) {
+	manifests, bin := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(manifests, "cost.yaml"), `
+name: costreport
+description: Estimate the monthly cost
+command: ./cost.sh
+args: ["--currency", "USD"]
+`, 0o644)
+	writeFile(t, filepath.Join(bin, "kbox-costreport"), "#!/bin/sh\n", 0o755)
+
+	plugins, skipped := Discover(manifests, bin)
+	if len(skipped) != 0 {
+		t.Fatalf("Discover skipped: %v", skipped)
+	}
+	if len(plugins) != 1 {
+		t.Fatalf("expected 1 plugin, got %+v", plugins)
+	}
+	p := plugins[0]
+	if p.Path != filepath.Join(manifests, "cost.sh") || p.Description != "Estimate the monthly cost" || len(p.Args) != 2 {
+		t.Errorf("unexpected plugin from manifest: %+v", p)
+	}
+	if len(p.Shadowed) != 1 {
+		t.Errorf("expected manifest to win over PATH, got %+v", p)
+	}
+
+	// A broken manifest is skipped without hiding the others
+	broken := filepath.Join(manifests, "broken.yaml")
+	writeFile(t, broken, "name: broken\n", 0o644)
+	plugins, skipped = Discover(manifests, "")
+	if len(plugins) != 1 || plugins[0].Name != "costreport" {
+		t.Errorf("expected costreport despite the broken manifest, got %+v", plugins)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), broken) || !strings.Contains(skipped[0].Error(), "command is required") {
+		t.Errorf("expected the broken manifest to be reported by name, got %v", skipped)
+	}
+}
+
+func TestRunPassesContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "kbox-echo")
+	writeFile(t, script, "#!/bin/sh\necho \"args=$*\"\necho \"ns=$KBOX_NAMESPACE\"\ncat\n", 0o755)
+
+	p := &Plugin{Name: "echo", Path: script, Args: []string{"--fixed"}}
+	c := &Context{
+		Name:        "echo",
+		Args:        []string{"report"},
+		KboxVersion: "1.2.3",
+		Namespace:   "staging",
+		Config:      map[string]string{"kind": "App"},
+	}
+	var stdout, stderr bytes.Buffer
+	if err := p.Run(context.Background(), c, []string{"report"}, &stdout, &stderr); err != nil {
+		t.Fatalf("Run failed: %v (%s)", err, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"args=--fixed report", "ns=staging", `"kbox_version":"1.2.3"`, `"config":{"kind":"App"}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in plugin output, got:\n%s", want, out)
+		}
+	}
+}
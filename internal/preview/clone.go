@@ -0,0 +1,72 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+)
+
+// Endpoint is a namespace on a cluster holding the app's dependencies
+type Endpoint struct {
+	Client    kubernetes.Interface
+	Config    *rest.Config
+	Namespace string
+}
+
+// DependencyPod returns the pod of an app's dependency (its StatefulSet's only replica)
+func DependencyPod(app, depType string) string {
+	return fmt.Sprintf("%s-%s-0", app, depType)
+}
+
+// CloneData copies the data of the app's depType dependency from src to dst,
+// streaming the source's dump straight into the target's restore, and
+// returns how many bytes were copied. The target's data is replaced.
+func CloneData(ctx context.Context, app, depType string, src, dst Endpoint) (int64, error) {
+	template, ok := dependencies.Get(depType)
+	if !ok || len(template.DumpCommand) == 0 {
+		return 0, fmt.Errorf("cloning %s data is not supported", depType)
+	}
+	pod := DependencyPod(app, depType)
+
+	reader, writer := io.Pipe()
+	counter := &countingReader{r: reader}
+	dumpErr := make(chan error, 1)
+	go func() {
+		err := debug.ExecStream(ctx, src.Client, src.Config, src.Namespace, pod, depType, template.DumpCommand, nil, writer)
+		writer.CloseWithError(err)
+		dumpErr <- err
+	}()
+
+	err := debug.ExecStream(ctx, dst.Client, dst.Config, dst.Namespace, pod, depType, template.RestoreCommand, counter, nil)
+	reader.Close()
+	// A failed dump may still have restored part of the data
+	if err := <-dumpErr; err != nil {
+		return counter.n, fmt.Errorf("dump from %s/%s failed: %w", src.Namespace, pod, err)
+	}
+	if err != nil {
+		return counter.n, fmt.Errorf("restore into %s/%s failed: %w", dst.Namespace, pod, err)
+	}
+
+	if len(template.ReloadCommand) > 0 {
+		_ = debug.ExecStream(ctx, dst.Client, dst.Config, dst.Namespace, pod, depType, template.ReloadCommand, nil, nil)
+	}
+	return counter.n, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -110,6 +110,29 @@ func (m *Manager) Destroy(ctx context.Context, name string) error {
 	return nil
 }
 
+// WaitDestroyed waits for a destroyed preview's namespace to be gone, which
+// happens once every object in it (including PVCs) has been finalized
+func (m *Manager) WaitDestroyed(ctx context.Context, name string, timeout time.Duration) error {
+	nsName := m.namespaceName(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		_, err := m.client.CoreV1().Namespaces().Get(ctx, nsName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for namespace %s to be deleted\n  → Check for stuck finalizers: kubectl get all,pvc -n %s", nsName, nsName)
+		case <-ticker.C:
+		}
+	}
+}
+
 // List returns all preview environments for the app
 func (m *Manager) List(ctx context.Context) ([]PreviewInfo, error) {
 	// List namespaces with preview labels for this app
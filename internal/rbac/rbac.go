@@ -0,0 +1,256 @@
+// Package rbac generates the least-privilege RBAC a CI service account needs
+// to deploy a bundle with kbox: only the resources kbox will touch, with only
+// the verbs it uses on them
+package rbac
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// Options describes how the deployer will run kbox
+type Options struct {
+	// ServiceAccount is the deployer's name (default: <app>-deployer)
+	ServiceAccount string
+	// Prune grants what kbox deploy --prune needs to list and delete
+	// orphaned resources
+	Prune bool
+	// Transactional grants what kbox deploy --transactional needs to
+	// restore or delete resources after a failed apply
+	Transactional bool
+	// ClusterChecks adds a ClusterRole for the pre-deploy checks that read
+	// cluster-scoped resources: the namespace's Pod Security level and the
+	// nodes' free capacity. kbox skips those checks without it.
+	ClusterChecks bool
+}
+
+// Verbs kbox uses to apply a resource with Server-Side Apply: it reads the
+// live object first, and a patch that creates it needs create
+var applyVerbs = []string{"get", "create", "patch"}
+
+// Verbs of the informers that follow a rollout
+var watchVerbs = []string{"get", "list", "watch"}
+
+// resource is a resource of an API group
+type resource struct {
+	group    string
+	resource string
+}
+
+// prunable are the resources kbox deploy --prune lists and deletes
+var prunable = []resource{
+	{"", "configmaps"}, {"", "secrets"}, {"", "services"}, {"", "serviceaccounts"}, {"", "persistentvolumeclaims"},
+	{"apps", "deployments"}, {"apps", "statefulsets"},
+	{"networking.k8s.io", "ingresses"}, {"networking.k8s.io", "networkpolicies"},
+	{"autoscaling", "horizontalpodautoscalers"}, {"policy", "poddisruptionbudgets"},
+	{"batch", "jobs"}, {"batch", "cronjobs"},
+}
+
+// Generate returns the deployer's ServiceAccount, Role and RoleBinding in the
+// bundle's namespace, and with ClusterChecks a ClusterRole and
+// ClusterRoleBinding. apps are the bundle's app configs, for their smoke tests.
+func Generate(app string, bundle *render.Bundle, apps []*config.AppConfig, opts Options) []runtime.Object {
+	namespace := "default"
+	if bundle.Anchor != nil && bundle.Anchor.Namespace != "" {
+		namespace = bundle.Anchor.Namespace
+	}
+	name := opts.ServiceAccount
+	if name == "" {
+		name = app + "-deployer"
+	}
+	labels := map[string]string{"app.kubernetes.io/name": app, "app.kubernetes.io/managed-by": "kbox"}
+
+	verbs := map[resource]map[string]bool{}
+	grant := func(r resource, vs ...string) {
+		if verbs[r] == nil {
+			verbs[r] = map[string]bool{}
+		}
+		for _, v := range vs {
+			verbs[r][v] = true
+		}
+	}
+
+	// The anchor and the release history
+	grant(resource{"", "configmaps"}, "get", "create", "patch", "update")
+	// The pre-deploy quota check
+	grant(resource{"", "resourcequotas"}, "list")
+
+	applied := map[resource]bool{}
+	for _, obj := range bundle.AllObjects() {
+		r, ok := resourceOf(obj)
+		if !ok {
+			continue
+		}
+		applied[r] = true
+		grant(r, applyVerbs...)
+		if opts.Transactional {
+			grant(r, "update", "delete")
+		}
+	}
+	if opts.Prune {
+		for _, r := range prunable {
+			grant(r, "list", "delete")
+		}
+	}
+
+	// Following the rollout, and the image digests of its pods
+	if applied[resource{"apps", "deployments"}] || applied[resource{"apps", "statefulsets"}] {
+		for _, r := range []resource{{"apps", "deployments"}, {"apps", "statefulsets"}, {"apps", "replicasets"}, {"", "pods"}, {"", "events"}} {
+			grant(r, watchVerbs...)
+		}
+	}
+	// Waiting for Jobs (e.g. migrations) and capturing their logs
+	if applied[resource{"batch", "jobs"}] {
+		grant(resource{"batch", "jobs"}, watchVerbs...)
+		grant(resource{"", "pods"}, "list")
+		grant(resource{"", "pods/log"}, "get")
+	}
+	// The LoadBalancer address
+	if applied[resource{"", "services"}] {
+		grant(resource{"", "services"}, "get")
+	}
+	// Smoke tests reach a ready pod through a port-forward, or exec into it
+	for _, cfg := range apps {
+		for _, t := range cfg.Spec.Tests {
+			grant(resource{"", "pods"}, "list")
+			if t.HTTP != nil {
+				grant(resource{"", "pods/portforward"}, "create")
+			}
+			if t.Exec != nil {
+				grant(resource{"", "pods/exec"}, "create")
+			}
+		}
+	}
+
+	rules := rulesFor(verbs)
+	// Creating the app's Roles, and binding them, needs the permissions they
+	// grant unless the deployer may escalate and bind these Roles by name
+	var roleNames []string
+	for _, role := range bundle.Roles {
+		roleNames = append(roleNames, role.Name)
+	}
+	if len(roleNames) > 0 {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{"rbac.authorization.k8s.io"},
+			Resources:     []string{"roles"},
+			Verbs:         []string{"bind", "escalate"},
+			ResourceNames: roleNames,
+		})
+	}
+
+	meta := func(name string, namespaced bool) metav1.ObjectMeta {
+		m := metav1.ObjectMeta{Name: name, Labels: labels}
+		if namespaced {
+			m.Namespace = namespace
+		}
+		return m
+	}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}
+	objects := []runtime.Object{
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta(name, true),
+		},
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: meta(name, true),
+			Rules:      rules,
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: meta(name, true),
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: name},
+		},
+	}
+
+	if opts.ClusterChecks {
+		// Cluster-scoped, so named after the namespace too
+		clusterName := namespace + "-" + name
+		objects = append(objects,
+			&rbacv1.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+				ObjectMeta: meta(clusterName, false),
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get"}, ResourceNames: []string{namespace}},
+					{APIGroups: []string{""}, Resources: []string{"nodes", "pods"}, Verbs: []string{"list"}},
+				},
+			},
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+				ObjectMeta: meta(clusterName, false),
+				Subjects:   []rbacv1.Subject{subject},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: clusterName},
+			},
+		)
+	}
+	return objects
+}
+
+// resourceOf returns the resource kbox applies obj as
+func resourceOf(obj runtime.Object) (resource, bool) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	// The apply engine doesn't create namespaces
+	if gvk.Kind == "" || gvk.Kind == "Namespace" {
+		return resource{}, false
+	}
+	plural := strings.ToLower(gvk.Kind) + "s"
+	switch gvk.Kind {
+	case "Ingress":
+		plural = "ingresses"
+	case "NetworkPolicy":
+		plural = "networkpolicies"
+	}
+	return resource{gvk.Group, plural}, true
+}
+
+// rulesFor turns granted verbs into rules, one per API group and verb set,
+// sorted so the output is stable
+func rulesFor(verbs map[resource]map[string]bool) []rbacv1.PolicyRule {
+	type key struct{ group, verbs string }
+	byKey := map[key][]string{}
+	for r, vs := range verbs {
+		list := make([]string, 0, len(vs))
+		for v := range vs {
+			list = append(list, v)
+		}
+		slices.SortFunc(list, func(a, b string) int { return verbOrder(a) - verbOrder(b) })
+		k := key{r.group, strings.Join(list, ",")}
+		byKey[k] = append(byKey[k], r.resource)
+	}
+
+	var rules []rbacv1.PolicyRule
+	for k, resources := range byKey {
+		sort.Strings(resources)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{k.group},
+			Resources: resources,
+			Verbs:     strings.Split(k.verbs, ","),
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].APIGroups[0] != rules[j].APIGroups[0] {
+			return rules[i].APIGroups[0] < rules[j].APIGroups[0]
+		}
+		return rules[i].Resources[0] < rules[j].Resources[0]
+	})
+	return rules
+}
+
+// verbOrder orders verbs as kubectl describes them: reads, then writes
+func verbOrder(verb string) int {
+	order := []string{"get", "list", "watch", "create", "patch", "update", "delete"}
+	if i := slices.Index(order, verb); i >= 0 {
+		return i
+	}
+	return len(order)
+}
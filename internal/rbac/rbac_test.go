@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"slices"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// verbsOf returns the verbs the rules grant on group/resource
+func verbsOf(rules []rbacv1.PolicyRule, group, resource string) []string {
+	var verbs []string
+	for _, r := range rules {
+		if slices.Contains(r.APIGroups, group) && slices.Contains(r.Resources, resource) {
+			verbs = append(verbs, r.Verbs...)
+		}
+	}
+	return verbs
+}
+
+func renderApp(t *testing.T) (*render.Bundle, *config.AppConfig) {
+	t.Helper()
+	cfg := &config.AppConfig{
+		APIVersion: "kbox.dev/v1",
+		Kind:       "App",
+		Metadata:   config.Metadata{Name: "shop", Namespace: "shop"},
+		Spec: config.AppSpec{
+			Image: "shop:v1",
+			Port:  8080,
+			Tests: []config.SmokeTestConfig{{Name: "health", HTTP: &config.HTTPTestConfig{Path: "/health"}}},
+		},
+	}
+	bundle, err := render.New(cfg).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundle, cfg
+}
+
+func TestGenerate(t *testing.T) {
+	bundle, cfg := renderApp(t)
+	objects := Generate("shop", bundle, []*config.AppConfig{cfg}, Options{})
+	if len(objects) != 3 {
+		t.Fatalf("expected ServiceAccount, Role and RoleBinding, got %d objects", len(objects))
+	}
+	role := objects[1].(*rbacv1.Role)
+	if role.Name != "shop-deployer" || role.Namespace != "shop" {
+		t.Errorf("unexpected role %s/%s", role.Namespace, role.Name)
+	}
+
+	if verbs := verbsOf(role.Rules, "apps", "deployments"); !slices.Equal(verbs, []string{"get", "list", "watch", "create", "patch"}) {
+		t.Errorf("expected deployments to be applied and watched, got %v", verbs)
+	}
+	if verbs := verbsOf(role.Rules, "", "pods/portforward"); !slices.Equal(verbs, []string{"create"}) {
+		t.Errorf("expected port-forwards for the HTTP smoke test, got %v", verbs)
+	}
+	// Nothing the bundle doesn't contain, and no deletes without --prune
+	if verbs := verbsOf(role.Rules, "networking.k8s.io", "ingresses"); len(verbs) != 0 {
+		t.Errorf("expected no access to ingresses, got %v", verbs)
+	}
+	for _, r := range role.Rules {
+		if slices.Contains(r.Verbs, "delete") || slices.Contains(r.Verbs, "*") || slices.Contains(r.Resources, "*") {
+			t.Errorf("unexpected rule %+v", r)
+		}
+	}
+
+	objects = Generate("shop", bundle, nil, Options{ServiceAccount: "ci", Prune: true, ClusterChecks: true})
+	role = objects[1].(*rbacv1.Role)
+	if verbs := verbsOf(role.Rules, "networking.k8s.io", "ingresses"); !slices.Equal(verbs, []string{"list", "delete"}) {
+		t.Errorf("expected --prune to list and delete ingresses, got %v", verbs)
+	}
+	if len(objects) != 5 {
+		t.Fatalf("expected a ClusterRole and ClusterRoleBinding with cluster checks, got %d objects", len(objects))
+	}
+	binding := objects[4].(*rbacv1.ClusterRoleBinding)
+	if binding.Name != "shop-ci" || binding.Subjects[0].Name != "ci" || binding.Subjects[0].Namespace != "shop" {
+		t.Errorf("unexpected cluster role binding %+v", binding)
+	}
+}
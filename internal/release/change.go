@@ -0,0 +1,87 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// CurrentChange describes a deploy run from dir: the git commit and branch
+// checked out there, and who is running kbox. Missing details are left empty.
+func CurrentChange(ctx context.Context, dir string) render.ChangeInfo {
+	change := render.ChangeInfo{
+		GitSHA:     gitOutput(ctx, dir, "rev-parse", "HEAD"),
+		GitBranch:  gitOutput(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"),
+		DeployedBy: Deployer(),
+	}
+	// CI checkouts are usually a detached HEAD; the CI knows the branch
+	if change.GitBranch == "HEAD" {
+		change.GitBranch = firstEnv("GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME")
+	}
+	return change
+}
+
+// ChangeCause formats the kubernetes.io/change-cause annotation,
+// e.g. "kbox deploy: myapp:v2 (main@1a2b3c4 by alice)"
+func ChangeCause(action, image string, change render.ChangeInfo) string {
+	var source []string
+	if change.GitSHA != "" {
+		ref := ShortSHA(change.GitSHA)
+		if change.GitBranch != "" {
+			ref = change.GitBranch + "@" + ref
+		}
+		source = append(source, ref)
+	}
+	if change.DeployedBy != "" {
+		source = append(source, "by "+change.DeployedBy)
+	}
+
+	cause := fmt.Sprintf("kbox %s: %s", action, image)
+	if len(source) > 0 {
+		cause += " (" + strings.Join(source, " ") + ")"
+	}
+	return cause
+}
+
+// ShortSHA abbreviates a git commit hash for display
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// Deployer identifies who is deploying: an explicit KBOX_DEPLOYED_BY, the CI
+// user that triggered the pipeline, or the local user
+func Deployer() string {
+	if who := firstEnv("KBOX_DEPLOYED_BY", "GITHUB_ACTOR", "GITLAB_USER_LOGIN"); who != "" {
+		return who
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) string {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -23,7 +23,7 @@ type RollbackOptions struct {
 }
 
 // Rollback reverts to a previous release
-func Rollback(ctx context.Context, client *kubernetes.Clientset, namespace, appName string, opts RollbackOptions) (*RollbackResult, error) {
+func Rollback(ctx context.Context, client kubernetes.Interface, namespace, appName string, opts RollbackOptions) (*RollbackResult, error) {
 	store := NewStore(client, namespace, appName)
 
 	// Determine target release
@@ -64,12 +64,23 @@ func Rollback(ctx context.Context, client *kubernetes.Clientset, namespace, appN
 		return result, nil
 	}
 
-	// Re-render the bundle from the stored config
+	// Re-render the bundle from the stored config. The rollback deploys the
+	// target's source, so it keeps the target's git details.
 	renderer := render.New(cfg)
 	bundle, err := renderer.Render()
 	if err != nil {
 		return nil, fmt.Errorf("failed to render release: %w", err)
 	}
+	change := render.ChangeInfo{
+		GitSHA:     target.GitSHA,
+		GitBranch:  target.GitBranch,
+		DeployedBy: Deployer(),
+	}
+	if next, err := store.NextRevision(ctx); err == nil {
+		change.Revision = next
+	}
+	change.Cause = ChangeCause("rollback to "+FormatRevision(target.Revision), target.Image, change)
+	bundle = bundle.WithChange(change)
 
 	// Apply the bundle
 	out := opts.Output
@@ -88,7 +99,7 @@ func Rollback(ctx context.Context, client *kubernetes.Clientset, namespace, appN
 	}
 
 	// Save the rollback as a new release (so we can rollback the rollback)
-	newRevision, err := store.Save(ctx, cfg)
+	newRevision, err := store.SaveWithChange(ctx, cfg, change, bundle.ConfigVersions())
 	if err != nil {
 		// Non-fatal - the rollback succeeded, just history tracking failed
 		if opts.Output != nil {
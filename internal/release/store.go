@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 const (
@@ -22,6 +24,10 @@ const (
 	LabelManagedBy = "app.kubernetes.io/managed-by"
 	// LabelApp identifies the application
 	LabelApp = "app"
+	// LabelOwnerManagedBy and LabelOwnerApp are kbox's ownership labels
+	// (see render.LabelManagedBy and render.LabelApp)
+	LabelOwnerManagedBy = "kbox.dev/managed-by"
+	LabelOwnerApp       = "kbox.dev/app"
 	// LabelReleaseRevision stores the revision number
 	LabelReleaseRevision = "kbox.dev/revision"
 	// AnnotationReleaseTime stores when the release was created
@@ -35,10 +41,33 @@ const (
 
 // Release represents a single deployment release
 type Release struct {
-	Revision  int       `json:"revision"`
-	Timestamp time.Time `json:"timestamp"`
+	Revision   int       `json:"revision"`
+	Timestamp  time.Time `json:"timestamp"`
+	Image      string    `json:"image"`
+	Config     string    `json:"config"` // Serialized AppConfig
+	GitSHA     string    `json:"git_sha,omitempty"`
+	GitBranch  string    `json:"git_branch,omitempty"`
+	DeployedBy string    `json:"deployed_by,omitempty"`
+	Cause      string    `json:"cause,omitempty"`
+	// ConfigVersions lists the versioned ConfigMaps and Secrets the release
+	// uses (see render.Bundle.ConfigVersions), so prune keeps them for rollback
+	ConfigVersions []string `json:"config_versions,omitempty"`
+	// PromotedFrom is the release of another environment kbox promote
+	// deployed this one's image from
+	PromotedFrom *Promotion `json:"promoted_from,omitempty"`
+	// PromotedTo are the releases kbox promote made from this one
+	PromotedTo []Promotion `json:"promoted_to,omitempty"`
+}
+
+// Promotion is the other end of a kbox promote: a release of the same app
+// in another environment
+type Promotion struct {
+	Env       string    `json:"env"`
+	Context   string    `json:"context,omitempty"`
+	Namespace string    `json:"namespace"`
+	Revision  int       `json:"revision,omitempty"` // 0 when the source had no release history
 	Image     string    `json:"image"`
-	Config    string    `json:"config"` // Serialized AppConfig
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // Store handles release history persistence using ConfigMaps
@@ -59,11 +88,17 @@ func NewStore(client kubernetes.Interface, namespace, appName string) *Store {
 
 // configMapName returns the name of the ConfigMap storing release history
 func (s *Store) configMapName() string {
-	return fmt.Sprintf("%s-releases", s.appName)
+	return render.ReleasesName(s.appName)
 }
 
 // Save stores a new release, returning the revision number
 func (s *Store) Save(ctx context.Context, cfg *config.AppConfig) (int, error) {
+	return s.SaveWithChange(ctx, cfg, render.ChangeInfo{}, nil)
+}
+
+// SaveWithChange stores a new release along with where it came from, who
+// deployed it, and the versioned config objects it uses
+func (s *Store) SaveWithChange(ctx context.Context, cfg *config.AppConfig, change render.ChangeInfo, configVersions []string) (int, error) {
 	// Get existing releases
 	releases, err := s.List(ctx)
 	if err != nil && !errors.IsNotFound(err) {
@@ -84,10 +119,16 @@ func (s *Store) Save(ctx context.Context, cfg *config.AppConfig) (int, error) {
 
 	// Create new release
 	release := Release{
-		Revision:  nextRevision,
-		Timestamp: time.Now().UTC(),
-		Image:     cfg.Spec.Image,
-		Config:    string(configJSON),
+		Revision:   nextRevision,
+		Timestamp:  time.Now().UTC(),
+		Image:      cfg.Spec.Image,
+		Config:     string(configJSON),
+		GitSHA:     change.GitSHA,
+		GitBranch:  change.GitBranch,
+		DeployedBy: change.DeployedBy,
+		Cause:      change.Cause,
+
+		ConfigVersions: configVersions,
 	}
 
 	// Add to releases
@@ -106,6 +147,63 @@ func (s *Store) Save(ctx context.Context, cfg *config.AppConfig) (int, error) {
 	return nextRevision, nil
 }
 
+// SetPromotedFrom records that a release was promoted from another
+// environment's
+func (s *Store) SetPromotedFrom(ctx context.Context, revision int, from Promotion) error {
+	return s.update(ctx, revision, func(r *Release) {
+		r.PromotedFrom = &from
+	})
+}
+
+// AddPromotedTo records that a release was promoted to another environment
+func (s *Store) AddPromotedTo(ctx context.Context, revision int, to Promotion) error {
+	return s.update(ctx, revision, func(r *Release) {
+		r.PromotedTo = append(r.PromotedTo, to)
+	})
+}
+
+// update changes a stored release
+func (s *Store) update(ctx context.Context, revision int, change func(*Release)) error {
+	releases, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range releases {
+		if releases[i].Revision == revision {
+			change(&releases[i])
+			return s.saveReleases(ctx, releases)
+		}
+	}
+	return fmt.Errorf("release %d not found", revision)
+}
+
+// NextRevision returns the revision number the next saved release will get
+func (s *Store) NextRevision(ctx context.Context) (int, error) {
+	releases, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(releases) == 0 {
+		return 1, nil
+	}
+	return releases[len(releases)-1].Revision + 1, nil
+}
+
+// ConfigVersions returns the versioned ConfigMaps and Secrets used by any
+// stored release
+func (s *Store) ConfigVersions(ctx context.Context) ([]string, error) {
+	releases, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, r := range releases {
+		versions = append(versions, r.ConfigVersions...)
+	}
+	slices.Sort(versions)
+	return slices.Compact(versions), nil
+}
+
 // List returns all stored releases, sorted by revision
 func (s *Store) List(ctx context.Context) ([]Release, error) {
 	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.configMapName(), metav1.GetOptions{})
@@ -199,8 +297,10 @@ func (s *Store) saveReleases(ctx context.Context, releases []Release) error {
 			Name:      s.configMapName(),
 			Namespace: s.namespace,
 			Labels: map[string]string{
-				LabelManagedBy: "kbox",
-				LabelApp:       s.appName,
+				LabelManagedBy:      "kbox",
+				LabelApp:            s.appName,
+				LabelOwnerManagedBy: "kbox",
+				LabelOwnerApp:       s.appName,
 			},
 			Annotations: map[string]string{
 				AnnotationReleaseTime: time.Now().UTC().Format(time.RFC3339),
@@ -222,9 +322,10 @@ func (s *Store) saveReleases(ctx context.Context, releases []Release) error {
 		return err
 	}
 
-	// Update existing
+	// Update existing (labels too, so history saved by older versions gains ownership labels)
 	existing.Data = cm.Data
 	existing.Annotations = cm.Annotations
+	existing.Labels = cm.Labels
 	_, err = s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, existing, metav1.UpdateOptions{})
 	return err
 }
@@ -3,6 +3,7 @@ package release
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/render"
 )
 
 func TestReleaseSaveAndRetrieve(t *testing.T) {
@@ -439,3 +441,124 @@ func TestRollbackRestoresConfig(t *testing.T) {
 		t.Errorf("expected replicas 3, got %d", restored.Spec.Replicas)
 	}
 }
+
+func TestReleaseSaveWithChange(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	store := NewStore(client, "default", "myapp")
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v2"},
+	}
+
+	next, err := store.NextRevision(ctx)
+	if err != nil || next != 1 {
+		t.Fatalf("expected next revision 1, got %d (%v)", next, err)
+	}
+
+	change := render.ChangeInfo{Revision: next, GitSHA: "1a2b3c4d5e6f", GitBranch: "main", DeployedBy: "alice"}
+	change.Cause = ChangeCause("deploy -e prod", cfg.Spec.Image, change)
+	if change.Cause != "kbox deploy -e prod: myapp:v2 (main@1a2b3c4 by alice)" {
+		t.Errorf("unexpected change cause %q", change.Cause)
+	}
+
+	rev, err := store.SaveWithChange(ctx, cfg, change, nil)
+	if err != nil || rev != next {
+		t.Fatalf("expected revision %d, got %d (%v)", next, rev, err)
+	}
+	saved, err := store.Get(ctx, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.GitSHA != change.GitSHA || saved.GitBranch != "main" || saved.DeployedBy != "alice" || saved.Cause != change.Cause {
+		t.Errorf("change not saved with release: %+v", saved)
+	}
+
+	if next, _ := store.NextRevision(ctx); next != 2 {
+		t.Errorf("expected next revision 2, got %d", next)
+	}
+}
+
+func TestChangeCauseWithoutGit(t *testing.T) {
+	cause := ChangeCause("rollback to v3", "myapp:v1", render.ChangeInfo{DeployedBy: "bob"})
+	if cause != "kbox rollback to v3: myapp:v1 (by bob)" {
+		t.Errorf("unexpected change cause %q", cause)
+	}
+}
+
+func TestStoreConfigVersions(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	store := NewStore(client, "default", "myapp")
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v1", ConfigVersioning: true},
+	}
+
+	if _, err := store.SaveWithChange(ctx, cfg, render.ChangeInfo{}, []string{"ConfigMap/myapp-config-aaa", "Secret/myapp-secrets-bbb"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SaveWithChange(ctx, cfg, render.ChangeInfo{}, []string{"ConfigMap/myapp-config-ccc", "Secret/myapp-secrets-bbb"}); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := store.ConfigVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ConfigMap/myapp-config-aaa", "ConfigMap/myapp-config-ccc", "Secret/myapp-secrets-bbb"}
+	if !slices.Equal(versions, want) {
+		t.Errorf("expected %v, got %v", want, versions)
+	}
+}
+
+func TestStorePromotion(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	staging := NewStore(client, "staging", "myapp")
+	prod := NewStore(client, "prod", "myapp")
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v2"},
+	}
+	image := "myapp:v2@sha256:abc"
+
+	from, _ := staging.Save(ctx, cfg)
+	prod.Save(ctx, cfg)
+	to, _ := prod.Save(ctx, cfg)
+
+	if err := prod.SetPromotedFrom(ctx, to, Promotion{Env: "staging", Namespace: "staging", Revision: from, Image: image}); err != nil {
+		t.Fatal(err)
+	}
+	if err := staging.AddPromotedTo(ctx, from, Promotion{Env: "prod", Namespace: "prod", Revision: to, Image: image}); err != nil {
+		t.Fatal(err)
+	}
+
+	promoted, _ := prod.Get(ctx, to)
+	if promoted.PromotedFrom == nil || promoted.PromotedFrom.Env != "staging" || promoted.PromotedFrom.Revision != from {
+		t.Errorf("expected the prod release promoted from staging #%d, got %+v", from, promoted.PromotedFrom)
+	}
+	source, _ := staging.Get(ctx, from)
+	if len(source.PromotedTo) != 1 || source.PromotedTo[0].Env != "prod" || source.PromotedTo[0].Revision != to {
+		t.Errorf("expected the staging release promoted to prod #%d, got %+v", to, source.PromotedTo)
+	}
+
+	if err := prod.SetPromotedFrom(ctx, 99, Promotion{}); err == nil {
+		t.Error("expected an error for a missing release")
+	}
+}
+
+func TestReleaseStoredUnderSharedName(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	store := NewStore(client, "default", "myapp")
+
+	cfg := &config.AppConfig{Metadata: config.Metadata{Name: "myapp"}, Spec: config.AppSpec{Image: "myapp:v1"}}
+	if _, err := store.Save(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// prune and down keep the history by this name
+	if _, err := client.CoreV1().ConfigMaps("default").Get(ctx, render.ReleasesName("myapp"), metav1.GetOptions{}); err != nil {
+		t.Errorf("release history not stored as %s: %v", render.ReleasesName("myapp"), err)
+	}
+}
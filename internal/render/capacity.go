@@ -0,0 +1,80 @@
+package render
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// WorkloadRequests is what one of the bundle's workloads asks the scheduler for
+type WorkloadRequests struct {
+	// Workload is Kind/name, e.g. Deployment/myapp
+	Workload string
+	// Pods is the most pods the workload runs: its replicas, or the HPA's
+	// maxReplicas when autoscaled
+	Pods int64
+	// PerPod is the CPU and memory each pod requests
+	PerPod corev1.ResourceList
+	// NodeSelector limits the nodes the pods can run on
+	NodeSelector map[string]string
+}
+
+// Total returns the requests of all the workload's pods together
+func (w WorkloadRequests) Total() corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for name, q := range w.PerPod {
+		total[name] = *resource.NewMilliQuantity(q.MilliValue()*w.Pods, q.Format)
+	}
+	return total
+}
+
+// ResourceRequests returns the resource requests of the bundle's long-running
+// workloads. Jobs and CronJobs are left out: their pods come and go.
+func (b *Bundle) ResourceRequests() []WorkloadRequests {
+	var requests []WorkloadRequests
+	add := func(kind, name string, replicas *int32, spec *corev1.PodSpec) {
+		pods := int64(1)
+		if replicas != nil {
+			pods = int64(*replicas)
+		}
+		if hpa := b.HPA; hpa != nil && hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+			pods = max(pods, int64(hpa.Spec.MaxReplicas))
+		}
+		requests = append(requests, WorkloadRequests{
+			Workload:     kind + "/" + name,
+			Pods:         pods,
+			PerPod:       PodRequests(spec),
+			NodeSelector: spec.NodeSelector,
+		})
+	}
+	for _, ss := range b.StatefulSets {
+		add("StatefulSet", ss.Name, ss.Spec.Replicas, &ss.Spec.Template.Spec)
+	}
+	for _, dep := range b.Deployments {
+		add("Deployment", dep.Name, dep.Spec.Replicas, &dep.Spec.Template.Spec)
+	}
+	return requests
+}
+
+// PodRequests returns the CPU and memory a pod requests from the scheduler:
+// its containers' requests added up, or the largest init container's if
+// that is more, since init containers run one at a time before the others
+func PodRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var sum resource.Quantity
+		for _, c := range spec.Containers {
+			if q, ok := c.Resources.Requests[name]; ok {
+				sum.Add(q)
+			}
+		}
+		for _, c := range spec.InitContainers {
+			if q, ok := c.Resources.Requests[name]; ok && q.Cmp(sum) > 0 {
+				sum = q.DeepCopy()
+			}
+		}
+		if !sum.IsZero() {
+			requests[name] = sum
+		}
+	}
+	return requests
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestResourceRequests(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:       "myapp:v1",
+			Port:        8080,
+			Replicas:    2,
+			Resources:   &config.ResourceConfig{CPU: "250m", Memory: "256Mi"},
+			Autoscaling: &config.AutoscalingConfig{Enabled: true, MinReplicas: 2, MaxReplicas: 6},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	requests := bundle.ResourceRequests()
+	if len(requests) != 1 {
+		t.Fatalf("expected one workload, got %+v", requests)
+	}
+	w := requests[0]
+	if w.Workload != "Deployment/myapp" || w.Pods != 6 {
+		t.Errorf("expected Deployment/myapp sized for the HPA max of 6 pods, got %s with %d", w.Workload, w.Pods)
+	}
+	total := w.Total()
+	cpu, mem := total[corev1.ResourceCPU], total[corev1.ResourceMemory]
+	if cpu.String() != "1500m" || mem.String() != "1536Mi" {
+		t.Errorf("expected 1500m CPU / 1536Mi memory in total, got %s / %s", cpu.String(), mem.String())
+	}
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"slices"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// Change-tracking annotations stamped on Deployments at deploy time.
+// The Deployment controller copies them to each new ReplicaSet, so
+// 'kubectl rollout history' shows the change cause even outside kbox.
+const (
+	AnnotationRevision    = "kbox.dev/revision"
+	AnnotationGitSHA      = "kbox.dev/git-sha"
+	AnnotationGitBranch   = "kbox.dev/git-branch"
+	AnnotationDeployedBy  = "kbox.dev/deployed-by"
+	AnnotationChangeCause = "kubernetes.io/change-cause"
+)
+
+// ChangeInfo describes a deploy: which release it is, what source it came from, and who ran it
+type ChangeInfo struct {
+	Revision   int    `json:"revision,omitempty"`
+	GitSHA     string `json:"git_sha,omitempty"`
+	GitBranch  string `json:"git_branch,omitempty"`
+	DeployedBy string `json:"deployed_by,omitempty"`
+	Cause      string `json:"cause,omitempty"`
+}
+
+// Annotations returns the change-tracking annotations for the non-empty fields
+func (c ChangeInfo) Annotations() map[string]string {
+	annotations := make(map[string]string)
+	if c.Revision > 0 {
+		annotations[AnnotationRevision] = strconv.Itoa(c.Revision)
+	}
+	for key, value := range map[string]string{
+		AnnotationGitSHA:      c.GitSHA,
+		AnnotationGitBranch:   c.GitBranch,
+		AnnotationDeployedBy:  c.DeployedBy,
+		AnnotationChangeCause: c.Cause,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// ChangeInfoFrom reads change-tracking annotations back from an object
+func ChangeInfoFrom(annotations map[string]string) ChangeInfo {
+	revision, _ := strconv.Atoi(annotations[AnnotationRevision])
+	return ChangeInfo{
+		Revision:   revision,
+		GitSHA:     annotations[AnnotationGitSHA],
+		GitBranch:  annotations[AnnotationGitBranch],
+		DeployedBy: annotations[AnnotationDeployedBy],
+		Cause:      annotations[AnnotationChangeCause],
+	}
+}
+
+// WithChange returns a copy of the bundle whose Deployments (or app
+// StatefulSet) carry the change
+// annotations. The bundle itself is not modified, so it can be applied to
+// several clusters at once with a different revision for each.
+func (b *Bundle) WithChange(c ChangeInfo) *Bundle {
+	annotations := c.Annotations()
+	stamp := func(dep *appsv1.Deployment) *appsv1.Deployment {
+		copied := dep.DeepCopy()
+		if copied.Annotations == nil {
+			copied.Annotations = make(map[string]string)
+		}
+		for key, value := range annotations {
+			copied.Annotations[key] = value
+		}
+		return copied
+	}
+
+	stamped := *b
+	stamped.Deployments = make([]*appsv1.Deployment, len(b.Deployments))
+	for i, dep := range b.Deployments {
+		stamped.Deployments[i] = stamp(dep)
+		if dep == b.Deployment {
+			stamped.Deployment = stamped.Deployments[i]
+		}
+	}
+	if b.Deployment != nil && stamped.Deployment == b.Deployment {
+		stamped.Deployment = stamp(b.Deployment)
+	}
+
+	if b.AppStatefulSet != nil {
+		ss := b.AppStatefulSet.DeepCopy()
+		if ss.Annotations == nil {
+			ss.Annotations = make(map[string]string)
+		}
+		for key, value := range annotations {
+			ss.Annotations[key] = value
+		}
+		stamped.AppStatefulSet = ss
+		stamped.StatefulSets = slices.Clone(b.StatefulSets)
+		for i, existing := range stamped.StatefulSets {
+			if existing == b.AppStatefulSet {
+				stamped.StatefulSets[i] = ss
+			}
+		}
+	}
+	return &stamped
+}
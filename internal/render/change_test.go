@@ -0,0 +1,53 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestBundleWithChange(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v2", Port: 8080},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	change := ChangeInfo{
+		Revision:   4,
+		GitSHA:     "1a2b3c4d5e6f",
+		GitBranch:  "main",
+		DeployedBy: "alice",
+		Cause:      "kbox deploy: myapp:v2 (main@1a2b3c4 by alice)",
+	}
+	stamped := bundle.WithChange(change)
+
+	annotations := stamped.Deployment.Annotations
+	if annotations[AnnotationRevision] != "4" || annotations[AnnotationChangeCause] != change.Cause {
+		t.Errorf("expected change annotations, got %v", annotations)
+	}
+	if stamped.Deployments[0] != stamped.Deployment {
+		t.Error("expected Deployment to point at the stamped Deployments[0]")
+	}
+	if got := ChangeInfoFrom(annotations); got != change {
+		t.Errorf("expected %+v back from annotations, got %+v", change, got)
+	}
+
+	// The original bundle is shared between clusters and must stay untouched
+	if _, ok := bundle.Deployment.Annotations[AnnotationRevision]; ok {
+		t.Error("WithChange modified the original bundle")
+	}
+	if stamped.Deployment.Spec.Template.Annotations[AnnotationRevision] != "" {
+		t.Error("change annotations on the pod template would restart pods on every deploy")
+	}
+}
+
+func TestChangeAnnotationsSkipEmptyFields(t *testing.T) {
+	annotations := ChangeInfo{DeployedBy: "ci"}.Annotations()
+	if len(annotations) != 1 || annotations[AnnotationDeployedBy] != "ci" {
+		t.Errorf("expected only deployed-by, got %v", annotations)
+	}
+}
@@ -0,0 +1,130 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Pod template annotations holding a hash of the ConfigMaps and Secrets the
+// pods use. Kubernetes doesn't restart pods when only their config changes;
+// a changed hash changes the template, which rolls the Deployment.
+const (
+	AnnotationConfigChecksum = "kbox.dev/config-checksum"
+	AnnotationSecretChecksum = "kbox.dev/secret-checksum"
+)
+
+// stampChecksums annotates a pod template with hashes of the bundle's
+// ConfigMaps and Secrets that it references. Dependency passwords and shared
+// secrets are skipped because they are generated per render and would roll
+// the pods on every deploy.
+func stampChecksums(template *corev1.PodTemplateSpec, configMaps []*corev1.ConfigMap, secrets []*corev1.Secret) {
+	usedConfigMaps, usedSecrets := podConfigRefs(&template.Spec)
+
+	config := sha256.New()
+	configFound := false
+	for _, cm := range configMaps {
+		if !usedConfigMaps[cm.Name] {
+			continue
+		}
+		configFound = true
+		hashData(config, cm.Name, cm.Data, cm.BinaryData)
+	}
+
+	secret := sha256.New()
+	secretFound := false
+	for _, s := range secrets {
+		if !usedSecrets[s.Name] || generatedSecret(s) {
+			continue
+		}
+		secretFound = true
+		data := maps.Clone(s.Data)
+		if data == nil {
+			data = make(map[string][]byte)
+		}
+		for k, v := range s.StringData {
+			data[k] = []byte(v)
+		}
+		hashData(secret, s.Name, nil, data)
+	}
+
+	if !configFound && !secretFound {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	if configFound {
+		template.Annotations[AnnotationConfigChecksum] = hex.EncodeToString(config.Sum(nil))
+	}
+	if secretFound {
+		template.Annotations[AnnotationSecretChecksum] = hex.EncodeToString(secret.Sum(nil))
+	}
+}
+
+// hashData writes an object's name and sorted key/value pairs to h
+func hashData(h io.Writer, name string, data map[string]string, binary map[string][]byte) {
+	h.Write([]byte(name + "\x00"))
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		h.Write([]byte(k + "\x00" + data[k] + "\x00"))
+	}
+	for _, k := range slices.Sorted(maps.Keys(binary)) {
+		h.Write([]byte(k + "\x00"))
+		h.Write(binary[k])
+		h.Write([]byte("\x00"))
+	}
+}
+
+// podConfigRefs returns the names of the ConfigMaps and Secrets a pod reads,
+// through env, envFrom or volumes
+func podConfigRefs(spec *corev1.PodSpec) (configMaps, secrets map[string]bool) {
+	configMaps = make(map[string]bool)
+	secrets = make(map[string]bool)
+
+	containers := append(slices.Clone(spec.InitContainers), spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				configMaps[from.ConfigMapRef.Name] = true
+			}
+			if from.SecretRef != nil {
+				secrets[from.SecretRef.Name] = true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configMaps[ref.Name] = true
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				secrets[ref.Name] = true
+			}
+		}
+	}
+
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			configMaps[v.ConfigMap.Name] = true
+		}
+		if v.Secret != nil {
+			secrets[v.Secret.SecretName] = true
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					configMaps[src.ConfigMap.Name] = true
+				}
+				if src.Secret != nil {
+					secrets[src.Secret.Name] = true
+				}
+			}
+		}
+	}
+	return configMaps, secrets
+}
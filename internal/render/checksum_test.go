@@ -0,0 +1,78 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func renderChecksum(t *testing.T, cfg *config.AppConfig) string {
+	t.Helper()
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	return bundle.Deployment.Spec.Template.Annotations[AnnotationConfigChecksum]
+}
+
+func TestConfigChecksumRollsOnEnvChange(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:        "myapp:v1",
+			Port:         8080,
+			Env:          map[string]string{"LOG_LEVEL": "info", "REGION": "us"},
+			Dependencies: []config.DependencyConfig{{Type: "postgres"}},
+		},
+	}
+
+	first := renderChecksum(t, cfg)
+	if first == "" {
+		t.Fatal("expected a config checksum on the pod template")
+	}
+	// Dependency passwords are generated per render and must not change the hash
+	if again := renderChecksum(t, cfg); again != first {
+		t.Errorf("expected a stable checksum for identical config, got %s then %s", first, again)
+	}
+
+	cfg.Spec.Env["LOG_LEVEL"] = "debug"
+	if changed := renderChecksum(t, cfg); changed == first {
+		t.Error("expected the checksum to change with the ConfigMap")
+	}
+
+	disabled := false
+	cfg.Spec.RollOnConfigChange = &disabled
+	if got := renderChecksum(t, cfg); got != "" {
+		t.Errorf("expected no checksum with rollOnConfigChange: false, got %s", got)
+	}
+}
+
+func TestConfigChecksumWithoutConfig(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v1", Port: 8080},
+	}
+	if got := renderChecksum(t, cfg); got != "" {
+		t.Errorf("expected no checksum without a ConfigMap, got %s", got)
+	}
+}
+
+func TestMultiServiceConfigChecksum(t *testing.T) {
+	cfg := &config.MultiServiceConfig{
+		Metadata: config.Metadata{Name: "shop"},
+		Services: map[string]config.ServiceSpec{
+			"api": {Image: "api:v1", Port: 8080, Env: map[string]string{"MODE": "live"}},
+			"web": {Image: "web:v1", Port: 3000},
+		},
+	}
+	bundle, err := NewMultiService(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	for _, dep := range bundle.Deployments {
+		_, ok := dep.Spec.Template.Annotations[AnnotationConfigChecksum]
+		if want := dep.Name == "shop-api"; ok != want {
+			t.Errorf("%s: expected checksum=%v, got annotations %v", dep.Name, want, dep.Spec.Template.Annotations)
+		}
+	}
+}
@@ -10,11 +10,12 @@ func (r *Renderer) RenderConfigMap() (*corev1.ConfigMap, error) {
 	cfg := r.config
 	name := cfg.Metadata.Name + "-config"
 
-	// Copy env vars to ConfigMap data
-	data := make(map[string]string)
-	for k, v := range cfg.Spec.Env {
-		data[k] = v
+	// Env vars, with envFromFile's merged in and helpers evaluated
+	data, computed, err := resolveEnv(&cfg.Spec)
+	if err != nil {
+		return nil, err
 	}
+	r.computedEnv = computed
 
 	cm := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
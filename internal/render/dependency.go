@@ -2,6 +2,8 @@ package render
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/dependencies"
@@ -16,6 +18,9 @@ import (
 type SecretEnvRef struct {
 	SecretName string
 	SecretKey  string
+	// Optional leaves the env var unset when the key is missing, for
+	// Secrets kbox doesn't create
+	Optional bool
 }
 
 // DependencyResources holds rendered resources for a dependency
@@ -35,6 +40,10 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 		return nil, fmt.Errorf("unsupported dependency type: %s\n  → Supported: %v", dep.Type, dependencies.SupportedTypes())
 	}
 
+	if dep.External != nil {
+		return renderExternalDependency(template, dep.External), nil
+	}
+
 	// Generate service name
 	serviceName := fmt.Sprintf("%s-%s", r.config.Metadata.Name, dep.Type)
 	namespace := r.Namespace()
@@ -140,10 +149,10 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      "data",
-				MountPath: getDataPath(dep.Type),
+				MountPath: dataPath(template),
 			},
 		},
-		SecurityContext: dependencySecurityContext(dep.Type),
+		SecurityContext: dependencySecurityContext(template),
 	}
 
 	// Add command args if the template requires them (e.g., redis --requirepass)
@@ -154,6 +163,13 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 		}
 	}
 
+	// Tune the server with its own options, after the template's
+	configArgs, err := dependencies.ConfigArgs(dep.Type, template, dep.Config)
+	if err != nil {
+		return nil, err
+	}
+	depContainer.Args = append(slices.Clip(depContainer.Args), configArgs...)
+
 	statefulSet := &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
@@ -175,7 +191,7 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext: dependencyPodSecurityContext(dep.Type),
+					SecurityContext: dependencyPodSecurityContext(template),
 					Containers:      []corev1.Container{depContainer},
 				},
 			},
@@ -219,6 +235,17 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 		}
 	}
 
+	// Pass the dependency's own env through; kbox sets its password
+	for _, name := range slices.Sorted(maps.Keys(dep.Env)) {
+		if slices.Contains(template.SecretKeys, name) {
+			return nil, fmt.Errorf("dependency %s: env %s is set by kbox from the generated password\n  → Remove it from dependencies[].env", dep.Type, name)
+		}
+		statefulSet.Spec.Template.Spec.Containers[0].Env = append(
+			statefulSet.Spec.Template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: name, Value: dep.Env[name]},
+		)
+	}
+
 	// Add readiness probe
 	if len(template.HealthCheck) > 0 {
 		statefulSet.Spec.Template.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
@@ -265,6 +292,24 @@ func (r *Renderer) RenderDependency(dep config.DependencyConfig) (*DependencyRes
 	return resources, nil
 }
 
+// renderExternalDependency only injects env vars: the server runs outside
+// the cluster, so there is nothing to deploy
+func renderExternalDependency(template dependencies.Template, ext *config.ExternalDependencyConfig) *DependencyResources {
+	plainEnvVars, secretEnvVars := dependencies.RenderExternalEnvVars(template, ext.Host, int(ext.Port), ext.SecretRef)
+	secretEnvRefs := make(map[string]SecretEnvRef)
+	for k, v := range secretEnvVars {
+		secretEnvRefs[k] = SecretEnvRef{
+			SecretName: v.SecretName,
+			SecretKey:  v.SecretKey,
+			Optional:   true,
+		}
+	}
+	return &DependencyResources{
+		EnvVars:       plainEnvVars,
+		SecretEnvRefs: secretEnvRefs,
+	}
+}
+
 // RenderAllDependencies renders all dependencies and returns collected resources
 func (r *Renderer) RenderAllDependencies() ([]*appsv1.StatefulSet, []*corev1.Service, []*corev1.Secret, map[string]string, map[string]SecretEnvRef, error) {
 	var statefulSets []*appsv1.StatefulSet
@@ -279,8 +324,12 @@ func (r *Renderer) RenderAllDependencies() ([]*appsv1.StatefulSet, []*corev1.Ser
 			return nil, nil, nil, nil, nil, err
 		}
 
-		statefulSets = append(statefulSets, res.StatefulSet)
-		services = append(services, res.Service)
+		if res.StatefulSet != nil {
+			statefulSets = append(statefulSets, res.StatefulSet)
+		}
+		if res.Service != nil {
+			services = append(services, res.Service)
+		}
 		if res.Secret != nil {
 			secrets = append(secrets, res.Secret)
 		}
@@ -299,15 +348,12 @@ func (r *Renderer) RenderAllDependencies() ([]*appsv1.StatefulSet, []*corev1.Ser
 	return statefulSets, services, secrets, envVars, secretEnvRefs, nil
 }
 
-// dependencySecurityContext returns security context appropriate for dependencies
-// Databases need writable filesystem, so readOnlyRootFilesystem is disabled for them
-func dependencySecurityContext(depType string) *corev1.SecurityContext {
+// dependencySecurityContext returns security context appropriate for dependencies.
+// Databases need a writable filesystem, so their templates turn off
+// readOnlyRootFilesystem.
+func dependencySecurityContext(template dependencies.Template) *corev1.SecurityContext {
 	allowPrivilegeEscalation := false
-	// Databases need writable filesystem
-	readOnly := true
-	if depType == "postgres" || depType == "mysql" || depType == "mongodb" || depType == "redis" {
-		readOnly = false
-	}
+	readOnly := !template.WritableRoot
 	return &corev1.SecurityContext{
 		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
 		ReadOnlyRootFilesystem:   &readOnly,
@@ -317,72 +363,30 @@ func dependencySecurityContext(depType string) *corev1.SecurityContext {
 	}
 }
 
-// dependencyPodSecurityContext returns pod-level security context for dependencies
-// Different databases require different UIDs to run correctly
-func dependencyPodSecurityContext(depType string) *corev1.PodSecurityContext {
-	runAsNonRoot := true
-	seccompProfile := &corev1.SeccompProfile{
-		Type: corev1.SeccompProfileTypeRuntimeDefault,
-	}
-
-	switch depType {
-	case "postgres":
-		// Postgres runs as uid 70 (postgres user in alpine image)
-		uid := int64(70)
-		return &corev1.PodSecurityContext{
-			RunAsNonRoot:   &runAsNonRoot,
-			RunAsUser:      &uid,
-			RunAsGroup:     &uid,
-			FSGroup:        &uid,
-			SeccompProfile: seccompProfile,
-		}
-	case "mysql":
-		// MySQL runs as uid 999 (mysql user)
-		uid := int64(999)
-		return &corev1.PodSecurityContext{
-			RunAsNonRoot:   &runAsNonRoot,
-			RunAsUser:      &uid,
-			RunAsGroup:     &uid,
-			FSGroup:        &uid,
-			SeccompProfile: seccompProfile,
-		}
-	case "mongodb":
-		// MongoDB runs as uid 999 (mongodb user)
-		uid := int64(999)
-		return &corev1.PodSecurityContext{
-			RunAsNonRoot:   &runAsNonRoot,
-			RunAsUser:      &uid,
-			RunAsGroup:     &uid,
-			FSGroup:        &uid,
-			SeccompProfile: seccompProfile,
-		}
-	case "redis":
-		// Redis can run as uid 999 (redis user)
-		uid := int64(999)
-		return &corev1.PodSecurityContext{
-			RunAsNonRoot:   &runAsNonRoot,
-			RunAsUser:      &uid,
-			RunAsGroup:     &uid,
-			FSGroup:        &uid,
-			SeccompProfile: seccompProfile,
-		}
-	default:
+// dependencyPodSecurityContext returns pod-level security context for dependencies.
+// Different databases require different UIDs to run correctly, e.g. 70 for the
+// postgres user in the alpine image.
+func dependencyPodSecurityContext(template dependencies.Template) *corev1.PodSecurityContext {
+	if template.RunAsUser == 0 {
 		return defaultPodSecurityContext()
 	}
+	runAsNonRoot := true
+	uid := template.RunAsUser
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &uid,
+		RunAsGroup:   &uid,
+		FSGroup:      &uid,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
 }
 
-// getDataPath returns the data directory path for a dependency type
-func getDataPath(depType string) string {
-	switch depType {
-	case "postgres":
-		return "/var/lib/postgresql/data"
-	case "mysql":
-		return "/var/lib/mysql"
-	case "mongodb":
-		return "/data/db"
-	case "redis":
-		return "/data"
-	default:
+// dataPath returns the data directory path for a dependency
+func dataPath(template dependencies.Template) string {
+	if template.DataPath == "" {
 		return "/data"
 	}
+	return template.DataPath
 }
@@ -0,0 +1,268 @@
+package render
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRenderExternalDependency(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			Dependencies: []config.DependencyConfig{
+				{Type: "postgres", External: &config.ExternalDependencyConfig{
+					Host:      "db.example.rds.amazonaws.com",
+					Port:      6432,
+					SecretRef: "myapp-db",
+				}},
+				{Type: "redis"},
+			},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	// Only redis runs in the cluster
+	if len(bundle.StatefulSets) != 1 || bundle.StatefulSets[0].Name != "myapp-redis" {
+		t.Errorf("expected only the redis StatefulSet, got %d", len(bundle.StatefulSets))
+	}
+	for _, svc := range bundle.Services {
+		if svc.Name == "myapp-postgres" {
+			t.Error("external postgres must not get a Service")
+		}
+	}
+	for _, secret := range bundle.Secrets {
+		if secret.Name == "myapp-postgres" {
+			t.Error("external postgres must not get a Secret")
+		}
+	}
+
+	env := make(map[string]corev1.EnvVar)
+	for _, e := range bundle.Deployment.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	if got := env["PGHOST"].Value; got != "db.example.rds.amazonaws.com" {
+		t.Errorf("PGHOST = %q, want the external host", got)
+	}
+	if got := env["PGPORT"].Value; got != "6432" {
+		t.Errorf("PGPORT = %q, want 6432", got)
+	}
+	for _, name := range []string{"DATABASE_URL", "PGPASSWORD", "PGUSER", "PGDATABASE"} {
+		ref := env[name].ValueFrom
+		if ref == nil || ref.SecretKeyRef == nil {
+			t.Errorf("%s should come from the Secret", name)
+			continue
+		}
+		if ref.SecretKeyRef.Name != "myapp-db" || ref.SecretKeyRef.Key != name {
+			t.Errorf("%s references %s/%s, want myapp-db/%s", name, ref.SecretKeyRef.Name, ref.SecretKeyRef.Key, name)
+		}
+		if ref.SecretKeyRef.Optional == nil || !*ref.SecretKeyRef.Optional {
+			t.Errorf("%s should be optional: the Secret may not have every key", name)
+		}
+	}
+	// The managed redis is unchanged
+	if ref := env["REDIS_PASSWORD"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "myapp-redis" || ref.SecretKeyRef.Optional != nil {
+		t.Errorf("REDIS_PASSWORD should reference the generated myapp-redis Secret")
+	}
+
+	// The app can reach the server's port
+	var allowed bool
+	for _, rule := range bundle.NetworkPolicies[0].Spec.Egress {
+		for _, p := range rule.Ports {
+			if p.Port != nil && p.Port.IntValue() == 6432 {
+				allowed = true
+			}
+		}
+	}
+	if !allowed {
+		t.Error("expected NetworkPolicy egress to port 6432")
+	}
+}
+
+func TestRenderExternalDependency_SecretOnly(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Dependencies: []config.DependencyConfig{
+				{Type: "postgres", External: &config.ExternalDependencyConfig{SecretRef: "cloudsql"}},
+			},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	for _, e := range bundle.Deployment.Spec.Template.Spec.Containers[0].Env {
+		if e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil || e.ValueFrom.SecretKeyRef.Name != "cloudsql" {
+			t.Errorf("%s should come from the cloudsql Secret without a host", e.Name)
+		}
+	}
+}
+
+func TestRenderCustomDependency(t *testing.T) {
+	_, template, err := dependencies.ParseTemplate([]byte(`image: registry.example.com/queue
+version: "2.3"
+port: 5672
+dataPath: /var/lib/queue
+runAsUser: 1001
+env:
+  QUEUE_URL: "amqp://app:{{.Password}}@{{.Service}}:{{.Port}}"
+  QUEUE_HOST: "{{.Service}}"
+secretKeys: [QUEUE_PASSWORD]
+`), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dependencies.Register("queue", template); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(dependencies.Registry, "queue") })
+
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:        "myapp:v1",
+			Port:         8080,
+			Dependencies: []config.DependencyConfig{{Type: "queue"}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	ss := bundle.StatefulSets[0]
+	c := ss.Spec.Template.Spec.Containers[0]
+	if ss.Name != "myapp-queue" || c.Image != "registry.example.com/queue:2.3" {
+		t.Errorf("unexpected StatefulSet %s running %s", ss.Name, c.Image)
+	}
+	if c.VolumeMounts[0].MountPath != "/var/lib/queue" {
+		t.Errorf("expected the data path from the template, got %s", c.VolumeMounts[0].MountPath)
+	}
+	if uid := ss.Spec.Template.Spec.SecurityContext.RunAsUser; uid == nil || *uid != 1001 {
+		t.Errorf("expected runAsUser 1001, got %v", uid)
+	}
+	if !*c.SecurityContext.ReadOnlyRootFilesystem {
+		t.Error("expected a read-only root filesystem unless the template asks otherwise")
+	}
+
+	env := map[string]corev1.EnvVar{}
+	for _, e := range bundle.Deployment.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	if env["QUEUE_HOST"].Value != "myapp-queue" {
+		t.Errorf("expected QUEUE_HOST to be injected, got %+v", env["QUEUE_HOST"])
+	}
+	if ref := env["QUEUE_URL"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "myapp-queue" {
+		t.Errorf("expected QUEUE_URL from the dependency Secret, got %+v", env["QUEUE_URL"])
+	}
+	if _, ok := bundle.Secrets[0].StringData["QUEUE_PASSWORD"]; !ok {
+		t.Error("expected the generated password in the dependency Secret")
+	}
+}
+
+func TestRenderDependency_ConfigAndEnv(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			Dependencies: []config.DependencyConfig{
+				{
+					Type:   "postgres",
+					Config: map[string]string{"shared_buffers": "256MB", "max_connections": "200"},
+					Env:    map[string]string{"TZ": "UTC", "POSTGRES_INITDB_ARGS": "--data-checksums"},
+				},
+				{Type: "redis", Config: map[string]string{"maxmemory-policy": "allkeys-lru"}},
+			},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	containers := map[string]corev1.Container{}
+	for _, ss := range bundle.StatefulSets {
+		containers[ss.Name] = ss.Spec.Template.Spec.Containers[0]
+	}
+
+	pg := containers["myapp-postgres"]
+	wantArgs := []string{"-c", "max_connections=200", "-c", "shared_buffers=256MB"}
+	if !slices.Equal(pg.Args, wantArgs) {
+		t.Errorf("postgres args = %v, want %v", pg.Args, wantArgs)
+	}
+	var names []string
+	for _, e := range pg.Env {
+		if e.ValueFrom == nil {
+			names = append(names, e.Name+"="+e.Value)
+		}
+	}
+	if !slices.Contains(names, "TZ=UTC") || !slices.Contains(names, "POSTGRES_INITDB_ARGS=--data-checksums") {
+		t.Errorf("expected the env passthrough on the postgres container, got %v", names)
+	}
+
+	redis := containers["myapp-redis"]
+	if n := len(redis.Args); n < 2 || redis.Args[n-2] != "--maxmemory-policy" || redis.Args[n-1] != "allkeys-lru" {
+		t.Errorf("expected --maxmemory-policy after the redis args, got %v", redis.Args)
+	}
+	if !slices.Contains(redis.Args, "--requirepass") {
+		t.Errorf("expected the redis password args to be kept, got %v", redis.Args)
+	}
+}
+
+func TestRenderDependency_ConfigErrors(t *testing.T) {
+	_, template, err := dependencies.ParseTemplate([]byte(`image: registry.example.com/queue
+version: "2.3"
+port: 5672
+dataPath: /var/lib/queue
+`), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dependencies.Register("queue", template); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(dependencies.Registry, "queue") })
+
+	tests := []struct {
+		name    string
+		dep     config.DependencyConfig
+		wantErr string
+	}{
+		{
+			name:    "generated secret",
+			dep:     config.DependencyConfig{Type: "postgres", Env: map[string]string{"POSTGRES_PASSWORD": "hunter2"}},
+			wantErr: "POSTGRES_PASSWORD is set by kbox",
+		},
+		{
+			name:    "template without configArgs",
+			dep:     config.DependencyConfig{Type: "queue", Config: map[string]string{"prefetch": "10"}},
+			wantErr: "queue doesn't take config settings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.AppConfig{
+				Metadata: config.Metadata{Name: "myapp"},
+				Spec:     config.AppSpec{Image: "myapp:v1", Port: 8080, Dependencies: []config.DependencyConfig{tt.dep}},
+			}
+			_, err := New(cfg).Render()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q in error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package render
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
 )
 
 // Security context helpers for hardened pod defaults
@@ -69,6 +73,17 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 		},
 	}
 
+	// The Service's other ports, so they show up by name on the pods
+	if cfg.Spec.Service != nil {
+		for _, p := range cfg.Spec.Service.Ports {
+			container.Ports = append(container.Ports, corev1.ContainerPort{
+				Name:          p.PortName(),
+				ContainerPort: int32(p.ContainerPort()),
+				Protocol:      corev1.Protocol(p.PortProtocol()),
+			})
+		}
+	}
+
 	// Add command/args if specified
 	if len(cfg.Spec.Command) > 0 {
 		container.Command = cfg.Spec.Command
@@ -76,6 +91,14 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 	if len(cfg.Spec.Args) > 0 {
 		container.Args = cfg.Spec.Args
 	}
+	web, hasWeb := cfg.Spec.Processes[config.WebProcess]
+	if hasWeb && len(web.Command) > 0 {
+		container.Command = web.Command
+		container.Args = web.Args
+	}
+	if hasWeb && web.Replicas != nil {
+		replicas = int32(*web.Replicas)
+	}
 
 	// Add environment variables
 	container.Env = r.renderEnvVars()
@@ -85,6 +108,9 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 
 	// Add resource requirements
 	container.Resources = r.renderResources()
+	if hasWeb && web.Resources != nil {
+		container.Resources = resourceRequirements(web.Resources)
+	}
 
 	// Add health probes if healthCheck is specified
 	if cfg.Spec.HealthCheck != "" {
@@ -112,7 +138,7 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 	}
 
 	// Add container-level security context
-	container.SecurityContext = defaultContainerSecurityContext()
+	container.SecurityContext = r.containerSecurityContext()
 
 	// Build deployment
 	deployment := &appsv1.Deployment{
@@ -136,7 +162,7 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: r.config.Metadata.Name,
-					SecurityContext:    defaultPodSecurityContext(),
+					SecurityContext:    r.podSecurityContext(),
 					InitContainers:     r.renderInitContainers(),
 					Containers:         []corev1.Container{container},
 					Volumes:            r.renderPodVolumes(),
@@ -152,6 +178,14 @@ func (r *Renderer) RenderDeployment() (*appsv1.Deployment, error) {
 		},
 	}
 
+	r.applyPlatform(&deployment.Spec.Template.Spec)
+	r.applyRollout(&deployment.Spec)
+
+	// The HPA owns the replica count; applying one would fight it
+	if r.autoscaled() {
+		deployment.Spec.Replicas = nil
+	}
+
 	return deployment, nil
 }
 
@@ -166,7 +200,7 @@ func (r *Renderer) renderEnvFrom() []corev1.EnvFromSource {
 	var envFrom []corev1.EnvFromSource
 
 	// Add ConfigMap reference for env vars (if any env vars are configured)
-	if len(r.config.Spec.Env) > 0 {
+	if r.config.Spec.HasEnv() {
 		configMapName := r.config.Metadata.Name + "-config"
 		envFrom = append(envFrom, corev1.EnvFromSource{
 			ConfigMapRef: &corev1.ConfigMapEnvSource{
@@ -205,12 +239,16 @@ func (r *Renderer) renderEnvFrom() []corev1.EnvFromSource {
 }
 
 func (r *Renderer) renderResources() corev1.ResourceRequirements {
+	return resourceRequirements(r.config.Spec.Resources)
+}
+
+// resourceRequirements converts a resources config, filling in defaults
+func resourceRequirements(cfg *config.ResourceConfig) corev1.ResourceRequirements {
 	resources := corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{},
 		Limits:   corev1.ResourceList{},
 	}
 
-	cfg := r.config.Spec.Resources
 	if cfg == nil {
 		// Sensible defaults
 		resources.Requests[corev1.ResourceMemory] = resource.MustParse("128Mi")
@@ -271,10 +309,10 @@ func (r *Renderer) renderInitContainers() []corev1.Container {
 
 		// Add env vars if specified
 		if len(ic.Env) > 0 {
-			for k, v := range ic.Env {
+			for _, k := range slices.Sorted(maps.Keys(ic.Env)) {
 				container.Env = append(container.Env, corev1.EnvVar{
 					Name:  k,
-					Value: v,
+					Value: ic.Env[k],
 				})
 			}
 		}
@@ -285,7 +323,7 @@ func (r *Renderer) renderInitContainers() []corev1.Container {
 		}
 
 		// Add container-level security context
-		container.SecurityContext = defaultContainerSecurityContext()
+		container.SecurityContext = r.containerSecurityContext()
 
 		initContainers = append(initContainers, container)
 	}
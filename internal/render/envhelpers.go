@@ -0,0 +1,208 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// envHelpers are the functions env values may call as ${name:arg}
+var envHelpers = map[string]func(arg string) (string, error){
+	// ${b64:value} base64-encodes value
+	"b64": func(arg string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(arg)), nil
+	},
+	// ${file:./path} is the file's contents, without trailing newlines
+	"file": func(arg string) (string, error) {
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	},
+	// ${json:./file.json} is the file as compact JSON, and
+	// ${json:./file.json#.db.host} one of its values: strings as they are,
+	// anything else as JSON
+	"json": jsonValue,
+}
+
+// resolveEnv returns an app's env variables: those of envFromFile, overridden
+// by spec.env, with helpers evaluated. computed lists, sorted, the variables
+// whose values came from a file or a helper, which kbox render --redact hides.
+func resolveEnv(spec *config.AppSpec) (env map[string]string, computed []string, err error) {
+	env = make(map[string]string)
+	fromFile := make(map[string]bool)
+	if spec.EnvFromFile != "" {
+		loaded, err := loadEnvFile(spec.EnvFromFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("envFromFile: %w", err)
+		}
+		for k, v := range loaded {
+			env[k] = v
+			fromFile[k] = true
+		}
+	}
+	for k, v := range spec.Env {
+		env[k] = v
+		delete(fromFile, k)
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		value, called, err := expandEnvValue(env[k])
+		if err != nil {
+			return nil, nil, fmt.Errorf("env %s: %w", k, err)
+		}
+		env[k] = value
+		if called || fromFile[k] {
+			computed = append(computed, k)
+		}
+	}
+	return env, computed, nil
+}
+
+// expandEnvValue evaluates the helpers in an env value, and reports whether
+// it called any. The argument is expanded first, so helpers nest, e.g.
+// ${b64:${file:./ca.pem}}. ${VAR} references and unknown helpers are left as
+// they are.
+func expandEnvValue(value string) (string, bool, error) {
+	var out strings.Builder
+	called := false
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			out.WriteString(value)
+			return out.String(), called, nil
+		}
+		name, rest, ok := strings.Cut(value[start+2:], ":")
+		helper := envHelpers[name]
+		if !ok || helper == nil {
+			out.WriteString(value[:start+2])
+			value = value[start+2:]
+			continue
+		}
+
+		end := closingBrace(rest)
+		if end < 0 {
+			return "", false, fmt.Errorf("${%s: is missing its closing }", name)
+		}
+		arg, _, err := expandEnvValue(rest[:end])
+		if err != nil {
+			return "", false, err
+		}
+		result, err := helper(arg)
+		if err != nil {
+			return "", false, fmt.Errorf("${%s:%s}: %w", name, arg, err)
+		}
+		out.WriteString(value[:start])
+		out.WriteString(result)
+		value = rest[end+1:]
+		called = true
+	}
+}
+
+// closingBrace returns the index of the } closing a ${ whose argument s
+// starts, skipping those of nested ${...}, or -1
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// jsonValue implements ${json:path#query}
+func jsonValue(arg string) (string, error) {
+	path, query, _ := strings.Cut(arg, "#")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if query = strings.TrimPrefix(query, "."); query != "" {
+		for _, key := range strings.Split(query, ".") {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				field, ok := v[key]
+				if !ok {
+					return "", fmt.Errorf("no %q key", key)
+				}
+				value = field
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(v) {
+					return "", fmt.Errorf("no index %q in a list of %d", key, len(v))
+				}
+				value = v[i]
+			default:
+				return "", fmt.Errorf("%q is not inside an object or a list", key)
+			}
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	compact, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(compact), nil
+}
+
+// loadEnvFile reads a YAML (or JSON) map of env variables. Values must be
+// scalars, and are kept as written, e.g. 1.10 stays "1.10".
+func loadEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	env := make(map[string]string)
+	if len(doc.Content) == 0 {
+		return env, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: must be a map of env variable names to values", path)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s: %s must be a string, number or boolean", path, key.Value)
+		}
+		if value.Tag == "!!null" {
+			env[key.Value] = ""
+			continue
+		}
+		env[key.Value] = value.Value
+	}
+	return env, nil
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestExpandEnvValue(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cert := write("ca.pem", "CERT\n")
+	settings := write("settings.json", `{"db": {"host": "db.internal", "port": 5432}, "hosts": ["a", "b"], "flags": {"beta": true}}`)
+
+	tests := []struct {
+		value   string
+		want    string
+		called  bool
+		wantErr string
+	}{
+		{value: "plain", want: "plain"},
+		{value: "${DATABASE_URL}", want: "${DATABASE_URL}"},
+		{value: "${unknown:x}", want: "${unknown:x}"},
+		{value: "${b64:hello}", want: "aGVsbG8=", called: true},
+		{value: "${file:" + cert + "}", want: "CERT", called: true},
+		{value: "${b64:${file:" + cert + "}}", want: "Q0VSVA==", called: true},
+		{value: "postgres://${json:" + settings + "#.db.host}:${json:" + settings + "#.db.port}/app", want: "postgres://db.internal:5432/app", called: true},
+		{value: "${json:" + settings + "#.hosts.1}", want: "b", called: true},
+		{value: "${json:" + settings + "#.flags}", want: `{"beta":true}`, called: true},
+		{value: "${json:" + settings + "#.db.user}", wantErr: `no "user" key`},
+		{value: "${file:" + filepath.Join(dir, "missing") + "}", wantErr: "no such file"},
+		{value: "${b64:open", wantErr: "missing its closing }"},
+	}
+
+	for _, tt := range tests {
+		got, called, err := expandEnvValue(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expandEnvValue(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandEnvValue(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want || called != tt.called {
+			t.Errorf("expandEnvValue(%q) = %q, %v, want %q, %v", tt.value, got, called, tt.want, tt.called)
+		}
+	}
+}
+
+func TestRenderEnvFromFile(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env.yaml")
+	os.WriteFile(envFile, []byte("LOG_LEVEL: info\nVERSION: 1.10\nDEBUG: false\nTOKEN: ${b64:secret}\n"), 0644)
+
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:       "myapp:v1",
+			EnvFromFile: envFile,
+			Env:         map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if len(bundle.ConfigMaps) != 1 {
+		t.Fatalf("expected the env ConfigMap, got %d ConfigMaps", len(bundle.ConfigMaps))
+	}
+	cm := bundle.ConfigMaps[0]
+	want := map[string]string{"LOG_LEVEL": "debug", "REGION": "eu", "VERSION": "1.10", "DEBUG": "false", "TOKEN": "c2VjcmV0"}
+	for k, v := range want {
+		if cm.Data[k] != v {
+			t.Errorf("%s = %q, want %q", k, cm.Data[k], v)
+		}
+	}
+	// env overrides the file's LOG_LEVEL, so it isn't computed
+	if got := bundle.ComputedEnv(cm); !slices.Equal(got, []string{"DEBUG", "TOKEN", "VERSION"}) {
+		t.Errorf("ComputedEnv = %v", got)
+	}
+
+	os.WriteFile(envFile, []byte("NESTED:\n  a: b\n"), 0644)
+	if _, err := New(cfg).Render(); err == nil || !strings.Contains(err.Error(), "NESTED must be a string") {
+		t.Errorf("expected an error for a nested value, got %v", err)
+	}
+}
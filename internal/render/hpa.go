@@ -6,9 +6,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// autoscaled reports whether an HPA manages the app's replicas. The
+// Deployment or StatefulSet then leaves spec.replicas out, so a deploy
+// doesn't reset the replica count the HPA chose.
+func (r *Renderer) autoscaled() bool {
+	return r.config.Spec.Autoscaling != nil && r.config.Spec.Autoscaling.Enabled
+}
+
 // RenderHPA creates a HorizontalPodAutoscaler if autoscaling is enabled
 func (r *Renderer) RenderHPA() *autoscalingv2.HorizontalPodAutoscaler {
-	if r.config.Spec.Autoscaling == nil || !r.config.Spec.Autoscaling.Enabled {
+	if !r.autoscaled() {
 		return nil
 	}
 
@@ -26,6 +33,11 @@ func (r *Renderer) RenderHPA() *autoscalingv2.HorizontalPodAutoscaler {
 		targetCPU = 80
 	}
 
+	kind := "Deployment"
+	if r.config.Spec.IsStatefulSet() {
+		kind = "StatefulSet"
+	}
+
 	return &autoscalingv2.HorizontalPodAutoscaler{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "autoscaling/v2",
@@ -39,7 +51,7 @@ func (r *Renderer) RenderHPA() *autoscalingv2.HorizontalPodAutoscaler {
 		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
 			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 				APIVersion: "apps/v1",
-				Kind:       "Deployment",
+				Kind:       kind,
 				Name:       r.config.Metadata.Name,
 			},
 			MinReplicas: &minReplicas,
@@ -0,0 +1,75 @@
+package render
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Roles of the images a bundle runs
+const (
+	ImageRoleApp        = "app"
+	ImageRoleProcess    = "process"
+	ImageRoleSidecar    = "sidecar"
+	ImageRoleInit       = "init"
+	ImageRoleDependency = "dependency"
+	ImageRoleJob        = "job"
+)
+
+// ImageUse is a container of the bundle and the image it runs
+type ImageUse struct {
+	Image string
+	// Workload is Kind/name, e.g. Deployment/myapp
+	Workload  string
+	Container string
+	// Role is app (the app's, or a service's, main container), process,
+	// sidecar, init, dependency or job
+	Role string
+}
+
+// ImageUses returns every container image the bundle runs, in apply order
+func (b *Bundle) ImageUses() []ImageUse {
+	var uses []ImageUse
+	for _, w := range b.podWorkloads() {
+		var role string
+		switch w.Kind {
+		case "StatefulSet":
+			role = ImageRoleDependency
+			if b.AppStatefulSet != nil && w.Name == b.AppStatefulSet.Name {
+				role = ImageRoleApp
+			}
+		case "Deployment":
+			role = ImageRoleApp
+			if w.Labels[LabelProcess] != "" {
+				role = ImageRoleProcess
+			}
+		default:
+			role = ImageRoleJob
+		}
+
+		workload := w.Kind + "/" + w.Name
+		for _, c := range w.Spec.InitContainers {
+			uses = append(uses, ImageUse{Image: c.Image, Workload: workload, Container: c.Name, Role: ImageRoleInit})
+		}
+		for i, c := range w.Spec.Containers {
+			use := ImageUse{Image: c.Image, Workload: workload, Container: c.Name, Role: role}
+			if i > 0 && (role == ImageRoleApp || role == ImageRoleProcess) {
+				use.Role = ImageRoleSidecar
+			}
+			uses = append(uses, use)
+		}
+	}
+	return uses
+}
+
+// RewriteImages replaces the images of the bundle's containers by those
+// images maps them to, e.g. to pull them from another registry
+func (b *Bundle) RewriteImages(images map[string]string) {
+	for _, w := range b.podWorkloads() {
+		for _, containers := range [][]corev1.Container{w.Spec.InitContainers, w.Spec.Containers} {
+			for i := range containers {
+				if image, ok := images[containers[i].Image]; ok {
+					containers[i].Image = image
+				}
+			}
+		}
+	}
+}
@@ -5,6 +5,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// external-dns annotations: the records to create for an Ingress or Service
+const (
+	AnnotationExternalDNSHostname = "external-dns.alpha.kubernetes.io/hostname"
+	AnnotationExternalDNSTTL      = "external-dns.alpha.kubernetes.io/ttl"
+)
+
 // RenderIngress creates an Ingress resource from the config
 func (r *Renderer) RenderIngress() (*networkingv1.Ingress, error) {
 	cfg := r.config.Spec.Ingress
@@ -93,6 +99,11 @@ func (r *Renderer) RenderIngress() (*networkingv1.Ingress, error) {
 		annotations["cert-manager.io/cluster-issuer"] = cfg.TLS.ClusterIssuer
 	}
 
+	// Have external-dns publish the host
+	if cfg.DNS && cfg.Host != "" {
+		annotations[AnnotationExternalDNSHostname] = cfg.Host
+	}
+
 	// Add any user-specified annotations (these take precedence)
 	for k, v := range cfg.Annotations {
 		annotations[k] = v
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Inventory summarizes a bundle: what it creates, what it requests from the
+// scheduler, and what it runs
+type Inventory struct {
+	// Kinds counts the objects of each kind, e.g. {"Deployment": 1}
+	Kinds map[string]int
+	Total int
+	// CPURequests and MemoryRequests are the requests of the long-running
+	// workloads at full scale (an HPA's maxReplicas), "" when there are none
+	CPURequests    string
+	MemoryRequests string
+	Images         []InventoryImage
+	// Secrets are the names of the Secrets the bundle creates or its pods read
+	Secrets []string
+}
+
+// InventoryImage is a container image the bundle's pods run
+type InventoryImage struct {
+	Image string
+	// Digest is set when the image is pinned by digest
+	Digest string
+	// Workloads run the image, as Kind/name
+	Workloads []string
+}
+
+// Inventory summarizes the bundle for a deploy result: object counts by kind,
+// total resource requests, the images its pods run, and the Secrets they use
+func (b *Bundle) Inventory() *Inventory {
+	inv := &Inventory{Kinds: map[string]int{}, Images: []InventoryImage{}}
+
+	objects := b.AllObjects()
+	if b.Anchor != nil {
+		objects = append(objects, b.Anchor)
+	}
+	for _, obj := range objects {
+		inv.Kinds[objectKind(obj)]++
+		inv.Total++
+	}
+
+	var cpu, memory resource.Quantity
+	for _, w := range b.ResourceRequests() {
+		total := w.Total()
+		if q, ok := total[corev1.ResourceCPU]; ok {
+			cpu.Add(q)
+		}
+		if q, ok := total[corev1.ResourceMemory]; ok {
+			memory.Add(q)
+		}
+	}
+	if !cpu.IsZero() {
+		inv.CPURequests = cpu.String()
+	}
+	if !memory.IsZero() {
+		inv.MemoryRequests = memory.String()
+	}
+
+	secrets := map[string]bool{}
+	for _, s := range b.Secrets {
+		secrets[s.Name] = true
+	}
+	images := map[string][]string{}
+	scan := func(kind, name string, spec *corev1.PodSpec) {
+		workload := kind + "/" + name
+		for _, c := range slices.Concat(spec.InitContainers, spec.Containers) {
+			if !slices.Contains(images[c.Image], workload) {
+				images[c.Image] = append(images[c.Image], workload)
+			}
+			for _, from := range c.EnvFrom {
+				if from.SecretRef != nil {
+					secrets[from.SecretRef.Name] = true
+				}
+			}
+			for _, env := range c.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					secrets[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+		}
+		for _, v := range spec.Volumes {
+			if v.Secret != nil {
+				secrets[v.Secret.SecretName] = true
+			}
+		}
+		for _, ref := range spec.ImagePullSecrets {
+			secrets[ref.Name] = true
+		}
+	}
+	for _, w := range b.podWorkloads() {
+		scan(w.Kind, w.Name, w.Spec)
+	}
+
+	for _, image := range slices.Sorted(maps.Keys(images)) {
+		entry := InventoryImage{Image: image, Workloads: images[image]}
+		if _, digest, ok := strings.Cut(image, "@"); ok {
+			entry.Digest = digest
+		}
+		inv.Images = append(inv.Images, entry)
+	}
+	inv.Secrets = append([]string{}, slices.Sorted(maps.Keys(secrets))...)
+	return inv
+}
+
+// podWorkload is a workload of the bundle and the spec of the pods it runs
+type podWorkload struct {
+	Kind   string
+	Name   string
+	Labels map[string]string
+	Spec   *corev1.PodSpec
+}
+
+// podWorkloads returns the bundle's workloads in apply order: StatefulSets,
+// Deployments, Jobs and CronJobs. Their Specs point into the bundle, so
+// changing one changes the manifest.
+func (b *Bundle) podWorkloads() []podWorkload {
+	var workloads []podWorkload
+	for _, ss := range b.StatefulSets {
+		workloads = append(workloads, podWorkload{"StatefulSet", ss.Name, ss.Labels, &ss.Spec.Template.Spec})
+	}
+	for _, dep := range b.Deployments {
+		workloads = append(workloads, podWorkload{"Deployment", dep.Name, dep.Labels, &dep.Spec.Template.Spec})
+	}
+	for _, job := range b.Jobs {
+		workloads = append(workloads, podWorkload{"Job", job.Name, job.Labels, &job.Spec.Template.Spec})
+	}
+	for _, cj := range b.CronJobs {
+		workloads = append(workloads, podWorkload{"CronJob", cj.Name, cj.Labels, &cj.Spec.JobTemplate.Spec.Template.Spec})
+	}
+	return workloads
+}
+
+// objectKind returns an object's kind, from its TypeMeta or else its Go type
+func objectKind(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return reflect.TypeOf(obj).Elem().Name()
+}
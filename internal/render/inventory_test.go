@@ -0,0 +1,109 @@
+package render
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestInventory(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:        "registry.example.com/myapp@" + digest,
+			Port:         8080,
+			Replicas:     2,
+			Resources:    &config.ResourceConfig{CPU: "250m", Memory: "256Mi"},
+			Dependencies: []config.DependencyConfig{{Type: "redis"}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	inv := bundle.Inventory()
+	if inv.Kinds["Deployment"] != 1 || inv.Kinds["StatefulSet"] != 1 || inv.Kinds["Secret"] == 0 {
+		t.Errorf("unexpected kinds: %v", inv.Kinds)
+	}
+	total := 0
+	for _, n := range inv.Kinds {
+		total += n
+	}
+	// AllObjects leaves out the anchor ConfigMap, which the deploy applies too
+	if want := len(bundle.AllObjects()) + 1; total != inv.Total || total != want {
+		t.Errorf("Total = %d, kinds add up to %d, want %d", inv.Total, total, want)
+	}
+	if inv.CPURequests == "" || inv.MemoryRequests == "" {
+		t.Errorf("expected total requests, got cpu=%q memory=%q", inv.CPURequests, inv.MemoryRequests)
+	}
+
+	var app, redis bool
+	for _, img := range inv.Images {
+		switch {
+		case img.Image == cfg.Spec.Image:
+			app = true
+			if img.Digest != digest {
+				t.Errorf("pinned image digest = %q, want %q", img.Digest, digest)
+			}
+			if !slices.Contains(img.Workloads, "Deployment/myapp") {
+				t.Errorf("app image workloads = %v", img.Workloads)
+			}
+		case img.Workloads[0] == "StatefulSet/myapp-redis":
+			redis = true
+			if img.Digest != "" {
+				t.Errorf("tagged image %s should have no digest before deploy", img.Image)
+			}
+		}
+	}
+	if !app || !redis {
+		t.Errorf("expected the app and redis images, got %+v", inv.Images)
+	}
+
+	if !slices.Contains(inv.Secrets, "myapp-redis") {
+		t.Errorf("expected the redis Secret by name, got %v", inv.Secrets)
+	}
+}
+
+func TestImageUses(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:          "myapp:v1",
+			Port:           8080,
+			InitContainers: []config.InitContainerConfig{{Name: "wait", Image: "busybox:1.36", Command: []string{"true"}}},
+			Dependencies:   []config.DependencyConfig{{Type: "redis"}},
+			Jobs:           []config.JobConfig{{Name: "report", Image: "reporter:v2", Command: []string{"run"}, Schedule: "0 * * * *"}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	roles := map[string]string{}
+	for _, use := range bundle.ImageUses() {
+		roles[use.Workload+" "+use.Image] = use.Role
+	}
+	for key, want := range map[string]string{
+		"Deployment/myapp myapp:v1":        ImageRoleApp,
+		"Deployment/myapp busybox:1.36":    ImageRoleInit,
+		"CronJob/myapp-report reporter:v2": ImageRoleJob,
+	} {
+		if roles[key] != want {
+			t.Errorf("role of %s = %q, want %q (all: %v)", key, roles[key], want, roles)
+		}
+	}
+	var dependency bool
+	for key, role := range roles {
+		if strings.HasPrefix(key, "StatefulSet/myapp-redis ") && role == ImageRoleDependency {
+			dependency = true
+		}
+	}
+	if !dependency {
+		t.Errorf("expected the redis image as a dependency, got %v", roles)
+	}
+}
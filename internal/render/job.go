@@ -1,6 +1,9 @@
 package render
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/bobbyrathoree/kbox/internal/config"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -45,10 +48,10 @@ func (r *Renderer) renderJob(jc config.JobConfig) *batchv1.Job {
 
 	// Add env vars if specified
 	if len(jc.Env) > 0 {
-		for k, v := range jc.Env {
+		for _, k := range slices.Sorted(maps.Keys(jc.Env)) {
 			container.Env = append(container.Env, corev1.EnvVar{
 				Name:  k,
-				Value: v,
+				Value: jc.Env[k],
 			})
 		}
 	}
@@ -59,7 +62,7 @@ func (r *Renderer) renderJob(jc config.JobConfig) *batchv1.Job {
 	}
 
 	// Add container-level security context
-	container.SecurityContext = defaultContainerSecurityContext()
+	container.SecurityContext = r.containerSecurityContext()
 
 	// Default backoff limit
 	var backoffLimit int32 = 3
@@ -84,7 +87,7 @@ func (r *Renderer) renderJob(jc config.JobConfig) *batchv1.Job {
 					Labels: r.jobLabels(jc.Name),
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext: defaultPodSecurityContext(),
+					SecurityContext: r.podSecurityContext(),
 					RestartPolicy:   corev1.RestartPolicyNever,
 					Containers:      []corev1.Container{container},
 					Volumes:         r.renderPodVolumes(),
@@ -98,6 +101,8 @@ func (r *Renderer) renderJob(jc config.JobConfig) *batchv1.Job {
 		job.Spec.TTLSecondsAfterFinished = jc.TTLSecondsAfterFinished
 	}
 
+	r.applyPlatform(&job.Spec.Template.Spec)
+
 	return job
 }
 
@@ -119,10 +124,10 @@ func (r *Renderer) renderCronJob(jc config.JobConfig) *batchv1.CronJob {
 
 	// Add env vars if specified
 	if len(jc.Env) > 0 {
-		for k, v := range jc.Env {
+		for _, k := range slices.Sorted(maps.Keys(jc.Env)) {
 			container.Env = append(container.Env, corev1.EnvVar{
 				Name:  k,
-				Value: v,
+				Value: jc.Env[k],
 			})
 		}
 	}
@@ -133,7 +138,7 @@ func (r *Renderer) renderCronJob(jc config.JobConfig) *batchv1.CronJob {
 	}
 
 	// Add container-level security context
-	container.SecurityContext = defaultContainerSecurityContext()
+	container.SecurityContext = r.containerSecurityContext()
 
 	// Default backoff limit
 	var backoffLimit int32 = 3
@@ -165,7 +170,7 @@ func (r *Renderer) renderCronJob(jc config.JobConfig) *batchv1.CronJob {
 							Labels: r.jobLabels(jc.Name),
 						},
 						Spec: corev1.PodSpec{
-							SecurityContext: defaultPodSecurityContext(),
+							SecurityContext: r.podSecurityContext(),
 							RestartPolicy:   corev1.RestartPolicyNever,
 							Containers:      []corev1.Container{container},
 							Volumes:         r.renderPodVolumes(),
@@ -181,6 +186,17 @@ func (r *Renderer) renderCronJob(jc config.JobConfig) *batchv1.CronJob {
 		cronJob.Spec.JobTemplate.Spec.TTLSecondsAfterFinished = jc.TTLSecondsAfterFinished
 	}
 
+	if jc.TimeZone != "" {
+		timeZone := jc.TimeZone
+		cronJob.Spec.TimeZone = &timeZone
+	}
+	if jc.HistoryLimit != nil {
+		cronJob.Spec.SuccessfulJobsHistoryLimit = jc.HistoryLimit
+		cronJob.Spec.FailedJobsHistoryLimit = jc.HistoryLimit
+	}
+
+	r.applyPlatform(&cronJob.Spec.JobTemplate.Spec.Template.Spec)
+
 	return cronJob
 }
 
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LabelManagedBy marks objects kbox created and is allowed to delete
+	LabelManagedBy = "kbox.dev/managed-by"
+
+	// LabelApp names the kbox app an object belongs to. For multi-service
+	// apps it is the top-level app, not the service.
+	LabelApp = "kbox.dev/app"
+
+	// ManagedByKbox is the value of LabelManagedBy
+	ManagedByKbox = "kbox"
+
+	// legacyManagedBy is the only ownership marker stamped by older kbox versions
+	legacyManagedBy = "app.kubernetes.io/managed-by"
+)
+
+// OwnershipSelector selects the objects kbox owns for app
+func OwnershipSelector(app string) string {
+	return LabelManagedBy + "=" + ManagedByKbox + "," + LabelApp + "=" + app
+}
+
+// OwnedSelectors returns every selector for objects kbox owns for app: the
+// ownership labels, then objects deployed by kbox versions that predate them.
+// Legacy matches still require kbox's managed-by label, so resources that
+// merely share the app=<name> convention are never selected. Redeploying
+// stamps the ownership labels on legacy objects.
+func OwnedSelectors(app string) []string {
+	return []string{
+		OwnershipSelector(app),
+		"app=" + app + "," + legacyManagedBy + "=" + ManagedByKbox,
+		LabelApp + "=" + app + "," + legacyManagedBy + "=" + ManagedByKbox, // dependencies
+	}
+}
+
+// AnchorName is the name of the ConfigMap that owns an app's other objects
+func AnchorName(app string) string {
+	return app + "-kbox-app"
+}
+
+// ReleasesName is the name of the ConfigMap that stores an app's release
+// history, which prune and down must recognize as the app's
+func ReleasesName(app string) string {
+	return app + "-releases"
+}
+
+// renderAnchor renders the app's anchor ConfigMap. The apply engine applies it
+// first and points the other objects' ownerReferences at it, so deleting the
+// anchor lets the garbage collector remove the whole app.
+func renderAnchor(app, namespace string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AnchorName(app),
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelManagedBy: ManagedByKbox,
+				LabelApp:       app,
+			},
+		},
+		Data: map[string]string{
+			"app": app,
+		},
+	}
+}
+
+// stampOwnership labels every object in the bundle as owned by app.
+// Only object metadata is labelled; pod templates and selectors are left alone
+// so upgrading kbox doesn't restart pods.
+func (b *Bundle) stampOwnership(app string) {
+	for _, obj := range b.AllObjects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		// Copy: label maps can be shared with pod templates
+		labels := maps.Clone(accessor.GetLabels())
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[LabelManagedBy] = ManagedByKbox
+		labels[LabelApp] = app
+		accessor.SetLabels(labels)
+	}
+}
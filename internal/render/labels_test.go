@@ -0,0 +1,59 @@
+package render
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestRenderStampsOwnershipLabels(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:        "myapp:v1",
+			Port:         8080,
+			Env:          map[string]string{"LOG_LEVEL": "info"},
+			Dependencies: []config.DependencyConfig{{Type: "postgres"}},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	for _, obj := range bundle.AllObjects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatalf("object without metadata: %T", obj)
+		}
+		labels := accessor.GetLabels()
+		if labels[LabelManagedBy] != ManagedByKbox || labels[LabelApp] != "myapp" {
+			t.Errorf("%T %s missing ownership labels: %v", obj, accessor.GetName(), labels)
+		}
+	}
+
+	if bundle.Anchor == nil || bundle.Anchor.Name != "myapp-kbox-app" || bundle.Anchor.Labels[LabelApp] != "myapp" {
+		t.Errorf("expected labelled anchor ConfigMap, got %+v", bundle.Anchor)
+	}
+
+	// Pod templates keep their labels so upgrades don't restart pods
+	podLabels := bundle.Deployment.Spec.Template.Labels
+	if _, ok := podLabels[LabelManagedBy]; ok {
+		t.Errorf("pod template should not carry %s: %v", LabelManagedBy, podLabels)
+	}
+}
+
+func TestOwnedSelectors(t *testing.T) {
+	selectors := OwnedSelectors("myapp")
+	if selectors[0] != "kbox.dev/managed-by=kbox,kbox.dev/app=myapp" {
+		t.Errorf("unexpected ownership selector %q", selectors[0])
+	}
+	for _, sel := range selectors {
+		if sel == "app=myapp" {
+			t.Error("bare app=<name> selector would match resources kbox doesn't own")
+		}
+	}
+}
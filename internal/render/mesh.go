@@ -0,0 +1,144 @@
+package render
+
+import (
+	"maps"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// Sidecar injection labels and annotations
+const (
+	LabelIstioInject             = "sidecar.istio.io/inject"
+	AnnotationIstioNativeSidecar = "sidecar.istio.io/nativeSidecar"
+	AnnotationIstioProxyConfig   = "proxy.istio.io/config"
+
+	AnnotationLinkerdInject        = "linkerd.io/inject"
+	AnnotationLinkerdNativeSidecar = "config.alpha.linkerd.io/proxy-enable-native-sidecar"
+)
+
+// meshPorts are the ports a mesh's sidecars use besides the app's: the
+// control plane they reach, and the proxy ports other pods reach them on
+type meshPorts struct {
+	namespace    string
+	controlPlane []int
+	inbound      []int
+}
+
+var meshes = map[string]meshPorts{
+	// istiod xDS and CA, and the proxy's Prometheus endpoint
+	config.MeshIstio: {namespace: "istio-system", controlPlane: []int{15012}, inbound: []int{15090}},
+	// destination, identity, and policy controllers, and the proxy's
+	// inbound and admin ports
+	config.MeshLinkerd: {namespace: "linkerd", controlPlane: []int{8086, 8080, 8090}, inbound: []int{4143, 4191}},
+}
+
+// applyMesh sets up the bundle's pods for spec.mesh: the app's pods and
+// Jobs get the sidecar (Jobs as a native sidecar, so they can complete),
+// and the NetworkPolicies let the sidecars reach the control plane.
+// Dependencies opt out when excludeDependencies is set, and the sleep
+// schedule's pods always do, as they only talk to the API server.
+func (r *Renderer) applyMesh(b *Bundle) {
+	mesh := r.config.Spec.Mesh
+	if mesh == nil || mesh.Provider == "" {
+		return
+	}
+	app := r.config.Metadata.Name
+
+	for _, dep := range b.Deployments {
+		injectSidecar(&dep.Spec.Template.ObjectMeta, mesh.Provider, false)
+	}
+	for _, ss := range b.StatefulSets {
+		optOut := ss != b.AppStatefulSet && mesh.ExcludeDependencies
+		injectSidecar(&ss.Spec.Template.ObjectMeta, meshOrNone(mesh.Provider, optOut), false)
+	}
+	for _, job := range b.Jobs {
+		injectSidecar(&job.Spec.Template.ObjectMeta, mesh.Provider, true)
+	}
+	for _, cj := range b.CronJobs {
+		injectSidecar(&cj.Spec.JobTemplate.Spec.Template.ObjectMeta, meshOrNone(mesh.Provider, isSleepCronJob(cj, app)), true)
+	}
+
+	if ports, ok := meshes[mesh.Provider]; ok {
+		for _, np := range b.NetworkPolicies {
+			allowMesh(np, ports)
+		}
+	}
+}
+
+func meshOrNone(provider string, optOut bool) string {
+	if optOut {
+		return config.MeshNone
+	}
+	return provider
+}
+
+// injectSidecar labels or annotates a pod template for the mesh's injector.
+// none opts out of both meshes, for namespaces that inject by default.
+func injectSidecar(pod *metav1.ObjectMeta, provider string, job bool) {
+	// Copy: pod templates often share their maps with the workload's
+	pod.Labels = maps.Clone(pod.Labels)
+	pod.Annotations = maps.Clone(pod.Annotations)
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+
+	switch provider {
+	case config.MeshIstio:
+		pod.Labels[LabelIstioInject] = "true"
+		// Start the app only once its proxy can carry traffic
+		pod.Annotations[AnnotationIstioProxyConfig] = `{"holdApplicationUntilProxyStarts": true}`
+		if job {
+			pod.Annotations[AnnotationIstioNativeSidecar] = "true"
+		}
+	case config.MeshLinkerd:
+		pod.Annotations[AnnotationLinkerdInject] = "enabled"
+		if job {
+			pod.Annotations[AnnotationLinkerdNativeSidecar] = "true"
+		}
+	case config.MeshNone:
+		pod.Labels[LabelIstioInject] = "false"
+		pod.Annotations[AnnotationLinkerdInject] = "disabled"
+	}
+}
+
+// allowMesh lets a NetworkPolicy's pods reach the mesh control plane, and
+// be reached on the proxy's own ports
+func allowMesh(np *networkingv1.NetworkPolicy, mesh meshPorts) {
+	tcp := corev1.ProtocolTCP
+	policyPorts := func(ports []int) []networkingv1.NetworkPolicyPort {
+		var result []networkingv1.NetworkPolicyPort
+		for _, p := range ports {
+			port := intstr.FromInt(p)
+			result = append(result, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &port})
+		}
+		return result
+	}
+
+	np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": mesh.namespace},
+			},
+		}},
+		Ports: policyPorts(mesh.controlPlane),
+	})
+	np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+		// Any namespace: meshed clients and the mesh's Prometheus
+		From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+		Ports: policyPorts(mesh.inbound),
+	})
+}
+
+// isSleepCronJob reports whether cj is one of the sleep schedule's
+func isSleepCronJob(cj *batchv1.CronJob, app string) bool {
+	return cj.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName == SleepName(app)
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestApplyMesh(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "dev"},
+		Spec: config.AppSpec{
+			Image:         "myapp:v1",
+			Port:          8080,
+			Dependencies:  []config.DependencyConfig{{Type: "postgres"}},
+			Jobs:          []config.JobConfig{{Name: "migrate", Command: []string{"./migrate"}}},
+			SleepSchedule: &config.SleepScheduleConfig{Sleep: "0 20 * * *", Wake: "0 7 * * *"},
+			Mesh:          &config.MeshConfig{Provider: config.MeshIstio, ExcludeDependencies: true},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	app := bundle.Deployment.Spec.Template
+	if app.Labels[LabelIstioInject] != "true" || app.Annotations[AnnotationIstioProxyConfig] == "" {
+		t.Errorf("expected the app injected, got labels %v annotations %v", app.Labels, app.Annotations)
+	}
+	if bundle.Deployment.Labels[LabelIstioInject] != "" {
+		t.Error("expected only the pod template labelled, not the Deployment")
+	}
+
+	db := bundle.StatefulSets[0]
+	if db.Spec.Template.Labels[LabelIstioInject] != "false" {
+		t.Errorf("expected the excluded dependency to opt out, got %v", db.Spec.Template.Labels)
+	}
+	if db.Labels[LabelIstioInject] != "" {
+		t.Error("expected the dependency's StatefulSet labels untouched")
+	}
+
+	job := bundle.Jobs[0].Spec.Template
+	if job.Labels[LabelIstioInject] != "true" || job.Annotations[AnnotationIstioNativeSidecar] != "true" {
+		t.Errorf("expected the job injected as a native sidecar, got %v %v", job.Labels, job.Annotations)
+	}
+	for _, cj := range bundle.CronJobs {
+		if cj.Spec.JobTemplate.Spec.Template.Labels[LabelIstioInject] != "false" {
+			t.Errorf("expected sleep CronJob %s to opt out", cj.Name)
+		}
+	}
+
+	np := bundle.NetworkPolicies[0]
+	egress := np.Spec.Egress[len(np.Spec.Egress)-1]
+	if egress.To[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"] != "istio-system" || egress.Ports[0].Port.IntValue() != 15012 {
+		t.Errorf("expected egress to istiod, got %+v", egress)
+	}
+}
+
+func TestApplyMesh_LinkerdAndNone(t *testing.T) {
+	for _, tt := range []struct {
+		mesh string
+		want string
+	}{
+		{config.MeshLinkerd, "enabled"},
+		{config.MeshNone, "disabled"},
+	} {
+		cfg := &config.AppConfig{
+			Metadata: config.Metadata{Name: "myapp"},
+			Spec:     config.AppSpec{Image: "myapp:v1", Port: 8080, Mesh: &config.MeshConfig{Provider: tt.mesh}},
+		}
+		bundle, err := New(cfg).Render()
+		if err != nil {
+			t.Fatalf("failed to render bundle: %v", err)
+		}
+		if got := bundle.Deployment.Spec.Template.Annotations[AnnotationLinkerdInject]; got != tt.want {
+			t.Errorf("%s: expected linkerd.io/inject %s, got %q", tt.mesh, tt.want, got)
+		}
+		rules := len(bundle.NetworkPolicies[0].Spec.Ingress)
+		if tt.mesh == config.MeshNone && rules != 1 {
+			t.Errorf("expected no mesh rules without a mesh, got %d ingress rules", rules)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"maps"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// stampMetadata adds metadata.labels and metadata.annotations to every
+// object in the bundle. Keys kbox sets itself win.
+func (b *Bundle) stampMetadata(md config.Metadata) {
+	if len(md.Labels) == 0 && len(md.Annotations) == 0 {
+		return
+	}
+	for _, obj := range b.AllObjects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		accessor.SetLabels(mergeMissing(accessor.GetLabels(), md.Labels))
+		accessor.SetAnnotations(mergeMissing(accessor.GetAnnotations(), md.Annotations))
+	}
+}
+
+// applyResourceMetadata adds spec.annotations and spec.labels to the
+// objects of each kind. Keys kbox sets itself win.
+func (r *Renderer) applyResourceMetadata(b *Bundle) {
+	annotations, labels := r.config.Spec.Annotations, r.config.Spec.Labels
+	if annotations == nil {
+		annotations = &config.ResourceMetadata{}
+	}
+	if labels == nil {
+		labels = &config.ResourceMetadata{}
+	}
+
+	for _, d := range b.Deployments {
+		d.Labels = mergeMissing(d.Labels, labels.Deployment)
+		d.Annotations = mergeMissing(d.Annotations, annotations.Deployment)
+		d.Spec.Template.Labels = mergeMissing(d.Spec.Template.Labels, labels.Pod)
+		d.Spec.Template.Annotations = mergeMissing(d.Spec.Template.Annotations, annotations.Pod)
+	}
+	if ss := b.AppStatefulSet; ss != nil {
+		ss.Labels = mergeMissing(ss.Labels, labels.Deployment)
+		ss.Annotations = mergeMissing(ss.Annotations, annotations.Deployment)
+		ss.Spec.Template.Labels = mergeMissing(ss.Spec.Template.Labels, labels.Pod)
+		ss.Spec.Template.Annotations = mergeMissing(ss.Spec.Template.Annotations, annotations.Pod)
+	}
+
+	name := r.config.Metadata.Name
+	for _, svc := range b.Services {
+		if svc.Name != name && svc.Name != HeadlessServiceName(name) {
+			continue
+		}
+		svc.Labels = mergeMissing(svc.Labels, labels.Service)
+		svc.Annotations = mergeMissing(svc.Annotations, annotations.Service)
+	}
+	for _, ing := range b.Ingresses {
+		ing.Labels = mergeMissing(ing.Labels, labels.Ingress)
+		ing.Annotations = mergeMissing(ing.Annotations, annotations.Ingress)
+	}
+}
+
+// mergeMissing returns a copy of dst with the keys of src it doesn't have.
+// Copied because label maps can be shared between objects.
+func mergeMissing(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	merged := maps.Clone(dst)
+	if merged == nil {
+		merged = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -2,10 +2,12 @@ package render
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // MultiServiceRenderer renders multi-service configurations
@@ -22,6 +24,9 @@ func NewMultiService(cfg *config.MultiServiceConfig) *MultiServiceRenderer {
 func (r *MultiServiceRenderer) Render() (*Bundle, error) {
 	bundle := &Bundle{}
 
+	// Shared secrets first, for the services' checksums to skip
+	bundle.Secrets = append(bundle.Secrets, r.renderSharedSecrets()...)
+
 	// Get services in dependency order
 	order := r.config.ServiceOrder()
 
@@ -44,6 +49,8 @@ func (r *MultiServiceRenderer) Render() (*Bundle, error) {
 
 		// Add service discovery environment variables
 		r.addServiceDiscoveryEnv(deployment, serviceName)
+		r.addDependencyWaits(deployment, serviceName)
+		r.addSharedSecretEnv(&deployment.Spec.Template, serviceName)
 
 		bundle.Deployments = append(bundle.Deployments, deployment)
 
@@ -55,13 +62,18 @@ func (r *MultiServiceRenderer) Render() (*Bundle, error) {
 		bundle.Services = append(bundle.Services, service)
 
 		// Render configmap if service has env vars
-		svc := r.config.Services[serviceName]
-		if len(svc.Env) > 0 {
+		if appCfg.Spec.HasEnv() {
 			cm, err := renderer.RenderConfigMap()
 			if err != nil {
 				return nil, fmt.Errorf("failed to render configmap for %s: %w", serviceName, err)
 			}
 			bundle.ConfigMaps = append(bundle.ConfigMaps, cm)
+			bundle.markComputedEnv(cm, renderer.computedEnv)
+		}
+
+		// Roll the service's pods when its ConfigMap changes
+		if appCfg.Spec.RollsOnConfigChange() {
+			stampChecksums(&deployment.Spec.Template, bundle.ConfigMaps, bundle.Secrets)
 		}
 	}
 
@@ -70,9 +82,29 @@ func (r *MultiServiceRenderer) Render() (*Bundle, error) {
 		bundle.Deployment = bundle.Deployments[0]
 	}
 
+	bundle.Anchor = renderAnchor(r.config.Metadata.Name, r.Namespace())
+	bundle.stampMetadata(r.config.Metadata)
+	bundle.stampOwnership(r.config.Metadata.Name)
 	return bundle, nil
 }
 
+// OnlyServices drops the Deployments, Services and ConfigMaps of the
+// MultiApp appName's services not listed, so a deploy leaves them as they
+// are. Objects the services share, such as shared secrets, stay.
+func (b *Bundle) OnlyServices(appName string, services []string) {
+	dropped := func(labels map[string]string) bool {
+		app := labels["app"]
+		return !slices.ContainsFunc(services, func(svc string) bool { return app == appName+"-"+svc })
+	}
+	b.Deployments = slices.DeleteFunc(b.Deployments, func(d *appsv1.Deployment) bool { return dropped(d.Labels) })
+	b.Services = slices.DeleteFunc(b.Services, func(s *corev1.Service) bool { return dropped(s.Labels) })
+	b.ConfigMaps = slices.DeleteFunc(b.ConfigMaps, func(cm *corev1.ConfigMap) bool { return dropped(cm.Labels) })
+	b.Deployment = nil
+	if len(b.Deployments) > 0 {
+		b.Deployment = b.Deployments[0]
+	}
+}
+
 // addServiceDiscoveryEnv adds environment variables for service discovery
 func (r *MultiServiceRenderer) addServiceDiscoveryEnv(deployment *appsv1.Deployment, currentService string) {
 	// Build service URLs for all services
@@ -91,7 +123,7 @@ func (r *MultiServiceRenderer) addServiceDiscoveryEnv(deployment *appsv1.Deploym
 		// Add service URLs for dependent services
 		svc := r.config.Services[currentService]
 		for _, depName := range svc.DependsOn {
-			envName := fmt.Sprintf("%s_URL", toEnvName(depName))
+			envName := fmt.Sprintf("%s_URL", ToEnvName(depName))
 			container.Env = append(container.Env, corev1.EnvVar{
 				Name:  envName,
 				Value: serviceURLs[depName],
@@ -100,8 +132,49 @@ func (r *MultiServiceRenderer) addServiceDiscoveryEnv(deployment *appsv1.Deploym
 	}
 }
 
-// toEnvName converts a service name to an environment variable name
-func toEnvName(name string) string {
+// WaitImage runs the init containers that wait for a service's
+// dependencies; busybox's wget fails on non-2xx responses
+const WaitImage = "busybox:1.36.1"
+
+// addDependencyWaits adds an init container per dependsOn service that
+// polls its readiness endpoint until it answers, when the service sets
+// waitForDependencies. They run as nobody whatever the service's security
+// profile, as busybox would otherwise run as root.
+func (r *MultiServiceRenderer) addDependencyWaits(deployment *appsv1.Deployment, currentService string) {
+	svc := r.config.Services[currentService]
+	if !svc.WaitForDependencies {
+		return
+	}
+
+	nobody := int64(65534)
+	var waits []corev1.Container
+	for _, depName := range svc.DependsOn {
+		sc := defaultContainerSecurityContext()
+		sc.RunAsUser = &nobody
+		url := r.config.ReadinessURL(depName)
+		script := fmt.Sprintf("until wget -q -T 5 -O /dev/null %s; do echo \"waiting for %s at %s\"; sleep 2; done", url, depName, url)
+		waits = append(waits, corev1.Container{
+			Name:    "wait-for-" + depName,
+			Image:   WaitImage,
+			Command: []string{"sh", "-c", script},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("16Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+			SecurityContext: sc,
+		})
+	}
+	spec := &deployment.Spec.Template.Spec
+	spec.InitContainers = append(waits, spec.InitContainers...)
+}
+
+// ToEnvName converts a service name to an environment variable name
+func ToEnvName(name string) string {
 	result := make([]byte, len(name))
 	for i, c := range name {
 		if c == '-' {
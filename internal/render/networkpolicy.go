@@ -1,6 +1,7 @@
 package render
 
 import (
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,7 +17,7 @@ func (r *Renderer) RenderNetworkPolicy() *networkingv1.NetworkPolicy {
 	udp := corev1.ProtocolUDP
 	tcp := corev1.ProtocolTCP
 
-	return &networkingv1.NetworkPolicy{
+	np := &networkingv1.NetworkPolicy{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "networking.k8s.io/v1",
 			Kind:       "NetworkPolicy",
@@ -99,4 +100,47 @@ func (r *Renderer) RenderNetworkPolicy() *networkingv1.NetworkPolicy {
 			},
 		},
 	}
+
+	// External dependencies' servers are outside the cluster, on their own ports
+	for _, dep := range r.config.Spec.Dependencies {
+		if dep.External == nil {
+			continue
+		}
+		port := dep.External.Port
+		if port == 0 {
+			template, ok := dependencies.Get(dep.Type)
+			if !ok {
+				continue
+			}
+			port = template.DefaultPort
+		}
+		depPort := intstr.FromInt32(port)
+		np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{
+					Protocol: &tcp,
+					Port:     &depPort,
+				},
+			},
+		})
+	}
+
+	// LoadBalancer and NodePort traffic comes from outside the cluster
+	if svc := r.config.Spec.Service; svc != nil && (svc.Type == "LoadBalancer" || svc.Type == "NodePort") {
+		targetPort := r.config.Spec.Port
+		if svc.TargetPort != 0 {
+			targetPort = svc.TargetPort
+		}
+		httpPort := intstr.FromInt(targetPort)
+		rule := networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &httpPort}},
+		}
+		for _, p := range svc.Ports {
+			protocol := corev1.Protocol(p.PortProtocol())
+			port := intstr.FromInt(p.ContainerPort())
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+		}
+		np.Spec.Ingress = append(np.Spec.Ingress, rule)
+	}
+	return np
 }
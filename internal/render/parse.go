@@ -0,0 +1,112 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// ParseBundle reads the manifests of app that ToYAML wrote back into a
+// bundle, so they can be applied as kbox would have applied them after
+// rendering. Objects of kinds the client doesn't know, such as
+// ServiceMonitors, are kept as unstructured objects.
+func ParseBundle(data []byte, app string) (*Bundle, error) {
+	bundle := &Bundle{}
+	decoder := scheme.Codecs.UniversalDeserializer()
+	namespace := ""
+
+	for i, doc := range bytes.Split(data, []byte("\n---\n")) {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			u := &unstructured.Unstructured{}
+			if yamlErr := yaml.Unmarshal(doc, &u.Object); yamlErr != nil || u.GetKind() == "" {
+				return nil, fmt.Errorf("document %d: %w", i+1, err)
+			}
+			if u.GetKind() != "ServiceMonitor" {
+				return nil, fmt.Errorf("document %d: unsupported kind %s", i+1, u.GetKind())
+			}
+			bundle.ServiceMonitors = append(bundle.ServiceMonitors, u)
+			continue
+		}
+
+		switch o := obj.(type) {
+		case *corev1.Namespace:
+			bundle.Namespace = o
+		case *corev1.ServiceAccount:
+			if o.Name == app && bundle.ServiceAccount == nil {
+				bundle.ServiceAccount = o
+			} else {
+				bundle.ServiceAccounts = append(bundle.ServiceAccounts, o)
+			}
+		case *rbacv1.Role:
+			bundle.Roles = append(bundle.Roles, o)
+		case *rbacv1.RoleBinding:
+			bundle.RoleBindings = append(bundle.RoleBindings, o)
+		case *corev1.PersistentVolumeClaim:
+			bundle.PersistentVolumeClaims = append(bundle.PersistentVolumeClaims, o)
+		case *corev1.ConfigMap:
+			bundle.ConfigMaps = append(bundle.ConfigMaps, o)
+		case *corev1.Secret:
+			bundle.Secrets = append(bundle.Secrets, o)
+		case *corev1.Service:
+			bundle.Services = append(bundle.Services, o)
+		case *appsv1.StatefulSet:
+			bundle.StatefulSets = append(bundle.StatefulSets, o)
+			if o.Name == app {
+				bundle.AppStatefulSet = o
+			}
+		case *appsv1.Deployment:
+			bundle.Deployments = append(bundle.Deployments, o)
+			if o.Name == app {
+				bundle.Deployment = o
+			}
+		case *batchv1.Job:
+			bundle.Jobs = append(bundle.Jobs, o)
+		case *batchv1.CronJob:
+			bundle.CronJobs = append(bundle.CronJobs, o)
+		case *networkingv1.Ingress:
+			bundle.Ingresses = append(bundle.Ingresses, o)
+		case *networkingv1.NetworkPolicy:
+			bundle.NetworkPolicies = append(bundle.NetworkPolicies, o)
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			bundle.HPA = o
+		case *policyv1.PodDisruptionBudget:
+			bundle.PDB = o
+		default:
+			return nil, fmt.Errorf("document %d: unsupported kind %s", i+1, obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		if ns := metaNamespace(obj); namespace == "" {
+			namespace = ns
+		}
+	}
+
+	if bundle.Deployment == nil && len(bundle.Deployments) > 0 {
+		bundle.Deployment = bundle.Deployments[0]
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	bundle.Anchor = renderAnchor(app, namespace)
+	return bundle, nil
+}
+
+// metaNamespace returns the namespace of a typed object, or ""
+func metaNamespace(obj any) string {
+	if o, ok := obj.(interface{ GetNamespace() string }); ok {
+		return o.GetNamespace()
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestParseBundle(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "shop"},
+		Spec: config.AppSpec{
+			Image:        "myapp:v1",
+			Port:         8080,
+			Env:          map[string]string{"LOG_LEVEL": "info"},
+			Dependencies: []config.DependencyConfig{{Type: "redis"}},
+			Jobs:         []config.JobConfig{{Name: "report", Command: []string{"run"}, Schedule: "0 * * * *"}},
+		},
+	}
+	rendered, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := rendered.ToYAML(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseBundle(buf.Bytes(), "myapp")
+	if err != nil {
+		t.Fatalf("ParseBundle() error: %v", err)
+	}
+	if got, want := len(parsed.AllObjects()), len(rendered.AllObjects()); got != want {
+		t.Errorf("parsed %d objects, want %d", got, want)
+	}
+	if parsed.Deployment == nil || parsed.Deployment.Name != "myapp" {
+		t.Errorf("expected the app Deployment, got %v", parsed.Deployment)
+	}
+	if parsed.Anchor == nil || parsed.Anchor.Name != AnchorName("myapp") || parsed.Anchor.Namespace != "shop" {
+		t.Errorf("expected the app's anchor in shop, got %+v", parsed.Anchor)
+	}
+	var again bytes.Buffer
+	parsed.ToYAML(&again)
+	if again.String() != buf.String() {
+		t.Error("parsed bundle should render the same manifests")
+	}
+
+	parsed.RewriteImages(map[string]string{"myapp:v1": "registry.internal/myapp:v1"})
+	if got := parsed.Deployment.Spec.Template.Spec.Containers[0].Image; got != "registry.internal/myapp:v1" {
+		t.Errorf("rewritten image = %q", got)
+	}
+	if got := parsed.CronJobs[0].Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image; got != "registry.internal/myapp:v1" {
+		t.Errorf("rewritten job image = %q", got)
+	}
+
+	if _, err := ParseBundle([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"), "myapp"); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
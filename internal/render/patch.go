@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// ApplyPatches applies spec.overrides patches to the objects they target,
+// in order. A patch that matches nothing, or leaves an object that no
+// longer decodes into its type (a misspelled field, a string where a
+// number goes), is an error.
+func (b *Bundle) ApplyPatches(patches []config.PatchConfig) error {
+	for i, patch := range patches {
+		field := fmt.Sprintf("spec.overrides.patches[%d]", i)
+		target := patch.Target.Kind
+		if patch.Target.Name != "" {
+			target += "/" + patch.Target.Name
+		}
+
+		matched := false
+		for _, obj := range b.AllObjects() {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				continue
+			}
+			if obj.GetObjectKind().GroupVersionKind().Kind != patch.Target.Kind {
+				continue
+			}
+			if patch.Target.Name != "" && accessor.GetName() != patch.Target.Name {
+				continue
+			}
+			matched = true
+			if err := applyPatch(obj, patch); err != nil {
+				return fmt.Errorf("%s (%s/%s): %w", field, patch.Target.Kind, accessor.GetName(), err)
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: %s matches no rendered object\n  → Run 'kbox render --summary' to list them", field, target)
+		}
+	}
+	return nil
+}
+
+// applyPatch patches obj in place
+func applyPatch(obj runtime.Object, patch config.PatchConfig) error {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	if len(patch.JSONPatch) > 0 {
+		ops, err := json.Marshal(patch.JSONPatch)
+		if err != nil {
+			return err
+		}
+		decoded, err := jsonpatch.DecodePatch(ops)
+		if err != nil {
+			return fmt.Errorf("invalid jsonPatch: %w", err)
+		}
+		if patched, err = decoded.Apply(original); err != nil {
+			return fmt.Errorf("jsonPatch failed: %w", err)
+		}
+	} else {
+		body, err := json.Marshal(patch.StrategicMerge)
+		if err != nil {
+			return err
+		}
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			// No Go type to read merge keys from: merge as a JSON merge patch
+			patched, err = jsonpatch.MergePatch(original, body)
+		} else {
+			patched, err = strategicpatch.StrategicMergePatch(original, body, obj)
+		}
+		if err != nil {
+			return fmt.Errorf("strategicMerge failed: %w", err)
+		}
+	}
+
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.UnmarshalJSON(patched)
+	}
+
+	// Decode strictly into a fresh object of the same type, so a patch
+	// can't leave fields the API server would drop or reject
+	fresh := reflect.New(reflect.TypeOf(obj).Elem())
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(fresh.Interface()); err != nil {
+		return fmt.Errorf("patched object is not a valid %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+	}
+	if kind := fresh.Interface().(runtime.Object).GetObjectKind().GroupVersionKind().Kind; kind != obj.GetObjectKind().GroupVersionKind().Kind {
+		return fmt.Errorf("patches may not change kind (got %q)", kind)
+	}
+	reflect.ValueOf(obj).Elem().Set(fresh.Elem())
+	return nil
+}
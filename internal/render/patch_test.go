@@ -0,0 +1,127 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func patchConfig(overrides *config.OverrideConfig) *config.AppConfig {
+	return &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "dev"},
+		Spec: config.AppSpec{
+			Image:     "myapp:v1",
+			Port:      8080,
+			Env:       map[string]string{"LOG": "info"},
+			Overrides: overrides,
+		},
+	}
+}
+
+func TestApplyPatches_StrategicMerge(t *testing.T) {
+	cfg := patchConfig(&config.OverrideConfig{
+		Deployment: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"dnsPolicy": "None",
+						// Merged into the app container by name, not replacing the list
+						"containers": []interface{}{
+							map[string]interface{}{"name": "myapp", "stdin": true},
+						},
+					},
+				},
+			},
+		},
+		Patches: []config.PatchConfig{{
+			Target:         config.PatchTarget{Kind: "ConfigMap"},
+			StrategicMerge: map[string]interface{}{"data": map[string]interface{}{"EXTRA": "1"}},
+		}},
+	})
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	pod := bundle.Deployment.Spec.Template.Spec
+	if pod.DNSPolicy != "None" {
+		t.Errorf("expected dnsPolicy None, got %q", pod.DNSPolicy)
+	}
+	if len(pod.Containers) != 1 || !pod.Containers[0].Stdin || pod.Containers[0].Image != "myapp:v1" {
+		t.Errorf("expected the patch merged into the app container, got %+v", pod.Containers)
+	}
+	if bundle.ConfigMaps[0].Data["EXTRA"] != "1" || bundle.ConfigMaps[0].Data["LOG"] != "info" {
+		t.Errorf("expected the ConfigMap patched, got %v", bundle.ConfigMaps[0].Data)
+	}
+	// Patches land before checksums, so the pods roll on patched config
+	if bundle.Deployment.Spec.Template.Annotations[AnnotationConfigChecksum] == "" {
+		t.Error("expected the config checksum to be stamped")
+	}
+}
+
+func TestApplyPatches_JSONPatch(t *testing.T) {
+	cfg := patchConfig(&config.OverrideConfig{
+		Patches: []config.PatchConfig{{
+			Target: config.PatchTarget{Kind: "Service", Name: "myapp"},
+			JSONPatch: []config.JSONPatchOperation{
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]interface{}{"example.com/lb": "internal"}},
+				{Op: "replace", Path: "/spec/type", Value: "NodePort"},
+			},
+		}},
+	})
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	svc := bundle.Services[0]
+	if svc.Spec.Type != "NodePort" || svc.Annotations["example.com/lb"] != "internal" {
+		t.Errorf("expected the Service patched, got type %q annotations %v", svc.Spec.Type, svc.Annotations)
+	}
+}
+
+func TestApplyPatches_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch config.PatchConfig
+		want  string
+	}{
+		{
+			name:  "no match",
+			patch: config.PatchConfig{Target: config.PatchTarget{Kind: "Ingress"}, StrategicMerge: map[string]interface{}{"spec": nil}},
+			want:  "matches no rendered object",
+		},
+		{
+			name: "unknown field",
+			patch: config.PatchConfig{
+				Target:         config.PatchTarget{Kind: "Deployment", Name: "myapp"},
+				StrategicMerge: map[string]interface{}{"spec": map[string]interface{}{"replicaz": 3}},
+			},
+			want: "not a valid Deployment",
+		},
+		{
+			name: "wrong type",
+			patch: config.PatchConfig{
+				Target:    config.PatchTarget{Kind: "Deployment"},
+				JSONPatch: []config.JSONPatchOperation{{Op: "replace", Path: "/spec/replicas", Value: "three"}},
+			},
+			want: "not a valid Deployment",
+		},
+		{
+			name: "missing path",
+			patch: config.PatchConfig{
+				Target:    config.PatchTarget{Kind: "Deployment"},
+				JSONPatch: []config.JSONPatchOperation{{Op: "remove", Path: "/spec/nope"}},
+			},
+			want: "jsonPatch failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(patchConfig(&config.OverrideConfig{Patches: []config.PatchConfig{tt.patch}})).Render()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -6,46 +6,28 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-// RenderPDB creates a PodDisruptionBudget
-// Auto-generates when replicas > 1 or autoscaling is enabled (for HA)
-// Can be explicitly configured via spec.pdb
+// RenderPDB creates a PodDisruptionBudget for apps running more than one
+// replica (see AppSpec.HasPDB): spec.pdb's minAvailable or maxUnavailable,
+// or maxUnavailable: 1 to keep the rest serving during drains and updates
 func (r *Renderer) RenderPDB() *policyv1.PodDisruptionBudget {
-	// Check if we should auto-generate PDB for HA workloads
-	autoGenerate := false
-	if r.config.Spec.Replicas > 1 {
-		autoGenerate = true
-	}
-	if r.config.Spec.Autoscaling != nil && r.config.Spec.Autoscaling.Enabled {
-		autoGenerate = true
-	}
-
-	// If explicit config provided, use it
-	if r.config.Spec.PDB != nil {
-		cfg := r.config.Spec.PDB
-		if cfg.MinAvailable != "" || cfg.MaxUnavailable != "" {
-			pdb := r.createPDB()
-			if cfg.MinAvailable != "" {
-				minAvail := intstr.Parse(cfg.MinAvailable)
-				pdb.Spec.MinAvailable = &minAvail
-			}
-			if cfg.MaxUnavailable != "" {
-				maxUnavail := intstr.Parse(cfg.MaxUnavailable)
-				pdb.Spec.MaxUnavailable = &maxUnavail
-			}
-			return pdb
-		}
+	if !r.config.Spec.HasPDB() {
+		return nil
 	}
 
-	// Auto-generate for HA workloads if no explicit config
-	if autoGenerate {
-		pdb := r.createPDB()
-		// Default: allow max 1 unavailable to ensure availability during updates
+	pdb := r.createPDB()
+	cfg := r.config.Spec.PDB
+	switch {
+	case cfg != nil && cfg.MinAvailable != "":
+		minAvail := intstr.Parse(cfg.MinAvailable)
+		pdb.Spec.MinAvailable = &minAvail
+	case cfg != nil && cfg.MaxUnavailable != "":
+		maxUnavail := intstr.Parse(cfg.MaxUnavailable)
+		pdb.Spec.MaxUnavailable = &maxUnavail
+	default:
 		maxUnavail := intstr.FromInt(1)
 		pdb.Spec.MaxUnavailable = &maxUnavail
-		return pdb
 	}
-
-	return nil
+	return pdb
 }
 
 // createPDB creates the base PDB structure
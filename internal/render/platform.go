@@ -0,0 +1,59 @@
+package render
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// Well-known node labels for OS and CPU architecture
+const (
+	LabelNodeOS   = "kubernetes.io/os"
+	LabelNodeArch = "kubernetes.io/arch"
+)
+
+// applyPlatform pins a pod to the app's node OS and architecture. Dedicated
+// Windows and arm64 node pools are commonly tainted, so the pod tolerates
+// those taints too. Windows pods drop the Linux-only security settings,
+// which the API server rejects for them.
+func (r *Renderer) applyPlatform(spec *corev1.PodSpec) {
+	app := &r.config.Spec
+	if app.Platform == "" && app.Arch == "" {
+		return
+	}
+	if spec.NodeSelector == nil {
+		spec.NodeSelector = make(map[string]string)
+	}
+
+	if app.Platform != "" {
+		spec.NodeSelector[LabelNodeOS] = app.Platform
+	}
+	if app.Platform == config.PlatformWindows {
+		spec.OS = &corev1.PodOS{Name: corev1.Windows}
+		spec.Tolerations = append(spec.Tolerations, corev1.Toleration{
+			Key:      "os",
+			Operator: corev1.TolerationOpEqual,
+			Value:    config.PlatformWindows,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+		if spec.SecurityContext != nil {
+			spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: spec.SecurityContext.RunAsNonRoot}
+		}
+		for i := range spec.InitContainers {
+			spec.InitContainers[i].SecurityContext = nil
+		}
+		for i := range spec.Containers {
+			spec.Containers[i].SecurityContext = nil
+		}
+	}
+
+	if app.Arch != "" {
+		spec.NodeSelector[LabelNodeArch] = app.Arch
+		spec.Tolerations = append(spec.Tolerations, corev1.Toleration{
+			Key:      LabelNodeArch,
+			Operator: corev1.TolerationOpEqual,
+			Value:    app.Arch,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+}
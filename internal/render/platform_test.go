@@ -0,0 +1,54 @@
+package render
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestPlatformArm64(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			Arch:  config.ArchARM64,
+			Jobs:  []config.JobConfig{{Name: "migrate", Command: []string{"migrate"}}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	for _, pod := range []corev1.PodSpec{bundle.Deployment.Spec.Template.Spec, bundle.Jobs[0].Spec.Template.Spec} {
+		if pod.NodeSelector[LabelNodeArch] != "arm64" {
+			t.Errorf("expected arm64 nodeSelector, got %v", pod.NodeSelector)
+		}
+		if len(pod.Tolerations) != 1 || pod.Tolerations[0].Key != LabelNodeArch {
+			t.Errorf("expected arm64 toleration, got %v", pod.Tolerations)
+		}
+	}
+}
+
+func TestPlatformWindows(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec:     config.AppSpec{Image: "myapp:v1", Port: 8080, Platform: config.PlatformWindows},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	pod := bundle.Deployment.Spec.Template.Spec
+	if pod.OS == nil || pod.OS.Name != corev1.Windows || pod.NodeSelector[LabelNodeOS] != "windows" {
+		t.Errorf("expected windows pod OS and nodeSelector, got %v %v", pod.OS, pod.NodeSelector)
+	}
+	if pod.SecurityContext.SeccompProfile != nil || pod.Containers[0].SecurityContext != nil {
+		t.Errorf("expected Linux-only security settings to be dropped, got %+v", pod.SecurityContext)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityRestricted); len(v) > 0 {
+		t.Errorf("expected windows pods to meet the restricted standard, got %v", v)
+	}
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// Pod Security Standards levels, as used in namespace labels
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// restrictedVolumeTypes are the only volume sources the restricted level allows
+var restrictedVolumeTypes = map[string]bool{
+	"configMap": true, "csi": true, "downwardAPI": true, "emptyDir": true,
+	"ephemeral": true, "persistentVolumeClaim": true, "projected": true, "secret": true,
+}
+
+// PodSecurityViolations checks the bundle's pod templates against a Pod
+// Security Standards level, returning one message per violation, e.g.
+// "StatefulSet/myapp-postgres: container postgres: must set runAsNonRoot"
+func (b *Bundle) PodSecurityViolations(level string) []string {
+	var violations []string
+	check := func(kind, name string, spec *corev1.PodSpec) {
+		for _, v := range PodSecurityViolations(spec, level) {
+			violations = append(violations, fmt.Sprintf("%s/%s: %s", kind, name, v))
+		}
+	}
+	for _, ss := range b.StatefulSets {
+		check("StatefulSet", ss.Name, &ss.Spec.Template.Spec)
+	}
+	for _, dep := range b.Deployments {
+		check("Deployment", dep.Name, &dep.Spec.Template.Spec)
+	}
+	for _, job := range b.Jobs {
+		check("Job", job.Name, &job.Spec.Template.Spec)
+	}
+	for _, cj := range b.CronJobs {
+		check("CronJob", cj.Name, &cj.Spec.JobTemplate.Spec.Template.Spec)
+	}
+	return violations
+}
+
+// PodSecurityViolations checks a pod spec against the main controls of a Pod
+// Security Standards level (https://kubernetes.io/docs/concepts/security/pod-security-standards/).
+// The privileged level allows everything.
+func PodSecurityViolations(spec *corev1.PodSpec, level string) []string {
+	if level != PodSecurityBaseline && level != PodSecurityRestricted {
+		return nil
+	}
+	restricted := level == PodSecurityRestricted
+	// Windows pods are exempt from the Linux-only controls
+	windows := spec.OS != nil && spec.OS.Name == corev1.Windows
+	var violations []string
+	add := func(format string, args ...any) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		add("must not share host namespaces")
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			add("volume %s: hostPath volumes are not allowed", v.Name)
+		} else if restricted && !restrictedVolumeTypes[volumeType(v)] {
+			add("volume %s: %s volumes are not allowed", v.Name, volumeType(v))
+		}
+	}
+
+	psc := spec.SecurityContext
+	if psc == nil {
+		psc = &corev1.PodSecurityContext{}
+	}
+	if psc.SeccompProfile != nil && psc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		add("seccomp profile must not be Unconfined")
+	}
+	if restricted && psc.RunAsUser != nil && *psc.RunAsUser == 0 {
+		add("must not run as UID 0")
+	}
+
+	containers := append(slices.Clone(spec.InitContainers), spec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		where := "container " + c.Name
+
+		if sc.Privileged != nil && *sc.Privileged {
+			add("%s: must not be privileged", where)
+		}
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				add("%s: must not use hostPort %d", where, p.HostPort)
+			}
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			add("%s: seccomp profile must not be Unconfined", where)
+		}
+
+		var added []corev1.Capability
+		if sc.Capabilities != nil {
+			added = sc.Capabilities.Add
+		}
+		for _, capability := range added {
+			allowed := slices.Contains(config.BaselineCapabilities, string(capability))
+			if restricted {
+				allowed = capability == "NET_BIND_SERVICE"
+			}
+			if !allowed {
+				add("%s: capability %s is not allowed", where, capability)
+			}
+		}
+
+		if !restricted {
+			continue
+		}
+		if !windows && (sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation) {
+			add("%s: must set allowPrivilegeEscalation=false", where)
+		}
+		if !windows && (sc.Capabilities == nil || !slices.Contains(sc.Capabilities.Drop, "ALL")) {
+			add("%s: must drop ALL capabilities", where)
+		}
+		nonRoot := psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			nonRoot = *sc.RunAsNonRoot
+		}
+		if !nonRoot {
+			add("%s: must set runAsNonRoot=true", where)
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			add("%s: must not run as UID 0", where)
+		}
+		seccomp := psc.SeccompProfile
+		if sc.SeccompProfile != nil {
+			seccomp = sc.SeccompProfile
+		}
+		if !windows && (seccomp == nil || (seccomp.Type != corev1.SeccompProfileTypeRuntimeDefault && seccomp.Type != corev1.SeccompProfileTypeLocalhost)) {
+			add("%s: must set seccompProfile to RuntimeDefault or Localhost", where)
+		}
+	}
+	return violations
+}
+
+// volumeType names a volume's source the way the Pod Security Standards do
+func volumeType(v corev1.Volume) string {
+	switch s := v.VolumeSource; {
+	case s.ConfigMap != nil:
+		return "configMap"
+	case s.CSI != nil:
+		return "csi"
+	case s.DownwardAPI != nil:
+		return "downwardAPI"
+	case s.EmptyDir != nil:
+		return "emptyDir"
+	case s.Ephemeral != nil:
+		return "ephemeral"
+	case s.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case s.Projected != nil:
+		return "projected"
+	case s.Secret != nil:
+		return "secret"
+	case s.HostPath != nil:
+		return "hostPath"
+	}
+	return "other"
+}
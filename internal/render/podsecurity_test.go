@@ -0,0 +1,82 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestDefaultsMeetRestrictedStandard(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			Env:   map[string]string{"A": "b"},
+			Jobs:  []config.JobConfig{{Name: "migrate", Command: []string{"migrate"}}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityRestricted); len(v) > 0 {
+		t.Errorf("expected default pods to meet the restricted standard, got %v", v)
+	}
+}
+
+func TestSecurityContextOverrides(t *testing.T) {
+	uid := int64(101)
+	writable := false
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "nginx:1.27",
+			Port:  80,
+			SecurityContext: &config.SecurityContextConfig{
+				RunAsUser:              &uid,
+				SeccompProfile:         "Localhost/profiles/nginx.json",
+				ReadOnlyRootFilesystem: &writable,
+				Capabilities:           []string{"NET_BIND_SERVICE"},
+			},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	pod := bundle.Deployment.Spec.Template.Spec
+	if *pod.SecurityContext.RunAsUser != 101 || *pod.SecurityContext.RunAsGroup != 1000 {
+		t.Errorf("expected runAsUser override with default group, got %+v", pod.SecurityContext)
+	}
+	if sp := pod.SecurityContext.SeccompProfile; sp.Type != "Localhost" || *sp.LocalhostProfile != "profiles/nginx.json" {
+		t.Errorf("unexpected seccomp profile %+v", sp)
+	}
+	sc := pod.Containers[0].SecurityContext
+	if *sc.ReadOnlyRootFilesystem || len(sc.Capabilities.Add) != 1 || sc.Capabilities.Drop[0] != "ALL" {
+		t.Errorf("unexpected container security context %+v", sc)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityRestricted); len(v) > 0 {
+		t.Errorf("expected overrides to stay restricted, got %v", v)
+	}
+
+	// The baseline preset runs as the image's user with default capabilities
+	cfg.Spec.SecurityContext = nil
+	cfg.Spec.SecurityProfile = config.SecurityProfileBaseline
+	bundle, _ = New(cfg).Render()
+	pod = bundle.Deployment.Spec.Template.Spec
+	if pod.SecurityContext.RunAsUser != nil || pod.SecurityContext.RunAsNonRoot != nil {
+		t.Errorf("expected baseline to keep the image user, got %+v", pod.SecurityContext)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityBaseline); len(v) > 0 {
+		t.Errorf("expected baseline preset to meet baseline, got %v", v)
+	}
+	violations := bundle.PodSecurityViolations(PodSecurityRestricted)
+	if len(violations) == 0 || !strings.HasPrefix(violations[0], "Deployment/myapp: container myapp:") {
+		t.Errorf("expected baseline preset to violate restricted, got %v", violations)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityPrivileged); v != nil {
+		t.Errorf("privileged allows everything, got %v", v)
+	}
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"maps"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// LabelProcess names the process type (see config.AppSpec.Processes) that a
+// Deployment and its pods run
+const LabelProcess = "kbox.dev/process"
+
+// ProcessName returns the Deployment name for a process type, e.g. myapp-worker
+func ProcessName(app, process string) string {
+	return app + "-" + process
+}
+
+// renderProcesses renders a Deployment for each non-web process type. Each
+// is a copy of the web Deployment (same image, env, and volumes) running the
+// process's command, without ports or HTTP probes. Pods are labeled
+// app=<app>-<process> so the app's Service never routes to them.
+func (r *Renderer) renderProcesses(web *appsv1.Deployment) []*appsv1.Deployment {
+	app := r.config.Metadata.Name
+	var deployments []*appsv1.Deployment
+
+	for _, process := range slices.Sorted(maps.Keys(r.config.Spec.Processes)) {
+		if process == config.WebProcess {
+			continue
+		}
+		proc := r.config.Spec.Processes[process]
+		name := ProcessName(app, process)
+
+		replicas := int32(1)
+		if proc.Replicas != nil {
+			replicas = int32(*proc.Replicas)
+		}
+
+		labels := r.Labels()
+		labels["app"] = name
+		labels["app.kubernetes.io/component"] = process
+		labels[LabelProcess] = process
+
+		dep := web.DeepCopy()
+		dep.Name = name
+		dep.Labels = labels
+		dep.Spec.Replicas = &replicas
+		dep.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}}
+		dep.Spec.Template.Labels = maps.Clone(labels)
+
+		container := &dep.Spec.Template.Spec.Containers[0]
+		container.Name = process
+		container.Command = proc.Command
+		container.Args = proc.Args
+		container.Ports = nil
+		container.LivenessProbe = nil
+		container.ReadinessProbe = nil
+		if proc.Resources != nil {
+			container.Resources = resourceRequirements(proc.Resources)
+		}
+
+		deployments = append(deployments, dep)
+	}
+	return deployments
+}
+
+// renderProcessNetworkPolicy restricts a process's pods like the app's own
+// policy does, except that nothing may connect to them: they serve no traffic
+func renderProcessNetworkPolicy(app *networkingv1.NetworkPolicy, process *appsv1.Deployment) *networkingv1.NetworkPolicy {
+	policy := app.DeepCopy()
+	policy.Name = process.Name
+	policy.Labels = maps.Clone(process.Labels)
+	policy.Spec.PodSelector = metav1.LabelSelector{MatchLabels: map[string]string{"app": process.Name}}
+	policy.Spec.Ingress = nil
+	return policy
+}
+
+// Workloads returns the names of everything a deploy waits on: the app's
+// Deployment or StatefulSet, then its process Deployments and the canary
+// of its traffic split
+func (b *Bundle) Workloads() []string {
+	var names []string
+	if name := b.WorkloadName(); name != "" {
+		names = append(names, name)
+	}
+	for _, dep := range b.Processes() {
+		names = append(names, dep.Name)
+	}
+	if canary := b.Canary(); canary != nil {
+		names = append(names, canary.Name)
+	}
+	return names
+}
+
+// Processes returns the Deployments of the app's non-web process types
+func (b *Bundle) Processes() []*appsv1.Deployment {
+	var processes []*appsv1.Deployment
+	for _, dep := range b.Deployments {
+		if dep.Labels[LabelProcess] != "" {
+			processes = append(processes, dep)
+		}
+	}
+	return processes
+}
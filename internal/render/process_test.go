@@ -0,0 +1,84 @@
+package render
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestRenderProcesses(t *testing.T) {
+	webReplicas, workerReplicas := 3, 2
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "shop"},
+		Spec: config.AppSpec{
+			Image:        "shop:v1",
+			Port:         3000,
+			HealthCheck:  "/health",
+			Env:          map[string]string{"RAILS_ENV": "production"},
+			Dependencies: []config.DependencyConfig{{Type: "redis"}},
+			Processes: map[string]config.ProcessConfig{
+				"web":    {Command: []string{"bin/rails", "server"}, Replicas: &webReplicas},
+				"worker": {Command: []string{"bundle", "exec", "sidekiq"}, Replicas: &workerReplicas, Resources: &config.ResourceConfig{Memory: "1Gi"}},
+				"clock":  {Command: []string{"bin/clock"}},
+			},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	web := bundle.Deployment
+	if web.Name != "shop" || *web.Spec.Replicas != 3 || !slices.Equal(web.Spec.Template.Spec.Containers[0].Command, []string{"bin/rails", "server"}) {
+		t.Errorf("expected the web process to configure the app Deployment, got %s replicas=%d command=%v",
+			web.Name, *web.Spec.Replicas, web.Spec.Template.Spec.Containers[0].Command)
+	}
+
+	processes := bundle.Processes()
+	if len(processes) != 2 || processes[0].Name != "shop-clock" || processes[1].Name != "shop-worker" {
+		t.Fatalf("expected shop-clock and shop-worker Deployments, got %d", len(processes))
+	}
+	if got := bundle.Workloads(); !slices.Equal(got, []string{"shop", "shop-clock", "shop-worker"}) {
+		t.Errorf("unexpected workloads %v", got)
+	}
+
+	worker := processes[1]
+	c := worker.Spec.Template.Spec.Containers[0]
+	if *worker.Spec.Replicas != 2 || !slices.Equal(c.Command, []string{"bundle", "exec", "sidekiq"}) {
+		t.Errorf("unexpected worker replicas=%d command=%v", *worker.Spec.Replicas, c.Command)
+	}
+	if len(c.Ports) != 0 || c.ReadinessProbe != nil || c.LivenessProbe != nil {
+		t.Error("worker should have no ports or HTTP probes")
+	}
+	if c.Resources.Requests.Memory().String() != "1Gi" {
+		t.Errorf("expected worker memory request 1Gi, got %s", c.Resources.Requests.Memory())
+	}
+	if worker.Spec.Template.Labels["app"] != "shop-worker" || worker.Spec.Selector.MatchLabels["app"] != "shop-worker" {
+		t.Error("worker pods must not match the app Service selector")
+	}
+	// Workers see the same config and dependencies as web
+	if len(c.EnvFrom) == 0 || len(c.Env) == 0 {
+		t.Error("expected worker to inherit envFrom and dependency env")
+	}
+	if worker.Spec.Template.Annotations[AnnotationConfigChecksum] == "" {
+		t.Error("expected worker pods to roll on config changes")
+	}
+
+	// Only web is exposed
+	for _, svc := range bundle.Services {
+		if svc.Spec.Selector["app"] == "shop-worker" || svc.Spec.Selector["app"] == "shop-clock" {
+			t.Errorf("service %s should not select a worker process", svc.Name)
+		}
+	}
+	var workerPolicy bool
+	for _, np := range bundle.NetworkPolicies {
+		if np.Name == "shop-worker" {
+			workerPolicy = np.Spec.PodSelector.MatchLabels["app"] == "shop-worker" && len(np.Spec.Ingress) == 0
+		}
+	}
+	if !workerPolicy {
+		t.Error("expected a deny-ingress NetworkPolicy for the worker")
+	}
+}
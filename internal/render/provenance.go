@@ -0,0 +1,288 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Provenance annotations, added by kbox render --show-provenance
+const (
+	// AnnotationSource lists the kbox.yaml fields that produced the object
+	AnnotationSource = "kbox.dev/source"
+	// AnnotationEnvironment is the environment overlay applied
+	AnnotationEnvironment = "kbox.dev/environment"
+	// AnnotationOverlay lists the overlay's fields that changed the object
+	AnnotationOverlay = "kbox.dev/overlay"
+	// AnnotationTemplate is the dependency template the object came from
+	AnnotationTemplate = "kbox.dev/template"
+)
+
+// Provenance says where a rendered object came from
+type Provenance struct {
+	// Sources are the kbox.yaml fields that produced the object, e.g.
+	// "spec.dependencies[0]". "spec" is the app's workload, which most of
+	// the spec shapes.
+	Sources []string
+	// Environment is the environment overlay applied, if any
+	Environment string
+	// Overlay are the overlay's fields that changed the object
+	Overlay []string
+	// Template is the dependency template, e.g. "postgres:15-alpine"
+	Template string
+}
+
+// TraceProvenance records where each object in the bundle came from, for
+// ToYAML and ToJSON to annotate it with. overlay lists the fields env
+// overrides (config.AppConfig.OverlayFields).
+func (r *Renderer) TraceProvenance(b *Bundle, env string, overlay []string) {
+	spec := r.config.Spec
+	t := newTracer(b, env, overlay)
+
+	if b.Namespace != nil {
+		t.set(b.Namespace, "metadata.namespace")
+	}
+	if b.ServiceAccount != nil {
+		t.set(b.ServiceAccount, "metadata.name")
+	}
+	for _, sa := range b.ServiceAccounts {
+		t.set(sa, "spec.sleepSchedule")
+	}
+	for _, role := range b.Roles {
+		t.set(role, "spec.sleepSchedule")
+	}
+	for _, binding := range b.RoleBindings {
+		t.set(binding, "spec.sleepSchedule")
+	}
+	for _, pvc := range b.PersistentVolumeClaims {
+		t.set(pvc, "spec.volumes")
+	}
+	for _, cm := range b.ConfigMaps {
+		t.set(cm, "spec.env")
+	}
+	for _, s := range b.Secrets {
+		name := s.Name
+		if original := s.Labels[LabelConfigVersionOf]; original != "" {
+			name = original
+		}
+		switch {
+		case s.Labels["kbox.dev/dependency"] != "":
+			// set below, with the dependency's other objects
+		case strings.HasSuffix(name, "-sops-secrets"):
+			t.set(s, "spec.secrets.fromSops")
+		default:
+			t.set(s, "spec.secrets.fromEnvFile")
+		}
+	}
+	for _, svc := range b.Services {
+		switch {
+		case svc.Labels["kbox.dev/dependency"] != "":
+		case svc.Name == HeadlessServiceName(r.config.Metadata.Name):
+			t.set(svc, "spec.workload", "spec.port")
+		default:
+			t.set(svc, "spec.port", "spec.service")
+		}
+	}
+	if b.AppStatefulSet != nil {
+		t.set(b.AppStatefulSet, "spec")
+	}
+	for _, dep := range b.Deployments {
+		if process := dep.Labels[LabelProcess]; process != "" {
+			t.set(dep, "spec.processes."+process)
+		} else {
+			t.set(dep, "spec")
+		}
+	}
+	if len(b.Deployments) == 0 && b.Deployment != nil {
+		t.set(b.Deployment, "spec")
+	}
+	for _, job := range b.Jobs {
+		t.set(job, jobSource(spec.Jobs, job.Labels["kbox.dev/job"]))
+	}
+	for _, cj := range b.CronJobs {
+		if isSleepCronJob(cj, r.config.Metadata.Name) {
+			t.set(cj, "spec.sleepSchedule")
+		} else {
+			t.set(cj, jobSource(spec.Jobs, cj.Labels["kbox.dev/job"]))
+		}
+	}
+	for _, ing := range b.Ingresses {
+		t.set(ing, "spec.ingress")
+	}
+	for _, np := range b.NetworkPolicies {
+		if process := np.Labels[LabelProcess]; process != "" {
+			t.set(np, "spec.processes."+process)
+		} else {
+			t.set(np, "metadata.name")
+		}
+	}
+	if b.HPA != nil {
+		t.set(b.HPA, "spec.autoscaling")
+	}
+	if b.PDB != nil {
+		t.set(b.PDB, "spec.pdb")
+	}
+	for _, sm := range b.ServiceMonitors {
+		t.set(sm, "spec.metrics")
+	}
+
+	// Dependencies' StatefulSets, Services and Secrets
+	for _, obj := range b.AllObjects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		depType := accessor.GetLabels()["kbox.dev/dependency"]
+		if depType == "" {
+			continue
+		}
+		for i, dep := range spec.Dependencies {
+			if dep.Type != depType {
+				continue
+			}
+			t.set(obj, fmt.Sprintf("spec.dependencies[%d]", i))
+			if template, ok := dependencies.Get(dep.Type); ok {
+				t.provenance[obj].Template = dependencies.ImageWithVersion(template, dep.Version)
+			}
+			break
+		}
+	}
+
+	t.finish()
+}
+
+// TraceProvenance records where each object in the bundle came from: the
+// service it belongs to. overlay lists the fields env overrides
+// (config.MultiServiceConfig.OverlayFields).
+func (r *MultiServiceRenderer) TraceProvenance(b *Bundle, env string, overlay []string) {
+	t := newTracer(b, env, overlay)
+	for _, obj := range b.AllObjects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		for name := range r.config.Services {
+			if accessor.GetLabels()["app"] != fmt.Sprintf("%s-%s", r.config.Metadata.Name, name) {
+				continue
+			}
+			source := "services." + name
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				source += ".env"
+			}
+			t.set(obj, source)
+		}
+	}
+	t.finish()
+}
+
+// jobSource returns the path of the named job
+func jobSource(jobs []config.JobConfig, name string) string {
+	for i, job := range jobs {
+		if job.Name == name {
+			return fmt.Sprintf("spec.jobs[%d]", i)
+		}
+	}
+	return "spec.jobs"
+}
+
+// tracer collects the provenance of a bundle's objects
+type tracer struct {
+	bundle     *Bundle
+	env        string
+	overlay    []string
+	provenance map[runtime.Object]*Provenance
+}
+
+func newTracer(b *Bundle, env string, overlay []string) *tracer {
+	return &tracer{bundle: b, env: env, overlay: overlay, provenance: make(map[runtime.Object]*Provenance)}
+}
+
+func (t *tracer) set(obj runtime.Object, sources ...string) {
+	t.provenance[obj] = &Provenance{Sources: sources, Environment: t.env}
+}
+
+// finish matches the overlay's fields to the objects they changed, and
+// stores the provenance in the bundle. A field changes an object when it is
+// one of the object's sources, or above or below one; fields no other
+// object claims are the app's ("spec").
+func (t *tracer) finish() {
+	claimed := make(map[string]bool)
+	for _, p := range t.provenance {
+		for _, source := range p.Sources {
+			if source == "spec" {
+				continue
+			}
+			for _, field := range t.overlay {
+				if related(field, source) {
+					claimed[field] = true
+				}
+			}
+		}
+	}
+
+	t.bundle.provenance = make(map[runtime.Object]Provenance, len(t.provenance))
+	for obj, p := range t.provenance {
+		for _, field := range t.overlay {
+			for _, source := range p.Sources {
+				if (source == "spec" && !claimed[field]) || (source != "spec" && related(field, source)) {
+					p.Overlay = append(p.Overlay, field)
+					break
+				}
+			}
+		}
+		t.bundle.provenance[obj] = *p
+	}
+}
+
+// related reports whether one path is the other, or contains it
+func related(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+".") || strings.HasPrefix(b, a+"[")
+}
+
+// annotated returns obj as a map, with its provenance annotations when the
+// bundle has traced them
+func (b *Bundle) annotated(obj runtime.Object) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var objMap map[string]interface{}
+	if err := json.Unmarshal(data, &objMap); err != nil {
+		return nil, err
+	}
+
+	p, ok := b.provenance[obj]
+	if !ok {
+		return objMap, nil
+	}
+	metadata, _ := objMap["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		objMap["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	annotations[AnnotationSource] = strings.Join(p.Sources, ", ")
+	if p.Environment != "" {
+		annotations[AnnotationEnvironment] = p.Environment
+	}
+	if len(p.Overlay) > 0 {
+		annotations[AnnotationOverlay] = strings.Join(p.Overlay, ", ")
+	}
+	if p.Template != "" {
+		annotations[AnnotationTemplate] = p.Template
+	}
+	return objMap, nil
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestTraceProvenance(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "dev"},
+		Spec: config.AppSpec{
+			Image:        "myapp:v1",
+			Port:         8080,
+			Env:          map[string]string{"LOG": "warn"},
+			Dependencies: []config.DependencyConfig{{Type: "redis"}, {Type: "postgres", Version: "16"}},
+			Ingress:      &config.IngressConfig{Enabled: true, Host: "myapp.com"},
+		},
+	}
+	renderer := New(cfg)
+	bundle, err := renderer.Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	renderer.TraceProvenance(bundle, "prod", []string{"spec.env.LOG", "spec.ingress.host", "spec.replicas"})
+
+	var out bytes.Buffer
+	if err := bundle.ToJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Objects []struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	annotations := make(map[string]map[string]string)
+	for _, obj := range result.Objects {
+		if obj.Metadata.Annotations[AnnotationSource] == "" {
+			t.Errorf("%s/%s has no provenance", obj.Kind, obj.Metadata.Name)
+		}
+		annotations[obj.Kind+"/"+obj.Metadata.Name] = obj.Metadata.Annotations
+	}
+
+	tests := []struct {
+		object   string
+		source   string
+		overlay  string
+		template string
+	}{
+		{"Deployment/myapp", "spec", "spec.replicas", ""},
+		{"ConfigMap/myapp-config", "spec.env", "spec.env.LOG", ""},
+		{"Ingress/myapp", "spec.ingress", "spec.ingress.host", ""},
+		{"StatefulSet/myapp-postgres", "spec.dependencies[1]", "", "postgres:16"},
+		{"Service/myapp-redis", "spec.dependencies[0]", "", "redis:7-alpine"},
+	}
+	for _, tt := range tests {
+		a, ok := annotations[tt.object]
+		if !ok {
+			t.Errorf("%s not rendered", tt.object)
+			continue
+		}
+		if a[AnnotationSource] != tt.source || a[AnnotationOverlay] != tt.overlay || a[AnnotationTemplate] != tt.template {
+			t.Errorf("%s: unexpected provenance %v", tt.object, a)
+		}
+		if a[AnnotationEnvironment] != "prod" {
+			t.Errorf("%s: expected environment prod, got %q", tt.object, a[AnnotationEnvironment])
+		}
+	}
+
+	// Without TraceProvenance, nothing is annotated
+	plain, _ := New(cfg).Render()
+	out.Reset()
+	if err := plain.ToYAML(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), AnnotationSource) {
+		t.Error("expected no provenance annotations without TraceProvenance")
+	}
+}
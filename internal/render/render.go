@@ -1,6 +1,9 @@
 package render
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/bobbyrathoree/kbox/internal/config"
 	"github.com/bobbyrathoree/kbox/internal/secrets"
 	appsv1 "k8s.io/api/apps/v1"
@@ -9,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -18,6 +22,9 @@ type Bundle struct {
 	// Objects in apply order
 	Namespace              *corev1.Namespace
 	ServiceAccount         *corev1.ServiceAccount
+	ServiceAccounts        []*corev1.ServiceAccount // for in-cluster automation, e.g. the sleep schedule
+	Roles                  []*rbacv1.Role
+	RoleBindings           []*rbacv1.RoleBinding
 	PersistentVolumeClaims []*corev1.PersistentVolumeClaim
 	ConfigMaps             []*corev1.ConfigMap
 	Secrets                []*corev1.Secret
@@ -33,6 +40,89 @@ type Bundle struct {
 	ServiceMonitors        []*unstructured.Unstructured
 	// Deployment is kept for backward compatibility (points to first deployment)
 	Deployment *appsv1.Deployment
+	// AppStatefulSet is the app itself when it runs as a StatefulSet
+	// (workload: statefulset). It is also listed in StatefulSets.
+	AppStatefulSet *appsv1.StatefulSet
+	// Anchor owns the other objects through ownerReferences. It is applied by
+	// the engine but left out of AllObjects: ownerReferences need its UID, so
+	// rendered manifests can't carry them.
+	Anchor *corev1.ConfigMap
+
+	// provenance is set by TraceProvenance, for ToYAML and ToJSON to annotate
+	// objects with
+	provenance map[runtime.Object]Provenance
+
+	// computedEnv holds, per env ConfigMap, the keys whose values came from
+	// a file or an env helper
+	computedEnv map[*corev1.ConfigMap][]string
+}
+
+// ComputedEnv returns the keys of an env ConfigMap whose values were loaded
+// from a file (envFromFile, ${file:...}, ${json:...}) or computed by a helper,
+// so kbox render --redact can hide them
+func (b *Bundle) ComputedEnv(cm *corev1.ConfigMap) []string {
+	return b.computedEnv[cm]
+}
+
+// Redact replaces all secret data with redacted placeholders, and the env
+// values loaded from files or computed by env helpers. Secret keys are kept,
+// so redacted output still shows what each Secret provides.
+func (b *Bundle) Redact() {
+	for _, cm := range b.ConfigMaps {
+		for _, key := range b.ComputedEnv(cm) {
+			cm.Data[key] = "[REDACTED]"
+		}
+	}
+	for _, secret := range b.Secrets {
+		for key := range secret.Data {
+			secret.Data[key] = []byte("[REDACTED]")
+		}
+
+		// Move StringData keys to Data, so the output shows data with
+		// [REDACTED] values even for secrets created with StringData
+		if len(secret.StringData) > 0 {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+			for key := range secret.StringData {
+				secret.Data[key] = []byte("[REDACTED]")
+			}
+			secret.StringData = nil
+		}
+	}
+}
+
+func (b *Bundle) markComputedEnv(cm *corev1.ConfigMap, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if b.computedEnv == nil {
+		b.computedEnv = make(map[*corev1.ConfigMap][]string)
+	}
+	b.computedEnv[cm] = keys
+}
+
+// WorkloadName returns the name of the app's Deployment or StatefulSet,
+// or "" if the bundle has neither
+func (b *Bundle) WorkloadName() string {
+	switch {
+	case b.Deployment != nil:
+		return b.Deployment.Name
+	case b.AppStatefulSet != nil:
+		return b.AppStatefulSet.Name
+	}
+	return ""
+}
+
+// PodTemplate returns the pod template of the app's Deployment or StatefulSet
+func (b *Bundle) PodTemplate() *corev1.PodTemplateSpec {
+	switch {
+	case b.Deployment != nil:
+		return &b.Deployment.Spec.Template
+	case b.AppStatefulSet != nil:
+		return &b.AppStatefulSet.Spec.Template
+	}
+	return nil
 }
 
 // AllObjects returns all objects in the bundle in apply order
@@ -47,6 +137,15 @@ func (b *Bundle) AllObjects() []runtime.Object {
 	if b.ServiceAccount != nil {
 		objects = append(objects, b.ServiceAccount)
 	}
+	for _, sa := range b.ServiceAccounts {
+		objects = append(objects, sa)
+	}
+	for _, role := range b.Roles {
+		objects = append(objects, role)
+	}
+	for _, binding := range b.RoleBindings {
+		objects = append(objects, binding)
+	}
 	// PVCs before anything that might use them
 	for _, pvc := range b.PersistentVolumeClaims {
 		objects = append(objects, pvc)
@@ -107,6 +206,10 @@ func (b *Bundle) AllObjects() []runtime.Object {
 // Renderer renders kbox config into Kubernetes objects
 type Renderer struct {
 	config *config.AppConfig
+
+	// computedEnv is set by RenderConfigMap: the env variables whose values
+	// came from a file or a helper
+	computedEnv []string
 }
 
 // New creates a new renderer for the given config
@@ -136,8 +239,8 @@ func (r *Renderer) Render() (*Bundle, error) {
 		depSecretEnvRefs = secretEnvRefs
 	}
 
-	// Render PersistentVolumeClaims for app volumes
-	if len(r.config.Spec.Volumes) > 0 {
+	// Render PersistentVolumeClaims for app volumes (a StatefulSet claims its own)
+	if len(r.config.Spec.Volumes) > 0 && !r.config.Spec.IsStatefulSet() {
 		pvcs, err := r.RenderVolumes()
 		if err != nil {
 			return nil, err
@@ -153,16 +256,22 @@ func (r *Renderer) Render() (*Bundle, error) {
 
 	// Inject dependency environment variables into the app deployment
 	if len(depEnvVars) > 0 || len(depSecretEnvRefs) > 0 {
+		// Sorted so identical configs render identical Deployments (no spurious rollouts)
 		for i := range deployment.Spec.Template.Spec.Containers {
 			// Add plaintext env vars (no passwords)
-			for k, v := range depEnvVars {
+			for _, k := range slices.Sorted(maps.Keys(depEnvVars)) {
 				deployment.Spec.Template.Spec.Containers[i].Env = append(
 					deployment.Spec.Template.Spec.Containers[i].Env,
-					corev1.EnvVar{Name: k, Value: v},
+					corev1.EnvVar{Name: k, Value: depEnvVars[k]},
 				)
 			}
 			// Add env vars that reference secrets (passwords)
-			for k, ref := range depSecretEnvRefs {
+			for _, k := range slices.Sorted(maps.Keys(depSecretEnvRefs)) {
+				ref := depSecretEnvRefs[k]
+				var optional *bool
+				if ref.Optional {
+					optional = &ref.Optional
+				}
 				deployment.Spec.Template.Spec.Containers[i].Env = append(
 					deployment.Spec.Template.Spec.Containers[i].Env,
 					corev1.EnvVar{
@@ -172,7 +281,8 @@ func (r *Renderer) Render() (*Bundle, error) {
 								LocalObjectReference: corev1.LocalObjectReference{
 									Name: ref.SecretName,
 								},
-								Key: ref.SecretKey,
+								Key:      ref.SecretKey,
+								Optional: optional,
 							},
 						},
 					},
@@ -181,8 +291,22 @@ func (r *Renderer) Render() (*Bundle, error) {
 		}
 	}
 
-	bundle.Deployment = deployment
-	bundle.Deployments = []*appsv1.Deployment{deployment}
+	// Non-web processes run from the same pod spec, dependency env included
+	processes := r.renderProcesses(deployment)
+
+	if r.config.Spec.IsStatefulSet() {
+		ss, err := r.RenderStatefulSet(deployment)
+		if err != nil {
+			return nil, err
+		}
+		bundle.AppStatefulSet = ss
+		bundle.StatefulSets = append(bundle.StatefulSets, ss)
+		bundle.Services = append(bundle.Services, r.RenderHeadlessService())
+	} else {
+		bundle.Deployment = deployment
+		bundle.Deployments = []*appsv1.Deployment{deployment}
+	}
+	bundle.Deployments = append(bundle.Deployments, processes...)
 
 	// Render Service
 	service, err := r.RenderService()
@@ -192,12 +316,13 @@ func (r *Renderer) Render() (*Bundle, error) {
 	bundle.Services = append(bundle.Services, service)
 
 	// Render ConfigMap for env vars if any
-	if len(r.config.Spec.Env) > 0 {
+	if r.config.Spec.HasEnv() {
 		cm, err := r.RenderConfigMap()
 		if err != nil {
 			return nil, err
 		}
 		bundle.ConfigMaps = append(bundle.ConfigMaps, cm)
+		bundle.markComputedEnv(cm, r.computedEnv)
 	}
 
 	// Render Secrets from .env file if configured
@@ -225,6 +350,15 @@ func (r *Renderer) Render() (*Bundle, error) {
 			return nil, err
 		}
 		bundle.Ingresses = append(bundle.Ingresses, ingress)
+
+		// Send a share of the ingress traffic to a canary Deployment
+		if split := r.config.Spec.TrafficSplit(); split != nil && bundle.Deployment != nil {
+			canary := r.renderCanary(deployment, split)
+			canaryService := renderCanaryService(service, canary)
+			bundle.Deployments = append(bundle.Deployments, canary)
+			bundle.Services = append(bundle.Services, canaryService)
+			bundle.Ingresses = append(bundle.Ingresses, renderCanaryIngress(ingress, canaryService, split.Weight))
+		}
 	}
 
 	// Render Jobs and CronJobs if configured
@@ -237,9 +371,24 @@ func (r *Renderer) Render() (*Bundle, error) {
 		bundle.CronJobs = cronJobs
 	}
 
+	// Render the CronJobs that put the app to sleep and wake it up
+	if r.config.Spec.SleepSchedule != nil {
+		sa, role, binding, cronJobs := r.RenderSleepSchedule()
+		bundle.ServiceAccounts = append(bundle.ServiceAccounts, sa)
+		bundle.Roles = append(bundle.Roles, role)
+		bundle.RoleBindings = append(bundle.RoleBindings, binding)
+		bundle.CronJobs = append(bundle.CronJobs, cronJobs...)
+	}
+
 	// Render NetworkPolicy
 	networkPolicy := r.RenderNetworkPolicy()
 	bundle.NetworkPolicies = append(bundle.NetworkPolicies, networkPolicy)
+	for _, process := range processes {
+		bundle.NetworkPolicies = append(bundle.NetworkPolicies, renderProcessNetworkPolicy(networkPolicy, process))
+	}
+	if canary := bundle.Canary(); canary != nil {
+		bundle.NetworkPolicies = append(bundle.NetworkPolicies, renderCanaryNetworkPolicy(networkPolicy, canary))
+	}
 
 	// Render HPA if autoscaling is enabled
 	bundle.HPA = r.RenderHPA()
@@ -252,6 +401,35 @@ func (r *Renderer) Render() (*Bundle, error) {
 		bundle.ServiceMonitors = append(bundle.ServiceMonitors, sm)
 	}
 
+	// Set up the pods for the service mesh, if any
+	r.applyMesh(bundle)
+
+	// Overrides patch the rendered objects before config is versioned and
+	// checksummed, so those see the patched ConfigMaps and Secrets
+	if err := bundle.ApplyPatches(r.config.Spec.Overrides.AllPatches(r.config.Metadata.Name)); err != nil {
+		return nil, err
+	}
+
+	// Versioned config changes the pod spec by itself; otherwise roll the
+	// pods through checksum annotations when their ConfigMaps or Secrets change
+	if r.config.Spec.ConfigVersioning {
+		bundle.versionConfig()
+	} else if r.config.Spec.RollsOnConfigChange() {
+		if template := bundle.PodTemplate(); template != nil {
+			stampChecksums(template, bundle.ConfigMaps, bundle.Secrets)
+		}
+		for _, process := range processes {
+			stampChecksums(&process.Spec.Template, bundle.ConfigMaps, bundle.Secrets)
+		}
+		if canary := bundle.Canary(); canary != nil {
+			stampChecksums(&canary.Spec.Template, bundle.ConfigMaps, bundle.Secrets)
+		}
+	}
+
+	r.applyResourceMetadata(bundle)
+	bundle.Anchor = renderAnchor(r.config.Metadata.Name, r.Namespace())
+	bundle.stampMetadata(r.config.Metadata)
+	bundle.stampOwnership(r.config.Metadata.Name)
 	return bundle, nil
 }
 
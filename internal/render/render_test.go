@@ -2,10 +2,13 @@ package render
 
 import (
 	"bytes"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/bobbyrathoree/kbox/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 )
 
 func TestRenderDeployment(t *testing.T) {
@@ -104,6 +107,183 @@ func TestRenderService(t *testing.T) {
 	}
 }
 
+func TestRenderService_Options(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  3000,
+			Service: &config.ServiceConfig{
+				Type:                   "LoadBalancer",
+				SessionAffinity:        "ClientIP",
+				SessionAffinityTimeout: 600,
+				ExternalTrafficPolicy:  "Local",
+				Annotations: map[string]string{
+					"service.beta.kubernetes.io/aws-load-balancer-scheme": "internal",
+				},
+			},
+		},
+	}
+
+	svc, err := New(cfg).RenderService()
+	if err != nil {
+		t.Fatalf("failed to render service: %v", err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("expected LoadBalancer, got %s", svc.Spec.Type)
+	}
+	if svc.Spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		t.Errorf("expected ClientIP session affinity, got %q", svc.Spec.SessionAffinity)
+	}
+	if c := svc.Spec.SessionAffinityConfig; c == nil || *c.ClientIP.TimeoutSeconds != 600 {
+		t.Errorf("expected a 600s affinity timeout, got %v", c)
+	}
+	if svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyLocal {
+		t.Errorf("expected externalTrafficPolicy Local, got %q", svc.Spec.ExternalTrafficPolicy)
+	}
+	if svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"] != "internal" {
+		t.Errorf("expected the internal LB annotation, got %v", svc.Annotations)
+	}
+
+	// Headless
+	cfg.Spec.Service = &config.ServiceConfig{ClusterIP: "None"}
+	svc, _ = New(cfg).RenderService()
+	if svc.Spec.ClusterIP != corev1.ClusterIPNone || svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("expected a headless ClusterIP Service, got %s %q", svc.Spec.Type, svc.Spec.ClusterIP)
+	}
+
+	// ExternalName has no pods behind it
+	cfg.Spec.Service = &config.ServiceConfig{Type: "ExternalName", ExternalName: "api.example.com"}
+	svc, _ = New(cfg).RenderService()
+	if svc.Spec.ExternalName != "api.example.com" || svc.Spec.Selector != nil {
+		t.Errorf("expected an ExternalName alias without a selector, got %q %v", svc.Spec.ExternalName, svc.Spec.Selector)
+	}
+}
+
+func TestRenderService_UDPLoadBalancer(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "game"},
+		Spec: config.AppSpec{
+			Image:       "game:v1",
+			Port:        8080,
+			HealthCheck: "/healthz",
+			Service: &config.ServiceConfig{
+				Type: "LoadBalancer",
+				Ports: []config.ServicePortConfig{
+					{Port: 7777, Protocol: "UDP"},
+					{Name: "rcon", Port: 27015, TargetPort: 25575},
+				},
+			},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	var svc *corev1.Service
+	for _, s := range bundle.Services {
+		if s.Name == "game" {
+			svc = s
+		}
+	}
+	if svc == nil || len(svc.Spec.Ports) != 3 {
+		t.Fatalf("expected http, udp-7777 and rcon ports, got %v", svc)
+	}
+	if p := svc.Spec.Ports[1]; p.Name != "udp-7777" || p.Protocol != corev1.ProtocolUDP || p.TargetPort.IntValue() != 7777 {
+		t.Errorf("unexpected UDP port %+v", p)
+	}
+	if p := svc.Spec.Ports[2]; p.Name != "rcon" || p.Protocol != corev1.ProtocolTCP || p.TargetPort.IntValue() != 25575 {
+		t.Errorf("unexpected rcon port %+v", p)
+	}
+
+	// UDP can't be health checked: probe the app's HTTP port instead
+	want := map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-healthcheck-protocol": "HTTP",
+		"service.beta.kubernetes.io/aws-load-balancer-healthcheck-port":     "8080",
+		"service.beta.kubernetes.io/aws-load-balancer-healthcheck-path":     "/healthz",
+		"service.beta.kubernetes.io/port_7777_health-probe_protocol":        "Http",
+		"service.beta.kubernetes.io/port_7777_health-probe_port":            "8080",
+		"service.beta.kubernetes.io/port_7777_health-probe_request-path":    "/healthz",
+	}
+	for k, v := range want {
+		if svc.Annotations[k] != v {
+			t.Errorf("annotation %s = %q, want %q", k, svc.Annotations[k], v)
+		}
+	}
+
+	// The pods name the ports, and the NetworkPolicy lets the traffic in
+	container := bundle.Deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 3 || container.Ports[1].Protocol != corev1.ProtocolUDP {
+		t.Errorf("expected the UDP port on the container, got %v", container.Ports)
+	}
+	var udpAllowed bool
+	for _, rule := range bundle.NetworkPolicies[0].Spec.Ingress {
+		for _, p := range rule.Ports {
+			if p.Protocol != nil && *p.Protocol == corev1.ProtocolUDP && p.Port.IntValue() == 7777 {
+				udpAllowed = true
+			}
+		}
+	}
+	if !udpAllowed {
+		t.Error("expected NetworkPolicy ingress on 7777/UDP")
+	}
+
+	// All-TCP load balancers keep their own health checks
+	cfg.Spec.Service.Ports = cfg.Spec.Service.Ports[1:]
+	svc, _ = New(cfg).RenderService()
+	if len(svc.Annotations) != 0 {
+		t.Errorf("expected no health check annotations, got %v", svc.Annotations)
+	}
+
+	// An HTTP check of an all-TCP load balancer probes each traffic port
+	cfg.Spec.Service.HealthCheck = &config.ServiceHealthCheckConfig{Path: "/healthz"}
+	svc, _ = New(cfg).RenderService()
+	if got := svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-healthcheck-port"]; got != "traffic-port" {
+		t.Errorf("AWS health check port = %q, want traffic-port", got)
+	}
+}
+
+func TestRenderExternalDNS(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:   "myapp:v1",
+			Port:    3000,
+			Ingress: &config.IngressConfig{Enabled: true, Host: "myapp.example.com", DNS: true},
+			Service: &config.ServiceConfig{
+				Type: "LoadBalancer",
+				DNS:  &config.ServiceDNSConfig{Hostname: "tcp.example.com", TTL: 60},
+			},
+		},
+	}
+
+	ingress, err := New(cfg).RenderIngress()
+	if err != nil {
+		t.Fatalf("failed to render ingress: %v", err)
+	}
+	if got := ingress.Annotations[AnnotationExternalDNSHostname]; got != "myapp.example.com" {
+		t.Errorf("ingress hostname annotation = %q, want myapp.example.com", got)
+	}
+
+	svc, err := New(cfg).RenderService()
+	if err != nil {
+		t.Fatalf("failed to render service: %v", err)
+	}
+	if got := svc.Annotations[AnnotationExternalDNSHostname]; got != "tcp.example.com" {
+		t.Errorf("service hostname annotation = %q, want tcp.example.com", got)
+	}
+	if got := svc.Annotations[AnnotationExternalDNSTTL]; got != "60" {
+		t.Errorf("service TTL annotation = %q, want 60", got)
+	}
+
+	cfg.Spec.Ingress.DNS = false
+	ingress, _ = New(cfg).RenderIngress()
+	if _, ok := ingress.Annotations[AnnotationExternalDNSHostname]; ok {
+		t.Error("expected no external-dns annotation without ingress.dns")
+	}
+}
+
 func TestRenderConfigMap(t *testing.T) {
 	cfg := &config.AppConfig{
 		Metadata: config.Metadata{Name: "myapp"},
@@ -224,3 +404,474 @@ func TestImageWithTag(t *testing.T) {
 		})
 	}
 }
+
+func TestBundleDigest(t *testing.T) {
+	digest := func(image string) string {
+		cfg := &config.AppConfig{
+			Metadata: config.Metadata{Name: "myapp"},
+			Spec: config.AppSpec{
+				Image:        image,
+				Port:         8080,
+				Dependencies: []config.DependencyConfig{{Type: "postgres"}},
+			},
+		}
+		bundle, err := New(cfg).Render()
+		if err != nil {
+			t.Fatalf("failed to render bundle: %v", err)
+		}
+		d, err := bundle.Digest()
+		if err != nil {
+			t.Fatalf("failed to digest bundle: %v", err)
+		}
+		return d
+	}
+
+	// Generated dependency passwords must not change the digest
+	if digest("myapp:v1") != digest("myapp:v1") {
+		t.Error("expected identical configs to have the same digest")
+	}
+	if digest("myapp:v1") == digest("myapp:v2") {
+		t.Error("expected a different image to change the digest")
+	}
+}
+
+func TestRenderDeployment_Autoscaled(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:       "myapp:v1",
+			Replicas:    3,
+			Autoscaling: &config.AutoscalingConfig{Enabled: true, MinReplicas: 2, MaxReplicas: 6},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	if bundle.Deployment.Spec.Replicas != nil {
+		t.Errorf("expected no replicas when the HPA owns them, got %d", *bundle.Deployment.Spec.Replicas)
+	}
+	if bundle.HPA == nil || *bundle.HPA.Spec.MinReplicas != 2 {
+		t.Error("expected the HPA with minReplicas 2")
+	}
+
+	cfg.Spec.Autoscaling.Enabled = false
+	bundle, err = New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	if r := bundle.Deployment.Spec.Replicas; r == nil || *r != 3 {
+		t.Errorf("expected 3 replicas without autoscaling, got %v", r)
+	}
+}
+
+func TestRenderDeployment_Rollout(t *testing.T) {
+	history := 3
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:    "myapp:v1",
+			Replicas: 2,
+			Rollout: &config.RolloutConfig{
+				MaxSurge:                "0",
+				MaxUnavailable:          "1",
+				MinReadySeconds:         10,
+				ProgressDeadlineSeconds: 300,
+				RevisionHistoryLimit:    &history,
+			},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	spec := bundle.Deployment.Spec
+	ru := spec.Strategy.RollingUpdate
+	if ru.MaxSurge.String() != "0" || ru.MaxUnavailable.String() != "1" {
+		t.Errorf("expected maxSurge 0 and maxUnavailable 1, got %s and %s", ru.MaxSurge.String(), ru.MaxUnavailable.String())
+	}
+	if spec.MinReadySeconds != 10 {
+		t.Errorf("expected minReadySeconds 10, got %d", spec.MinReadySeconds)
+	}
+	if spec.ProgressDeadlineSeconds == nil || *spec.ProgressDeadlineSeconds != 300 {
+		t.Errorf("expected progressDeadlineSeconds 300, got %v", spec.ProgressDeadlineSeconds)
+	}
+	if spec.RevisionHistoryLimit == nil || *spec.RevisionHistoryLimit != 3 {
+		t.Errorf("expected revisionHistoryLimit 3, got %v", spec.RevisionHistoryLimit)
+	}
+
+	// Without spec.rollout the Kubernetes defaults stay
+	cfg.Spec.Rollout = nil
+	bundle, err = New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	ru = bundle.Deployment.Spec.Strategy.RollingUpdate
+	if ru.MaxSurge.String() != "25%" || ru.MaxUnavailable.String() != "25%" {
+		t.Errorf("expected 25%% defaults, got %s and %s", ru.MaxSurge.String(), ru.MaxUnavailable.String())
+	}
+	if bundle.Deployment.Spec.ProgressDeadlineSeconds != nil {
+		t.Error("expected no progressDeadlineSeconds by default")
+	}
+}
+
+func TestRender_Metadata(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{
+			Name:        "myapp",
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{"argocd.argoproj.io/sync-wave": "1"},
+		},
+		Spec: config.AppSpec{
+			Image:   "myapp:v1",
+			Ingress: &config.IngressConfig{Enabled: true, Host: "myapp.example.com"},
+			Annotations: &config.ResourceMetadata{
+				Deployment: map[string]string{"reloader.stakater.com/auto": "true"},
+				Pod:        map[string]string{"prometheus.io/scrape": "true"},
+				Ingress:    map[string]string{"alb.ingress.kubernetes.io/scheme": "internal"},
+			},
+			Labels: &config.ResourceMetadata{
+				Pod:     map[string]string{"version": "v1"},
+				Service: map[string]string{"tier": "web", "app": "ignored"},
+			},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	for _, obj := range bundle.AllObjects() {
+		accessor, _ := meta.Accessor(obj)
+		if accessor.GetLabels()["team"] != "payments" || accessor.GetAnnotations()["argocd.argoproj.io/sync-wave"] != "1" {
+			t.Errorf("%T %s: expected metadata labels and annotations", obj, accessor.GetName())
+		}
+	}
+
+	d := bundle.Deployment
+	if d.Annotations["reloader.stakater.com/auto"] != "true" {
+		t.Error("expected the deployment annotation on the Deployment")
+	}
+	if d.Spec.Template.Annotations["prometheus.io/scrape"] != "true" || d.Spec.Template.Labels["version"] != "v1" {
+		t.Errorf("expected pod annotations and labels on the template, got %v %v", d.Spec.Template.Annotations, d.Spec.Template.Labels)
+	}
+	if _, ok := d.Labels["version"]; ok {
+		t.Error("pod labels should not be on the Deployment itself")
+	}
+	if bundle.Ingresses[0].Annotations["alb.ingress.kubernetes.io/scheme"] != "internal" {
+		t.Error("expected the ingress annotation on the Ingress")
+	}
+	svc := bundle.Services[0]
+	if svc.Labels["tier"] != "web" || svc.Labels["app"] != "myapp" {
+		t.Errorf("expected tier added and app kept, got %v", svc.Labels)
+	}
+}
+
+func TestRenderPDB(t *testing.T) {
+	render := func(replicas int, pdb *config.PDBConfig) *Bundle {
+		t.Helper()
+		cfg := &config.AppConfig{
+			Metadata: config.Metadata{Name: "myapp"},
+			Spec:     config.AppSpec{Image: "myapp:v1", Replicas: replicas, PDB: pdb},
+		}
+		bundle, err := New(cfg).Render()
+		if err != nil {
+			t.Fatalf("failed to render bundle: %v", err)
+		}
+		return bundle
+	}
+
+	if b := render(3, nil); b.PDB == nil || b.PDB.Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("expected a default maxUnavailable: 1 budget, got %+v", b.PDB)
+	}
+	if b := render(3, &config.PDBConfig{MinAvailable: "50%"}); b.PDB == nil || b.PDB.Spec.MinAvailable.String() != "50%" || b.PDB.Spec.MaxUnavailable != nil {
+		t.Errorf("expected minAvailable: 50%%, got %+v", b.PDB)
+	}
+	if b := render(1, &config.PDBConfig{MinAvailable: "1"}); b.PDB != nil {
+		t.Error("expected no budget for a single replica")
+	}
+}
+
+func TestRender_Deterministic(t *testing.T) {
+	env := map[string]string{"A": "1", "B": "2", "C": "3", "D": "4", "E": "5", "F": "6"}
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{
+			Name:        "myapp",
+			Labels:      map[string]string{"team": "payments", "tier": "web", "cost": "shared"},
+			Annotations: map[string]string{"a.example.com/one": "1", "b.example.com/two": "2"},
+		},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Env:   env,
+			InitContainers: []config.InitContainerConfig{
+				{Name: "migrate", Command: []string{"./migrate"}, Env: env},
+			},
+			Jobs: []config.JobConfig{
+				{Name: "seed", Command: []string{"./seed"}, Env: env},
+				{Name: "report", Command: []string{"./report"}, Schedule: "0 * * * *", Env: env},
+			},
+			Processes: map[string]config.ProcessConfig{
+				"worker":    {Command: []string{"./work"}},
+				"scheduler": {Command: []string{"./schedule"}},
+				"mailer":    {Command: []string{"./mail"}},
+			},
+			Ingress: &config.IngressConfig{
+				Enabled:     true,
+				Host:        "myapp.example.com",
+				Annotations: map[string]string{"x.example.com/a": "1", "y.example.com/b": "2"},
+			},
+		},
+	}
+	render := func() []byte {
+		t.Helper()
+		bundle, err := New(cfg).Render()
+		if err != nil {
+			t.Fatalf("failed to render bundle: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := bundle.ToYAML(&buf); err != nil {
+			t.Fatalf("failed to write YAML: %v", err)
+		}
+		if err := bundle.ToJSON(&buf); err != nil {
+			t.Fatalf("failed to write JSON: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	// Map iteration order changes between runs, so a few renders catch it
+	first := render()
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(first, render()) {
+			t.Fatal("rendering the same config twice produced different output")
+		}
+	}
+}
+
+func TestRenderMultiService_Deterministic(t *testing.T) {
+	cfg := &config.MultiServiceConfig{
+		Metadata: config.Metadata{Name: "shop"},
+		Services: map[string]config.ServiceSpec{
+			"api":      {Image: "api:v1", Port: 8080, DependsOn: []string{"db-proxy"}},
+			"web":      {Image: "web:v1", Port: 3000, DependsOn: []string{"api"}},
+			"worker":   {Image: "worker:v1", Port: 9000},
+			"mailer":   {Image: "mailer:v1", Port: 9001},
+			"db-proxy": {Image: "proxy:v1", Port: 5432},
+		},
+	}
+	render := func() []byte {
+		t.Helper()
+		bundle, err := NewMultiService(cfg).Render()
+		if err != nil {
+			t.Fatalf("failed to render bundle: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := bundle.ToYAML(&buf); err != nil {
+			t.Fatalf("failed to write YAML: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := render()
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(first, render()) {
+			t.Fatal("rendering the same config twice produced different output")
+		}
+	}
+}
+
+func TestRenderMultiService_WaitForDependencies(t *testing.T) {
+	cfg := &config.MultiServiceConfig{
+		Metadata: config.Metadata{Name: "shop"},
+		Services: map[string]config.ServiceSpec{
+			"api":   {Image: "api:v1", Port: 8080, HealthCheck: "/health"},
+			"cache": {Image: "cache:v1", Port: 6000, Readiness: &config.ReadinessEndpoint{Path: "/ready", Port: 6001}},
+			"web":   {Image: "web:v1", Port: 3000, DependsOn: []string{"api", "cache"}, WaitForDependencies: true},
+			"admin": {Image: "admin:v1", Port: 3001, DependsOn: []string{"api"}},
+		},
+	}
+	bundle, err := NewMultiService(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	inits := map[string][]corev1.Container{}
+	for _, d := range bundle.Deployments {
+		inits[d.Name] = d.Spec.Template.Spec.InitContainers
+	}
+	if len(inits["shop-admin"]) != 0 {
+		t.Errorf("admin doesn't set waitForDependencies, got init containers %v", inits["shop-admin"])
+	}
+	waits := inits["shop-web"]
+	if len(waits) != 2 {
+		t.Fatalf("expected an init container per dependency, got %d", len(waits))
+	}
+	for i, want := range []struct{ name, url string }{
+		{"wait-for-api", "http://shop-api:8080/health"},
+		{"wait-for-cache", "http://shop-cache:6001/ready"},
+	} {
+		c := waits[i]
+		if c.Name != want.name || c.Image != WaitImage {
+			t.Errorf("init container %d = %s (%s), want %s (%s)", i, c.Name, c.Image, want.name, WaitImage)
+		}
+		if script := strings.Join(c.Command, " "); !strings.Contains(script, "wget -q -T 5 -O /dev/null "+want.url+";") {
+			t.Errorf("%s doesn't poll %s: %s", c.Name, want.url, script)
+		}
+		if sc := c.SecurityContext; sc == nil || sc.RunAsUser == nil || *sc.RunAsUser == 0 {
+			t.Errorf("%s should run as a non-root user", c.Name)
+		}
+	}
+}
+
+func TestRenderMultiService_SharedSecrets(t *testing.T) {
+	cfg := &config.MultiServiceConfig{
+		Metadata: config.Metadata{Name: "shop", Namespace: "prod"},
+		Services: map[string]config.ServiceSpec{
+			"api":    {Image: "api:v1", Port: 8080},
+			"web":    {Image: "web:v1", Port: 3000},
+			"worker": {Image: "worker:v1", Port: 9000},
+		},
+		SharedSecrets: map[string]config.SharedSecretConfig{
+			"internal-api": {Keys: []string{"INTERNAL_API_TOKEN"}, Services: []string{"api", "web"}, RotateEvery: "720h"},
+		},
+	}
+	bundle, err := NewMultiService(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	if len(bundle.Secrets) != 1 {
+		t.Fatalf("expected the shared secret, got %d secrets", len(bundle.Secrets))
+	}
+	secret := bundle.Secrets[0]
+	if secret.Name != "shop-internal-api" || secret.Namespace != "prod" || secret.Labels[LabelSharedSecret] != "internal-api" {
+		t.Errorf("unexpected secret %s/%s labels %v", secret.Namespace, secret.Name, secret.Labels)
+	}
+	if token := secret.StringData["INTERNAL_API_TOKEN"]; len(token) < 32 {
+		t.Errorf("expected a generated token, got %q", token)
+	}
+	if secret.Annotations[AnnotationRotateEvery] != "720h" {
+		t.Errorf("expected rotateEvery annotated, got %v", secret.Annotations)
+	}
+
+	for _, d := range bundle.Deployments {
+		var ref *corev1.SecretKeySelector
+		for _, env := range d.Spec.Template.Spec.Containers[0].Env {
+			if env.Name == "INTERNAL_API_TOKEN" && env.ValueFrom != nil {
+				ref = env.ValueFrom.SecretKeyRef
+			}
+		}
+		wantRef := d.Name != "shop-worker"
+		if (ref != nil) != wantRef {
+			t.Errorf("%s: INTERNAL_API_TOKEN injected = %v, want %v", d.Name, ref != nil, wantRef)
+		}
+		if ref != nil && (ref.Name != "shop-internal-api" || ref.Key != "INTERNAL_API_TOKEN") {
+			t.Errorf("%s: unexpected secret reference %+v", d.Name, ref)
+		}
+		// The token is generated per render; hashing it would roll the pods every deploy
+		if _, ok := d.Spec.Template.Annotations[AnnotationSecretChecksum]; ok {
+			t.Errorf("%s: shared secrets shouldn't be checksummed", d.Name)
+		}
+	}
+}
+
+func TestBundle_OnlyServices(t *testing.T) {
+	cfg := &config.MultiServiceConfig{
+		Metadata: config.Metadata{Name: "shop"},
+		Services: map[string]config.ServiceSpec{
+			"api": {Image: "api:v1", Port: 8080, Env: map[string]string{"MODE": "api"}},
+			"web": {Image: "web:v1", Port: 3000, Env: map[string]string{"MODE": "web"}},
+		},
+		SharedSecrets: map[string]config.SharedSecretConfig{
+			"session": {Keys: []string{"SESSION_KEY"}, Services: []string{"api", "web"}},
+		},
+	}
+	bundle, err := NewMultiService(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	bundle.OnlyServices("shop", []string{"web"})
+
+	if len(bundle.Deployments) != 1 || bundle.Deployments[0].Name != "shop-web" || bundle.Deployment != bundle.Deployments[0] {
+		t.Errorf("expected only the web Deployment, got %d", len(bundle.Deployments))
+	}
+	if len(bundle.Services) != 1 || bundle.Services[0].Name != "shop-web" {
+		t.Errorf("expected only the web Service, got %d", len(bundle.Services))
+	}
+	for _, cm := range bundle.ConfigMaps {
+		if cm.Name != "shop-web-config" {
+			t.Errorf("unexpected ConfigMap %s", cm.Name)
+		}
+	}
+	if len(bundle.Secrets) != 1 {
+		t.Errorf("expected the shared secret kept, got %d secrets", len(bundle.Secrets))
+	}
+}
+
+func TestRender_TrafficSplit(t *testing.T) {
+	replicas := 2
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			Ingress: &config.IngressConfig{
+				Enabled: true,
+				Host:    "myapp.example.com",
+				DNS:     true,
+				TLS:     &config.TLSConfig{Enabled: true},
+			},
+			Traffic: &config.TrafficConfig{Split: &config.TrafficSplit{CanaryImage: "myapp:v2", Weight: 20, Replicas: &replicas}},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	canary := bundle.Canary()
+	if canary == nil || canary.Name != "myapp-canary" {
+		t.Fatalf("expected a myapp-canary Deployment, got %v", canary)
+	}
+	if bundle.Deployment.Name != "myapp" {
+		t.Errorf("expected the app to stay the primary Deployment, got %s", bundle.Deployment.Name)
+	}
+	if canary.Spec.Template.Spec.Containers[0].Image != "myapp:v2" || *canary.Spec.Replicas != 2 {
+		t.Errorf("expected 2 replicas of myapp:v2, got %d of %s", *canary.Spec.Replicas, canary.Spec.Template.Spec.Containers[0].Image)
+	}
+	if canary.Spec.Selector.MatchLabels["app"] != "myapp-canary" || canary.Spec.Template.Labels[LabelTrack] != TrackCanary {
+		t.Errorf("expected canary pods selected apart from the app's, got %v", canary.Spec.Template.Labels)
+	}
+	if bundle.Services[0].Spec.Selector["app"] != "myapp" {
+		t.Error("the app's Service must not select canary pods")
+	}
+
+	if len(bundle.Ingresses) != 2 {
+		t.Fatalf("expected the app and canary Ingresses, got %d", len(bundle.Ingresses))
+	}
+	ing := bundle.Ingresses[1]
+	if ing.Annotations[AnnotationNginxCanary] != "true" || ing.Annotations[AnnotationNginxCanaryWeight] != "20" {
+		t.Errorf("expected canary annotations, got %v", ing.Annotations)
+	}
+	if _, ok := ing.Annotations["external-dns.alpha.kubernetes.io/hostname"]; ok {
+		t.Error("the DNS record belongs to the app's Ingress")
+	}
+	if backend := ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service; backend.Name != "myapp-canary" || backend.Port.Number != 8080 {
+		t.Errorf("expected the canary Service as backend, got %s:%d", backend.Name, backend.Port.Number)
+	}
+	if ing.Spec.Rules[0].Host != "myapp.example.com" {
+		t.Error("expected the canary Ingress on the app's host")
+	}
+
+	if got := bundle.Workloads(); !slices.Equal(got, []string{"myapp", "myapp-canary"}) {
+		t.Errorf("expected deploy to wait on both Deployments, got %v", got)
+	}
+	found := false
+	for _, policy := range bundle.NetworkPolicies {
+		if policy.Spec.PodSelector.MatchLabels["app"] == "myapp-canary" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a NetworkPolicy for the canary pods")
+	}
+}
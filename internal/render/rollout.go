@@ -0,0 +1,32 @@
+package render
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// applyRollout sets spec.rollout on the Deployment; unset fields keep the
+// Kubernetes defaults (25% surge, 25% unavailable)
+func (r *Renderer) applyRollout(spec *appsv1.DeploymentSpec) {
+	cfg := r.config.Spec.Rollout
+	if cfg == nil {
+		return
+	}
+	if cfg.MaxSurge != "" {
+		maxSurge := intstr.Parse(cfg.MaxSurge)
+		spec.Strategy.RollingUpdate.MaxSurge = &maxSurge
+	}
+	if cfg.MaxUnavailable != "" {
+		maxUnavail := intstr.Parse(cfg.MaxUnavailable)
+		spec.Strategy.RollingUpdate.MaxUnavailable = &maxUnavail
+	}
+	spec.MinReadySeconds = int32(cfg.MinReadySeconds)
+	if cfg.ProgressDeadlineSeconds > 0 {
+		deadline := int32(cfg.ProgressDeadlineSeconds)
+		spec.ProgressDeadlineSeconds = &deadline
+	}
+	if cfg.RevisionHistoryLimit != nil {
+		limit := int32(*cfg.RevisionHistoryLimit)
+		spec.RevisionHistoryLimit = &limit
+	}
+}
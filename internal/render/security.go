@@ -0,0 +1,73 @@
+package render
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// podSecurityContext returns the app's pod security context: kbox's hardened
+// defaults for the restricted profile, the image's own user for baseline,
+// with spec.securityContext overrides on top
+func (r *Renderer) podSecurityContext() *corev1.PodSecurityContext {
+	psc := defaultPodSecurityContext()
+	if r.config.Spec.EffectiveSecurityProfile() == config.SecurityProfileBaseline {
+		psc = &corev1.PodSecurityContext{SeccompProfile: psc.SeccompProfile}
+	}
+
+	sc := r.config.Spec.SecurityContext
+	if sc == nil {
+		return psc
+	}
+	if sc.RunAsUser != nil {
+		psc.RunAsUser = sc.RunAsUser
+	}
+	if sc.RunAsGroup != nil {
+		psc.RunAsGroup = sc.RunAsGroup
+	}
+	if sc.FSGroup != nil {
+		psc.FSGroup = sc.FSGroup
+	}
+	if sc.RunAsNonRoot != nil {
+		psc.RunAsNonRoot = sc.RunAsNonRoot
+	}
+	if sc.SeccompProfile != "" {
+		profile := &corev1.SeccompProfile{Type: corev1.SeccompProfileType(sc.SeccompProfile)}
+		if file, ok := strings.CutPrefix(sc.SeccompProfile, "Localhost/"); ok {
+			profile.Type = corev1.SeccompProfileTypeLocalhost
+			profile.LocalhostProfile = &file
+		}
+		psc.SeccompProfile = profile
+	}
+	return psc
+}
+
+// containerSecurityContext returns the security context for the app's
+// containers. The baseline profile keeps the runtime's default capabilities
+// and a writable root filesystem, for images that expect them.
+func (r *Renderer) containerSecurityContext() *corev1.SecurityContext {
+	csc := defaultContainerSecurityContext()
+	if r.config.Spec.EffectiveSecurityProfile() == config.SecurityProfileBaseline {
+		readOnly := false
+		csc = &corev1.SecurityContext{ReadOnlyRootFilesystem: &readOnly}
+	}
+
+	sc := r.config.Spec.SecurityContext
+	if sc == nil {
+		return csc
+	}
+	if sc.ReadOnlyRootFilesystem != nil {
+		csc.ReadOnlyRootFilesystem = sc.ReadOnlyRootFilesystem
+	}
+	if len(sc.Capabilities) > 0 {
+		if csc.Capabilities == nil {
+			csc.Capabilities = &corev1.Capabilities{}
+		}
+		for _, c := range sc.Capabilities {
+			csc.Capabilities.Add = append(csc.Capabilities.Add, corev1.Capability(c))
+		}
+	}
+	return csc
+}
@@ -1,6 +1,11 @@
 package render
 
 import (
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -21,6 +26,8 @@ func (r *Renderer) RenderService() (*corev1.Service, error) {
 			serviceType = corev1.ServiceTypeNodePort
 		case "LoadBalancer":
 			serviceType = corev1.ServiceTypeLoadBalancer
+		case "ExternalName":
+			serviceType = corev1.ServiceTypeExternalName
 		}
 	}
 
@@ -60,5 +67,135 @@ func (r *Renderer) RenderService() (*corev1.Service, error) {
 		},
 	}
 
+	if svc := cfg.Spec.Service; svc != nil {
+		for _, p := range svc.Ports {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+				Name:       p.PortName(),
+				Port:       int32(p.Port),
+				TargetPort: intstr.FromInt32(int32(p.ContainerPort())),
+				Protocol:   corev1.Protocol(p.PortProtocol()),
+			})
+		}
+		applyServiceOptions(service, svc)
+		if serviceType == corev1.ServiceTypeLoadBalancer {
+			r.addHealthCheckAnnotations(service, svc)
+		}
+	}
+
 	return service, nil
 }
+
+// applyServiceOptions sets the options that only some Service types use;
+// validation has checked they fit the type
+func applyServiceOptions(service *corev1.Service, svc *config.ServiceConfig) {
+	if svc.DNS != nil {
+		service.Annotations = map[string]string{AnnotationExternalDNSHostname: svc.DNS.Hostname}
+		if svc.DNS.TTL > 0 {
+			service.Annotations[AnnotationExternalDNSTTL] = strconv.Itoa(svc.DNS.TTL)
+		}
+	}
+	// User annotations take precedence
+	if len(svc.Annotations) > 0 {
+		if service.Annotations == nil {
+			service.Annotations = make(map[string]string)
+		}
+		maps.Copy(service.Annotations, svc.Annotations)
+	}
+	if svc.ClusterIP != "" {
+		service.Spec.ClusterIP = svc.ClusterIP
+	}
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		// An alias for another host: there are no pods behind it
+		service.Spec.ExternalName = svc.ExternalName
+		service.Spec.Selector = nil
+	}
+	if svc.SessionAffinity != "" {
+		service.Spec.SessionAffinity = corev1.ServiceAffinity(svc.SessionAffinity)
+	}
+	if svc.SessionAffinityTimeout != 0 {
+		timeout := svc.SessionAffinityTimeout
+		service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+			ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: &timeout},
+		}
+	}
+	if svc.ExternalTrafficPolicy != "" {
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicy(svc.ExternalTrafficPolicy)
+	}
+}
+
+// Cloud load balancer health check annotations. AWS checks each target on
+// the port traffic reaches it on (awsTrafficPort), whether the targets are
+// nodes or pods; a fixed port is the pod's, so it assumes IP targets
+// (aws-load-balancer-nlb-target-type: ip).
+const (
+	annotationAWSHealthCheckProtocol = "service.beta.kubernetes.io/aws-load-balancer-healthcheck-protocol"
+	annotationAWSHealthCheckPort     = "service.beta.kubernetes.io/aws-load-balancer-healthcheck-port"
+	annotationAWSHealthCheckPath     = "service.beta.kubernetes.io/aws-load-balancer-healthcheck-path"
+	awsTrafficPort                   = "traffic-port"
+	// Azure probes each port separately: port_<port>_health-probe_<setting>
+	annotationAzureHealthProbe = "service.beta.kubernetes.io/port_%d_health-probe_%s"
+)
+
+// addHealthCheckAnnotations points a LoadBalancer's health checks at a TCP
+// port: load balancers can't health check UDP, so without this its UDP
+// ports never become healthy. It does nothing for all-TCP Services without
+// a healthCheck, which the load balancer checks on their own ports.
+func (r *Renderer) addHealthCheckAnnotations(service *corev1.Service, svc *config.ServiceConfig) {
+	var udpPorts []int32
+	for _, p := range service.Spec.Ports {
+		if p.Protocol == corev1.ProtocolUDP {
+			udpPorts = append(udpPorts, p.Port)
+		}
+	}
+	hc := svc.HealthCheck
+	if hc == nil && len(udpPorts) == 0 {
+		return
+	}
+	if hc == nil {
+		hc = &config.ServiceHealthCheckConfig{Path: r.config.Spec.HealthCheck}
+	}
+
+	// The probed Service port, and the container port behind it
+	port := service.Spec.Ports[0]
+	for _, p := range service.Spec.Ports {
+		if hc.Port != 0 && p.Port == int32(hc.Port) && p.Protocol == corev1.ProtocolTCP {
+			port = p
+		}
+	}
+	protocol, azureProtocol := "TCP", "Tcp"
+	if hc.Path != "" {
+		protocol, azureProtocol = "HTTP", "Http"
+	}
+
+	// Each target is checked on its own traffic port, unless a port was
+	// chosen or UDP ports need checking on a TCP one
+	awsPort := awsTrafficPort
+	if hc.Port != 0 || len(udpPorts) > 0 {
+		awsPort = port.TargetPort.String()
+	}
+	annotations := map[string]string{
+		annotationAWSHealthCheckProtocol: protocol,
+		annotationAWSHealthCheckPort:     awsPort,
+	}
+	if hc.Path != "" {
+		annotations[annotationAWSHealthCheckPath] = hc.Path
+	}
+	probed := udpPorts
+	if svc.HealthCheck != nil {
+		probed = nil
+		for _, p := range service.Spec.Ports {
+			probed = append(probed, p.Port)
+		}
+	}
+	for _, p := range probed {
+		annotations[fmt.Sprintf(annotationAzureHealthProbe, p, "protocol")] = azureProtocol
+		annotations[fmt.Sprintf(annotationAzureHealthProbe, p, "port")] = strconv.Itoa(int(port.Port))
+		if hc.Path != "" {
+			annotations[fmt.Sprintf(annotationAzureHealthProbe, p, "request-path")] = hc.Path
+		}
+	}
+
+	// The Service's own annotations take precedence
+	maps.Copy(annotations, service.Annotations)
+	service.Annotations = annotations
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+)
+
+// Shared secrets are rendered with freshly generated values, which the apply
+// engine swaps for the live ones while the Secret exists and isn't due for
+// rotation (see apply.Engine.SetRotateSecrets).
+const (
+	// LabelSharedSecret names the sharedSecrets entry a Secret renders
+	LabelSharedSecret = "kbox.dev/shared-secret"
+
+	// AnnotationRotateEvery is the Secret's rotateEvery
+	AnnotationRotateEvery = "kbox.dev/rotate-every"
+
+	// AnnotationRotatedAt is when the Secret's values were generated
+	AnnotationRotatedAt = "kbox.dev/rotated-at"
+
+	// AnnotationSharedSecrets on a pod template lists the shared secrets it
+	// uses with when they were generated, so a rotation rolls its pods
+	AnnotationSharedSecrets = "kbox.dev/shared-secrets"
+)
+
+// generatedSecret reports whether s holds values kbox generates anew on
+// every render, dependency passwords and shared secrets, which would
+// otherwise change hashes and roll pods on every deploy
+func generatedSecret(s *corev1.Secret) bool {
+	return s.Labels["kbox.dev/dependency"] != "" || s.Labels[LabelSharedSecret] != ""
+}
+
+// SharedSecretName returns the name of the Secret of a sharedSecrets entry
+func SharedSecretName(appName, name string) string {
+	return fmt.Sprintf("%s-%s", appName, name)
+}
+
+// renderSharedSecrets renders the sharedSecrets Secrets, in name order
+func (r *MultiServiceRenderer) renderSharedSecrets() []*corev1.Secret {
+	var secrets []*corev1.Secret
+	for _, name := range slices.Sorted(maps.Keys(r.config.SharedSecrets)) {
+		shared := r.config.SharedSecrets[name]
+		secret := &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SharedSecretName(r.config.Metadata.Name, name),
+				Namespace: r.Namespace(),
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "kbox",
+					"kbox.dev/app":                 r.config.Metadata.Name,
+					LabelSharedSecret:              name,
+				},
+			},
+			StringData: make(map[string]string),
+		}
+		if shared.RotateEvery != "" {
+			secret.Annotations = map[string]string{AnnotationRotateEvery: shared.RotateEvery}
+		}
+		for _, key := range shared.Keys {
+			secret.StringData[key] = dependencies.GeneratePassword() + dependencies.GeneratePassword()
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// addSharedSecretEnv injects the keys of the shared secrets listing
+// currentService into its containers, by reference
+func (r *MultiServiceRenderer) addSharedSecretEnv(template *corev1.PodTemplateSpec, currentService string) {
+	for _, name := range slices.Sorted(maps.Keys(r.config.SharedSecrets)) {
+		shared := r.config.SharedSecrets[name]
+		if !slices.Contains(shared.Services, currentService) {
+			continue
+		}
+		for i := range template.Spec.Containers {
+			container := &template.Spec.Containers[i]
+			for _, key := range shared.Keys {
+				container.Env = append(container.Env, corev1.EnvVar{
+					Name: key,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: SharedSecretName(r.config.Metadata.Name, name)},
+							Key:                  key,
+						},
+					},
+				})
+			}
+		}
+	}
+}
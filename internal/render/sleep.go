@@ -0,0 +1,151 @@
+package render
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationSleepReplicas records the replicas of a workload scaled to zero
+// by kbox sleep (or the sleep schedule), for kbox wake to restore
+const AnnotationSleepReplicas = "kbox.dev/sleep-replicas"
+
+// DefaultSleepImage runs kubectl (and sh) for the sleep schedule's
+// CronJobs. It's pinned so a deploy never changes what the CronJobs run;
+// spec.sleepSchedule.image overrides it, e.g. to match the cluster version.
+const DefaultSleepImage = "alpine/k8s:1.33.4"
+
+// SleepName names the sleep schedule's ServiceAccount, Role, and RoleBinding
+func SleepName(app string) string {
+	return app + "-sleep"
+}
+
+// sleepScript and wakeScript do in-cluster what kbox sleep and kbox wake do
+// (see the sleep package), selecting workloads by $SELECTOR
+const (
+	sleepScript = `set -eu
+for r in $(kubectl get deployments,statefulsets -l "$SELECTOR" -o name); do
+  n=$(kubectl get "$r" -o jsonpath='{.spec.replicas}')
+  if [ "$n" != "0" ]; then
+    kubectl patch "$r" --type merge -p "{\"metadata\":{\"annotations\":{\"` + AnnotationSleepReplicas + `\":\"$n\"}},\"spec\":{\"replicas\":0}}"
+  fi
+done`
+	wakeScript = `set -eu
+for r in $(kubectl get deployments,statefulsets -l "$SELECTOR" -o name); do
+  n=$(kubectl get "$r" -o jsonpath='{.metadata.annotations.kbox\.dev/sleep-replicas}')
+  if [ -n "$n" ]; then
+    kubectl patch "$r" --type merge -p "{\"metadata\":{\"annotations\":{\"` + AnnotationSleepReplicas + `\":null}},\"spec\":{\"replicas\":$n}}"
+  fi
+done`
+)
+
+// RenderSleepSchedule renders the CronJobs that scale the app to zero and
+// back on spec.sleepSchedule, and a ServiceAccount allowed to do only that
+func (r *Renderer) RenderSleepSchedule() (*corev1.ServiceAccount, *rbacv1.Role, *rbacv1.RoleBinding, []*batchv1.CronJob) {
+	schedule := r.config.Spec.SleepSchedule
+	app := r.config.Metadata.Name
+	name := SleepName(app)
+	meta := metav1.ObjectMeta{Name: name, Namespace: r.Namespace(), Labels: r.Labels()}
+
+	sa := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: meta,
+	}
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: meta,
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "statefulsets"},
+			Verbs:     []string{"get", "list", "patch"},
+		}},
+	}
+	binding := &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: meta,
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: r.Namespace(),
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+	}
+
+	cronJobs := []*batchv1.CronJob{
+		r.renderSleepCronJob("sleep", schedule.Sleep, sleepScript),
+		r.renderSleepCronJob("wake", schedule.Wake, wakeScript),
+	}
+	return sa, role, binding, cronJobs
+}
+
+// renderSleepCronJob renders the CronJob that runs script on schedule
+func (r *Renderer) renderSleepCronJob(action, schedule, script string) *batchv1.CronJob {
+	app := r.config.Metadata.Name
+	image := r.config.Spec.SleepSchedule.Image
+	if image == "" {
+		image = DefaultSleepImage
+	}
+
+	// Not app=<app>: the app's NetworkPolicy would block the API server
+	labels := r.jobLabels(action)
+	labels["app"] = fmt.Sprintf("%s-%s", app, action)
+
+	var timeZone *string
+	if tz := r.config.Spec.SleepSchedule.TimeZone; tz != "" {
+		timeZone = &tz
+	}
+	var backoffLimit int32 = 2
+	var history int32 = 1
+
+	return &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", app, action),
+			Namespace: r.Namespace(),
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			TimeZone:                   timeZone,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &history,
+			FailedJobsHistoryLimit:     &history,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							ServiceAccountName: SleepName(app),
+							SecurityContext:    defaultPodSecurityContext(),
+							RestartPolicy:      corev1.RestartPolicyNever,
+							Containers: []corev1.Container{{
+								Name:            action,
+								Image:           image,
+								Command:         []string{"/bin/sh", "-c", script},
+								Env:             []corev1.EnvVar{{Name: "SELECTOR", Value: OwnershipSelector(app)}, {Name: "HOME", Value: "/tmp"}},
+								SecurityContext: defaultContainerSecurityContext(),
+								VolumeMounts:    []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}},
+							}},
+							Volumes: []corev1.Volume{{
+								Name:         "tmp",
+								VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestRenderSleepSchedule(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "dev"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Port:  8080,
+			SleepSchedule: &config.SleepScheduleConfig{
+				Sleep:    "0 20 * * 1-5",
+				Wake:     "0 7 * * 1-5",
+				TimeZone: "Europe/Berlin",
+			},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+
+	if len(bundle.CronJobs) != 2 || bundle.CronJobs[0].Name != "myapp-sleep" || bundle.CronJobs[1].Name != "myapp-wake" {
+		t.Fatalf("expected sleep and wake CronJobs, got %d", len(bundle.CronJobs))
+	}
+	sleep := bundle.CronJobs[0]
+	if sleep.Spec.Schedule != "0 20 * * 1-5" || *sleep.Spec.TimeZone != "Europe/Berlin" {
+		t.Errorf("unexpected schedule %q", sleep.Spec.Schedule)
+	}
+	pod := sleep.Spec.JobTemplate.Spec.Template
+	if pod.Spec.ServiceAccountName != "myapp-sleep" {
+		t.Errorf("expected the sleep ServiceAccount, got %q", pod.Spec.ServiceAccountName)
+	}
+	if pod.Labels["app"] == "myapp" {
+		t.Errorf("sleep pods must not be selected by the app's NetworkPolicy")
+	}
+	if len(bundle.Roles) != 1 || len(bundle.RoleBindings) != 1 || len(bundle.ServiceAccounts) != 1 {
+		t.Errorf("expected a ServiceAccount, Role and RoleBinding for the schedule")
+	}
+	if bundle.Roles[0].Labels[LabelApp] != "myapp" {
+		t.Errorf("expected the Role to carry the ownership labels, got %v", bundle.Roles[0].Labels)
+	}
+	if v := bundle.PodSecurityViolations(PodSecurityRestricted); len(v) > 0 {
+		t.Errorf("expected sleep pods to meet the restricted standard, got %v", v)
+	}
+}
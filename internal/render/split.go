@@ -0,0 +1,116 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// splitHeader starts every file WriteFiles writes, so the next run knows
+// which files in the directory are its own to replace
+const splitHeader = "# Generated by kbox render --split-files\n"
+
+// Select returns the bundle's objects of the given kinds (case-insensitive)
+// and names, in apply order. No kinds, or no names, matches any.
+func (b *Bundle) Select(kinds, names []string) []runtime.Object {
+	var selected []runtime.Object
+	for _, obj := range b.AllObjects() {
+		kind, name := objectKindName(obj)
+		if len(kinds) > 0 && !slices.ContainsFunc(kinds, func(k string) bool { return strings.EqualFold(k, kind) }) {
+			continue
+		}
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		selected = append(selected, obj)
+	}
+	return selected
+}
+
+// WriteFiles writes each object to its own file in dir, named
+// <position>-<kind>-<name>.yaml so listing the directory keeps the apply
+// order, plus a kustomization.yaml listing them. Files a previous run wrote
+// are removed first, so objects gone from the bundle don't linger. It
+// returns the files written.
+func (b *Bundle) WriteFiles(dir string, objects []runtime.Object) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := removeSplitFiles(dir); err != nil {
+		return nil, err
+	}
+
+	width := len(fmt.Sprint(len(objects)))
+	var files []string
+	for i, obj := range objects {
+		kind, name := objectKindName(obj)
+		file := fmt.Sprintf("%0*d-%s-%s.yaml", width, i+1, strings.ToLower(kind), name)
+
+		var buf bytes.Buffer
+		buf.WriteString(splitHeader)
+		if err := b.writeObject(&buf, obj); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), buf.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	var kustomization strings.Builder
+	kustomization.WriteString(splitHeader)
+	kustomization.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n")
+	for _, file := range files {
+		fmt.Fprintf(&kustomization, "  - %s\n", file)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(kustomization.String()), 0644); err != nil {
+		return nil, err
+	}
+	return append(files, "kustomization.yaml"), nil
+}
+
+// removeSplitFiles removes the YAML files in dir that WriteFiles wrote
+func removeSplitFiles(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		first, _ := bufio.NewReader(f).ReadString('\n')
+		f.Close()
+		if first != splitHeader {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectRef returns an object's Kind/name
+func ObjectRef(obj runtime.Object) string {
+	kind, name := objectKindName(obj)
+	return kind + "/" + name
+}
+
+// objectKindName returns an object's kind and name
+func objectKindName(obj runtime.Object) (string, string) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return kind, ""
+	}
+	return kind, accessor.GetName()
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestBundleSelectAndWriteFiles(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp", Namespace: "shop"},
+		Spec: config.AppSpec{
+			Image: "myapp:v1",
+			Env:   map[string]string{"LOG_LEVEL": "info"},
+		},
+	}
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	var refs []string
+	for _, obj := range bundle.Select([]string{"deployment", "Service"}, nil) {
+		refs = append(refs, ObjectRef(obj))
+	}
+	if !slices.Equal(refs, []string{"Service/myapp", "Deployment/myapp"}) {
+		t.Errorf("Select by kind = %v", refs)
+	}
+	if got := bundle.Select(nil, []string{"myapp-config"}); len(got) != 1 || ObjectRef(got[0]) != "ConfigMap/myapp-config" {
+		t.Errorf("Select by name returned %d objects", len(got))
+	}
+	if got := bundle.Select([]string{"Ingress"}, nil); len(got) != 0 {
+		t.Errorf("expected no Ingress, got %d objects", len(got))
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.yaml"), []byte("mine: true\n"), 0644)
+	if _, err := bundle.WriteFiles(dir, bundle.AllObjects()); err != nil {
+		t.Fatalf("WriteFiles: %v", err)
+	}
+	files, err := bundle.WriteFiles(dir, bundle.Select([]string{"Deployment"}, nil))
+	if err != nil {
+		t.Fatalf("WriteFiles: %v", err)
+	}
+	if !slices.Equal(files, []string{"1-deployment-myapp.yaml", "kustomization.yaml"}) {
+		t.Errorf("files = %v", files)
+	}
+
+	// The first run's files are replaced, others are left alone
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !slices.Equal(names, []string{"1-deployment-myapp.yaml", "kustomization.yaml", "notes.yaml"}) {
+		t.Errorf("directory holds %v", names)
+	}
+	kustomization, _ := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	if !strings.Contains(string(kustomization), "resources:\n  - 1-deployment-myapp.yaml\n") {
+		t.Errorf("kustomization.yaml:\n%s", kustomization)
+	}
+	deployment, _ := os.ReadFile(filepath.Join(dir, "1-deployment-myapp.yaml"))
+	if !strings.Contains(string(deployment), "kind: Deployment") {
+		t.Errorf("deployment file:\n%s", deployment)
+	}
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HeadlessServiceName is the Service that gives each replica of a StatefulSet
+// app a stable DNS name (<app>-0.<app>-headless)
+func HeadlessServiceName(app string) string {
+	return app + "-headless"
+}
+
+// RenderStatefulSet runs the pods of deployment as a StatefulSet instead.
+// Volumes with a size become volumeClaimTemplates, so each replica gets its
+// own PersistentVolumeClaim rather than sharing one.
+func (r *Renderer) RenderStatefulSet(deployment *appsv1.Deployment) (*appsv1.StatefulSet, error) {
+	name := r.config.Metadata.Name
+	template := *deployment.Spec.Template.DeepCopy()
+
+	var claims []corev1.PersistentVolumeClaim
+	claimed := make(map[string]bool)
+	for _, vol := range r.config.Spec.Volumes {
+		if vol.Size == "" {
+			continue
+		}
+		pvc, err := r.renderPVC(vol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render volume claim for %s: %w", vol.Name, err)
+		}
+		// Claim templates are named after the volume the containers mount
+		claims = append(claims, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: vol.Name, Labels: r.Labels()},
+			Spec:       pvc.Spec,
+		})
+		claimed[vol.Name] = true
+	}
+	var volumes []corev1.Volume
+	for _, v := range template.Spec.Volumes {
+		if !claimed[v.Name] {
+			volumes = append(volumes, v)
+		}
+	}
+	template.Spec.Volumes = volumes
+
+	return &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: deployment.ObjectMeta,
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName:          HeadlessServiceName(name),
+			Replicas:             deployment.Spec.Replicas,
+			MinReadySeconds:      deployment.Spec.MinReadySeconds,
+			RevisionHistoryLimit: deployment.Spec.RevisionHistoryLimit,
+			Selector:             deployment.Spec.Selector,
+			Template:             template,
+			VolumeClaimTemplates: claims,
+			// Replicas start, update, and stop one at a time, in order
+			PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
+		},
+	}, nil
+}
+
+// RenderHeadlessService creates the headless Service a StatefulSet app needs
+// for per-replica DNS. Not-ready replicas are published so peers can find
+// each other while starting up.
+func (r *Renderer) RenderHeadlessService() *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      HeadlessServiceName(r.config.Metadata.Name),
+			Namespace: r.Namespace(),
+			Labels:    r.Labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                "None",
+			Selector:                 r.Selector(),
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{
+					Name:     "http",
+					Port:     int32(r.config.Spec.Port),
+					Protocol: corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
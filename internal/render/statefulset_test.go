@@ -0,0 +1,88 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestRenderStatefulSetWorkload(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "queue"},
+		Spec: config.AppSpec{
+			Image:    "queue:v1",
+			Port:     5672,
+			Replicas: 3,
+			Workload: config.WorkloadStatefulSet,
+			Env:      map[string]string{"CLUSTER": "on"},
+			Volumes: []config.VolumeConfig{
+				{Name: "data", MountPath: "/var/lib/queue", Size: "5Gi"},
+				{Name: "tmp", MountPath: "/tmp", EmptyDir: true},
+			},
+			Autoscaling: &config.AutoscalingConfig{Enabled: true, MaxReplicas: 5},
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	if bundle.Deployment != nil || len(bundle.Deployments) != 0 {
+		t.Error("expected no Deployment for a StatefulSet workload")
+	}
+	ss := bundle.AppStatefulSet
+	if ss == nil || len(bundle.StatefulSets) != 1 || bundle.StatefulSets[0] != ss {
+		t.Fatalf("expected the app StatefulSet in the bundle, got %+v", bundle.StatefulSets)
+	}
+	if bundle.WorkloadName() != "queue" {
+		t.Errorf("expected workload name queue, got %q", bundle.WorkloadName())
+	}
+	if ss.Spec.ServiceName != "queue-headless" {
+		t.Errorf("unexpected StatefulSet serviceName %s", ss.Spec.ServiceName)
+	}
+	// The HPA owns the replica count
+	if ss.Spec.Replicas != nil {
+		t.Errorf("expected no replicas on an autoscaled StatefulSet, got %d", *ss.Spec.Replicas)
+	}
+
+	// Sized volumes become per-replica claims instead of a shared PVC
+	if len(bundle.PersistentVolumeClaims) != 0 {
+		t.Errorf("expected no standalone PVCs, got %d", len(bundle.PersistentVolumeClaims))
+	}
+	if len(ss.Spec.VolumeClaimTemplates) != 1 || ss.Spec.VolumeClaimTemplates[0].Name != "data" {
+		t.Fatalf("expected a data volumeClaimTemplate, got %+v", ss.Spec.VolumeClaimTemplates)
+	}
+	for _, v := range ss.Spec.Template.Spec.Volumes {
+		if v.Name == "data" {
+			t.Error("claimed volume should not also be a pod volume")
+		}
+	}
+	if len(ss.Spec.Template.Spec.Volumes) != 1 || ss.Spec.Template.Spec.Volumes[0].Name != "tmp" {
+		t.Errorf("expected the emptyDir volume to remain, got %+v", ss.Spec.Template.Spec.Volumes)
+	}
+
+	var headless bool
+	for _, svc := range bundle.Services {
+		if svc.Name == "queue-headless" {
+			headless = svc.Spec.ClusterIP == "None"
+		}
+	}
+	if !headless {
+		t.Error("expected a headless Service")
+	}
+
+	if bundle.HPA == nil || bundle.HPA.Spec.ScaleTargetRef.Kind != "StatefulSet" {
+		t.Errorf("expected the HPA to scale the StatefulSet, got %+v", bundle.HPA.Spec.ScaleTargetRef)
+	}
+	if ss.Spec.Template.Annotations[AnnotationConfigChecksum] == "" {
+		t.Error("expected a config checksum on the StatefulSet pod template")
+	}
+
+	stamped := bundle.WithChange(ChangeInfo{Revision: 4})
+	if stamped.AppStatefulSet.Annotations[AnnotationRevision] != "4" || stamped.StatefulSets[0] != stamped.AppStatefulSet {
+		t.Error("expected WithChange to stamp the app StatefulSet")
+	}
+	if ss.Annotations[AnnotationRevision] != "" {
+		t.Error("WithChange should not modify the original bundle")
+	}
+}
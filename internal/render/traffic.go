@@ -0,0 +1,120 @@
+package render
+
+import (
+	"maps"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+// NGINX Ingress canary annotations: an Ingress with canary: "true" for the
+// same host and path as another receives canary-weight percent of its
+// requests
+const (
+	AnnotationNginxCanary       = "nginx.ingress.kubernetes.io/canary"
+	AnnotationNginxCanaryWeight = "nginx.ingress.kubernetes.io/canary-weight"
+)
+
+// LabelTrack marks the canary Deployment of a traffic split, and its pods,
+// as TrackCanary
+const (
+	LabelTrack  = "kbox.dev/track"
+	TrackCanary = "canary"
+)
+
+// CanaryName returns the name of the canary Deployment, Service and Ingress
+// of a traffic split, e.g. myapp-canary
+func CanaryName(app string) string {
+	return app + "-" + TrackCanary
+}
+
+// renderCanary renders the canary Deployment of a traffic split: a copy of
+// the app's running the canary image. Its pods are labeled app=<app>-canary,
+// so the app's Service and Deployment never select them; only the canary
+// Ingress routes to them.
+func (r *Renderer) renderCanary(app *appsv1.Deployment, split *config.TrafficSplit) *appsv1.Deployment {
+	name := CanaryName(r.config.Metadata.Name)
+	replicas := int32(1)
+	if split.Replicas != nil {
+		replicas = int32(*split.Replicas)
+	}
+
+	labels := r.Labels()
+	labels["app"] = name
+	labels[LabelTrack] = TrackCanary
+
+	dep := app.DeepCopy()
+	dep.Name = name
+	dep.Labels = labels
+	dep.Spec.Replicas = &replicas
+	dep.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}}
+	dep.Spec.Template.Labels = maps.Clone(labels)
+	dep.Spec.Template.Spec.Containers[0].Image = split.CanaryImage
+	return dep
+}
+
+// renderCanaryService renders the ClusterIP Service the canary Ingress
+// routes to, on the app Service's ports
+func renderCanaryService(app *corev1.Service, canary *appsv1.Deployment) *corev1.Service {
+	svc := app.DeepCopy()
+	svc.Name = canary.Name
+	svc.Labels = maps.Clone(canary.Labels)
+	svc.Annotations = nil
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Selector = map[string]string{"app": canary.Name}
+	svc.Spec.ExternalTrafficPolicy = ""
+	svc.Spec.LoadBalancerSourceRanges = nil
+	for i := range svc.Spec.Ports {
+		svc.Spec.Ports[i].NodePort = 0
+	}
+	return svc
+}
+
+// renderCanaryIngress renders the canary Ingress: the app's rules, pointed
+// at the canary Service, with the NGINX canary annotations. Certificates and
+// DNS records stay with the app's Ingress.
+func renderCanaryIngress(app *networkingv1.Ingress, canary *corev1.Service, weight int) *networkingv1.Ingress {
+	ing := app.DeepCopy()
+	ing.Name = canary.Name
+	ing.Labels = maps.Clone(canary.Labels)
+	ing.Annotations = map[string]string{
+		AnnotationNginxCanary:       "true",
+		AnnotationNginxCanaryWeight: strconv.Itoa(weight),
+	}
+	for i := range ing.Spec.Rules {
+		if ing.Spec.Rules[i].HTTP == nil {
+			continue
+		}
+		for j := range ing.Spec.Rules[i].HTTP.Paths {
+			if backend := ing.Spec.Rules[i].HTTP.Paths[j].Backend.Service; backend != nil {
+				backend.Name = canary.Name
+			}
+		}
+	}
+	return ing
+}
+
+// renderCanaryNetworkPolicy lets into the canary's pods what the app's
+// policy lets into the app's
+func renderCanaryNetworkPolicy(app *networkingv1.NetworkPolicy, canary *appsv1.Deployment) *networkingv1.NetworkPolicy {
+	policy := app.DeepCopy()
+	policy.Name = canary.Name
+	policy.Labels = maps.Clone(canary.Labels)
+	policy.Spec.PodSelector = metav1.LabelSelector{MatchLabels: map[string]string{"app": canary.Name}}
+	return policy
+}
+
+// Canary returns the canary Deployment of the app's traffic split, or nil
+func (b *Bundle) Canary() *appsv1.Deployment {
+	for _, dep := range b.Deployments {
+		if dep.Labels[LabelTrack] == TrackCanary {
+			return dep
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LabelConfigVersionOf marks a versioned ConfigMap or Secret with the name
+// it was rendered from, so every version of one object can be found
+const LabelConfigVersionOf = "kbox.dev/config-version-of"
+
+// versionHashLength is how many hex digits of the content hash go in a name
+const versionHashLength = 10
+
+// versionConfig renames the bundle's ConfigMaps and Secrets to
+// <name>-<content hash>, marks them immutable, and points the pod specs at
+// the new names. A config change then creates a new object and rolls the
+// pods, while the old object stays around for rollback until pruned.
+// Dependency passwords and shared secrets are left alone: they are generated
+// per render, so hashing them would create a new version on every deploy.
+func (b *Bundle) versionConfig() {
+	immutable := true
+
+	configMaps := make(map[string]string)
+	for _, cm := range b.ConfigMaps {
+		versioned := versionedName(cm.Name, cm.Data, cm.BinaryData)
+		configMaps[cm.Name] = versioned
+		markVersion(&cm.ObjectMeta.Labels, cm.Name)
+		cm.Name = versioned
+		cm.Immutable = &immutable
+	}
+
+	secrets := make(map[string]string)
+	for _, s := range b.Secrets {
+		if generatedSecret(s) {
+			continue
+		}
+		data := maps.Clone(s.Data)
+		if data == nil {
+			data = make(map[string][]byte)
+		}
+		for k, v := range s.StringData {
+			data[k] = []byte(v)
+		}
+		versioned := versionedName(s.Name, nil, data)
+		secrets[s.Name] = versioned
+		markVersion(&s.ObjectMeta.Labels, s.Name)
+		s.Name = versioned
+		s.Immutable = &immutable
+	}
+
+	for _, dep := range b.Deployments {
+		renameConfigRefs(&dep.Spec.Template.Spec, configMaps, secrets)
+	}
+	if b.AppStatefulSet != nil {
+		renameConfigRefs(&b.AppStatefulSet.Spec.Template.Spec, configMaps, secrets)
+	}
+	for _, job := range b.Jobs {
+		renameConfigRefs(&job.Spec.Template.Spec, configMaps, secrets)
+	}
+	for _, cj := range b.CronJobs {
+		renameConfigRefs(&cj.Spec.JobTemplate.Spec.Template.Spec, configMaps, secrets)
+	}
+}
+
+// ConfigVersions lists the versioned ConfigMaps and Secrets in the bundle as
+// "Kind/name", the form prune uses to decide what to keep
+func (b *Bundle) ConfigVersions() []string {
+	var versions []string
+	for _, cm := range b.ConfigMaps {
+		if cm.Labels[LabelConfigVersionOf] != "" {
+			versions = append(versions, "ConfigMap/"+cm.Name)
+		}
+	}
+	for _, s := range b.Secrets {
+		if s.Labels[LabelConfigVersionOf] != "" {
+			versions = append(versions, "Secret/"+s.Name)
+		}
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+func versionedName(name string, data map[string]string, binary map[string][]byte) string {
+	h := sha256.New()
+	hashData(h, name, data, binary)
+	return name + "-" + hex.EncodeToString(h.Sum(nil))[:versionHashLength]
+}
+
+func markVersion(labels *map[string]string, name string) {
+	if *labels == nil {
+		*labels = make(map[string]string)
+	}
+	(*labels)[LabelConfigVersionOf] = name
+}
+
+// renameConfigRefs rewrites a pod's env, envFrom and volume references to
+// the renamed ConfigMaps and Secrets
+func renameConfigRefs(spec *corev1.PodSpec, configMaps, secrets map[string]string) {
+	rename := func(name *string, names map[string]string) {
+		if renamed, ok := names[*name]; ok {
+			*name = renamed
+		}
+	}
+
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			for j := range c.EnvFrom {
+				if ref := c.EnvFrom[j].ConfigMapRef; ref != nil {
+					rename(&ref.Name, configMaps)
+				}
+				if ref := c.EnvFrom[j].SecretRef; ref != nil {
+					rename(&ref.Name, secrets)
+				}
+			}
+			for j := range c.Env {
+				from := c.Env[j].ValueFrom
+				if from == nil {
+					continue
+				}
+				if ref := from.ConfigMapKeyRef; ref != nil {
+					rename(&ref.Name, configMaps)
+				}
+				if ref := from.SecretKeyRef; ref != nil {
+					rename(&ref.Name, secrets)
+				}
+			}
+		}
+	}
+
+	for i := range spec.Volumes {
+		v := &spec.Volumes[i]
+		if v.ConfigMap != nil {
+			rename(&v.ConfigMap.Name, configMaps)
+		}
+		if v.Secret != nil {
+			rename(&v.Secret.SecretName, secrets)
+		}
+		if v.Projected != nil {
+			for j := range v.Projected.Sources {
+				src := &v.Projected.Sources[j]
+				if src.ConfigMap != nil {
+					rename(&src.ConfigMap.Name, configMaps)
+				}
+				if src.Secret != nil {
+					rename(&src.Secret.Name, secrets)
+				}
+			}
+		}
+	}
+}
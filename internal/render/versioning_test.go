@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestConfigVersioning(t *testing.T) {
+	cfg := &config.AppConfig{
+		Metadata: config.Metadata{Name: "myapp"},
+		Spec: config.AppSpec{
+			Image:            "myapp:v1",
+			Port:             8080,
+			Env:              map[string]string{"LOG_LEVEL": "info"},
+			Dependencies:     []config.DependencyConfig{{Type: "postgres"}},
+			ConfigVersioning: true,
+		},
+	}
+
+	bundle, err := New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render bundle: %v", err)
+	}
+	if len(bundle.ConfigMaps) != 1 {
+		t.Fatalf("expected 1 ConfigMap, got %d", len(bundle.ConfigMaps))
+	}
+	cm := bundle.ConfigMaps[0]
+	if !strings.HasPrefix(cm.Name, "myapp-config-") || len(cm.Name) != len("myapp-config-")+versionHashLength {
+		t.Errorf("expected a content-hash suffix, got %s", cm.Name)
+	}
+	if cm.Immutable == nil || !*cm.Immutable {
+		t.Error("expected versioned ConfigMap to be immutable")
+	}
+	if cm.Labels[LabelConfigVersionOf] != "myapp-config" {
+		t.Errorf("expected %s=myapp-config, got %q", LabelConfigVersionOf, cm.Labels[LabelConfigVersionOf])
+	}
+
+	// The pod spec follows the rename
+	found := false
+	for _, from := range bundle.Deployment.Spec.Template.Spec.Containers[0].EnvFrom {
+		if from.ConfigMapRef != nil {
+			found = from.ConfigMapRef.Name == cm.Name
+		}
+	}
+	if !found {
+		t.Errorf("expected envFrom to reference %s", cm.Name)
+	}
+	if _, ok := bundle.Deployment.Spec.Template.Annotations[AnnotationConfigChecksum]; ok {
+		t.Error("versioned config should not need a checksum annotation")
+	}
+
+	// Dependency secrets keep their names
+	for _, s := range bundle.Secrets {
+		if s.Labels["kbox.dev/dependency"] != "" && s.Labels[LabelConfigVersionOf] != "" {
+			t.Errorf("dependency secret %s should not be versioned", s.Name)
+		}
+	}
+
+	if versions := bundle.ConfigVersions(); len(versions) != 1 || versions[0] != "ConfigMap/"+cm.Name {
+		t.Errorf("unexpected config versions %v", versions)
+	}
+
+	// Same content, same name; new content, new name
+	again, _ := New(cfg).Render()
+	if again.ConfigMaps[0].Name != cm.Name {
+		t.Errorf("expected a stable name, got %s then %s", cm.Name, again.ConfigMaps[0].Name)
+	}
+	cfg.Spec.Env["LOG_LEVEL"] = "debug"
+	changed, _ := New(cfg).Render()
+	if changed.ConfigMaps[0].Name == cm.Name {
+		t.Error("expected a new name when the content changes")
+	}
+}
@@ -2,17 +2,25 @@ package render
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/yaml"
 )
 
-// ToYAML converts a bundle to YAML output
+// ToYAML converts a bundle to YAML output, with provenance annotations
+// when TraceProvenance was called
 func (b *Bundle) ToYAML(w io.Writer) error {
-	objects := b.AllObjects()
+	return b.WriteYAML(w, b.AllObjects())
+}
 
+// WriteYAML writes some of the bundle's objects (see Select) as a YAML
+// stream, annotated like ToYAML
+func (b *Bundle) WriteYAML(w io.Writer, objects []runtime.Object) error {
 	for i, obj := range objects {
 		if i > 0 {
 			// Separate documents with ---
@@ -20,8 +28,7 @@ func (b *Bundle) ToYAML(w io.Writer) error {
 				return err
 			}
 		}
-
-		if err := writeObjectYAML(w, obj); err != nil {
+		if err := b.writeObject(w, obj); err != nil {
 			return err
 		}
 	}
@@ -29,6 +36,39 @@ func (b *Bundle) ToYAML(w io.Writer) error {
 	return nil
 }
 
+// writeObject writes an object as YAML, with its provenance annotations if any
+func (b *Bundle) writeObject(w io.Writer, obj runtime.Object) error {
+	if b.provenance == nil {
+		return writeObjectYAML(w, obj)
+	}
+	objMap, err := b.annotated(obj)
+	if err != nil {
+		return err
+	}
+	yamlBytes, err := yaml.Marshal(objMap)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(yamlBytes)
+	return err
+}
+
+// Digest returns a content hash of the bundle, used to detect no-op deploys.
+// Dependency passwords and shared secrets are skipped because they are generated per render.
+func (b *Bundle) Digest() (string, error) {
+	h := sha256.New()
+	for _, obj := range b.AllObjects() {
+		if s, ok := obj.(*corev1.Secret); ok && generatedSecret(s) {
+			continue
+		}
+		if err := writeObjectYAML(h, obj); err != nil {
+			return "", err
+		}
+		h.Write([]byte("---\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func writeObjectYAML(w io.Writer, obj runtime.Object) error {
 	// Marshal directly to YAML - sigs.k8s.io/yaml handles k8s objects
 	yamlBytes, err := yaml.Marshal(obj)
@@ -49,22 +89,21 @@ func ObjectToYAML(obj runtime.Object) (string, error) {
 	return buf.String(), nil
 }
 
-// ToJSON converts a bundle to JSON output (array of objects)
+// ToJSON converts a bundle to JSON output (array of objects), with
+// provenance annotations when TraceProvenance was called
 func (b *Bundle) ToJSON(w io.Writer) error {
-	objects := b.AllObjects()
+	return b.WriteJSON(w, b.AllObjects())
+}
 
+// WriteJSON writes some of the bundle's objects (see Select) like ToJSON
+func (b *Bundle) WriteJSON(w io.Writer, objects []runtime.Object) error {
 	// Convert to JSON-friendly representation
 	var jsonObjects []map[string]interface{}
 	for _, obj := range objects {
-		// Marshal to JSON first, then unmarshal to get clean map
-		jsonBytes, err := json.Marshal(obj)
+		objMap, err := b.annotated(obj)
 		if err != nil {
 			return err
 		}
-		var objMap map[string]interface{}
-		if err := json.Unmarshal(jsonBytes, &objMap); err != nil {
-			return err
-		}
 		jsonObjects = append(jsonObjects, objMap)
 	}
 
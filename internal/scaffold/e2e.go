@@ -0,0 +1,261 @@
+// Package scaffold generates starter files for a kbox app, such as an
+// end-to-end deployment test
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// E2EOptions describes the app an end-to-end test deploys
+type E2EOptions struct {
+	// App is the app's name (metadata.name)
+	App string
+	// AppDir is the directory of kbox.yaml, relative to the test file
+	AppDir string
+	// Env is the environment overlay to deploy ("" for the base config)
+	Env string
+	// Port is the app's container port; 0 skips the health check
+	Port int
+	// HealthPath is the path the test GETs (default: /)
+	HealthPath string
+	// Build deploys with 'kbox up', building the image and loading it into
+	// kind; otherwise 'kbox deploy' pulls the configured image
+	Build bool
+	// Package is the test's package name (default: e2e)
+	Package string
+}
+
+// E2ETest returns a gofmt'd Go test file that creates a kind cluster, deploys
+// the app into a fresh namespace, waits for the rollout, GETs its health
+// endpoint, and tears everything down. It needs kind, kubectl, docker and
+// kbox on PATH, and is behind the e2e build tag so 'go test ./...' skips it.
+func E2ETest(opts E2EOptions) ([]byte, error) {
+	if opts.HealthPath == "" {
+		opts.HealthPath = "/"
+	}
+	if opts.Package == "" {
+		opts.Package = "e2e"
+	}
+	data := struct {
+		E2EOptions
+		TestName string
+	}{opts, testName(opts.App)}
+
+	var buf bytes.Buffer
+	if err := e2eTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated test doesn't parse: %w", err)
+	}
+	return src, nil
+}
+
+// testName turns an app name into the exported part of a test name,
+// e.g. my-api becomes MyApi
+func testName(app string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range app {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	if sb.Len() == 0 {
+		return "App"
+	}
+	return sb.String()
+}
+
+var e2eTemplate = template.Must(template.New("e2e").Parse(`//go:build e2e
+
+// End-to-end deployment test for {{.App}}, generated by 'kbox test scaffold'.
+// It's yours to edit: add requests that exercise the app after the health check.
+//
+// Run it with:
+//
+//	go test -tags e2e -v -timeout 15m .
+//
+// It needs kind, kubectl, docker and kbox on PATH. The kind cluster is created
+// if it doesn't exist and deleted afterwards, unless KBOX_E2E_KEEP_CLUSTER is
+// set or the cluster was already there.
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+{{- if .Port}}
+	"net"
+	"net/http"
+{{- end}}
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// clusterName is the kind cluster the test deploys to
+	clusterName = "kbox-e2e"
+	kubeContext = "kind-" + clusterName
+
+	appName = {{printf "%q" .App}}
+	// appDir is the directory of the app's kbox.yaml, relative to this file
+	appDir = {{printf "%q" .AppDir}}
+{{- if .Env}}
+	// env is the environment overlay deployed
+	env = {{printf "%q" .Env}}
+{{- end}}
+{{- if .Port}}
+	appPort    = {{.Port}}
+	healthPath = {{printf "%q" .HealthPath}}
+{{- end}}
+
+	// timeout bounds the deploy, including the image build
+	timeout = 10 * time.Minute
+)
+
+func TestMain(m *testing.M) {
+	for _, tool := range []string{"kind", "kubectl", "docker", "kbox"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			fmt.Fprintf(os.Stderr, "skipping e2e tests: %s is not on PATH\n", tool)
+			os.Exit(0)
+		}
+	}
+
+	created, err := ensureCluster()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create kind cluster %s: %v\n", clusterName, err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if created && os.Getenv("KBOX_E2E_KEEP_CLUSTER") == "" {
+		if out, err := run("kind", "delete", "cluster", "--name", clusterName); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to delete kind cluster %s: %v\n%s", clusterName, err, out)
+		}
+	}
+	os.Exit(code)
+}
+
+func Test{{.TestName}}Deploys(t *testing.T) {
+	namespace := fmt.Sprintf("%s-e2e-%d", appName, time.Now().Unix())
+	if out, err := kubectl("create", "namespace", namespace); err != nil {
+		t.Fatalf("failed to create namespace: %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		if out, err := kubectl("delete", "namespace", namespace, "--wait=false"); err != nil {
+			t.Logf("failed to delete namespace %s: %v\n%s", namespace, err, out)
+		}
+	})
+
+	// Deploy and wait for the rollout
+	args := []string{ {{- if .Build}}"up", "--no-logs"{{else}}"deploy"{{end}}, "--ci", "--context", kubeContext, "-n", namespace}
+{{- if .Env}}
+	args = append(args, "-e", env)
+{{- end}}
+	if out, err := kbox(args...); err != nil {
+		t.Fatalf("kbox %s failed: %v\n%s", args[0], err, out)
+	}
+	if out, err := kubectl("-n", namespace, "rollout", "status", "deployment/"+appName, "--timeout=2m"); err != nil {
+		t.Fatalf("rollout didn't finish: %v\n%s", err, out)
+	}
+{{- if .Port}}
+
+	// Hit the health endpoint through a port-forward
+	port := freePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	forward := exec.CommandContext(ctx, "kubectl", "--context", kubeContext, "-n", namespace,
+		"port-forward", "deployment/"+appName, fmt.Sprintf("%d:%d", port, appPort))
+	if err := forward.Start(); err != nil {
+		t.Fatalf("failed to port-forward: %v", err)
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", port, healthPath)
+	deadline := time.Now().Add(time.Minute)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 400 {
+				break
+			}
+			err = fmt.Errorf("status %s", resp.Status)
+		}
+		if time.Now().After(deadline) {
+			out, _ := kubectl("-n", namespace, "logs", "deployment/"+appName, "--tail=50")
+			t.Fatalf("GET %s: %v\napp logs:\n%s", url, err, out)
+		}
+		time.Sleep(time.Second)
+	}
+{{- end}}
+}
+
+// ensureCluster creates the kind cluster unless it exists, reporting whether
+// it created it
+func ensureCluster() (bool, error) {
+	out, err := run("kind", "get", "clusters")
+	if err != nil {
+		return false, fmt.Errorf("%w\n%s", err, out)
+	}
+	for _, name := range strings.Fields(out) {
+		if name == clusterName {
+			return false, nil
+		}
+	}
+	if out, err := run("kind", "create", "cluster", "--name", clusterName, "--wait", "2m"); err != nil {
+		return false, fmt.Errorf("%w\n%s", err, out)
+	}
+	return true, nil
+}
+
+func kbox(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "kbox", args...)
+	cmd.Dir = appDir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+func kubectl(args ...string) (string, error) {
+	return run("kubectl", append([]string{"--context", kubeContext}, args...)...)
+}
+
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	return string(out), err
+}
+{{- if .Port}}
+
+// freePort returns a local port nothing is listening on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+{{- end}}
+`))
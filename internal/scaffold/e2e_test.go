@@ -0,0 +1,77 @@
+package scaffold
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestE2ETest(t *testing.T) {
+	tests := []struct {
+		name string
+		opts E2EOptions
+		want []string
+		skip []string
+	}{
+		{
+			name: "built app with a health check",
+			opts: E2EOptions{App: "my-api", AppDir: "../..", Port: 8080, HealthPath: "/healthz", Build: true},
+			want: []string{"//go:build e2e", "func TestMyApiDeploys(", `"up", "--no-logs"`, `= "/healthz"`, `= "../.."`},
+			skip: []string{`"deploy"`, `"-e", env`},
+		},
+		{
+			name: "image-only worker in an environment",
+			opts: E2EOptions{App: "worker", AppDir: "..", Env: "staging", Package: "deploytest"},
+			want: []string{"package deploytest", `"deploy", "--ci"`, `= "staging"`, `"-e", env`},
+			skip: []string{"net/http", "freePort", `"up"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := E2ETest(tt.opts)
+			if err != nil {
+				t.Fatalf("E2ETest() error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(src), want) {
+					t.Errorf("expected %q in:\n%s", want, src)
+				}
+			}
+			for _, skip := range tt.skip {
+				if strings.Contains(string(src), skip) {
+					t.Errorf("unexpected %q in:\n%s", skip, src)
+				}
+			}
+
+			// The file must compile: no unused imports or variables
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "e2e_test.go", src, 0)
+			if err != nil {
+				t.Fatalf("generated file doesn't parse: %v", err)
+			}
+			conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+			if _, err := conf.Check("e2e", fset, []*ast.File{file}, nil); err != nil {
+				t.Errorf("generated file doesn't type-check: %v\n%s", err, src)
+			}
+		})
+	}
+}
+
+func TestTestName(t *testing.T) {
+	for app, want := range map[string]string{
+		"web":       "Web",
+		"my-api":    "MyApi",
+		"api.v2":    "ApiV2",
+		"---":       "App",
+		"shop-cart": "ShopCart",
+	} {
+		if got := testName(app); got != want {
+			t.Errorf("testName(%q) = %q, want %q", app, got, want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobbyrathoree/kbox/internal/output"
+)
+
+// maxBodyBytes caps request bodies; requests only carry a few options
+const maxBodyBytes = 1 << 20
+
+// namePattern matches app and preview names (DNS labels)
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Runner runs a kbox command and returns its stdout, stderr and exit code.
+// err is only set when the command couldn't be run at all.
+type Runner interface {
+	Run(ctx context.Context, args []string) (stdout, stderr []byte, exitCode int, err error)
+}
+
+// ExecRunner runs commands with the kbox binary at Path in Dir
+type ExecRunner struct {
+	Path string
+	Dir  string
+}
+
+// Run implements Runner
+func (r *ExecRunner) Run(ctx context.Context, args []string) ([]byte, []byte, int, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.Path, args...)
+	cmd.Dir = r.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.Bytes(), stderr.Bytes(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return stdout.Bytes(), stderr.Bytes(), 0, nil
+}
+
+// Request holds the options of an API call. Each operation uses the fields
+// that match its command's flags.
+type Request struct {
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Env       string `json:"env,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+	Prune     bool   `json:"prune,omitempty"`
+	Confirm   bool   `json:"confirm,omitempty"` // Required for protected environments
+	Timeout   string `json:"timeout,omitempty"`
+	Name      string `json:"name,omitempty"` // Preview name
+}
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Remote     string    `json:"remote"`
+	Actor      string    `json:"actor,omitempty"` // X-Kbox-Actor, e.g. the chat user behind a bot
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Operation  string    `json:"operation,omitempty"`
+	Context    string    `json:"context,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Env        string    `json:"env,omitempty"`
+	Status     int       `json:"status"`
+	ErrorCode  string    `json:"errorCode,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Server exposes kbox operations over an authenticated HTTP API. Each call
+// runs the matching kbox command with JSON output, so the API behaves exactly
+// like the CLI (same engine, exit codes and errorCode values).
+type Server struct {
+	token  string
+	runner Runner
+	audit  io.Writer
+
+	auditMu sync.Mutex
+	// mutating serializes deploys and preview changes so they can't interleave
+	mutating sync.Mutex
+
+	mux *http.ServeMux
+}
+
+// New creates a server that accepts requests bearing token, runs commands
+// with runner and writes an audit log line per request to audit (nil discards)
+func New(token string, runner Runner, audit io.Writer) *Server {
+	if audit == nil {
+		audit = io.Discard
+	}
+	s := &Server{token: token, runner: runner, audit: audit, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	})
+	s.handle("POST /v1/render", "render", false, renderArgs)
+	s.handle("POST /v1/deploy", "deploy", true, deployArgs)
+	s.handle("GET /v1/status/{app}", "status", false, statusArgs)
+	s.handle("GET /v1/previews", "preview list", false, previewListArgs)
+	s.handle("POST /v1/previews", "preview create", true, previewCreateArgs)
+	s.handle("DELETE /v1/previews/{name}", "preview destroy", true, previewDestroyArgs)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// argsFunc builds the command line for a request, or returns a validation error
+type argsFunc func(r *http.Request, req *Request) ([]string, error)
+
+// handle registers an operation: authenticate, parse the request, run the
+// command and audit the outcome
+func (s *Server) handle(pattern, operation string, mutating bool, build argsFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := AuditEntry{
+			Time:      start.UTC(),
+			Remote:    r.RemoteAddr,
+			Actor:     r.Header.Get("X-Kbox-Actor"),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Operation: operation,
+		}
+		status, body := s.serve(r, mutating, build, &entry)
+		entry.Status = status
+		if code, ok := body["errorCode"]; ok {
+			entry.ErrorCode = fmt.Sprint(code)
+		}
+		entry.DurationMs = time.Since(start).Milliseconds()
+		s.writeAudit(entry)
+
+		if raw, ok := body["raw"].(json.RawMessage); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write(raw)
+			return
+		}
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kbox"`)
+		}
+		writeJSON(w, status, body)
+	})
+}
+
+// serve runs one request, returning the HTTP status and response body.
+// A body with "raw" holds the command's own JSON output.
+func (s *Server) serve(r *http.Request, mutating bool, build argsFunc, entry *AuditEntry) (int, map[string]interface{}) {
+	if !s.authorized(r) {
+		return http.StatusUnauthorized, errorBody("missing or invalid bearer token", output.ErrGeneric)
+	}
+
+	req := &Request{}
+	if r.Method == http.MethodGet || r.Method == http.MethodDelete {
+		q := r.URL.Query()
+		req.Context = q.Get("context")
+		req.Namespace = q.Get("namespace")
+	}
+	if r.Body != nil && r.Method == http.MethodPost {
+		dec := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil && err != io.EOF {
+			return http.StatusBadRequest, errorBody("invalid request body: "+err.Error(), output.ErrGeneric)
+		}
+	}
+	entry.Context, entry.Namespace, entry.Env = req.Context, req.Namespace, req.Env
+
+	args, err := build(r, req)
+	if err == nil {
+		err = validateCommon(req)
+	}
+	if err != nil {
+		return http.StatusBadRequest, errorBody(err.Error(), output.ErrGeneric)
+	}
+	args = append(append([]string{}, args...), commonArgs(req)...)
+
+	if mutating {
+		s.mutating.Lock()
+		defer s.mutating.Unlock()
+	}
+	stdout, stderr, exitCode, err := s.runner.Run(r.Context(), args)
+	if err != nil {
+		return http.StatusInternalServerError, errorBody("failed to run kbox: "+err.Error(), output.ErrGeneric)
+	}
+
+	code := output.CodeForExit(exitCode)
+	status := statusFor(code)
+	// Commands print their JSON result to stdout; kbox's own errors go to stderr
+	candidates := [][]byte{stdout}
+	if exitCode != 0 {
+		candidates = append(candidates, stderr)
+	}
+	for _, out := range candidates {
+		if out = bytes.TrimSpace(out); len(out) > 0 && json.Valid(out) {
+			return status, map[string]interface{}{"raw": json.RawMessage(out), "errorCode": code}
+		}
+	}
+	if exitCode == 0 {
+		return status, map[string]interface{}{"success": true, "output": string(stdout)}
+	}
+	return status, errorBody(commandError(stderr, stdout), code)
+}
+
+// authorized checks the request's bearer token in constant time
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || s.token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) writeAudit(entry AuditEntry) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	json.NewEncoder(s.audit).Encode(entry)
+}
+
+// statusFor maps a kbox error code to an HTTP status
+func statusFor(code output.ErrorCode) int {
+	switch code {
+	case "":
+		return http.StatusOK
+	case output.ErrConfig:
+		return http.StatusUnprocessableEntity
+	case output.ErrPolicy, output.ErrForbidden:
+		return http.StatusForbidden
+	case output.ErrCluster:
+		return http.StatusBadGateway
+	case output.ErrRolloutTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func renderArgs(r *http.Request, req *Request) ([]string, error) {
+	args := []string{"render"}
+	if req.Env != "" {
+		args = append(args, "--env="+req.Env)
+	}
+	return args, nil
+}
+
+func deployArgs(r *http.Request, req *Request) ([]string, error) {
+	args := []string{"deploy"}
+	if req.Env != "" {
+		args = append(args, "--env="+req.Env)
+	}
+	if req.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if req.Prune {
+		args = append(args, "--prune")
+	}
+	if req.Confirm {
+		args = append(args, "--yes")
+	}
+	if req.Timeout != "" {
+		if _, err := time.ParseDuration(req.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %q", req.Timeout)
+		}
+		args = append(args, "--rollout-timeout="+req.Timeout)
+	}
+	return args, nil
+}
+
+func statusArgs(r *http.Request, req *Request) ([]string, error) {
+	app := r.PathValue("app")
+	if !namePattern.MatchString(app) {
+		return nil, fmt.Errorf("invalid app name %q", app)
+	}
+	return []string{"status", app}, nil
+}
+
+func previewListArgs(r *http.Request, req *Request) ([]string, error) {
+	return []string{"preview", "list"}, nil
+}
+
+func previewCreateArgs(r *http.Request, req *Request) ([]string, error) {
+	if !namePattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("invalid preview name %q", req.Name)
+	}
+	return []string{"preview", "create", "--name=" + req.Name}, nil
+}
+
+func previewDestroyArgs(r *http.Request, req *Request) ([]string, error) {
+	name := r.PathValue("name")
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid preview name %q", name)
+	}
+	return []string{"preview", "destroy", "--name=" + name, "--yes"}, nil
+}
+
+// validateCommon rejects values that could be read as extra flags or paths
+func validateCommon(req *Request) error {
+	for field, value := range map[string]string{"context": req.Context, "namespace": req.Namespace, "env": req.Env} {
+		if strings.HasPrefix(value, "-") || strings.ContainsAny(value, "\n\x00") {
+			return fmt.Errorf("invalid %s %q", field, value)
+		}
+	}
+	if req.Namespace != "" && !namePattern.MatchString(req.Namespace) {
+		return fmt.Errorf("invalid namespace %q", req.Namespace)
+	}
+	return nil
+}
+
+// commonArgs selects the cluster per request and asks for CI-style JSON output
+func commonArgs(req *Request) []string {
+	args := []string{"--ci", "--output=json"}
+	if req.Context != "" {
+		args = append(args, "--context="+req.Context)
+	}
+	if req.Namespace != "" {
+		args = append(args, "--namespace="+req.Namespace)
+	}
+	return args
+}
+
+// commandError picks the most useful message from a failed command
+func commandError(stderr, stdout []byte) string {
+	msg := strings.TrimSpace(string(stderr))
+	if msg == "" {
+		msg = strings.TrimSpace(string(stdout))
+	}
+	if msg == "" {
+		msg = "command failed"
+	}
+	return msg
+}
+
+func errorBody(msg string, code output.ErrorCode) map[string]interface{} {
+	return map[string]interface{}{
+		"success":   false,
+		"error":     msg,
+		"errorCode": code,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
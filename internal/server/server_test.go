@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeRunner records the commands it is asked to run and returns a canned result
+type fakeRunner struct {
+	calls    [][]string
+	stdout   string
+	stderr   string
+	exitCode int
+}
+
+func (f *fakeRunner) Run(ctx context.Context, args []string) ([]byte, []byte, int, error) {
+	f.calls = append(f.calls, args)
+	return []byte(f.stdout), []byte(f.stderr), f.exitCode, nil
+}
+
+func doRequest(s *Server, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("X-Kbox-Actor", "alice")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	runner := &fakeRunner{}
+	s := New("secret", runner, nil)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := doRequest(s, http.MethodPost, "/v1/deploy", `{}`, token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("expected no command to run without a valid token, got %v", runner.calls)
+	}
+
+	if rec := doRequest(s, http.MethodGet, "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected health check without a token, got %d", rec.Code)
+	}
+}
+
+func TestServerDeploy(t *testing.T) {
+	runner := &fakeRunner{stdout: `{"success":true,"app":"myapp"}` + "\n"}
+	var audit bytes.Buffer
+	s := New("secret", runner, &audit)
+
+	rec := doRequest(s, http.MethodPost, "/v1/deploy",
+		`{"context":"prod-east","namespace":"shop","env":"prod","prune":true,"confirm":true,"timeout":"2m"}`, "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.TrimSpace(rec.Body.String()) != `{"success":true,"app":"myapp"}` {
+		t.Errorf("expected the command's JSON result, got %s", rec.Body.String())
+	}
+
+	want := "deploy --env=prod --prune --yes --rollout-timeout=2m --ci --output=json --context=prod-east --namespace=shop"
+	if len(runner.calls) != 1 || strings.Join(runner.calls[0], " ") != want {
+		t.Errorf("expected %q, got %v", want, runner.calls)
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal(audit.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid audit line %q: %v", audit.String(), err)
+	}
+	if entry.Operation != "deploy" || entry.Actor != "alice" || entry.Context != "prod-east" || entry.Status != http.StatusOK {
+		t.Errorf("unexpected audit entry: %+v", entry)
+	}
+}
+
+func TestServerMapsExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   *fakeRunner
+		status   int
+		wantBody string
+	}{
+		{"config error", &fakeRunner{stderr: "failed to load kbox.yaml", exitCode: 2}, http.StatusUnprocessableEntity, `"errorCode":"config_error"`},
+		{"rollout timeout", &fakeRunner{stdout: `{"success":false,"errorCode":"rollout_timeout"}`, exitCode: 4}, http.StatusGatewayTimeout, `"rollout_timeout"`},
+		{"json error on stderr", &fakeRunner{stderr: `{"success":false,"errorCode":"cluster_unreachable"}`, exitCode: 3}, http.StatusBadGateway, `"cluster_unreachable"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var audit bytes.Buffer
+			s := New("secret", tt.runner, &audit)
+			rec := doRequest(s, http.MethodGet, "/v1/status/myapp?context=dev", "", "secret")
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("expected %s in body, got %s", tt.wantBody, rec.Body.String())
+			}
+			if !strings.Contains(audit.String(), `"errorCode"`) {
+				t.Errorf("expected the error code in the audit log, got %s", audit.String())
+			}
+		})
+	}
+}
+
+func TestServerRejectsInvalidInput(t *testing.T) {
+	runner := &fakeRunner{}
+	s := New("secret", runner, nil)
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/deploy", `{"context":"--kubeconfig=/etc/passwd"}`},
+		{http.MethodPost, "/v1/deploy", `{"timeout":"soon"}`},
+		{http.MethodPost, "/v1/deploy", `{"unknown":true}`},
+		{http.MethodPost, "/v1/previews", `{"name":"Bad_Name"}`},
+		{http.MethodGet, "/v1/status/myapp?namespace=-x", ""},
+	} {
+		rec := doRequest(s, tc.method, tc.path, tc.body, "secret")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: expected 400, got %d", tc.method, tc.path, tc.body, rec.Code)
+		}
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("expected no command for invalid input, got %v", runner.calls)
+	}
+}
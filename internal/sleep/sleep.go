@@ -0,0 +1,151 @@
+// Package sleep scales an app's workloads to zero and restores them, so idle
+// environments don't hold on to cluster capacity
+package sleep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// Scaled is a workload Sleep or Wake changed
+type Scaled struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Replicas int32  `json:"replicas"` // before sleeping, or after waking
+}
+
+func (s Scaled) String() string {
+	return s.Kind + "/" + s.Name
+}
+
+// workload is a Deployment or StatefulSet kbox owns for the app
+type workload struct {
+	kind        string
+	name        string
+	replicas    int32
+	annotations map[string]string
+}
+
+// Sleep scales the app's Deployments and StatefulSets (dependencies
+// included) to zero, recording each one's replicas in an annotation for
+// Wake. Workloads already at zero are left alone.
+func Sleep(ctx context.Context, client kubernetes.Interface, namespace, app string) ([]Scaled, error) {
+	workloads, err := list(ctx, client, namespace, app)
+	if err != nil {
+		return nil, err
+	}
+	var scaled []Scaled
+	for _, w := range workloads {
+		if w.replicas == 0 {
+			continue
+		}
+		patch := map[string]any{
+			"metadata": map[string]any{"annotations": map[string]any{render.AnnotationSleepReplicas: strconv.Itoa(int(w.replicas))}},
+			"spec":     map[string]any{"replicas": 0},
+		}
+		if err := scale(ctx, client, namespace, w, patch); err != nil {
+			return scaled, err
+		}
+		scaled = append(scaled, Scaled{Kind: w.kind, Name: w.name, Replicas: w.replicas})
+	}
+	return scaled, nil
+}
+
+// Wake restores the replicas Sleep recorded and removes the annotation.
+// Workloads Sleep didn't scale down are left alone.
+func Wake(ctx context.Context, client kubernetes.Interface, namespace, app string) ([]Scaled, error) {
+	workloads, err := list(ctx, client, namespace, app)
+	if err != nil {
+		return nil, err
+	}
+	var scaled []Scaled
+	for _, w := range workloads {
+		value, ok := w.annotations[render.AnnotationSleepReplicas]
+		if !ok {
+			continue
+		}
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return scaled, fmt.Errorf("%s/%s: invalid %s annotation %q", w.kind, w.name, render.AnnotationSleepReplicas, value)
+		}
+		patch := map[string]any{
+			"metadata": map[string]any{"annotations": map[string]any{render.AnnotationSleepReplicas: nil}},
+			"spec":     map[string]any{"replicas": replicas},
+		}
+		if err := scale(ctx, client, namespace, w, patch); err != nil {
+			return scaled, err
+		}
+		scaled = append(scaled, Scaled{Kind: w.kind, Name: w.name, Replicas: int32(replicas)})
+	}
+	return scaled, nil
+}
+
+func scale(ctx context.Context, client kubernetes.Interface, namespace string, w workload, patch map[string]any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	switch w.kind {
+	case "StatefulSet":
+		_, err = client.AppsV1().StatefulSets(namespace).Patch(ctx, w.name, types.MergePatchType, data, metav1.PatchOptions{})
+	default:
+		_, err = client.AppsV1().Deployments(namespace).Patch(ctx, w.name, types.MergePatchType, data, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scale %s/%s: %w", w.kind, w.name, err)
+	}
+	return nil
+}
+
+// list returns the Deployments and StatefulSets kbox owns for app, sorted by kind and name
+func list(ctx context.Context, client kubernetes.Interface, namespace, app string) ([]workload, error) {
+	seen := make(map[string]bool)
+	var workloads []workload
+	add := func(w workload) {
+		if key := w.kind + "/" + w.name; !seen[key] {
+			seen[key] = true
+			workloads = append(workloads, w)
+		}
+	}
+	for _, selector := range render.OwnedSelectors(app) {
+		opts := metav1.ListOptions{LabelSelector: selector}
+		deps, err := client.AppsV1().Deployments(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for _, d := range deps.Items {
+			add(workload{kind: "Deployment", name: d.Name, replicas: replicasOf(d.Spec.Replicas), annotations: d.Annotations})
+		}
+		sets, err := client.AppsV1().StatefulSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for _, s := range sets.Items {
+			add(workload{kind: "StatefulSet", name: s.Name, replicas: replicasOf(s.Spec.Replicas), annotations: s.Annotations})
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].kind != workloads[j].kind {
+			return workloads[i].kind < workloads[j].kind
+		}
+		return workloads[i].name < workloads[j].name
+	})
+	return workloads, nil
+}
+
+// replicasOf returns a workload's replicas; unset means 1
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
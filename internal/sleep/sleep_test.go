@@ -0,0 +1,66 @@
+package sleep
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+func owned(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "dev",
+		Labels:    map[string]string{render.LabelManagedBy: render.ManagedByKbox, render.LabelApp: "myapp"},
+	}
+}
+
+func TestSleepAndWake(t *testing.T) {
+	ctx := context.Background()
+	three, one := int32(3), int32(1)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: owned("myapp"), Spec: appsv1.DeploymentSpec{Replicas: &three}},
+		&appsv1.StatefulSet{ObjectMeta: owned("myapp-postgres"), Spec: appsv1.StatefulSetSpec{Replicas: &one}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "dev"}, Spec: appsv1.DeploymentSpec{Replicas: &three}},
+	)
+
+	scaled, err := Sleep(ctx, client, "dev", "myapp")
+	if err != nil {
+		t.Fatalf("Sleep failed: %v", err)
+	}
+	if len(scaled) != 2 || scaled[0].String() != "Deployment/myapp" || scaled[0].Replicas != 3 {
+		t.Fatalf("expected the app's Deployment and StatefulSet to sleep, got %v", scaled)
+	}
+	dep, _ := client.AppsV1().Deployments("dev").Get(ctx, "myapp", metav1.GetOptions{})
+	if *dep.Spec.Replicas != 0 || dep.Annotations[render.AnnotationSleepReplicas] != "3" {
+		t.Errorf("expected 0 replicas with 3 recorded, got %d %v", *dep.Spec.Replicas, dep.Annotations)
+	}
+	other, _ := client.AppsV1().Deployments("dev").Get(ctx, "other", metav1.GetOptions{})
+	if *other.Spec.Replicas != 3 {
+		t.Errorf("expected a Deployment kbox doesn't own to be left alone")
+	}
+
+	// Sleeping again changes nothing, so the recorded replicas survive
+	if again, _ := Sleep(ctx, client, "dev", "myapp"); len(again) != 0 {
+		t.Errorf("expected no changes when already asleep, got %v", again)
+	}
+
+	scaled, err = Wake(ctx, client, "dev", "myapp")
+	if err != nil {
+		t.Fatalf("Wake failed: %v", err)
+	}
+	if len(scaled) != 2 {
+		t.Fatalf("expected both workloads to wake, got %v", scaled)
+	}
+	dep, _ = client.AppsV1().Deployments("dev").Get(ctx, "myapp", metav1.GetOptions{})
+	if *dep.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas restored, got %d", *dep.Spec.Replicas)
+	}
+	if _, ok := dep.Annotations[render.AnnotationSleepReplicas]; ok {
+		t.Errorf("expected the sleep annotation to be removed, got %v", dep.Annotations)
+	}
+}
@@ -0,0 +1,294 @@
+// Package smoke runs post-deploy smoke tests against a rolled-out app.
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/debug"
+)
+
+const (
+	// DefaultTimeout is the per-test timeout when none is configured
+	DefaultTimeout = 30 * time.Second
+
+	// retryInterval is the delay between attempts of a failing test
+	retryInterval = 2 * time.Second
+
+	// maxBodyBytes caps how much of a response body is read for matching
+	maxBodyBytes = 1 << 20
+)
+
+// Result is the outcome of a single smoke test
+type Result struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // http or exec
+	Passed     bool   `json:"passed"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of a smoke test run
+type Report struct {
+	Passed  bool     `json:"passed"`
+	Results []Result `json:"results"`
+}
+
+// Failed returns the results that did not pass
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.Passed {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Runner executes smoke tests against an app's pods
+type Runner struct {
+	client     kubernetes.Interface
+	restConfig *rest.Config
+	namespace  string
+	appName    string
+	appPort    int
+	out        io.Writer
+}
+
+// NewRunner creates a smoke test runner. appPort is used for HTTP tests without an explicit port.
+func NewRunner(client kubernetes.Interface, restConfig *rest.Config, namespace, appName string, appPort int, out io.Writer) *Runner {
+	if out == nil {
+		out = io.Discard
+	}
+	return &Runner{
+		client:     client,
+		restConfig: restConfig,
+		namespace:  namespace,
+		appName:    appName,
+		appPort:    appPort,
+		out:        out,
+	}
+}
+
+// Run executes all tests in order and reports the outcome of each
+func (r *Runner) Run(ctx context.Context, tests []config.SmokeTestConfig) *Report {
+	report := &Report{Passed: true}
+
+	for i, t := range tests {
+		res := r.runOne(ctx, i, t)
+		if res.Passed {
+			fmt.Fprintf(r.out, "  ✓ %s (%dms)\n", res.Name, res.DurationMs)
+		} else {
+			fmt.Fprintf(r.out, "  ✗ %s: %s\n", res.Name, res.Message)
+			report.Passed = false
+		}
+		report.Results = append(report.Results, res)
+	}
+
+	return report
+}
+
+func (r *Runner) runOne(ctx context.Context, index int, t config.SmokeTestConfig) Result {
+	start := time.Now()
+	res := Result{Name: TestName(index, t)}
+
+	timeout := DefaultTimeout
+	if t.Timeout != "" {
+		if d, err := time.ParseDuration(t.Timeout); err == nil {
+			timeout = d
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var check func(context.Context, string) error
+	switch {
+	case t.HTTP != nil:
+		res.Type = "http"
+		check = func(ctx context.Context, pod string) error { return r.checkHTTP(ctx, pod, t.HTTP) }
+	case t.Exec != nil:
+		res.Type = "exec"
+		check = func(ctx context.Context, pod string) error { return r.checkExec(ctx, pod, t.Exec) }
+	default:
+		res.Message = "no http or exec check configured"
+		return res
+	}
+
+	// Retry until the check passes or the timeout expires; the last error wins
+	var lastErr error
+	for {
+		pod, err := r.readyPod(ctx)
+		if err == nil {
+			lastErr = check(ctx, pod)
+		} else {
+			lastErr = err
+		}
+		if lastErr == nil {
+			res.Passed = true
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			res.Message = lastErr.Error()
+			res.DurationMs = time.Since(start).Milliseconds()
+			return res
+		case <-time.After(retryInterval):
+		}
+	}
+
+	res.DurationMs = time.Since(start).Milliseconds()
+	return res
+}
+
+// readyPod returns the name of a ready app pod
+func (r *Runner) readyPod(ctx context.Context) (string, error) {
+	pods, err := debug.FindPods(ctx, r.client, r.namespace, r.appName)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range pods {
+		if p.Ready {
+			return p.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no ready pods for %s", r.appName)
+}
+
+// checkHTTP port-forwards to the pod and performs the request
+func (r *Runner) checkHTTP(ctx context.Context, pod string, h *config.HTTPTestConfig) error {
+	remotePort := h.Port
+	if remotePort == 0 {
+		remotePort = r.appPort
+	}
+
+	localPort, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	defer close(stopCh)
+
+	go func() {
+		errCh <- debug.PortForward(ctx, r.client, r.restConfig, r.namespace, pod, debug.PortForwardOptions{
+			LocalPort:  localPort,
+			RemotePort: remotePort,
+			StopCh:     stopCh,
+			ReadyCh:    readyCh,
+			Out:        io.Discard,
+			ErrOut:     io.Discard,
+		})
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return fmt.Errorf("port-forward failed: %w", err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", localPort, normalizePath(h.Path))
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, normalizePath(h.Path), err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	return matchHTTP(h, resp.StatusCode, string(body))
+}
+
+// checkExec runs the command in the pod and matches its output
+func (r *Runner) checkExec(ctx context.Context, pod string, e *config.ExecTestConfig) error {
+	container, err := debug.GetPodContainer(ctx, r.client, r.namespace, pod)
+	if err != nil {
+		return err
+	}
+	stdout, stderr, err := debug.Exec(ctx, r.client, r.restConfig, r.namespace, pod, container, e.Command)
+	if err != nil {
+		msg := strings.TrimSpace(stderr)
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s failed: %s", strings.Join(e.Command, " "), msg)
+	}
+	if e.ExpectOutput != "" && !strings.Contains(stdout, e.ExpectOutput) {
+		return fmt.Errorf("output does not contain %q", e.ExpectOutput)
+	}
+	return nil
+}
+
+// matchHTTP compares a response against the test expectations
+func matchHTTP(h *config.HTTPTestConfig, status int, body string) error {
+	expect := h.ExpectStatus
+	if expect == 0 {
+		expect = http.StatusOK
+	}
+	if status != expect {
+		return fmt.Errorf("expected status %d, got %d", expect, status)
+	}
+	if h.ExpectBody != "" && !strings.Contains(body, h.ExpectBody) {
+		return fmt.Errorf("response body does not contain %q", h.ExpectBody)
+	}
+	return nil
+}
+
+// TestName returns the display name of a test, deriving one when unset
+func TestName(index int, t config.SmokeTestConfig) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	switch {
+	case t.HTTP != nil:
+		method := t.HTTP.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		return fmt.Sprintf("%s %s", method, normalizePath(t.HTTP.Path))
+	case t.Exec != nil:
+		return strings.Join(t.Exec.Command, " ")
+	}
+	return fmt.Sprintf("test-%d", index+1)
+}
+
+func normalizePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
+
+// freePort asks the OS for an unused local port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
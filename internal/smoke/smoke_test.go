@@ -0,0 +1,62 @@
+package smoke
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+)
+
+func TestMatchHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   config.HTTPTestConfig
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"default expects 200", config.HTTPTestConfig{}, 200, "", ""},
+		{"status mismatch", config.HTTPTestConfig{}, 503, "", "expected status 200, got 503"},
+		{"custom status", config.HTTPTestConfig{ExpectStatus: 204}, 204, "", ""},
+		{"body match", config.HTTPTestConfig{ExpectBody: "ok"}, 200, `{"status":"ok"}`, ""},
+		{"body mismatch", config.HTTPTestConfig{ExpectBody: "ok"}, 200, "degraded", "does not contain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := matchHTTP(&tt.check, tt.status, tt.body)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTestName(t *testing.T) {
+	if got := TestName(0, config.SmokeTestConfig{Name: "health"}); got != "health" {
+		t.Errorf("expected explicit name, got %q", got)
+	}
+	if got := TestName(0, config.SmokeTestConfig{HTTP: &config.HTTPTestConfig{Path: "healthz"}}); got != "GET /healthz" {
+		t.Errorf("expected derived http name, got %q", got)
+	}
+	if got := TestName(0, config.SmokeTestConfig{Exec: &config.ExecTestConfig{Command: []string{"cat", "/tmp/ready"}}}); got != "cat /tmp/ready" {
+		t.Errorf("expected derived exec name, got %q", got)
+	}
+	if got := TestName(2, config.SmokeTestConfig{}); got != "test-3" {
+		t.Errorf("expected fallback name, got %q", got)
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	r := &Report{Results: []Result{{Name: "a", Passed: true}, {Name: "b"}}}
+	failed := r.Failed()
+	if len(failed) != 1 || failed[0].Name != "b" {
+		t.Errorf("expected only b to fail, got %+v", failed)
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/format"
 	"github.com/bobbyrathoree/kbox/internal/k8s"
 	"github.com/bobbyrathoree/kbox/internal/tui/components"
 )
@@ -29,18 +31,25 @@ const (
 
 // Model is the main Bubbletea model for the dashboard
 type Model struct {
+	// ctx is the command's context; fetches and log streams stop with it
+	ctx context.Context
+
 	// K8s connection
 	client    *k8s.Client
+	metrics   *debug.MetricsClient
 	appName   string
 	namespace string
 	context   string
 
 	// Data
-	status    *debug.AppStatus
-	logs      []debug.LogLine
-	pods      []debug.PodInfo
-	cpuHist   []float64
-	memHist   []float64
+	status  *debug.AppStatus
+	logs    []debug.LogLine
+	pods    []debug.PodInfo
+	cpuHist []float64
+	memHist []float64
+
+	// metricsUnavailable is set when the cluster has no metrics-server
+	metricsUnavailable bool
 
 	// UI state
 	focused       int
@@ -55,22 +64,31 @@ type Model struct {
 	// Channels for log streaming
 	logChan    chan debug.LogLine
 	cancelLogs context.CancelFunc
+
+	// logFile also receives the streamed logs, if set
+	logFile debug.LineWriter
+	// podSelector chooses the pods whose logs are streamed
+	podSelector debug.PodSelector
 }
 
 // Message types
 type statusMsg *debug.AppStatus
 type podsMsg []debug.PodInfo
+type metricsMsg debug.ResourceUsage
+type metricsUnavailableMsg struct{}
 type logMsg debug.LogLine
 type tickMsg time.Time
 type errMsg error
 
-// NewDashboard creates a new dashboard model
-func NewDashboard(client *k8s.Client, appName, namespace string) Model {
+// NewDashboard creates a new dashboard model. Cluster requests derive from ctx.
+func NewDashboard(ctx context.Context, client *k8s.Client, appName, namespace string) Model {
 	vp := viewport.New(80, 10)
 	vp.SetContent("")
 
 	return Model{
+		ctx:          ctx,
 		client:       client,
+		metrics:      debug.NewMetricsClient(client.Clientset),
 		appName:      appName,
 		namespace:    namespace,
 		context:      client.Context,
@@ -81,10 +99,23 @@ func NewDashboard(client *k8s.Client, appName, namespace string) Model {
 	}
 }
 
+// WithLogFile returns the model with its streamed logs also written to f
+func (m Model) WithLogFile(f debug.LineWriter) Model {
+	m.logFile = f
+	return m
+}
+
+// WithPodSelector returns the model streaming the logs of the pods sel chooses
+func (m Model) WithPodSelector(sel debug.PodSelector) Model {
+	m.podSelector = sel
+	return m
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.fetchStatus(),
+		m.fetchMetrics(),
 		m.startLogStream(),
 		m.tick(),
 	)
@@ -107,20 +138,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case statusMsg:
 		m.status = msg
 		m.lastError = nil
-		// Update metrics history
-		if msg != nil && msg.Deployment != nil {
-			// Simulate CPU/Memory for now (would come from metrics-server)
-			// This is placeholder until we add metrics-server integration
-			if len(m.cpuHist) >= 30 {
-				m.cpuHist = m.cpuHist[1:]
-			}
-			if len(m.memHist) >= 30 {
-				m.memHist = m.memHist[1:]
-			}
-			// Add some variation for visual effect
-			m.cpuHist = append(m.cpuHist, float64(20+len(m.cpuHist)%30))
-			m.memHist = append(m.memHist, float64(40+len(m.memHist)%20))
+		return m, nil
+
+	case metricsMsg:
+		m.metricsUnavailable = false
+		if len(m.cpuHist) >= 30 {
+			m.cpuHist = m.cpuHist[1:]
 		}
+		if len(m.memHist) >= 30 {
+			m.memHist = m.memHist[1:]
+		}
+		m.cpuHist = append(m.cpuHist, float64(msg.CPUMillis))
+		m.memHist = append(m.memHist, float64(msg.MemoryBytes)/(1024*1024))
+		return m, nil
+
+	case metricsUnavailableMsg:
+		m.metricsUnavailable = true
 		return m, nil
 
 	case podsMsg:
@@ -135,6 +168,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		return m, tea.Batch(
 			m.fetchStatus(),
+			m.fetchMetrics(),
 			m.tick(),
 		)
 
@@ -172,7 +206,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "r":
-		return m, m.restartDeployment()
+		return m, m.restartWorkload()
 
 	case "?":
 		m.showHelp = !m.showHelp
@@ -226,7 +260,7 @@ func (m Model) View() string {
 	b.WriteString(m.renderHeader())
 	b.WriteString("\n")
 
-	// Top row: Deployment + Metrics
+	// Top row: workload + Metrics
 	topRow := m.renderTopRow()
 	b.WriteString(topRow)
 	b.WriteString("\n")
@@ -265,15 +299,21 @@ func (m Model) renderHeader() string {
 	return left + strings.Repeat(" ", padding) + right
 }
 
-// renderTopRow renders deployment status and metrics side by side
+// renderTopRow renders workload status and metrics side by side
 func (m Model) renderTopRow() string {
 	halfWidth := (m.width - 3) / 2
 
-	// Deployment panel
+	// Workload panel, titled by kind
+	title := "DEPLOYMENT"
+	if m.status != nil && m.status.Deployment != nil {
+		title = strings.ToUpper(m.status.Deployment.Kind)
+	} else if m.status != nil && len(m.status.CronJobs) > 0 {
+		title = "CRONJOBS"
+	}
 	depContent := m.renderDeploymentContent()
 	depPanel := components.PanelStyle.
 		Width(halfWidth).
-		Render(components.HeaderStyle.Render("DEPLOYMENT") + "\n" + depContent)
+		Render(components.HeaderStyle.Render(title) + "\n" + depContent)
 
 	// Metrics panel
 	metricsContent := m.renderMetricsContent()
@@ -284,11 +324,15 @@ func (m Model) renderTopRow() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, depPanel, " ", metricsPanel)
 }
 
-// renderDeploymentContent renders deployment status
+// renderDeploymentContent renders the Deployment or StatefulSet status, or
+// the app's CronJobs when it only runs on a schedule
 func (m Model) renderDeploymentContent() string {
-	if m.status == nil || m.status.Deployment == nil {
+	if m.status == nil {
 		return components.LabelStyle.Render("Loading...")
 	}
+	if m.status.Deployment == nil {
+		return m.renderCronJobsContent()
+	}
 
 	d := m.status.Deployment
 	var lines []string
@@ -303,14 +347,39 @@ func (m Model) renderDeploymentContent() string {
 	lines = append(lines, components.LabelStyle.Render("Image: ")+image)
 
 	// Age
-	age := formatDuration(time.Since(d.CreatedAt))
+	age := format.Age(d.CreatedAt)
 	lines = append(lines, components.LabelStyle.Render("Age: ")+age)
 
 	return strings.Join(lines, "\n")
 }
 
+// renderCronJobsContent lists the app's CronJobs and when each last ran
+func (m Model) renderCronJobsContent() string {
+	if len(m.status.CronJobs) == 0 {
+		return components.LabelStyle.Render("No Deployment, StatefulSet or CronJob found")
+	}
+
+	var lines []string
+	for _, cj := range m.status.CronJobs {
+		last := "never"
+		if !cj.LastSchedule.IsZero() {
+			last = format.Ago(cj.LastSchedule)
+		}
+		line := fmt.Sprintf("%s %s", cj.Name, components.LabelStyle.Render(cj.Schedule+", last "+last))
+		if cj.Suspended {
+			line += components.LabelStyle.Render(" (suspended)")
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // renderMetricsContent renders CPU/Memory sparklines
 func (m Model) renderMetricsContent() string {
+	if m.metricsUnavailable && len(m.cpuHist) == 0 {
+		return components.LabelStyle.Render("metrics-server not available")
+	}
 	if len(m.cpuHist) == 0 {
 		return components.LabelStyle.Render("Gathering metrics...")
 	}
@@ -320,7 +389,7 @@ func (m Model) renderMetricsContent() string {
 	// CPU sparkline
 	cpuSparkline := components.Sparkline(m.cpuHist, 20)
 	cpuLast := m.cpuHist[len(m.cpuHist)-1]
-	lines = append(lines, fmt.Sprintf("CPU  %s %.0f%%", cpuSparkline, cpuLast))
+	lines = append(lines, fmt.Sprintf("CPU  %s %.0fm", cpuSparkline, cpuLast))
 
 	// Memory sparkline
 	memSparkline := components.Sparkline(m.memHist, 20)
@@ -346,7 +415,7 @@ func (m Model) renderPods() string {
 	} else {
 		for _, pod := range m.status.Pods {
 			icon := components.StatusIcon(pod.Phase, pod.Ready)
-			age := formatDuration(pod.Age)
+			age := format.Age(pod.Created)
 			line := fmt.Sprintf("%s %-30s %-10s %s",
 				icon,
 				components.TruncateWithEllipsis(pod.Name, 30),
@@ -403,7 +472,7 @@ KEYBOARD SHORTCUTS
 
   Actions
   ───────
-  r          Restart deployment
+  r          Restart deployment or statefulset
   l          Toggle fullscreen logs
 
   General
@@ -489,7 +558,7 @@ func (m *Model) updateLogsContent() {
 
 func (m Model) fetchStatus() tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
 		defer cancel()
 
 		status, err := debug.GetAppStatus(ctx, m.client.Clientset, m.namespace, m.appName)
@@ -500,13 +569,36 @@ func (m Model) fetchStatus() tea.Cmd {
 	}
 }
 
+// fetchMetrics sums current usage across the app's pods
+func (m Model) fetchMetrics() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
+		defer cancel()
+
+		usage, err := m.metrics.PodUsage(ctx, m.namespace, "app="+m.appName)
+		if errors.Is(err, debug.ErrMetricsUnavailable) {
+			return metricsUnavailableMsg{}
+		}
+		if err != nil {
+			return errMsg(err)
+		}
+
+		var total debug.ResourceUsage
+		for _, u := range usage {
+			total.CPUMillis += u.CPUMillis
+			total.MemoryBytes += u.MemoryBytes
+		}
+		return metricsMsg(total)
+	}
+}
+
 func (m *Model) startLogStream() tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(m.ctx)
 		m.cancelLogs = cancel
 
 		// Find pods
-		pods, err := debug.FindPods(ctx, m.client.Clientset, m.namespace, m.appName)
+		pods, err := debug.FindPodsWith(ctx, m.client.Clientset, m.namespace, m.appName, m.podSelector)
 		if err != nil {
 			return errMsg(err)
 		}
@@ -525,6 +617,9 @@ func (m *Model) startLogStream() tea.Cmd {
 			writer := &channelWriter{ch: logChan, ctx: ctx}
 			opts := debug.DefaultLogsOptions()
 			opts.TailLines = 50
+			if m.logFile != nil {
+				opts.Files = []debug.LineWriter{m.logFile}
+			}
 			_ = debug.StreamLogs(ctx, m.client.Clientset, m.namespace, pods, opts, writer)
 		}()
 
@@ -538,32 +633,56 @@ func (m Model) tick() tea.Cmd {
 	})
 }
 
-func (m Model) restartDeployment() tea.Cmd {
+// restartWorkload rolls the app's pods the way kubectl rollout restart does,
+// on whichever kind of workload status found
+func (m Model) restartWorkload() tea.Cmd {
+	kind, name := "", m.appName
+	if m.status != nil && m.status.Deployment != nil {
+		kind, name = m.status.Deployment.Kind, m.status.Deployment.Name
+	}
+
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
 		defer cancel()
 
-		// Get deployment
-		dep, err := m.client.Clientset.AppsV1().Deployments(m.namespace).Get(ctx, m.appName, metav1.GetOptions{})
-		if err != nil {
-			return errMsg(fmt.Errorf("failed to get deployment: %w", err))
-		}
-
-		// Add restart annotation
-		if dep.Spec.Template.Annotations == nil {
-			dep.Spec.Template.Annotations = make(map[string]string)
-		}
-		dep.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+		restartedAt := time.Now().Format(time.RFC3339)
+		apps := m.client.Clientset.AppsV1()
 
-		_, err = m.client.Clientset.AppsV1().Deployments(m.namespace).Update(ctx, dep, metav1.UpdateOptions{})
-		if err != nil {
-			return errMsg(fmt.Errorf("failed to restart: %w", err))
+		switch kind {
+		case "Deployment":
+			dep, err := apps.Deployments(m.namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return errMsg(fmt.Errorf("failed to get deployment: %w", err))
+			}
+			setRestartedAt(&dep.Spec.Template.ObjectMeta, restartedAt)
+			if _, err := apps.Deployments(m.namespace).Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
+				return errMsg(fmt.Errorf("failed to restart: %w", err))
+			}
+		case "StatefulSet":
+			sts, err := apps.StatefulSets(m.namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return errMsg(fmt.Errorf("failed to get statefulset: %w", err))
+			}
+			setRestartedAt(&sts.Spec.Template.ObjectMeta, restartedAt)
+			if _, err := apps.StatefulSets(m.namespace).Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+				return errMsg(fmt.Errorf("failed to restart: %w", err))
+			}
+		default:
+			return errMsg(fmt.Errorf("nothing to restart: %s has no Deployment or StatefulSet", m.appName))
 		}
 
 		return nil
 	}
 }
 
+// setRestartedAt stamps the pod template so the controller rolls its pods
+func setRestartedAt(meta *metav1.ObjectMeta, at string) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations["kubectl.kubernetes.io/restartedAt"] = at
+}
+
 // channelWriter adapts io.Writer to send log lines to a channel
 type channelWriter struct {
 	ch  chan<- debug.LogLine
@@ -586,16 +705,3 @@ func (w *channelWriter) Write(p []byte) (n int, err error) {
 		return len(p), nil
 	}
 }
-
-func formatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
-}
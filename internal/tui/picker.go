@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bobbyrathoree/kbox/internal/tui/components"
+)
+
+// ErrPickerCancelled is returned when the user exits the picker without choosing
+var ErrPickerCancelled = errors.New("selection cancelled")
+
+// pickerModel is a minimal filterable list for choosing one item
+type pickerModel struct {
+	title    string
+	items    []string
+	current  string
+	filter   string
+	cursor   int
+	selected string
+	quitting bool
+}
+
+// Pick shows an interactive list and returns the chosen item.
+// Typing filters the list; the current item is marked and preselected.
+func Pick(title string, items []string, current string) (string, error) {
+	m := pickerModel{title: title, items: items, current: current}
+	for i, item := range items {
+		if item == current {
+			m.cursor = i
+		}
+	}
+
+	result, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return "", fmt.Errorf("picker error: %w", err)
+	}
+	final := result.(pickerModel)
+	if final.selected == "" {
+		return "", ErrPickerCancelled
+	}
+	return final.selected, nil
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m pickerModel) visible() []string {
+	if m.filter == "" {
+		return m.items
+	}
+	var out []string
+	for _, item := range m.items {
+		if strings.Contains(strings.ToLower(item), strings.ToLower(m.filter)) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	visible := m.visible()
+	switch key.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyEnter:
+		if len(visible) > 0 {
+			m.selected = visible[m.cursor]
+		}
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyUp, tea.KeyShiftTab:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case tea.KeyDown, tea.KeyTab:
+		if m.cursor < len(visible)-1 {
+			m.cursor++
+		}
+	case tea.KeyBackspace:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+			m.cursor = 0
+		}
+	case tea.KeyRunes:
+		m.filter += string(key.Runes)
+		m.cursor = 0
+	}
+	return m, nil
+}
+
+func (m pickerModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(components.TitleStyle.Render(m.title))
+	if m.filter != "" {
+		b.WriteString(components.LabelStyle.Render("  filter: " + m.filter))
+	}
+	b.WriteString("\n\n")
+
+	visible := m.visible()
+	if len(visible) == 0 {
+		b.WriteString(components.LabelStyle.Render("  no matches"))
+		b.WriteString("\n")
+	}
+	for i, item := range visible {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = components.TitleStyle.Render("> ")
+		}
+		line := item
+		if item == m.current {
+			line += components.LabelStyle.Render(" (current)")
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(components.LabelStyle.Render("↑/↓ move • type to filter • enter select • esc cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
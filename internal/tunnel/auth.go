@@ -0,0 +1,32 @@
+package tunnel
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ParseCredentials splits "user:pass" into its parts
+func ParseCredentials(credentials string) (string, string, error) {
+	user, pass, ok := strings.Cut(credentials, ":")
+	if !ok || user == "" || pass == "" {
+		return "", "", fmt.Errorf("credentials must be in the form user:pass")
+	}
+	return user, pass, nil
+}
+
+// BasicAuth wraps a handler so requests must carry the given credentials
+func BasicAuth(user, pass string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		passOK := subtle.ConstantTimeCompare([]byte(p), []byte(pass)) == 1
+		if !ok || !userOK || !passOK {
+			w.Header().Set("WWW-Authenticate", `Basic realm="kbox share"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
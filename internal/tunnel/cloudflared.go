@@ -0,0 +1,144 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// cloudflaredStartTimeout bounds how long we wait for cloudflared to report a URL
+const cloudflaredStartTimeout = 30 * time.Second
+
+var quickTunnelURL = regexp.MustCompile(`https://[a-zA-Z0-9-]+\.trycloudflare\.com`)
+
+// CloudflaredProvider implements Provider by running the cloudflared binary.
+// Without a tunnel name it creates a Quick Tunnel on trycloudflare.com; with a
+// named tunnel and hostname it routes the hostname to the tunnel and runs it.
+type CloudflaredProvider struct {
+	binary string
+}
+
+// NewCloudflaredProvider creates a new cloudflared provider
+func NewCloudflaredProvider() *CloudflaredProvider {
+	return &CloudflaredProvider{binary: "cloudflared"}
+}
+
+// Name returns the provider name
+func (p *CloudflaredProvider) Name() string {
+	return "cloudflared"
+}
+
+// CreateTunnel starts cloudflared and waits for the public URL
+func (p *CloudflaredProvider) CreateTunnel(ctx context.Context, cfg Config) (Tunnel, error) {
+	path, err := exec.LookPath(p.binary)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflared not found in PATH: %w", err)
+	}
+
+	args, err := cloudflaredArgs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Named tunnels need a DNS route for the hostname; --overwrite-dns makes this idempotent
+	if cfg.TunnelName != "" {
+		route := exec.CommandContext(ctx, path, "tunnel", "route", "dns", "--overwrite-dns", cfg.TunnelName, cfg.Hostname)
+		if out, err := route.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to route %s to tunnel %s: %w: %s", cfg.Hostname, cfg.TunnelName, err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start cloudflared: %w", err)
+	}
+
+	t := &cloudflaredTunnel{cmd: cmd, done: make(chan error, 1)}
+	urlCh := make(chan string, 1)
+	go t.watch(stderr, cfg, urlCh)
+
+	select {
+	case url := <-urlCh:
+		t.url = url
+		return t, nil
+	case err := <-t.done:
+		return nil, fmt.Errorf("cloudflared exited before the tunnel was ready: %v", err)
+	case <-time.After(cloudflaredStartTimeout):
+		t.Close()
+		return nil, fmt.Errorf("timed out waiting for cloudflared to create the tunnel")
+	case <-ctx.Done():
+		t.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// cloudflaredArgs builds the cloudflared command line for a quick or named tunnel
+func cloudflaredArgs(cfg Config) ([]string, error) {
+	localURL := fmt.Sprintf("http://localhost:%d", cfg.LocalPort)
+	if cfg.TunnelName != "" {
+		if cfg.Hostname == "" {
+			return nil, fmt.Errorf("named tunnel %q requires a hostname", cfg.TunnelName)
+		}
+		return []string{"tunnel", "--no-autoupdate", "run", "--url", localURL, cfg.TunnelName}, nil
+	}
+	if cfg.Hostname != "" {
+		return nil, fmt.Errorf("custom hostname %q requires a named cloudflared tunnel", cfg.Hostname)
+	}
+	return []string{"tunnel", "--no-autoupdate", "--url", localURL}, nil
+}
+
+// cloudflaredTunnel implements Tunnel for a cloudflared process
+type cloudflaredTunnel struct {
+	cmd  *exec.Cmd
+	url  string
+	done chan error
+}
+
+// watch scans cloudflared's log output for readiness, then reaps the process
+func (t *cloudflaredTunnel) watch(stderr io.Reader, cfg Config, urlCh chan<- string) {
+	sent := false
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		if sent {
+			continue
+		}
+		line := scanner.Text()
+		if cfg.TunnelName == "" {
+			if url := quickTunnelURL.FindString(line); url != "" {
+				urlCh <- url
+				sent = true
+			}
+		} else if strings.Contains(line, "Registered tunnel connection") {
+			urlCh <- "https://" + cfg.Hostname
+			sent = true
+		}
+	}
+	t.done <- t.cmd.Wait()
+}
+
+// URL returns the public URL
+func (t *cloudflaredTunnel) URL() string {
+	return t.url
+}
+
+// Wait blocks until cloudflared exits
+func (t *cloudflaredTunnel) Wait() error {
+	return <-t.done
+}
+
+// Close stops cloudflared
+func (t *cloudflaredTunnel) Close() error {
+	if t.cmd.Process == nil {
+		return nil
+	}
+	return t.cmd.Process.Kill()
+}
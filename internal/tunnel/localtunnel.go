@@ -0,0 +1,194 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLocaltunnelServer is the public localtunnel server
+const DefaultLocaltunnelServer = "https://localtunnel.me"
+
+// localtunnelMaxFailures is how many consecutive reconnect failures end the tunnel
+const localtunnelMaxFailures = 10
+
+// LocaltunnelProvider implements Provider using the localtunnel protocol.
+// It needs no account or binary: the server assigns a TCP port, and kbox keeps a
+// pool of raw TCP connections to it, each proxied to the local port.
+type LocaltunnelProvider struct {
+	client *http.Client
+}
+
+// NewLocaltunnelProvider creates a new localtunnel provider
+func NewLocaltunnelProvider() *LocaltunnelProvider {
+	return &LocaltunnelProvider{client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Name returns the provider name
+func (p *LocaltunnelProvider) Name() string {
+	return "localtunnel"
+}
+
+// localtunnelLease is the server's response to a tunnel request
+type localtunnelLease struct {
+	ID           string `json:"id"`
+	Port         int    `json:"port"`
+	MaxConnCount int    `json:"max_conn_count"`
+	URL          string `json:"url"`
+	Message      string `json:"message"`
+}
+
+// CreateTunnel requests a tunnel from the server and starts the connection pool
+func (p *LocaltunnelProvider) CreateTunnel(ctx context.Context, cfg Config) (Tunnel, error) {
+	server := cfg.Server
+	if server == "" {
+		server = DefaultLocaltunnelServer
+	}
+	base, err := url.Parse(server)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid localtunnel server %q", server)
+	}
+
+	lease, err := p.requestLease(ctx, server, cfg.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	tctx, cancel := context.WithCancel(ctx)
+	t := &localTunnel{
+		url:       lease.URL,
+		remote:    net.JoinHostPort(base.Hostname(), strconv.Itoa(lease.Port)),
+		localPort: cfg.LocalPort,
+		cancel:    cancel,
+		done:      make(chan error, 1),
+	}
+
+	conns := lease.MaxConnCount
+	if conns <= 0 {
+		conns = 1
+	}
+	for i := 0; i < conns; i++ {
+		go t.worker(tctx)
+	}
+	return t, nil
+}
+
+// requestLease asks the server for a new tunnel, optionally with a specific subdomain
+func (p *LocaltunnelProvider) requestLease(ctx context.Context, server, subdomain string) (*localtunnelLease, error) {
+	endpoint := strings.TrimSuffix(server, "/") + "/?new"
+	if subdomain != "" {
+		endpoint = strings.TrimSuffix(server, "/") + "/" + url.PathEscape(subdomain)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach localtunnel server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var lease localtunnelLease
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		return nil, fmt.Errorf("invalid response from localtunnel server (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || lease.Port == 0 {
+		msg := lease.Message
+		if msg == "" {
+			msg = resp.Status
+		}
+		return nil, fmt.Errorf("localtunnel server refused the tunnel: %s", msg)
+	}
+	return &lease, nil
+}
+
+// localTunnel implements Tunnel for localtunnel
+type localTunnel struct {
+	url       string
+	remote    string
+	localPort int
+	cancel    context.CancelFunc
+	done      chan error
+	once      sync.Once
+}
+
+// URL returns the public URL
+func (t *localTunnel) URL() string {
+	return t.url
+}
+
+// Wait blocks until the tunnel is closed or can no longer reach the server
+func (t *localTunnel) Wait() error {
+	return <-t.done
+}
+
+// Close shuts down the connection pool
+func (t *localTunnel) Close() error {
+	t.finish(nil)
+	return nil
+}
+
+func (t *localTunnel) finish(err error) {
+	t.once.Do(func() {
+		t.cancel()
+		t.done <- err
+	})
+}
+
+// worker keeps one connection to the server open, proxying each to the local port.
+// The server hands a connection one request stream; when it closes, a new one is dialed.
+func (t *localTunnel) worker(ctx context.Context) {
+	failures := 0
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	for ctx.Err() == nil {
+		remote, err := dialer.DialContext(ctx, "tcp", t.remote)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			if failures >= localtunnelMaxFailures {
+				t.finish(fmt.Errorf("lost connection to localtunnel server: %w", err))
+				return
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		failures = 0
+
+		local, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", t.localPort))
+		if err != nil {
+			remote.Close()
+			time.Sleep(time.Second)
+			continue
+		}
+
+		// Close both ends on shutdown so the copy goroutines return
+		stop := context.AfterFunc(ctx, func() {
+			remote.Close()
+			local.Close()
+		})
+		done := make(chan struct{}, 2)
+		go func() {
+			copyData(local, remote)
+			done <- struct{}{}
+		}()
+		go func() {
+			copyData(remote, local)
+			done <- struct{}{}
+		}()
+		<-done
+		stop()
+		remote.Close()
+		local.Close()
+	}
+}
@@ -0,0 +1,180 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Proxy is a local HTTP server placed between a tunnel and the app's port-forward.
+// It hosts optional middleware (basic auth, request inspection, path routing), so
+// these features work the same for every provider.
+type Proxy struct {
+	listener net.Listener
+	server   *http.Server
+}
+
+// NewProxy starts serving handler on a free local port
+func NewProxy(handler http.Handler) (*Proxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start local proxy: %w", err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	return &Proxy{listener: listener, server: server}, nil
+}
+
+// Port returns the local port the proxy listens on
+func (p *Proxy) Port() int {
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the proxy
+func (p *Proxy) Close() error {
+	return p.server.Close()
+}
+
+// ReverseProxy forwards requests to localhost:port
+func ReverseProxy(port int) http.Handler {
+	target := &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", port)}
+	return httputil.NewSingleHostReverseProxy(target)
+}
+
+// PathRouter routes /<name>/... to the matching port with the prefix stripped.
+// Requests that match no route get a 404 listing the available paths.
+func PathRouter(routes map[string]int) http.Handler {
+	names := make([]string, 0, len(routes))
+	handlers := make(map[string]http.Handler, len(routes))
+	for name, port := range routes {
+		names = append(names, name)
+		handlers[name] = http.StripPrefix("/"+name, ReverseProxy(port))
+	}
+	sort.Strings(names)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segment := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if h, ok := handlers[segment]; ok {
+			if r.URL.Path == "/"+segment {
+				// Keep relative links working by serving the service root at /<name>/
+				http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, "kbox share: no service at this path. Available:")
+		for _, name := range names {
+			fmt.Fprintf(w, "  /%s/\n", name)
+		}
+	})
+}
+
+// Exchange is the metadata of one proxied request
+type Exchange struct {
+	Time     time.Time     `json:"time"`
+	Label    string        `json:"label,omitempty"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Bytes    int64         `json:"bytes"`
+}
+
+// Inspector records request/response metadata and prints it as it happens
+type Inspector struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewInspector creates an inspector that prints exchanges to out
+func NewInspector(out io.Writer) *Inspector {
+	return &Inspector{out: out}
+}
+
+// Wrap returns a handler that records every exchange, tagged with label
+func (i *Inspector) Wrap(label string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		i.print(Exchange{
+			Time:     start,
+			Label:    label,
+			Method:   r.Method,
+			Path:     r.URL.RequestURI(),
+			Status:   rec.status,
+			Duration: time.Since(start),
+			Bytes:    rec.bytes,
+		})
+	})
+}
+
+func (i *Inspector) print(e Exchange) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	fmt.Fprintln(i.out, FormatExchange(e))
+}
+
+// FormatExchange renders an exchange as a single colored terminal line
+func FormatExchange(e Exchange) string {
+	color := "\033[32m" // 2xx green
+	switch {
+	case e.Status >= 500:
+		color = "\033[31m"
+	case e.Status >= 400:
+		color = "\033[33m"
+	case e.Status >= 300:
+		color = "\033[36m"
+	}
+	label := ""
+	if e.Label != "" {
+		label = fmt.Sprintf("[%s] ", e.Label)
+	}
+	return fmt.Sprintf("  %s %s%-7s %s %s%d\033[0m %s %s",
+		e.Time.Format("15:04:05"), label, e.Method, e.Path, color, e.Status,
+		e.Duration.Round(time.Millisecond), formatBytes(e.Bytes))
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// statusRecorder captures the status code and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush supports streaming responses through the recorder
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
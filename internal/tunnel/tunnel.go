@@ -2,6 +2,7 @@ package tunnel
 
 import (
 	"context"
+	"fmt"
 )
 
 // Tunnel represents an active tunnel connection
@@ -27,6 +28,15 @@ type Config struct {
 	// Region for the tunnel (optional)
 	Region string
 
+	// Hostname is a custom hostname (cloudflared) or requested subdomain (localtunnel)
+	Hostname string
+
+	// TunnelName is the cloudflared named tunnel to run
+	TunnelName string
+
+	// Server overrides the localtunnel server (default: https://localtunnel.me)
+	Server string
+
 	// Metadata for the tunnel
 	AppName   string
 	Namespace string
@@ -41,6 +51,22 @@ type Provider interface {
 	Name() string
 }
 
+// Providers lists the supported provider names
+var Providers = []string{"ngrok", "cloudflared", "localtunnel"}
+
+// NewProvider returns the provider with the given name (empty means ngrok)
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case "", "ngrok":
+		return NewNgrokProvider(), nil
+	case "cloudflared":
+		return NewCloudflaredProvider(), nil
+	case "localtunnel":
+		return NewLocaltunnelProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown tunnel provider %q (must be ngrok, cloudflared, or localtunnel)", name)
+}
+
 // Result represents the outcome of a share operation
 type Result struct {
 	Success   bool   `json:"success"`
@@ -0,0 +1,193 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	handler := BasicAuth("demo", "s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		wantStatus int
+	}{
+		{"no credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong password", "demo", "nope", true, http.StatusUnauthorized},
+		{"valid", "demo", "s3cret", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.setAuth {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	user, pass, err := ParseCredentials("demo:a:b")
+	if err != nil || user != "demo" || pass != "a:b" {
+		t.Errorf("unexpected result: %q %q %v", user, pass, err)
+	}
+	for _, bad := range []string{"demo", ":pass", "demo:"} {
+		if _, _, err := ParseCredentials(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCloudflaredArgs(t *testing.T) {
+	args, err := cloudflaredArgs(Config{LocalPort: 8080})
+	if err != nil || strings.Join(args, " ") != "tunnel --no-autoupdate --url http://localhost:8080" {
+		t.Errorf("unexpected quick tunnel args %v (%v)", args, err)
+	}
+
+	args, err = cloudflaredArgs(Config{LocalPort: 8080, TunnelName: "demo", Hostname: "demo.example.com"})
+	if err != nil || args[len(args)-1] != "demo" {
+		t.Errorf("unexpected named tunnel args %v (%v)", args, err)
+	}
+
+	if _, err := cloudflaredArgs(Config{LocalPort: 8080, Hostname: "demo.example.com"}); err == nil {
+		t.Error("expected error for hostname without a named tunnel")
+	}
+	if _, err := cloudflaredArgs(Config{LocalPort: 8080, TunnelName: "demo"}); err == nil {
+		t.Error("expected error for named tunnel without a hostname")
+	}
+
+	if got := quickTunnelURL.FindString("INF |  https://calm-river-1234.trycloudflare.com  |"); got != "https://calm-river-1234.trycloudflare.com" {
+		t.Errorf("unexpected quick tunnel URL %q", got)
+	}
+}
+
+func TestLocaltunnel(t *testing.T) {
+	// Local app the tunnel forwards to
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello from app"))
+	}))
+	defer app.Close()
+	appPort := app.Listener.Addr().(*net.TCPAddr).Port
+
+	// Fake localtunnel server: the lease endpoint plus the TCP port clients connect to
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	lt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/myapp" {
+			t.Errorf("expected subdomain request, got %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(localtunnelLease{
+			ID:           "myapp",
+			Port:         tcp.Addr().(*net.TCPAddr).Port,
+			MaxConnCount: 1,
+			URL:          "https://myapp.loca.lt",
+		})
+	}))
+	defer lt.Close()
+
+	tun, err := NewLocaltunnelProvider().CreateTunnel(context.Background(), Config{
+		LocalPort: appPort,
+		Hostname:  "myapp",
+		Server:    lt.URL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create tunnel: %v", err)
+	}
+	defer tun.Close()
+	if tun.URL() != "https://myapp.loca.lt" {
+		t.Errorf("unexpected URL %q", tun.URL())
+	}
+
+	// The server relays a public request over the client's connection
+	conn, err := tcp.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: myapp.loca.lt\r\nConnection: close\r\n\r\n")
+	body, _ := io.ReadAll(conn)
+	if !strings.Contains(string(body), "hello from app") {
+		t.Errorf("expected app response through tunnel, got %q", body)
+	}
+}
+
+func TestPathRouter(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api " + r.URL.Path))
+	}))
+	defer api.Close()
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("web " + r.URL.Path))
+	}))
+	defer web.Close()
+
+	router := PathRouter(map[string]int{
+		"api": api.Listener.Addr().(*net.TCPAddr).Port,
+		"web": web.Listener.Addr().(*net.TCPAddr).Port,
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/api/users", http.StatusOK, "api /users"},
+		{"/web/", http.StatusOK, "web /"},
+		{"/api", http.StatusMovedPermanently, ""},
+		{"/other", http.StatusNotFound, "/api/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestInspector(t *testing.T) {
+	var out strings.Builder
+	handler := NewInspector(&out).Wrap("api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hooks?id=1", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected status to pass through, got %d", rec.Code)
+	}
+
+	line := out.String()
+	for _, want := range []string{"[api]", "POST", "/hooks?id=1", "201", "7B"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+}
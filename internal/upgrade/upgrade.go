@@ -0,0 +1,249 @@
+// Package upgrade finds, verifies, and installs kbox releases from GitHub,
+// and compares kbox versions.
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository kbox releases are published to
+const Repo = "bobbyrathoree/kbox"
+
+const (
+	// ChecksumsAsset lists the sha256 of each release binary, as sha256sum prints them
+	ChecksumsAsset = "checksums.txt"
+
+	// SignatureAsset is the base64 ed25519 signature of ChecksumsAsset
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// Release is a published kbox release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Client talks to the GitHub releases API
+type Client struct {
+	// BaseURL is the API root (default: https://api.github.com)
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for api.github.com
+func NewClient() *Client {
+	return &Client{BaseURL: "https://api.github.com", HTTP: &http.Client{Timeout: 60 * time.Second}}
+}
+
+// GetRelease returns the release tagged tag, or the latest one when tag is empty
+func (c *Client) GetRelease(ctx context.Context, tag string) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", c.BaseURL, Repo)
+	if tag != "" {
+		url = fmt.Sprintf("%s/repos/%s/releases/tags/%s", c.BaseURL, Repo, tag)
+	}
+	data, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// Download fetches a release asset
+func (c *Client) Download(ctx context.Context, release *Release, name string) ([]byte, error) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return c.get(ctx, asset.URL)
+		}
+	}
+	return nil, fmt.Errorf("release %s has no %s", release.Tag, name)
+}
+
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// AssetName is the release binary for a platform, e.g. kbox_linux_arm64
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("kbox_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Fetch downloads the binary for this platform from the release and
+// verifies it against the release's checksums, and the checksums against
+// their signature when publicKey (base64 ed25519) is set. An empty key
+// skips the signature; callers decide whether that is acceptable
+func (c *Client) Fetch(ctx context.Context, release *Release, publicKey string) ([]byte, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	checksums, err := c.Download(ctx, release, ChecksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	if publicKey != "" {
+		signature, err := c.Download(ctx, release, SignatureAsset)
+		if err != nil {
+			return nil, err
+		}
+		if err := VerifySignature(checksums, signature, publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	binary, err := c.Download(ctx, release, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyChecksum(binary, name, checksums); err != nil {
+		return nil, err
+	}
+	return binary, nil
+}
+
+// VerifyChecksum checks data against name's sha256 in a checksums file
+func VerifyChecksum(data []byte, name string, checksums []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, fields[0]) {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, fields[0], got)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no checksum for %s", ChecksumsAsset, name)
+}
+
+// VerifySignature checks a base64 ed25519 signature of data
+func VerifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", SignatureAsset, err)
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return fmt.Errorf("%s signature does not match the release key", ChecksumsAsset)
+	}
+	return nil
+}
+
+// Install replaces the executable at path with binary. The new file is
+// written next to it and renamed over it, so a failure leaves the old one
+// in place.
+func Install(path string, binary []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".kbox-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", dir, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	// Windows can't replace a running executable, but can rename it
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Version is a parsed major.minor.patch version
+type Version [3]int
+
+// ParseVersion parses versions such as "v1.2.3", "1.2", or "1.2.3-rc.1"
+// (pre-release and build suffixes are ignored)
+func ParseVersion(s string) (Version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return Version{}, false
+	}
+	var v Version
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// Less reports whether v is older than other
+func (v Version) Less(other Version) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v[0], v[1], v[2])
+}
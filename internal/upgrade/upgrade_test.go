@@ -0,0 +1,173 @@
+package upgrade
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		ok   bool
+	}{
+		{"v1.2.3", Version{1, 2, 3}, true},
+		{"1.4", Version{1, 4, 0}, true},
+		{"v2", Version{2, 0, 0}, true},
+		{"v1.5.0-rc.1", Version{1, 5, 0}, true},
+		{"dev", Version{}, false},
+		{"", Version{}, false},
+		{"1.2.3.4", Version{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if !(Version{1, 2, 3}).Less(Version{1, 10, 0}) {
+		t.Error("expected v1.2.3 < v1.10.0")
+	}
+	if (Version{1, 2, 3}).Less(Version{1, 2, 3}) {
+		t.Error("expected v1.2.3 not < v1.2.3")
+	}
+}
+
+// fakeRelease serves a release with the binary for this platform
+func fakeRelease(t *testing.T, binary []byte, checksum string, signature string) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	if checksum == "" {
+		sum := sha256.Sum256(binary)
+		checksum = hex.EncodeToString(sum[:])
+	}
+	checksums := fmt.Sprintf("%s  %s\n0000  kbox_plan9_386\n", checksum, name)
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/repos/"+Repo+"/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		assets := []Asset{
+			{Name: name, URL: srv.URL + "/dl/bin"},
+			{Name: ChecksumsAsset, URL: srv.URL + "/dl/checksums"},
+		}
+		if signature != "" {
+			assets = append(assets, Asset{Name: SignatureAsset, URL: srv.URL + "/dl/sig"})
+		}
+		json.NewEncoder(w).Encode(Release{Tag: "v1.5.0", Assets: assets})
+	})
+	mux.HandleFunc("/dl/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/dl/checksums", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(checksums)) })
+	mux.HandleFunc("/dl/sig", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(signature)) })
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	binary := []byte("new kbox")
+	srv := fakeRelease(t, binary, "", "")
+	client := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+
+	release, err := client.GetRelease(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Tag != "v1.5.0" {
+		t.Errorf("expected v1.5.0, got %s", release.Tag)
+	}
+	got, err := client.Fetch(context.Background(), release, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new kbox" {
+		t.Errorf("unexpected binary %q", got)
+	}
+}
+
+func TestFetch_ChecksumMismatch(t *testing.T) {
+	srv := fakeRelease(t, []byte("tampered"), strings.Repeat("ab", 32), "")
+	client := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+
+	release, err := client.GetRelease(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Fetch(context.Background(), release, ""); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestFetch_Signature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(pub)
+	binary := []byte("new kbox")
+	sum := sha256.Sum256(binary)
+	checksums := fmt.Sprintf("%s  %s\n0000  kbox_plan9_386\n", hex.EncodeToString(sum[:]), AssetName(runtime.GOOS, runtime.GOARCH))
+
+	t.Run("valid", func(t *testing.T) {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(checksums)))
+		srv := fakeRelease(t, binary, "", sig)
+		client := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+		release, _ := client.GetRelease(context.Background(), "")
+		if _, err := client.Fetch(context.Background(), release, publicKey); err != nil {
+			t.Errorf("expected a valid signature, got %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte("other checksums")))
+		srv := fakeRelease(t, binary, "", sig)
+		client := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+		release, _ := client.GetRelease(context.Background(), "")
+		if _, err := client.Fetch(context.Background(), release, publicKey); err == nil || !strings.Contains(err.Error(), "signature") {
+			t.Errorf("expected a signature error, got %v", err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		srv := fakeRelease(t, binary, "", "")
+		client := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+		release, _ := client.GetRelease(context.Background(), "")
+		if _, err := client.Fetch(context.Background(), release, publicKey); err == nil {
+			t.Error("expected an error for an unsigned release")
+		}
+	})
+}
+
+func TestInstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kbox")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("expected the new binary, got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected the binary to be executable, got %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 && runtime.GOOS != "windows" {
+		t.Errorf("expected no leftover files, got %d entries", len(entries))
+	}
+}
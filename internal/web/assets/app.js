@@ -0,0 +1,142 @@
+// kbox web dashboard: polls the JSON API and streams logs over a websocket.
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+
+function esc(s) {
+  return String(s ?? "").replace(/[&<>"']/g, (c) => ({
+    "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;",
+  })[c]);
+}
+
+function ago(ts) {
+  const secs = Math.max(0, Math.round((Date.now() - new Date(ts)) / 1000));
+  if (secs < 60) return secs + "s ago";
+  if (secs < 3600) return Math.round(secs / 60) + "m ago";
+  if (secs < 86400) return Math.round(secs / 3600) + "h ago";
+  return Math.round(secs / 86400) + "d ago";
+}
+
+// source describes where a release came from, e.g. " · main@1a2b3c4 by alice"
+function source(c) {
+  let s = "";
+  if (c.git_sha) s += " · " + (c.git_branch ? esc(c.git_branch) + "@" : "") + esc(c.git_sha.slice(0, 7));
+  if (c.deployed_by) s += " by " + esc(c.deployed_by);
+  return s;
+}
+
+async function getJSON(path) {
+  const res = await fetch(path);
+  const body = await res.json();
+  if (!res.ok) throw new Error(body.error || res.statusText);
+  return body;
+}
+
+function showError(id, err) {
+  $(id).innerHTML = `<tr><td colspan="6" class="bad">${esc(err.message)}</td></tr>`;
+}
+
+async function loadStatus() {
+  try {
+    const s = await getJSON("api/status");
+    const d = s.Deployment;
+    if (d) {
+      const cls = d.ReadyReplicas === d.Replicas ? "ok" : "warn";
+      $("deployment-body").innerHTML =
+        `<div><span class="${cls}">${d.ReadyReplicas}/${d.Replicas} ready</span>, ${d.UpdatedReplicas} updated, ${d.AvailableReplicas} available</div>` +
+        `<div class="muted">${esc(d.Name)} · ${esc(d.Strategy)} · ${esc(d.Image)}</div>` +
+        (d.Change && d.Change.revision ? `<div class="muted">v${d.Change.revision}${source(d.Change)}</div>` : "");
+    } else {
+      $("deployment-body").textContent = "No deployment found";
+    }
+    $("pods-body").innerHTML = (s.Pods || []).map((p) => {
+      const cls = p.Ready ? "ok" : (p.Phase === "Running" ? "warn" : "bad");
+      const containers = (p.Containers || [])
+        .map((c) => `${esc(c.Name)}: ${esc(c.State)}${c.Reason ? " (" + esc(c.Reason) + ")" : ""}`)
+        .join("<br>");
+      return `<tr><td>${esc(p.Name)}</td><td class="${cls}">${esc(p.Phase)}</td><td>${p.Ready ? "yes" : "no"}</td>` +
+        `<td class="${p.Restarts > 0 ? "warn" : ""}">${p.Restarts}</td><td>${esc(p.Node)}</td><td>${containers}</td></tr>`;
+    }).join("") || `<tr><td colspan="6" class="muted">No pods</td></tr>`;
+    $("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+  } catch (err) {
+    $("deployment-body").innerHTML = `<span class="bad">${esc(err.message)}</span>`;
+  }
+}
+
+async function loadEvents() {
+  try {
+    const events = await getJSON("api/events");
+    $("events-body").innerHTML = events.slice().reverse().map((e) =>
+      `<tr><td>${ago(e.LastSeen)}</td><td class="${e.Type === "Warning" ? "warn" : ""}">${esc(e.Type)}</td>` +
+      `<td>${esc(e.Reason)}${e.Count > 1 ? " ×" + e.Count : ""}</td><td>${esc(e.Kind)}/${esc(e.Object)}</td><td>${esc(e.Message)}</td></tr>`
+    ).join("") || `<tr><td colspan="5" class="muted">No recent events</td></tr>`;
+  } catch (err) {
+    showError("events-body", err);
+  }
+}
+
+async function loadReleases() {
+  try {
+    const releases = await getJSON("api/releases");
+    $("releases-body").innerHTML = releases.slice().reverse().map((r) =>
+      `<tr><td>v${r.revision}</td><td>${ago(r.timestamp)}</td><td>${esc(r.image)}${source(r)}</td></tr>`
+    ).join("") || `<tr><td colspan="3" class="muted">No releases</td></tr>`;
+  } catch (err) {
+    showError("releases-body", err);
+  }
+}
+
+async function loadTopology() {
+  try {
+    const t = await getJSON("api/topology");
+    if (!t) return;
+    const name = (id) => (t.Nodes[id] ? `${t.Nodes[id].Type}/${t.Nodes[id].Name}` : id);
+    const edges = (t.Edges || []).map((e) =>
+      `<div>${esc(name(e.From))} <span class="muted">—${esc(e.EdgeType)}${e.Label ? " " + esc(e.Label) : ""}→</span> ${esc(name(e.To))}</div>`
+    );
+    $("topology-body").innerHTML = edges.join("") || Object.keys(t.Nodes).map((id) => `<div>${esc(name(id))}</div>`).join("");
+    $("topology-body").classList.remove("muted");
+  } catch (err) {
+    $("topology-body").innerHTML = `<span class="bad">${esc(err.message)}</span>`;
+  }
+}
+
+const maxLogLines = 2000;
+
+function streamLogs() {
+  const proto = location.protocol === "https:" ? "wss:" : "ws:";
+  const ws = new WebSocket(`${proto}//${location.host}${location.pathname.replace(/[^/]*$/, "")}api/logs`);
+  const pre = $("logs-body");
+  ws.onopen = () => { $("logs-state").textContent = "streaming"; };
+  ws.onmessage = (msg) => {
+    const atBottom = pre.scrollTop + pre.clientHeight >= pre.scrollHeight - 4;
+    const line = document.createElement("div");
+    line.textContent = msg.data;
+    if (msg.data.startsWith("[k8s/event")) line.className = "event-line";
+    pre.appendChild(line);
+    while (pre.childElementCount > maxLogLines) pre.firstChild.remove();
+    if (atBottom) pre.scrollTop = pre.scrollHeight;
+  };
+  ws.onclose = () => {
+    $("logs-state").textContent = "disconnected, retrying…";
+    setTimeout(streamLogs, 3000);
+  };
+}
+
+async function init() {
+  const info = await getJSON("api/app");
+  $("app").textContent = info.app;
+  $("namespace").textContent = "namespace: " + info.namespace;
+  document.title = `kbox · ${info.app}`;
+
+  loadStatus();
+  loadEvents();
+  loadReleases();
+  loadTopology();
+  streamLogs();
+  setInterval(loadStatus, 2000);
+  setInterval(loadEvents, 5000);
+  setInterval(loadReleases, 30000);
+}
+
+init();
@@ -0,0 +1,196 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/graph"
+	"github.com/bobbyrathoree/kbox/internal/release"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Source provides the data shown by the dashboard
+type Source interface {
+	Status(ctx context.Context) (*debug.AppStatus, error)
+	Events(ctx context.Context) ([]debug.EventInfo, error)
+	Releases(ctx context.Context) ([]release.Release, error)
+	// StreamLogs writes log lines (with events interleaved) to w until ctx is done
+	StreamLogs(ctx context.Context, w io.Writer) error
+}
+
+// ClusterSource reads an app's state from the cluster
+type ClusterSource struct {
+	Client    kubernetes.Interface
+	Namespace string
+	App       string
+}
+
+// Status implements Source
+func (c *ClusterSource) Status(ctx context.Context) (*debug.AppStatus, error) {
+	return debug.GetAppStatus(ctx, c.Client, c.Namespace, c.App)
+}
+
+// Events implements Source
+func (c *ClusterSource) Events(ctx context.Context) ([]debug.EventInfo, error) {
+	return debug.ListAppEvents(ctx, c.Client, c.Namespace, c.App, debug.EventsOptions{Since: time.Hour})
+}
+
+// Releases implements Source
+func (c *ClusterSource) Releases(ctx context.Context) ([]release.Release, error) {
+	return release.NewStore(c.Client, c.Namespace, c.App).List(ctx)
+}
+
+// StreamLogs implements Source
+func (c *ClusterSource) StreamLogs(ctx context.Context, w io.Writer) error {
+	pods, err := debug.FindPods(ctx, c.Client, c.Namespace, c.App)
+	if err != nil {
+		return err
+	}
+	return debug.StreamLogs(ctx, c.Client, c.Namespace, pods, debug.DefaultLogsOptions(), w)
+}
+
+// releaseSummary is a release without its config, which may hold env values
+type releaseSummary struct {
+	Revision   int       `json:"revision"`
+	Timestamp  time.Time `json:"timestamp"`
+	Image      string    `json:"image"`
+	GitSHA     string    `json:"git_sha,omitempty"`
+	GitBranch  string    `json:"git_branch,omitempty"`
+	DeployedBy string    `json:"deployed_by,omitempty"`
+}
+
+// Dashboard is a read-only web UI with the same data as the terminal dashboard
+type Dashboard struct {
+	app       string
+	namespace string
+	source    Source
+	topology  *graph.Topology // nil without a kbox.yaml
+	mux       *http.ServeMux
+}
+
+// New creates a dashboard for app. topology may be nil.
+func New(app, namespace string, source Source, topology *graph.Topology) *Dashboard {
+	d := &Dashboard{app: app, namespace: namespace, source: source, topology: topology, mux: http.NewServeMux()}
+
+	static, _ := fs.Sub(assets, "assets")
+	d.mux.Handle("GET /", http.FileServerFS(static))
+	d.mux.HandleFunc("GET /api/app", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"app": d.app, "namespace": d.namespace}, nil)
+	})
+	d.mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		status, err := d.source.Status(r.Context())
+		writeJSON(w, status, err)
+	})
+	d.mux.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {
+		events, err := d.source.Events(r.Context())
+		if events == nil {
+			events = []debug.EventInfo{}
+		}
+		writeJSON(w, events, err)
+	})
+	d.mux.HandleFunc("GET /api/releases", func(w http.ResponseWriter, r *http.Request) {
+		releases, err := d.source.Releases(r.Context())
+		summaries := make([]releaseSummary, 0, len(releases))
+		for _, rel := range releases {
+			summaries = append(summaries, releaseSummary{
+				Revision:   rel.Revision,
+				Timestamp:  rel.Timestamp,
+				Image:      rel.Image,
+				GitSHA:     rel.GitSHA,
+				GitBranch:  rel.GitBranch,
+				DeployedBy: rel.DeployedBy,
+			})
+		}
+		writeJSON(w, summaries, err)
+	})
+	d.mux.HandleFunc("GET /api/topology", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.topology, nil)
+	})
+	d.mux.HandleFunc("GET /api/logs", d.serveLogs)
+	return d
+}
+
+// ServeHTTP implements http.Handler
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mux.ServeHTTP(w, r)
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// serveLogs streams log lines over a websocket, one message per line
+func (d *Dashboard) serveLogs(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already replied
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// The browser never sends anything; a read error means it went away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := d.source.StreamLogs(ctx, &lineWriter{conn: conn}); err != nil && ctx.Err() == nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("kbox: "+err.Error()))
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// ansiPattern matches the color codes kbox logs use in a terminal
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// lineWriter sends each complete line written to it as a websocket message,
+// without terminal colors
+type lineWriter struct {
+	conn *websocket.Conn
+	buf  []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := l.conn.WriteMessage(websocket.TextMessage, ansiPattern.ReplaceAll(l.buf[:i], nil)); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/bobbyrathoree/kbox/internal/debug"
+	"github.com/bobbyrathoree/kbox/internal/release"
+)
+
+// fakeSource returns canned dashboard data
+type fakeSource struct {
+	statusErr error
+	releases  []release.Release
+	logs      []string
+}
+
+func (f *fakeSource) Status(ctx context.Context) (*debug.AppStatus, error) {
+	if f.statusErr != nil {
+		return nil, f.statusErr
+	}
+	return &debug.AppStatus{Name: "myapp", Namespace: "default"}, nil
+}
+
+func (f *fakeSource) Events(ctx context.Context) ([]debug.EventInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeSource) Releases(ctx context.Context) ([]release.Release, error) {
+	return f.releases, nil
+}
+
+func (f *fakeSource) StreamLogs(ctx context.Context, w io.Writer) error {
+	for _, line := range f.logs {
+		fmt.Fprint(w, line)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func get(t *testing.T, d *Dashboard, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestDashboardServesAssets(t *testing.T) {
+	d := New("myapp", "default", &fakeSource{}, nil)
+
+	rec := get(t, d, "/")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "app.js") {
+		t.Errorf("expected index.html, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get(t, d, "/api/app")
+	if strings.TrimSpace(rec.Body.String()) != `{"app":"myapp","namespace":"default"}` {
+		t.Errorf("unexpected app info: %s", rec.Body.String())
+	}
+
+	rec = get(t, d, "/api/topology")
+	if strings.TrimSpace(rec.Body.String()) != "null" {
+		t.Errorf("expected null topology without a kbox.yaml, got %s", rec.Body.String())
+	}
+}
+
+func TestDashboardReleasesOmitConfig(t *testing.T) {
+	source := &fakeSource{releases: []release.Release{{
+		Revision: 3,
+		Image:    "myapp:v3",
+		Config:   "spec:\n  env:\n    API_KEY: hunter2\n",
+	}}}
+	d := New("myapp", "default", source, nil)
+
+	rec := get(t, d, "/api/releases")
+	if strings.Contains(rec.Body.String(), "hunter2") {
+		t.Errorf("release config leaked: %s", rec.Body.String())
+	}
+	var releases []releaseSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &releases); err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 1 || releases[0].Revision != 3 || releases[0].Image != "myapp:v3" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestDashboardStatusError(t *testing.T) {
+	d := New("myapp", "default", &fakeSource{statusErr: errors.New("connection refused")}, nil)
+
+	rec := get(t, d, "/api/status")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("expected the error in the body, got %s", rec.Body.String())
+	}
+}
+
+func TestDashboardStreamsLogs(t *testing.T) {
+	source := &fakeSource{logs: []string{"\x1b[36m[web-1]\x1b[0m started\n[web-1] listen", "ing on :8080\n"}}
+	srv := httptest.NewServer(New("myapp", "default", source, nil))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/logs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for _, want := range []string{"[web-1] started", "[web-1] listening on :8080"} {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != want {
+			t.Errorf("expected %q, got %q", want, msg)
+		}
+	}
+}
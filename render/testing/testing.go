@@ -0,0 +1,163 @@
+// Package testing provides fixtures and golden-file assertions for
+// snapshot-testing rendered manifests, like net/http/httptest does for
+// handlers. It's importable from outside kbox, so a repository can snapshot
+// what its own kbox.yaml renders to, and dependency templates and renderer
+// changes get a test that fails with a reviewable diff:
+//
+//	import rendertesting "github.com/bobbyrathoree/kbox/render/testing"
+//
+//	func TestManifests(t *testing.T) {
+//		cfg := rendertesting.Load(t, "kbox.yaml", "prod")
+//		rendertesting.AssertGolden(t, "testdata/prod.golden", rendertesting.Render(t, cfg))
+//	}
+//
+// Run the tests with UPDATE_GOLDEN=1 to write the golden files, then
+// review and commit them. Secrets are redacted, so generated dependency
+// passwords don't make snapshots flaky.
+package testing
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/config"
+	"github.com/bobbyrathoree/kbox/internal/golden"
+	"github.com/bobbyrathoree/kbox/internal/render"
+)
+
+// UpdateEnv is the environment variable that makes AssertGolden write
+// golden files instead of comparing against them
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Updating reports whether golden files are being rewritten: UpdateEnv is
+// set to anything but "", "0" or "false"
+func Updating() bool {
+	switch os.Getenv(UpdateEnv) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
+// Option changes a fixture config
+type Option func(*config.AppConfig)
+
+// App returns a minimal valid app config: image <name>:v1 on port 8080,
+// one replica, in the default namespace, with defaults applied
+func App(name string, opts ...Option) *config.AppConfig {
+	cfg := &config.AppConfig{
+		APIVersion: config.DefaultAPIVersion,
+		Kind:       config.DefaultKind,
+		Metadata:   config.Metadata{Name: name, Namespace: "default"},
+		Spec: config.AppSpec{
+			Image:    name + ":v1",
+			Port:     8080,
+			Replicas: 1,
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.WithDefaults()
+	return cfg
+}
+
+// Dependency adds a dependency of the given type (postgres, redis, ...)
+func Dependency(typ string) Option {
+	return func(cfg *config.AppConfig) {
+		cfg.Spec.Dependencies = append(cfg.Spec.Dependencies, config.DependencyConfig{Type: typ})
+	}
+}
+
+// Env sets an env variable
+func Env(key, value string) Option {
+	return func(cfg *config.AppConfig) {
+		if cfg.Spec.Env == nil {
+			cfg.Spec.Env = make(map[string]string)
+		}
+		cfg.Spec.Env[key] = value
+	}
+}
+
+// Replicas sets the replica count
+func Replicas(n int) Option {
+	return func(cfg *config.AppConfig) {
+		cfg.Spec.Replicas = n
+	}
+}
+
+// Ingress enables an Ingress for host
+func Ingress(host string) Option {
+	return func(cfg *config.AppConfig) {
+		cfg.Spec.Ingress = &config.IngressConfig{Enabled: true, Host: host}
+	}
+}
+
+// Load reads a kbox.yaml fixture, such as testdata/kbox.yaml, merged
+// with environment env unless env is ""
+func Load(t testing.TB, path, env string) *config.AppConfig {
+	t.Helper()
+	cfg, err := config.NewLoader(".").LoadFile(path)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", path, err)
+	}
+	if env != "" {
+		cfg = cfg.ForEnvironment(env)
+	}
+	return cfg
+}
+
+// Render validates and renders cfg, failing the test on error
+func Render(t testing.TB, cfg *config.AppConfig) *render.Bundle {
+	t.Helper()
+	if err := config.Validate(cfg); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	bundle, err := render.New(cfg).Render()
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	return bundle
+}
+
+// YAML returns the bundle's manifests with secrets redacted, in the same
+// form kbox render --redact prints. The bundle is redacted in place.
+func YAML(t testing.TB, bundle *render.Bundle) string {
+	t.Helper()
+	bundle.Redact()
+	var buf bytes.Buffer
+	if err := bundle.ToYAML(&buf); err != nil {
+		t.Fatalf("failed to write YAML: %v", err)
+	}
+	return buf.String()
+}
+
+// AssertGolden compares the bundle's redacted YAML with the golden file at
+// path, or writes the file when UpdateEnv is set
+func AssertGolden(t testing.TB, path string, bundle *render.Bundle) {
+	t.Helper()
+	AssertGoldenString(t, path, YAML(t, bundle))
+}
+
+// AssertGoldenString compares got with the golden file at path, or writes
+// the file when UpdateEnv is set
+func AssertGoldenString(t testing.TB, path, got string) {
+	t.Helper()
+	if Updating() {
+		if err := golden.Write(path, got); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return
+	}
+	want, ok, err := golden.Read(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if !ok {
+		t.Fatalf("golden file %s doesn't exist; run the test with %s=1 to create it", path, UpdateEnv)
+	}
+	if diff := golden.Diff(want, got); diff != "" {
+		t.Errorf("output differs from %s (run with %s=1 to accept it):\n%s", path, UpdateEnv, diff)
+	}
+}
@@ -0,0 +1,84 @@
+package testing
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobbyrathoree/kbox/internal/dependencies"
+)
+
+// TestDependencyTemplates snapshots every dependency template; run with
+// UPDATE_GOLDEN=1 after changing one
+func TestDependencyTemplates(t *testing.T) {
+	for _, typ := range dependencies.SupportedTypes() {
+		t.Run(typ, func(t *testing.T) {
+			bundle := Render(t, App("myapp", Dependency(typ)))
+			AssertGolden(t, filepath.Join("testdata", "dependency-"+typ+".golden"), bundle)
+		})
+	}
+}
+
+func TestYAML_RedactsGeneratedPasswords(t *testing.T) {
+	first := YAML(t, Render(t, App("myapp", Dependency("postgres"))))
+	second := YAML(t, Render(t, App("myapp", Dependency("postgres"))))
+	if first != second {
+		t.Error("expected the same YAML for two renders with generated passwords")
+	}
+	if !strings.Contains(first, base64.StdEncoding.EncodeToString([]byte("[REDACTED]"))) {
+		t.Error("expected the dependency secret to be redacted")
+	}
+}
+
+// recorder is a testing.TB that records failures instead of failing
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertGoldenString(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.golden")
+
+	rec := &recorder{TB: t}
+	AssertGoldenString(rec, path, "replicas: 2\n")
+	if len(rec.failures) == 0 || !strings.Contains(rec.failures[0], UpdateEnv+"=1") {
+		t.Errorf("expected a missing golden file to point at %s, got %v", UpdateEnv, rec.failures)
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	AssertGoldenString(t, path, "replicas: 2\n")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "replicas: 2\n" {
+		t.Fatalf("expected the golden file to be written, got %q, %v", data, err)
+	}
+
+	t.Setenv(UpdateEnv, "")
+	AssertGoldenString(t, path, "replicas: 2\n")
+
+	rec = &recorder{TB: t}
+	AssertGoldenString(rec, path, "replicas: 3\n")
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], "+ replicas: 3") {
+		t.Errorf("expected a diff, got %v", rec.failures)
+	}
+}
+
+func TestUpdating(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true} {
+		t.Setenv(UpdateEnv, value)
+		if got := Updating(); got != want {
+			t.Errorf("%s=%q: expected %v, got %v", UpdateEnv, value, want, got)
+		}
+	}
+}